@@ -18,6 +18,7 @@ import (
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/cipher/bip32"
 	"github.com/skycoin/skycoin/src/cipher/bip39"
+	"github.com/skycoin/skycoin/src/cipher/shamir"
 	"github.com/skycoin/skycoin/src/cipher/testsuite"
 	"github.com/skycoin/skycoin/src/util/file"
 )
@@ -27,6 +28,7 @@ const (
 	manyAddressesFilename   = "many-addresses.golden"
 	seedFilenameFormat      = "seed-%04d.golden"
 	bip32SeedFilenameFormat = "seed-bip32-%04d.golden"
+	shamirFilenameFormat    = "shamir-%04d.golden"
 	randomSeedLength        = 1024
 )
 
@@ -54,7 +56,12 @@ public keys and addresses generated from this seed.
 The number of secret keys generated is much larger than for the other seeds.
 This file is used to test deterministic key generation more thoroughly.
 This file will not contain any signatures,
-because the filesize would be too large.`, inputTestDataFilename, manyAddressesFilename)
+because the filesize would be too large.
+
+Multiple files named shamir-{num}.golden will be generated.
+Each of these files contains a secret and the mnemonic shares
+it was split into with Shamir's secret sharing, for a range of
+thresholds, share counts and secret lengths.`, inputTestDataFilename, manyAddressesFilename)
 
 type job struct {
 	jobID        int
@@ -122,6 +129,10 @@ func main() {
 
 	writeSeedTestDataFiles(*outputDir, inputs, jobs)
 	writeBip32SeedTestDataFiles(*outputDir, inputs, jobs)
+
+	fmt.Println("Generating shamir share data")
+
+	writeShamirTestDataFiles(*outputDir)
 }
 
 func createJobs(seedsCount, addressCount int) []job {
@@ -235,6 +246,45 @@ func writeBip32SeedTestDataFiles(outputDir string, inputs *testsuite.InputTestDa
 	<-writeDone
 }
 
+func writeShamirTestDataFiles(outputDir string) {
+	// threshold, share count and secret length of each generated file
+	params := []struct {
+		threshold int
+		count     int
+		secretLen int
+	}{
+		{1, 1, shamir.MinSecretLength},
+		{1, 3, 32},
+		{2, 3, shamir.MinSecretLength},
+		{2, 3, 17},
+		{3, 5, 32},
+		{3, 5, 33},
+		{5, 5, 64},
+		{7, shamir.MaxShareCount, shamir.MaxSecretLength},
+	}
+
+	for i, p := range params {
+		secret := cipher.RandByte(p.secretLen)
+		shares, err := shamir.SplitMnemonics(secret, p.threshold, p.count)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		data := &testsuite.ShamirTestData{
+			Secret:    secret,
+			Threshold: p.threshold,
+			Shares:    shares,
+		}
+
+		filename := filepath.Join(outputDir, fmt.Sprintf(shamirFilenameFormat, i))
+		if err := file.SaveJSON(filename, data.ToJSON(), 0644); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+}
+
 func generateInputTestData(inputsCount int) *testsuite.InputTestData {
 	var hashes []cipher.SHA256
 
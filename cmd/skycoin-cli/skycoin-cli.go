@@ -16,6 +16,7 @@ import (
 	_ "github.com/skycoin/skycoin/src/wallet/bip44wallet"
 	_ "github.com/skycoin/skycoin/src/wallet/collection"
 	_ "github.com/skycoin/skycoin/src/wallet/deterministic"
+	_ "github.com/skycoin/skycoin/src/wallet/watchwallet"
 	_ "github.com/skycoin/skycoin/src/wallet/xpubwallet"
 )
 
@@ -14,6 +14,8 @@ import (
 	"github.com/skycoin/skycoin/src/readable"
 	wh "github.com/skycoin/skycoin/src/util/http"
 	"github.com/skycoin/skycoin/src/visor"
+	"github.com/skycoin/skycoin/src/visor/blockdb"
+	"github.com/skycoin/skycoin/src/visor/historydb"
 )
 
 // blockchainMetadataHandler returns the blockchain metadata
@@ -72,7 +74,17 @@ func blockchainProgressHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
-		wh.SendJSONOr500(logger, w, readable.NewBlockchainProgress(progress))
+		historyIndex, err := gateway.GetHistoryIndexStatus()
+		if err != nil {
+			err = fmt.Errorf("gateway.GetHistoryIndexStatus failed: %v", err)
+			wh.Error500(w, err.Error())
+			return
+		}
+
+		bp := readable.NewBlockchainProgress(progress)
+		bp.HistoryIndex = readable.NewHistoryIndexStatus(*historyIndex)
+
+		wh.SendJSONOr500(logger, w, bp)
 	}
 }
 
@@ -148,7 +160,14 @@ func blockHandler(gateway Gatewayer) http.HandlerFunc {
 			}
 
 			if err != nil {
-				wh.Error500(w, err.Error())
+				switch err.(type) {
+				case blockdb.ErrBlockPruned:
+					wh.Error410(w, err.Error())
+				case historydb.ErrHistoryIndexing:
+					wh.Error503(w, err.Error())
+				default:
+					wh.Error500(w, err.Error())
+				}
 				return
 			}
 
@@ -176,7 +195,12 @@ func blockHandler(gateway Gatewayer) http.HandlerFunc {
 		}
 
 		if err != nil {
-			wh.Error500(w, err.Error())
+			switch err.(type) {
+			case blockdb.ErrBlockPruned:
+				wh.Error410(w, err.Error())
+			default:
+				wh.Error500(w, err.Error())
+			}
 			return
 		}
 
@@ -291,6 +315,10 @@ func blocksHandler(gateway Gatewayer) http.HandlerFunc {
 				switch err.(type) {
 				case visor.ErrBlockNotExist:
 					wh.Error404(w, err.Error())
+				case blockdb.ErrBlockPruned:
+					wh.Error410(w, err.Error())
+				case historydb.ErrHistoryIndexing:
+					wh.Error503(w, err.Error())
 				default:
 					wh.Error500(w, err.Error())
 				}
@@ -318,6 +346,8 @@ func blocksHandler(gateway Gatewayer) http.HandlerFunc {
 				switch err.(type) {
 				case visor.ErrBlockNotExist:
 					wh.Error404(w, err.Error())
+				case blockdb.ErrBlockPruned:
+					wh.Error410(w, err.Error())
 				default:
 					wh.Error500(w, err.Error())
 				}
@@ -370,7 +400,14 @@ func lastBlocksHandler(gateway Gatewayer) http.HandlerFunc {
 		if verbose {
 			blocks, inputs, err := gateway.GetLastBlocksVerbose(n)
 			if err != nil {
-				wh.Error500(w, err.Error())
+				switch err.(type) {
+				case blockdb.ErrBlockPruned:
+					wh.Error410(w, err.Error())
+				case historydb.ErrHistoryIndexing:
+					wh.Error503(w, err.Error())
+				default:
+					wh.Error500(w, err.Error())
+				}
 				return
 			}
 
@@ -386,7 +423,12 @@ func lastBlocksHandler(gateway Gatewayer) http.HandlerFunc {
 
 		blocks, err := gateway.GetLastBlocks(n)
 		if err != nil {
-			wh.Error500(w, err.Error())
+			switch err.(type) {
+			case blockdb.ErrBlockPruned:
+				wh.Error410(w, err.Error())
+			default:
+				wh.Error500(w, err.Error())
+			}
 			return
 		}
 
@@ -22,6 +22,7 @@ import (
 	"github.com/skycoin/skycoin/src/readable"
 	"github.com/skycoin/skycoin/src/testutil"
 	"github.com/skycoin/skycoin/src/visor"
+	"github.com/skycoin/skycoin/src/visor/blockdb"
 )
 
 func TestGetBlockchainMetadata(t *testing.T) {
@@ -122,6 +123,8 @@ func TestGetBlockchainProgress(t *testing.T) {
 		headBkSeq                   uint64
 		headBkSeqErr                error
 		getBlockchainProgressResult *daemon.BlockchainProgress
+		historyIndexStatus          *visor.HistoryIndexStatus
+		historyIndexStatusErr       error
 		result                      readable.BlockchainProgress
 	}{
 		{
@@ -146,6 +149,16 @@ func TestGetBlockchainProgress(t *testing.T) {
 			err:    "500 Internal Server Error - gateway.GetBlockchainProgress progress is nil",
 		},
 
+		{
+			name:                        "500 - GetHistoryIndexStatus error",
+			method:                      http.MethodGet,
+			status:                      http.StatusInternalServerError,
+			err:                         "500 Internal Server Error - gateway.GetHistoryIndexStatus failed: GetHistoryIndexStatus error",
+			headBkSeq:                   99,
+			getBlockchainProgressResult: &daemon.BlockchainProgress{},
+			historyIndexStatusErr:       errors.New("GetHistoryIndexStatus error"),
+		},
+
 		{
 			name:      "200",
 			method:    http.MethodGet,
@@ -165,6 +178,11 @@ func TestGetBlockchainProgress(t *testing.T) {
 				Current: 99,
 				Highest: 102,
 			},
+			historyIndexStatus: &visor.HistoryIndexStatus{
+				Indexing:     true,
+				ParsedBlocks: 40,
+				TotalBlocks:  100,
+			},
 			result: readable.BlockchainProgress{
 				Peers: []readable.PeerBlockchainHeight{
 					{
@@ -178,6 +196,11 @@ func TestGetBlockchainProgress(t *testing.T) {
 				},
 				Current: 99,
 				Highest: 102,
+				HistoryIndex: readable.HistoryIndexStatus{
+					Indexing:     true,
+					ParsedBlocks: 40,
+					TotalBlocks:  100,
+				},
 			},
 		},
 	}
@@ -187,6 +210,7 @@ func TestGetBlockchainProgress(t *testing.T) {
 			gateway := &MockGatewayer{}
 			gateway.On("HeadBkSeq").Return(tc.headBkSeq, true, tc.headBkSeqErr)
 			gateway.On("GetBlockchainProgress", tc.headBkSeq).Return(tc.getBlockchainProgressResult)
+			gateway.On("GetHistoryIndexStatus").Return(tc.historyIndexStatus, tc.historyIndexStatusErr)
 
 			endpoint := "/api/v1/blockchain/progress"
 			req, err := http.NewRequest(tc.method, endpoint, nil)
@@ -371,6 +395,15 @@ func TestGetBlock(t *testing.T) {
 			seq:                     1,
 			gatewayGetBlockBySeqErr: errors.New("GetSignedBlockBySeq failed"),
 		},
+		{
+			name:                    "410 - get block by seq pruned",
+			method:                  http.MethodGet,
+			status:                  http.StatusGone,
+			err:                     "410 Gone - The body of block seq=1 has been pruned",
+			seqStr:                  "1",
+			seq:                     1,
+			gatewayGetBlockBySeqErr: blockdb.NewErrBlockPruned(1),
+		},
 		{
 			name:                       "200 - get block by seq",
 			method:                     http.MethodGet,
@@ -756,6 +789,18 @@ func TestGetBlocks(t *testing.T) {
 			gatewayGetBlocksVerboseError: visor.NewErrBlockNotExist(4),
 		},
 
+		{
+			name:   "410 - block seq pruned",
+			method: http.MethodGet,
+			status: http.StatusGone,
+			err:    "410 Gone - The body of block seq=2 has been pruned",
+			body: &httpBody{
+				Seqs: "1,2,4",
+			},
+			seqs:                  []uint64{1, 2, 4},
+			gatewayGetBlocksError: blockdb.NewErrBlockPruned(2),
+		},
+
 		{
 			name:   "500 - gatewayGetBlocksInRangeError",
 			method: http.MethodGet,
@@ -1097,6 +1142,17 @@ func TestGetLastBlocks(t *testing.T) {
 			num:                       1,
 			gatewayGetLastBlocksError: errors.New("gatewayGetLastBlocksError"),
 		},
+		{
+			name:   "410 - gatewayGetLastBlocksError pruned",
+			method: http.MethodGet,
+			status: http.StatusGone,
+			err:    "410 Gone - The body of block seq=1 has been pruned",
+			body: httpBody{
+				Num: "1",
+			},
+			num:                       1,
+			gatewayGetLastBlocksError: blockdb.NewErrBlockPruned(1),
+		},
 		{
 			name:   "500 - gatewayGetLastBlocksVerboseError",
 			method: http.MethodGet,
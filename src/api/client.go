@@ -616,9 +616,11 @@ func (c *Client) AddressUxOuts(addr string) ([]readable.SpentOutput, error) {
 }
 
 // Wallet makes a request to GET /api/v1/wallet
-func (c *Client) Wallet(id string) (*WalletResponse, error) {
+// The entries of a bip44 wallet are those of the account set with wallet.OptionAccount
+func (c *Client) Wallet(id string, options ...wallet.Option) (*WalletResponse, error) {
 	v := url.Values{}
 	v.Add("id", id)
+	addAccountValue(v, options...)
 	endpoint := "/api/v1/wallet?" + v.Encode()
 
 	var wr WalletResponse
@@ -651,6 +653,10 @@ type CreateWalletOptions struct {
 	Encrypt               bool
 	Bip44Coin             *bip44.CoinType
 	CollectionPrivateKeys string
+	SeedShares            []string
+	GapLimit              uint64
+	WatchAddresses        []string
+	WatchXPubs            []string
 }
 
 // CreateWallet makes a request to POST /api/v1/wallet/create and creates a wallet.
@@ -686,6 +692,22 @@ func (c *Client) CreateWallet(o CreateWalletOptions) (*WalletResponse, error) {
 		v.Add("private-keys", o.CollectionPrivateKeys)
 	}
 
+	if o.GapLimit > 0 {
+		v.Add("gap-limit", fmt.Sprint(o.GapLimit))
+	}
+
+	if len(o.SeedShares) > 0 {
+		v.Add("seed-shares", strings.Join(o.SeedShares, ","))
+	}
+
+	if len(o.WatchAddresses) > 0 {
+		v.Add("addresses", strings.Join(o.WatchAddresses, ","))
+	}
+
+	if len(o.WatchXPubs) > 0 {
+		v.Add("xpubs", strings.Join(o.WatchXPubs, ","))
+	}
+
 	var w WalletResponse
 	if err := c.PostForm("/api/v1/wallet/create", strings.NewReader(v.Encode()), &w); err != nil {
 		return nil, err
@@ -743,6 +765,8 @@ func (c *Client) NewWalletAddress(id string, password string, options ...wallet.
 		v.Add("num", fmt.Sprint(opts.GenerateN))
 	}
 
+	addAccountValue(v, options...)
+
 	if len(opts.PrivateKeys) > 0 {
 		keys := make([]string, 0, len(opts.PrivateKeys))
 		for _, k := range opts.PrivateKeys {
@@ -780,9 +804,11 @@ func (c *Client) ScanWalletAddresses(id string, n int, password string) ([]strin
 }
 
 // WalletBalance makes a request to GET /api/v1/wallet/balance
-func (c *Client) WalletBalance(id string) (*BalanceResponse, error) {
+// The balance of a bip44 wallet is that of the account set with wallet.OptionAccount
+func (c *Client) WalletBalance(id string, options ...wallet.Option) (*BalanceResponse, error) {
 	v := url.Values{}
 	v.Add("id", id)
+	addAccountValue(v, options...)
 	endpoint := "/api/v1/wallet/balance?" + v.Encode()
 
 	var b BalanceResponse
@@ -818,9 +844,11 @@ type Receiver struct {
 
 // WalletCreateTransactionRequest is sent to /api/v1/wallet/transaction
 type WalletCreateTransactionRequest struct {
-	Unsigned bool   `json:"unsigned"`
-	WalletID string `json:"wallet_id"`
-	Password string `json:"password"`
+	Unsigned     bool   `json:"unsigned"`
+	WalletID     string `json:"wallet_id"`
+	Password     string `json:"password"`
+	SessionToken string `json:"session_token,omitempty"`
+	Account      uint32 `json:"account,omitempty"`
 	CreateTransactionRequest
 }
 
@@ -943,6 +971,154 @@ func (c *Client) WalletSeed(id string, password string) (*WalletSeedResponse, er
 	return &r, nil
 }
 
+// WalletUnlock makes a request to POST /api/v2/wallet/unlock.
+// The wallet stays unlocked for timeout minutes.
+func (c *Client) WalletUnlock(id, password string, timeout int) (*WalletSessionResponse, error) {
+	var r WalletSessionResponse
+	ok, err := c.PostJSONV2("/api/v2/wallet/unlock", WalletUnlockRequest{
+		ID:       id,
+		Password: password,
+		Timeout:  timeout,
+	}, &r)
+	if ok {
+		return &r, err
+	}
+
+	return nil, err
+}
+
+// WalletLock makes a request to POST /api/v2/wallet/lock
+func (c *Client) WalletLock(id string) error {
+	_, err := c.PostJSONV2("/api/v2/wallet/lock", WalletLockRequest{
+		ID: id,
+	}, nil)
+	return err
+}
+
+// WalletAccounts makes a request to GET /api/v2/wallet/accounts
+func (c *Client) WalletAccounts(id string) ([]WalletAccountResponse, error) {
+	v := url.Values{}
+	v.Add("id", id)
+	endpoint := "/api/v2/wallet/accounts?" + v.Encode()
+
+	var r []WalletAccountResponse
+	ok, err := c.GetV2(endpoint, &r)
+	if ok {
+		return r, err
+	}
+
+	return nil, err
+}
+
+// WalletCreateAccount makes a request to POST /api/v2/wallet/accounts/create
+func (c *Client) WalletCreateAccount(id, name, password string) (*WalletAccountResponse, error) {
+	var r WalletAccountResponse
+	ok, err := c.PostJSONV2("/api/v2/wallet/accounts/create", WalletAccountCreateRequest{
+		ID:       id,
+		Name:     name,
+		Password: password,
+	}, &r)
+	if ok {
+		return &r, err
+	}
+
+	return nil, err
+}
+
+// WalletRenameAccount makes a request to POST /api/v2/wallet/accounts/rename
+func (c *Client) WalletRenameAccount(id string, account uint32, name string) error {
+	_, err := c.PostJSONV2("/api/v2/wallet/accounts/rename", WalletAccountRenameRequest{
+		ID:      id,
+		Account: account,
+		Name:    name,
+	}, nil)
+	return err
+}
+
+// WalletDiscoveryStatus makes a request to GET /api/v2/wallet/discovery
+func (c *Client) WalletDiscoveryStatus(id string) (*WalletDiscoveryResponse, error) {
+	v := url.Values{}
+	v.Add("id", id)
+	endpoint := "/api/v2/wallet/discovery?" + v.Encode()
+
+	var r WalletDiscoveryResponse
+	ok, err := c.GetV2(endpoint, &r)
+	if ok {
+		return &r, err
+	}
+
+	return nil, err
+}
+
+// WalletDiscover makes a request to POST /api/v2/wallet/discovery.
+// If gapLimit is 0, the wallet's gap limit is not changed.
+func (c *Client) WalletDiscover(id string, gapLimit uint64) (*WalletDiscoverResponse, error) {
+	var r WalletDiscoverResponse
+	ok, err := c.PostJSONV2("/api/v2/wallet/discovery", WalletDiscoverRequest{
+		ID:       id,
+		GapLimit: gapLimit,
+	}, &r)
+	if ok {
+		return &r, err
+	}
+
+	return nil, err
+}
+
+// WalletWatch makes a request to POST /api/v2/wallet/watch
+func (c *Client) WalletWatch(id string, addrs, xpubs []string) (*WalletWatchResponse, error) {
+	var r WalletWatchResponse
+	ok, err := c.PostJSONV2("/api/v2/wallet/watch", WalletWatchRequest{
+		ID:        id,
+		Addresses: addrs,
+		XPubs:     xpubs,
+	}, &r)
+	if ok {
+		return &r, err
+	}
+
+	return nil, err
+}
+
+// addAccountValue adds the bip44 account selected with wallet.OptionAccount to the url values
+func addAccountValue(v url.Values, options ...wallet.Option) {
+	var opts wallet.Bip44EntriesOptions
+	for _, f := range options {
+		f(&opts)
+	}
+
+	if opts.Account > 0 {
+		v.Add("account", fmt.Sprint(opts.Account))
+	}
+}
+
+// WalletSeedSplit makes a request to POST /api/v2/wallet/seed/split
+func (c *Client) WalletSeedSplit(id, password string, threshold, shares int) (*WalletSeedSharesResponse, error) {
+	var r WalletSeedSharesResponse
+	ok, err := c.PostJSONV2("/api/v2/wallet/seed/split", WalletSeedSplitRequest{
+		ID:        id,
+		Password:  password,
+		Threshold: threshold,
+		Shares:    shares,
+	}, &r)
+	if ok {
+		return &r, err
+	}
+
+	return nil, err
+}
+
+// WalletSeedCombine makes a request to POST /api/v2/wallet/seed/combine
+func (c *Client) WalletSeedCombine(shares []string) (string, error) {
+	var r WalletSeedResponse
+	if _, err := c.PostJSONV2("/api/v2/wallet/seed/combine", WalletSeedCombineRequest{
+		Shares: shares,
+	}, &r); err != nil {
+		return "", err
+	}
+	return r.Seed, nil
+}
+
 // NetworkConnection makes a request to GET /api/v1/network/connection
 func (c *Client) NetworkConnection(addr string) (*readable.Connection, error) {
 	v := url.Values{}
@@ -1368,6 +1544,49 @@ func (c *Client) Disconnect(id uint64) error {
 	return c.PostForm("/api/v1/network/connection/disconnect", strings.NewReader(v.Encode()), &obj)
 }
 
+// NetworkBans makes a request to GET /api/v1/network/bans
+func (c *Client) NetworkBans() (*BansResponse, error) {
+	var br BansResponse
+	if err := c.Get("/api/v1/network/bans", &br); err != nil {
+		return nil, err
+	}
+	return &br, nil
+}
+
+// ClearBans makes a request to POST /api/v1/network/bans/clear.
+// If no IPs are given, all bans are removed.
+func (c *Client) ClearBans(ips []string) error {
+	v := url.Values{}
+	if len(ips) != 0 {
+		v.Add("ips", strings.Join(ips, ","))
+	}
+
+	var obj struct{}
+	return c.PostForm("/api/v1/network/bans/clear", strings.NewReader(v.Encode()), &obj)
+}
+
+// BackupDB makes a request to POST /api/v1/db/backup.
+// path is an absolute path on the node's host
+func (c *Client) BackupDB(path string) (*DBBackupResponse, error) {
+	v := url.Values{}
+	v.Add("path", path)
+
+	var br DBBackupResponse
+	if err := c.PostForm("/api/v1/db/backup", strings.NewReader(v.Encode()), &br); err != nil {
+		return nil, err
+	}
+	return &br, nil
+}
+
+// CompactDB makes a request to POST /api/v1/db/compact
+func (c *Client) CompactDB() (*DBCompactResponse, error) {
+	var cr DBCompactResponse
+	if err := c.PostForm("/api/v1/db/compact", strings.NewReader(""), &cr); err != nil {
+		return nil, err
+	}
+	return &cr, nil
+}
+
 // GetAllStorageValues makes a GET request to /api/v2/data to get all the values from the storage of
 // `storageType` type
 func (c *Client) GetAllStorageValues(storageType kvstorage.Type) (map[string]string, error) {
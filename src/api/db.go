@@ -0,0 +1,92 @@
+package api
+
+import (
+	"net/http"
+	"path/filepath"
+
+	wh "github.com/skycoin/skycoin/src/util/http"
+	"github.com/skycoin/skycoin/src/visor"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+// DBBackupResponse is returned by POST /api/v1/db/backup
+type DBBackupResponse struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// dbBackupHandler writes a consistent copy of the database to a new file while the node is running
+// URI: /api/v1/db/backup
+// Method: POST
+// Args:
+//	path: Absolute path of the backup file on the node's host, in the node's data directory. The file must not exist [required]
+func dbBackupHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			wh.Error405(w)
+			return
+		}
+
+		path := r.FormValue("path")
+		if path == "" {
+			wh.Error400(w, "path is required")
+			return
+		}
+
+		if !filepath.IsAbs(path) {
+			wh.Error400(w, "path must be absolute")
+			return
+		}
+
+		size, err := gateway.BackupDB(path)
+		if err != nil {
+			switch err {
+			case dbutil.ErrBackupFileExists, visor.ErrBackupOutsideDataDirectory:
+				wh.Error400(w, err.Error())
+			default:
+				wh.Error500(w, err.Error())
+			}
+			return
+		}
+
+		wh.SendJSONOr500(logger, w, DBBackupResponse{
+			Path: path,
+			Size: size,
+		})
+	}
+}
+
+// DBCompactResponse is returned by POST /api/v1/db/compact
+type DBCompactResponse struct {
+	SizeBefore int64 `json:"size_before"`
+	SizeAfter  int64 `json:"size_after"`
+}
+
+// dbCompactHandler rewrites the database file without its free pages.
+// The node pauses until the compacted file replaces the database file
+// URI: /api/v1/db/compact
+// Method: POST
+func dbCompactHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			wh.Error405(w)
+			return
+		}
+
+		before, after, err := gateway.CompactDB()
+		if err != nil {
+			switch err {
+			case dbutil.ErrCompactUnsupported, dbutil.ErrDatabaseReadOnly:
+				wh.Error403(w, err.Error())
+			default:
+				wh.Error500(w, err.Error())
+			}
+			return
+		}
+
+		wh.SendJSONOr500(logger, w, DBCompactResponse{
+			SizeBefore: before,
+			SizeAfter:  after,
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/visor"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+func TestDBBackup(t *testing.T) {
+	tt := []struct {
+		name        string
+		method      string
+		status      int
+		err         string
+		path        string
+		backupDBErr error
+		size        int64
+	}{
+		{
+			name:   "405",
+			method: http.MethodGet,
+			status: http.StatusMethodNotAllowed,
+			err:    "405 Method Not Allowed",
+		},
+
+		{
+			name:   "400 missing path",
+			method: http.MethodPost,
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - path is required",
+		},
+
+		{
+			name:   "400 relative path",
+			method: http.MethodPost,
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - path must be absolute",
+			path:   "backup.db",
+		},
+
+		{
+			name:        "400 file exists",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			err:         "400 Bad Request - Backup file already exists",
+			path:        "/tmp/backup.db",
+			backupDBErr: dbutil.ErrBackupFileExists,
+		},
+
+		{
+			name:        "400 outside data directory",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			err:         "400 Bad Request - Backup file must be in the data directory",
+			path:        "/etc/backup.db",
+			backupDBErr: visor.ErrBackupOutsideDataDirectory,
+		},
+
+		{
+			name:        "500 BackupDB error",
+			method:      http.MethodPost,
+			status:      http.StatusInternalServerError,
+			err:         "500 Internal Server Error - foo",
+			path:        "/tmp/backup.db",
+			backupDBErr: errors.New("foo"),
+		},
+
+		{
+			name:   "200",
+			method: http.MethodPost,
+			status: http.StatusOK,
+			path:   "/tmp/backup.db",
+			size:   1024 * 1024,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			gateway.On("BackupDB", tc.path).Return(tc.size, tc.backupDBErr)
+
+			endpoint := "/api/v1/db/backup"
+			v := url.Values{}
+			if tc.path != "" {
+				v.Add("path", tc.path)
+			}
+
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(v.Encode()))
+			require.NoError(t, err)
+			req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			if status != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+				return
+			}
+
+			var resp DBBackupResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &resp)
+			require.NoError(t, err)
+			require.Equal(t, DBBackupResponse{
+				Path: tc.path,
+				Size: tc.size,
+			}, resp)
+		})
+	}
+}
+
+func TestDBCompact(t *testing.T) {
+	tt := []struct {
+		name         string
+		method       string
+		status       int
+		err          string
+		compactDBErr error
+		before       int64
+		after        int64
+	}{
+		{
+			name:   "405",
+			method: http.MethodGet,
+			status: http.StatusMethodNotAllowed,
+			err:    "405 Method Not Allowed",
+		},
+
+		{
+			name:         "403 unsupported backend",
+			method:       http.MethodPost,
+			status:       http.StatusForbidden,
+			err:          "403 Forbidden - Only a bolt database file can be compacted",
+			compactDBErr: dbutil.ErrCompactUnsupported,
+		},
+
+		{
+			name:         "403 read-only",
+			method:       http.MethodPost,
+			status:       http.StatusForbidden,
+			err:          "403 Forbidden - database is in read-only mode",
+			compactDBErr: dbutil.ErrDatabaseReadOnly,
+		},
+
+		{
+			name:         "500 CompactDB error",
+			method:       http.MethodPost,
+			status:       http.StatusInternalServerError,
+			err:          "500 Internal Server Error - foo",
+			compactDBErr: errors.New("foo"),
+		},
+
+		{
+			name:   "200",
+			method: http.MethodPost,
+			status: http.StatusOK,
+			before: 1024 * 1024 * 64,
+			after:  1024 * 1024 * 16,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			gateway.On("CompactDB").Return(tc.before, tc.after, tc.compactDBErr)
+
+			endpoint := "/api/v1/db/compact"
+			req, err := http.NewRequest(tc.method, endpoint, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			if status != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+				return
+			}
+
+			var resp DBCompactResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &resp)
+			require.NoError(t, err)
+			require.Equal(t, DBCompactResponse{
+				SizeBefore: tc.before,
+				SizeAfter:  tc.after,
+			}, resp)
+		})
+	}
+}
@@ -6,9 +6,11 @@ import (
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
 	"github.com/skycoin/skycoin/src/daemon"
+	"github.com/skycoin/skycoin/src/daemon/pex"
 	"github.com/skycoin/skycoin/src/kvstorage"
 	"github.com/skycoin/skycoin/src/transaction"
 	"github.com/skycoin/skycoin/src/visor"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
 	"github.com/skycoin/skycoin/src/visor/historydb"
 	"github.com/skycoin/skycoin/src/wallet"
 )
@@ -53,6 +55,8 @@ type Daemoner interface {
 	GetBlockchainProgress(headSeq uint64) *daemon.BlockchainProgress
 	InjectBroadcastTransaction(txn coin.Transaction) error
 	InjectTransaction(txn coin.Transaction) error
+	GetBans() []pex.Ban
+	ClearBans(ips []string) error
 }
 
 // Visorer interface for visor.Visor methods used by the API
@@ -88,13 +92,21 @@ type Visorer interface {
 	GetTransactionsNum() (uint64, error)
 	GetWalletUnconfirmedTransactions(wltID string) ([]visor.UnconfirmedTransaction, error)
 	GetWalletUnconfirmedTransactionsVerbose(wltID string) ([]visor.UnconfirmedTransaction, [][]visor.TransactionInput, error)
-	GetWalletBalance(wltID string) (wallet.BalancePair, wallet.AddressBalances, error)
+	GetWalletBalance(wltID string, options ...wallet.Option) (wallet.BalancePair, wallet.AddressBalances, error)
 	CreateTransaction(p transaction.Params, wp visor.CreateTransactionParams) (*coin.Transaction, []visor.TransactionInput, error)
 	WalletCreateTransaction(wltID string, p transaction.Params, wp visor.CreateTransactionParams) (*coin.Transaction, []visor.TransactionInput, error)
 	WalletCreateTransactionSigned(wltID string, password []byte, p transaction.Params, wp visor.CreateTransactionParams) (*coin.Transaction, []visor.TransactionInput, error)
 	WalletSignTransaction(wltID string, password []byte, txn *coin.Transaction, signIndexes []int) (*coin.Transaction, []visor.TransactionInput, error)
+	WalletCreateTransactionSignedWithSession(wltID, token string, p transaction.Params, wp visor.CreateTransactionParams) (*coin.Transaction, []visor.TransactionInput, error)
+	WalletSignTransactionWithSession(wltID, token string, txn *coin.Transaction, signIndexes []int) (*coin.Transaction, []visor.TransactionInput, error)
 	ScanWalletAddresses(wltID string, password []byte, num uint64) ([]cipher.Address, error)
+	DiscoverWalletAddresses(wltID string) ([]cipher.Address, error)
 	TransactionsFinder() wallet.TransactionsFinder
+	DBStats() (dbutil.Stats, error)
+	BackupDB(path string) (int64, error)
+	CompactDB() (int64, int64, error)
+	SignatureCacheStats() visor.SignatureCacheStats
+	GetHistoryIndexStatus() (*visor.HistoryIndexStatus, error)
 }
 
 // Walleter interface for wallet.Service methods used by the API
@@ -103,10 +115,20 @@ type Walleter interface {
 	EncryptWallet(wltID string, password []byte) (wallet.Wallet, error)
 	DecryptWallet(wltID string, password []byte) (wallet.Wallet, error)
 	GetWalletSeed(wltID string, password []byte) (string, string, error)
+	SplitWalletSeed(wltID string, password []byte, threshold, count int) ([]string, string, error)
 	CreateWallet(wltName string, options wallet.Options) (wallet.Wallet, error)
 	RecoverWallet(wltID, seed, seedPassphrase string, password []byte) (wallet.Wallet, error)
 	NewAddresses(wltID string, password []byte, options ...wallet.Option) ([]cipher.Address, error)
+	NewAddressesWithSession(wltID, token string, options ...wallet.Option) ([]cipher.Address, error)
+	UnlockWallet(wltID string, password []byte, timeout time.Duration) (*wallet.Session, error)
+	LockWallet(wltID string) error
+	GetBip44Accounts(wltID string) ([]wallet.Bip44Account, error)
+	NewBip44Account(wltID, name string, password []byte) (*wallet.Bip44Account, error)
+	RenameBip44Account(wltID string, index uint32, name string) error
 	ScanAddresses(wltID string, password []byte, n uint64, tf wallet.TransactionsFinder) ([]cipher.Address, error)
+	GetDiscoveryStatus(wltID string) (*wallet.DiscoveryStatus, error)
+	SetGapLimit(wltID string, gapLimit uint64) error
+	AddWatchAddresses(wltID string, addrs []cipher.Address, xpubs []string) ([]cipher.Address, error)
 	GetWallet(wltID string) (wallet.Wallet, error)
 	GetWallets() (wallet.Wallets, error)
 	UpdateWalletLabel(wltID, label string) error
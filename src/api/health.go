@@ -17,6 +17,78 @@ type BlockchainMetadata struct {
 	TimeSinceLastBlock wh.Duration `json:"time_since_last_block"`
 }
 
+// prunedUnavailableEndpoints are the endpoints that can fail on a pruned node,
+// because they read block bodies or historical transactions and outputs which have been pruned
+var prunedUnavailableEndpoints = []string{
+	"/api/v1/block",
+	"/api/v1/blocks",
+	"/api/v1/last_blocks",
+	"/api/v1/transaction",
+	"/api/v2/transaction",
+	"/api/v1/transactions",
+	"/api/v2/transactions",
+	"/api/v1/transactions/num",
+	"/api/v1/rawtx",
+	"/api/v1/uxout",
+	"/api/v1/address_uxouts",
+}
+
+// historyUnavailableEndpoints are the endpoints that return 503 while the history database is being rebuilt,
+// because they read historical transactions and outputs. The verbose block endpoints also read the history
+var historyUnavailableEndpoints = []string{
+	"/api/v1/block?verbose=1",
+	"/api/v1/blocks?verbose=1",
+	"/api/v1/last_blocks?verbose=1",
+	"/api/v1/transaction",
+	"/api/v1/transactions",
+	"/api/v2/transactions",
+	"/api/v1/transactions/num",
+	"/api/v1/rawtx",
+	"/api/v1/uxout",
+	"/api/v1/address_uxouts",
+}
+
+// PruningStatus is the block pruning status of the node, included in the /health response
+type PruningStatus struct {
+	// Number of most recent blocks whose bodies are kept, 0 if pruning is disabled
+	PruneDepth uint64 `json:"prune_depth"`
+	// Lowest seq of the blocks whose bodies are available, other than the genesis block, 0 if no block has been pruned
+	LowestBlockSeq uint64 `json:"lowest_block_seq"`
+	// Endpoints that don't return the data of the pruned blocks
+	UnavailableEndpoints []string `json:"unavailable_endpoints"`
+}
+
+// HistoryIndexStatus is the progress of the rebuild of the history database, included in the /health response
+type HistoryIndexStatus struct {
+	readable.HistoryIndexStatus
+	// Endpoints that return 503 until the rebuild is finished
+	UnavailableEndpoints []string `json:"unavailable_endpoints"`
+}
+
+// DatabaseStatus is the storage status of the database, included in the /health response
+type DatabaseStatus struct {
+	// Size of the database file in bytes, 0 if the database is not stored in a file
+	Size int64 `json:"size"`
+	// Size of a page of the database file in bytes
+	PageSize int `json:"page_size"`
+	// Number of free pages of the database file, which are released by compacting the database
+	FreePages int `json:"free_pages"`
+}
+
+// SignatureCacheStatus is the usage of the cache of verified transaction input signatures, included in the /health response
+type SignatureCacheStatus struct {
+	// Maximum number of cached signatures, 0 if the cache is disabled
+	Capacity int `json:"capacity"`
+	// Number of cached signatures
+	Size int `json:"size"`
+	// Number of signatures found in the cache, that were not verified again
+	Hits uint64 `json:"hits"`
+	// Number of signatures not found in the cache, that were verified
+	Misses uint64 `json:"misses"`
+	// Ratio of the signatures found in the cache
+	HitRate float64 `json:"hit_rate"`
+}
+
 // HealthResponse is returned by the /health endpoint
 type HealthResponse struct {
 	BlockchainMetadata   BlockchainMetadata   `json:"blockchain"`
@@ -37,6 +109,10 @@ type HealthResponse struct {
 	UnconfirmedVerifyTxn readable.VerifyTxn   `json:"unconfirmed_verify_transaction"`
 	StartedAt            int64                `json:"started_at"`
 	Fiber                readable.FiberConfig `json:"fiber"`
+	Pruning              PruningStatus        `json:"pruning"`
+	Database             DatabaseStatus       `json:"database"`
+	SignatureCache       SignatureCacheStatus `json:"signature_cache"`
+	HistoryIndex         HistoryIndexStatus   `json:"history_index"`
 }
 
 func getHealthData(c muxConfig, gateway Gatewayer) (*HealthResponse, error) {
@@ -62,6 +138,23 @@ func getHealthData(c muxConfig, gateway Gatewayer) (*HealthResponse, error) {
 		}
 	}
 
+	dbStats, err := gateway.DBStats()
+	if err != nil {
+		return nil, fmt.Errorf("gateway.DBStats failed: %v", err)
+	}
+
+	sigCacheStats := gateway.SignatureCacheStats()
+
+	historyIndex, err := gateway.GetHistoryIndexStatus()
+	if err != nil {
+		return nil, fmt.Errorf("gateway.GetHistoryIndexStatus failed: %v", err)
+	}
+
+	historyUnavailable := []string{}
+	if historyIndex.Indexing {
+		historyUnavailable = historyUnavailableEndpoints
+	}
+
 	elapsedBlockTime := time.Now().UTC().Unix() - int64(metadata.HeadBlock.Head.Time)
 	timeSinceLastBlock := time.Second * time.Duration(elapsedBlockTime)
 
@@ -72,6 +165,11 @@ func getHealthData(c muxConfig, gateway Gatewayer) (*HealthResponse, error) {
 		return nil, err
 	}
 
+	unavailableEndpoints := []string{}
+	if metadata.LowestBlockSeq > 0 {
+		unavailableEndpoints = prunedUnavailableEndpoints
+	}
+
 	return &HealthResponse{
 		BlockchainMetadata: BlockchainMetadata{
 			BlockchainMetadata: readable.NewBlockchainMetadata(*metadata),
@@ -94,6 +192,27 @@ func getHealthData(c muxConfig, gateway Gatewayer) (*HealthResponse, error) {
 		UnconfirmedVerifyTxn: readable.NewVerifyTxn(gateway.DaemonConfig().UnconfirmedVerifyTxn),
 		Uptime:               wh.FromDuration(time.Since(gateway.StartedAt())),
 		StartedAt:            gateway.StartedAt().Unix(),
+		Pruning: PruningStatus{
+			PruneDepth:           c.health.PruneDepth,
+			LowestBlockSeq:       metadata.LowestBlockSeq,
+			UnavailableEndpoints: unavailableEndpoints,
+		},
+		Database: DatabaseStatus{
+			Size:      dbStats.Size,
+			PageSize:  dbStats.PageSize,
+			FreePages: dbStats.FreePages,
+		},
+		SignatureCache: SignatureCacheStatus{
+			Capacity: sigCacheStats.Capacity,
+			Size:     sigCacheStats.Size,
+			Hits:     sigCacheStats.Hits,
+			Misses:   sigCacheStats.Misses,
+			HitRate:  sigCacheStats.HitRate(),
+		},
+		HistoryIndex: HistoryIndexStatus{
+			HistoryIndexStatus:   readable.NewHistoryIndexStatus(*historyIndex),
+			UnavailableEndpoints: historyUnavailable,
+		},
 	}, nil
 }
 
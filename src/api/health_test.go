@@ -20,6 +20,7 @@ import (
 	"github.com/skycoin/skycoin/src/readable"
 	"github.com/skycoin/skycoin/src/util/useragent"
 	"github.com/skycoin/skycoin/src/visor"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
 )
 
 func TestHealthHandler(t *testing.T) {
@@ -30,8 +31,12 @@ func TestHealthHandler(t *testing.T) {
 		err                      string
 		getBlockchainMetadataErr error
 		getConnectionsErr        error
+		dbStatsErr               error
+		historyIndexStatusErr    error
+		historyIndexing          bool
 		cfg                      muxConfig
 		walletAPIEnabled         bool
+		lowestBlockSeq           uint64
 	}{
 		{
 			name:   "405 method not allowed",
@@ -59,6 +64,24 @@ func TestHealthHandler(t *testing.T) {
 			cfg:               defaultMuxConfig(),
 		},
 
+		{
+			name:       "gateway.DBStats error",
+			method:     http.MethodGet,
+			code:       http.StatusInternalServerError,
+			err:        "500 Internal Server Error - gateway.DBStats failed: DBStats failed",
+			dbStatsErr: errors.New("DBStats failed"),
+			cfg:        defaultMuxConfig(),
+		},
+
+		{
+			name:                  "gateway.GetHistoryIndexStatus error",
+			method:                http.MethodGet,
+			code:                  http.StatusInternalServerError,
+			err:                   "500 Internal Server Error - gateway.GetHistoryIndexStatus failed: GetHistoryIndexStatus failed",
+			historyIndexStatusErr: errors.New("GetHistoryIndexStatus failed"),
+			cfg:                   defaultMuxConfig(),
+		},
+
 		{
 			name:             "valid response",
 			method:           http.MethodGet,
@@ -87,6 +110,28 @@ func TestHealthHandler(t *testing.T) {
 			},
 			walletAPIEnabled: false,
 		},
+
+		{
+			name:   "valid response, pruned node",
+			method: http.MethodGet,
+			code:   http.StatusOK,
+			cfg: func() muxConfig {
+				c := defaultMuxConfig()
+				c.health.PruneDepth = 100
+				return c
+			}(),
+			walletAPIEnabled: true,
+			lowestBlockSeq:   21076,
+		},
+
+		{
+			name:             "valid response, history being rebuilt",
+			method:           http.MethodGet,
+			code:             http.StatusOK,
+			cfg:              defaultMuxConfig(),
+			walletAPIEnabled: true,
+			historyIndexing:  true,
+		},
 	}
 
 	for _, tc := range cases {
@@ -117,8 +162,9 @@ func TestHealthHandler(t *testing.T) {
 						},
 					},
 				},
-				Unspents:    unspents,
-				Unconfirmed: unconfirmed,
+				Unspents:       unspents,
+				Unconfirmed:    unconfirmed,
+				LowestBlockSeq: tc.lowestBlockSeq,
 			}
 
 			buildInfo := readable.BuildInfo{
@@ -182,6 +228,39 @@ func TestHealthHandler(t *testing.T) {
 				gateway.On("GetConnections", mock.Anything).Return(conns, nil)
 			}
 
+			dbStats := dbutil.Stats{
+				Size:      1024 * 1024 * 64,
+				PageSize:  4096,
+				FreePages: 120,
+			}
+			if tc.dbStatsErr != nil {
+				gateway.On("DBStats").Return(dbutil.Stats{}, tc.dbStatsErr)
+			} else {
+				gateway.On("DBStats").Return(dbStats, nil)
+			}
+
+			sigCacheStats := visor.SignatureCacheStats{
+				Capacity: 100000,
+				Size:     2000,
+				Hits:     300,
+				Misses:   100,
+			}
+			gateway.On("SignatureCacheStats").Return(sigCacheStats)
+
+			historyIndexStatus := &visor.HistoryIndexStatus{
+				ParsedBlocks: 1001,
+				TotalBlocks:  1001,
+			}
+			if tc.historyIndexing {
+				historyIndexStatus.Indexing = true
+				historyIndexStatus.ParsedBlocks = 400
+			}
+			if tc.historyIndexStatusErr != nil {
+				gateway.On("GetHistoryIndexStatus").Return(nil, tc.historyIndexStatusErr)
+			} else {
+				gateway.On("GetHistoryIndexStatus").Return(historyIndexStatus, nil)
+			}
+
 			startedAt := time.Now().Add(time.Second * -4)
 
 			gateway.On("StartedAt").Return(startedAt)
@@ -255,6 +334,34 @@ func TestHealthHandler(t *testing.T) {
 			require.Equal(t, dc.UnconfirmedVerifyTxn.MaxDropletPrecision, r.UnconfirmedVerifyTxn.MaxDropletPrecision)
 			require.True(t, time.Now().Unix() > r.StartedAt)
 
+			require.Equal(t, tc.cfg.health.PruneDepth, r.Pruning.PruneDepth)
+			require.Equal(t, tc.lowestBlockSeq, r.Pruning.LowestBlockSeq)
+			require.Equal(t, tc.lowestBlockSeq, r.BlockchainMetadata.LowestBlockSeq)
+			if tc.lowestBlockSeq == 0 {
+				require.Empty(t, r.Pruning.UnavailableEndpoints)
+			} else {
+				require.Equal(t, prunedUnavailableEndpoints, r.Pruning.UnavailableEndpoints)
+			}
+
+			require.Equal(t, dbStats.Size, r.Database.Size)
+			require.Equal(t, dbStats.PageSize, r.Database.PageSize)
+			require.Equal(t, dbStats.FreePages, r.Database.FreePages)
+
+			require.Equal(t, SignatureCacheStatus{
+				Capacity: 100000,
+				Size:     2000,
+				Hits:     300,
+				Misses:   100,
+				HitRate:  0.75,
+			}, r.SignatureCache)
+
+			require.Equal(t, readable.NewHistoryIndexStatus(*historyIndexStatus), r.HistoryIndex.HistoryIndexStatus)
+			if tc.historyIndexing {
+				require.Equal(t, historyUnavailableEndpoints, r.HistoryIndex.UnavailableEndpoints)
+			} else {
+				require.Empty(t, r.HistoryIndex.UnavailableEndpoints)
+			}
+
 		})
 	}
 }
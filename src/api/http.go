@@ -90,6 +90,7 @@ type HealthConfig struct {
 	Fiber           readable.FiberConfig
 	DaemonUserAgent useragent.Data
 	BlockPublisher  bool
+	PruneDepth      uint64
 }
 
 type muxConfig struct {
@@ -519,6 +520,34 @@ func newServerMux(c muxConfig, gateway Gatewayer) *http.ServeMux {
 	webHandlerV2("/wallet/seed/verify", http.HandlerFunc(walletVerifySeedHandler), map[string][]string{
 		http.MethodPost: {EndpointsWallet},
 	})
+	webHandlerV2("/wallet/unlock", walletUnlockHandler(gateway), map[string][]string{
+		http.MethodPost: {EndpointsWallet},
+	})
+	webHandlerV2("/wallet/lock", walletLockHandler(gateway), map[string][]string{
+		http.MethodPost: {EndpointsWallet},
+	})
+	webHandlerV2("/wallet/accounts", walletAccountsHandler(gateway), map[string][]string{
+		http.MethodGet: {EndpointsWallet},
+	})
+	webHandlerV2("/wallet/accounts/create", walletAccountCreateHandler(gateway), map[string][]string{
+		http.MethodPost: {EndpointsWallet},
+	})
+	webHandlerV2("/wallet/accounts/rename", walletAccountRenameHandler(gateway), map[string][]string{
+		http.MethodPost: {EndpointsWallet},
+	})
+	webHandlerV2("/wallet/discovery", walletDiscoveryHandler(gateway), map[string][]string{
+		http.MethodGet:  {EndpointsWallet},
+		http.MethodPost: {EndpointsWallet},
+	})
+	webHandlerV2("/wallet/watch", walletWatchHandler(gateway), map[string][]string{
+		http.MethodPost: {EndpointsWallet},
+	})
+	webHandlerV2("/wallet/seed/split", walletSeedSplitHandler(gateway), map[string][]string{
+		http.MethodPost: {EndpointsInsecureWalletSeed},
+	})
+	webHandlerV2("/wallet/seed/combine", http.HandlerFunc(walletSeedCombineHandler), map[string][]string{
+		http.MethodPost: {EndpointsWallet},
+	})
 
 	webHandlerV1("/wallet/unload", walletUnloadHandler(gateway), map[string][]string{
 		http.MethodPost: {EndpointsWallet},
@@ -572,6 +601,20 @@ func newServerMux(c muxConfig, gateway Gatewayer) *http.ServeMux {
 	webHandlerV1("/network/connection/disconnect", disconnectHandler(gateway), map[string][]string{
 		http.MethodPost: {EndpointsNetCtrl},
 	})
+	webHandlerV1("/network/bans", bansHandler(gateway), map[string][]string{
+		http.MethodGet: {EndpointsNetCtrl},
+	})
+	webHandlerV1("/network/bans/clear", clearBansHandler(gateway), map[string][]string{
+		http.MethodPost: {EndpointsNetCtrl},
+	})
+
+	// Database admin endpoints
+	webHandlerV1("/db/backup", dbBackupHandler(gateway), map[string][]string{
+		http.MethodPost: {EndpointsNetCtrl},
+	})
+	webHandlerV1("/db/compact", dbCompactHandler(gateway), map[string][]string{
+		http.MethodPost: {EndpointsNetCtrl},
+	})
 
 	// Transaction related endpoints
 	webHandlerV1("/pendingTxs", pendingTxnsHandler(gateway), map[string][]string{
@@ -91,6 +91,18 @@ var endpointsMethods = map[string][]string{
 	"/api/v1/network/connection/disconnect": []string{
 		http.MethodPost,
 	},
+	"/api/v1/network/bans": []string{
+		http.MethodGet,
+	},
+	"/api/v1/network/bans/clear": []string{
+		http.MethodPost,
+	},
+	"/api/v1/db/backup": []string{
+		http.MethodPost,
+	},
+	"/api/v1/db/compact": []string{
+		http.MethodPost,
+	},
 	"/api/v1/outputs": []string{
 		http.MethodGet,
 		http.MethodPost,
@@ -166,6 +178,34 @@ var endpointsMethods = map[string][]string{
 	"/api/v2/wallet/seed/verify": []string{
 		http.MethodPost,
 	},
+	"/api/v2/wallet/unlock": []string{
+		http.MethodPost,
+	},
+	"/api/v2/wallet/lock": []string{
+		http.MethodPost,
+	},
+	"/api/v2/wallet/accounts": []string{
+		http.MethodGet,
+	},
+	"/api/v2/wallet/accounts/create": []string{
+		http.MethodPost,
+	},
+	"/api/v2/wallet/accounts/rename": []string{
+		http.MethodPost,
+	},
+	"/api/v2/wallet/discovery": []string{
+		http.MethodGet,
+		http.MethodPost,
+	},
+	"/api/v2/wallet/watch": []string{
+		http.MethodPost,
+	},
+	"/api/v2/wallet/seed/split": []string{
+		http.MethodPost,
+	},
+	"/api/v2/wallet/seed/combine": []string{
+		http.MethodPost,
+	},
 	"/api/v2/wallet/transaction/sign": []string{
 		http.MethodPost,
 	},
@@ -8,12 +8,16 @@ import (
 
 	daemon "github.com/skycoin/skycoin/src/daemon"
 
+	dbutil "github.com/skycoin/skycoin/src/visor/dbutil"
+
 	historydb "github.com/skycoin/skycoin/src/visor/historydb"
 
 	kvstorage "github.com/skycoin/skycoin/src/kvstorage"
 
 	mock "github.com/stretchr/testify/mock"
 
+	pex "github.com/skycoin/skycoin/src/daemon/pex"
+
 	time "time"
 
 	transaction "github.com/skycoin/skycoin/src/transaction"
@@ -42,6 +46,29 @@ func (_m *MockGatewayer) AddStorageValue(storageType kvstorage.Type, key string,
 	return r0
 }
 
+// AddWatchAddresses provides a mock function with given fields: wltID, addrs, xpubs
+func (_m *MockGatewayer) AddWatchAddresses(wltID string, addrs []cipher.Address, xpubs []string) ([]cipher.Address, error) {
+	ret := _m.Called(wltID, addrs, xpubs)
+
+	var r0 []cipher.Address
+	if rf, ok := ret.Get(0).(func(string, []cipher.Address, []string) []cipher.Address); ok {
+		r0 = rf(wltID, addrs, xpubs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]cipher.Address)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, []cipher.Address, []string) error); ok {
+		r1 = rf(wltID, addrs, xpubs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // AddressCount provides a mock function with given fields:
 func (_m *MockGatewayer) AddressCount() (uint64, error) {
 	ret := _m.Called()
@@ -63,6 +90,69 @@ func (_m *MockGatewayer) AddressCount() (uint64, error) {
 	return r0, r1
 }
 
+// BackupDB provides a mock function with given fields: path
+func (_m *MockGatewayer) BackupDB(path string) (int64, error) {
+	ret := _m.Called(path)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(string) int64); ok {
+		r0 = rf(path)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(path)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ClearBans provides a mock function with given fields: ips
+func (_m *MockGatewayer) ClearBans(ips []string) error {
+	ret := _m.Called(ips)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]string) error); ok {
+		r0 = rf(ips)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CompactDB provides a mock function with given fields:
+func (_m *MockGatewayer) CompactDB() (int64, int64, error) {
+	ret := _m.Called()
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func() int64); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 int64
+	if rf, ok := ret.Get(1).(func() int64); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func() error); ok {
+		r2 = rf()
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // CreateTransaction provides a mock function with given fields: p, wp
 func (_m *MockGatewayer) CreateTransaction(p transaction.Params, wp visor.CreateTransactionParams) (*coin.Transaction, []visor.TransactionInput, error) {
 	ret := _m.Called(p, wp)
@@ -118,6 +208,27 @@ func (_m *MockGatewayer) CreateWallet(wltName string, options wallet.Options) (w
 	return r0, r1
 }
 
+// DBStats provides a mock function with given fields:
+func (_m *MockGatewayer) DBStats() (dbutil.Stats, error) {
+	ret := _m.Called()
+
+	var r0 dbutil.Stats
+	if rf, ok := ret.Get(0).(func() dbutil.Stats); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(dbutil.Stats)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DaemonConfig provides a mock function with given fields:
 func (_m *MockGatewayer) DaemonConfig() daemon.DaemonConfig {
 	ret := _m.Called()
@@ -169,6 +280,29 @@ func (_m *MockGatewayer) DisconnectByGnetID(gnetID uint64) error {
 	return r0
 }
 
+// DiscoverWalletAddresses provides a mock function with given fields: wltID
+func (_m *MockGatewayer) DiscoverWalletAddresses(wltID string) ([]cipher.Address, error) {
+	ret := _m.Called(wltID)
+
+	var r0 []cipher.Address
+	if rf, ok := ret.Get(0).(func(string) []cipher.Address); ok {
+		r0 = rf(wltID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]cipher.Address)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(wltID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // EncryptWallet provides a mock function with given fields: wltID, password
 func (_m *MockGatewayer) EncryptWallet(wltID string, password []byte) (wallet.Wallet, error) {
 	ret := _m.Called(wltID, password)
@@ -293,6 +427,45 @@ func (_m *MockGatewayer) GetBalanceOfAddresses(addrs []cipher.Address) ([]wallet
 	return r0, r1
 }
 
+// GetBans provides a mock function with given fields: 
+func (_m *MockGatewayer) GetBans() []pex.Ban {
+	ret := _m.Called()
+
+	var r0 []pex.Ban
+	if rf, ok := ret.Get(0).(func() []pex.Ban); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]pex.Ban)
+		}
+	}
+
+	return r0
+}
+
+// GetBip44Accounts provides a mock function with given fields: wltID
+func (_m *MockGatewayer) GetBip44Accounts(wltID string) ([]wallet.Bip44Account, error) {
+	ret := _m.Called(wltID)
+
+	var r0 []wallet.Bip44Account
+	if rf, ok := ret.Get(0).(func(string) []wallet.Bip44Account); ok {
+		r0 = rf(wltID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]wallet.Bip44Account)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(wltID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetBlockchainMetadata provides a mock function with given fields:
 func (_m *MockGatewayer) GetBlockchainMetadata() (*visor.BlockchainMetadata, error) {
 	ret := _m.Called()
@@ -504,6 +677,29 @@ func (_m *MockGatewayer) GetDefaultConnections() []string {
 	return r0
 }
 
+// GetDiscoveryStatus provides a mock function with given fields: wltID
+func (_m *MockGatewayer) GetDiscoveryStatus(wltID string) (*wallet.DiscoveryStatus, error) {
+	ret := _m.Called(wltID)
+
+	var r0 *wallet.DiscoveryStatus
+	if rf, ok := ret.Get(0).(func(string) *wallet.DiscoveryStatus); ok {
+		r0 = rf(wltID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*wallet.DiscoveryStatus)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(wltID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetExchgConnection provides a mock function with given fields:
 func (_m *MockGatewayer) GetExchgConnection() []string {
 	ret := _m.Called()
@@ -520,6 +716,29 @@ func (_m *MockGatewayer) GetExchgConnection() []string {
 	return r0
 }
 
+// GetHistoryIndexStatus provides a mock function with given fields:
+func (_m *MockGatewayer) GetHistoryIndexStatus() (*visor.HistoryIndexStatus, error) {
+	ret := _m.Called()
+
+	var r0 *visor.HistoryIndexStatus
+	if rf, ok := ret.Get(0).(func() *visor.HistoryIndexStatus); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*visor.HistoryIndexStatus)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetLastBlocks provides a mock function with given fields: num
 func (_m *MockGatewayer) GetLastBlocks(num uint64) ([]coin.SignedBlock, error) {
 	ret := _m.Called(num)
@@ -996,20 +1215,27 @@ func (_m *MockGatewayer) GetWallet(wltID string) (wallet.Wallet, error) {
 	return r0, r1
 }
 
-// GetWalletBalance provides a mock function with given fields: wltID
-func (_m *MockGatewayer) GetWalletBalance(wltID string) (wallet.BalancePair, wallet.AddressBalances, error) {
-	ret := _m.Called(wltID)
+// GetWalletBalance provides a mock function with given fields: wltID, options
+func (_m *MockGatewayer) GetWalletBalance(wltID string, options ...wallet.Option) (wallet.BalancePair, wallet.AddressBalances, error) {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, wltID)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
 
 	var r0 wallet.BalancePair
-	if rf, ok := ret.Get(0).(func(string) wallet.BalancePair); ok {
-		r0 = rf(wltID)
+	if rf, ok := ret.Get(0).(func(string, ...wallet.Option) wallet.BalancePair); ok {
+		r0 = rf(wltID, options...)
 	} else {
 		r0 = ret.Get(0).(wallet.BalancePair)
 	}
 
 	var r1 wallet.AddressBalances
-	if rf, ok := ret.Get(1).(func(string) wallet.AddressBalances); ok {
-		r1 = rf(wltID)
+	if rf, ok := ret.Get(1).(func(string, ...wallet.Option) wallet.AddressBalances); ok {
+		r1 = rf(wltID, options...)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(wallet.AddressBalances)
@@ -1017,8 +1243,8 @@ func (_m *MockGatewayer) GetWalletBalance(wltID string) (wallet.BalancePair, wal
 	}
 
 	var r2 error
-	if rf, ok := ret.Get(2).(func(string) error); ok {
-		r2 = rf(wltID)
+	if rf, ok := ret.Get(2).(func(string, ...wallet.Option) error); ok {
+		r2 = rf(wltID, options...)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -1188,6 +1414,20 @@ func (_m *MockGatewayer) InjectTransaction(txn coin.Transaction) error {
 	return r0
 }
 
+// LockWallet provides a mock function with given fields: wltID
+func (_m *MockGatewayer) LockWallet(wltID string) error {
+	ret := _m.Called(wltID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(wltID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewAddresses provides a mock function with given fields: wltID, password, options
 func (_m *MockGatewayer) NewAddresses(wltID string, password []byte, options ...wallet.Option) ([]cipher.Address, error) {
 	_va := make([]interface{}, len(options))
@@ -1218,6 +1458,59 @@ func (_m *MockGatewayer) NewAddresses(wltID string, password []byte, options ...
 	return r0, r1
 }
 
+// NewAddressesWithSession provides a mock function with given fields: wltID, token, options
+func (_m *MockGatewayer) NewAddressesWithSession(wltID string, token string, options ...wallet.Option) ([]cipher.Address, error) {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, wltID, token)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 []cipher.Address
+	if rf, ok := ret.Get(0).(func(string, string, ...wallet.Option) []cipher.Address); ok {
+		r0 = rf(wltID, token, options...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]cipher.Address)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, ...wallet.Option) error); ok {
+		r1 = rf(wltID, token, options...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewBip44Account provides a mock function with given fields: wltID, name, password
+func (_m *MockGatewayer) NewBip44Account(wltID string, name string, password []byte) (*wallet.Bip44Account, error) {
+	ret := _m.Called(wltID, name, password)
+
+	var r0 *wallet.Bip44Account
+	if rf, ok := ret.Get(0).(func(string, string, []byte) *wallet.Bip44Account); ok {
+		r0 = rf(wltID, name, password)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*wallet.Bip44Account)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, []byte) error); ok {
+		r1 = rf(wltID, name, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // RecoverWallet provides a mock function with given fields: wltID, seed, seedPassphrase, password
 func (_m *MockGatewayer) RecoverWallet(wltID string, seed string, seedPassphrase string, password []byte) (wallet.Wallet, error) {
 	ret := _m.Called(wltID, seed, seedPassphrase, password)
@@ -1255,6 +1548,20 @@ func (_m *MockGatewayer) RemoveStorageValue(storageType kvstorage.Type, key stri
 	return r0
 }
 
+// RenameBip44Account provides a mock function with given fields: wltID, index, name
+func (_m *MockGatewayer) RenameBip44Account(wltID string, index uint32, name string) error {
+	ret := _m.Called(wltID, index, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, uint32, string) error); ok {
+		r0 = rf(wltID, index, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ResendUnconfirmedTxns provides a mock function with given fields:
 func (_m *MockGatewayer) ResendUnconfirmedTxns() ([]cipher.SHA256, error) {
 	ret := _m.Called()
@@ -1324,6 +1631,64 @@ func (_m *MockGatewayer) ScanWalletAddresses(wltID string, password []byte, num
 	return r0, r1
 }
 
+// SetGapLimit provides a mock function with given fields: wltID, gapLimit
+func (_m *MockGatewayer) SetGapLimit(wltID string, gapLimit uint64) error {
+	ret := _m.Called(wltID, gapLimit)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, uint64) error); ok {
+		r0 = rf(wltID, gapLimit)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SignatureCacheStats provides a mock function with given fields:
+func (_m *MockGatewayer) SignatureCacheStats() visor.SignatureCacheStats {
+	ret := _m.Called()
+
+	var r0 visor.SignatureCacheStats
+	if rf, ok := ret.Get(0).(func() visor.SignatureCacheStats); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(visor.SignatureCacheStats)
+	}
+
+	return r0
+}
+
+// SplitWalletSeed provides a mock function with given fields: wltID, password, threshold, count
+func (_m *MockGatewayer) SplitWalletSeed(wltID string, password []byte, threshold int, count int) ([]string, string, error) {
+	ret := _m.Called(wltID, password, threshold, count)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(string, []byte, int, int) []string); ok {
+		r0 = rf(wltID, password, threshold, count)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(string, []byte, int, int) string); ok {
+		r1 = rf(wltID, password, threshold, count)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string, []byte, int, int) error); ok {
+		r2 = rf(wltID, password, threshold, count)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // StartedAt provides a mock function with given fields:
 func (_m *MockGatewayer) StartedAt() time.Time {
 	ret := _m.Called()
@@ -1368,6 +1733,29 @@ func (_m *MockGatewayer) UnloadWallet(wltID string) error {
 	return r0
 }
 
+// UnlockWallet provides a mock function with given fields: wltID, password, timeout
+func (_m *MockGatewayer) UnlockWallet(wltID string, password []byte, timeout time.Duration) (*wallet.Session, error) {
+	ret := _m.Called(wltID, password, timeout)
+
+	var r0 *wallet.Session
+	if rf, ok := ret.Get(0).(func(string, []byte, time.Duration) *wallet.Session); ok {
+		r0 = rf(wltID, password, timeout)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*wallet.Session)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, []byte, time.Duration) error); ok {
+		r1 = rf(wltID, password, timeout)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UpdateWalletLabel provides a mock function with given fields: wltID, label
 func (_m *MockGatewayer) UpdateWalletLabel(wltID string, label string) error {
 	ret := _m.Called(wltID, label)
@@ -1490,6 +1878,38 @@ func (_m *MockGatewayer) WalletCreateTransactionSigned(wltID string, password []
 	return r0, r1, r2
 }
 
+// WalletCreateTransactionSignedWithSession provides a mock function with given fields: wltID, token, p, wp
+func (_m *MockGatewayer) WalletCreateTransactionSignedWithSession(wltID string, token string, p transaction.Params, wp visor.CreateTransactionParams) (*coin.Transaction, []visor.TransactionInput, error) {
+	ret := _m.Called(wltID, token, p, wp)
+
+	var r0 *coin.Transaction
+	if rf, ok := ret.Get(0).(func(string, string, transaction.Params, visor.CreateTransactionParams) *coin.Transaction); ok {
+		r0 = rf(wltID, token, p, wp)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*coin.Transaction)
+		}
+	}
+
+	var r1 []visor.TransactionInput
+	if rf, ok := ret.Get(1).(func(string, string, transaction.Params, visor.CreateTransactionParams) []visor.TransactionInput); ok {
+		r1 = rf(wltID, token, p, wp)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]visor.TransactionInput)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string, string, transaction.Params, visor.CreateTransactionParams) error); ok {
+		r2 = rf(wltID, token, p, wp)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // WalletDir provides a mock function with given fields:
 func (_m *MockGatewayer) WalletDir() (string, error) {
 	ret := _m.Called()
@@ -1542,3 +1962,35 @@ func (_m *MockGatewayer) WalletSignTransaction(wltID string, password []byte, tx
 
 	return r0, r1, r2
 }
+
+// WalletSignTransactionWithSession provides a mock function with given fields: wltID, token, txn, signIndexes
+func (_m *MockGatewayer) WalletSignTransactionWithSession(wltID string, token string, txn *coin.Transaction, signIndexes []int) (*coin.Transaction, []visor.TransactionInput, error) {
+	ret := _m.Called(wltID, token, txn, signIndexes)
+
+	var r0 *coin.Transaction
+	if rf, ok := ret.Get(0).(func(string, string, *coin.Transaction, []int) *coin.Transaction); ok {
+		r0 = rf(wltID, token, txn, signIndexes)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*coin.Transaction)
+		}
+	}
+
+	var r1 []visor.TransactionInput
+	if rf, ok := ret.Get(1).(func(string, string, *coin.Transaction, []int) []visor.TransactionInput); ok {
+		r1 = rf(wltID, token, txn, signIndexes)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]visor.TransactionInput)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string, string, *coin.Transaction, []int) error); ok {
+		r2 = rf(wltID, token, txn, signIndexes)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
@@ -4,12 +4,14 @@ package api
 
 import (
 	"fmt"
+	"net"
 	"net/http"
 	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/skycoin/skycoin/src/daemon"
+	"github.com/skycoin/skycoin/src/daemon/pex"
 	"github.com/skycoin/skycoin/src/readable"
 	wh "github.com/skycoin/skycoin/src/util/http"
 )
@@ -226,3 +228,62 @@ func disconnectHandler(gateway Gatewayer) http.HandlerFunc {
 		wh.SendJSONOr500(logger, w, struct{}{})
 	}
 }
+
+// BansResponse is returned by /api/v1/network/bans
+type BansResponse struct {
+	Bans []readable.Ban `json:"bans"`
+}
+
+// bansHandler returns the banned peer IPs
+// URI: /api/v1/network/bans
+// Method: GET
+func bansHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			wh.Error405(w)
+			return
+		}
+
+		wh.SendJSONOr500(logger, w, BansResponse{
+			Bans: readable.NewBans(gateway.GetBans()),
+		})
+	}
+}
+
+// clearBansHandler removes the bans of peer IPs
+// URI: /api/v1/network/bans/clear
+// Method: POST
+// Args:
+//	ips: Comma separated list of banned IPs to unban [optional, unbans all IPs if not provided]
+func clearBansHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			wh.Error405(w)
+			return
+		}
+
+		var ips []string
+		if formIPs := r.FormValue("ips"); formIPs != "" {
+			for _, ip := range strings.Split(formIPs, ",") {
+				ip = strings.TrimSpace(ip)
+				if net.ParseIP(ip) == nil {
+					wh.Error400(w, fmt.Sprintf("invalid ip %q", ip))
+					return
+				}
+				ips = append(ips, ip)
+			}
+		}
+
+		if err := gateway.ClearBans(ips); err != nil {
+			switch err {
+			case pex.ErrNotBanned:
+				wh.Error404(w, err.Error())
+			default:
+				wh.Error500(w, err.Error())
+			}
+			return
+		}
+
+		wh.SendJSONOr500(logger, w, struct{}{})
+	}
+}
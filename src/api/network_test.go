@@ -14,6 +14,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/skycoin/skycoin/src/daemon"
+	"github.com/skycoin/skycoin/src/daemon/gnet"
 	"github.com/skycoin/skycoin/src/daemon/pex"
 	"github.com/skycoin/skycoin/src/readable"
 	"github.com/skycoin/skycoin/src/util/useragent"
@@ -57,6 +58,30 @@ func TestConnection(t *testing.T) {
 					ID:           1,
 					LastSent:     time.Unix(99999, 0),
 					LastReceived: time.Unix(1111111, 0),
+					Traffic: gnet.ConnectionTraffic{
+						Traffic: gnet.Traffic{
+							BytesSent:        120,
+							BytesReceived:    4096,
+							MessagesSent:     3,
+							MessagesReceived: 2,
+						},
+						MessageTypes: map[string]gnet.Traffic{
+							"INTR": {
+								BytesSent:        96,
+								BytesReceived:    96,
+								MessagesSent:     1,
+								MessagesReceived: 1,
+							},
+							"GETB": {
+								BytesSent:    24,
+								MessagesSent: 2,
+							},
+							"GIVB": {
+								BytesReceived:    4000,
+								MessagesReceived: 1,
+							},
+						},
+					},
 				},
 				ConnectionDetails: daemon.ConnectionDetails{
 					Outgoing:    true,
@@ -66,6 +91,12 @@ func TestConnection(t *testing.T) {
 					ListenPort:  9877,
 					Height:      1234,
 					UserAgent:   useragent.MustParse("skycoin:0.25.1(foo)"),
+					Capabilities: daemon.Capabilities{
+						Services:                 daemon.ServiceCompactBlocks | daemon.ServiceDandelion,
+						MaxIncomingMessageLength: 1024 * 1024,
+						MaxOutgoingMessageLength: 256 * 1024,
+						MessageTypes:             []string{"INTR", "GIVB", "STEM"},
+					},
 				},
 				Pex: pex.Peer{
 					Trusted: false,
@@ -84,6 +115,36 @@ func TestConnection(t *testing.T) {
 				Height:        1234,
 				UserAgent:     useragent.MustParse("skycoin:0.25.1(foo)"),
 				IsTrustedPeer: false,
+				Capabilities: readable.Capabilities{
+					Services:                 []string{"compact_blocks", "dandelion"},
+					MaxIncomingMessageLength: 1024 * 1024,
+					MaxOutgoingMessageLength: 256 * 1024,
+					MessageTypes:             []string{"INTR", "GIVB", "STEM"},
+				},
+				Traffic: readable.ConnectionTraffic{
+					Traffic: readable.Traffic{
+						BytesSent:        120,
+						BytesReceived:    4096,
+						MessagesSent:     3,
+						MessagesReceived: 2,
+					},
+					MessageTypes: map[string]readable.Traffic{
+						"INTR": {
+							BytesSent:        96,
+							BytesReceived:    96,
+							MessagesSent:     1,
+							MessagesReceived: 1,
+						},
+						"GETB": {
+							BytesSent:    24,
+							MessagesSent: 2,
+						},
+						"GIVB": {
+							BytesReceived:    4000,
+							MessagesReceived: 1,
+						},
+					},
+				},
 			},
 		},
 
@@ -156,6 +217,9 @@ func TestConnections(t *testing.T) {
 			ListenPort:  9877,
 			Height:      1234,
 			UserAgent:   useragent.MustParse("skycoin:0.25.1(foo)"),
+			Capabilities: daemon.Capabilities{
+				Services: daemon.ServiceDandelion,
+			},
 		},
 		Pex: pex.Peer{
 			Trusted: true,
@@ -193,6 +257,13 @@ func TestConnections(t *testing.T) {
 		Height:        1234,
 		UserAgent:     useragent.MustParse("skycoin:0.25.1(foo)"),
 		IsTrustedPeer: true,
+		Capabilities: readable.Capabilities{
+			Services:     []string{"dandelion"},
+			MessageTypes: []string{},
+		},
+		Traffic: readable.ConnectionTraffic{
+			MessageTypes: map[string]readable.Traffic{},
+		},
 	}
 
 	readIntrIn := readable.Connection{
@@ -208,6 +279,13 @@ func TestConnections(t *testing.T) {
 		Height:        1234,
 		UserAgent:     useragent.MustParse("skycoin:0.25.1(foo)"),
 		IsTrustedPeer: false,
+		Capabilities: readable.Capabilities{
+			Services:     []string{},
+			MessageTypes: []string{},
+		},
+		Traffic: readable.ConnectionTraffic{
+			MessageTypes: map[string]readable.Traffic{},
+		},
 	}
 
 	conns := []daemon.Connection{intrOut, intrIn}
@@ -628,3 +706,174 @@ func TestDisconnect(t *testing.T) {
 		})
 	}
 }
+
+func TestBans(t *testing.T) {
+	tt := []struct {
+		name     string
+		method   string
+		status   int
+		err      string
+		bans     []pex.Ban
+		response BansResponse
+	}{
+		{
+			name:   "405",
+			method: http.MethodPost,
+			status: http.StatusMethodNotAllowed,
+			err:    "405 Method Not Allowed",
+		},
+
+		{
+			name:   "200 no bans",
+			method: http.MethodGet,
+			status: http.StatusOK,
+			response: BansResponse{
+				Bans: []readable.Ban{},
+			},
+		},
+
+		{
+			name:   "200",
+			method: http.MethodGet,
+			status: http.StatusOK,
+			bans: []pex.Ban{
+				{
+					IP:        "112.32.32.14",
+					Reason:    "Sent an invalid block",
+					CreatedAt: 1540000000,
+					ExpiresAt: 1540086400,
+				},
+			},
+			response: BansResponse{
+				Bans: []readable.Ban{
+					{
+						IP:        "112.32.32.14",
+						Reason:    "Sent an invalid block",
+						CreatedAt: 1540000000,
+						ExpiresAt: 1540086400,
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			gateway.On("GetBans").Return(tc.bans)
+
+			endpoint := "/api/v1/network/bans"
+			req, err := http.NewRequest(tc.method, endpoint, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			if status != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()), "got `%v`| %d, want `%v`",
+					strings.TrimSpace(rr.Body.String()), status, tc.err)
+			} else {
+				var obj BansResponse
+				err = json.Unmarshal(rr.Body.Bytes(), &obj)
+				require.NoError(t, err)
+				require.Equal(t, tc.response, obj)
+			}
+		})
+	}
+}
+
+func TestClearBans(t *testing.T) {
+	tt := []struct {
+		name         string
+		method       string
+		status       int
+		err          string
+		ips          string
+		gatewayIPs   []string
+		clearBansErr error
+	}{
+		{
+			name:   "405",
+			method: http.MethodGet,
+			status: http.StatusMethodNotAllowed,
+			err:    "405 Method Not Allowed",
+		},
+
+		{
+			name:   "400 invalid ip",
+			method: http.MethodPost,
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid ip \"112.32.32.14:6000\"",
+			ips:    "113.32.32.14,112.32.32.14:6000",
+		},
+
+		{
+			name:         "404 ip not banned",
+			method:       http.MethodPost,
+			status:       http.StatusNotFound,
+			err:          "404 Not Found - IP is not banned",
+			ips:          "112.32.32.14",
+			gatewayIPs:   []string{"112.32.32.14"},
+			clearBansErr: pex.ErrNotBanned,
+		},
+
+		{
+			name:         "500 ClearBans error",
+			method:       http.MethodPost,
+			status:       http.StatusInternalServerError,
+			err:          "500 Internal Server Error - foo",
+			clearBansErr: errors.New("foo"),
+		},
+
+		{
+			name:       "200 some ips",
+			method:     http.MethodPost,
+			status:     http.StatusOK,
+			ips:        "112.32.32.14, 113.32.32.14",
+			gatewayIPs: []string{"112.32.32.14", "113.32.32.14"},
+		},
+
+		{
+			name:   "200 all ips",
+			method: http.MethodPost,
+			status: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			gateway.On("ClearBans", tc.gatewayIPs).Return(tc.clearBansErr)
+
+			endpoint := "/api/v1/network/bans/clear"
+			v := url.Values{}
+			if tc.ips != "" {
+				v.Add("ips", tc.ips)
+			}
+
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(v.Encode()))
+			require.NoError(t, err)
+			req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			if status != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()), "got `%v`| %d, want `%v`",
+					strings.TrimSpace(rr.Body.String()), status, tc.err)
+			} else {
+				var obj struct{}
+				err = json.Unmarshal(rr.Body.Bytes(), &obj)
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -542,12 +542,21 @@ func transactionHandlerV2(gateway Gatewayer) http.HandlerFunc {
 
 // walletCreateTransactionRequest is sent to POST /api/v1/wallet/transaction
 type walletCreateTransactionRequest struct {
-	Unsigned bool   `json:"unsigned"`
-	WalletID string `json:"wallet_id"`
-	Password string `json:"password"`
+	Unsigned     bool   `json:"unsigned"`
+	WalletID     string `json:"wallet_id"`
+	Password     string `json:"password"`
+	SessionToken string `json:"session_token"`
+	Account      uint32 `json:"account"`
 	createTransactionRequest
 }
 
+// VisorParams converts walletCreateTransactionRequest to visor.CreateTransactionParams
+func (r walletCreateTransactionRequest) VisorParams() visor.CreateTransactionParams {
+	p := r.createTransactionRequest.VisorParams()
+	p.Account = r.Account
+	return p
+}
+
 // Validate validates walletCreateTransactionRequest data
 func (r walletCreateTransactionRequest) Validate() error {
 	if r.WalletID == "" {
@@ -558,6 +567,14 @@ func (r walletCreateTransactionRequest) Validate() error {
 		return errors.New("password must not be used for unsigned transactions")
 	}
 
+	if r.Unsigned && r.SessionToken != "" {
+		return errors.New("session_token must not be used for unsigned transactions")
+	}
+
+	if len(r.Password) != 0 && r.SessionToken != "" {
+		return errors.New("password and session_token can not be combined")
+	}
+
 	return r.createTransactionRequest.Validate()
 }
 
@@ -593,9 +610,12 @@ func walletCreateTransactionHandler(gateway Gatewayer) http.HandlerFunc {
 
 		var txn *coin.Transaction
 		var inputs []visor.TransactionInput
-		if req.Unsigned {
+		switch {
+		case req.Unsigned:
 			txn, inputs, err = gateway.WalletCreateTransaction(req.WalletID, req.TransactionParams(), req.VisorParams())
-		} else {
+		case req.SessionToken != "":
+			txn, inputs, err = gateway.WalletCreateTransactionSignedWithSession(req.WalletID, req.SessionToken, req.TransactionParams(), req.VisorParams())
+		default:
 			txn, inputs, err = gateway.WalletCreateTransactionSigned(req.WalletID, []byte(req.Password), req.TransactionParams(), req.VisorParams())
 		}
 		if err != nil {
@@ -640,6 +660,7 @@ func walletCreateTransactionHandler(gateway Gatewayer) http.HandlerFunc {
 type WalletSignTransactionRequest struct {
 	WalletID           string `json:"wallet_id"`
 	Password           string `json:"password"`
+	SessionToken       string `json:"session_token,omitempty"`
 	EncodedTransaction string `json:"encoded_transaction"`
 	SignIndexes        []int  `json:"sign_indexes"`
 }
@@ -675,6 +696,12 @@ func walletSignTransactionHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
+		if req.Password != "" && req.SessionToken != "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "password and session_token can not be combined")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
 		txn, err := decodeTxn(req.EncodedTransaction)
 		if err != nil {
 			resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("Decode transaction failed: %v", err))
@@ -709,7 +736,13 @@ func walletSignTransactionHandler(gateway Gatewayer) http.HandlerFunc {
 			signIndexesMap[i] = struct{}{}
 		}
 
-		signedTxn, inputs, err := gateway.WalletSignTransaction(req.WalletID, []byte(req.Password), txn, req.SignIndexes)
+		var signedTxn *coin.Transaction
+		var inputs []visor.TransactionInput
+		if req.SessionToken != "" {
+			signedTxn, inputs, err = gateway.WalletSignTransactionWithSession(req.WalletID, req.SessionToken, txn, req.SignIndexes)
+		} else {
+			signedTxn, inputs, err = gateway.WalletSignTransaction(req.WalletID, []byte(req.Password), txn, req.SignIndexes)
+		}
 		if err != nil {
 			var resp HTTPResponse
 			switch err.(type) {
@@ -872,9 +872,11 @@ func TestCreateTransaction(t *testing.T) {
 func TestWalletCreateTransaction(t *testing.T) {
 	type rawWalletCreateTxnRequest struct {
 		rawCreateTxnRequest
-		WalletID string `json:"wallet_id"`
-		Password string `json:"password"`
-		Unsigned bool   `json:"unsigned"`
+		WalletID     string `json:"wallet_id"`
+		Password     string `json:"password"`
+		SessionToken string `json:"session_token,omitempty"`
+		Account      uint32 `json:"account,omitempty"`
+		Unsigned     bool   `json:"unsigned"`
 	}
 
 	changeAddress := testutil.MakeAddress()
@@ -1758,6 +1760,96 @@ func TestWalletCreateTransaction(t *testing.T) {
 		err:    "400 Bad Request - password must not be used for unsigned transactions",
 	})
 
+	sessionTxnRequest := func(password, token string, unsigned bool) rawWalletCreateTxnRequest {
+		return rawWalletCreateTxnRequest{
+			rawCreateTxnRequest: rawCreateTxnRequest{
+				HoursSelection: rawHoursSelection{
+					Type: transaction.HoursSelectionTypeManual,
+				},
+				To: []rawReceiver{
+					{
+						Address: destinationAddress.String(),
+						Coins:   "1.01",
+						Hours:   "100",
+					},
+				},
+				ChangeAddress: changeAddress.String(),
+			},
+			WalletID:     "foo.wlt",
+			Password:     password,
+			SessionToken: token,
+			Unsigned:     unsigned,
+		}
+	}
+
+	cases = append(cases, []testCase{
+		{
+			name:   "400 - session token provided for unsigned request",
+			method: http.MethodPost,
+			body:   sessionTxnRequest("", "token", true),
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - session_token must not be used for unsigned transactions",
+		},
+		{
+			name:   "400 - password and session token",
+			method: http.MethodPost,
+			body:   sessionTxnRequest("foo", "token", false),
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - password and session_token can not be combined",
+		},
+		{
+			name:                        "400 - session not found",
+			method:                      http.MethodPost,
+			body:                        sessionTxnRequest("", "token", false),
+			status:                      http.StatusBadRequest,
+			gatewayCreateTransactionErr: wallet.ErrSessionNotFound,
+			err:                         "400 Bad Request - wallet session not found or expired",
+		},
+		{
+			name:                           "200 - session token",
+			method:                         http.MethodPost,
+			body:                           sessionTxnRequest("", "token", false),
+			status:                         http.StatusOK,
+			gatewayCreateTransactionResult: txn,
+			gatewayCreateTransactionInputs: inputs,
+			createTransactionResponse:      createTxnResponse,
+		},
+	}...)
+
+	accountTxnRequest := func(account uint32) rawWalletCreateTxnRequest {
+		r := sessionTxnRequest("foo", "", false)
+		r.Account = account
+		return r
+	}
+
+	cases = append(cases, []testCase{
+		{
+			name:                        "400 - bip44 account not exist",
+			method:                      http.MethodPost,
+			body:                        accountTxnRequest(2),
+			status:                      http.StatusBadRequest,
+			gatewayCreateTransactionErr: wallet.ErrBip44AccountNotExist,
+			err:                         "400 Bad Request - bip44 account doesn't exist",
+		},
+		{
+			name:                        "400 - wallet not bip44",
+			method:                      http.MethodPost,
+			body:                        accountTxnRequest(1),
+			status:                      http.StatusBadRequest,
+			gatewayCreateTransactionErr: wallet.ErrWalletNotBip44,
+			err:                         "400 Bad Request - wallet is not a bip44 wallet",
+		},
+		{
+			name:                           "200 - bip44 account",
+			method:                         http.MethodPost,
+			body:                           accountTxnRequest(1),
+			status:                         http.StatusOK,
+			gatewayCreateTransactionResult: txn,
+			gatewayCreateTransactionInputs: inputs,
+			createTransactionResponse:      createTxnResponse,
+		},
+	}...)
+
 	for _, tc := range cases {
 		name := fmt.Sprintf("unsigned=%v %s", tc.body.Unsigned, tc.name)
 		t.Run(name, func(t *testing.T) {
@@ -1772,6 +1864,9 @@ func TestWalletCreateTransaction(t *testing.T) {
 				if tc.body.Unsigned {
 					x := gateway.On("WalletCreateTransaction", body.WalletID, body.TransactionParams(), body.VisorParams())
 					x.Return(tc.gatewayCreateTransactionResult, tc.gatewayCreateTransactionInputs, tc.gatewayCreateTransactionErr)
+				} else if body.SessionToken != "" {
+					x := gateway.On("WalletCreateTransactionSignedWithSession", body.WalletID, body.SessionToken, body.TransactionParams(), body.VisorParams())
+					x.Return(tc.gatewayCreateTransactionResult, tc.gatewayCreateTransactionInputs, tc.gatewayCreateTransactionErr)
 				} else {
 					x := gateway.On("WalletCreateTransactionSigned", body.WalletID, []byte(body.Password), body.TransactionParams(), body.VisorParams())
 					x.Return(tc.gatewayCreateTransactionResult, tc.gatewayCreateTransactionInputs, tc.gatewayCreateTransactionErr)
@@ -2100,6 +2195,48 @@ func TestWalletSignTransaction(t *testing.T) {
 			},
 		},
 
+		{
+			name:   "400 - password and session token",
+			method: http.MethodPost,
+			body: &WalletSignTransactionRequest{
+				WalletID:           "foo.wlt",
+				Password:           "foo",
+				SessionToken:       "token",
+				EncodedTransaction: validBody.EncodedTransaction,
+			},
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "password and session_token can not be combined"),
+		},
+
+		{
+			name:   "400 - session not found",
+			method: http.MethodPost,
+			body: &WalletSignTransactionRequest{
+				WalletID:           "foo.wlt",
+				SessionToken:       "token",
+				EncodedTransaction: validBody.EncodedTransaction,
+			},
+			status:                    http.StatusBadRequest,
+			gatewaySignTransactionErr: wallet.ErrSessionNotFound,
+			httpResponse:              NewHTTPErrorResponse(http.StatusBadRequest, wallet.ErrSessionNotFound.Error()),
+		},
+
+		{
+			name:   "200 - session token",
+			method: http.MethodPost,
+			body: &WalletSignTransactionRequest{
+				WalletID:           "foo.wlt",
+				SessionToken:       "token",
+				EncodedTransaction: validBody.EncodedTransaction,
+			},
+			status:                       http.StatusOK,
+			gatewaySignTransactionResult: &signedTxn,
+			gatewaySignTransactionInputs: inputs,
+			httpResponse: HTTPResponse{
+				Data: *signedTxnResp,
+			},
+		},
+
 		{
 			name:   "200 - sign indexes",
 			method: http.MethodPost,
@@ -2133,6 +2270,7 @@ func TestWalletSignTransaction(t *testing.T) {
 
 			if tc.body != nil {
 				gateway.On("WalletSignTransaction", tc.body.WalletID, []byte(tc.body.Password), txn, tc.body.SignIndexes).Return(tc.gatewaySignTransactionResult, tc.gatewaySignTransactionInputs, tc.gatewaySignTransactionErr)
+				gateway.On("WalletSignTransactionWithSession", tc.body.WalletID, tc.body.SessionToken, txn, tc.body.SignIndexes).Return(tc.gatewaySignTransactionResult, tc.gatewaySignTransactionInputs, tc.gatewaySignTransactionErr)
 			}
 
 			endpoint := "/api/v2/wallet/transaction/sign"
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"sort"
 	"strconv"
@@ -15,9 +16,11 @@ import (
 	"github.com/skycoin/skycoin/src/daemon"
 	"github.com/skycoin/skycoin/src/readable"
 	"github.com/skycoin/skycoin/src/transaction"
+	"github.com/skycoin/skycoin/src/util/droplet"
 	wh "github.com/skycoin/skycoin/src/util/http"
 	"github.com/skycoin/skycoin/src/util/mathutil"
 	"github.com/skycoin/skycoin/src/visor"
+	"github.com/skycoin/skycoin/src/visor/historydb"
 )
 
 // pendingTxnsHandler returns pending (unconfirmed) transactions
@@ -123,8 +126,13 @@ func transactionHandler(gateway Gatewayer) http.HandlerFunc {
 		if verbose {
 			txn, inputs, err := gateway.GetTransactionWithInputs(h)
 			if err != nil {
+			switch err.(type) {
+			case historydb.ErrHistoryIndexing:
+				wh.Error503(w, err.Error())
+			default:
 				wh.Error500(w, err.Error())
-				return
+			}
+			return
 			}
 			if txn == nil {
 				wh.Error404(w, "")
@@ -143,8 +151,13 @@ func transactionHandler(gateway Gatewayer) http.HandlerFunc {
 
 		txn, err := gateway.GetTransaction(h)
 		if err != nil {
+		switch err.(type) {
+		case historydb.ErrHistoryIndexing:
+			wh.Error503(w, err.Error())
+		default:
 			wh.Error500(w, err.Error())
-			return
+		}
+		return
 		}
 		if txn == nil {
 			wh.Error404(w, "")
@@ -295,8 +308,13 @@ func transactionsHandler(gateway Gatewayer) http.HandlerFunc {
 		if verbose {
 			txns, inputs, _, err := gateway.GetTransactionsWithInputs(flts, visor.AscOrder, nil)
 			if err != nil {
+			switch err.(type) {
+			case historydb.ErrHistoryIndexing:
+				wh.Error503(w, err.Error())
+			default:
 				wh.Error500(w, err.Error())
-				return
+			}
+			return
 			}
 
 			rTxns, err := NewTransactionsWithStatusVerbose(txns, inputs)
@@ -311,8 +329,13 @@ func transactionsHandler(gateway Gatewayer) http.HandlerFunc {
 		} else {
 			txns, _, err := gateway.GetTransactions(flts, visor.AscOrder, nil)
 			if err != nil {
+			switch err.(type) {
+			case historydb.ErrHistoryIndexing:
+				wh.Error503(w, err.Error())
+			default:
 				wh.Error500(w, err.Error())
-				return
+			}
+			return
 			}
 
 			rTxns, err := NewTransactionsWithStatus(txns)
@@ -339,7 +362,12 @@ func transactionsNumHandler(gateway Gatewayer) http.Handler {
 		}
 		num, err := gateway.GetTransactionsNum()
 		if err != nil {
-			writeError500Response(w, err.Error())
+			switch err.(type) {
+			case historydb.ErrHistoryIndexing:
+				wh.Error503(w, err.Error())
+			default:
+				writeError500Response(w, err.Error())
+			}
 			return
 		}
 
@@ -359,6 +387,11 @@ func transactionsNumHandler(gateway Gatewayer) http.Handler {
 // Args:
 //     addrs: Comma separated addresses [optional, returns all transactions if no address provided]
 //     confirmed: Whether the transactions should be confirmed [optional, must be 0 or 1; if not provided, returns all]
+//     senders: Comma separated addresses, returns the transactions that spend outputs of any of them [optional]
+//     receivers: Comma separated addresses, returns the transactions that send coins to any of them [optional]
+//     time_from, time_to: Unix time range of the transactions, inclusive [optional]
+//     block_from, block_to: Block seq range of the confirmed transactions, inclusive [optional]
+//     min_coins, max_coins: Range of the total coins of the transaction outputs, inclusive [optional]
 //	   verbose: [bool] include verbose transaction input data
 //     page: Page number
 //     limit: the number of transactions per page [optional, default to 10, must be <= 100]
@@ -402,6 +435,57 @@ func transactionsHandlerV2(gateway Gatewayer) http.HandlerFunc {
 			flts = append(flts, visor.NewConfirmedTxFilter(confirmed))
 		}
 
+		// Gets the 'senders' and 'receivers' parameter values
+		senders, err := parseAddressesFromStr(r.FormValue("senders"))
+		if err != nil {
+			writeError400Response(w, fmt.Sprintf("parse parameter: 'senders' failed: %v", err))
+			return
+		}
+		if len(senders) > 0 {
+			flts = append(flts, visor.NewSenderAddrsFilter(senders))
+		}
+
+		receivers, err := parseAddressesFromStr(r.FormValue("receivers"))
+		if err != nil {
+			writeError400Response(w, fmt.Sprintf("parse parameter: 'receivers' failed: %v", err))
+			return
+		}
+		if len(receivers) > 0 {
+			flts = append(flts, visor.NewReceiverAddrsFilter(receivers))
+		}
+
+		// Gets the range parameter values
+		parseUint := func(s string) (uint64, error) {
+			return strconv.ParseUint(s, 10, 64)
+		}
+
+		timeFrom, timeTo, ok, err := parseUint64Range(r, "time_from", "time_to", parseUint)
+		if err != nil {
+			writeError400Response(w, err.Error())
+			return
+		}
+		if ok {
+			flts = append(flts, visor.NewTimeRangeTxFilter(timeFrom, timeTo))
+		}
+
+		blockFrom, blockTo, ok, err := parseUint64Range(r, "block_from", "block_to", parseUint)
+		if err != nil {
+			writeError400Response(w, err.Error())
+			return
+		}
+		if ok {
+			flts = append(flts, visor.NewBlockRangeTxFilter(blockFrom, blockTo))
+		}
+
+		minCoins, maxCoins, ok, err := parseUint64Range(r, "min_coins", "max_coins", droplet.FromString)
+		if err != nil {
+			writeError400Response(w, err.Error())
+			return
+		}
+		if ok {
+			flts = append(flts, visor.NewCoinsTxFilter(minCoins, maxCoins))
+		}
+
 		order, err := parseSortOrderFromStr(r.FormValue("sort"))
 		if err != nil {
 			writeError400Response(w, fmt.Sprintf("invalid 'sort' value: %v", err))
@@ -440,7 +524,12 @@ func transactionsHandlerV2(gateway Gatewayer) http.HandlerFunc {
 		if verbose {
 			txns, inputs, pages, err := gateway.GetTransactionsWithInputs(flts, order, pageIndex)
 			if err != nil {
-				writeError500Response(w, err.Error())
+				switch err.(type) {
+				case historydb.ErrHistoryIndexing:
+					writeHTTPResponse(w, NewHTTPErrorResponse(http.StatusServiceUnavailable, err.Error()))
+				default:
+					writeError500Response(w, err.Error())
+				}
 				return
 			}
 
@@ -465,7 +554,12 @@ func transactionsHandlerV2(gateway Gatewayer) http.HandlerFunc {
 		} else {
 			txns, pages, err := gateway.GetTransactions(flts, order, pageIndex)
 			if err != nil {
-				writeError500Response(w, err.Error())
+				switch err.(type) {
+				case historydb.ErrHistoryIndexing:
+					writeHTTPResponse(w, NewHTTPErrorResponse(http.StatusServiceUnavailable, err.Error()))
+				default:
+					writeError500Response(w, err.Error())
+				}
 				return
 			}
 
@@ -491,6 +585,40 @@ func transactionsHandlerV2(gateway Gatewayer) http.HandlerFunc {
 	}
 }
 
+// parseUint64Range parses the optional bounds of an inclusive range of values.
+// A missing lower bound is 0 and a missing upper bound is math.MaxUint64. Returns false if both bounds are missing
+func parseUint64Range(r *http.Request, fromKey, toKey string, parse func(string) (uint64, error)) (uint64, uint64, bool, error) {
+	fromStr := r.FormValue(fromKey)
+	toStr := r.FormValue(toKey)
+	if fromStr == "" && toStr == "" {
+		return 0, 0, false, nil
+	}
+
+	var from uint64
+	if fromStr != "" {
+		var err error
+		from, err = parse(fromStr)
+		if err != nil {
+			return 0, 0, false, fmt.Errorf("invalid '%s' value: %v", fromKey, err)
+		}
+	}
+
+	to := uint64(math.MaxUint64)
+	if toStr != "" {
+		var err error
+		to, err = parse(toStr)
+		if err != nil {
+			return 0, 0, false, fmt.Errorf("invalid '%s' value: %v", toKey, err)
+		}
+	}
+
+	if from > to {
+		return 0, 0, false, fmt.Errorf("'%s' must not be greater than '%s'", fromKey, toKey)
+	}
+
+	return from, to, true, nil
+}
+
 // InjectTransactionRequest is sent to POST /api/v1/injectTransaction
 type InjectTransactionRequest struct {
 	RawTxn      string `json:"rawtx"`
@@ -636,8 +764,13 @@ func rawTxnHandler(gateway Gatewayer) http.HandlerFunc {
 
 		txn, err := gateway.GetTransaction(h)
 		if err != nil {
+		switch err.(type) {
+		case historydb.ErrHistoryIndexing:
+			wh.Error503(w, err.Error())
+		default:
 			wh.Error400(w, err.Error())
-			return
+		}
+		return
 		}
 
 		if txn == nil {
@@ -27,6 +27,7 @@ import (
 	"github.com/skycoin/skycoin/src/testutil"
 	"github.com/skycoin/skycoin/src/transaction"
 	"github.com/skycoin/skycoin/src/visor"
+	"github.com/skycoin/skycoin/src/visor/historydb"
 )
 
 func createUnconfirmedTxn(t *testing.T) visor.UnconfirmedTransaction {
@@ -378,6 +379,18 @@ func TestGetTransactionByID(t *testing.T) {
 			getTransactionResultVerboseError: errors.New("getTransactionResultVerboseError"),
 		},
 
+		{
+			name:   "503 - history being rebuilt",
+			method: http.MethodGet,
+			status: http.StatusServiceUnavailable,
+			err:    "503 Service Unavailable - The history database is being indexed (1200 blocks indexed so far), try again later",
+			httpBody: &httpBody{
+				txid: validHash,
+			},
+			txid:                testutil.SHA256FromHex(t, validHash),
+			getTransactionError: historydb.ErrHistoryIndexing{ParsedBlocks: 1200},
+		},
+
 		{
 			name:   "404",
 			method: http.MethodGet,
@@ -1397,6 +1410,7 @@ func TestTransactionsHandlerV2(t *testing.T) {
 		method                       string
 		disableCSRF                  bool
 		args                         []string
+		flts                         []visor.TxFilter
 		verbose                      bool
 		gatewayGetTransactions       []visor.Transaction
 		gatewayGetTransactionsInputs [][]visor.TransactionInput
@@ -1502,6 +1516,64 @@ func TestTransactionsHandlerV2(t *testing.T) {
 			expectStatusCode: 400,
 			expectErrMsg:     "invalid 'confirmed' value: strconv.ParseBool: parsing \"abc\": invalid syntax",
 		},
+		{
+			name:   "GET with senders and receivers",
+			method: "GET",
+			args:   []string{"senders=" + addrs[0].String() + "," + addrs[1].String(), "receivers=" + addrs[2].String()},
+			flts: []visor.TxFilter{
+				visor.NewSenderAddrsFilter(addrs[:2]),
+				visor.NewReceiverAddrsFilter(addrs[2:3]),
+			},
+			gatewayGetTransactions: txns[:2],
+			gatewayTotalPage:       uint64(1),
+			expectStatusCode:       200,
+			expectPageInfo:         readable.PageInfo{TotalPages: 1, CurrentPage: 1, PageSize: 10},
+			expectTxns:             expectTxns(t, txns[:2], nil),
+		},
+		{
+			name:   "GET with ranges",
+			method: "GET",
+			args:   []string{"confirmed=1", "time_from=1500000000", "time_to=1600000000", "block_to=200", "min_coins=1.5"},
+			flts: []visor.TxFilter{
+				visor.NewConfirmedTxFilter(true),
+				visor.NewTimeRangeTxFilter(1500000000, 1600000000),
+				visor.NewBlockRangeTxFilter(0, 200),
+				visor.NewCoinsTxFilter(1500000, math.MaxUint64),
+			},
+			gatewayGetTransactions: txns[:3],
+			gatewayTotalPage:       uint64(1),
+			expectStatusCode:       200,
+			expectPageInfo:         readable.PageInfo{TotalPages: 1, CurrentPage: 1, PageSize: 10},
+			expectTxns:             expectTxns(t, txns[:3], nil),
+		},
+		{
+			name:             "invalid senders",
+			method:           "GET",
+			args:             []string{"senders=abc"},
+			expectStatusCode: 400,
+			expectErrMsg:     "parse parameter: 'senders' failed: address \"abc\" is invalid: Invalid address length",
+		},
+		{
+			name:             "invalid time_from",
+			method:           "GET",
+			args:             []string{"time_from=abc"},
+			expectStatusCode: 400,
+			expectErrMsg:     "invalid 'time_from' value: strconv.ParseUint: parsing \"abc\": invalid syntax",
+		},
+		{
+			name:             "invalid block range",
+			method:           "GET",
+			args:             []string{"block_from=10", "block_to=9"},
+			expectStatusCode: 400,
+			expectErrMsg:     "'block_from' must not be greater than 'block_to'",
+		},
+		{
+			name:             "invalid max_coins",
+			method:           "GET",
+			args:             []string{"max_coins=1.0000001"},
+			expectStatusCode: 400,
+			expectErrMsg:     "invalid 'max_coins' value: Droplet string conversion failed: Too many decimal places",
+		},
 	}
 
 	for _, tc := range tt {
@@ -1551,6 +1623,9 @@ func TestTransactionsHandlerV2(t *testing.T) {
 				}
 			}
 			pi, _ := visor.NewPageIndex(pageSize, page) // nolint:errcheck
+			if tc.flts != nil {
+				flts = tc.flts
+			}
 
 			gateway.On("GetTransactions", flts, visor.AscOrder, pi).Return(tc.gatewayGetTransactions, tc.gatewayTotalPage, nil)
 			gateway.On("GetTransactionsWithInputs", flts, visor.AscOrder, pi).Return(tc.gatewayGetTransactions, tc.gatewayGetTransactionsInputs, tc.gatewayTotalPage, nil)
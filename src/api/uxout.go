@@ -6,6 +6,7 @@ import (
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/readable"
 	wh "github.com/skycoin/skycoin/src/util/http"
+	"github.com/skycoin/skycoin/src/visor/historydb"
 )
 
 // URI: /api/v1/uxout
@@ -34,8 +35,13 @@ func uxOutHandler(gateway Gatewayer) http.HandlerFunc {
 
 		uxout, headTime, err := gateway.GetUxOutByID(id)
 		if err != nil {
+		switch err.(type) {
+		case historydb.ErrHistoryIndexing:
+			wh.Error503(w, err.Error())
+		default:
 			wh.Error400(w, err.Error())
-			return
+		}
+		return
 		}
 
 		if uxout == nil {
@@ -79,8 +85,13 @@ func addrUxOutsHandler(gateway Gatewayer) http.HandlerFunc {
 
 		uxs, headTime, err := gateway.GetSpentOutputsForAddresses([]cipher.Address{cipherAddr})
 		if err != nil {
+		switch err.(type) {
+		case historydb.ErrHistoryIndexing:
+			wh.Error503(w, err.Error())
+		default:
 			wh.Error400(w, err.Error())
-			return
+		}
+		return
 		}
 
 		ret := make([]readable.SpentOutput, 0)
@@ -103,6 +103,18 @@ func TestGetUxOutByID(t *testing.T) {
 			getGetUxOutByIDArg:   testutil.SHA256FromHex(t, validHash),
 			getGetUxOutByIDError: errors.New("getGetUxOutByIDError"),
 		},
+		{
+			name:   "503 - history being rebuilt",
+			method: http.MethodGet,
+			status: http.StatusServiceUnavailable,
+			err:    "503 Service Unavailable - The history database is being indexed (0 blocks indexed so far), try again later",
+			httpBody: &httpBody{
+				uxid: validHash,
+			},
+			uxid:                 validHash,
+			getGetUxOutByIDArg:   testutil.SHA256FromHex(t, validHash),
+			getGetUxOutByIDError: historydb.ErrHistoryIndexing{},
+		},
 		{
 			name:   "404 - uxout == nil",
 			method: http.MethodGet,
@@ -9,6 +9,8 @@ import (
 	"net/http"
 	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/cipher/bip39"
@@ -40,8 +42,9 @@ type WalletResponse struct {
 	Entries []readable.WalletEntry `json:"entries"`
 }
 
-// NewWalletResponse creates WalletResponse struct from wallet.Wallet
-func NewWalletResponse(w wallet.Wallet) (*WalletResponse, error) {
+// NewWalletResponse creates WalletResponse struct from wallet.Wallet.
+// The entries of bip44 wallets can be selected with wallet.OptionAccount, entries of account 0 are returned by default.
+func NewWalletResponse(w wallet.Wallet, options ...wallet.Option) (*WalletResponse, error) {
 	var wr WalletResponse
 
 	wr.Meta.Coin = w.Coin()
@@ -54,7 +57,7 @@ func NewWalletResponse(w wallet.Wallet) (*WalletResponse, error) {
 	wr.Meta.Timestamp = w.Timestamp()
 	wr.Meta.Temp = w.IsTemp()
 
-	var options []wallet.Option
+	var entriesOptions []wallet.Option
 	switch w.Type() {
 	case wallet.WalletTypeBip44:
 		bip44Coin := w.Bip44Coin()
@@ -63,13 +66,19 @@ func NewWalletResponse(w wallet.Wallet) (*WalletResponse, error) {
 		}
 		wr.Meta.Bip44Coin = bip44Coin
 
-		// get entries on both external and change chains
-		options = append(options, wallet.OptionExternal(), wallet.OptionChange())
+		// get entries on both external and change chains of the selected account
+		entriesOptions = append(options, wallet.OptionExternal(), wallet.OptionChange())
 	case wallet.WalletTypeXPub:
 		wr.Meta.XPub = w.XPub()
+	case wallet.WalletTypeWatchOnly:
+		ww, ok := w.(wallet.WatchWallet)
+		if !ok {
+			return nil, errors.New("Wallet is not a watch-only wallet")
+		}
+		wr.Meta.XPubs = ww.XPubs()
 	}
 
-	entries, err := w.GetEntries(options...)
+	entries, err := w.GetEntries(entriesOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get wallet entries: %v", err)
 	}
@@ -94,6 +103,14 @@ func NewWalletResponse(w wallet.Wallet) (*WalletResponse, error) {
 		case wallet.WalletTypeXPub:
 			childNumber := e.ChildNumber
 			wr.Entries[i].ChildNumber = &childNumber
+		case wallet.WalletTypeWatchOnly:
+			// Watched addresses that are not derived from an xpub key have no public key
+			if e.Public.Null() {
+				wr.Entries[i].Public = ""
+				continue
+			}
+			childNumber := e.ChildNumber
+			wr.Entries[i].ChildNumber = &childNumber
 		}
 	}
 
@@ -106,6 +123,7 @@ func NewWalletResponse(w wallet.Wallet) (*WalletResponse, error) {
 // Method: GET
 // Args:
 //     id: wallet id [required]
+//     account: bip44 account index [optional, defaults to 0]
 func walletBalanceHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -118,7 +136,13 @@ func walletBalanceHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
-		walletBalance, addressBalances, err := gateway.GetWalletBalance(wltID)
+		opts, err := parseAccountOption(r)
+		if err != nil {
+			wh.Error400(w, err.Error())
+			return
+		}
+
+		walletBalance, addressBalances, err := gateway.GetWalletBalance(wltID, opts...)
 		if err != nil {
 			logger.Errorf("Get wallet balance failed: id: %v, err: %v", wltID, err)
 			switch err {
@@ -126,6 +150,8 @@ func walletBalanceHandler(gateway Gatewayer) http.HandlerFunc {
 				wh.Error404(w, "")
 			case wallet.ErrWalletAPIDisabled:
 				wh.Error403(w, "")
+			case wallet.ErrWalletNotBip44, wallet.ErrBip44AccountNotExist:
+				wh.Error400(w, err.Error())
 			default:
 				wh.Error500(w, err.Error())
 			}
@@ -207,7 +233,7 @@ func balanceHandler(gateway Gatewayer) http.HandlerFunc {
 // Args:
 //     seed: wallet seed [required]
 //     seed-passphrase: wallet seed passphrase [optional, bip44 type wallet only]
-//     type: wallet type [required, one of "deterministic", "bip44" or "xpub"]
+//     type: wallet type [required, one of "deterministic", "bip44", "xpub" or "watch-only"]
 //     bip44-coin: BIP44 coin type [optional, defaults to 8000 (skycoin's coin type), only valid if type is "bip44"]
 //     xpub: xpub key [required for xpub wallets]
 //     label: wallet label [required]
@@ -215,6 +241,10 @@ func balanceHandler(gateway Gatewayer) http.HandlerFunc {
 //     encrypt: bool value, whether encrypt the wallet [optional]
 //     password: password for encrypting wallet [optional, must be provided if "encrypt" is set]
 //     private-keys: private keys for generating addresses for collection wallets.[optional, multiple keys must be joined with commas]
+//     seed-shares: mnemonic shares of the wallet seed, an alternative to seed [optional, multiple shares must be joined with commas]
+//     gap-limit: number of unused addresses scanned ahead by address discovery [optional, must be > 0, defaults to 20]
+//     addresses: addresses watched by a watch-only wallet [optional, multiple addresses must be joined with commas]
+//     xpubs: xpub keys watched by a watch-only wallet [optional, multiple keys must be joined with commas]
 func walletCreateHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -297,18 +327,47 @@ func walletCreateHandler(gateway Gatewayer) http.HandlerFunc {
 			}
 		}()
 
+		var seedShares []string
+		if sharesStr := r.FormValue("seed-shares"); sharesStr != "" {
+			seedShares = splitCommaList(sharesStr)
+		}
+
+		var gapLimit uint64
+		if gapLimitStr := r.FormValue("gap-limit"); gapLimitStr != "" {
+			gapLimit, err = strconv.ParseUint(gapLimitStr, 10, 64)
+			if err != nil || gapLimit == 0 {
+				wh.Error400(w, "invalid gap-limit value")
+				return
+			}
+		}
+
+		addrs, err := parseWatchAddresses(splitCommaList(r.FormValue("addresses")))
+		if err != nil {
+			wh.Error400(w, err.Error())
+			return
+		}
+
+		var watchAddrs []cipher.Addresser
+		for _, a := range addrs {
+			watchAddrs = append(watchAddrs, a)
+		}
+
 		wlt, err := gateway.CreateWallet("", wallet.Options{
 			Seed:                  seed,
+			SeedShares:            seedShares,
 			Label:                 label,
 			Encrypt:               encrypt,
 			Password:              []byte(password),
 			ScanN:                 scanN,
+			GapLimit:              gapLimit,
 			Type:                  walletType,
 			SeedPassphrase:        r.FormValue("seed-passphrase"),
 			Bip44Coin:             bip44Coin,
 			XPub:                  r.FormValue("xpub"),
 			TF:                    gateway.TransactionsFinder(),
 			CollectionPrivateKeys: secKeys,
+			WatchAddresses:        watchAddrs,
+			WatchXPubs:            splitCommaList(r.FormValue("xpubs")),
 		})
 		if err != nil {
 			switch err.(type) {
@@ -449,6 +508,8 @@ func walletCreateTempHandler(gateway Gatewayer) http.HandlerFunc {
 //     id: wallet id [required]
 //     num: number of address need to create [optional, if not set the default value is 1]
 //     password: wallet password [optional, must be provided if the wallet is encrypted]
+//     session-token: token of an unlocked wallet session, an alternative to password [optional]
+//     account: bip44 account index [optional, defaults to 0]
 func walletNewAddressesHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -462,7 +523,12 @@ func walletNewAddressesHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
-		var opts []wallet.Option
+		opts, err := parseAccountOption(r)
+		if err != nil {
+			wh.Error400(w, err.Error())
+			return
+		}
+
 		// Compute the number of addresses to create, default is 1
 		num := r.FormValue("num")
 		if num != "" {
@@ -488,7 +554,18 @@ func walletNewAddressesHandler(gateway Gatewayer) http.HandlerFunc {
 			opts = append(opts, wallet.OptionCollectionPrivateKeys(privateKeys))
 		}
 
-		addrs, err := gateway.NewAddresses(wltID, []byte(password), opts...)
+		sessionToken := r.FormValue("session-token")
+		if password != "" && sessionToken != "" {
+			wh.Error400(w, "password and session-token can not be combined")
+			return
+		}
+
+		var addrs []cipher.Address
+		if sessionToken != "" {
+			addrs, err = gateway.NewAddressesWithSession(wltID, sessionToken, opts...)
+		} else {
+			addrs, err = gateway.NewAddresses(wltID, []byte(password), opts...)
+		}
 		if err != nil {
 			switch err {
 			case wallet.ErrWalletAPIDisabled:
@@ -624,6 +701,7 @@ func walletUpdateHandler(gateway Gatewayer) http.HandlerFunc {
 // Method: GET
 // Args:
 //     id: wallet id [required]
+//     account: bip44 account index of the returned entries [optional, defaults to 0]
 func walletHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -637,6 +715,12 @@ func walletHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
+		opts, err := parseAccountOption(r)
+		if err != nil {
+			wh.Error400(w, err.Error())
+			return
+		}
+
 		wlt, err := gateway.GetWallet(wltID)
 		if err != nil {
 			switch err {
@@ -647,7 +731,13 @@ func walletHandler(gateway Gatewayer) http.HandlerFunc {
 			}
 			return
 		}
-		rlt, err := NewWalletResponse(wlt)
+
+		if err := checkAccountOption(wlt, opts...); err != nil {
+			wh.Error400(w, err.Error())
+			return
+		}
+
+		rlt, err := NewWalletResponse(wlt, opts...)
 		if err != nil {
 			wh.Error500(w, err.Error())
 			return
@@ -952,6 +1042,754 @@ func walletVerifySeedHandler(w http.ResponseWriter, r *http.Request) {
 	writeHTTPResponse(w, HTTPResponse{Data: struct{}{}})
 }
 
+// WalletSeedSplitRequest is the request data for POST /api/v2/wallet/seed/split
+type WalletSeedSplitRequest struct {
+	ID        string `json:"id"`
+	Password  string `json:"password"`
+	Threshold int    `json:"threshold"`
+	Shares    int    `json:"shares"`
+}
+
+// WalletSeedSharesResponse is returned by /api/v2/wallet/seed/split
+type WalletSeedSharesResponse struct {
+	Threshold      int      `json:"threshold"`
+	Shares         []string `json:"shares"`
+	SeedPassphrase string   `json:"seed_passphrase,omitempty"`
+}
+
+// walletSeedSplitHandler splits the seed of an encrypted wallet into mnemonic shares
+// with Shamir's secret sharing. Any threshold of the shares can recover the seed.
+// The seed passphrase is returned alongside the shares, it is not part of them.
+// URI: /api/v2/wallet/seed/split
+// Method: POST
+// Args:
+//  id: wallet id
+//  password: wallet password
+//  threshold: number of shares required to recover the seed
+//  shares: number of shares to create
+func walletSeedSplitHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req WalletSeedSplitRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		defer func() {
+			req.Password = ""
+		}()
+
+		if req.ID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "id is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.Threshold <= 0 {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "threshold must be > 0")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.Shares < req.Threshold {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "shares must be >= threshold")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		shares, seedPassphrase, err := gateway.SplitWalletSeed(req.ID, []byte(req.Password), req.Threshold, req.Shares)
+		if err != nil {
+			var resp HTTPResponse
+			switch err {
+			case wallet.ErrWalletAPIDisabled, wallet.ErrSeedAPIDisabled:
+				resp = NewHTTPErrorResponse(http.StatusForbidden, "")
+			case wallet.ErrWalletNotExist:
+				resp = NewHTTPErrorResponse(http.StatusNotFound, "")
+			default:
+				switch err.(type) {
+				case wallet.Error:
+					resp = NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+				default:
+					resp = NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+				}
+			}
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: WalletSeedSharesResponse{
+				Threshold:      req.Threshold,
+				Shares:         shares,
+				SeedPassphrase: seedPassphrase,
+			},
+		})
+	}
+}
+
+// WalletSeedCombineRequest is the request data for POST /api/v2/wallet/seed/combine
+type WalletSeedCombineRequest struct {
+	Shares []string `json:"shares"`
+}
+
+// walletSeedCombineHandler verifies and combines mnemonic shares of a wallet seed
+// URI: /api/v2/wallet/seed/combine
+// Method: POST
+// Args:
+//  shares: mnemonic shares of the seed, at least as many as the threshold
+func walletSeedCombineHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	var req WalletSeedCombineRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	if len(req.Shares) == 0 {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, "shares are required")
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	seed, err := wallet.CombineSeedShares(req.Shares)
+	if err != nil {
+		resp := NewHTTPErrorResponse(http.StatusUnprocessableEntity, err.Error())
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	writeHTTPResponse(w, HTTPResponse{
+		Data: WalletSeedResponse{
+			Seed: seed,
+		},
+	})
+}
+
+// splitCommaList splits a comma separated list, such as seed share mnemonics or xpub keys
+func splitCommaList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// parseWatchAddresses parses the addresses watched by a watch-only wallet
+func parseWatchAddresses(addrs []string) ([]cipher.Address, error) {
+	if len(addrs) == 0 {
+		return nil, nil
+	}
+
+	res := make([]cipher.Address, len(addrs))
+	for i, a := range addrs {
+		addr, err := cipher.DecodeBase58Address(a)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %q: %v", a, err)
+		}
+		res[i] = addr
+	}
+	return res, nil
+}
+
+// WalletUnlockRequest is the request data for POST /api/v2/wallet/unlock
+type WalletUnlockRequest struct {
+	ID       string `json:"id"`
+	Password string `json:"password"`
+	Timeout  int    `json:"timeout"`
+}
+
+// WalletSessionResponse is returned by /api/v2/wallet/unlock
+type WalletSessionResponse struct {
+	ID           string `json:"id"`
+	SessionToken string `json:"session_token"`
+	ExpiresAt    int64  `json:"expires_at"`
+}
+
+// walletUnlockHandler unlocks an encrypted wallet for a number of minutes.
+// The returned session token can be used instead of the password by
+// /api/v1/wallet/transaction, /api/v2/wallet/transaction/sign and /api/v1/wallet/newAddress
+// URI: /api/v2/wallet/unlock
+// Method: POST
+// Args:
+//  id: wallet id
+//  password: wallet password
+//  timeout: number of minutes to keep the wallet unlocked
+func walletUnlockHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req WalletUnlockRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		defer func() {
+			req.Password = ""
+		}()
+
+		if req.ID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "id is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.Timeout <= 0 {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "timeout must be > 0")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		s, err := gateway.UnlockWallet(req.ID, []byte(req.Password), time.Duration(req.Timeout)*time.Minute)
+		if err != nil {
+			var resp HTTPResponse
+			switch err {
+			case wallet.ErrWalletAPIDisabled:
+				resp = NewHTTPErrorResponse(http.StatusForbidden, "")
+			case wallet.ErrWalletNotExist:
+				resp = NewHTTPErrorResponse(http.StatusNotFound, "")
+			default:
+				switch err.(type) {
+				case wallet.Error:
+					resp = NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+				default:
+					resp = NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+				}
+			}
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: WalletSessionResponse{
+				ID:           s.WalletID,
+				SessionToken: s.Token,
+				ExpiresAt:    s.ExpiresAt.Unix(),
+			},
+		})
+	}
+}
+
+// WalletLockRequest is the request data for POST /api/v2/wallet/lock
+type WalletLockRequest struct {
+	ID string `json:"id"`
+}
+
+// walletLockHandler ends all unlocked sessions of a wallet
+// URI: /api/v2/wallet/lock
+// Method: POST
+// Args:
+//  id: wallet id
+func walletLockHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req WalletLockRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.ID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "id is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if err := gateway.LockWallet(req.ID); err != nil {
+			var resp HTTPResponse
+			switch err {
+			case wallet.ErrWalletAPIDisabled:
+				resp = NewHTTPErrorResponse(http.StatusForbidden, "")
+			case wallet.ErrWalletNotExist:
+				resp = NewHTTPErrorResponse(http.StatusNotFound, "")
+			default:
+				resp = NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			}
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{})
+	}
+}
+
+// parseAccountOption parses the optional "account" form value selecting a bip44 account
+func parseAccountOption(r *http.Request) ([]wallet.Option, error) {
+	account := r.FormValue("account")
+	if account == "" {
+		return nil, nil
+	}
+
+	n, err := strconv.ParseUint(account, 10, 32)
+	if err != nil {
+		return nil, errors.New("invalid account value")
+	}
+
+	return []wallet.Option{wallet.OptionAccount(uint32(n))}, nil
+}
+
+// checkAccountOption checks that the bip44 account selected by the options exists in the wallet
+func checkAccountOption(w wallet.Wallet, options ...wallet.Option) error {
+	if err := wallet.CheckAccountOption(w, options...); err != nil {
+		return err
+	}
+
+	if w.Type() != wallet.WalletTypeBip44 {
+		return nil
+	}
+
+	var opts wallet.Bip44EntriesOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	if opts.Account >= uint32(len(w.Accounts())) {
+		return wallet.ErrBip44AccountNotExist
+	}
+	return nil
+}
+
+// WalletAccountResponse is the bip44 account data returned by the wallet accounts endpoints
+type WalletAccountResponse struct {
+	Index uint32 `json:"index"`
+	Name  string `json:"name"`
+}
+
+// walletAccountsHandler returns the accounts of a bip44 wallet
+// URI: /api/v2/wallet/accounts
+// Method: GET
+// Args:
+//  id: wallet id
+func walletAccountsHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		wltID := r.FormValue("id")
+		if wltID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "id is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		accounts, err := gateway.GetBip44Accounts(wltID)
+		if err != nil {
+			writeHTTPResponse(w, walletAccountErrorResponse(err))
+			return
+		}
+
+		rsp := make([]WalletAccountResponse, len(accounts))
+		for i, a := range accounts {
+			rsp[i] = WalletAccountResponse{
+				Index: a.Index,
+				Name:  a.Name,
+			}
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: rsp,
+		})
+	}
+}
+
+// WalletAccountCreateRequest is the request data for POST /api/v2/wallet/accounts/create
+type WalletAccountCreateRequest struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+// walletAccountCreateHandler creates a new account in a bip44 wallet
+// URI: /api/v2/wallet/accounts/create
+// Method: POST
+// Args:
+//  id: wallet id
+//  name: account name
+//  password: wallet password [required if the wallet is encrypted]
+func walletAccountCreateHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req WalletAccountCreateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		defer func() {
+			req.Password = ""
+		}()
+
+		if req.ID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "id is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.Name == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "name is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		a, err := gateway.NewBip44Account(req.ID, req.Name, []byte(req.Password))
+		if err != nil {
+			writeHTTPResponse(w, walletAccountErrorResponse(err))
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: WalletAccountResponse{
+				Index: a.Index,
+				Name:  a.Name,
+			},
+		})
+	}
+}
+
+// WalletAccountRenameRequest is the request data for POST /api/v2/wallet/accounts/rename
+type WalletAccountRenameRequest struct {
+	ID      string `json:"id"`
+	Account uint32 `json:"account"`
+	Name    string `json:"name"`
+}
+
+// walletAccountRenameHandler renames an account of a bip44 wallet
+// URI: /api/v2/wallet/accounts/rename
+// Method: POST
+// Args:
+//  id: wallet id
+//  account: account index
+//  name: new account name
+func walletAccountRenameHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req WalletAccountRenameRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.ID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "id is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.Name == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "name is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if err := gateway.RenameBip44Account(req.ID, req.Account, req.Name); err != nil {
+			writeHTTPResponse(w, walletAccountErrorResponse(err))
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: WalletAccountResponse{
+				Index: req.Account,
+				Name:  req.Name,
+			},
+		})
+	}
+}
+
+// walletAccountErrorResponse maps errors of the wallet accounts endpoints to responses
+func walletAccountErrorResponse(err error) HTTPResponse {
+	switch err {
+	case wallet.ErrWalletAPIDisabled:
+		return NewHTTPErrorResponse(http.StatusForbidden, "")
+	case wallet.ErrWalletNotExist, wallet.ErrBip44AccountNotExist:
+		return NewHTTPErrorResponse(http.StatusNotFound, err.Error())
+	}
+
+	switch err.(type) {
+	case wallet.Error:
+		return NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+	default:
+		return NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+	}
+}
+
+// WalletDiscoveryResponse is the address discovery status of a wallet
+type WalletDiscoveryResponse struct {
+	ID       string `json:"id"`
+	GapLimit uint64 `json:"gap_limit"`
+	Running  bool   `json:"running"`
+	Scanned  uint64 `json:"scanned"`
+	Found    uint64 `json:"found"`
+	LastRun  int64  `json:"last_run"`
+	Error    string `json:"error,omitempty"`
+}
+
+// NewWalletDiscoveryResponse creates a WalletDiscoveryResponse from a wallet.DiscoveryStatus
+func NewWalletDiscoveryResponse(s *wallet.DiscoveryStatus) WalletDiscoveryResponse {
+	var lastRun int64
+	if !s.LastRun.IsZero() {
+		lastRun = s.LastRun.Unix()
+	}
+
+	return WalletDiscoveryResponse{
+		ID:       s.WalletID,
+		GapLimit: s.GapLimit,
+		Running:  s.Running,
+		Scanned:  s.Scanned,
+		Found:    s.Found,
+		LastRun:  lastRun,
+		Error:    s.Error,
+	}
+}
+
+// WalletDiscoverRequest is the request data for POST /api/v2/wallet/discovery
+type WalletDiscoverRequest struct {
+	ID       string `json:"id"`
+	GapLimit uint64 `json:"gap_limit,omitempty"`
+}
+
+// WalletDiscoverResponse is the response data for POST /api/v2/wallet/discovery
+type WalletDiscoverResponse struct {
+	Addresses []string                `json:"addresses"`
+	Status    WalletDiscoveryResponse `json:"status"`
+}
+
+// Dispatches /wallet/discovery endpoint.
+// Method: GET, POST
+// URI: /api/v2/wallet/discovery
+func walletDiscoveryHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			walletDiscoveryStatusHandler(w, r, gateway)
+		case http.MethodPost:
+			walletDiscoverHandler(w, r, gateway)
+		default:
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+		}
+	}
+}
+
+// Returns the address discovery status of a wallet
+// Args:
+//  id: wallet id
+func walletDiscoveryStatusHandler(w http.ResponseWriter, r *http.Request, gateway Gatewayer) {
+	wltID := r.FormValue("id")
+	if wltID == "" {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, "id is required")
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	s, err := gateway.GetDiscoveryStatus(wltID)
+	if err != nil {
+		writeHTTPResponse(w, walletDiscoveryErrorResponse(err))
+		return
+	}
+
+	writeHTTPResponse(w, HTTPResponse{
+		Data: NewWalletDiscoveryResponse(s),
+	})
+}
+
+// Runs the address discovery of a wallet, after updating its gap limit if provided
+// Args:
+//  id: wallet id
+//  gap_limit: number of unused addresses scanned ahead on each address chain [optional]
+func walletDiscoverHandler(w http.ResponseWriter, r *http.Request, gateway Gatewayer) {
+	var req WalletDiscoverRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	if req.ID == "" {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, "id is required")
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	if req.GapLimit != 0 {
+		if err := gateway.SetGapLimit(req.ID, req.GapLimit); err != nil {
+			writeHTTPResponse(w, walletDiscoveryErrorResponse(err))
+			return
+		}
+	}
+
+	addrs, err := gateway.DiscoverWalletAddresses(req.ID)
+	if err != nil {
+		writeHTTPResponse(w, walletDiscoveryErrorResponse(err))
+		return
+	}
+
+	s, err := gateway.GetDiscoveryStatus(req.ID)
+	if err != nil {
+		writeHTTPResponse(w, walletDiscoveryErrorResponse(err))
+		return
+	}
+
+	rsp := WalletDiscoverResponse{
+		Addresses: make([]string, len(addrs)),
+		Status:    NewWalletDiscoveryResponse(s),
+	}
+	for i, a := range addrs {
+		rsp.Addresses[i] = a.String()
+	}
+
+	writeHTTPResponse(w, HTTPResponse{
+		Data: rsp,
+	})
+}
+
+// walletDiscoveryErrorResponse maps errors of the wallet discovery endpoint to responses
+func walletDiscoveryErrorResponse(err error) HTTPResponse {
+	switch err {
+	case wallet.ErrWalletAPIDisabled:
+		return NewHTTPErrorResponse(http.StatusForbidden, "")
+	case wallet.ErrWalletNotExist:
+		return NewHTTPErrorResponse(http.StatusNotFound, err.Error())
+	}
+
+	switch err.(type) {
+	case wallet.Error:
+		return NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+	default:
+		return NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+	}
+}
+
+// WalletWatchRequest is the request data for POST /api/v2/wallet/watch
+type WalletWatchRequest struct {
+	ID        string   `json:"id"`
+	Addresses []string `json:"addresses"`
+	XPubs     []string `json:"xpubs"`
+}
+
+// WalletWatchResponse is the response data for POST /api/v2/wallet/watch
+type WalletWatchResponse struct {
+	Addresses []string `json:"addresses"`
+}
+
+// walletWatchHandler adds addresses and xpub keys to a watch-only wallet.
+// The first address of each xpub key is generated, further addresses are found by address discovery.
+// URI: /api/v2/wallet/watch
+// Method: POST
+// Args:
+//  id: wallet id
+//  addresses: addresses to watch [optional]
+//  xpubs: xpub keys to watch [optional]
+func walletWatchHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req WalletWatchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.ID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "id is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if len(req.Addresses) == 0 && len(req.XPubs) == 0 {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "addresses or xpubs is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		addrs, err := parseWatchAddresses(req.Addresses)
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		added, err := gateway.AddWatchAddresses(req.ID, addrs, req.XPubs)
+		if err != nil {
+			writeHTTPResponse(w, walletWatchErrorResponse(err))
+			return
+		}
+
+		rsp := WalletWatchResponse{
+			Addresses: make([]string, len(added)),
+		}
+		for i, a := range added {
+			rsp.Addresses[i] = a.String()
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: rsp,
+		})
+	}
+}
+
+// walletWatchErrorResponse maps errors of the watch-only wallet endpoints to responses
+func walletWatchErrorResponse(err error) HTTPResponse {
+	switch err {
+	case wallet.ErrWalletAPIDisabled:
+		return NewHTTPErrorResponse(http.StatusForbidden, "")
+	case wallet.ErrWalletNotExist:
+		return NewHTTPErrorResponse(http.StatusNotFound, err.Error())
+	}
+
+	switch err.(type) {
+	case wallet.Error:
+		return NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+	default:
+		return NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+	}
+}
+
 // Unloads wallet from the wallet service
 // URI: /api/v1/wallet/unload
 // Method: POST
@@ -1085,10 +1923,11 @@ func walletDecryptHandler(gateway Gatewayer) http.HandlerFunc {
 
 // WalletRecoverRequest is the request data for POST /api/v2/wallet/recover
 type WalletRecoverRequest struct {
-	ID             string `json:"id"`
-	Seed           string `json:"seed"`
-	SeedPassphrase string `json:"seed_passphrase"`
-	Password       string `json:"password"`
+	ID             string   `json:"id"`
+	Seed           string   `json:"seed"`
+	SeedShares     []string `json:"seed_shares,omitempty"`
+	SeedPassphrase string   `json:"seed_passphrase"`
+	Password       string   `json:"password"`
 }
 
 // URI: /api/v2/wallet/recover
@@ -1096,6 +1935,7 @@ type WalletRecoverRequest struct {
 // Args:
 //  id: wallet id
 //  seed: wallet seed
+//  seed_shares: [optional] mnemonic shares of the wallet seed, used if seed is not provided
 //  password: [optional] new password
 // Recovers an encrypted wallet by providing the seed.
 // The first address will be generated from seed and compared to the first address
@@ -1123,6 +1963,22 @@ func walletRecoverHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
+		if req.Seed != "" && len(req.SeedShares) != 0 {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, wallet.ErrSeedAndSeedSharesSet.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if len(req.SeedShares) != 0 {
+			seed, err := wallet.CombineSeedShares(req.SeedShares)
+			if err != nil {
+				resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+				writeHTTPResponse(w, resp)
+				return
+			}
+			req.Seed = seed
+		}
+
 		if req.Seed == "" {
 			resp := NewHTTPErrorResponse(http.StatusBadRequest, "seed is required")
 			writeHTTPResponse(w, resp)
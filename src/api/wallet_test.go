@@ -11,6 +11,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"encoding/json"
 
@@ -20,12 +21,15 @@ import (
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/cipher/bip39"
 	"github.com/skycoin/skycoin/src/cipher/crypto"
+	"github.com/skycoin/skycoin/src/cipher/shamir"
 	"github.com/skycoin/skycoin/src/coin"
 	"github.com/skycoin/skycoin/src/readable"
 	"github.com/skycoin/skycoin/src/testutil"
 	"github.com/skycoin/skycoin/src/visor"
 	"github.com/skycoin/skycoin/src/wallet"
+	"github.com/skycoin/skycoin/src/wallet/bip44wallet"
 	"github.com/skycoin/skycoin/src/wallet/deterministic"
+	"github.com/skycoin/skycoin/src/wallet/watchwallet"
 )
 
 func TestGetBalanceHandler(t *testing.T) {
@@ -377,6 +381,7 @@ func TestWalletBalanceHandler(t *testing.T) {
 		status                        int
 		err                           string
 		walletID                      string
+		account                       string
 		gatewayGetWalletBalanceResult balanceResult
 		gatewayBalanceErr             error
 		result                        *readable.BalancePair
@@ -439,6 +444,41 @@ func TestWalletBalanceHandler(t *testing.T) {
 			gatewayGetWalletBalanceResult: balanceResult{},
 			gatewayBalanceErr:             wallet.ErrWalletAPIDisabled,
 		},
+		{
+			name:   "400 - invalid account",
+			method: http.MethodGet,
+			body: &httpBody{
+				WalletID: "foo",
+			},
+			account:  "x",
+			status:   http.StatusBadRequest,
+			err:      "400 Bad Request - invalid account value",
+			walletID: "foo",
+		},
+		{
+			name:   "400 - account of non bip44 wallet",
+			method: http.MethodGet,
+			body: &httpBody{
+				WalletID: "foo",
+			},
+			account:           "1",
+			status:            http.StatusBadRequest,
+			err:               "400 Bad Request - wallet is not a bip44 wallet",
+			walletID:          "foo",
+			gatewayBalanceErr: wallet.ErrWalletNotBip44,
+		},
+		{
+			name:   "400 - account does not exist",
+			method: http.MethodGet,
+			body: &httpBody{
+				WalletID: "foo",
+			},
+			account:           "2",
+			status:            http.StatusBadRequest,
+			err:               "400 Bad Request - bip44 account doesn't exist",
+			walletID:          "foo",
+			gatewayBalanceErr: wallet.ErrBip44AccountNotExist,
+		},
 		{
 			name:   "200 - OK",
 			method: http.MethodGet,
@@ -450,13 +490,43 @@ func TestWalletBalanceHandler(t *testing.T) {
 			walletID: "foo",
 			result:   &readable.BalancePair{},
 		},
+		{
+			name:   "200 - OK account",
+			method: http.MethodGet,
+			body: &httpBody{
+				WalletID: "foo",
+			},
+			account:  "1",
+			status:   http.StatusOK,
+			err:      "",
+			walletID: "foo",
+			gatewayGetWalletBalanceResult: balanceResult{
+				BalancePair: wallet.BalancePair{
+					Confirmed: wallet.Balance{Coins: 1e6, Hours: 2},
+					Predicted: wallet.Balance{Coins: 1e6, Hours: 2},
+				},
+			},
+			result: &readable.BalancePair{
+				Confirmed: readable.Balance{Coins: 1e6, Hours: 2},
+				Predicted: readable.Balance{Coins: 1e6, Hours: 2},
+			},
+		},
 	}
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
 			gateway := &MockGatewayer{}
-			gateway.On("GetWalletBalance", tc.walletID).Return(tc.gatewayGetWalletBalanceResult.BalancePair,
-				tc.gatewayGetWalletBalanceResult.Addresses, tc.gatewayBalanceErr)
+			if tc.account != "" {
+				gateway.On("GetWalletBalance", tc.walletID, mock.MatchedBy(func(opt wallet.Option) bool {
+					var opts wallet.Bip44EntriesOptions
+					opt(&opts)
+					return fmt.Sprint(opts.Account) == tc.account
+				})).Return(tc.gatewayGetWalletBalanceResult.BalancePair,
+					tc.gatewayGetWalletBalanceResult.Addresses, tc.gatewayBalanceErr)
+			} else {
+				gateway.On("GetWalletBalance", tc.walletID).Return(tc.gatewayGetWalletBalanceResult.BalancePair,
+					tc.gatewayGetWalletBalanceResult.Addresses, tc.gatewayBalanceErr)
+			}
 
 			endpoint := "/api/v1/wallet/balance"
 
@@ -466,6 +536,9 @@ func TestWalletBalanceHandler(t *testing.T) {
 					v.Add("id", tc.body.WalletID)
 				}
 			}
+			if tc.account != "" {
+				v.Add("account", tc.account)
+			}
 			if len(v) > 0 {
 				endpoint += "?" + v.Encode()
 			}
@@ -873,6 +946,18 @@ func TestWalletTransactionsHandler(t *testing.T) {
 
 func TestWalletCreateHandler(t *testing.T) {
 	_, responseEntries := makeEntries([]byte("seed"), 5)
+
+	watchAddr := testutil.MakeAddress()
+	watchXPub := "xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8"
+	watchWlt, err := watchwallet.NewWallet("filename", "bar",
+		wallet.OptionWatchAddresses([]cipher.Addresser{watchAddr}),
+		wallet.OptionWatchXPubs([]string{watchXPub}))
+	require.NoError(t, err)
+	watchEntries, err := watchWlt.GetEntries()
+	require.NoError(t, err)
+	require.Len(t, watchEntries, 2)
+	var watchChildNumber uint32
+
 	type httpBody struct {
 		Seed           string
 		Label          string
@@ -883,6 +968,10 @@ func TestWalletCreateHandler(t *testing.T) {
 		SeedPassphrase string
 		Bip44Coin      string
 		XPub           string
+		SeedShares     string
+		GapLimit       string
+		Addresses      string
+		XPubs          string
 	}
 	tt := []struct {
 		name                      string
@@ -1289,6 +1378,158 @@ func TestWalletCreateHandler(t *testing.T) {
 				Entries: []readable.WalletEntry{},
 			},
 		},
+		{
+			name:   "200 - OK - seed shares",
+			method: http.MethodPost,
+			body: &httpBody{
+				Type:       wallet.WalletTypeDeterministic,
+				SeedShares: "share one, share two",
+				Label:      "bar",
+				ScanN:      "2",
+			},
+			status:  http.StatusOK,
+			err:     "",
+			wltName: "filename",
+			options: wallet.Options{
+				Type:       wallet.WalletTypeDeterministic,
+				Label:      "bar",
+				SeedShares: []string{"share one", "share two"},
+				Password:   []byte{},
+				ScanN:      2,
+			},
+			gatewayCreateWalletResult: func(_ string, _ wallet.Options) wallet.Wallet {
+				return &deterministic.Wallet{
+					Meta: wallet.Meta{
+						"filename": "filename",
+					},
+				}
+			},
+			responseBody: WalletResponse{
+				Meta: readable.WalletMeta{
+					Filename: "filename",
+				},
+				Entries: []readable.WalletEntry{},
+			},
+		},
+		{
+			name:   "200 - OK - gap limit",
+			method: http.MethodPost,
+			body: &httpBody{
+				Type:     wallet.WalletTypeDeterministic,
+				Seed:     "foo",
+				Label:    "bar",
+				GapLimit: "50",
+			},
+			status:  http.StatusOK,
+			err:     "",
+			wltName: "filename",
+			options: wallet.Options{
+				Type:     wallet.WalletTypeDeterministic,
+				Label:    "bar",
+				Seed:     "foo",
+				Password: []byte{},
+				GapLimit: 50,
+			},
+			gatewayCreateWalletResult: func(_ string, _ wallet.Options) wallet.Wallet {
+				return &deterministic.Wallet{
+					Meta: wallet.Meta{
+						"filename": "filename",
+					},
+				}
+			},
+			responseBody: WalletResponse{
+				Meta: readable.WalletMeta{
+					Filename: "filename",
+				},
+				Entries: []readable.WalletEntry{},
+			},
+		},
+		{
+			name:   "400 Bad request - invalid gap limit",
+			method: http.MethodPost,
+			body: &httpBody{
+				Type:     wallet.WalletTypeDeterministic,
+				Seed:     "foo",
+				Label:    "bar",
+				GapLimit: "0",
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid gap-limit value",
+		},
+		{
+			name:   "200 - OK - watch-only",
+			method: http.MethodPost,
+			body: &httpBody{
+				Type:      wallet.WalletTypeWatchOnly,
+				Label:     "bar",
+				Addresses: watchAddr.String(),
+				XPubs:     watchXPub,
+			},
+			status:  http.StatusOK,
+			wltName: "filename",
+			options: wallet.Options{
+				Type:           wallet.WalletTypeWatchOnly,
+				Label:          "bar",
+				Password:       []byte{},
+				WatchAddresses: []cipher.Addresser{watchAddr},
+				WatchXPubs:     []string{watchXPub},
+			},
+			gatewayCreateWalletResult: func(_ string, _ wallet.Options) wallet.Wallet {
+				return watchWlt
+			},
+			responseBody: WalletResponse{
+				Meta: readable.WalletMeta{
+					Coin:      wallet.CoinTypeSkycoin,
+					Filename:  "filename",
+					Label:     "bar",
+					Type:      wallet.WalletTypeWatchOnly,
+					Version:   wallet.Version,
+					Timestamp: watchWlt.Timestamp(),
+					XPubs:     []string{watchXPub},
+				},
+				Entries: []readable.WalletEntry{
+					{
+						Address: watchAddr.String(),
+					},
+					{
+						Address:     watchEntries[1].Address.String(),
+						Public:      watchEntries[1].Public.Hex(),
+						ChildNumber: &watchChildNumber,
+					},
+				},
+			},
+		},
+		{
+			name:   "400 Bad request - invalid watch address",
+			method: http.MethodPost,
+			body: &httpBody{
+				Type:      wallet.WalletTypeWatchOnly,
+				Label:     "bar",
+				Addresses: "badaddr",
+			},
+			status: http.StatusBadRequest,
+			err:    `400 Bad Request - invalid address "badaddr": Invalid address length`,
+		},
+		{
+			name:   "400 Bad request - missing watch addresses",
+			method: http.MethodPost,
+			body: &httpBody{
+				Type:  wallet.WalletTypeWatchOnly,
+				Label: "bar",
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - missing watch addresses or xpub keys",
+			options: wallet.Options{
+				Type:     wallet.WalletTypeWatchOnly,
+				Label:    "bar",
+				Password: []byte{},
+			},
+			gatewayCreateWalletErr: wallet.ErrMissingWatchAddresses,
+			gatewayCreateWalletResult: func(_ string, _ wallet.Options) wallet.Wallet {
+				var p *watchwallet.Wallet
+				return p
+			},
+		},
 		{
 			name:   "400 Bad request - encrypt without password",
 			method: http.MethodPost,
@@ -1340,6 +1581,14 @@ func TestWalletCreateHandler(t *testing.T) {
 					v.Add("seed-passphrase", tc.body.SeedPassphrase)
 				}
 
+				if tc.body.SeedShares != "" {
+					v.Add("seed-shares", tc.body.SeedShares)
+				}
+
+				if tc.body.GapLimit != "" {
+					v.Add("gap-limit", tc.body.GapLimit)
+				}
+
 				if tc.body.Bip44Coin != "" {
 					v.Add("bip44-coin", tc.body.Bip44Coin)
 				}
@@ -1347,6 +1596,14 @@ func TestWalletCreateHandler(t *testing.T) {
 				if tc.body.XPub != "" {
 					v.Add("xpub", tc.body.XPub)
 				}
+
+				if tc.body.Addresses != "" {
+					v.Add("addresses", tc.body.Addresses)
+				}
+
+				if tc.body.XPubs != "" {
+					v.Add("xpubs", tc.body.XPubs)
+				}
 			}
 
 			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(v.Encode()))
@@ -1791,9 +2048,11 @@ func TestGetWalletSeed(t *testing.T) {
 
 func TestWalletNewAddressesHandler(t *testing.T) {
 	type httpBody struct {
-		ID       string
-		Num      string
-		Password string
+		ID           string
+		Num          string
+		Password     string
+		SessionToken string
+		Account      string
 	}
 	type Addresses struct {
 		Address []string `json:"addresses"`
@@ -1820,6 +2079,7 @@ func TestWalletNewAddressesHandler(t *testing.T) {
 		walletID                  string
 		n                         uint64
 		password                  string
+		sessionToken              string
 		gatewayNewAddressesResult []cipher.Address
 		gatewayNewAddressesErr    error
 		responseBody              Addresses
@@ -1952,6 +2212,86 @@ func TestWalletNewAddressesHandler(t *testing.T) {
 			gatewayNewAddressesResult: emptyAddrs,
 			responseBody:              responseEmptyAddresses,
 		},
+		{
+			name:   "400 - password and session token",
+			method: http.MethodPost,
+			body: &httpBody{
+				ID:           "foo",
+				SessionToken: "token",
+			},
+			password: "pwd",
+			status:   http.StatusBadRequest,
+			err:      "400 Bad Request - password and session-token can not be combined",
+		},
+		{
+			name:   "400 - session not found",
+			method: http.MethodPost,
+			body: &httpBody{
+				ID:           "foo",
+				Num:          "1",
+				SessionToken: "token",
+			},
+			status:                 http.StatusBadRequest,
+			err:                    "400 Bad Request - wallet session not found or expired",
+			walletID:               "foo",
+			n:                      1,
+			sessionToken:           "token",
+			gatewayNewAddressesErr: wallet.ErrSessionNotFound,
+		},
+		{
+			name:   "200 - OK - session token",
+			method: http.MethodPost,
+			body: &httpBody{
+				ID:           "foo",
+				Num:          "1",
+				SessionToken: "token",
+			},
+			status:                    http.StatusOK,
+			walletID:                  "foo",
+			n:                         1,
+			sessionToken:              "token",
+			gatewayNewAddressesResult: addrs,
+			responseBody:              responseAddresses,
+		},
+		{
+			name:   "400 - invalid account",
+			method: http.MethodPost,
+			body: &httpBody{
+				ID:      "foo",
+				Num:     "1",
+				Account: "foo",
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid account value",
+		},
+		{
+			name:   "400 - account does not exist",
+			method: http.MethodPost,
+			body: &httpBody{
+				ID:      "foo",
+				Num:     "1",
+				Account: "2",
+			},
+			status:                 http.StatusBadRequest,
+			err:                    "400 Bad Request - bip44 account doesn't exist",
+			walletID:               "foo",
+			n:                      1,
+			gatewayNewAddressesErr: wallet.ErrBip44AccountNotExist,
+		},
+		{
+			name:   "200 - OK - account",
+			method: http.MethodPost,
+			body: &httpBody{
+				ID:      "foo",
+				Num:     "1",
+				Account: "1",
+			},
+			status:                    http.StatusOK,
+			walletID:                  "foo",
+			n:                         1,
+			gatewayNewAddressesResult: addrs,
+			responseBody:              responseAddresses,
+		},
 		{
 			name:   "200 - OK - CSRF disabled",
 			method: http.MethodPost,
@@ -1975,8 +2315,20 @@ func TestWalletNewAddressesHandler(t *testing.T) {
 				return tc.n == n
 			})
 			gateway := &MockGatewayer{}
-			gateway.On("NewAddresses", tc.walletID,
-				[]byte(tc.password), mb).Return(tc.gatewayNewAddressesResult, tc.gatewayNewAddressesErr)
+			if tc.body != nil && tc.body.Account != "" {
+				account := mock.MatchedBy(func(option wallet.Option) bool {
+					var opts wallet.Bip44EntriesOptions
+					option(&opts)
+					return fmt.Sprint(opts.Account) == tc.body.Account
+				})
+				gateway.On("NewAddresses", tc.walletID,
+					[]byte(tc.password), account, mb).Return(tc.gatewayNewAddressesResult, tc.gatewayNewAddressesErr)
+			} else {
+				gateway.On("NewAddresses", tc.walletID,
+					[]byte(tc.password), mb).Return(tc.gatewayNewAddressesResult, tc.gatewayNewAddressesErr)
+			}
+			gateway.On("NewAddressesWithSession", tc.walletID,
+				tc.sessionToken, mb).Return(tc.gatewayNewAddressesResult, tc.gatewayNewAddressesErr)
 
 			endpoint := "/api/v1/wallet/newAddress"
 
@@ -1988,6 +2340,15 @@ func TestWalletNewAddressesHandler(t *testing.T) {
 				if tc.body.Num != "" {
 					v.Add("num", tc.body.Num)
 				}
+				if tc.password != "" {
+					v.Add("password", tc.password)
+				}
+				if tc.body.SessionToken != "" {
+					v.Add("session-token", tc.body.SessionToken)
+				}
+				if tc.body.Account != "" {
+					v.Add("account", tc.body.Account)
+				}
 			}
 
 			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(v.Encode()))
@@ -3030,6 +3391,9 @@ func TestWalletRecover(t *testing.T) {
 	okWalletEncryptedResponse, err := NewWalletResponse(okWalletEncrypted)
 	require.NoError(t, err)
 
+	seedShares, err := wallet.SplitSeed("fooseed", 2, 3)
+	require.NoError(t, err)
+
 	cases := []struct {
 		name          string
 		method        string
@@ -3084,6 +3448,29 @@ func TestWalletRecover(t *testing.T) {
 			},
 			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "seed is required"),
 		},
+		{
+			name:        "seed and seed shares",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			httpBody: toJSON(t, WalletRecoverRequest{
+				ID:         "foo",
+				Seed:       "fooseed",
+				SeedShares: seedShares[:2],
+			}),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, wallet.ErrSeedAndSeedSharesSet.Error()),
+		},
+		{
+			name:        "not enough seed shares",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			httpBody: toJSON(t, WalletRecoverRequest{
+				ID:         "foo",
+				SeedShares: seedShares[:1],
+			}),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, shamir.ErrNotEnoughShares.Error()),
+		},
 		{
 			name:        "wallet not encrypted",
 			method:      http.MethodPost,
@@ -3204,6 +3591,23 @@ func TestWalletRecover(t *testing.T) {
 				Data: *okWalletEncryptedResponse,
 			},
 		},
+		{
+			name:        "ok, seed shares",
+			method:      http.MethodPost,
+			status:      http.StatusOK,
+			contentType: ContentTypeJSON,
+			req: &WalletRecoverRequest{
+				ID:         "foo",
+				SeedShares: []string{seedShares[2], seedShares[0]},
+				Password:   "foopassword",
+			},
+			gatewayReturn: gatewayReturnPair{
+				w: okWalletEncrypted,
+			},
+			httpResponse: HTTPResponse{
+				Data: *okWalletEncryptedResponse,
+			},
+		},
 	}
 
 	for _, tc := range cases {
@@ -3214,7 +3618,11 @@ func TestWalletRecover(t *testing.T) {
 				if tc.req.Password != "" {
 					password = []byte(tc.req.Password)
 				}
-				gateway.On("RecoverWallet", tc.req.ID, tc.req.Seed, tc.req.SeedPassphrase, password).Return(tc.gatewayReturn.w, tc.gatewayReturn.err)
+				seed := tc.req.Seed
+				if len(tc.req.SeedShares) != 0 {
+					seed = "fooseed"
+				}
+				gateway.On("RecoverWallet", tc.req.ID, seed, tc.req.SeedPassphrase, password).Return(tc.gatewayReturn.w, tc.gatewayReturn.err)
 			}
 
 			if tc.httpBody == "" && tc.req != nil {
@@ -3265,3 +3673,1373 @@ func TestWalletRecover(t *testing.T) {
 		})
 	}
 }
+
+func TestWalletSeedSplit(t *testing.T) {
+	shares, err := wallet.SplitSeed("fooseed", 2, 3)
+	require.NoError(t, err)
+
+	type gatewayReturnPair struct {
+		shares         []string
+		seedPassphrase string
+		err            error
+	}
+
+	cases := []struct {
+		name          string
+		method        string
+		status        int
+		req           *WalletSeedSplitRequest
+		httpBody      string
+		httpResponse  HTTPResponse
+		gatewayReturn gatewayReturnPair
+	}{
+		{
+			name:         "405",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+		{
+			name:         "400 - EOF",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "EOF"),
+		},
+		{
+			name:         "400 - missing id",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			httpBody:     toJSON(t, WalletSeedSplitRequest{Threshold: 2, Shares: 3}),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "id is required"),
+		},
+		{
+			name:         "400 - invalid threshold",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			httpBody:     toJSON(t, WalletSeedSplitRequest{ID: "foo.wlt", Shares: 3}),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "threshold must be > 0"),
+		},
+		{
+			name:         "400 - shares less than threshold",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			httpBody:     toJSON(t, WalletSeedSplitRequest{ID: "foo.wlt", Threshold: 3, Shares: 2}),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "shares must be >= threshold"),
+		},
+		{
+			name:   "400 - invalid password",
+			method: http.MethodPost,
+			status: http.StatusBadRequest,
+			req: &WalletSeedSplitRequest{
+				ID:        "foo.wlt",
+				Password:  "bar",
+				Threshold: 2,
+				Shares:    3,
+			},
+			gatewayReturn: gatewayReturnPair{
+				err: wallet.ErrInvalidPassword,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, wallet.ErrInvalidPassword.Error()),
+		},
+		{
+			name:   "403 - seed api disabled",
+			method: http.MethodPost,
+			status: http.StatusForbidden,
+			req: &WalletSeedSplitRequest{
+				ID:        "foo.wlt",
+				Password:  "pwd",
+				Threshold: 2,
+				Shares:    3,
+			},
+			gatewayReturn: gatewayReturnPair{
+				err: wallet.ErrSeedAPIDisabled,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusForbidden, ""),
+		},
+		{
+			name:   "404 - wallet does not exist",
+			method: http.MethodPost,
+			status: http.StatusNotFound,
+			req: &WalletSeedSplitRequest{
+				ID:        "foo.wlt",
+				Password:  "pwd",
+				Threshold: 2,
+				Shares:    3,
+			},
+			gatewayReturn: gatewayReturnPair{
+				err: wallet.ErrWalletNotExist,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, ""),
+		},
+		{
+			name:   "500 - other error",
+			method: http.MethodPost,
+			status: http.StatusInternalServerError,
+			req: &WalletSeedSplitRequest{
+				ID:        "foo.wlt",
+				Password:  "pwd",
+				Threshold: 2,
+				Shares:    3,
+			},
+			gatewayReturn: gatewayReturnPair{
+				err: errors.New("wallet error"),
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusInternalServerError, "wallet error"),
+		},
+		{
+			name:   "200",
+			method: http.MethodPost,
+			status: http.StatusOK,
+			req: &WalletSeedSplitRequest{
+				ID:        "foo.wlt",
+				Password:  "pwd",
+				Threshold: 2,
+				Shares:    3,
+			},
+			gatewayReturn: gatewayReturnPair{
+				shares:         shares,
+				seedPassphrase: "foopassphrase",
+			},
+			httpResponse: HTTPResponse{
+				Data: WalletSeedSharesResponse{
+					Threshold:      2,
+					Shares:         shares,
+					SeedPassphrase: "foopassphrase",
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			if tc.req != nil {
+				gateway.On("SplitWalletSeed", tc.req.ID, []byte(tc.req.Password), tc.req.Threshold, tc.req.Shares).Return(tc.gatewayReturn.shares, tc.gatewayReturn.seedPassphrase, tc.gatewayReturn.err)
+				tc.httpBody = toJSON(t, tc.req)
+			}
+
+			endpoint := "/api/v2/wallet/seed/split"
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(tc.httpBody))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", ContentTypeJSON)
+
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if rsp.Data == nil {
+				require.Nil(t, tc.httpResponse.Data)
+			} else {
+				require.NotNil(t, tc.httpResponse.Data)
+
+				var sharesRsp WalletSeedSharesResponse
+				err := json.Unmarshal(rsp.Data, &sharesRsp)
+				require.NoError(t, err)
+
+				require.Equal(t, tc.httpResponse.Data, sharesRsp)
+			}
+		})
+	}
+}
+
+func TestWalletSeedCombine(t *testing.T) {
+	seed := "chief stadium sniff exhibit ostrich exit fruit noodle good lava coin supply"
+	shares, err := wallet.SplitSeed(seed, 2, 3)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name         string
+		method       string
+		status       int
+		httpBody     string
+		httpResponse HTTPResponse
+	}{
+		{
+			name:         "405",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+		{
+			name:         "400 - EOF",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "EOF"),
+		},
+		{
+			name:         "400 - missing shares",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			httpBody:     "{}",
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "shares are required"),
+		},
+		{
+			name:   "422 - not enough shares",
+			method: http.MethodPost,
+			status: http.StatusUnprocessableEntity,
+			httpBody: toJSON(t, WalletSeedCombineRequest{
+				Shares: shares[:1],
+			}),
+			httpResponse: NewHTTPErrorResponse(http.StatusUnprocessableEntity, shamir.ErrNotEnoughShares.Error()),
+		},
+		{
+			name:   "422 - invalid share",
+			method: http.MethodPost,
+			status: http.StatusUnprocessableEntity,
+			httpBody: toJSON(t, WalletSeedCombineRequest{
+				Shares: []string{shares[0], seed},
+			}),
+			httpResponse: NewHTTPErrorResponse(http.StatusUnprocessableEntity, shamir.ErrInvalidMnemonicLength.Error()),
+		},
+		{
+			name:   "200",
+			method: http.MethodPost,
+			status: http.StatusOK,
+			httpBody: toJSON(t, WalletSeedCombineRequest{
+				Shares: []string{shares[2], shares[1]},
+			}),
+			httpResponse: HTTPResponse{
+				Data: WalletSeedResponse{
+					Seed: seed,
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			endpoint := "/api/v2/wallet/seed/combine"
+			gateway := &MockGatewayer{}
+
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(tc.httpBody))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", ContentTypeJSON)
+
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if rsp.Data == nil {
+				require.Nil(t, tc.httpResponse.Data)
+			} else {
+				require.NotNil(t, tc.httpResponse.Data)
+
+				var seedRsp WalletSeedResponse
+				err := json.Unmarshal(rsp.Data, &seedRsp)
+				require.NoError(t, err)
+
+				require.Equal(t, tc.httpResponse.Data, seedRsp)
+			}
+		})
+	}
+}
+
+func TestWalletUnlock(t *testing.T) {
+	expiresAt := time.Now().Add(time.Minute)
+
+	type gatewayReturnPair struct {
+		session *wallet.Session
+		err     error
+	}
+
+	cases := []struct {
+		name          string
+		method        string
+		status        int
+		req           *WalletUnlockRequest
+		httpBody      string
+		httpResponse  HTTPResponse
+		gatewayReturn gatewayReturnPair
+	}{
+		{
+			name:         "405",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+		{
+			name:         "400 - EOF",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "EOF"),
+		},
+		{
+			name:         "400 - missing id",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			httpBody:     toJSON(t, WalletUnlockRequest{Password: "pwd", Timeout: 10}),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "id is required"),
+		},
+		{
+			name:         "400 - missing timeout",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			httpBody:     toJSON(t, WalletUnlockRequest{ID: "foo.wlt", Password: "pwd"}),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "timeout must be > 0"),
+		},
+		{
+			name:   "400 - invalid password",
+			method: http.MethodPost,
+			status: http.StatusBadRequest,
+			req: &WalletUnlockRequest{
+				ID:       "foo.wlt",
+				Password: "bar",
+				Timeout:  10,
+			},
+			gatewayReturn: gatewayReturnPair{
+				err: wallet.ErrInvalidPassword,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, wallet.ErrInvalidPassword.Error()),
+		},
+		{
+			name:   "400 - invalid timeout",
+			method: http.MethodPost,
+			status: http.StatusBadRequest,
+			req: &WalletUnlockRequest{
+				ID:       "foo.wlt",
+				Password: "pwd",
+				Timeout:  1000,
+			},
+			gatewayReturn: gatewayReturnPair{
+				err: wallet.ErrInvalidSessionTimeout,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, wallet.ErrInvalidSessionTimeout.Error()),
+		},
+		{
+			name:   "403 - wallet api disabled",
+			method: http.MethodPost,
+			status: http.StatusForbidden,
+			req: &WalletUnlockRequest{
+				ID:       "foo.wlt",
+				Password: "pwd",
+				Timeout:  10,
+			},
+			gatewayReturn: gatewayReturnPair{
+				err: wallet.ErrWalletAPIDisabled,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusForbidden, ""),
+		},
+		{
+			name:   "404 - wallet does not exist",
+			method: http.MethodPost,
+			status: http.StatusNotFound,
+			req: &WalletUnlockRequest{
+				ID:       "foo.wlt",
+				Password: "pwd",
+				Timeout:  10,
+			},
+			gatewayReturn: gatewayReturnPair{
+				err: wallet.ErrWalletNotExist,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, ""),
+		},
+		{
+			name:   "200",
+			method: http.MethodPost,
+			status: http.StatusOK,
+			req: &WalletUnlockRequest{
+				ID:       "foo.wlt",
+				Password: "pwd",
+				Timeout:  10,
+			},
+			gatewayReturn: gatewayReturnPair{
+				session: &wallet.Session{
+					WalletID:  "foo.wlt",
+					Token:     "token",
+					ExpiresAt: expiresAt,
+				},
+			},
+			httpResponse: HTTPResponse{
+				Data: WalletSessionResponse{
+					ID:           "foo.wlt",
+					SessionToken: "token",
+					ExpiresAt:    expiresAt.Unix(),
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			if tc.req != nil {
+				gateway.On("UnlockWallet", tc.req.ID, []byte(tc.req.Password), time.Duration(tc.req.Timeout)*time.Minute).Return(tc.gatewayReturn.session, tc.gatewayReturn.err)
+				tc.httpBody = toJSON(t, tc.req)
+			}
+
+			endpoint := "/api/v2/wallet/unlock"
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(tc.httpBody))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", ContentTypeJSON)
+
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if rsp.Data == nil {
+				require.Nil(t, tc.httpResponse.Data)
+			} else {
+				require.NotNil(t, tc.httpResponse.Data)
+
+				var sessionRsp WalletSessionResponse
+				err := json.Unmarshal(rsp.Data, &sessionRsp)
+				require.NoError(t, err)
+
+				require.Equal(t, tc.httpResponse.Data, sessionRsp)
+			}
+		})
+	}
+}
+
+func TestWalletLock(t *testing.T) {
+	cases := []struct {
+		name         string
+		method       string
+		status       int
+		httpBody     string
+		walletID     string
+		gatewayErr   error
+		httpResponse HTTPResponse
+	}{
+		{
+			name:         "405",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+		{
+			name:         "400 - missing id",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			httpBody:     "{}",
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "id is required"),
+		},
+		{
+			name:         "403 - wallet api disabled",
+			method:       http.MethodPost,
+			status:       http.StatusForbidden,
+			walletID:     "foo.wlt",
+			gatewayErr:   wallet.ErrWalletAPIDisabled,
+			httpResponse: NewHTTPErrorResponse(http.StatusForbidden, ""),
+		},
+		{
+			name:         "404 - wallet does not exist",
+			method:       http.MethodPost,
+			status:       http.StatusNotFound,
+			walletID:     "foo.wlt",
+			gatewayErr:   wallet.ErrWalletNotExist,
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, ""),
+		},
+		{
+			name:         "200",
+			method:       http.MethodPost,
+			status:       http.StatusOK,
+			walletID:     "foo.wlt",
+			httpResponse: HTTPResponse{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			if tc.walletID != "" {
+				gateway.On("LockWallet", tc.walletID).Return(tc.gatewayErr)
+				tc.httpBody = toJSON(t, WalletLockRequest{ID: tc.walletID})
+			}
+
+			endpoint := "/api/v2/wallet/lock"
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(tc.httpBody))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", ContentTypeJSON)
+
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+			require.Nil(t, rsp.Data)
+		})
+	}
+}
+
+func TestWalletGetBip44Account(t *testing.T) {
+	w, err := bip44wallet.NewWallet("test.wlt", "test", bip39.MustNewDefaultMnemonic(), "")
+	require.NoError(t, err)
+	_, err = w.NewAccount("savings")
+	require.NoError(t, err)
+	addrs, err := w.GenerateAddresses(wallet.OptionAccount(1), wallet.OptionGenerateN(2))
+	require.NoError(t, err)
+
+	account0Addrs, err := w.GetAddresses(wallet.OptionExternal(), wallet.OptionChange())
+	require.NoError(t, err)
+	require.Len(t, account0Addrs, 2)
+
+	dw, err := deterministic.NewWallet("d.wlt", "test", "seed", wallet.OptionGenerateN(1))
+	require.NoError(t, err)
+
+	cases := []struct {
+		name      string
+		walletID  string
+		wallet    wallet.Wallet
+		account   string
+		status    int
+		err       string
+		addresses []string
+	}{
+		{
+			name:     "400 - invalid account",
+			walletID: "test.wlt",
+			wallet:   w,
+			account:  "-1",
+			status:   http.StatusBadRequest,
+			err:      "400 Bad Request - invalid account value",
+		},
+		{
+			name:     "400 - account does not exist",
+			walletID: "test.wlt",
+			wallet:   w,
+			account:  "2",
+			status:   http.StatusBadRequest,
+			err:      "400 Bad Request - bip44 account doesn't exist",
+		},
+		{
+			name:     "400 - account of non bip44 wallet",
+			walletID: "d.wlt",
+			wallet:   dw,
+			account:  "1",
+			status:   http.StatusBadRequest,
+			err:      "400 Bad Request - wallet is not a bip44 wallet",
+		},
+		{
+			name:      "200 - account 0",
+			walletID:  "test.wlt",
+			wallet:    w,
+			account:   "0",
+			status:    http.StatusOK,
+			addresses: []string{account0Addrs[0].String(), account0Addrs[1].String()},
+		},
+		{
+			name:      "200 - account 1",
+			walletID:  "test.wlt",
+			wallet:    w,
+			account:   "1",
+			status:    http.StatusOK,
+			addresses: []string{addrs[0].String(), addrs[1].String()},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			gateway.On("GetWallet", tc.walletID).Return(tc.wallet.Clone(), nil)
+
+			v := url.Values{}
+			v.Add("id", tc.walletID)
+			v.Add("account", tc.account)
+
+			req, err := http.NewRequest(http.MethodGet, "/api/v1/wallet?"+v.Encode(), nil)
+			require.NoError(t, err)
+
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			if tc.status != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()))
+				return
+			}
+
+			var rlt WalletResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &rlt))
+
+			var addresses []string
+			for _, e := range rlt.Entries {
+				addresses = append(addresses, e.Address)
+			}
+			require.Equal(t, tc.addresses, addresses)
+		})
+	}
+}
+
+func TestWalletAccounts(t *testing.T) {
+	cases := []struct {
+		name          string
+		method        string
+		walletID      string
+		status        int
+		gatewayReturn []wallet.Bip44Account
+		gatewayErr    error
+		httpResponse  HTTPResponse
+	}{
+		{
+			name:         "405",
+			method:       http.MethodPost,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+		{
+			name:         "400 - missing id",
+			method:       http.MethodGet,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "id is required"),
+		},
+		{
+			name:         "400 - not a bip44 wallet",
+			method:       http.MethodGet,
+			walletID:     "foo.wlt",
+			status:       http.StatusBadRequest,
+			gatewayErr:   wallet.ErrWalletNotBip44,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "wallet is not a bip44 wallet"),
+		},
+		{
+			name:         "403 - wallet api disabled",
+			method:       http.MethodGet,
+			walletID:     "foo.wlt",
+			status:       http.StatusForbidden,
+			gatewayErr:   wallet.ErrWalletAPIDisabled,
+			httpResponse: NewHTTPErrorResponse(http.StatusForbidden, ""),
+		},
+		{
+			name:         "404 - wallet does not exist",
+			method:       http.MethodGet,
+			walletID:     "foo.wlt",
+			status:       http.StatusNotFound,
+			gatewayErr:   wallet.ErrWalletNotExist,
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "wallet doesn't exist"),
+		},
+		{
+			name:     "200",
+			method:   http.MethodGet,
+			walletID: "foo.wlt",
+			status:   http.StatusOK,
+			gatewayReturn: []wallet.Bip44Account{
+				{Name: "default", Index: 0},
+				{Name: "savings", Index: 1},
+			},
+			httpResponse: HTTPResponse{
+				Data: []WalletAccountResponse{
+					{Name: "default", Index: 0},
+					{Name: "savings", Index: 1},
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			gateway.On("GetBip44Accounts", tc.walletID).Return(tc.gatewayReturn, tc.gatewayErr)
+
+			endpoint := "/api/v2/wallet/accounts"
+			if tc.walletID != "" {
+				endpoint += "?id=" + tc.walletID
+			}
+
+			req, err := http.NewRequest(tc.method, endpoint, nil)
+			require.NoError(t, err)
+
+			req.Header.Set("Content-Type", ContentTypeJSON)
+
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if rsp.Data == nil {
+				require.Nil(t, tc.httpResponse.Data)
+			} else {
+				require.NotNil(t, tc.httpResponse.Data)
+
+				var accounts []WalletAccountResponse
+				err := json.Unmarshal(rsp.Data, &accounts)
+				require.NoError(t, err)
+				require.Equal(t, tc.httpResponse.Data, accounts)
+			}
+		})
+	}
+}
+
+func TestWalletAccountCreate(t *testing.T) {
+	type gatewayReturnPair struct {
+		account *wallet.Bip44Account
+		err     error
+	}
+
+	cases := []struct {
+		name          string
+		method        string
+		status        int
+		req           *WalletAccountCreateRequest
+		httpBody      string
+		httpResponse  HTTPResponse
+		gatewayReturn gatewayReturnPair
+	}{
+		{
+			name:         "405",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+		{
+			name:         "400 - EOF",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "EOF"),
+		},
+		{
+			name:         "400 - missing id",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			httpBody:     toJSON(t, WalletAccountCreateRequest{Name: "savings"}),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "id is required"),
+		},
+		{
+			name:         "400 - missing name",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			httpBody:     toJSON(t, WalletAccountCreateRequest{ID: "foo.wlt"}),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "name is required"),
+		},
+		{
+			name:   "400 - name conflict",
+			method: http.MethodPost,
+			status: http.StatusBadRequest,
+			req:    &WalletAccountCreateRequest{ID: "foo.wlt", Name: "default"},
+			gatewayReturn: gatewayReturnPair{
+				err: wallet.ErrBip44AccountNameConflict,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "bip44 account name already exists"),
+		},
+		{
+			name:   "400 - invalid password",
+			method: http.MethodPost,
+			status: http.StatusBadRequest,
+			req:    &WalletAccountCreateRequest{ID: "foo.wlt", Name: "savings", Password: "bad"},
+			gatewayReturn: gatewayReturnPair{
+				err: wallet.ErrInvalidPassword,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "invalid password"),
+		},
+		{
+			name:   "403 - wallet api disabled",
+			method: http.MethodPost,
+			status: http.StatusForbidden,
+			req:    &WalletAccountCreateRequest{ID: "foo.wlt", Name: "savings"},
+			gatewayReturn: gatewayReturnPair{
+				err: wallet.ErrWalletAPIDisabled,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusForbidden, ""),
+		},
+		{
+			name:   "404 - wallet does not exist",
+			method: http.MethodPost,
+			status: http.StatusNotFound,
+			req:    &WalletAccountCreateRequest{ID: "foo.wlt", Name: "savings"},
+			gatewayReturn: gatewayReturnPair{
+				err: wallet.ErrWalletNotExist,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "wallet doesn't exist"),
+		},
+		{
+			name:   "200",
+			method: http.MethodPost,
+			status: http.StatusOK,
+			req:    &WalletAccountCreateRequest{ID: "foo.wlt", Name: "savings", Password: "pwd"},
+			gatewayReturn: gatewayReturnPair{
+				account: &wallet.Bip44Account{Name: "savings", Index: 1},
+			},
+			httpResponse: HTTPResponse{
+				Data: WalletAccountResponse{Name: "savings", Index: 1},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			if tc.req != nil {
+				gateway.On("NewBip44Account", tc.req.ID, tc.req.Name, []byte(tc.req.Password)).Return(tc.gatewayReturn.account, tc.gatewayReturn.err)
+				tc.httpBody = toJSON(t, *tc.req)
+			}
+
+			endpoint := "/api/v2/wallet/accounts/create"
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(tc.httpBody))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", ContentTypeJSON)
+
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if rsp.Data == nil {
+				require.Nil(t, tc.httpResponse.Data)
+			} else {
+				require.NotNil(t, tc.httpResponse.Data)
+
+				var account WalletAccountResponse
+				err := json.Unmarshal(rsp.Data, &account)
+				require.NoError(t, err)
+				require.Equal(t, tc.httpResponse.Data, account)
+			}
+		})
+	}
+}
+
+func TestWalletAccountRename(t *testing.T) {
+	cases := []struct {
+		name         string
+		method       string
+		status       int
+		req          *WalletAccountRenameRequest
+		httpBody     string
+		gatewayErr   error
+		httpResponse HTTPResponse
+	}{
+		{
+			name:         "405",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+		{
+			name:         "400 - missing id",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			httpBody:     toJSON(t, WalletAccountRenameRequest{Name: "savings"}),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "id is required"),
+		},
+		{
+			name:         "400 - missing name",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			httpBody:     toJSON(t, WalletAccountRenameRequest{ID: "foo.wlt"}),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "name is required"),
+		},
+		{
+			name:         "400 - not a bip44 wallet",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			req:          &WalletAccountRenameRequest{ID: "foo.wlt", Name: "savings"},
+			gatewayErr:   wallet.ErrWalletNotBip44,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "wallet is not a bip44 wallet"),
+		},
+		{
+			name:         "404 - account does not exist",
+			method:       http.MethodPost,
+			status:       http.StatusNotFound,
+			req:          &WalletAccountRenameRequest{ID: "foo.wlt", Account: 3, Name: "savings"},
+			gatewayErr:   wallet.ErrBip44AccountNotExist,
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "bip44 account doesn't exist"),
+		},
+		{
+			name:   "200",
+			method: http.MethodPost,
+			status: http.StatusOK,
+			req:    &WalletAccountRenameRequest{ID: "foo.wlt", Account: 1, Name: "savings"},
+			httpResponse: HTTPResponse{
+				Data: WalletAccountResponse{Name: "savings", Index: 1},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			if tc.req != nil {
+				gateway.On("RenameBip44Account", tc.req.ID, tc.req.Account, tc.req.Name).Return(tc.gatewayErr)
+				tc.httpBody = toJSON(t, *tc.req)
+			}
+
+			endpoint := "/api/v2/wallet/accounts/rename"
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(tc.httpBody))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", ContentTypeJSON)
+
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if rsp.Data == nil {
+				require.Nil(t, tc.httpResponse.Data)
+			} else {
+				require.NotNil(t, tc.httpResponse.Data)
+
+				var account WalletAccountResponse
+				err := json.Unmarshal(rsp.Data, &account)
+				require.NoError(t, err)
+				require.Equal(t, tc.httpResponse.Data, account)
+			}
+		})
+	}
+}
+
+func TestWalletDiscoveryStatus(t *testing.T) {
+	lastRun := time.Unix(1540000000, 0).UTC()
+
+	cases := []struct {
+		name          string
+		method        string
+		walletID      string
+		status        int
+		gatewayReturn *wallet.DiscoveryStatus
+		gatewayErr    error
+		httpResponse  HTTPResponse
+	}{
+		{
+			name:         "405",
+			method:       http.MethodPut,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+		{
+			name:         "400 - missing id",
+			method:       http.MethodGet,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "id is required"),
+		},
+		{
+			name:         "403 - wallet api disabled",
+			method:       http.MethodGet,
+			walletID:     "foo.wlt",
+			status:       http.StatusForbidden,
+			gatewayErr:   wallet.ErrWalletAPIDisabled,
+			httpResponse: NewHTTPErrorResponse(http.StatusForbidden, ""),
+		},
+		{
+			name:         "404 - wallet does not exist",
+			method:       http.MethodGet,
+			walletID:     "foo.wlt",
+			status:       http.StatusNotFound,
+			gatewayErr:   wallet.ErrWalletNotExist,
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "wallet doesn't exist"),
+		},
+		{
+			name:     "200 - never run",
+			method:   http.MethodGet,
+			walletID: "foo.wlt",
+			status:   http.StatusOK,
+			gatewayReturn: &wallet.DiscoveryStatus{
+				WalletID: "foo.wlt",
+				GapLimit: 20,
+			},
+			httpResponse: HTTPResponse{
+				Data: WalletDiscoveryResponse{
+					ID:       "foo.wlt",
+					GapLimit: 20,
+				},
+			},
+		},
+		{
+			name:     "200",
+			method:   http.MethodGet,
+			walletID: "foo.wlt",
+			status:   http.StatusOK,
+			gatewayReturn: &wallet.DiscoveryStatus{
+				WalletID: "foo.wlt",
+				GapLimit: 20,
+				Scanned:  60,
+				Found:    12,
+				LastRun:  lastRun,
+			},
+			httpResponse: HTTPResponse{
+				Data: WalletDiscoveryResponse{
+					ID:       "foo.wlt",
+					GapLimit: 20,
+					Scanned:  60,
+					Found:    12,
+					LastRun:  lastRun.Unix(),
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			gateway.On("GetDiscoveryStatus", tc.walletID).Return(tc.gatewayReturn, tc.gatewayErr)
+
+			endpoint := "/api/v2/wallet/discovery"
+			if tc.walletID != "" {
+				endpoint += "?id=" + tc.walletID
+			}
+
+			req, err := http.NewRequest(tc.method, endpoint, nil)
+			require.NoError(t, err)
+
+			req.Header.Set("Content-Type", ContentTypeJSON)
+
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if rsp.Data == nil {
+				require.Nil(t, tc.httpResponse.Data)
+			} else {
+				require.NotNil(t, tc.httpResponse.Data)
+
+				var s WalletDiscoveryResponse
+				err := json.Unmarshal(rsp.Data, &s)
+				require.NoError(t, err)
+				require.Equal(t, tc.httpResponse.Data, s)
+			}
+		})
+	}
+}
+
+func TestWalletDiscover(t *testing.T) {
+	addrs := []cipher.Address{
+		testutil.MakeAddress(),
+		testutil.MakeAddress(),
+	}
+
+	status := &wallet.DiscoveryStatus{
+		WalletID: "foo.wlt",
+		GapLimit: 50,
+		Scanned:  100,
+		Found:    2,
+		LastRun:  time.Unix(1540000000, 0).UTC(),
+	}
+
+	cases := []struct {
+		name           string
+		method         string
+		status         int
+		req            *WalletDiscoverRequest
+		httpBody       string
+		setGapLimitErr error
+		discoverResult []cipher.Address
+		discoverErr    error
+		httpResponse   HTTPResponse
+	}{
+		{
+			name:         "400 - missing id",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			httpBody:     toJSON(t, WalletDiscoverRequest{GapLimit: 50}),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "id is required"),
+		},
+		{
+			name:           "400 - invalid gap limit",
+			method:         http.MethodPost,
+			status:         http.StatusBadRequest,
+			req:            &WalletDiscoverRequest{ID: "foo.wlt", GapLimit: 50},
+			setGapLimitErr: wallet.ErrInvalidGapLimit,
+			httpResponse:   NewHTTPErrorResponse(http.StatusBadRequest, "gap limit must be > 0"),
+		},
+		{
+			name:         "403 - wallet api disabled",
+			method:       http.MethodPost,
+			status:       http.StatusForbidden,
+			req:          &WalletDiscoverRequest{ID: "foo.wlt"},
+			discoverErr:  wallet.ErrWalletAPIDisabled,
+			httpResponse: NewHTTPErrorResponse(http.StatusForbidden, ""),
+		},
+		{
+			name:         "404 - wallet does not exist",
+			method:       http.MethodPost,
+			status:       http.StatusNotFound,
+			req:          &WalletDiscoverRequest{ID: "foo.wlt"},
+			discoverErr:  wallet.ErrWalletNotExist,
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "wallet doesn't exist"),
+		},
+		{
+			name:         "500 - other error",
+			method:       http.MethodPost,
+			status:       http.StatusInternalServerError,
+			req:          &WalletDiscoverRequest{ID: "foo.wlt"},
+			discoverErr:  errors.New("db error"),
+			httpResponse: NewHTTPErrorResponse(http.StatusInternalServerError, "db error"),
+		},
+		{
+			name:           "200",
+			method:         http.MethodPost,
+			status:         http.StatusOK,
+			req:            &WalletDiscoverRequest{ID: "foo.wlt", GapLimit: 50},
+			discoverResult: addrs,
+			httpResponse: HTTPResponse{
+				Data: WalletDiscoverResponse{
+					Addresses: []string{addrs[0].String(), addrs[1].String()},
+					Status:    NewWalletDiscoveryResponse(status),
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			if tc.req != nil {
+				gateway.On("SetGapLimit", tc.req.ID, tc.req.GapLimit).Return(tc.setGapLimitErr)
+				gateway.On("DiscoverWalletAddresses", tc.req.ID).Return(tc.discoverResult, tc.discoverErr)
+				gateway.On("GetDiscoveryStatus", tc.req.ID).Return(status, nil)
+				tc.httpBody = toJSON(t, *tc.req)
+			}
+
+			endpoint := "/api/v2/wallet/discovery"
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(tc.httpBody))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", ContentTypeJSON)
+
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if rsp.Data == nil {
+				require.Nil(t, tc.httpResponse.Data)
+			} else {
+				require.NotNil(t, tc.httpResponse.Data)
+
+				var r WalletDiscoverResponse
+				err := json.Unmarshal(rsp.Data, &r)
+				require.NoError(t, err)
+				require.Equal(t, tc.httpResponse.Data, r)
+			}
+
+			if tc.req != nil && tc.req.GapLimit == 0 {
+				gateway.AssertNotCalled(t, "SetGapLimit", tc.req.ID, tc.req.GapLimit)
+			}
+		})
+	}
+}
+
+func TestWalletWatch(t *testing.T) {
+	addrs := []cipher.Address{
+		testutil.MakeAddress(),
+		testutil.MakeAddress(),
+	}
+	xpubAddr := testutil.MakeAddress()
+	xpub := "xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8"
+
+	cases := []struct {
+		name         string
+		method       string
+		status       int
+		httpBody     string
+		addrs        []cipher.Address
+		xpubs        []string
+		watchResult  []cipher.Address
+		watchErr     error
+		httpResponse HTTPResponse
+	}{
+		{
+			name:         "405",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+		{
+			name:         "400 - missing id",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			httpBody:     toJSON(t, WalletWatchRequest{Addresses: []string{addrs[0].String()}}),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "id is required"),
+		},
+		{
+			name:         "400 - missing addresses and xpubs",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			httpBody:     toJSON(t, WalletWatchRequest{ID: "foo.wlt"}),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "addresses or xpubs is required"),
+		},
+		{
+			name:     "400 - invalid address",
+			method:   http.MethodPost,
+			status:   http.StatusBadRequest,
+			httpBody: toJSON(t, WalletWatchRequest{ID: "foo.wlt", Addresses: []string{"badaddr"}}),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest,
+				`invalid address "badaddr": Invalid address length`),
+		},
+		{
+			name:         "400 - not a watch-only wallet",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			httpBody:     toJSON(t, WalletWatchRequest{ID: "foo.wlt", Addresses: []string{addrs[0].String()}}),
+			addrs:        addrs[:1],
+			watchErr:     wallet.ErrWalletNotWatchOnly,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "wallet is not a watch-only wallet"),
+		},
+		{
+			name:         "403 - wallet api disabled",
+			method:       http.MethodPost,
+			status:       http.StatusForbidden,
+			httpBody:     toJSON(t, WalletWatchRequest{ID: "foo.wlt", Addresses: []string{addrs[0].String()}}),
+			addrs:        addrs[:1],
+			watchErr:     wallet.ErrWalletAPIDisabled,
+			httpResponse: NewHTTPErrorResponse(http.StatusForbidden, ""),
+		},
+		{
+			name:         "404 - wallet does not exist",
+			method:       http.MethodPost,
+			status:       http.StatusNotFound,
+			httpBody:     toJSON(t, WalletWatchRequest{ID: "foo.wlt", Addresses: []string{addrs[0].String()}}),
+			addrs:        addrs[:1],
+			watchErr:     wallet.ErrWalletNotExist,
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "wallet doesn't exist"),
+		},
+		{
+			name:         "500 - other error",
+			method:       http.MethodPost,
+			status:       http.StatusInternalServerError,
+			httpBody:     toJSON(t, WalletWatchRequest{ID: "foo.wlt", Addresses: []string{addrs[0].String()}}),
+			addrs:        addrs[:1],
+			watchErr:     errors.New("disk error"),
+			httpResponse: NewHTTPErrorResponse(http.StatusInternalServerError, "disk error"),
+		},
+		{
+			name:   "200",
+			method: http.MethodPost,
+			status: http.StatusOK,
+			httpBody: toJSON(t, WalletWatchRequest{
+				ID:        "foo.wlt",
+				Addresses: []string{addrs[0].String(), addrs[1].String()},
+				XPubs:     []string{xpub},
+			}),
+			addrs:       addrs,
+			xpubs:       []string{xpub},
+			watchResult: []cipher.Address{addrs[0], addrs[1], xpubAddr},
+			httpResponse: HTTPResponse{
+				Data: WalletWatchResponse{
+					Addresses: []string{addrs[0].String(), addrs[1].String(), xpubAddr.String()},
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			gateway.On("AddWatchAddresses", "foo.wlt", tc.addrs, tc.xpubs).Return(tc.watchResult, tc.watchErr)
+
+			endpoint := "/api/v2/wallet/watch"
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(tc.httpBody))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", ContentTypeJSON)
+
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if rsp.Data == nil {
+				require.Nil(t, tc.httpResponse.Data)
+			} else {
+				require.NotNil(t, tc.httpResponse.Data)
+
+				var r WalletWatchResponse
+				err := json.Unmarshal(rsp.Data, &r)
+				require.NoError(t, err)
+				require.Equal(t, tc.httpResponse.Data, r)
+			}
+		})
+	}
+}
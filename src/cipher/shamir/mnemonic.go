@@ -0,0 +1,188 @@
+package shamir
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"strings"
+
+	"github.com/skycoin/skycoin/src/cipher/bip39/wordlists"
+)
+
+// A share is encoded as a mnemonic sentence of words from the BIP39 english wordlist,
+// each word encoding 11 bits of the following big-endian payload:
+//
+//	identifier (2 bytes) | threshold (1 byte) | index (1 byte) | value length (1 byte) | value | checksum (4 bytes)
+//
+// The payload is zero padded at the end to a multiple of 11 bits.
+// The checksum is the first 4 bytes of SHA256(checksumCustomization || payload without checksum).
+
+const (
+	shareHeaderLength     = 5
+	shareChecksumLength   = 4
+	bitsPerWord           = 11
+	checksumCustomization = "skycoin-shamir"
+)
+
+var (
+	// ErrInvalidMnemonicLength is returned if a share mnemonic has an invalid number of words
+	ErrInvalidMnemonicLength = errors.New("invalid share mnemonic length")
+	// ErrUnknownWord is returned if a share mnemonic contains an unrecognized word
+	ErrUnknownWord = errors.New("share mnemonic contains an unrecognized word")
+	// ErrChecksumIncorrect is returned if the share mnemonic checksum is invalid
+	ErrChecksumIncorrect = errors.New("share mnemonic checksum incorrect")
+	// ErrInvalidPadding is returned if the share mnemonic padding bits are not zero
+	ErrInvalidPadding = errors.New("share mnemonic padding is invalid")
+
+	wordMap map[string]int
+)
+
+func init() {
+	wordMap = make(map[string]int, len(wordlists.English))
+	for i, w := range wordlists.English {
+		wordMap[w] = i
+	}
+}
+
+// Mnemonic encodes the share as a mnemonic sentence
+func (s Share) Mnemonic() string {
+	payload := make([]byte, 0, shareHeaderLength+len(s.Value)+shareChecksumLength)
+	var id [2]byte
+	binary.BigEndian.PutUint16(id[:], s.Identifier)
+	payload = append(payload, id[:]...)
+	payload = append(payload, s.Threshold, s.Index, byte(len(s.Value)))
+	payload = append(payload, s.Value...)
+	payload = append(payload, shareChecksum(payload)...)
+
+	words := make([]string, wordCount(len(payload)))
+	for i := range words {
+		words[i] = wordlists.English[readBits(payload, i*bitsPerWord, bitsPerWord)]
+	}
+
+	return strings.Join(words, " ")
+}
+
+// ShareFromMnemonic decodes a share from a mnemonic sentence
+func ShareFromMnemonic(mnemonic string) (Share, error) {
+	words := strings.Fields(mnemonic)
+
+	minWords := wordCount(shareHeaderLength + MinSecretLength + shareChecksumLength)
+	maxWords := wordCount(shareHeaderLength + MaxSecretLength + shareChecksumLength)
+	if len(words) < minWords || len(words) > maxWords {
+		return Share{}, ErrInvalidMnemonicLength
+	}
+
+	payload := make([]byte, (len(words)*bitsPerWord+7)/8)
+	for i, w := range words {
+		idx, ok := wordMap[strings.ToLower(w)]
+		if !ok {
+			return Share{}, ErrUnknownWord
+		}
+		writeBits(payload, i*bitsPerWord, bitsPerWord, idx)
+	}
+
+	valueLength := int(payload[4])
+	payloadLength := shareHeaderLength + valueLength + shareChecksumLength
+	if valueLength < MinSecretLength || payloadLength > len(payload) {
+		return Share{}, ErrInvalidMnemonicLength
+	}
+
+	// The words must hold exactly the payload, plus less than one word of zero padding
+	if wordCount(payloadLength) != len(words) {
+		return Share{}, ErrInvalidMnemonicLength
+	}
+	for _, b := range payload[payloadLength:] {
+		if b != 0 {
+			return Share{}, ErrInvalidPadding
+		}
+	}
+
+	checksumStart := payloadLength - shareChecksumLength
+	if !bytes.Equal(shareChecksum(payload[:checksumStart]), payload[checksumStart:payloadLength]) {
+		return Share{}, ErrChecksumIncorrect
+	}
+
+	return Share{
+		Identifier: binary.BigEndian.Uint16(payload[:2]),
+		Threshold:  payload[2],
+		Index:      payload[3],
+		Value:      copyBytes(payload[shareHeaderLength:checksumStart]),
+	}, nil
+}
+
+// SplitMnemonics splits secret into count shares encoded as mnemonic sentences,
+// threshold of which are required to recover it
+func SplitMnemonics(secret []byte, threshold, count int) ([]string, error) {
+	shares, err := Split(secret, threshold, count)
+	if err != nil {
+		return nil, err
+	}
+
+	mnemonics := make([]string, len(shares))
+	for i, s := range shares {
+		mnemonics[i] = s.Mnemonic()
+	}
+	return mnemonics, nil
+}
+
+// CombineMnemonics recovers the secret from shares encoded as mnemonic sentences
+func CombineMnemonics(mnemonics []string) ([]byte, error) {
+	shares := make([]Share, len(mnemonics))
+	for i, m := range mnemonics {
+		var err error
+		shares[i], err = ShareFromMnemonic(m)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return Combine(shares)
+}
+
+// ValidateMnemonic returns an error if the mnemonic is not a valid share
+func ValidateMnemonic(mnemonic string) error {
+	_, err := ShareFromMnemonic(mnemonic)
+	return err
+}
+
+func shareChecksum(b []byte) []byte {
+	h := sha256.New()
+	if _, err := h.Write([]byte(checksumCustomization)); err != nil {
+		panic(err)
+	}
+	if _, err := h.Write(b); err != nil {
+		panic(err)
+	}
+	return h.Sum(nil)[:shareChecksumLength]
+}
+
+// wordCount returns the number of words needed to encode n bytes
+func wordCount(n int) int {
+	return (n*8 + bitsPerWord - 1) / bitsPerWord
+}
+
+// readBits reads n bits starting from bit offset of b, as a big-endian integer.
+// Bits past the end of b are read as zero.
+func readBits(b []byte, offset, n int) int {
+	var v int
+	for i := offset; i < offset+n; i++ {
+		v <<= 1
+		if i/8 < len(b) && b[i/8]&(0x80>>uint(i%8)) != 0 {
+			v |= 1
+		}
+	}
+	return v
+}
+
+// writeBits writes the n low bits of v to b starting from bit offset, big-endian.
+// Bits past the end of b must be zero.
+func writeBits(b []byte, offset, n, v int) {
+	for i := 0; i < n; i++ {
+		if v&(1<<uint(n-1-i)) == 0 {
+			continue
+		}
+		pos := offset + i
+		b[pos/8] |= 0x80 >> uint(pos%8)
+	}
+}
@@ -0,0 +1,220 @@
+/*
+Package shamir implements Shamir's secret sharing over GF(256), in the style of SLIP-0039.
+
+A secret is split into N shares, any M of which can be combined to recover the secret.
+Following SLIP-0039, the polynomial is additionally constrained to pass through a digest
+share at x=254, so that combining shares can detect a wrong or tampered share set
+instead of silently returning garbage.
+
+Groups (two-level sharing) and the SLIP-0039 passphrase encryption of the master secret
+are not implemented. Shares are encoded as mnemonic sentences using the BIP39 english wordlist,
+see Share.Mnemonic.
+
+SLIP-0039 spec: https://github.com/satoshilabs/slips/blob/master/slip-0039.md
+*/
+package shamir
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+const (
+	// MinSecretLength is the minimum length of a secret, in bytes
+	MinSecretLength = 16
+	// MaxSecretLength is the maximum length of a secret, in bytes
+	MaxSecretLength = 255
+	// MaxShareCount is the maximum number of shares a secret can be split into
+	MaxShareCount = 16
+
+	digestLength = 4
+	digestIndex  = 254
+	secretIndex  = 255
+)
+
+var (
+	// ErrInvalidThreshold is returned if the threshold is 0 or greater than the share count
+	ErrInvalidThreshold = errors.New("threshold must be > 0 and <= share count")
+	// ErrInvalidShareCount is returned if the share count is 0 or greater than MaxShareCount
+	ErrInvalidShareCount = errors.New("share count must be > 0 and <= 16")
+	// ErrInvalidSecretLength is returned if the secret length is out of range
+	ErrInvalidSecretLength = errors.New("secret length must be >= 16 and <= 255 bytes")
+	// ErrNotEnoughShares is returned when combining fewer shares than the threshold
+	ErrNotEnoughShares = errors.New("not enough shares to recover the secret")
+	// ErrInvalidShareIndex is returned when combining a share whose index is not lower than MaxShareCount.
+	// Higher indexes include the x coordinates of the digest share and of the secret
+	ErrInvalidShareIndex = errors.New("share index must be < 16")
+	// ErrDuplicateShareIndex is returned when combining two shares with the same index
+	ErrDuplicateShareIndex = errors.New("duplicate share index")
+	// ErrShareSetMismatch is returned when combining shares that do not belong to the same share set
+	ErrShareSetMismatch = errors.New("shares do not belong to the same share set")
+	// ErrInvalidDigest is returned if the recovered secret does not match the digest share
+	ErrInvalidDigest = errors.New("invalid digest of the recovered secret, the shares are corrupted or mismatched")
+)
+
+// Share is one of the shares of a secret
+type Share struct {
+	// Identifier is a random value shared by all shares of the same secret
+	Identifier uint16
+	// Threshold is the number of shares required to recover the secret
+	Threshold byte
+	// Index is the x coordinate of the share
+	Index byte
+	// Value is the y coordinates of the share, one per byte of the secret
+	Value []byte
+}
+
+// Split splits secret into count shares, threshold of which are required to recover it
+func Split(secret []byte, threshold, count int) ([]Share, error) {
+	if count <= 0 || count > MaxShareCount {
+		return nil, ErrInvalidShareCount
+	}
+
+	if threshold <= 0 || threshold > count {
+		return nil, ErrInvalidThreshold
+	}
+
+	if len(secret) < MinSecretLength || len(secret) > MaxSecretLength {
+		return nil, ErrInvalidSecretLength
+	}
+
+	id := binary.BigEndian.Uint16(cipher.RandByte(2))
+
+	newShare := func(index int, value []byte) Share {
+		return Share{
+			Identifier: id,
+			Threshold:  byte(threshold),
+			Index:      byte(index),
+			Value:      value,
+		}
+	}
+
+	shares := make([]Share, 0, count)
+
+	// With a threshold of 1 the polynomial is constant, every share is the secret itself
+	if threshold == 1 {
+		for i := 0; i < count; i++ {
+			shares = append(shares, newShare(i, copyBytes(secret)))
+		}
+		return shares, nil
+	}
+
+	// The first threshold-2 shares are random, the remaining two degrees of freedom
+	// are fixed by the digest share and the secret
+	randomShareCount := threshold - 2
+	for i := 0; i < randomShareCount; i++ {
+		shares = append(shares, newShare(i, cipher.RandByte(len(secret))))
+	}
+
+	randomPart := cipher.RandByte(len(secret) - digestLength)
+	digestShare := append(createDigest(randomPart, secret), randomPart...)
+
+	basePoints := make([]Share, len(shares), threshold)
+	copy(basePoints, shares)
+	basePoints = append(basePoints, newShare(digestIndex, digestShare), newShare(secretIndex, secret))
+
+	for i := randomShareCount; i < count; i++ {
+		shares = append(shares, newShare(i, interpolate(basePoints, byte(i))))
+	}
+
+	return shares, nil
+}
+
+// Combine recovers the secret from shares. At least Threshold shares must be provided,
+// only the first Threshold shares are used.
+func Combine(shares []Share) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, ErrNotEnoughShares
+	}
+
+	first := shares[0]
+	if first.Threshold == 0 || int(first.Threshold) > MaxShareCount {
+		return nil, ErrInvalidThreshold
+	}
+	if len(first.Value) < MinSecretLength || len(first.Value) > MaxSecretLength {
+		return nil, ErrInvalidSecretLength
+	}
+
+	indexes := make(map[byte]struct{}, len(shares))
+	for _, s := range shares {
+		if s.Identifier != first.Identifier || s.Threshold != first.Threshold || len(s.Value) != len(first.Value) {
+			return nil, ErrShareSetMismatch
+		}
+
+		if int(s.Index) >= MaxShareCount {
+			return nil, ErrInvalidShareIndex
+		}
+
+		if _, ok := indexes[s.Index]; ok {
+			return nil, ErrDuplicateShareIndex
+		}
+		indexes[s.Index] = struct{}{}
+	}
+
+	threshold := int(first.Threshold)
+	if len(shares) < threshold {
+		return nil, ErrNotEnoughShares
+	}
+	shares = shares[:threshold]
+
+	if threshold == 1 {
+		return copyBytes(first.Value), nil
+	}
+
+	secret := interpolate(shares, secretIndex)
+	digestShare := interpolate(shares, digestIndex)
+
+	digest := createDigest(digestShare[digestLength:], secret)
+	if !hmac.Equal(digest, digestShare[:digestLength]) {
+		return nil, ErrInvalidDigest
+	}
+
+	return secret, nil
+}
+
+func createDigest(randomPart, secret []byte) []byte {
+	h := hmac.New(sha256.New, randomPart)
+	if _, err := h.Write(secret); err != nil {
+		panic(err)
+	}
+	return h.Sum(nil)[:digestLength]
+}
+
+// interpolate evaluates at x the polynomial passing through the points of shares,
+// using Lagrange interpolation over GF(256).
+// Each byte of the share values is treated as an independent polynomial.
+func interpolate(shares []Share, x byte) []byte {
+	for _, s := range shares {
+		if s.Index == x {
+			return copyBytes(s.Value)
+		}
+	}
+
+	result := make([]byte, len(shares[0].Value))
+	for i, si := range shares {
+		// basis is the lagrange basis polynomial l_i evaluated at x
+		basis := byte(1)
+		for j, sj := range shares {
+			if i == j {
+				continue
+			}
+			basis = gfMul(basis, gfDiv(x^sj.Index, si.Index^sj.Index))
+		}
+
+		for k, v := range si.Value {
+			result[k] ^= gfMul(v, basis)
+		}
+	}
+
+	return result
+}
+
+func copyBytes(b []byte) []byte {
+	c := make([]byte, len(b))
+	copy(c, b)
+	return c
+}
@@ -0,0 +1,245 @@
+package shamir
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/bip39/wordlists"
+)
+
+func TestGF256(t *testing.T) {
+	for a := 1; a < 256; a++ {
+		// a * a^-1 == 1
+		inv := gfDiv(1, byte(a))
+		require.Equal(t, byte(1), gfMul(byte(a), inv))
+
+		for b := 1; b < 256; b++ {
+			require.Equal(t, byte(a), gfDiv(gfMul(byte(a), byte(b)), byte(b)))
+		}
+	}
+
+	// Known products in the Rijndael field
+	require.Equal(t, byte(0xc1), gfMul(0x57, 0x83))
+	require.Equal(t, byte(0xfe), gfMul(0x57, 0x13))
+	require.Equal(t, byte(0), gfMul(0, 0x13))
+}
+
+func TestSplitCombine(t *testing.T) {
+	cases := []struct {
+		threshold int
+		count     int
+		secretLen int
+	}{
+		{1, 1, 16},
+		{1, 3, 16},
+		{2, 2, 16},
+		{2, 3, 32},
+		{3, 5, 17},
+		{5, 5, 64},
+		{7, 16, 255},
+	}
+
+	for _, tc := range cases {
+		name := fmt.Sprintf("%d-of-%d len=%d", tc.threshold, tc.count, tc.secretLen)
+		t.Run(name, func(t *testing.T) {
+			secret := cipher.RandByte(tc.secretLen)
+
+			shares, err := Split(secret, tc.threshold, tc.count)
+			require.NoError(t, err)
+			require.Len(t, shares, tc.count)
+
+			for i, s := range shares {
+				require.Equal(t, byte(i), s.Index)
+				require.Equal(t, byte(tc.threshold), s.Threshold)
+				require.Equal(t, shares[0].Identifier, s.Identifier)
+				require.Len(t, s.Value, tc.secretLen)
+			}
+
+			// Any threshold shares recover the secret, try a few windows in reverse order too
+			for start := 0; start+tc.threshold <= tc.count; start++ {
+				subset := make([]Share, tc.threshold)
+				copy(subset, shares[start:start+tc.threshold])
+				recovered, err := Combine(subset)
+				require.NoError(t, err)
+				require.Equal(t, secret, recovered)
+
+				for i, j := 0, len(subset)-1; i < j; i, j = i+1, j-1 {
+					subset[i], subset[j] = subset[j], subset[i]
+				}
+				recovered, err = Combine(subset)
+				require.NoError(t, err)
+				require.Equal(t, secret, recovered)
+			}
+
+			// More shares than the threshold are accepted
+			recovered, err := Combine(shares)
+			require.NoError(t, err)
+			require.Equal(t, secret, recovered)
+
+			if tc.threshold > 1 {
+				_, err = Combine(shares[:tc.threshold-1])
+				require.Equal(t, ErrNotEnoughShares, err)
+			}
+		})
+	}
+}
+
+func TestSplitInvalid(t *testing.T) {
+	secret := cipher.RandByte(16)
+
+	_, err := Split(secret, 1, 0)
+	require.Equal(t, ErrInvalidShareCount, err)
+
+	_, err = Split(secret, 1, MaxShareCount+1)
+	require.Equal(t, ErrInvalidShareCount, err)
+
+	_, err = Split(secret, 0, 3)
+	require.Equal(t, ErrInvalidThreshold, err)
+
+	_, err = Split(secret, 4, 3)
+	require.Equal(t, ErrInvalidThreshold, err)
+
+	_, err = Split(cipher.RandByte(15), 2, 3)
+	require.Equal(t, ErrInvalidSecretLength, err)
+
+	_, err = Split(cipher.RandByte(256), 2, 3)
+	require.Equal(t, ErrInvalidSecretLength, err)
+}
+
+func TestCombineInvalid(t *testing.T) {
+	secret := cipher.RandByte(32)
+	shares, err := Split(secret, 3, 5)
+	require.NoError(t, err)
+
+	_, err = Combine(nil)
+	require.Equal(t, ErrNotEnoughShares, err)
+
+	_, err = Combine([]Share{shares[0], shares[1], shares[1]})
+	require.Equal(t, ErrDuplicateShareIndex, err)
+
+	// Shares can't stand in for the digest share or the secret
+	for _, index := range []byte{MaxShareCount, digestIndex, secretIndex} {
+		s := shares[2]
+		s.Index = index
+		_, err = Combine([]Share{shares[0], shares[1], s})
+		require.Equal(t, ErrInvalidShareIndex, err)
+	}
+
+	otherShares, err := Split(secret, 3, 5)
+	require.NoError(t, err)
+	otherShares[2].Identifier = shares[0].Identifier + 1
+	_, err = Combine([]Share{shares[0], shares[1], otherShares[2]})
+	require.Equal(t, ErrShareSetMismatch, err)
+
+	// A share from another split with the same identifier fails the digest check
+	otherShares[2].Identifier = shares[0].Identifier
+	_, err = Combine([]Share{shares[0], shares[1], otherShares[2]})
+	require.Equal(t, ErrInvalidDigest, err)
+
+	// A corrupted share fails the digest check
+	corrupted := Share{
+		Identifier: shares[2].Identifier,
+		Threshold:  shares[2].Threshold,
+		Index:      shares[2].Index,
+		Value:      append([]byte{}, shares[2].Value...),
+	}
+	corrupted.Value[0] ^= 0x01
+	_, err = Combine([]Share{shares[0], shares[1], corrupted})
+	require.Equal(t, ErrInvalidDigest, err)
+}
+
+func TestMnemonic(t *testing.T) {
+	for _, n := range []int{MinSecretLength, 17, 32, 33, 100, MaxSecretLength} {
+		t.Run(fmt.Sprintf("len=%d", n), func(t *testing.T) {
+			secret := cipher.RandByte(n)
+			mnemonics, err := SplitMnemonics(secret, 2, 3)
+			require.NoError(t, err)
+			require.Len(t, mnemonics, 3)
+
+			for _, m := range mnemonics {
+				require.NoError(t, ValidateMnemonic(m))
+				require.Len(t, strings.Split(m, " "), wordCount(shareHeaderLength+n+shareChecksumLength))
+			}
+
+			recovered, err := CombineMnemonics(mnemonics[1:])
+			require.NoError(t, err)
+			require.Equal(t, secret, recovered)
+
+			// Extra whitespace and uppercase words are tolerated
+			s, err := ShareFromMnemonic("  " + strings.ToUpper(strings.Replace(mnemonics[0], " ", "\n  ", -1)) + " ")
+			require.NoError(t, err)
+			s2, err := ShareFromMnemonic(mnemonics[0])
+			require.NoError(t, err)
+			require.Equal(t, s2, s)
+			require.Equal(t, mnemonics[0], s.Mnemonic())
+		})
+	}
+}
+
+func TestMnemonicInvalid(t *testing.T) {
+	mnemonics, err := SplitMnemonics(cipher.RandByte(16), 2, 3)
+	require.NoError(t, err)
+	words := strings.Split(mnemonics[0], " ")
+
+	// Too short
+	err = ValidateMnemonic(strings.Join(words[:10], " "))
+	require.Equal(t, ErrInvalidMnemonicLength, err)
+
+	// Missing a word
+	err = ValidateMnemonic(strings.Join(words[:len(words)-1], " "))
+	require.Equal(t, ErrInvalidMnemonicLength, err)
+
+	// Extra word
+	err = ValidateMnemonic(strings.Join(append(words, wordlists.English[0]), " "))
+	require.Equal(t, ErrInvalidMnemonicLength, err)
+
+	// Unknown word
+	unknown := append([]string{}, words...)
+	unknown[3] = "skycoin"
+	err = ValidateMnemonic(strings.Join(unknown, " "))
+	require.Equal(t, ErrUnknownWord, err)
+
+	// Changed word
+	changed := append([]string{}, words...)
+	for _, w := range wordlists.English {
+		if w != changed[8] {
+			changed[8] = w
+			break
+		}
+	}
+	err = ValidateMnemonic(strings.Join(changed, " "))
+	require.Equal(t, ErrChecksumIncorrect, err)
+
+	// Non-zero padding bits in the last word
+	padded := append([]string{}, words...)
+	last := wordMap[padded[len(padded)-1]]
+	padded[len(padded)-1] = wordlists.English[last|1]
+	if padded[len(padded)-1] != words[len(words)-1] {
+		err = ValidateMnemonic(strings.Join(padded, " "))
+		require.Equal(t, ErrInvalidPadding, err)
+	}
+}
+
+func TestReadWriteBits(t *testing.T) {
+	b := cipher.RandByte(33)
+	out := make([]byte, len(b))
+	for i := 0; i < wordCount(len(b)); i++ {
+		v := readBits(b, i*bitsPerWord, bitsPerWord)
+		require.True(t, v < 1<<bitsPerWord)
+		if (i+1)*bitsPerWord > len(out)*8 {
+			// the last word is partially beyond the end of the buffer
+			tmp := make([]byte, len(out)+2)
+			copy(tmp, out)
+			writeBits(tmp, i*bitsPerWord, bitsPerWord, v)
+			copy(out, tmp)
+		} else {
+			writeBits(out, i*bitsPerWord, bitsPerWord, v)
+		}
+	}
+	require.True(t, bytes.Equal(b, out))
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/cipher/base58"
 	"github.com/skycoin/skycoin/src/cipher/bip32"
+	"github.com/skycoin/skycoin/src/cipher/shamir"
 	secp256k1 "github.com/skycoin/skycoin/src/cipher/secp256k1-go"
 )
 
@@ -525,3 +526,83 @@ func validateBip32KeyTestData(inputData *InputTestData, basePath string, seed []
 
 	return validateKeyTestData(inputData, secKey, data.KeysTestData)
 }
+
+// ShamirTestDataJSON contains a secret and the mnemonic shares it was split into
+type ShamirTestDataJSON struct {
+	Secret    string   `json:"secret"`
+	Threshold int      `json:"threshold"`
+	Shares    []string `json:"shares"`
+}
+
+// ShamirTestData contains a secret and the mnemonic shares it was split into
+type ShamirTestData struct {
+	Secret    []byte
+	Threshold int
+	Shares    []string
+}
+
+// ToJSON converts ShamirTestData to ShamirTestDataJSON
+func (s *ShamirTestData) ToJSON() *ShamirTestDataJSON {
+	return &ShamirTestDataJSON{
+		Secret:    hex.EncodeToString(s.Secret),
+		Threshold: s.Threshold,
+		Shares:    s.Shares,
+	}
+}
+
+// ShamirTestDataFromJSON converts ShamirTestDataJSON to ShamirTestData
+func ShamirTestDataFromJSON(d *ShamirTestDataJSON) (*ShamirTestData, error) {
+	secret, err := hex.DecodeString(d.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ShamirTestData{
+		Secret:    secret,
+		Threshold: d.Threshold,
+		Shares:    d.Shares,
+	}, nil
+}
+
+// ValidateShamirData validates the provided ShamirTestData against the current cipher library.
+// Every window of Threshold consecutive shares must recover the secret,
+// and fewer than Threshold shares must not.
+func ValidateShamirData(data *ShamirTestData) error {
+	if data.Threshold <= 0 || data.Threshold > len(data.Shares) {
+		return errors.New("threshold must be > 0 and <= the number of shares")
+	}
+
+	for _, m := range data.Shares {
+		s, err := shamir.ShareFromMnemonic(m)
+		if err != nil {
+			return fmt.Errorf("shamir.ShareFromMnemonic failed: %v", err)
+		}
+
+		if int(s.Threshold) != data.Threshold {
+			return errors.New("share threshold does not match threshold")
+		}
+
+		if s.Mnemonic() != m {
+			return errors.New("share mnemonic does not roundtrip")
+		}
+	}
+
+	for i := 0; i+data.Threshold <= len(data.Shares); i++ {
+		secret, err := shamir.CombineMnemonics(data.Shares[i : i+data.Threshold])
+		if err != nil {
+			return fmt.Errorf("shamir.CombineMnemonics failed: %v", err)
+		}
+
+		if !bytes.Equal(secret, data.Secret) {
+			return errors.New("recovered secret does not match secret")
+		}
+	}
+
+	if data.Threshold > 1 {
+		if _, err := shamir.CombineMnemonics(data.Shares[:data.Threshold-1]); err != shamir.ErrNotEnoughShares {
+			return errors.New("shamir.CombineMnemonics did not fail with fewer shares than the threshold")
+		}
+	}
+
+	return nil
+}
@@ -17,6 +17,7 @@ const (
 	inputHashesFilename   = "input-hashes.golden"
 	seedFileRegex         = `^seed-\d+.golden$`
 	bip32SeedFileRegex    = `^seed-bip32-\d+.golden$`
+	shamirFileRegex       = `^shamir-\d+.golden$`
 )
 
 func TestManyAddresses(t *testing.T) {
@@ -93,6 +94,28 @@ func TestBip32SeedSignatures(t *testing.T) {
 	}
 }
 
+func TestShamirShares(t *testing.T) {
+	shamirFiles, err := traverseFiles(testdataDir, shamirFileRegex)
+	require.NoError(t, err)
+	require.NotEmpty(t, shamirFiles)
+
+	for _, fn := range shamirFiles {
+		t.Run(fn, func(t *testing.T) {
+			fn = filepath.Join(testdataDir, fn)
+
+			var shamirDataJSON ShamirTestDataJSON
+			err := file.LoadJSON(fn, &shamirDataJSON)
+			require.NoError(t, err)
+
+			shamirData, err := ShamirTestDataFromJSON(&shamirDataJSON)
+			require.NoError(t, err)
+
+			err = ValidateShamirData(shamirData)
+			require.NoError(t, err)
+		})
+	}
+}
+
 func traverseFiles(dir string, filenameTemplate string) ([]string, error) { //nolint:unparam
 	files := make([]string, 0)
 	if err := filepath.Walk(dir, func(_ string, f os.FileInfo, _ error) error {
@@ -0,0 +1,182 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/spf13/cobra"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/params"
+	"github.com/skycoin/skycoin/src/util/apputil"
+	"github.com/skycoin/skycoin/src/visor"
+)
+
+func exportBlocksCmd() *cobra.Command {
+	exportBlocksCmd := &cobra.Command{
+		Short: "Export blocks to a blocks file",
+		Use:   "exportBlocks [db path]",
+		Long: `Writes the signed blocks of the given database file to a portable blocks file,
+    which can be imported with importBlocks or the node's -import-blocks option.
+    If no argument is specificed, the blocks of the default data.db in $HOME/.$COIN/ will be exported.`,
+		Args:         cobra.MaximumNArgs(1),
+		SilenceUsage: true,
+		RunE:         exportBlocks,
+	}
+
+	exportBlocksCmd.Flags().StringP("output", "o", "blocks.dat", "Output blocks file")
+	exportBlocksCmd.Flags().Uint64("start", 0, "First block seq to export")
+	exportBlocksCmd.Flags().Uint64("end", 0, "Last block seq to export. Defaults to the head block")
+
+	return exportBlocksCmd
+}
+
+func exportBlocks(c *cobra.Command, args []string) error {
+	output, err := c.Flags().GetString("output")
+	if err != nil {
+		return err
+	}
+
+	start, err := c.Flags().GetUint64("start")
+	if err != nil {
+		return err
+	}
+
+	end := uint64(math.MaxUint64)
+	if c.Flags().Changed("end") {
+		end, err = c.Flags().GetUint64("end")
+		if err != nil {
+			return err
+		}
+	}
+
+	// get db path
+	dbPath := ""
+	if len(args) > 0 {
+		dbPath = args[0]
+	}
+	dbPath, err = resolveDBPath(cliConfig, dbPath)
+	if err != nil {
+		return err
+	}
+
+	// check if this file exists
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return fmt.Errorf("db file: %v does not exist", dbPath)
+	}
+
+	db, err := bolt.Open(dbPath, 0600, &bolt.Options{
+		Timeout:  5 * time.Second,
+		ReadOnly: true,
+	})
+	if err != nil {
+		return fmt.Errorf("open db failed: %v", err)
+	}
+	defer db.Close()
+
+	pubkey, err := cipher.PubKeyFromHex(blockchainPubkey)
+	if err != nil {
+		return fmt.Errorf("decode blockchain pubkey failed: %v", err)
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	go func() {
+		apputil.CatchInterrupt(quitChan)
+	}()
+
+	w := bufio.NewWriter(f)
+	hdr, err := visor.ExportBlocks(wrapDB(db), pubkey, w, start, end, quitChan)
+	if err != nil {
+		return fmt.Errorf("export blocks failed: %v", err)
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+
+	fmt.Printf("exported blocks %d-%d to %s\n", hdr.StartSeq, hdr.EndSeq, output)
+	return nil
+}
+
+func importBlocksCmd() *cobra.Command {
+	return &cobra.Command{
+		Short: "Import blocks from a blocks file",
+		Use:   "importBlocks [blocks file] [db path]",
+		Long: `Executes the signed blocks of a blocks file created by exportBlocks in the given database file.
+    The signature of each block is verified. Blocks already in the database are skipped,
+    so an interrupted import is resumed by running it again.
+    If no db path is specificed, the blocks are imported into the default data.db in $HOME/.$COIN/.
+    The node must not be running.`,
+		Args:                  cobra.RangeArgs(1, 2),
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		RunE:                  importBlocks,
+	}
+}
+
+func importBlocks(_ *cobra.Command, args []string) error {
+	// get db path
+	dbPath := ""
+	if len(args) > 1 {
+		dbPath = args[1]
+	}
+	dbPath, err := resolveDBPath(cliConfig, dbPath)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	db, err := bolt.Open(dbPath, 0600, &bolt.Options{
+		Timeout: 5 * time.Second,
+	})
+	if err != nil {
+		return fmt.Errorf("open db failed: %v", err)
+	}
+	defer db.Close()
+
+	pubkey, err := cipher.PubKeyFromHex(blockchainPubkey)
+	if err != nil {
+		return fmt.Errorf("decode blockchain pubkey failed: %v", err)
+	}
+
+	cfg := visor.NewConfig()
+	cfg.BlockchainPubkey = pubkey
+	cfg.Distribution = params.MainNetDistribution
+
+	v, err := visor.New(cfg, wrapDB(db), nil)
+	if err != nil {
+		return fmt.Errorf("open blockchain failed: %v", err)
+	}
+
+	go func() {
+		apputil.CatchInterrupt(quitChan)
+	}()
+
+	n, err := v.ImportBlocks(bufio.NewReader(f), quitChan)
+	fmt.Printf("imported %d blocks\n", n)
+	if err != nil {
+		if err == visor.ErrBlocksFileStopped {
+			return nil
+		}
+		return fmt.Errorf("import blocks failed: %v", err)
+	}
+
+	return nil
+}
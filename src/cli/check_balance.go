@@ -35,13 +35,17 @@ type BalanceResult struct {
 }
 
 func walletBalanceCmd() *cobra.Command {
-	return &cobra.Command{
+	walletBalanceCmd := &cobra.Command{
 		Short:                 "Check the balance of a wallet",
 		Use:                   "walletBalance [wallet]",
 		Args:                  cobra.ExactArgs(1),
 		DisableFlagsInUseLine: true,
 		RunE:                  checkWltBalance,
 	}
+
+	walletBalanceCmd.Flags().Uint32("account", 0, "BIP44 account to check (bip44 wallets only)")
+
+	return walletBalanceCmd
 }
 
 func addressBalanceCmd() *cobra.Command {
@@ -59,7 +63,12 @@ func addressBalanceCmd() *cobra.Command {
 
 func checkWltBalance(c *cobra.Command, args []string) error {
 	w := args[0]
-	balRlt, err := CheckWalletBalance(apiClient, w)
+	account, err := c.Flags().GetUint32("account")
+	if err != nil {
+		return err
+	}
+
+	balRlt, err := CheckWalletBalance(apiClient, w, wallet.OptionAccount(account))
 	switch err.(type) {
 	case nil:
 	case WalletLoadError:
@@ -95,9 +104,10 @@ func addrBalance(_ *cobra.Command, args []string) error {
 
 // PUBLIC
 
-// CheckWalletBalance returns the total and individual balances of addresses in a wallet file
-func CheckWalletBalance(c GetOutputser, id string) (*BalanceResult, error) {
-	wlt, err := apiClient.Wallet(id)
+// CheckWalletBalance returns the total and individual balances of addresses in a wallet file.
+// Only the addresses of the account set with wallet.OptionAccount are checked in a bip44 wallet
+func CheckWalletBalance(c GetOutputser, id string, options ...wallet.Option) (*BalanceResult, error) {
+	wlt, err := apiClient.Wallet(id, options...)
 	if err != nil {
 		return nil, err
 	}
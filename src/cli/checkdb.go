@@ -30,25 +30,38 @@ func wrapDB(db *bolt.DB) *dbutil.DB {
 }
 
 func checkDBCmd() *cobra.Command {
-	return &cobra.Command{
+	checkDBCmd := &cobra.Command{
 		Short: "Verify the database",
 		Use:   "checkdb [db path]",
 		Long: `Checks if the given database file contains valid skycoin blockchain data.
-    If no argument is specificed, the default data.db in $HOME/.$COIN/ will be checked.`,
-		Args:                  cobra.MaximumNArgs(1),
-		DisableFlagsInUseLine: true,
-		SilenceUsage:          true,
-		RunE:                  checkDB,
+    If no argument is specificed, the default data.db in $HOME/.$COIN/ will be checked.
+    If the database is inconsistent, a report of the issues and their repairs is printed.
+    With --repair, the issues are repaired instead of resetting the database: the chain is truncated
+    to the last good block, the unspent pool and the address index are rebuilt, and the history database
+    is erased to be rebuilt by the node when it starts. The node must not be running, and the
+    database file should be backed up first.`,
+		Args:         cobra.MaximumNArgs(1),
+		SilenceUsage: true,
+		RunE:         checkDB,
 	}
+
+	checkDBCmd.Flags().Bool("repair", false, "Repair the issues that are found")
+
+	return checkDBCmd
 }
 
-func checkDB(_ *cobra.Command, args []string) error {
+func checkDB(c *cobra.Command, args []string) error {
+	repair, err := c.Flags().GetBool("repair")
+	if err != nil {
+		return err
+	}
+
 	// get db path
 	dbPath := ""
 	if len(args) > 0 {
 		dbPath = args[0]
 	}
-	dbPath, err := resolveDBPath(cliConfig, dbPath)
+	dbPath, err = resolveDBPath(cliConfig, dbPath)
 	if err != nil {
 		return err
 	}
@@ -60,7 +73,7 @@ func checkDB(_ *cobra.Command, args []string) error {
 
 	db, err := bolt.Open(dbPath, 0600, &bolt.Options{
 		Timeout:  5 * time.Second,
-		ReadOnly: true,
+		ReadOnly: !repair,
 	})
 
 	if err != nil {
@@ -75,14 +88,42 @@ func checkDB(_ *cobra.Command, args []string) error {
 		apputil.CatchInterrupt(quitChan)
 	}()
 
-	if err := visor.CheckDatabase(wrapDB(db), pubkey, quitChan); err != nil {
+	wdb := wrapDB(db)
+	defer wdb.Close()
+
+	report, err := visor.CheckDatabaseReport(wdb, pubkey, quitChan)
+	if err != nil {
 		if err == visor.ErrVerifyStopped {
 			return nil
 		}
 		return fmt.Errorf("checkdb failed: %v", err)
 	}
 
-	fmt.Println("check db success")
+	if report == nil || report.OK() {
+		fmt.Println("check db success")
+		return nil
+	}
+
+	if err := printJSON(report); err != nil {
+		return err
+	}
+
+	if !report.Repairable() {
+		return fmt.Errorf("checkdb failed: found %d issues, the database can't be repaired and must be reset", len(report.Issues))
+	}
+
+	if !repair {
+		return fmt.Errorf("checkdb failed: found %d issues, run checkdb with --repair to repair them", len(report.Issues))
+	}
+
+	if err := visor.RepairDatabase(wdb, report, quitChan); err != nil {
+		if err == visor.ErrVerifyStopped {
+			return nil
+		}
+		return fmt.Errorf("repair db failed: %v", err)
+	}
+
+	fmt.Printf("repaired db, the head block is %d\n", report.LastGoodBlockSeq)
 	return nil
 }
 
@@ -181,10 +181,13 @@ func NewCLI(cfg Config) (*cobra.Command, error) {
 		addressGenCmd(),
 		fiberAddressGenCmd(),
 		addressOutputsCmd(),
+		backupDBCmd(),
 		blocksCmd(),
 		broadcastTxCmd(),
 		checkDBCmd(),
 		checkDBEncodingCmd(),
+		combineSeedSharesCmd(),
+		compactDBCmd(),
 		createRawTxnCmd(),
 		createRawTxnV2Cmd(),
 		signTxnCmd(),
@@ -192,12 +195,20 @@ func NewCLI(cfg Config) (*cobra.Command, error) {
 		encodeJSONTxnCmd(),
 		decryptWalletCmd(),
 		encryptWalletCmd(),
+		exportBlocksCmd(),
+		importBlocksCmd(),
+		exportSnapshotCmd(),
+		importSnapshotCmd(),
+		verifySnapshotCmd(),
 		lastBlocksCmd(),
+		listBansCmd(),
+		clearBansCmd(),
 		listAddressesCmd(),
 		listWalletsCmd(),
 		sendCmd(),
 		showConfigCmd(),
 		showSeedCmd(),
+		splitSeedCmd(),
 		statusCmd(),
 		transactionCmd(),
 		verifyTransactionCmd(),
@@ -206,7 +217,12 @@ func NewCLI(cfg Config) (*cobra.Command, error) {
 		walletCreateCmd(),
 		walletCreateTempCmd(),
 		walletAddAddressesCmd(),
+		walletAccountsCmd(),
+		walletCreateAccountCmd(),
+		walletRenameAccountCmd(),
 		walletScanAddressesCmd(),
+		walletDiscoverCmd(),
+		walletWatchCmd(),
 		walletKeyExportCmd(),
 		walletBalanceCmd(),
 		walletHisCmd(),
@@ -97,6 +97,7 @@ example: -m '[{"addr":"$addr1", "coins": "10.2"}, {"addr":"$addr2", "coins": "20
 	createRawTxnCmd.Flags().StringP("password", "p", "", "Wallet password")
 	createRawTxnCmd.Flags().BoolP("json", "j", false, "Returns the results in JSON format.")
 	createRawTxnCmd.Flags().String("csv", "", "CSV file containing addresses and amounts to send")
+	createRawTxnCmd.Flags().Uint32("account", 0, "BIP44 account to spend from (bip44 wallets only)")
 
 	return createRawTxnCmd
 }
@@ -149,6 +150,7 @@ func createRawTxnV2Cmd() *cobra.Command {
 	createRawTxnCmd.Flags().String("csv", "", "CSV file containing addresses and amounts to send")
 	createRawTxnCmd.Flags().StringP("password", "p", "", "Wallet password")
 	createRawTxnCmd.Flags().BoolP("unsign", "", false, "Do not sign the transaction")
+	createRawTxnCmd.Flags().Uint32("account", 0, "BIP44 account to spend from (bip44 wallets only)")
 	createRawTxnCmd.Flags().BoolP("json", "j", false, "Returns the results in JSON format.")
 
 	createRawTxnCmd.Flags().BoolP("ignore-unconfirmed", "", false, "Ignore unconfirmed transactions")
@@ -165,8 +167,13 @@ func makeWalletCreateTxnRequest(c *cobra.Command, args []string) (*api.WalletCre
 		return nil, err
 	}
 
+	account, err := c.Flags().GetUint32("account")
+	if err != nil {
+		return nil, err
+	}
+
 	walletFile := args[0]
-	w, err := apiClient.Wallet(walletFile)
+	w, err := apiClient.Wallet(walletFile, wallet.OptionAccount(account))
 	if err != nil {
 		return nil, err
 	}
@@ -193,6 +200,7 @@ func makeWalletCreateTxnRequest(c *cobra.Command, args []string) (*api.WalletCre
 	req := api.WalletCreateTransactionRequest{
 		Unsigned:                 unsign,
 		WalletID:                 w.Meta.Filename,
+		Account:                  account,
 		CreateTransactionRequest: *ctr,
 	}
 
@@ -338,7 +346,7 @@ func fromWalletOrAddress(c *cobra.Command, walletFile string) (walletAddress, er
 	return wltAddr, nil
 }
 
-func getChangeAddress(wltAddr walletAddress, chgAddr string) (string, error) {
+func getChangeAddress(wltAddr walletAddress, chgAddr string, options ...wallet.Option) (string, error) {
 	if chgAddr == "" {
 		switch {
 		case wltAddr.Address != "":
@@ -350,7 +358,10 @@ func getChangeAddress(wltAddr walletAddress, chgAddr string) (string, error) {
 			if err != nil {
 				return "", WalletLoadError{err}
 			}
-			es, err := wlt.GetEntries()
+			if err := wallet.CheckAccountOption(wlt, options...); err != nil {
+				return "", err
+			}
+			es, err := wlt.GetEntries(options...)
 			if err != nil {
 				return "", err
 			}
@@ -553,6 +564,7 @@ type createRawTxnArgs struct {
 	ChangeAddress string
 	SendAmounts   []SendAmount
 	Password      PasswordReader
+	Account       uint32
 }
 
 func parseCreateRawTxnArgs(c *cobra.Command, args []string) (*createRawTxnArgs, error) {
@@ -561,11 +573,16 @@ func parseCreateRawTxnArgs(c *cobra.Command, args []string) (*createRawTxnArgs,
 		return nil, err
 	}
 
+	account, err := c.Flags().GetUint32("account")
+	if err != nil {
+		return nil, err
+	}
+
 	changeAddress, err := c.Flags().GetString("change-address")
 	if err != nil {
 		return nil, err
 	}
-	chgAddr, err := getChangeAddress(wltAddr, changeAddress)
+	chgAddr, err := getChangeAddress(wltAddr, changeAddress, wallet.OptionAccount(account))
 	if err != nil {
 		return nil, err
 	}
@@ -590,6 +607,7 @@ func parseCreateRawTxnArgs(c *cobra.Command, args []string) (*createRawTxnArgs,
 		ChangeAddress: chgAddr,
 		SendAmounts:   toAddrs,
 		Password:      pr,
+		Account:       account,
 	}, nil
 }
 
@@ -606,12 +624,14 @@ func createRawTxnCmdHandler(c *cobra.Command, args []string) (*coin.Transaction,
 	if parsedArgs.Address == "" {
 		return CreateRawTxnFromWallet(apiClient, parsedArgs.WalletID,
 			parsedArgs.ChangeAddress, parsedArgs.SendAmounts,
-			parsedArgs.Password, params.MainNetDistribution)
+			parsedArgs.Password, params.MainNetDistribution,
+			wallet.OptionAccount(parsedArgs.Account))
 	}
 
 	return CreateRawTxnFromAddress(apiClient, parsedArgs.Address,
 		parsedArgs.WalletID, parsedArgs.ChangeAddress, parsedArgs.SendAmounts,
-		parsedArgs.Password, params.MainNetDistribution)
+		parsedArgs.Password, params.MainNetDistribution,
+		wallet.OptionAccount(parsedArgs.Account))
 }
 
 func validateSendAmounts(toAddrs []SendAmount) error {
@@ -636,8 +656,9 @@ func validateSendAmounts(toAddrs []SendAmount) error {
 
 // PUBLIC
 
-// CreateRawTxnFromWallet creates a transaction from any address or combination of addresses in a wallet
-func CreateRawTxnFromWallet(c GetOutputser, walletFile, chgAddr string, toAddrs []SendAmount, pr PasswordReader, distParams params.Distribution) (*coin.Transaction, error) {
+// CreateRawTxnFromWallet creates a transaction from any address or combination of addresses in a wallet.
+// The bip44 account to spend from can be selected with wallet.OptionAccount.
+func CreateRawTxnFromWallet(c GetOutputser, walletFile, chgAddr string, toAddrs []SendAmount, pr PasswordReader, distParams params.Distribution, options ...wallet.Option) (*coin.Transaction, error) {
 	// check change address
 	cAddr, err := cipher.DecodeBase58Address(chgAddr)
 	if err != nil {
@@ -650,7 +671,11 @@ func CreateRawTxnFromWallet(c GetOutputser, walletFile, chgAddr string, toAddrs
 		return nil, err
 	}
 
-	if _, err := wlt.GetEntry(cAddr); err != nil {
+	if err := wallet.CheckAccountOption(wlt, options...); err != nil {
+		return nil, err
+	}
+
+	if _, err := wlt.GetEntry(cAddr, options...); err != nil {
 		if err == wallet.ErrEntryNotFound {
 			return nil, fmt.Errorf("change address %v is not in wallet", chgAddr)
 		}
@@ -683,7 +708,7 @@ func CreateRawTxnFromWallet(c GetOutputser, walletFile, chgAddr string, toAddrs
 	}
 
 	// get all address in the wallet
-	totalAddrs, err := wlt.GetAddresses()
+	totalAddrs, err := wlt.GetAddresses(options...)
 	if err != nil {
 		return nil, err
 	}
@@ -693,23 +718,28 @@ func CreateRawTxnFromWallet(c GetOutputser, walletFile, chgAddr string, toAddrs
 		addrStrArray[i] = a.String()
 	}
 
-	return CreateRawTxn(c, wlt, addrStrArray, chgAddr, toAddrs, password, distParams)
+	return CreateRawTxn(c, wlt, addrStrArray, chgAddr, toAddrs, password, distParams, options...)
 }
 
-// CreateRawTxnFromAddress creates a transaction from a specific address in a wallet
-func CreateRawTxnFromAddress(c GetOutputser, addr, walletFile, chgAddr string, toAddrs []SendAmount, pr PasswordReader, distParams params.Distribution) (*coin.Transaction, error) {
+// CreateRawTxnFromAddress creates a transaction from a specific address in a wallet.
+// The bip44 account of the address can be selected with wallet.OptionAccount.
+func CreateRawTxnFromAddress(c GetOutputser, addr, walletFile, chgAddr string, toAddrs []SendAmount, pr PasswordReader, distParams params.Distribution, options ...wallet.Option) (*coin.Transaction, error) {
 	// check if the address is in the default wallet.
 	wlt, err := wallet.Load(walletFile)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := wallet.CheckAccountOption(wlt, options...); err != nil {
+		return nil, err
+	}
+
 	srcAddr, err := cipher.DecodeBase58Address(addr)
 	if err != nil {
 		return nil, ErrAddress
 	}
 
-	if _, err := wlt.GetEntry(srcAddr); err != nil {
+	if _, err := wlt.GetEntry(srcAddr, options...); err != nil {
 		if err == wallet.ErrEntryNotFound {
 			return nil, fmt.Errorf("%v address is not in wallet", addr)
 		}
@@ -754,7 +784,7 @@ func CreateRawTxnFromAddress(c GetOutputser, addr, walletFile, chgAddr string, t
 		}
 	}
 
-	return CreateRawTxn(c, wlt, []string{addr}, chgAddr, toAddrs, password, distParams)
+	return CreateRawTxn(c, wlt, []string{addr}, chgAddr, toAddrs, password, distParams, options...)
 }
 
 // GetOutputser implements unspent output querying
@@ -763,7 +793,7 @@ type GetOutputser interface {
 }
 
 // CreateRawTxn creates a transaction from a set of addresses contained in a loaded wallet.Wallet
-func CreateRawTxn(c GetOutputser, wlt wallet.Wallet, inAddrs []string, chgAddr string, toAddrs []SendAmount, password []byte, distParams params.Distribution) (*coin.Transaction, error) {
+func CreateRawTxn(c GetOutputser, wlt wallet.Wallet, inAddrs []string, chgAddr string, toAddrs []SendAmount, password []byte, distParams params.Distribution, options ...wallet.Option) (*coin.Transaction, error) {
 	if err := validateSendAmounts(toAddrs); err != nil {
 		return nil, err
 	}
@@ -779,7 +809,7 @@ func CreateRawTxn(c GetOutputser, wlt wallet.Wallet, inAddrs []string, chgAddr s
 		return nil, err
 	}
 
-	txn, err := createRawTxn(outputs, wlt, chgAddr, toAddrs, password)
+	txn, err := createRawTxn(outputs, wlt, chgAddr, toAddrs, password, options...)
 	if err != nil {
 		return nil, err
 	}
@@ -812,7 +842,7 @@ func CreateRawTxn(c GetOutputser, wlt wallet.Wallet, inAddrs []string, chgAddr s
 	return txn, nil
 }
 
-func createRawTxn(uxouts *readable.UnspentOutputsSummary, wlt wallet.Wallet, chgAddr string, toAddrs []SendAmount, password []byte) (*coin.Transaction, error) {
+func createRawTxn(uxouts *readable.UnspentOutputsSummary, wlt wallet.Wallet, chgAddr string, toAddrs []SendAmount, password []byte, options ...wallet.Option) (*coin.Transaction, error) {
 	// Calculate total required coins
 	var totalCoins uint64
 	for _, arg := range toAddrs {
@@ -834,7 +864,7 @@ func createRawTxn(uxouts *readable.UnspentOutputsSummary, wlt wallet.Wallet, chg
 	}
 
 	f := func(w wallet.Wallet) (*coin.Transaction, error) {
-		keys, err := getKeys(w, spendOutputs)
+		keys, err := getKeys(w, spendOutputs, options...)
 		if err != nil {
 			return nil, err
 		}
@@ -970,10 +1000,10 @@ func mustMakeUtxoOutput(addr string, coins, hours uint64) coin.TransactionOutput
 	return uo
 }
 
-func getKeys(wlt wallet.Wallet, outs []transaction.UxBalance) ([]cipher.SecKey, error) {
+func getKeys(wlt wallet.Wallet, outs []transaction.UxBalance, options ...wallet.Option) ([]cipher.SecKey, error) {
 	keys := make([]cipher.SecKey, len(outs))
 	for i, o := range outs {
-		entry, err := wlt.GetEntry(o.Address)
+		entry, err := wlt.GetEntry(o.Address, options...)
 		if err != nil {
 			if err == wallet.ErrEntryNotFound {
 				return nil, fmt.Errorf("%v is not in wallet", o.Address.String())
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/spf13/cobra"
+)
+
+func backupDBCmd() *cobra.Command {
+	return &cobra.Command{
+		Short: "Back up the database of a running node",
+		Use:   "backupDB [backup file]",
+		Long: `Makes the node write a consistent copy of its database to a new file, without stopping the node.
+    The backup file is written by the node, so the path is a path on the node's host,
+    which must be in the node's data directory. The backup file must not exist. Requires the NET_CTRL API set to be enabled on the node.`,
+		Args:                  cobra.ExactArgs(1),
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			path, err := filepath.Abs(args[0])
+			if err != nil {
+				return err
+			}
+
+			resp, err := apiClient.BackupDB(path)
+			if err != nil {
+				return err
+			}
+
+			return printJSON(resp)
+		},
+	}
+}
+
+func compactDBCmd() *cobra.Command {
+	compactDBCmd := &cobra.Command{
+		Short: "Compact the database",
+		Use:   "compactDB [db path]",
+		Long: `Rewrites the database file without its free pages, which shrinks the file.
+    By default, the running node compacts its database, pausing while the compacted file replaces the database file.
+    This requires the NET_CTRL API set to be enabled on the node.
+    With --offline, the given database file is compacted, and the node must not be running.
+    If no db path is specificed, the default data.db in $HOME/.$COIN/ is compacted.`,
+		Args:         cobra.MaximumNArgs(1),
+		SilenceUsage: true,
+		RunE:         compactDB,
+	}
+
+	compactDBCmd.Flags().Bool("offline", false, "Compact a database file while the node is not running")
+
+	return compactDBCmd
+}
+
+func compactDB(c *cobra.Command, args []string) error {
+	offline, err := c.Flags().GetBool("offline")
+	if err != nil {
+		return err
+	}
+
+	if !offline {
+		if len(args) > 0 {
+			return errors.New("a db path can only be given with --offline")
+		}
+
+		resp, err := apiClient.CompactDB()
+		if err != nil {
+			return err
+		}
+
+		return printJSON(resp)
+	}
+
+	// get db path
+	dbPath := ""
+	if len(args) > 0 {
+		dbPath = args[0]
+	}
+	dbPath, err = resolveDBPath(cliConfig, dbPath)
+	if err != nil {
+		return err
+	}
+
+	// check if this file exists
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return fmt.Errorf("db file: %v does not exist", dbPath)
+	}
+
+	db, err := bolt.Open(dbPath, 0600, &bolt.Options{
+		Timeout: 5 * time.Second,
+	})
+	if err != nil {
+		return fmt.Errorf("open db failed: %v", err)
+	}
+
+	wdb := wrapDB(db)
+	defer wdb.Close()
+
+	before, after, err := wdb.Compact()
+	if err != nil {
+		return fmt.Errorf("compact db failed: %v", err)
+	}
+
+	fmt.Printf("compacted %s from %d to %d bytes\n", dbPath, before, after)
+	return nil
+}
@@ -25,7 +25,8 @@ func walletAddAddressesCmd() *cobra.Command {
     if you load the wallet from seed elsewhere. In that case, you'll have to manually
     generate addresses to cover the gap of unused addresses in the sequence.
 
-    BIP44 wallets generate their addresses on the external (0'/0) chain.
+    BIP44 wallets generate their addresses on the external chain of the account
+    selected with --account, defaults to the external (0'/0) chain of account 0.
 
     Use caution when using the "-p" command. If you have command
     history enabled your wallet encryption password can be recovered from the
@@ -38,6 +39,7 @@ func walletAddAddressesCmd() *cobra.Command {
 	walletAddAddressesCmd.Flags().StringP("password", "p", "", "wallet password")
 	walletAddAddressesCmd.Flags().BoolP("json", "j", false, "Returns the results in JSON format")
 	walletAddAddressesCmd.Flags().StringP("private-keys", "", "", "wallet private keys for collection wallet")
+	walletAddAddressesCmd.Flags().Uint32("account", 0, "BIP44 account to generate addresses on (bip44 wallets only)")
 
 	return walletAddAddressesCmd
 }
@@ -82,6 +84,14 @@ func generateAddrs(c *cobra.Command, args []string) error {
 		opts = append(opts, wallet.OptionGenerateN(num))
 	}
 
+	account, err := c.Flags().GetUint32("account")
+	if err != nil {
+		return err
+	}
+	if account > 0 {
+		opts = append(opts, wallet.OptionAccount(account))
+	}
+
 	var pwd []byte
 	pr := NewPasswordReader([]byte(c.Flag("password").Value.String()))
 	if wlt.Meta.Encrypted && wlt.Meta.Type != wallet.WalletTypeBip44 {
@@ -45,11 +45,13 @@ func walletCreateCmd() *cobra.Command {
 	walletCreateCmd.Flags().Uint32P("bip44-coin", "", uint32(bip44.CoinTypeSkycoin), "BIP44 coin type")
 	walletCreateCmd.Flags().Uint64P("num", "n", 1, `Number of addresses to generate.`)
 	walletCreateCmd.Flags().Uint64P("scan", "", 1, `Number of addresses to scan ahead for balances.`)
-	walletCreateCmd.Flags().StringP("type", "t", wallet.WalletTypeDeterministic, "Wallet type. Types are \"collection\", \"deterministic\", \"bip44\" or \"xpub\"")
+	walletCreateCmd.Flags().StringP("type", "t", wallet.WalletTypeDeterministic, "Wallet type. Types are \"collection\", \"deterministic\", \"bip44\", \"xpub\" or \"watch-only\"")
 	walletCreateCmd.Flags().BoolP("encrypt", "e", true, "Create encrypted wallet.")
 	walletCreateCmd.Flags().StringP("password", "p", "", "Wallet password")
 	walletCreateCmd.Flags().StringP("xpub", "", "", "xpub key for \"xpub\" type wallets")
 	walletCreateCmd.Flags().StringP("private-keys", "", "", "Collection private keys")
+	walletCreateCmd.Flags().StringP("addresses", "", "", "Comma separated addresses watched by \"watch-only\" type wallets")
+	walletCreateCmd.Flags().StringP("xpubs", "", "", "Comma separated xpub keys watched by \"watch-only\" type wallets")
 
 	return walletCreateCmd
 }
@@ -139,6 +141,8 @@ func generateWalletHandler(c *cobra.Command, _ []string) error {
 	var (
 		sd                    string
 		collectionPrivateKeys string
+		watchAddrs            []string
+		watchXPubs            []string
 	)
 	switch walletType {
 	case wallet.WalletTypeBip44:
@@ -182,6 +186,22 @@ func generateWalletHandler(c *cobra.Command, _ []string) error {
 			return fmt.Errorf("%q type wallets do not use seeds", walletType)
 		}
 
+	case wallet.WalletTypeWatchOnly:
+		// watch-only wallet has no secrets to encrypt
+		encrypt = false
+		if s != "" || random || mnemonic {
+			return fmt.Errorf("%q type wallets do not use seeds", walletType)
+		}
+
+		var err error
+		watchAddrs, watchXPubs, err = parseWatchFlags(c)
+		if err != nil {
+			return err
+		}
+		if len(watchAddrs) == 0 && len(watchXPubs) == 0 {
+			return wallet.ErrMissingWatchAddresses
+		}
+
 	default:
 		return fmt.Errorf("unhandled wallet type %q", walletType)
 	}
@@ -212,6 +232,8 @@ func generateWalletHandler(c *cobra.Command, _ []string) error {
 		ScanN:                 scan,
 		XPub:                  xpub,
 		CollectionPrivateKeys: collectionPrivateKeys,
+		WatchAddresses:        watchAddrs,
+		WatchXPubs:            watchXPubs,
 	}
 
 	wlt, err := apiClient.CreateWallet(opts)
@@ -231,8 +253,8 @@ func generateWalletHandler(c *cobra.Command, _ []string) error {
 		}
 	}
 
-	n := num - uint64(addrN)
-	if n > 0 {
+	if num > uint64(addrN) {
+		n := num - uint64(addrN)
 		_, err := apiClient.NewWalletAddress(id, string(password), wallet.OptionGenerateN(n))
 		if err != nil {
 			return err
@@ -41,6 +41,7 @@ import (
 	// register wallets
 	_ "github.com/skycoin/skycoin/src/wallet/bip44wallet"
 	_ "github.com/skycoin/skycoin/src/wallet/collection"
+	_ "github.com/skycoin/skycoin/src/wallet/watchwallet"
 	_ "github.com/skycoin/skycoin/src/wallet/xpubwallet"
 )
 
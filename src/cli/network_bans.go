@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/spf13/cobra"
+)
+
+func listBansCmd() *cobra.Command {
+	return &cobra.Command{
+		Short:                 "List the banned peer IPs",
+		Use:                   "listBans",
+		Args:                  cobra.NoArgs,
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			bans, err := apiClient.NetworkBans()
+			if err != nil {
+				return err
+			}
+
+			return printJSON(bans)
+		},
+	}
+}
+
+func clearBansCmd() *cobra.Command {
+	return &cobra.Command{
+		Short: "Unban peer IPs",
+		Long: `Removes the bans of the given peer IPs and resets their reputation scores.
+    If no IPs are given, all bans are removed.`,
+		Use:                   "clearBans [ip...]",
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			for _, ip := range args {
+				if net.ParseIP(ip) == nil {
+					return fmt.Errorf("invalid ip %q", ip)
+				}
+			}
+
+			return apiClient.ClearBans(args)
+		},
+	}
+}
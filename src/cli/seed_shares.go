@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/skycoin/skycoin/src/wallet"
+)
+
+func splitSeedCmd() *cobra.Command {
+	splitSeedCmd := &cobra.Command{
+		Args:  cobra.ExactArgs(1),
+		Use:   "splitSeed [wallet]",
+		Short: "Split wallet seed into recovery shares",
+		Long: `Split the seed of a wallet into mnemonic shares with Shamir's secret sharing.
+    Any "threshold" of the shares can recover the seed with combineSeedShares
+    or when creating or recovering a wallet. Fewer shares reveal nothing about the seed.
+
+    The seed passphrase of bip44 wallets is not part of the shares and is printed
+    separately, it must be backed up as well.
+
+    Use caution when using the "-p" command. If you have command history enabled
+    your wallet encryption password can be recovered from the history log. If you
+    do not include the "-p" option you will be prompted to enter your password
+    after you enter your command.`,
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			w := args[0]
+
+			password, err := c.Flags().GetString("password")
+			if err != nil {
+				return err
+			}
+
+			threshold, err := c.Flags().GetInt("threshold")
+			if err != nil {
+				return err
+			}
+
+			count, err := c.Flags().GetInt("shares")
+			if err != nil {
+				return err
+			}
+
+			jsonOutput, err := c.Flags().GetBool("json")
+			if err != nil {
+				return err
+			}
+
+			if threshold <= 0 {
+				return errors.New("threshold must be > 0")
+			}
+
+			if count < threshold {
+				return errors.New("shares must be >= threshold")
+			}
+
+			wlt, err := apiClient.Wallet(w)
+			if err != nil {
+				printHelp(c)
+				return err
+			}
+
+			var pwd []byte
+			if wlt.Meta.Encrypted {
+				pwd, err = NewPasswordReader([]byte(password)).Password()
+				if err != nil {
+					return err
+				}
+			}
+
+			rsp, err := apiClient.WalletSeedSplit(w, string(pwd), threshold, count)
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput {
+				return printJSON(rsp)
+			}
+
+			for i, s := range rsp.Shares {
+				fmt.Printf("%d: %s\n", i+1, s)
+			}
+			if rsp.SeedPassphrase != "" {
+				fmt.Println(rsp.SeedPassphrase)
+			}
+			return nil
+		},
+	}
+
+	splitSeedCmd.Flags().StringP("password", "p", "", "Wallet password")
+	splitSeedCmd.Flags().IntP("threshold", "t", 2, "Number of shares required to recover the seed")
+	splitSeedCmd.Flags().IntP("shares", "n", 3, "Number of shares to create")
+	splitSeedCmd.Flags().BoolP("json", "j", false, "Returns the results in JSON format.")
+
+	return splitSeedCmd
+}
+
+func combineSeedSharesCmd() *cobra.Command {
+	combineSeedSharesCmd := &cobra.Command{
+		Args:  cobra.MinimumNArgs(1),
+		Use:   "combineSeedShares [share...]",
+		Short: "Recover a wallet seed from recovery shares",
+		Long: `Verify and combine mnemonic shares created by splitSeed to recover the wallet seed.
+    Each share must be quoted as a single argument. This command works offline.`,
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			jsonOutput, err := c.Flags().GetBool("json")
+			if err != nil {
+				return err
+			}
+
+			shares := make([]string, len(args))
+			for i, s := range args {
+				shares[i] = strings.TrimSpace(s)
+			}
+
+			seed, err := wallet.CombineSeedShares(shares)
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput {
+				return printJSON(struct {
+					Seed string `json:"seed"`
+				}{
+					Seed: seed,
+				})
+			}
+
+			fmt.Println(seed)
+			return nil
+		},
+	}
+
+	combineSeedSharesCmd.Flags().BoolP("json", "j", false, "Returns the results in JSON format.")
+
+	return combineSeedSharesCmd
+}
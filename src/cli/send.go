@@ -62,6 +62,7 @@ example: -m '[{"addr":"$addr1", "coins": "10.2"}, {"addr":"$addr2", "coins": "20
 	sendCmd.Flags().StringP("password", "p", "", "Wallet password")
 	sendCmd.Flags().BoolP("json", "j", false, "Returns the results in JSON format.")
 	sendCmd.Flags().String("csv", "", "CSV file containing addresses and amounts to send")
+	sendCmd.Flags().Uint32("account", 0, "BIP44 account to spend from (bip44 wallets only)")
 
 	return sendCmd
 }
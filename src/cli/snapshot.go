@@ -0,0 +1,244 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/spf13/cobra"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/params"
+	"github.com/skycoin/skycoin/src/util/apputil"
+	"github.com/skycoin/skycoin/src/visor"
+)
+
+func exportSnapshotCmd() *cobra.Command {
+	exportSnapshotCmd := &cobra.Command{
+		Short: "Export a snapshot of the unspent outputs",
+		Use:   "exportSnapshot [db path]",
+		Long: `Writes a snapshot of the unspent outputs of the given database file before the block of given seq is executed.
+    The snapshot includes the signed block headers up to that block, the block itself and the address index of the outputs,
+    and can be imported with importSnapshot or the node's -import-snapshot option.
+    If no argument is specificed, the snapshot is taken from the default data.db in $HOME/.$COIN/.`,
+		Args:         cobra.MaximumNArgs(1),
+		SilenceUsage: true,
+		RunE:         exportSnapshot,
+	}
+
+	exportSnapshotCmd.Flags().StringP("output", "o", "snapshot.dat", "Output snapshot file")
+	exportSnapshotCmd.Flags().Uint64("seq", 0, "Seq of the snapshot block. Defaults to the head block")
+
+	return exportSnapshotCmd
+}
+
+func exportSnapshot(c *cobra.Command, args []string) error {
+	output, err := c.Flags().GetString("output")
+	if err != nil {
+		return err
+	}
+
+	seq := uint64(math.MaxUint64)
+	if c.Flags().Changed("seq") {
+		seq, err = c.Flags().GetUint64("seq")
+		if err != nil {
+			return err
+		}
+	}
+
+	// get db path
+	dbPath := ""
+	if len(args) > 0 {
+		dbPath = args[0]
+	}
+	dbPath, err = resolveDBPath(cliConfig, dbPath)
+	if err != nil {
+		return err
+	}
+
+	// check if this file exists
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return fmt.Errorf("db file: %v does not exist", dbPath)
+	}
+
+	db, err := bolt.Open(dbPath, 0600, &bolt.Options{
+		Timeout:  5 * time.Second,
+		ReadOnly: true,
+	})
+	if err != nil {
+		return fmt.Errorf("open db failed: %v", err)
+	}
+	defer db.Close()
+
+	pubkey, err := cipher.PubKeyFromHex(blockchainPubkey)
+	if err != nil {
+		return fmt.Errorf("decode blockchain pubkey failed: %v", err)
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	go func() {
+		apputil.CatchInterrupt(quitChan)
+	}()
+
+	w := bufio.NewWriter(f)
+	hdr, err := visor.ExportSnapshot(wrapDB(db), pubkey, w, seq, quitChan)
+	if err != nil {
+		return fmt.Errorf("export snapshot failed: %v", err)
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+
+	fmt.Printf("exported snapshot at block %d with %d outputs and outputs hash %s to %s\n", hdr.Seq, hdr.OutputsCount, hdr.OutputsHash.Hex(), output)
+	return nil
+}
+
+func importSnapshotCmd() *cobra.Command {
+	return &cobra.Command{
+		Short: "Bootstrap a database from a snapshot file",
+		Use:   "importSnapshot [snapshot file] [db path]",
+		Long: `Bootstraps the given database file from a snapshot file created by exportSnapshot.
+    The block header signatures are verified, and the unspent outputs are verified against the UxHash
+    of the snapshot block. The UxHash is not collision-resistant, so only import a snapshot from a trusted source,
+    and compare the printed outputs hash with the one published by that source.
+    The database must be empty or have only the genesis block.
+    The pruned block bodies and their history are downloaded from peers once the node is started.
+    If no db path is specificed, the snapshot is imported into the default data.db in $HOME/.$COIN/.
+    The node must not be running.`,
+		Args:                  cobra.RangeArgs(1, 2),
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		RunE:                  importSnapshot,
+	}
+}
+
+func importSnapshot(_ *cobra.Command, args []string) error {
+	// get db path
+	dbPath := ""
+	if len(args) > 1 {
+		dbPath = args[1]
+	}
+	dbPath, err := resolveDBPath(cliConfig, dbPath)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	db, err := bolt.Open(dbPath, 0600, &bolt.Options{
+		Timeout: 5 * time.Second,
+	})
+	if err != nil {
+		return fmt.Errorf("open db failed: %v", err)
+	}
+	defer db.Close()
+
+	pubkey, err := cipher.PubKeyFromHex(blockchainPubkey)
+	if err != nil {
+		return fmt.Errorf("decode blockchain pubkey failed: %v", err)
+	}
+
+	cfg := visor.NewConfig()
+	cfg.BlockchainPubkey = pubkey
+	cfg.Distribution = params.MainNetDistribution
+
+	v, err := visor.New(cfg, wrapDB(db), nil)
+	if err != nil {
+		return fmt.Errorf("open blockchain failed: %v", err)
+	}
+
+	go func() {
+		apputil.CatchInterrupt(quitChan)
+	}()
+
+	hdr, err := v.ImportSnapshot(bufio.NewReader(f), quitChan)
+	if err != nil {
+		if err == visor.ErrSnapshotStopped {
+			return nil
+		}
+		return fmt.Errorf("import snapshot failed: %v", err)
+	}
+
+	fmt.Printf("imported snapshot at block %d with %d outputs and outputs hash %s\n", hdr.Seq, hdr.OutputsCount, hdr.OutputsHash.Hex())
+	return nil
+}
+
+func verifySnapshotCmd() *cobra.Command {
+	return &cobra.Command{
+		Short: "Verify a snapshot file against a database",
+		Use:   "verifySnapshot [snapshot file] [db path]",
+		Long: `Verifies a snapshot file created by exportSnapshot against the blockchain of the given database file.
+    The block headers of the snapshot must match the blockchain, and the unspent outputs must match
+    the unspent outputs computed from the blockchain. The database must not be pruned after the snapshot block.
+    If no db path is specificed, the snapshot is verified against the default data.db in $HOME/.$COIN/.`,
+		Args:                  cobra.RangeArgs(1, 2),
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		RunE:                  verifySnapshot,
+	}
+}
+
+func verifySnapshot(_ *cobra.Command, args []string) error {
+	// get db path
+	dbPath := ""
+	if len(args) > 1 {
+		dbPath = args[1]
+	}
+	dbPath, err := resolveDBPath(cliConfig, dbPath)
+	if err != nil {
+		return err
+	}
+
+	// check if this file exists
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return fmt.Errorf("db file: %v does not exist", dbPath)
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	db, err := bolt.Open(dbPath, 0600, &bolt.Options{
+		Timeout:  5 * time.Second,
+		ReadOnly: true,
+	})
+	if err != nil {
+		return fmt.Errorf("open db failed: %v", err)
+	}
+	defer db.Close()
+
+	pubkey, err := cipher.PubKeyFromHex(blockchainPubkey)
+	if err != nil {
+		return fmt.Errorf("decode blockchain pubkey failed: %v", err)
+	}
+
+	go func() {
+		apputil.CatchInterrupt(quitChan)
+	}()
+
+	hdr, err := visor.VerifySnapshot(wrapDB(db), pubkey, bufio.NewReader(f), quitChan)
+	if err != nil {
+		return fmt.Errorf("verify snapshot failed: %v", err)
+	}
+
+	fmt.Printf("snapshot at block %d with %d outputs and outputs hash %s matches the blockchain\n", hdr.Seq, hdr.OutputsCount, hdr.OutputsHash.Hex())
+	return nil
+}
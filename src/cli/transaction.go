@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/skycoin/skycoin/src/util/droplet"
 	"github.com/skycoin/skycoin/src/wallet"
@@ -14,6 +16,7 @@ import (
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
 	"github.com/skycoin/skycoin/src/readable"
+	"github.com/skycoin/skycoin/src/visor"
 
 	"github.com/spf13/cobra"
 )
@@ -241,15 +244,29 @@ func recomputeHashes(rTxn *readable.Transaction) error {
 }
 
 func addressTransactionsCmd() *cobra.Command {
-	return &cobra.Command{
+	addressTransactionsCmd := &cobra.Command{
 		Short: "Show detail for transaction associated with one or more specified addresses",
 		Use:   "addressTransactions [address list]",
 		Long: `Display transactions for specific addresses, separate multiple addresses with a space,
-        example: addressTransactions addr1 addr2 addr3`,
+        example: addressTransactions addr1 addr2 addr3
+
+        The transactions can also be filtered by the role of the addresses, by time, by block seq
+        and by the total coins of their outputs. The addresses are optional when a filter is used,
+        example: addressTransactions --time-from 2019-01-01T00:00:00Z --time-to 2019-01-31T23:59:59Z`,
 		DisableFlagsInUseLine: true,
 		SilenceUsage:          true,
 		RunE:                  getAddressTransactionsCmd,
 	}
+
+	addressTransactionsCmd.Flags().String("role", "any", `Role of the addresses in the transactions, "any", "sender" or "receiver"`)
+	addressTransactionsCmd.Flags().String("time-from", "", "Earliest time of the transactions, an RFC3339 time or a unix timestamp")
+	addressTransactionsCmd.Flags().String("time-to", "", "Latest time of the transactions, an RFC3339 time or a unix timestamp")
+	addressTransactionsCmd.Flags().Uint64("block-from", 0, "Lowest block seq of the confirmed transactions")
+	addressTransactionsCmd.Flags().Uint64("block-to", 0, "Highest block seq of the confirmed transactions")
+	addressTransactionsCmd.Flags().String("min-coins", "", "Minimum total coins of the transaction outputs")
+	addressTransactionsCmd.Flags().String("max-coins", "", "Maximum total coins of the transaction outputs")
+
+	return addressTransactionsCmd
 }
 
 func getAddressTransactionsCmd(c *cobra.Command, args []string) error {
@@ -263,6 +280,21 @@ func getAddressTransactionsCmd(c *cobra.Command, args []string) error {
 		}
 	}
 
+	filterArgs, err := addressTransactionsFilterArgs(c, addrs)
+	if err != nil {
+		return err
+	}
+
+	// The filters are only supported by the paginated transactions API
+	if len(filterArgs) > 0 {
+		txns, err := getAllTransactionsVerboseV2(filterArgs)
+		if err != nil {
+			return err
+		}
+
+		return printJSON(txns)
+	}
+
 	// If one or more addresses have been provided, request their transactions - otherwise report an error
 	if len(addrs) > 0 {
 		outputs, err := apiClient.TransactionsVerbose(addrs)
@@ -276,6 +308,143 @@ func getAddressTransactionsCmd(c *cobra.Command, args []string) error {
 	return fmt.Errorf("at least one address must be specified. Example: %s addr1 addr2 addr3", c.Name())
 }
 
+// addressTransactionsFilterArgs returns the /api/v2/transactions arguments of the addressTransactions flags and addresses.
+// Returns no arguments if no filter flag is set
+func addressTransactionsFilterArgs(c *cobra.Command, addrs []string) ([]api.RequestArg, error) {
+	var args []api.RequestArg
+
+	role, err := c.Flags().GetString("role")
+	if err != nil {
+		return nil, err
+	}
+
+	switch role {
+	case "any":
+	case "sender", "receiver":
+		if len(addrs) == 0 {
+			return nil, fmt.Errorf("at least one address must be specified with --role %s", role)
+		}
+		args = append(args, api.RequestArg{
+			Key:   role + "s",
+			Value: strings.Join(addrs, ","),
+		})
+	default:
+		return nil, fmt.Errorf(`invalid --role %q, must be "any", "sender" or "receiver"`, role)
+	}
+
+	for _, name := range []string{"time-from", "time-to"} {
+		if !c.Flags().Changed(name) {
+			continue
+		}
+
+		v, err := c.Flags().GetString(name)
+		if err != nil {
+			return nil, err
+		}
+
+		t, err := parseTimeArg(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s: %v", name, err)
+		}
+
+		args = append(args, api.RequestArg{
+			Key:   strings.Replace(name, "-", "_", 1),
+			Value: strconv.FormatUint(t, 10),
+		})
+	}
+
+	for _, name := range []string{"block-from", "block-to"} {
+		if !c.Flags().Changed(name) {
+			continue
+		}
+
+		v, err := c.Flags().GetUint64(name)
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, api.RequestArg{
+			Key:   strings.Replace(name, "-", "_", 1),
+			Value: strconv.FormatUint(v, 10),
+		})
+	}
+
+	for _, name := range []string{"min-coins", "max-coins"} {
+		if !c.Flags().Changed(name) {
+			continue
+		}
+
+		v, err := c.Flags().GetString(name)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := droplet.FromString(v); err != nil {
+			return nil, fmt.Errorf("invalid --%s: %v", name, err)
+		}
+
+		args = append(args, api.RequestArg{
+			Key:   strings.Replace(name, "-", "_", 1),
+			Value: v,
+		})
+	}
+
+	// Without a role, the addresses match the transactions that involve them
+	if len(args) > 0 && role == "any" && len(addrs) > 0 {
+		args = append(args, api.RequestArg{
+			Key:   "addrs",
+			Value: strings.Join(addrs, ","),
+		})
+	}
+
+	return args, nil
+}
+
+// parseTimeArg parses an RFC3339 time or a unix timestamp
+func parseTimeArg(s string) (uint64, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		if t.Unix() < 0 {
+			return 0, fmt.Errorf("time %q is before the unix epoch", s)
+		}
+		return uint64(t.Unix()), nil
+	}
+
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("time %q must be an RFC3339 time or a unix timestamp", s)
+	}
+
+	return v, nil
+}
+
+// getAllTransactionsVerboseV2 requests all of the pages of the transactions that match the arguments
+func getAllTransactionsVerboseV2(args []api.RequestArg) ([]readable.TransactionWithStatusVerbose, error) {
+	txns := []readable.TransactionWithStatusVerbose{}
+	for page := uint64(1); ; page++ {
+		pageArgs := append([]api.RequestArg{
+			{
+				Key:   "page",
+				Value: strconv.FormatUint(page, 10),
+			},
+			{
+				Key:   "limit",
+				Value: strconv.FormatUint(visor.MaxTxnPageSize, 10),
+			},
+		}, args...)
+
+		rsp, err := apiClient.TransactionsVerboseV2(pageArgs...)
+		if err != nil {
+			return nil, err
+		}
+
+		txns = append(txns, rsp.Txns...)
+
+		if page >= rsp.PageInfo.TotalPages {
+			return txns, nil
+		}
+	}
+}
+
 func verifyTransactionCmd() *cobra.Command {
 	return &cobra.Command{
 		Short:                 "Verify if the specific transaction is spendable",
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+func walletAccountsCmd() *cobra.Command {
+	walletAccountsCmd := &cobra.Command{
+		Args:  cobra.ExactArgs(1),
+		Use:   "walletAccounts [wallet]",
+		Short: "List the accounts of a bip44 wallet",
+		RunE: func(c *cobra.Command, args []string) error {
+			jsonOutput, err := c.Flags().GetBool("json")
+			if err != nil {
+				return err
+			}
+
+			accounts, err := apiClient.WalletAccounts(args[0])
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput {
+				return printJSON(accounts)
+			}
+
+			for _, a := range accounts {
+				fmt.Printf("%d: %s\n", a.Index, a.Name)
+			}
+			return nil
+		},
+	}
+
+	walletAccountsCmd.Flags().BoolP("json", "j", false, "Returns the results in JSON format.")
+
+	return walletAccountsCmd
+}
+
+func walletCreateAccountCmd() *cobra.Command {
+	walletCreateAccountCmd := &cobra.Command{
+		Args:  cobra.ExactArgs(2),
+		Use:   "walletCreateAccount [wallet] [name]",
+		Short: "Create a new account in a bip44 wallet",
+		Long: `Create a new account in a bip44 wallet.
+    The account keys are derived from the wallet seed, so the wallet password
+    is required if the wallet is encrypted.
+
+    Use caution when using the "-p" command. If you have command history enabled
+    your wallet encryption password can be recovered from the history log. If you
+    do not include the "-p" option you will be prompted to enter your password
+    after you enter your command.`,
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			id := args[0]
+
+			jsonOutput, err := c.Flags().GetBool("json")
+			if err != nil {
+				return err
+			}
+
+			wlt, err := apiClient.Wallet(id)
+			if err != nil {
+				printHelp(c)
+				return err
+			}
+
+			var pwd []byte
+			if wlt.Meta.Encrypted {
+				pwd, err = getPassword(c)
+				if err != nil {
+					return err
+				}
+			}
+
+			account, err := apiClient.WalletCreateAccount(id, args[1], string(pwd))
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput {
+				return printJSON(account)
+			}
+
+			fmt.Printf("%d: %s\n", account.Index, account.Name)
+			return nil
+		},
+	}
+
+	walletCreateAccountCmd.Flags().StringP("password", "p", "", "Wallet password")
+	walletCreateAccountCmd.Flags().BoolP("json", "j", false, "Returns the results in JSON format.")
+
+	return walletCreateAccountCmd
+}
+
+func walletRenameAccountCmd() *cobra.Command {
+	return &cobra.Command{
+		Args:                  cobra.ExactArgs(3),
+		Use:                   "walletRenameAccount [wallet] [account] [name]",
+		Short:                 "Rename an account of a bip44 wallet",
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		RunE: func(c *cobra.Command, args []string) error {
+			account, err := strconv.ParseUint(args[1], 10, 32)
+			if err != nil {
+				printHelp(c)
+				return fmt.Errorf("invalid account: %v", err)
+			}
+
+			return apiClient.WalletRenameAccount(args[0], uint32(account), args[2])
+		},
+	}
+}
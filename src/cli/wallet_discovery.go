@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+func walletDiscoverCmd() *cobra.Command {
+	walletDiscoverCmd := &cobra.Command{
+		Args:  cobra.ExactArgs(1),
+		Use:   "walletDiscover [wallet]",
+		Short: "Discover the used addresses of a wallet with a gap limit scan",
+		Long: `Discover the used addresses of a wallet with a gap limit scan.
+
+    Each address chain of the wallet is scanned in windows of "gap limit"
+    addresses, and the wallet is extended up to the last address with any
+    transaction history, until a window has no activity. All accounts and
+    their change chains are scanned for bip44 wallets.
+
+    The node also runs the discovery in the background, when a wallet is
+    loaded and when new blocks are received.
+
+    The argument of [wallet] could be a wallet file name or a fullpath of the wallet
+    file. For example, both foo.wlt and $HOME/.skycoin/wallets/foo.wlt could be resolved.`,
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			gapLimit, err := c.Flags().GetUint64("gap-limit")
+			if err != nil {
+				return err
+			}
+
+			jsonOutput, err := c.Flags().GetBool("json")
+			if err != nil {
+				return err
+			}
+
+			_, id := filepath.Split(args[0])
+
+			rsp, err := apiClient.WalletDiscover(id, gapLimit)
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput {
+				return printJSON(rsp)
+			}
+
+			for _, addr := range rsp.Addresses {
+				fmt.Println(addr)
+			}
+			return nil
+		},
+	}
+
+	walletDiscoverCmd.Flags().Uint64P("gap-limit", "g", 0, "Set the gap limit of the wallet before the scan. The wallet's gap limit is used if not set")
+	walletDiscoverCmd.Flags().BoolP("json", "j", false, "Returns the results in JSON format.")
+
+	return walletDiscoverCmd
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func walletWatchCmd() *cobra.Command {
+	walletWatchCmd := &cobra.Command{
+		Args:  cobra.ExactArgs(1),
+		Use:   "walletWatch [wallet]",
+		Short: "Add addresses or xpub keys to a watch-only wallet",
+		Long: `Add addresses or xpub keys to a watch-only wallet.
+
+    The first address of each xpub key is added to the wallet, the further
+    used addresses of the xpub key are found by address discovery.
+
+    The new watched addresses are printed.
+
+    The argument of [wallet] could be a wallet file name or a fullpath of the wallet
+    file. For example, both foo.wlt and $HOME/.skycoin/wallets/foo.wlt could be resolved.`,
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			addrs, xpubs, err := parseWatchFlags(c)
+			if err != nil {
+				return err
+			}
+
+			if len(addrs) == 0 && len(xpubs) == 0 {
+				return errors.New("--addresses or --xpubs must be set")
+			}
+
+			jsonOutput, err := c.Flags().GetBool("json")
+			if err != nil {
+				return err
+			}
+
+			_, id := filepath.Split(args[0])
+
+			rsp, err := apiClient.WalletWatch(id, addrs, xpubs)
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput {
+				return printJSON(rsp)
+			}
+
+			for _, addr := range rsp.Addresses {
+				fmt.Println(addr)
+			}
+			return nil
+		},
+	}
+
+	walletWatchCmd.Flags().StringP("addresses", "a", "", "Comma separated addresses to watch")
+	walletWatchCmd.Flags().StringP("xpubs", "x", "", "Comma separated xpub keys to watch")
+	walletWatchCmd.Flags().BoolP("json", "j", false, "Returns the results in JSON format.")
+
+	return walletWatchCmd
+}
+
+// parseWatchFlags returns the addresses and xpub keys of the --addresses and --xpubs flags
+func parseWatchFlags(c *cobra.Command) ([]string, []string, error) {
+	addrs, err := c.Flags().GetString("addresses")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	xpubs, err := c.Flags().GetString("xpubs")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return splitFlagList(addrs), splitFlagList(xpubs), nil
+}
+
+// splitFlagList splits a comma separated flag value
+func splitFlagList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -75,7 +75,14 @@ type TransactionOutput struct {
 // Verify cannot check if the transaction would create or destroy coins
 // or if the inputs have the required coin base
 func (txn *Transaction) Verify() error {
-	return txn.verify(true)
+	return txn.verify(true, true)
+}
+
+// VerifyWithoutSignatures is Verify without the check that the signatures are valid.
+// It must only be used for transactions whose input signatures were already verified
+// with VerifyInputSignatures, which subsumes that check
+func (txn *Transaction) VerifyWithoutSignatures() error {
+	return txn.verify(true, false)
 }
 
 // VerifyUnsigned attempts to determine if the transaction is well formed,
@@ -85,10 +92,10 @@ func (txn *Transaction) Verify() error {
 // Verify cannot check if the transaction would create or destroy coins
 // or if the inputs have the required coin base
 func (txn *Transaction) VerifyUnsigned() error {
-	return txn.verify(false)
+	return txn.verify(false, true)
 }
 
-func (txn *Transaction) verify(signed bool) error {
+func (txn *Transaction) verify(signed, verifySigs bool) error {
 	if len(txn.In) == 0 {
 		return errors.New("No inputs")
 	}
@@ -186,6 +193,10 @@ func (txn *Transaction) verify(signed bool) error {
 			continue
 		}
 
+		if !verifySigs {
+			continue
+		}
+
 		hash := cipher.AddSHA256(txn.InnerHash, txn.In[i])
 		if err := cipher.VerifySignatureRecoverPubKey(sig, hash); err != nil {
 			return err
@@ -546,6 +557,19 @@ func DeserializeTransactionHex(s string) (Transaction, error) {
 	return DeserializeTransaction(b)
 }
 
+// OutputCoins returns the coins sent as outputs
+func (txn *Transaction) OutputCoins() (uint64, error) {
+	coins := uint64(0)
+	for i := range txn.Out {
+		var err error
+		coins, err = mathutil.AddUint64(coins, txn.Out[i].Coins)
+		if err != nil {
+			return 0, errors.New("Transaction output coins overflow")
+		}
+	}
+	return coins, nil
+}
+
 // OutputHours returns the coin hours sent as outputs. This does not include the fee.
 func (txn *Transaction) OutputHours() (uint64, error) {
 	hours := uint64(0)
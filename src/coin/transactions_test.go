@@ -197,6 +197,31 @@ func TestTransactionVerify(t *testing.T) {
 	require.NoError(t, txn.Verify())
 }
 
+func TestTransactionVerifyWithoutSignatures(t *testing.T) {
+	txn := makeTransaction(t)
+	require.NoError(t, txn.VerifyWithoutSignatures())
+
+	// Invalid signature, not empty, is not checked
+	badSig := "9a0f86874a4d9541f58a1de4db1c1b58765a868dc6f027445d0a2a8a7bddd1c45ea559fcd7bef45e1b76ccdaf8e50bbebd952acbbea87d1cb3f7a964bc89bf1ed5"
+	txn.Sigs[0] = cipher.MustSigFromHex(badSig)
+	testutil.RequireError(t, txn.Verify(), "Failed to recover pubkey from signature")
+	require.NoError(t, txn.VerifyWithoutSignatures())
+
+	// Invalid signature, empty
+	txn.Sigs[0] = cipher.Sig{}
+	testutil.RequireError(t, txn.VerifyWithoutSignatures(), "Unsigned input in transaction")
+
+	// The rest of the transaction is verified
+	txn = makeTransaction(t)
+	txn.Out[0].Coins = 0
+	require.NoError(t, txn.UpdateHeader())
+	testutil.RequireError(t, txn.VerifyWithoutSignatures(), "Zero coin output")
+
+	txn = makeTransaction(t)
+	txn.Type = 1
+	testutil.RequireError(t, txn.VerifyWithoutSignatures(), "transaction type invalid")
+}
+
 func TestTransactionVerifyUnsigned(t *testing.T) {
 	txn, _ := makeTransactionMultipleInputs(t, 2)
 	err := txn.VerifyUnsigned()
@@ -510,6 +535,22 @@ func TestTransactionSerialization(t *testing.T) {
 	require.Equal(t, sshh, txn.MustSerializeHex())
 }
 
+func TestTransactionOutputCoins(t *testing.T) {
+	txn := Transaction{}
+	err := txn.PushOutput(makeAddress(), 1e6, 100)
+	require.NoError(t, err)
+	err = txn.PushOutput(makeAddress(), 2e6, 200)
+	require.NoError(t, err)
+	coins, err := txn.OutputCoins()
+	require.NoError(t, err)
+	require.Equal(t, coins, uint64(3e6))
+
+	err = txn.PushOutput(makeAddress(), math.MaxUint64-1e6, 0)
+	require.NoError(t, err)
+	_, err = txn.OutputCoins()
+	testutil.RequireError(t, err, "Transaction output coins overflow")
+}
+
 func TestTransactionOutputHours(t *testing.T) {
 	txn := Transaction{}
 	err := txn.PushOutput(makeAddress(), 1e6, 100)
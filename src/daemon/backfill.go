@@ -0,0 +1,163 @@
+package daemon
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/skycoin/skycoin/src/coin"
+)
+
+// blockBackfill tracks the download of the pruned blocks of a node bootstrapped from a snapshot.
+// The pruned blocks are requested in order from peers that have not pruned them,
+// one request at a time, and restored with their history in the background.
+// A request that is not delivered in time is sent to another peer
+type blockBackfill struct {
+	sync.Mutex
+	stallTimeout time.Duration
+
+	addr        string    // peer that blocks were requested from, empty if no request is outstanding
+	lastBlock   uint64    // the blocks after lastBlock were requested
+	requestedAt time.Time // when the blocks were requested
+}
+
+func newBlockBackfill(stallTimeout time.Duration) *blockBackfill {
+	return &blockBackfill{
+		stallTimeout: stallTimeout,
+	}
+}
+
+// pending returns true if a request is outstanding.
+// A request that stalled is cleared, and the address of the peer that stalled it is returned
+func (b *blockBackfill) pending(now time.Time) (bool, string) {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.addr == "" {
+		return false, ""
+	}
+
+	if now.Sub(b.requestedAt) < b.stallTimeout {
+		return true, ""
+	}
+
+	addr := b.addr
+	b.addr = ""
+	return false, addr
+}
+
+// request records a request for the blocks after lastBlock sent to a peer
+func (b *blockBackfill) request(addr string, lastBlock uint64, now time.Time) {
+	b.Lock()
+	defer b.Unlock()
+
+	b.addr = addr
+	b.lastBlock = lastBlock
+	b.requestedAt = now
+}
+
+// receive returns true if the blocks are the response to the outstanding request, which is cleared
+func (b *blockBackfill) receive(addr string, blocks []coin.SignedBlock) bool {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.addr == "" || b.addr != addr || len(blocks) == 0 || blocks[0].Seq() != b.lastBlock+1 {
+		return false
+	}
+
+	b.addr = ""
+	return true
+}
+
+// removePeer clears the outstanding request if it was sent to a disconnected peer
+func (b *blockBackfill) removePeer(addr string) {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.addr == addr {
+		b.addr = ""
+	}
+}
+
+// requestBackfill requests the next pruned blocks from a peer that has not pruned them,
+// if the node was bootstrapped from a snapshot and no request is outstanding
+func (dm *Daemon) requestBackfill() {
+	if dm.config.DisableNetworking {
+		return
+	}
+
+	now := time.Now().UTC()
+	pending, stalledAddr := dm.blockBackfill.pending(now)
+	if stalledAddr != "" {
+		logger.WithField("addr", stalledAddr).Info("Peer stalled the backfill of pruned blocks")
+		if dm.connections.get(stalledAddr) != nil {
+			dm.adjustPeerScore(stalledAddr, behaviorSlowBlocksResponse)
+		}
+	}
+	if pending {
+		return
+	}
+
+	lastBlock, end, ok, err := dm.visor.BackfillRange()
+	if err != nil {
+		logger.WithError(err).Error("visor.BackfillRange failed")
+		return
+	}
+	if !ok {
+		return
+	}
+
+	var addrs []string
+	for _, c := range dm.connections.all() {
+		if c.HasIntroduced() && c.Capabilities.LowestBlockSeq == 0 && c.Height >= end && c.Addr != stalledAddr {
+			addrs = append(addrs, c.Addr)
+		}
+	}
+	if len(addrs) == 0 {
+		return
+	}
+
+	addr := addrs[rand.Intn(len(addrs))]
+
+	count := end - lastBlock
+	if count > dm.config.GetBlocksRequestCount {
+		count = dm.config.GetBlocksRequestCount
+	}
+
+	m := NewGetBlocksMessage(lastBlock, count)
+	if err := dm.sendMessage(addr, m); err != nil {
+		logger.WithError(err).WithField("addr", addr).Warning("Send GetBlocksMessage failed")
+		return
+	}
+
+	dm.blockBackfill.request(addr, lastBlock, now)
+}
+
+// receiveBackfillBlocks restores the pruned blocks received from a peer in response to a backfill request
+// and requests the next pruned blocks.
+// Returns false if the blocks are not a response to a backfill request
+func (dm *Daemon) receiveBackfillBlocks(addr string, blocks []coin.SignedBlock) bool {
+	if !dm.blockBackfill.receive(addr, blocks) {
+		return false
+	}
+
+	fields := logrus.Fields{
+		"addr":  addr,
+		"start": blocks[0].Seq(),
+	}
+
+	n, err := dm.visor.BackfillBlocks(blocks)
+	if err != nil {
+		logger.WithError(err).WithFields(fields).Warning("Received pruned blocks that do not match the blockchain")
+		dm.adjustPeerScore(addr, behaviorInvalidBlock)
+		return true
+	}
+
+	logger.WithFields(fields).Infof("Backfilled %d pruned blocks", n)
+
+	dm.requestBackfill()
+
+	return true
+}
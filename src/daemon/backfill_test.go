@@ -0,0 +1,53 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockBackfill(t *testing.T) {
+	_, _, blocks := makeSignedChain(t, 6)
+
+	b := newBlockBackfill(time.Second)
+	now := time.Now()
+
+	pending, stalled := b.pending(now)
+	require.False(t, pending)
+	require.Empty(t, stalled)
+
+	// Blocks received without a request are not backfill blocks
+	require.False(t, b.receive("1.1.1.1:6000", blocks[2:4]))
+
+	b.request("1.1.1.1:6000", 1, now)
+	pending, stalled = b.pending(now.Add(time.Millisecond * 500))
+	require.True(t, pending)
+	require.Empty(t, stalled)
+
+	// Blocks from another peer or starting at another seq are not backfill blocks
+	require.False(t, b.receive("2.2.2.2:6000", blocks[2:4]))
+	require.False(t, b.receive("1.1.1.1:6000", blocks[3:5]))
+	require.False(t, b.receive("1.1.1.1:6000", nil))
+
+	require.True(t, b.receive("1.1.1.1:6000", blocks[2:4]))
+	pending, _ = b.pending(now)
+	require.False(t, pending)
+
+	// A request that is not delivered in time is cleared
+	b.request("1.1.1.1:6000", 3, now)
+	pending, stalled = b.pending(now.Add(time.Second))
+	require.False(t, pending)
+	require.Equal(t, "1.1.1.1:6000", stalled)
+	require.False(t, b.receive("1.1.1.1:6000", blocks[4:6]))
+
+	// A request to a disconnected peer is cleared
+	b.request("2.2.2.2:6000", 3, now)
+	b.removePeer("1.1.1.1:6000")
+	pending, _ = b.pending(now)
+	require.True(t, pending)
+	b.removePeer("2.2.2.2:6000")
+	pending, stalled = b.pending(now)
+	require.False(t, pending)
+	require.Empty(t, stalled)
+}
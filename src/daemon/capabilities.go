@@ -0,0 +1,315 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/skycoin/skycoin/src/daemon/gnet"
+	"github.com/skycoin/skycoin/src/daemon/pex"
+	"github.com/skycoin/skycoin/src/util/iputil"
+)
+
+// Service bits set in the Capabilities of the IntroductionMessage
+const (
+	// ServiceCompactBlocks is set by peers that accept CompactBlockMessage
+	ServiceCompactBlocks uint64 = 1 << 0
+	// ServiceDandelion is set by peers that accept StemTxnMessage
+	ServiceDandelion uint64 = 1 << 1
+	// ServicePeerTimestamps is set by peers that accept GiveAddrsMessage
+	ServicePeerTimestamps uint64 = 1 << 2
+)
+
+// serviceNames are the names of the service bits shown by the API
+var serviceNames = map[uint64]string{
+	ServiceCompactBlocks:  "compact_blocks",
+	ServiceDandelion:      "dandelion",
+	ServicePeerTimestamps: "peer_timestamps",
+}
+
+// capabilitiesMarker starts the capabilities section of the IntroductionMessage.
+// Extra data after the genesis hash that does not start with it is ignored, like in older versions
+var capabilitiesMarker = []byte("CAPS")
+
+// Types of the records of the capabilities TLV section of the IntroductionMessage
+const (
+	// capabilityRecordServices value is the services bitmask, uint64
+	capabilityRecordServices uint16 = 1
+	// capabilityRecordMessageLengths value is the maximum incoming and outgoing message lengths, uint64 each
+	capabilityRecordMessageLengths uint16 = 2
+	// capabilityRecordMessageTypes value is a concatenation of the 4 byte prefixes of the accepted message types
+	capabilityRecordMessageTypes uint16 = 3
+	// capabilityRecordOnionAddress value is the decoded name of the version 3 onion service that the peer listens on,
+	// 35 bytes, followed by its port, uint16
+	capabilityRecordOnionAddress uint16 = 4
+	// capabilityRecordLowestBlockSeq value is the lowest seq of the non-genesis blocks that the peer can send, uint64
+	capabilityRecordLowestBlockSeq uint16 = 5
+)
+
+var (
+	// ErrCapabilitiesMarkerMissing the capabilities section does not start with capabilitiesMarker
+	ErrCapabilitiesMarkerMissing = errors.New("Capabilities section marker is missing")
+	// ErrCapabilitiesRecordTruncated a capabilities record is longer than the remaining data
+	ErrCapabilitiesRecordTruncated = errors.New("Capabilities record is truncated")
+	// ErrCapabilitiesRecordInvalidLength a known capabilities record has an invalid length
+	ErrCapabilitiesRecordInvalidLength = errors.New("Capabilities record has an invalid length")
+	// ErrCapabilitiesRecordDuplicate a capabilities record type appears more than once
+	ErrCapabilitiesRecordDuplicate = errors.New("Capabilities record is duplicated")
+	// ErrCapabilitiesRecordInvalidValue a known capabilities record has an invalid value
+	ErrCapabilitiesRecordInvalidValue = errors.New("Capabilities record has an invalid value")
+)
+
+// Capabilities are the optional protocol features of a peer, sent in the IntroductionMessage
+// as a section of type-length-value records. Records of unknown types are skipped,
+// so that new capabilities can be added without breaking older peers.
+type Capabilities struct {
+	// Services is a bitmask of optional protocol features
+	Services uint64
+	// MaxIncomingMessageLength is the maximum length of the messages that the peer accepts
+	MaxIncomingMessageLength uint64
+	// MaxOutgoingMessageLength is the maximum length of the messages that the peer sends
+	MaxOutgoingMessageLength uint64
+	// MessageTypes are the prefixes of the message types that the peer accepts.
+	// If empty, the peer did not send its message types
+	MessageTypes []string
+	// OnionAddress is the onion:port address of the onion service that the peer listens on.
+	// Incoming connections to an onion service come from the local Tor daemon, so the peer
+	// advertises the address that it can be reached on
+	OnionAddress string
+	// LowestBlockSeq is the lowest seq of the blocks, other than the genesis block, that the peer can send.
+	// The bodies of older blocks have been pruned by the peer. 0 if the peer has all blocks
+	LowestBlockSeq uint64
+}
+
+// HasServices returns true if all of the service bits are set
+func (c Capabilities) HasServices(services uint64) bool {
+	return c.Services&services == services
+}
+
+// SupportsMessageType returns true if the peer accepts a message type.
+// Peers that did not send their message types are assumed to accept all message types.
+func (c Capabilities) SupportsMessageType(prefix string) bool {
+	if len(c.MessageTypes) == 0 {
+		return true
+	}
+
+	for _, t := range c.MessageTypes {
+		if t == prefix {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ServiceNames returns the names of the service bits that are set, sorted
+func (c Capabilities) ServiceNames() []string {
+	names := make([]string, 0, len(serviceNames))
+	for s, name := range serviceNames {
+		if c.HasServices(s) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// empty returns true if no capability is set
+func (c Capabilities) empty() bool {
+	return c.Services == 0 && c.MaxIncomingMessageLength == 0 && c.MaxOutgoingMessageLength == 0 && len(c.MessageTypes) == 0 && c.OnionAddress == "" && c.LowestBlockSeq == 0
+}
+
+// encodeCapabilities encodes the capabilities section: capabilitiesMarker followed by TLV records.
+// Each record is a uint16 type, a uint16 value length and the value. Records that are not set are omitted.
+// OnionAddress must be a valid onion:port address.
+func encodeCapabilities(c Capabilities) []byte {
+	b := append([]byte{}, capabilitiesMarker...)
+
+	appendRecord := func(t uint16, v []byte) {
+		var h [4]byte
+		binary.LittleEndian.PutUint16(h[:2], t)
+		binary.LittleEndian.PutUint16(h[2:], uint16(len(v)))
+		b = append(b, h[:]...)
+		b = append(b, v...)
+	}
+
+	if c.Services != 0 {
+		v := make([]byte, 8)
+		binary.LittleEndian.PutUint64(v, c.Services)
+		appendRecord(capabilityRecordServices, v)
+	}
+
+	if c.MaxIncomingMessageLength != 0 || c.MaxOutgoingMessageLength != 0 {
+		v := make([]byte, 16)
+		binary.LittleEndian.PutUint64(v[:8], c.MaxIncomingMessageLength)
+		binary.LittleEndian.PutUint64(v[8:], c.MaxOutgoingMessageLength)
+		appendRecord(capabilityRecordMessageLengths, v)
+	}
+
+	if len(c.MessageTypes) != 0 {
+		v := make([]byte, 0, len(c.MessageTypes)*4)
+		for _, t := range c.MessageTypes {
+			p := [4]byte{}
+			copy(p[:], t)
+			v = append(v, p[:]...)
+		}
+		appendRecord(capabilityRecordMessageTypes, v)
+	}
+
+	if c.OnionAddress != "" {
+		host, port, err := iputil.SplitAddr(c.OnionAddress)
+		if err != nil {
+			logger.WithError(err).Panic("encodeCapabilities invalid OnionAddress")
+		}
+		onion, err := pex.DecodeOnion(host)
+		if err != nil {
+			logger.WithError(err).Panic("encodeCapabilities invalid OnionAddress")
+		}
+
+		v := make([]byte, pex.OnionV3AddrLen+2)
+		copy(v, onion)
+		binary.LittleEndian.PutUint16(v[pex.OnionV3AddrLen:], port)
+		appendRecord(capabilityRecordOnionAddress, v)
+	}
+
+	if c.LowestBlockSeq != 0 {
+		v := make([]byte, 8)
+		binary.LittleEndian.PutUint64(v, c.LowestBlockSeq)
+		appendRecord(capabilityRecordLowestBlockSeq, v)
+	}
+
+	return b
+}
+
+// hasCapabilities returns true if b starts with a capabilities section
+func hasCapabilities(b []byte) bool {
+	return bytes.HasPrefix(b, capabilitiesMarker)
+}
+
+// decodeCapabilities decodes a capabilities section. Records of unknown types are skipped.
+func decodeCapabilities(b []byte) (Capabilities, error) {
+	if !hasCapabilities(b) {
+		return Capabilities{}, ErrCapabilitiesMarkerMissing
+	}
+	b = b[len(capabilitiesMarker):]
+
+	var c Capabilities
+	seen := make(map[uint16]struct{})
+
+	for len(b) > 0 {
+		if len(b) < 4 {
+			return Capabilities{}, ErrCapabilitiesRecordTruncated
+		}
+
+		t := binary.LittleEndian.Uint16(b[:2])
+		n := int(binary.LittleEndian.Uint16(b[2:4]))
+		b = b[4:]
+		if len(b) < n {
+			return Capabilities{}, ErrCapabilitiesRecordTruncated
+		}
+		v := b[:n]
+		b = b[n:]
+
+		if _, ok := seen[t]; ok {
+			return Capabilities{}, ErrCapabilitiesRecordDuplicate
+		}
+		seen[t] = struct{}{}
+
+		switch t {
+		case capabilityRecordServices:
+			if n != 8 {
+				return Capabilities{}, ErrCapabilitiesRecordInvalidLength
+			}
+			c.Services = binary.LittleEndian.Uint64(v)
+		case capabilityRecordMessageLengths:
+			if n != 16 {
+				return Capabilities{}, ErrCapabilitiesRecordInvalidLength
+			}
+			c.MaxIncomingMessageLength = binary.LittleEndian.Uint64(v[:8])
+			c.MaxOutgoingMessageLength = binary.LittleEndian.Uint64(v[8:])
+		case capabilityRecordMessageTypes:
+			if n%4 != 0 {
+				return Capabilities{}, ErrCapabilitiesRecordInvalidLength
+			}
+			c.MessageTypes = make([]string, 0, n/4)
+			for i := 0; i < n; i += 4 {
+				c.MessageTypes = append(c.MessageTypes, string(v[i:i+4]))
+			}
+		case capabilityRecordOnionAddress:
+			if n != pex.OnionV3AddrLen+2 {
+				return Capabilities{}, ErrCapabilitiesRecordInvalidLength
+			}
+			host, err := pex.EncodeOnion(v[:pex.OnionV3AddrLen])
+			if err != nil {
+				return Capabilities{}, ErrCapabilitiesRecordInvalidValue
+			}
+			c.OnionAddress = fmt.Sprintf("%s:%d", host, binary.LittleEndian.Uint16(v[pex.OnionV3AddrLen:]))
+		case capabilityRecordLowestBlockSeq:
+			if n != 8 {
+				return Capabilities{}, ErrCapabilitiesRecordInvalidLength
+			}
+			c.LowestBlockSeq = binary.LittleEndian.Uint64(v)
+		}
+	}
+
+	return c, nil
+}
+
+// capabilities returns the capabilities sent in our IntroductionMessage
+func (dm *Daemon) capabilities() Capabilities {
+	services := ServicePeerTimestamps
+	if !dm.config.DisableCompactBlocks {
+		services |= ServiceCompactBlocks
+	}
+	if !dm.config.DisableDandelion {
+		services |= ServiceDandelion
+	}
+
+	messageTypes := make([]string, len(dm.Messages.Config.Messages))
+	for i, m := range dm.Messages.Config.Messages {
+		messageTypes[i] = string(m.Prefix[:])
+	}
+
+	// A pruned node advertises that it can't send the blocks whose bodies were pruned
+	lowestBlockSeq, err := dm.visor.LowestBlockSeq()
+	if err != nil {
+		logger.WithError(err).Error("visor.LowestBlockSeq failed")
+	}
+
+	return Capabilities{
+		Services:                 services,
+		MaxIncomingMessageLength: dm.config.MaxIncomingMessageLength,
+		MaxOutgoingMessageLength: dm.config.MaxOutgoingMessageLength,
+		MessageTypes:             messageTypes,
+		OnionAddress:             dm.config.OnionAddress,
+		LowestBlockSeq:           lowestBlockSeq,
+	}
+}
+
+// messageType returns the prefix of a registered message
+func (dm *Daemon) messageType(msg gnet.Message) (string, bool) {
+	t := reflect.TypeOf(msg)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	for _, m := range dm.Messages.Config.Messages {
+		if reflect.TypeOf(m.Message) == t {
+			return string(m.Prefix[:]), true
+		}
+	}
+
+	return "", false
+}
+
+// supportsMessage returns true if the connection accepts a message, according to the message types of its capabilities
+func (dm *Daemon) supportsMessage(c *connection, msg gnet.Message) bool {
+	prefix, ok := dm.messageType(msg)
+	if !ok {
+		return true
+	}
+	return c.Capabilities.SupportsMessageType(prefix)
+}
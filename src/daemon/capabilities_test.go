@@ -0,0 +1,210 @@
+package daemon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/params"
+	"github.com/skycoin/skycoin/src/testutil"
+)
+
+func TestCapabilitiesEncodeDecode(t *testing.T) {
+	cases := []struct {
+		name string
+		c    Capabilities
+	}{
+		{
+			name: "services only",
+			c: Capabilities{
+				Services: ServiceCompactBlocks,
+			},
+		},
+		{
+			name: "all records",
+			c: Capabilities{
+				Services:                 ServiceCompactBlocks | ServiceDandelion,
+				MaxIncomingMessageLength: 1024 * 1024,
+				MaxOutgoingMessageLength: 256 * 1024,
+				MessageTypes:             []string{"INTR", "CMPB", "STEM"},
+				OnionAddress:             "pg6mmjiyjmcrsslvykfwnntlaru7p5svn6y2ymmju6nubxndf4pscryd.onion:6000",
+				LowestBlockSeq:           1234,
+			},
+		},
+		{
+			name: "lowest block seq only",
+			c: Capabilities{
+				LowestBlockSeq: 100,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := encodeCapabilities(tc.c)
+			require.True(t, hasCapabilities(b))
+			c, err := decodeCapabilities(b)
+			require.NoError(t, err)
+			require.Equal(t, tc.c, c)
+		})
+	}
+}
+
+func TestDecodeCapabilities(t *testing.T) {
+	services := []byte{1, 0, 8, 0, 3, 0, 0, 0, 0, 0, 0, 0}
+
+	cases := []struct {
+		name string
+		b    []byte
+		c    Capabilities
+		err  error
+	}{
+		{
+			name: "no marker",
+			b:    services,
+			err:  ErrCapabilitiesMarkerMissing,
+		},
+		{
+			name: "empty",
+			b:    capabilitiesMarker,
+		},
+		{
+			name: "services",
+			b:    append(append([]byte{}, capabilitiesMarker...), services...),
+			c: Capabilities{
+				Services: ServiceCompactBlocks | ServiceDandelion,
+			},
+		},
+		{
+			name: "unknown record is skipped",
+			b:    append(append([]byte("CAPS"), 99, 0, 2, 0, 1, 2), services...),
+			c: Capabilities{
+				Services: ServiceCompactBlocks | ServiceDandelion,
+			},
+		},
+		{
+			name: "truncated record header",
+			b:    []byte("CAPS\x01\x00\x08"),
+			err:  ErrCapabilitiesRecordTruncated,
+		},
+		{
+			name: "truncated record value",
+			b:    append([]byte("CAPS"), services[:10]...),
+			err:  ErrCapabilitiesRecordTruncated,
+		},
+		{
+			name: "invalid services length",
+			b:    []byte("CAPS\x01\x00\x02\x00\x01\x00"),
+			err:  ErrCapabilitiesRecordInvalidLength,
+		},
+		{
+			name: "invalid message types length",
+			b:    []byte("CAPS\x03\x00\x05\x00INTRG"),
+			err:  ErrCapabilitiesRecordInvalidLength,
+		},
+		{
+			name: "invalid onion address length",
+			b:    append([]byte("CAPS\x04\x00\x21\x00"), make([]byte, 33)...),
+			err:  ErrCapabilitiesRecordInvalidLength,
+		},
+		{
+			name: "invalid onion address version",
+			b:    append([]byte("CAPS\x04\x00\x25\x00"), make([]byte, 37)...),
+			err:  ErrCapabilitiesRecordInvalidValue,
+		},
+		{
+			name: "invalid lowest block seq length",
+			b:    []byte("CAPS\x05\x00\x04\x00\x01\x00\x00\x00"),
+			err:  ErrCapabilitiesRecordInvalidLength,
+		},
+		{
+			name: "duplicate record",
+			b:    append(append([]byte("CAPS"), services...), services...),
+			err:  ErrCapabilitiesRecordDuplicate,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := decodeCapabilities(tc.b)
+			if tc.err != nil {
+				require.Equal(t, tc.err, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.c, c)
+		})
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	var c Capabilities
+	require.True(t, c.empty())
+	require.True(t, c.HasServices(0))
+	require.False(t, c.HasServices(ServiceCompactBlocks))
+	require.True(t, c.SupportsMessageType("STEM"))
+	require.Empty(t, c.ServiceNames())
+
+	c = Capabilities{
+		Services:     ServiceDandelion | ServiceCompactBlocks | 1<<40,
+		MessageTypes: []string{"INTR", "GIVB"},
+	}
+	require.False(t, c.empty())
+	require.True(t, c.HasServices(ServiceCompactBlocks|ServiceDandelion))
+	require.False(t, c.HasServices(ServicePeerTimestamps))
+	require.True(t, c.SupportsMessageType("GIVB"))
+	require.False(t, c.SupportsMessageType("STEM"))
+	require.Equal(t, []string{"compact_blocks", "dandelion"}, c.ServiceNames())
+
+	c.Services |= ServicePeerTimestamps
+	require.Equal(t, []string{"compact_blocks", "dandelion", "peer_timestamps"}, c.ServiceNames())
+}
+
+func TestIntroductionMessageCapabilities(t *testing.T) {
+	pubkey, _ := cipher.GenerateKeyPair()
+	genesisHash := testutil.RandSHA256(t)
+	verifyParams := params.VerifyTxn{
+		BurnFactor:          2,
+		MaxTransactionSize:  32768,
+		MaxDropletPrecision: 3,
+	}
+
+	dc := DaemonConfig{
+		Mirror:           10000,
+		BlockchainPubkey: pubkey,
+	}
+
+	capabilities := Capabilities{
+		Services:                 ServiceCompactBlocks,
+		MaxIncomingMessageLength: 1024,
+		MaxOutgoingMessageLength: 2048,
+		MessageTypes:             []string{"INTR", "CMPB"},
+	}
+
+	// Capabilities are omitted if empty
+	extra := newIntroductionMessageExtra(pubkey, "skycoin:0.26.0", verifyParams, genesisHash, Capabilities{})
+	extraWithCapabilities := newIntroductionMessageExtra(pubkey, "skycoin:0.26.0", verifyParams, genesisHash, capabilities)
+	require.Equal(t, extra, extraWithCapabilities[:len(extra)])
+	require.Equal(t, encodeCapabilities(capabilities), extraWithCapabilities[len(extra):])
+
+	intro := NewIntroductionMessage(10001, 3, 6000, pubkey, "skycoin:0.26.0", verifyParams, genesisHash, Capabilities{})
+	require.NoError(t, intro.Verify(dc, nil))
+	require.Equal(t, Capabilities{}, intro.Capabilities)
+	require.Equal(t, genesisHash, intro.GenesisHash)
+
+	intro = NewIntroductionMessage(10001, 3, 6000, pubkey, "skycoin:0.26.0", verifyParams, genesisHash, capabilities)
+	require.NoError(t, intro.Verify(dc, nil))
+	require.Equal(t, capabilities, intro.Capabilities)
+	require.Equal(t, genesisHash, intro.GenesisHash)
+
+	// A malformed capabilities section is rejected
+	intro.Capabilities = Capabilities{}
+	intro.Extra = intro.Extra[:len(intro.Extra)-2]
+	require.Equal(t, ErrDisconnectInvalidExtraData, intro.Verify(dc, nil))
+
+	// Additional data that is not a capabilities section is ignored
+	intro.Extra = append(extra, []byte("additional data")...)
+	require.NoError(t, intro.Verify(dc, nil))
+	require.Equal(t, Capabilities{}, intro.Capabilities)
+}
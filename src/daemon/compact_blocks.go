@@ -0,0 +1,369 @@
+package daemon
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/visor"
+)
+
+var (
+	// ErrCompactBlockTooManyTxns a compact block has more transactions than a block can have
+	ErrCompactBlockTooManyTxns = errors.New("Compact block has too many transactions")
+	// ErrCompactBlockInvalidPrefilledIndex a prefilled transaction index is out of range or not in ascending order
+	ErrCompactBlockInvalidPrefilledIndex = errors.New("Compact block prefilled transaction index is invalid")
+	// ErrCompactBlockTxnsCountMismatch the number of transactions received does not match the missing transactions of a compact block
+	ErrCompactBlockTxnsCountMismatch = errors.New("Number of transactions does not match the missing transactions of the compact block")
+	// ErrCompactBlockBodyHashMismatch the reconstructed transactions of a compact block do not match the header body hash
+	ErrCompactBlockBodyHashMismatch = errors.New("Compact block transactions do not match the header body hash")
+)
+
+// compactBlockShortIDKey returns the key of the short transaction IDs of a compact block.
+// The key depends on a nonce chosen by the sender, so that short ID collisions differ between peers.
+func compactBlockShortIDKey(headerHash cipher.SHA256, nonce uint64) cipher.SHA256 {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], nonce)
+	return cipher.AddSHA256(headerHash, cipher.SumSHA256(b[:]))
+}
+
+// compactBlockShortID returns the short ID of a transaction in a compact block
+func compactBlockShortID(key, txnHash cipher.SHA256) uint64 {
+	h := cipher.AddSHA256(key, txnHash)
+	return binary.LittleEndian.Uint64(h[:8])
+}
+
+// reconstructCompactBlock rebuilds the block of a compact block from its prefilled transactions
+// and the known transactions.
+// Returns the block and the indexes of the transactions that could not be found,
+// which are left empty in the block.
+// Short IDs that match more than one known transaction are treated as missing.
+func reconstructCompactBlock(m *CompactBlockMessage, known coin.Transactions) (coin.SignedBlock, []uint32, error) {
+	n := len(m.ShortIDs) + len(m.Prefilled)
+	if n > 65535 {
+		return coin.SignedBlock{}, nil, ErrCompactBlockTooManyTxns
+	}
+
+	txns := make(coin.Transactions, n)
+	prefilled := make([]bool, n)
+	for i, p := range m.Prefilled {
+		if int(p.Index) >= n || (i > 0 && p.Index <= m.Prefilled[i-1].Index) {
+			return coin.SignedBlock{}, nil, ErrCompactBlockInvalidPrefilledIndex
+		}
+		txns[p.Index] = p.Transaction
+		prefilled[p.Index] = true
+	}
+
+	key := compactBlockShortIDKey(m.Header.Hash(), m.Nonce)
+
+	// Maps short IDs to the index of the known transaction, or -1 if the short ID is ambiguous
+	shortIDs := make(map[uint64]int, len(known))
+	for i, txn := range known {
+		id := compactBlockShortID(key, txn.Hash())
+		if _, ok := shortIDs[id]; ok {
+			shortIDs[id] = -1
+		} else {
+			shortIDs[id] = i
+		}
+	}
+
+	var missing []uint32
+	j := 0
+	for i := range txns {
+		if prefilled[i] {
+			continue
+		}
+
+		if k, ok := shortIDs[m.ShortIDs[j]]; ok && k >= 0 {
+			txns[i] = known[k]
+		} else {
+			missing = append(missing, uint32(i))
+		}
+		j++
+	}
+
+	b := coin.SignedBlock{
+		Block: coin.Block{
+			Head: m.Header,
+			Body: coin.BlockBody{
+				Transactions: txns,
+			},
+		},
+		Sig: m.Sig,
+	}
+
+	return b, missing, nil
+}
+
+// fillCompactBlock sets the missing transactions of a reconstructed compact block
+// and verifies the transactions against the header body hash
+func fillCompactBlock(b *coin.SignedBlock, missing []uint32, txns coin.Transactions) error {
+	if len(txns) != len(missing) {
+		return ErrCompactBlockTxnsCountMismatch
+	}
+
+	for i, idx := range missing {
+		b.Block.Body.Transactions[idx] = txns[i]
+	}
+
+	return verifyCompactBlockBody(*b)
+}
+
+// verifyCompactBlockBody verifies that the reconstructed transactions of a compact block match the header body hash
+func verifyCompactBlockBody(b coin.SignedBlock) error {
+	if b.Block.Body.Hash() != b.Block.Head.BodyHash {
+		return ErrCompactBlockBodyHashMismatch
+	}
+	return nil
+}
+
+// pendingCompactBlock is a reconstructed compact block waiting for its missing transactions
+type pendingCompactBlock struct {
+	addr    string
+	block   coin.SignedBlock
+	missing []uint32
+}
+
+// compactBlocks records the compact blocks waiting for their missing transactions, keyed by block hash
+type compactBlocks struct {
+	sync.Mutex
+	pending map[cipher.SHA256]*pendingCompactBlock
+}
+
+func newCompactBlocks() *compactBlocks {
+	return &compactBlocks{
+		pending: make(map[cipher.SHA256]*pendingCompactBlock),
+	}
+}
+
+// add records a pending compact block and removes the pending compact blocks at or below headSeq.
+// Returns false if the missing transactions of the block were already requested.
+func (c *compactBlocks) add(p *pendingCompactBlock, headSeq uint64) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	for hash, q := range c.pending {
+		if q.block.Seq() <= headSeq {
+			delete(c.pending, hash)
+		}
+	}
+
+	hash := p.block.HashHeader()
+	if _, ok := c.pending[hash]; ok {
+		return false
+	}
+
+	c.pending[hash] = p
+	return true
+}
+
+// remove removes and returns the pending compact block with the hash, if its missing transactions were requested from addr
+func (c *compactBlocks) remove(addr string, hash cipher.SHA256) *pendingCompactBlock {
+	c.Lock()
+	defer c.Unlock()
+
+	p, ok := c.pending[hash]
+	if !ok || p.addr != addr {
+		return nil
+	}
+
+	delete(c.pending, hash)
+	return p
+}
+
+// removePeer removes the pending compact blocks whose missing transactions were requested from addr
+func (c *compactBlocks) removePeer(addr string) {
+	c.Lock()
+	defer c.Unlock()
+
+	for hash, p := range c.pending {
+		if p.addr == addr {
+			delete(c.pending, hash)
+		}
+	}
+}
+
+// supportsCompactBlocks returns true if the connection accepts CompactBlockMessage
+func (dm *Daemon) supportsCompactBlocks(c *connection) bool {
+	return !dm.config.DisableCompactBlocks && c.HasIntroduced() && c.Capabilities.HasServices(ServiceCompactBlocks)
+}
+
+// unannouncedTxns returns the hashes of the unconfirmed transactions that we never announced.
+// Peers are unlikely to know these transactions, so they are prefilled in compact blocks.
+func (dm *Daemon) unannouncedTxns() (map[cipher.SHA256]struct{}, error) {
+	never := time.Time{}.UnixNano()
+	txns, err := dm.visor.GetUnconfirmedTransactions(func(txn visor.UnconfirmedTransaction) bool {
+		return txn.Announced == never
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make(map[cipher.SHA256]struct{}, len(txns))
+	for _, txn := range txns {
+		hashes[txn.Transaction.Hash()] = struct{}{}
+	}
+
+	return hashes, nil
+}
+
+// receiveCompactBlock reconstructs the block of a compact block from the unconfirmed pool.
+// If transactions are missing, they are requested from the peer with a GetBlockTxnsMessage.
+// Compact blocks that don't follow our head block are ignored, and blocks are requested from the peer if it is ahead.
+func (dm *Daemon) receiveCompactBlock(addr string, m *CompactBlockMessage) {
+	seq := m.Header.BkSeq
+	fields := logrus.Fields{
+		"addr": addr,
+		"seq":  seq,
+	}
+
+	headSeq, ok, err := dm.visor.HeadBkSeq()
+	if err != nil {
+		logger.WithError(err).WithFields(fields).Error("visor.HeadBkSeq failed")
+		return
+	}
+	if !ok {
+		logger.WithFields(fields).Error("No HeadBkSeq found, cannot process compact block")
+		return
+	}
+
+	if seq <= headSeq {
+		return
+	}
+
+	if seq > headSeq+1 {
+		logger.WithFields(fields).WithField("headSeq", headSeq).Debug("Compact block is ahead of our head block, requesting blocks")
+		if err := dm.requestBlocksFromAddr(addr); err != nil {
+			logger.WithError(err).WithFields(fields).Warning("requestBlocksFromAddr failed")
+		}
+		return
+	}
+
+	// Verify the header before reconstructing the block, to avoid doing work for a forged header
+	h := SignedBlockHeader{
+		Header: m.Header,
+		Sig:    m.Sig,
+	}
+	if err := h.VerifySignature(dm.config.BlockchainPubkey); err != nil {
+		logger.WithError(err).WithFields(fields).Warning("Compact block header signature is invalid")
+		dm.adjustPeerScore(addr, behaviorInvalidBlock)
+		return
+	}
+
+	unconfirmed, err := dm.visor.GetAllUnconfirmedTransactions()
+	if err != nil {
+		logger.WithError(err).WithFields(fields).Error("visor.GetAllUnconfirmedTransactions failed")
+		return
+	}
+
+	known := make(coin.Transactions, len(unconfirmed))
+	for i, u := range unconfirmed {
+		known[i] = u.Transaction
+	}
+
+	b, missing, err := reconstructCompactBlock(m, known)
+	if err != nil {
+		logger.WithError(err).WithFields(fields).Warning("Received invalid compact block")
+		dm.adjustPeerScore(addr, behaviorProtocolViolation)
+		return
+	}
+
+	if len(missing) == 0 {
+		dm.executeCompactBlock(addr, b)
+		return
+	}
+
+	if !dm.compactBlocks.add(&pendingCompactBlock{
+		addr:    addr,
+		block:   b,
+		missing: missing,
+	}, headSeq) {
+		logger.WithFields(fields).Debug("Missing transactions of compact block were already requested")
+		return
+	}
+
+	logger.WithFields(fields).Debugf("Compact block is missing %d of %d transactions, requesting them", len(missing), len(b.Block.Body.Transactions))
+
+	if err := dm.sendMessage(addr, NewGetBlockTxnsMessage(b.HashHeader(), missing)); err != nil {
+		logger.WithError(err).WithFields(fields).Warning("Send GetBlockTxnsMessage failed")
+		dm.compactBlocks.remove(addr, b.HashHeader())
+	}
+}
+
+// receiveBlockTxns completes a pending compact block with the missing transactions received from a peer and executes it.
+// If the transactions do not complete the block, the full block is requested from the peer.
+func (dm *Daemon) receiveBlockTxns(addr string, hash cipher.SHA256, txns coin.Transactions) {
+	p := dm.compactBlocks.remove(addr, hash)
+	if p == nil {
+		logger.WithField("addr", addr).WithField("hash", hash.Hex()).Debug("Received transactions for a compact block that is not pending")
+		return
+	}
+
+	fields := logrus.Fields{
+		"addr": addr,
+		"seq":  p.block.Seq(),
+	}
+
+	// A peer that doesn't have the block replies without transactions
+	if len(txns) == 0 {
+		logger.WithFields(fields).Debug("Peer did not send the missing transactions of the compact block")
+		dm.requestFullBlock(addr, p.block.Seq())
+		return
+	}
+
+	if err := fillCompactBlock(&p.block, p.missing, txns); err != nil {
+		logger.WithError(err).WithFields(fields).Warning("Received invalid transactions for compact block")
+		dm.adjustPeerScore(addr, behaviorProtocolViolation)
+		dm.requestFullBlock(addr, p.block.Seq())
+		return
+	}
+
+	dm.executeCompactBlock(addr, p.block)
+}
+
+// executeCompactBlock executes a reconstructed compact block and announces it to peers.
+// If the reconstructed transactions do not match the header, which can happen on a short ID collision,
+// the full block is requested from the peer.
+func (dm *Daemon) executeCompactBlock(addr string, b coin.SignedBlock) {
+	fields := logrus.Fields{
+		"addr": addr,
+		"seq":  b.Seq(),
+	}
+
+	if err := verifyCompactBlockBody(b); err != nil {
+		logger.WithError(err).WithFields(fields).Info("Compact block could not be reconstructed")
+		dm.requestFullBlock(addr, b.Seq())
+		return
+	}
+
+	if err := dm.executeSignedBlock(b); err != nil {
+		logger.Critical().WithError(err).WithFields(fields).Error("Failed to execute compact block")
+		dm.adjustPeerScore(addr, behaviorInvalidBlock)
+		return
+	}
+
+	logger.Critical().WithFields(fields).Info("Added new block")
+	dm.adjustPeerScore(addr, behaviorUsefulBlock)
+	dm.blockSync.setHead(b.Seq(), b.HashHeader())
+
+	abm := NewAnnounceBlocksMessage(b.Seq())
+	if _, err := dm.broadcastMessage(abm); err != nil {
+		logger.WithError(err).Warning("Broadcast AnnounceBlocksMessage failed")
+	}
+}
+
+// requestFullBlock falls back to requesting a block with a GetBlocksMessage
+func (dm *Daemon) requestFullBlock(addr string, seq uint64) {
+	m := NewGetBlocksMessage(seq-1, 1)
+	if err := dm.sendMessage(addr, m); err != nil {
+		logger.WithError(err).WithField("addr", addr).Warning("Send GetBlocksMessage failed")
+		return
+	}
+
+	dm.recordBlocksRequest(dm.connections.get(addr), seq-1)
+}
@@ -0,0 +1,169 @@
+package daemon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/daemon/gnet"
+	"github.com/skycoin/skycoin/src/testutil"
+)
+
+func makeCompactBlockTestBlock(t *testing.T, n int) coin.SignedBlock {
+	txns := make(coin.Transactions, n)
+	for i := range txns {
+		txns[i] = coin.Transaction{
+			Length:    uint32(i),
+			InnerHash: testutil.RandSHA256(t),
+		}
+	}
+
+	body := coin.BlockBody{
+		Transactions: txns,
+	}
+
+	return coin.SignedBlock{
+		Block: coin.Block{
+			Head: coin.BlockHeader{
+				BkSeq:    10,
+				Time:     1000,
+				PrevHash: testutil.RandSHA256(t),
+				BodyHash: body.Hash(),
+			},
+			Body: body,
+		},
+		Sig: testutil.RandSig(t),
+	}
+}
+
+func TestReconstructCompactBlock(t *testing.T) {
+	b := makeCompactBlockTestBlock(t, 5)
+	txns := b.Block.Body.Transactions
+
+	prefill := map[cipher.SHA256]struct{}{
+		txns[0].Hash(): {},
+		txns[3].Hash(): {},
+	}
+	m := NewCompactBlockMessage(b, 12345, prefill)
+	require.Len(t, m.ShortIDs, 3)
+	require.Len(t, m.Prefilled, 2)
+	require.Equal(t, uint32(0), m.Prefilled[0].Index)
+	require.Equal(t, uint32(3), m.Prefilled[1].Index)
+
+	// All transactions are known
+	rb, missing, err := reconstructCompactBlock(m, coin.Transactions{txns[4], txns[2], txns[1]})
+	require.NoError(t, err)
+	require.Empty(t, missing)
+	require.Equal(t, b, rb)
+	require.NoError(t, verifyCompactBlockBody(rb))
+
+	// Transactions 2 and 4 are not known
+	known := coin.Transactions{txns[1], {InnerHash: testutil.RandSHA256(t)}}
+	rb, missing, err = reconstructCompactBlock(m, known)
+	require.NoError(t, err)
+	require.Equal(t, []uint32{2, 4}, missing)
+	require.Equal(t, ErrCompactBlockBodyHashMismatch, verifyCompactBlockBody(rb))
+
+	// The number of transactions must match the missing transactions
+	rb2 := rb
+	rb2.Block.Body.Transactions = append(coin.Transactions{}, rb.Block.Body.Transactions...)
+	require.Equal(t, ErrCompactBlockTxnsCountMismatch, fillCompactBlock(&rb2, missing, coin.Transactions{txns[2]}))
+
+	// The transactions must match the body hash
+	require.Equal(t, ErrCompactBlockBodyHashMismatch, fillCompactBlock(&rb2, missing, coin.Transactions{txns[4], txns[2]}))
+
+	require.NoError(t, fillCompactBlock(&rb, missing, coin.Transactions{txns[2], txns[4]}))
+	require.Equal(t, b, rb)
+
+	// A short ID that matches multiple known transactions is missing
+	key := compactBlockShortIDKey(b.HashHeader(), m.Nonce)
+	dup := coin.Transactions{txns[1], txns[1], txns[2], txns[4]}
+	require.Equal(t, compactBlockShortID(key, dup[0].Hash()), compactBlockShortID(key, dup[1].Hash()))
+	_, missing, err = reconstructCompactBlock(m, dup)
+	require.NoError(t, err)
+	require.Equal(t, []uint32{1}, missing)
+
+	// A different nonce changes the short IDs
+	m2 := NewCompactBlockMessage(b, 54321, prefill)
+	require.NotEqual(t, m.ShortIDs, m2.ShortIDs)
+
+	// Prefilled indexes must be in range and ascending
+	bad := *m
+	bad.Prefilled = []PrefilledTransaction{m.Prefilled[1], m.Prefilled[0]}
+	_, _, err = reconstructCompactBlock(&bad, nil)
+	require.Equal(t, ErrCompactBlockInvalidPrefilledIndex, err)
+
+	bad.Prefilled = []PrefilledTransaction{m.Prefilled[0], {Index: 5}}
+	_, _, err = reconstructCompactBlock(&bad, nil)
+	require.Equal(t, ErrCompactBlockInvalidPrefilledIndex, err)
+}
+
+func TestCompactBlocksPending(t *testing.T) {
+	c := newCompactBlocks()
+
+	b10 := makeCompactBlockTestBlock(t, 1)
+	b11 := makeCompactBlockTestBlock(t, 1)
+	b11.Block.Head.BkSeq = 11
+
+	require.True(t, c.add(&pendingCompactBlock{addr: "1.1.1.1:6000", block: b10}, 9))
+	require.False(t, c.add(&pendingCompactBlock{addr: "2.2.2.2:6000", block: b10}, 9))
+
+	// Only the peer that the transactions were requested from can complete the block
+	require.Nil(t, c.remove("2.2.2.2:6000", b10.HashHeader()))
+	p := c.remove("1.1.1.1:6000", b10.HashHeader())
+	require.NotNil(t, p)
+	require.Equal(t, b10, p.block)
+	require.Nil(t, c.remove("1.1.1.1:6000", b10.HashHeader()))
+
+	// Pending blocks at or below the head are removed
+	require.True(t, c.add(&pendingCompactBlock{addr: "1.1.1.1:6000", block: b10}, 9))
+	require.True(t, c.add(&pendingCompactBlock{addr: "2.2.2.2:6000", block: b11}, 10))
+	require.Len(t, c.pending, 1)
+	require.Contains(t, c.pending, b11.HashHeader())
+
+	c.removePeer("2.2.2.2:6000")
+	require.Empty(t, c.pending)
+}
+
+func TestGetBlockTxnsMessageProcess(t *testing.T) {
+	b := makeCompactBlockTestBlock(t, 3)
+	hash := b.HashHeader()
+	addr := "127.0.0.1:1234"
+
+	newMessage := func(indexes []uint32) *GetBlockTxnsMessage {
+		m := NewGetBlockTxnsMessage(hash, indexes)
+		m.c = &gnet.MessageContext{
+			ConnID: 10,
+			Addr:   addr,
+		}
+		return m
+	}
+
+	// The requested transactions are sent
+	d := &mockDaemoner{}
+	d.On("DaemonConfig").Return(DaemonConfig{})
+	d.On("getSignedBlockByHash", hash).Return(&b, nil)
+	d.On("sendMessage", addr, NewGiveBlockTxnsMessage(hash, coin.Transactions{b.Block.Body.Transactions[0], b.Block.Body.Transactions[2]})).Return(nil)
+	newMessage([]uint32{0, 2}).process(d)
+	d.AssertExpectations(t)
+
+	// An unknown block is answered without transactions
+	d = &mockDaemoner{}
+	d.On("DaemonConfig").Return(DaemonConfig{})
+	d.On("getSignedBlockByHash", hash).Return(nil, nil)
+	d.On("sendMessage", addr, NewGiveBlockTxnsMessage(hash, nil)).Return(nil)
+	newMessage([]uint32{0, 2}).process(d)
+	d.AssertExpectations(t)
+
+	// An index out of range is a protocol violation
+	d = &mockDaemoner{}
+	d.On("DaemonConfig").Return(DaemonConfig{})
+	d.On("getSignedBlockByHash", hash).Return(&b, nil)
+	d.On("adjustPeerScore", addr, behaviorProtocolViolation).Return()
+	newMessage([]uint32{0, 3}).process(d)
+	d.AssertExpectations(t)
+	d.AssertNotCalled(t, "sendMessage", addr, mock.Anything)
+}
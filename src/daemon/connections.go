@@ -65,6 +65,7 @@ type ConnectionDetails struct {
 	UserAgent            useragent.Data
 	UnconfirmedVerifyTxn params.VerifyTxn
 	GenesisHash          cipher.SHA256
+	Capabilities         Capabilities
 }
 
 // HasIntroduced returns true if the connection has introduced
@@ -300,6 +301,7 @@ func (c *Connections) introduced(addr string, gnetID uint64, m *IntroductionMess
 	conn.UserAgent = m.UserAgent
 	conn.UnconfirmedVerifyTxn = m.UnconfirmedVerifyTxn
 	conn.GenesisHash = m.GenesisHash
+	conn.Capabilities = m.Capabilities
 
 	if !conn.Outgoing {
 		listenAddr := conn.ListenAddr()
@@ -399,6 +401,16 @@ func (c *Connections) SetHeight(addr string, gnetID uint64, height uint64) error
 	})
 }
 
+// SetLowestBlockSeq sets the lowest block seq of the capabilities of a connection
+func (c *Connections) SetLowestBlockSeq(addr string, gnetID uint64, seq uint64) error {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.modify(addr, gnetID, func(c *ConnectionDetails) {
+		c.Capabilities.LowestBlockSeq = seq
+	})
+}
+
 func (c *Connections) updateMirror(ip string, mirror uint32, port uint16) error {
 	x := c.mirrors[mirror]
 	if x == nil {
@@ -2,6 +2,7 @@ package daemon
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -376,10 +377,10 @@ func TestConnectionsMultipleSameListenPort(t *testing.T) {
 
 	listenAddrConns := conns.getByListenAddr(addr1)
 	require.Len(t, listenAddrConns, 2)
-	require.True(t, *listenAddrConns[0] == *c || *listenAddrConns[0] == *c2)
-	if *listenAddrConns[0] == *c {
+	require.True(t, reflect.DeepEqual(*listenAddrConns[0], *c) || reflect.DeepEqual(*listenAddrConns[0], *c2))
+	if reflect.DeepEqual(*listenAddrConns[0], *c) {
 		require.Equal(t, c2, listenAddrConns[1])
-	} else if *listenAddrConns[0] == *c2 {
+	} else if reflect.DeepEqual(*listenAddrConns[0], *c2) {
 		require.Equal(t, c, listenAddrConns[1])
 	}
 
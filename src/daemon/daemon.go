@@ -4,9 +4,11 @@ Package daemon controls the networking layer of the skycoin daemon
 package daemon
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"math/rand"
+	"net"
 	"reflect"
 	"sort"
 	"strings"
@@ -35,6 +37,8 @@ var (
 	ErrNetworkingDisabled = errors.New("Networking is disabled")
 	// ErrNoPeerAcceptsTxn is returned if no peer will propagate a transaction broadcasted with BroadcastUserTransaction
 	ErrNoPeerAcceptsTxn = errors.New("No peer will propagate this transaction")
+	// ErrMessageTypeNotSupported is returned if a message is sent to a peer that does not accept its message type
+	ErrMessageTypeNotSupported = errors.New("Peer does not support this message type")
 
 	logger = logging.MustGetLogger("daemon")
 )
@@ -95,6 +99,32 @@ func (cfg *Config) preprocess() (Config, error) {
 	config.Pool.port = config.Daemon.Port
 	config.Pool.address = config.Daemon.Address
 
+	if config.Daemon.ProxyOnly {
+		if config.Pool.Proxy == "" {
+			return Config{}, errors.New("ProxyOnly requires a proxy")
+		}
+		config.Daemon.DisableIncomingConnections = true
+		// DNS seeds are resolved without the proxy, which would leak that the node is running
+		if len(config.Pex.DNSSeeds) > 0 {
+			logger.Info("DNS seeds are disabled because all connections are made through the proxy.")
+			config.Pex.DNSSeeds = nil
+		}
+	}
+	config.Pool.proxyOnlyOnion = !config.Daemon.ProxyOnly
+	config.Pex.Proxy = config.Pool.Proxy
+	config.Pex.ProxyUsername = config.Pool.ProxyUsername
+	config.Pex.ProxyPassword = config.Pool.ProxyPassword
+
+	if config.Daemon.OnionAddress != "" {
+		host, _, err := iputil.SplitAddr(config.Daemon.OnionAddress)
+		if err != nil {
+			return Config{}, fmt.Errorf("Invalid OnionAddress: %v", err)
+		}
+		if _, err := pex.DecodeOnion(host); err != nil {
+			return Config{}, fmt.Errorf("Invalid OnionAddress: %v", err)
+		}
+	}
+
 	if config.Daemon.DisableNetworking {
 		logger.Info("Networking is disabled")
 		config.Pex.Disabled = true
@@ -107,12 +137,19 @@ func (cfg *Config) preprocess() (Config, error) {
 		if config.Daemon.DisableOutgoingConnections {
 			logger.Info("Outgoing connections are disabled.")
 		}
+		if config.Daemon.ProxyOnly {
+			logger.WithField("proxy", config.Pool.Proxy).Info("All outgoing connections are made through the proxy.")
+		}
 	}
 
 	if config.Daemon.MaxConnections < config.Daemon.MaxOutgoingConnections {
 		return Config{}, errors.New("MaxOutgoingConnections cannot be more than MaxConnections")
 	}
 
+	if config.Daemon.DandelionFluffProbability < 0 || config.Daemon.DandelionFluffProbability > 1 {
+		return Config{}, errors.New("DandelionFluffProbability must be between 0 and 1")
+	}
+
 	if config.Daemon.MaxPendingConnections > config.Daemon.MaxOutgoingConnections {
 		config.Daemon.MaxPendingConnections = config.Daemon.MaxOutgoingConnections
 	}
@@ -171,6 +208,8 @@ type DaemonConfig struct { //nolint:golint
 	MaxConnections int
 	// Number of outgoing connections to maintain
 	MaxOutgoingConnections int
+	// Maximum number of outgoing connections to random peers in the same address group (/16 subnet)
+	MaxOutgoingPerGroup int
 	// Maximum number of connections to try at once
 	MaxPendingConnections int
 	// How long to wait for a version packet
@@ -189,6 +228,12 @@ type DaemonConfig struct { //nolint:golint
 	DisableIncomingConnections bool
 	// Run on localhost and only connect to localhost peers
 	LocalhostOnly bool
+	// Make all outgoing connections through the proxy of the pool config, instead of only the connections to onion peers,
+	// and disable incoming connections
+	ProxyOnly bool
+	// Onion address (onion:port) of a Tor onion service that forwards to our listening port.
+	// It is advertised to peers, and incoming connections from the local Tor daemon are not limited by IPCountsMax
+	OnionAddress string
 	// Log ping and pong messages
 	LogPings bool
 	// How often to request blocks from peers
@@ -224,12 +269,48 @@ type DaemonConfig struct { //nolint:golint
 	MaxBlockTransactionsSize uint32
 	// Maximum number of blocks to response on /api/v1/last_blocks API
 	MaxLastBlocksCount uint64
+	// Ban a peer's IP when its reputation score falls to -BanScore. Set to 0 to disable banning
+	BanScore int
+	// How long to ban a peer's IP for
+	BanDuration time.Duration
+	// How long a peer has to respond to a GetBlocksMessage before its reputation score is decreased
+	BlocksResponseTimeout time.Duration
+	// Disable the headers-first block sync, and only request blocks with GetBlocksMessage
+	DisableHeadersSync bool
+	// How many headers to request in a GetHeadersMessage
+	GetHeadersRequestCount uint64
+	// Maximum number of headers to respond with to a GetHeadersMessage
+	MaxGetHeadersResponseCount uint64
+	// Maximum number of peers to request headers from at once
+	HeadersSyncPeers int
+	// Maximum number of windows of GetBlocksRequestCount blocks to download in parallel during the headers-first sync
+	SyncMaxWindows int
+	// How long a peer has to deliver a window of blocks before the window is requested from another peer
+	SyncStallTimeout time.Duration
+	// How often to check for stalled windows and request windows from idle peers
+	SyncRate time.Duration
+	// How often to request the pruned blocks of a node bootstrapped from a snapshot from peers that have them.
+	// A request that is not delivered within SyncStallTimeout is sent to another peer
+	BackfillRate time.Duration
+	// Don't send or request compact blocks, and don't advertise the compact blocks capability
+	DisableCompactBlocks bool
+	// Broadcast transactions to all peers instead of relaying them along a Dandelion++ stem,
+	// and don't advertise the Dandelion capability
+	DisableDandelion bool
+	// Probability that the node fluffs the stem transactions it receives during an epoch
+	DandelionFluffProbability float64
+	// How long the stem peers and fluff mode are kept before they are chosen again
+	DandelionEpochDuration time.Duration
+	// Minimum time to wait for a stem transaction to be fluffed by another node before fluffing it
+	DandelionEmbargoDuration time.Duration
+	// How often to fluff the stem transactions whose embargo expired
+	DandelionRate time.Duration
 }
 
 // NewDaemonConfig creates daemon config
 func NewDaemonConfig() DaemonConfig {
 	return DaemonConfig{
-		ProtocolVersion:              2,
+		ProtocolVersion:              3,
 		MinProtocolVersion:           2,
 		Address:                      "",
 		Port:                         6677,
@@ -237,6 +318,7 @@ func NewDaemonConfig() DaemonConfig {
 		OutgoingTrustedRate:          time.Millisecond * 100,
 		MaxConnections:               128,
 		MaxOutgoingConnections:       8,
+		MaxOutgoingPerGroup:          1,
 		MaxPendingConnections:        8,
 		IntroductionWait:             time.Second * 30,
 		CullInvalidRate:              time.Second * 3,
@@ -246,6 +328,8 @@ func NewDaemonConfig() DaemonConfig {
 		DisableOutgoingConnections:   false,
 		DisableIncomingConnections:   false,
 		LocalhostOnly:                false,
+		ProxyOnly:                    false,
+		OnionAddress:                 "",
 		LogPings:                     true,
 		BlocksRequestRate:            time.Second * 60,
 		BlocksAnnounceRate:           time.Second * 60,
@@ -260,6 +344,23 @@ func NewDaemonConfig() DaemonConfig {
 		MaxOutgoingMessageLength:     256 * 1024,
 		MaxIncomingMessageLength:     1024 * 1024,
 		MaxBlockTransactionsSize:     32768,
+		BanScore:                     100,
+		BanDuration:                  time.Hour * 24,
+		BlocksResponseTimeout:        time.Second * 30,
+		DisableHeadersSync:           false,
+		GetHeadersRequestCount:       512,
+		MaxGetHeadersResponseCount:   512,
+		HeadersSyncPeers:             3,
+		SyncMaxWindows:               16,
+		SyncStallTimeout:             time.Second * 20,
+		SyncRate:                     time.Second,
+		BackfillRate:                 time.Second * 5,
+		DisableCompactBlocks:         false,
+		DisableDandelion:             false,
+		DandelionFluffProbability:    0.1,
+		DandelionEpochDuration:       time.Minute * 10,
+		DandelionEmbargoDuration:     time.Second * 30,
+		DandelionRate:                time.Second,
 	}
 }
 
@@ -272,11 +373,13 @@ type daemoner interface {
 	sendMessage(addr string, msg gnet.Message) error
 	broadcastMessage(msg gnet.Message) ([]uint64, error)
 	disconnectNow(addr string, r gnet.DisconnectReason) error
-	addPeers(addrs []string) int
+	addGossipedPeers(source string, addrs []pex.GossipAddr) int
 	recordPeerHeight(addr string, gnetID, height uint64)
 	getSignedBlocksSince(seq, count uint64) ([]coin.SignedBlock, error)
+	getSignedBlockHeadersSince(seq, count uint64) ([]SignedBlockHeader, error)
 	headBkSeq() (uint64, bool, error)
 	executeSignedBlock(b coin.SignedBlock) error
+	verifyBlockSignatures(blocks []coin.SignedBlock)
 	filterKnownUnconfirmed(txns []cipher.SHA256) ([]cipher.SHA256, error)
 	getKnownUnconfirmed(txns []cipher.SHA256) (coin.Transactions, error)
 	requestBlocksFromAddr(addr string) error
@@ -286,6 +389,18 @@ type daemoner interface {
 	recordMessageEvent(m asyncMessage, c *gnet.MessageContext) error
 	connectionIntroduced(addr string, gnetID uint64, m *IntroductionMessage) (*connection, error)
 	sendRandomPeers(addr string) error
+	adjustPeerScore(addr string, b peerBehavior)
+	recordBlocksResponse(addr string)
+	receiveHeaders(addr string, gnetID uint64, headers []SignedBlockHeader)
+	receiveSyncBlocks(addr string, blocks []coin.SignedBlock) bool
+	sendPrunedBlocks(addr string)
+	receivePrunedBlocks(addr string, gnetID, lowestSeq uint64)
+	receiveBackfillBlocks(addr string, blocks []coin.SignedBlock) bool
+	getSignedBlockByHash(hash cipher.SHA256) (*coin.SignedBlock, error)
+	receiveCompactBlock(addr string, m *CompactBlockMessage)
+	receiveBlockTxns(addr string, hash cipher.SHA256, txns coin.Transactions)
+	receiveStemTransaction(addr string, txn coin.Transaction)
+	txnsFluffed(hashes []cipher.SHA256)
 }
 
 // Daemon stateful properties of the daemon
@@ -303,6 +418,16 @@ type Daemon struct {
 	announcedTxns *announcedTxnsCache
 	// Cache of connection metadata
 	connections *Connections
+	// Reputation scores of peers
+	reputation *peerReputation
+	// Headers-first block sync state
+	blockSync *blockSync
+	// Backfill state of the pruned blocks of a node bootstrapped from a snapshot
+	blockBackfill *blockBackfill
+	// Compact blocks waiting for their missing transactions
+	compactBlocks *compactBlocks
+	// Dandelion++ stem routes and embargoed transactions
+	dandelion *dandelion
 	// connect, disconnect, message, error events channel
 	events chan interface{}
 	// quit channel
@@ -334,6 +459,11 @@ func New(config Config, v *visor.Visor) (*Daemon, error) {
 
 		announcedTxns: newAnnouncedTxnsCache(),
 		connections:   NewConnections(),
+		reputation:    newPeerReputation(),
+		blockSync:     newBlockSync(config.Daemon.BlockchainPubkey, config.Daemon.GetBlocksRequestCount, config.Daemon.SyncMaxWindows, config.Daemon.SyncStallTimeout),
+		blockBackfill: newBlockBackfill(config.Daemon.SyncStallTimeout),
+		compactBlocks: newCompactBlocks(),
+		dandelion:     newDandelion(config.Daemon.DandelionFluffProbability, config.Daemon.DandelionEpochDuration, config.Daemon.DandelionEmbargoDuration),
 		events:        make(chan interface{}, config.Pool.EventChannelSize),
 		quit:          make(chan struct{}),
 		done:          make(chan struct{}),
@@ -419,9 +549,22 @@ func (dm *Daemon) Run() error {
 	defer blocksRequestTicker.Stop()
 	blocksAnnounceTicker := time.NewTicker(dm.config.BlocksAnnounceRate)
 	defer blocksAnnounceTicker.Stop()
+	blocksSyncTicker := time.NewTicker(dm.config.SyncRate)
+	defer blocksSyncTicker.Stop()
+	if dm.config.DisableHeadersSync {
+		blocksSyncTicker.Stop()
+	}
+
+	backfillTicker := time.NewTicker(dm.config.BackfillRate)
+	defer backfillTicker.Stop()
 
 	flushAnnouncedTxnsTicker := time.NewTicker(dm.config.FlushAnnouncedTxnsRate)
 	defer flushAnnouncedTxnsTicker.Stop()
+	dandelionTicker := time.NewTicker(dm.config.DandelionRate)
+	defer dandelionTicker.Stop()
+	if dm.config.DisableDandelion {
+		dandelionTicker.Stop()
+	}
 
 	// Try to connect to limited trusted public peers
 	if !dm.config.DisableOutgoingConnections {
@@ -464,6 +607,10 @@ loop:
 				logger.WithError(err).Error("Failed to set unconfirmed txn announce time")
 			}
 
+		case <-dandelionTicker.C:
+			elapser.Register("dandelionTicker")
+			dm.fluffExpiredEmbargoes()
+
 		case <-blockCreationTicker.C:
 			// Create blocks, if block publisher
 			elapser.Register("blockCreationTicker.C")
@@ -489,6 +636,14 @@ loop:
 				logger.WithError(err).Warning("requestBlocks failed")
 			}
 
+		case <-blocksSyncTicker.C:
+			elapser.Register("blocksSyncTicker")
+			dm.checkSyncStalls()
+
+		case <-backfillTicker.C:
+			elapser.Register("backfillTicker")
+			dm.requestBackfill()
+
 		case <-blocksAnnounceTicker.C:
 			elapser.Register("blocksAnnounceTicker")
 			if err := dm.announceBlocks(); err != nil {
@@ -625,6 +780,7 @@ func (dm *Daemon) startConnPool(wg *sync.WaitGroup, errC chan error) {
 			elapser.Register("cullInvalidTicker")
 			if !dm.config.DisableNetworking {
 				dm.cullInvalidConnections()
+				dm.penalizeSlowBlocksResponses()
 			}
 
 		case <-clearStaleConnectionsTicker.C:
@@ -642,6 +798,9 @@ func (dm *Daemon) startConnPool(wg *sync.WaitGroup, errC chan error) {
 						logger.WithError(err).WithField("addr", addr).Error("Disconnect")
 					}
 				}
+
+				// Forget the legacy peers that are due to be retried with encryption
+				dm.pool.Pool.PruneLegacyPeers()
 			}
 		}
 	}
@@ -732,6 +891,10 @@ func (dm *Daemon) connectToPeer(p pex.Peer) error {
 		return errors.New("Already connected to this peer")
 	}
 
+	if dm.pex.IsBanned(p.Addr) {
+		return errors.New("Peer is banned")
+	}
+
 	cnt := dm.connections.IPCount(a)
 	if !dm.config.LocalhostOnly && cnt != 0 {
 		return errors.New("Already connected to a peer with this base IP")
@@ -805,9 +968,14 @@ func (dm *Daemon) connectToRandomPeer() {
 		return
 	}
 
-	// Make a connection to a random (public) peer
-	peers := dm.pex.Random(dm.config.MaxOutgoingConnections - dm.connections.OutgoingLen())
-	for _, p := range peers {
+	// Make connections to random (public) peers, spread across address groups
+	n := dm.config.MaxOutgoingConnections - dm.connections.OutgoingLen()
+	peers := dm.pex.Random(0)
+	maxPerGroup := dm.config.MaxOutgoingPerGroup
+	if dm.config.LocalhostOnly {
+		maxPerGroup = 0
+	}
+	for _, p := range diverseOutgoingPeers(peers, dm.outgoingGroupCounts(), n, maxPerGroup) {
 		if err := dm.connectToPeer(p); err != nil {
 			logger.WithError(err).WithField("addr", p.Addr).Warning("connectToPeer failed")
 		}
@@ -819,6 +987,39 @@ func (dm *Daemon) connectToRandomPeer() {
 	}
 }
 
+// outgoingGroupCounts returns the number of outgoing and pending connections in each address group
+func (dm *Daemon) outgoingGroupCounts() map[string]int {
+	groups := make(map[string]int)
+	for _, c := range dm.connections.all() {
+		if c.Outgoing {
+			groups[pex.AddrGroup(c.Addr)]++
+		}
+	}
+	return groups
+}
+
+// diverseOutgoingPeers returns up to n peers to connect to, in order, skipping the peers of the address groups
+// that already have maxPerGroup outgoing connections, so that the peers of a single subnet can't take
+// all of the outgoing connections. If maxPerGroup is 0, the number of connections per group is not limited.
+func diverseOutgoingPeers(peers pex.Peers, groups map[string]int, n, maxPerGroup int) pex.Peers {
+	var selected pex.Peers
+	for _, p := range peers {
+		if len(selected) >= n {
+			break
+		}
+
+		g := pex.AddrGroup(p.Addr)
+		if maxPerGroup > 0 && groups[g] >= maxPerGroup {
+			continue
+		}
+
+		groups[g]++
+		selected = append(selected, p)
+	}
+
+	return selected
+}
+
 // Removes connections who haven't sent a version after connecting
 func (dm *Daemon) cullInvalidConnections() {
 	now := time.Now().UTC()
@@ -939,6 +1140,14 @@ func (dm *Daemon) onConnectEvent(e ConnectEvent) {
 		logger.Critical().WithFields(fields).Warning("Connection.Outgoing does not match ConnectEvent.Solicited state")
 	}
 
+	if dm.pex.IsBanned(e.Addr) && !dm.isTrustedPeer(e.Addr) {
+		logger.WithFields(fields).Info("Peer is banned, disconnecting")
+		if err := dm.Disconnect(e.Addr, ErrDisconnectIsBlacklisted); err != nil {
+			logger.WithError(err).WithFields(fields).Error("Disconnect")
+		}
+		return
+	}
+
 	if dm.ipCountMaxed(e.Addr) {
 		logger.WithFields(fields).Info("Max connections for this IP address reached, disconnecting")
 		if err := dm.Disconnect(e.Addr, ErrDisconnectIPLimitReached); err != nil {
@@ -957,6 +1166,7 @@ func (dm *Daemon) onConnectEvent(e ConnectEvent) {
 		dm.config.userAgent,
 		dm.config.UnconfirmedVerifyTxn,
 		dm.config.GenesisHash,
+		dm.capabilities(),
 	)); err != nil {
 		logger.WithFields(fields).WithError(err).Error("Send IntroductionMessage failed")
 		return
@@ -976,6 +1186,17 @@ func (dm *Daemon) onDisconnectEvent(e DisconnectEvent) {
 		return
 	}
 
+	// A closed connection can't respond to a blocks request anymore
+	dm.reputation.blocksReceived(e.Addr)
+	dm.blockSync.removePeer(e.Addr)
+	dm.blockBackfill.removePeer(e.Addr)
+	dm.compactBlocks.removePeer(e.Addr)
+	dm.dandelion.removePeer(e.Addr)
+
+	if b, ok := disconnectBehavior(e.Reason); ok {
+		dm.adjustPeerScore(e.Addr, b)
+	}
+
 	// TODO -- blacklist peer for certain reasons, not just remove
 	switch e.Reason {
 	case ErrDisconnectIntroductionTimeout,
@@ -1033,6 +1254,21 @@ func (dm *Daemon) onGnetConnect(addr string, gnetID uint64, solicited bool) {
 	}
 }
 
+// onGnetAccept triggered before the transport handshake of an incoming gnet.Connection.
+// Connections from banned peers, and from IP addresses that reached the connection limit, are rejected
+// before the handshake. Both are checked again by onConnectEvent
+func (dm *Daemon) onGnetAccept(addr string) error {
+	if dm.pex.IsBanned(addr) && !dm.isTrustedPeer(addr) {
+		return ErrDisconnectIsBlacklisted
+	}
+
+	if dm.ipCountMaxed(addr) {
+		return ErrDisconnectIPLimitReached
+	}
+
+	return nil
+}
+
 // onGnetConnectFailure triggered when a gnet.Connection fails to connect
 func (dm *Daemon) onGnetConnectFailure(addr string, solicited bool, err error) {
 	dm.events <- ConnectFailureEvent{
@@ -1043,7 +1279,7 @@ func (dm *Daemon) onGnetConnectFailure(addr string, solicited bool, err error) {
 }
 
 // Returns whether the ipCount maximum has been reached.
-// Always false when using LocalhostOnly config.
+// Always false when using LocalhostOnly config, and for the connections from the local Tor daemon to our onion service.
 func (dm *Daemon) ipCountMaxed(addr string) bool {
 	ip, _, err := iputil.SplitAddr(addr)
 	if err != nil {
@@ -1051,14 +1287,36 @@ func (dm *Daemon) ipCountMaxed(addr string) bool {
 		return true
 	}
 
+	if dm.isOnionServiceConn(addr) {
+		return false
+	}
+
 	return !dm.config.LocalhostOnly && dm.connections.IPCount(ip) >= dm.config.IPCountsMax
 }
 
+// isOnionServiceConn returns true if addr is an incoming connection to our onion service, which comes from the local Tor daemon
+func (dm *Daemon) isOnionServiceConn(addr string) bool {
+	if dm.config.OnionAddress == "" {
+		return false
+	}
+
+	ip, _, err := iputil.SplitAddr(addr)
+	return err == nil && iputil.IsLocalhost(ip)
+}
+
 // When an async message send finishes, its result is handled by this.
 // This method must take care to perform only thread-safe actions, since it is called
 // outside of the daemon run loop
 func (dm *Daemon) handleMessageSendResult(r gnet.SendResult) {
 	if r.Error != nil {
+		if r.Error == gnet.ErrUploadCapReached {
+			logger.WithFields(logrus.Fields{
+				"addr":    r.Addr,
+				"msgType": reflect.TypeOf(r.Message),
+			}).Debug("Message not sent, daily upload cap reached")
+			return
+		}
+
 		var lg logrus.FieldLogger
 		if r.Error == gnet.ErrMsgExceedsMaxLen {
 			lg = logger.Critical()
@@ -1098,13 +1356,44 @@ func (dm *Daemon) requestBlocks() error {
 		return errors.New("Cannot request blocks, there is no head block")
 	}
 
+	// Request headers from the peers that support the headers-first sync, and blocks from the others
+	var addrs []string
+	for _, c := range dm.connections.all() {
+		if !c.HasIntroduced() {
+			continue
+		}
+
+		if dm.supportsHeadersSync(&c) {
+			if err := dm.requestHeadersFromAddr(c.Addr); err != nil {
+				logger.WithError(err).WithField("addr", c.Addr).Debug("requestHeadersFromAddr failed")
+			}
+			continue
+		}
+
+		// A pruned peer can't send the blocks after our head block
+		if c.Capabilities.LowestBlockSeq > headSeq+1 {
+			continue
+		}
+
+		addrs = append(addrs, c.Addr)
+	}
+
+	if len(addrs) == 0 {
+		return nil
+	}
+
 	m := NewGetBlocksMessage(headSeq, dm.config.GetBlocksRequestCount)
 
-	if _, err := dm.broadcastMessage(m); err != nil {
+	ids, err := dm.pool.Pool.BroadcastMessage(m, addrs)
+	if err != nil {
 		logger.WithError(err).Debug("Broadcast GetBlocksMessage failed")
 		return err
 	}
 
+	for _, id := range ids {
+		dm.recordBlocksRequest(dm.connections.getByGnetID(id), headSeq)
+	}
+
 	return nil
 }
 
@@ -1144,12 +1433,18 @@ func (dm *Daemon) createAndPublishBlock() (*coin.SignedBlock, error) {
 		return nil, ErrNetworkingDisabled
 	}
 
+	// Collect the transactions that peers are unlikely to know before they are removed from the pool
+	prefill, err := dm.unannouncedTxns()
+	if err != nil {
+		return nil, err
+	}
+
 	sb, err := dm.visor.CreateAndExecuteBlock()
 	if err != nil {
 		return nil, err
 	}
 
-	err = dm.broadcastBlock(sb)
+	err = dm.broadcastBlock(sb, prefill)
 
 	return &sb, err
 }
@@ -1169,6 +1464,10 @@ func (dm *Daemon) ResendUnconfirmedTxns() ([]cipher.SHA256, error) {
 	var txids []cipher.SHA256
 	for i := range txns {
 		txnHash := txns[i].Transaction.Hash()
+		if dm.dandelion.isEmbargoed(txnHash) {
+			// Stem transactions are fluffed when their embargo expires
+			continue
+		}
 		logger.WithField("txid", txnHash.Hex()).Debug("Rebroadcast transaction")
 		if _, err := dm.BroadcastTransaction(txns[i].Transaction); err == nil {
 			txids = append(txids, txnHash)
@@ -1200,9 +1499,24 @@ func (dm *Daemon) BroadcastTransaction(txn coin.Transaction) ([]uint64, error) {
 	return ids, nil
 }
 
-// BroadcastUserTransaction broadcasts a single transaction to all peers.
+// BroadcastUserTransaction relays a single transaction to a Dandelion++ stem peer,
+// or broadcasts it to all peers if there is no stem peer that would propagate it.
 // Returns an error if no peers that would propagate the transaction could be reached.
 func (dm *Daemon) BroadcastUserTransaction(txn coin.Transaction, head *coin.SignedBlock, inputs coin.UxArray) error {
+	if dm.config.DisableNetworking {
+		return ErrNetworkingDisabled
+	}
+
+	if c := dm.stemTransaction("", txn); c != nil {
+		if _, err := checkBroadcastTxnRecipients(dm.connections, []uint64{c.gnetID}, txn, head, inputs); err == nil {
+			logger.WithField("addr", c.Addr).Debug("BroadcastUserTransaction transaction relayed to stem peer")
+			return nil
+		}
+
+		// The stem peer would not propagate the transaction, broadcast it instead
+		dm.dandelion.fluffed([]cipher.SHA256{txn.Hash()})
+	}
+
 	ids, err := dm.BroadcastTransaction(txn)
 	if err != nil {
 		return err
@@ -1317,12 +1631,18 @@ func (dm *Daemon) Disconnect(addr string, r gnet.DisconnectReason) error {
 
 // Implements private daemoner interface methods:
 
-// requestBlocksFromAddr sends a GetBlocksMessage to one connected address
+// requestBlocksFromAddr sends a GetBlocksMessage to one connected address.
+// If the peer supports the headers-first sync, a GetHeadersMessage is sent instead.
 func (dm *Daemon) requestBlocksFromAddr(addr string) error {
 	if dm.config.DisableNetworking {
 		return ErrNetworkingDisabled
 	}
 
+	c := dm.connections.get(addr)
+	if c != nil && dm.supportsHeadersSync(c) {
+		return dm.requestHeadersFromAddr(addr)
+	}
+
 	headSeq, ok, err := dm.visor.HeadBkSeq()
 	if err != nil {
 		return err
@@ -1331,22 +1651,68 @@ func (dm *Daemon) requestBlocksFromAddr(addr string) error {
 		return errors.New("Cannot request blocks from addr, there is no head block")
 	}
 
+	if c != nil && c.Capabilities.LowestBlockSeq > headSeq+1 {
+		return errors.New("Cannot request blocks from addr, the peer pruned the blocks after the head block")
+	}
+
 	m := NewGetBlocksMessage(headSeq, dm.config.GetBlocksRequestCount)
-	return dm.sendMessage(addr, m)
+	if err := dm.sendMessage(addr, m); err != nil {
+		return err
+	}
+
+	dm.recordBlocksRequest(dm.connections.get(addr), headSeq)
+	return nil
 }
 
-// broadcastBlock sends a signed block to all connections
-func (dm *Daemon) broadcastBlock(sb coin.SignedBlock) error {
+// broadcastBlock sends a signed block to all connections.
+// Connections that accept compact blocks are sent a CompactBlockMessage with the transactions in prefill sent in full,
+// other connections are sent a GiveBlocksMessage.
+func (dm *Daemon) broadcastBlock(sb coin.SignedBlock, prefill map[cipher.SHA256]struct{}) error {
 	if dm.config.DisableNetworking {
 		return ErrNetworkingDisabled
 	}
 
-	m := NewGiveBlocksMessage([]coin.SignedBlock{sb}, dm.config.MaxOutgoingMessageLength)
-	if len(m.Blocks) != 1 {
-		logger.Critical().Error("NewGiveBlocksMessage truncated its only block")
+	var addrs, compactAddrs []string
+	for _, c := range dm.connections.all() {
+		switch {
+		case !c.HasIntroduced():
+		case dm.supportsCompactBlocks(&c):
+			compactAddrs = append(compactAddrs, c.Addr)
+		default:
+			addrs = append(addrs, c.Addr)
+		}
 	}
 
-	_, err := dm.broadcastMessage(m)
+	// Return the same error as broadcastMessage if there are no connections
+	err := gnet.ErrNoAddresses
+	sent := false
+
+	if len(compactAddrs) != 0 {
+		nonce := binary.LittleEndian.Uint64(cipher.RandByte(8))
+		m := NewCompactBlockMessage(sb, nonce, prefill)
+		if _, err = dm.pool.Pool.BroadcastMessage(m, compactAddrs); err == nil {
+			sent = true
+		} else {
+			logger.WithError(err).Debug("Broadcast CompactBlockMessage failed")
+		}
+	}
+
+	if len(addrs) != 0 {
+		m := NewGiveBlocksMessage([]coin.SignedBlock{sb}, dm.config.MaxOutgoingMessageLength)
+		if len(m.Blocks) != 1 {
+			logger.Critical().Error("NewGiveBlocksMessage truncated its only block")
+		}
+
+		if _, err = dm.pool.Pool.BroadcastMessage(m, addrs); err == nil {
+			sent = true
+		} else {
+			logger.WithError(err).Debug("Broadcast GiveBlocksMessage failed")
+		}
+	}
+
+	if sent {
+		return nil
+	}
 	return err
 }
 
@@ -1380,7 +1746,22 @@ func (dm *Daemon) connectionIntroduced(addr string, gnetID uint64, m *Introducti
 			logger.Critical().WithError(err).WithFields(fields).Error("pex.SetHasIncomingPort failed")
 			return nil, err
 		}
+
+		// Move the peer to the tried table, where it can't be evicted by gossiped peers
+		if err := dm.pex.MarkTried(listenAddr); err != nil {
+			logger.Critical().WithError(err).WithFields(fields).Error("pex.MarkTried failed")
+			return nil, err
+		}
 	} else {
+		if c.Capabilities.OnionAddress != "" {
+			// The peer listens on an onion service, which is the address that other peers can reach it on
+			listenAddr = c.Capabilities.OnionAddress
+			fields["listenAddr"] = listenAddr
+		} else if dm.isOnionServiceConn(addr) {
+			// The connection came through our onion service and the peer's own address is unknown
+			return c, nil
+		}
+
 		// For successful incoming connections, add the peer to the peer list, with their self-reported listen port
 		if err := dm.pex.AddPeer(listenAddr); err != nil {
 			logger.Critical().WithError(err).WithFields(fields).Error("pex.AddPeer failed")
@@ -1406,7 +1787,24 @@ func (dm *Daemon) sendRandomPeers(addr string) error {
 		return errors.New("No peers available")
 	}
 
-	m := NewGivePeersMessage(peers, dm.config.MaxOutgoingMessageLength)
+	// Peers that accept timestamps are sent the time when we last saw each peer
+	if c := dm.connections.get(addr); c != nil && c.Capabilities.HasServices(ServicePeerTimestamps) {
+		return dm.sendMessage(addr, NewGiveAddrsMessage(peers, dm.config.MaxOutgoingMessageLength))
+	}
+
+	// GivePeersMessage can only encode IP addresses, onion and DNS hostname peers are not sent
+	ipPeers := make([]pex.Peer, 0, len(peers))
+	for _, p := range peers {
+		if host, _, err := iputil.SplitAddr(p.Addr); err == nil && net.ParseIP(host) != nil {
+			ipPeers = append(ipPeers, p)
+		}
+	}
+	if len(ipPeers) == 0 {
+		logger.Debug("sendRandomPeers: no IP peers to send in reply")
+		return errors.New("No peers available")
+	}
+
+	m := NewGivePeersMessage(ipPeers, dm.config.MaxOutgoingMessageLength)
 
 	return dm.sendMessage(addr, m)
 }
@@ -1432,6 +1830,9 @@ func (dm *Daemon) announceTxnHashes(hashes []cipher.SHA256) error {
 		return ErrNetworkingDisabled
 	}
 
+	// Embargoed stem transactions are not announced until they are fluffed
+	hashes = dm.dandelion.filterEmbargoed(hashes)
+
 	// Divide hashes into multiple sets of max size
 	hashesSet := divideHashes(hashes, dm.config.MaxTxnAnnounceNum)
 
@@ -1475,7 +1876,12 @@ func divideHashes(hashes []cipher.SHA256, n int) [][]cipher.SHA256 {
 }
 
 // sendMessage sends a Message to a Connection and pushes the result onto the SendResults channel.
+// Returns ErrMessageTypeNotSupported if the peer does not accept the message type.
 func (dm *Daemon) sendMessage(addr string, msg gnet.Message) error {
+	if c := dm.connections.get(addr); c != nil && !dm.supportsMessage(c, msg) {
+		return ErrMessageTypeNotSupported
+	}
+
 	return dm.pool.Pool.SendMessage(addr, msg)
 }
 
@@ -1491,7 +1897,7 @@ func (dm *Daemon) broadcastMessage(msg gnet.Message) ([]uint64, error) {
 	conns := dm.connections.all()
 	var addrs []string
 	for _, c := range conns {
-		if c.HasIntroduced() {
+		if c.HasIntroduced() && dm.supportsMessage(&c, msg) {
 			addrs = append(addrs, c.Addr)
 		}
 	}
@@ -1510,9 +1916,9 @@ func (dm *Daemon) pexConfig() pex.Config {
 	return dm.pex.Config
 }
 
-// addPeers adds peers to the pex
-func (dm *Daemon) addPeers(addrs []string) int {
-	return dm.pex.AddPeers(addrs)
+// addGossipedPeers adds peers received via PEX from a source peer to the pex
+func (dm *Daemon) addGossipedPeers(source string, addrs []pex.GossipAddr) int {
+	return dm.pex.AddGossipedPeers(source, addrs)
 }
 
 // recordPeerHeight records the height of specific peer
@@ -1527,6 +1933,30 @@ func (dm *Daemon) getSignedBlocksSince(seq, count uint64) ([]coin.SignedBlock, e
 	return dm.visor.GetSignedBlocksSince(seq, count)
 }
 
+// getSignedBlockHeadersSince returns the signed headers of up to count blocks after seq.
+// The headers of pruned blocks are available
+func (dm *Daemon) getSignedBlockHeadersSince(seq, count uint64) ([]SignedBlockHeader, error) {
+	vHeaders, err := dm.visor.GetSignedBlockHeadersSince(seq, count)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make([]SignedBlockHeader, len(vHeaders))
+	for i, h := range vHeaders {
+		headers[i] = SignedBlockHeader{
+			Header: h.Header,
+			Sig:    h.Sig,
+		}
+	}
+
+	return headers, nil
+}
+
+// getSignedBlockByHash returns the signed block with the header hash, or nil if not found
+func (dm *Daemon) getSignedBlockByHash(hash cipher.SHA256) (*coin.SignedBlock, error) {
+	return dm.visor.GetSignedBlockByHash(hash)
+}
+
 // headBkSeq returns the head block sequence
 func (dm *Daemon) headBkSeq() (uint64, bool, error) {
 	return dm.visor.HeadBkSeq()
@@ -1537,14 +1967,23 @@ func (dm *Daemon) executeSignedBlock(b coin.SignedBlock) error {
 	return dm.visor.ExecuteSignedBlock(b)
 }
 
+// verifyBlockSignatures verifies the input signatures of consecutive blocks in parallel before they are executed,
+// so that executing the blocks one by one does not verify them again
+func (dm *Daemon) verifyBlockSignatures(blocks []coin.SignedBlock) {
+	if err := dm.visor.VerifyBlockSignatures(blocks); err != nil {
+		logger.WithError(err).Warning("dm.visor.VerifyBlockSignatures failed")
+	}
+}
+
 // filterKnownUnconfirmed returns unconfirmed txn hashes with known ones removed
 func (dm *Daemon) filterKnownUnconfirmed(txns []cipher.SHA256) ([]cipher.SHA256, error) {
 	return dm.visor.FilterKnownUnconfirmed(txns)
 }
 
-// getKnownUnconfirmed returns unconfirmed txn hashes with known ones removed
+// getKnownUnconfirmed returns the unconfirmed txns of the hashes that are known.
+// Embargoed stem transactions are not returned, so that they are not revealed before they are fluffed
 func (dm *Daemon) getKnownUnconfirmed(txns []cipher.SHA256) (coin.Transactions, error) {
-	return dm.visor.GetKnownUnconfirmed(txns)
+	return dm.visor.GetKnownUnconfirmed(dm.dandelion.filterEmbargoed(txns))
 }
 
 // injectTransaction records a coin.Transaction to the UnconfirmedTxnPool if the txn is not
@@ -1571,6 +2010,7 @@ type GnetConnectionDetails struct {
 	ID           uint64
 	LastSent     time.Time
 	LastReceived time.Time
+	Traffic      gnet.ConnectionTraffic
 }
 
 func newConnection(dc *connection, gc *gnet.Connection, pp *pex.Peer) Connection {
@@ -1586,6 +2026,7 @@ func newConnection(dc *connection, gc *gnet.Connection, pp *pex.Peer) Connection
 			ID:           gc.ID,
 			LastSent:     gc.LastSent,
 			LastReceived: gc.LastReceived,
+			Traffic:      gc.Traffic,
 		}
 	}
 
@@ -8,6 +8,7 @@ import (
 
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/daemon/pex"
 	"github.com/skycoin/skycoin/src/params"
 	"github.com/skycoin/skycoin/src/transaction"
 	"github.com/skycoin/skycoin/src/util/fee"
@@ -340,3 +341,99 @@ func TestCheckBroadcastTxnRecipients(t *testing.T) {
 		})
 	}
 }
+
+func TestDiverseOutgoingPeers(t *testing.T) {
+	peers := pex.Peers{
+		{Addr: "112.32.1.1:6000"},
+		{Addr: "112.32.2.2:6000"},
+		{Addr: "113.32.1.1:6000"},
+		{Addr: "114.32.1.1:6000"},
+		{Addr: "114.32.2.2:6000"},
+		{Addr: "115.32.1.1:6000"},
+	}
+
+	// One peer per address group
+	groups := map[string]int{}
+	selected := diverseOutgoingPeers(peers, groups, 8, 1)
+	require.Equal(t, []string{"112.32.1.1:6000", "113.32.1.1:6000", "114.32.1.1:6000", "115.32.1.1:6000"}, selected.ToAddrs())
+	require.Equal(t, map[string]int{
+		"112.32.0.0/16": 1,
+		"113.32.0.0/16": 1,
+		"114.32.0.0/16": 1,
+		"115.32.0.0/16": 1,
+	}, groups)
+
+	// Groups of existing outgoing connections are skipped
+	groups = map[string]int{
+		"112.32.0.0/16": 1,
+		"114.32.0.0/16": 1,
+	}
+	selected = diverseOutgoingPeers(peers, groups, 8, 1)
+	require.Equal(t, []string{"113.32.1.1:6000", "115.32.1.1:6000"}, selected.ToAddrs())
+
+	// At most n peers are selected
+	selected = diverseOutgoingPeers(peers, map[string]int{}, 2, 1)
+	require.Equal(t, []string{"112.32.1.1:6000", "113.32.1.1:6000"}, selected.ToAddrs())
+
+	// Two peers per address group
+	selected = diverseOutgoingPeers(peers, map[string]int{"112.32.0.0/16": 1}, 8, 2)
+	require.Equal(t, []string{"112.32.1.1:6000", "113.32.1.1:6000", "114.32.1.1:6000", "114.32.2.2:6000", "115.32.1.1:6000"}, selected.ToAddrs())
+
+	// No limit per address group
+	selected = diverseOutgoingPeers(peers, map[string]int{"112.32.0.0/16": 5}, 8, 0)
+	require.Equal(t, peers.ToAddrs(), selected.ToAddrs())
+}
+
+func TestPreprocessProxy(t *testing.T) {
+	newConfig := func() Config {
+		cfg := NewConfig()
+		cfg.Daemon.UserAgent = useragent.Data{
+			Coin:    "skycoin",
+			Version: "0.26.0",
+		}
+		return cfg
+	}
+
+	// Only connections to onion peers are made through the proxy
+	cfg := newConfig()
+	cfg.Pool.Proxy = "127.0.0.1:9050"
+	cfg.Pool.ProxyUsername = "user"
+	cfg.Pool.ProxyPassword = "pass"
+	cfg.Pex.DNSSeeds = []string{"seed.example.com"}
+	c, err := cfg.preprocess()
+	require.NoError(t, err)
+	require.True(t, c.Pool.proxyOnlyOnion)
+	require.False(t, c.Daemon.DisableIncomingConnections)
+	require.Equal(t, "127.0.0.1:9050", c.Pex.Proxy)
+	require.Equal(t, "user", c.Pex.ProxyUsername)
+	require.Equal(t, "pass", c.Pex.ProxyPassword)
+	require.Equal(t, []string{"seed.example.com"}, c.Pex.DNSSeeds)
+
+	// ProxyOnly makes all connections through the proxy, disables incoming connections and DNS seeds
+	cfg.Daemon.ProxyOnly = true
+	c, err = cfg.preprocess()
+	require.NoError(t, err)
+	require.False(t, c.Pool.proxyOnlyOnion)
+	require.True(t, c.Daemon.DisableIncomingConnections)
+	require.Empty(t, c.Pex.DNSSeeds)
+
+	// ProxyOnly requires a proxy
+	cfg = newConfig()
+	cfg.Daemon.ProxyOnly = true
+	_, err = cfg.preprocess()
+	require.EqualError(t, err, "ProxyOnly requires a proxy")
+
+	// OnionAddress must be a valid onion:port address
+	cfg = newConfig()
+	cfg.Daemon.OnionAddress = "pg6mmjiyjmcrsslvykfwnntlaru7p5svn6y2ymmju6nubxndf4pscryd.onion:6000"
+	_, err = cfg.preprocess()
+	require.NoError(t, err)
+
+	cfg.Daemon.OnionAddress = "pg6mmjiyjmcrsslvykfwnntlaru7p5svn6y2ymmju6nubxndf4pscryd.onion"
+	_, err = cfg.preprocess()
+	require.Error(t, err)
+
+	cfg.Daemon.OnionAddress = "1.2.3.4:6000"
+	_, err = cfg.preprocess()
+	require.EqualError(t, err, "Invalid OnionAddress: Invalid onion address")
+}
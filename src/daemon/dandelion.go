@@ -0,0 +1,351 @@
+package daemon
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/transaction"
+)
+
+// dandelionStemPeers is the number of outgoing peers that stem transactions are relayed to during an epoch
+const dandelionStemPeers = 2
+
+// dandelion is the state of the Dandelion++ transaction relay.
+//
+// Transactions are first relayed along a stem of single peers, then fluffed (announced to all peers) by a node
+// that is in fluff mode for the current epoch. At the start of each epoch, a node chooses whether it is in fluff mode
+// and up to dandelionStemPeers outgoing peers to relay stem transactions to. Each peer that sends us stem
+// transactions is mapped to one of these peers for the whole epoch, so that the stem paths don't change between transactions.
+//
+// A stem transaction is embargoed: it is not announced to peers until it is seen announced by another node,
+// or until its embargo timer expires, in which case we fluff it ourselves. This protects against stem peers
+// that drop transactions.
+type dandelion struct {
+	sync.Mutex
+	fluffProbability float64
+	epochDuration    time.Duration
+	embargoDuration  time.Duration
+	rand             *rand.Rand
+
+	epochEnd time.Time
+	// fluff is true if stem transactions received from peers are fluffed in this epoch
+	fluff bool
+	// destinations are the stem peers of this epoch
+	destinations []string
+	// routes maps the peer that sent a stem transaction to its stem peer.
+	// Our own transactions use the empty string as the source
+	routes map[string]string
+	// embargoes maps the embargoed transactions to their embargo expiry time
+	embargoes map[cipher.SHA256]time.Time
+}
+
+func newDandelion(fluffProbability float64, epochDuration, embargoDuration time.Duration) *dandelion {
+	return &dandelion{
+		fluffProbability: fluffProbability,
+		epochDuration:    epochDuration,
+		embargoDuration:  embargoDuration,
+		rand:             rand.New(rand.NewSource(time.Now().UnixNano())),
+		routes:           make(map[string]string),
+		embargoes:        make(map[cipher.SHA256]time.Time),
+	}
+}
+
+// route returns the stem peer of a transaction received from a peer, chosen from peers.
+// Transactions created by us have an empty from address; these are always stemmed.
+// Returns false if the transaction should be fluffed instead.
+func (d *dandelion) route(from string, peers []string, now time.Time) (string, bool) {
+	d.Lock()
+	defer d.Unlock()
+
+	if !now.Before(d.epochEnd) {
+		d.newEpoch(now)
+	}
+
+	if d.fluff && from != "" {
+		return "", false
+	}
+
+	d.refreshDestinations(peers)
+	if len(d.destinations) == 0 {
+		return "", false
+	}
+
+	if to, ok := d.routes[from]; ok {
+		return to, true
+	}
+
+	to := d.destinations[d.rand.Intn(len(d.destinations))]
+	d.routes[from] = to
+	return to, true
+}
+
+// newEpoch starts a new epoch, choosing the fluff mode and clearing the stem peers and routes
+func (d *dandelion) newEpoch(now time.Time) {
+	d.epochEnd = now.Add(d.epochDuration)
+	d.fluff = d.rand.Float64() < d.fluffProbability
+	d.destinations = nil
+	d.routes = make(map[string]string)
+}
+
+// refreshDestinations removes the stem peers that are not in peers anymore and
+// fills the stem peers up to dandelionStemPeers from peers
+func (d *dandelion) refreshDestinations(peers []string) {
+	available := make(map[string]struct{}, len(peers))
+	for _, p := range peers {
+		available[p] = struct{}{}
+	}
+
+	destinations := d.destinations[:0]
+	for _, p := range d.destinations {
+		if _, ok := available[p]; ok {
+			destinations = append(destinations, p)
+			delete(available, p)
+		} else {
+			d.removeRoutesTo(p)
+		}
+	}
+	d.destinations = destinations
+
+	if len(d.destinations) >= dandelionStemPeers || len(available) == 0 {
+		return
+	}
+
+	candidates := make([]string, 0, len(available))
+	for _, p := range peers {
+		if _, ok := available[p]; ok {
+			candidates = append(candidates, p)
+		}
+	}
+
+	d.rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+
+	for _, p := range candidates {
+		if len(d.destinations) >= dandelionStemPeers {
+			break
+		}
+		d.destinations = append(d.destinations, p)
+	}
+}
+
+func (d *dandelion) removeRoutesTo(addr string) {
+	for from, to := range d.routes {
+		if to == addr {
+			delete(d.routes, from)
+		}
+	}
+}
+
+// removePeer removes a disconnected peer from the stem peers and routes
+func (d *dandelion) removePeer(addr string) {
+	d.Lock()
+	defer d.Unlock()
+
+	delete(d.routes, addr)
+	d.removeRoutesTo(addr)
+
+	for i, p := range d.destinations {
+		if p == addr {
+			d.destinations = append(d.destinations[:i], d.destinations[i+1:]...)
+			break
+		}
+	}
+}
+
+// embargo embargoes a stem transaction. The embargo expires randomly between
+// embargoDuration and twice embargoDuration from now, so that the expiry does not reveal the stem position
+func (d *dandelion) embargo(hash cipher.SHA256, now time.Time) {
+	d.Lock()
+	defer d.Unlock()
+
+	if _, ok := d.embargoes[hash]; ok {
+		return
+	}
+
+	var jitter time.Duration
+	if d.embargoDuration > 0 {
+		jitter = time.Duration(d.rand.Int63n(int64(d.embargoDuration)))
+	}
+
+	d.embargoes[hash] = now.Add(d.embargoDuration + jitter)
+}
+
+// isEmbargoed returns true if a transaction is embargoed
+func (d *dandelion) isEmbargoed(hash cipher.SHA256) bool {
+	d.Lock()
+	defer d.Unlock()
+
+	_, ok := d.embargoes[hash]
+	return ok
+}
+
+// filterEmbargoed returns the hashes that are not embargoed
+func (d *dandelion) filterEmbargoed(hashes []cipher.SHA256) []cipher.SHA256 {
+	d.Lock()
+	defer d.Unlock()
+
+	if len(d.embargoes) == 0 {
+		return hashes
+	}
+
+	filtered := make([]cipher.SHA256, 0, len(hashes))
+	for _, h := range hashes {
+		if _, ok := d.embargoes[h]; !ok {
+			filtered = append(filtered, h)
+		}
+	}
+
+	return filtered
+}
+
+// fluffed lifts the embargo of transactions that were seen fluffed by another node.
+// Returns the hashes that were embargoed.
+func (d *dandelion) fluffed(hashes []cipher.SHA256) []cipher.SHA256 {
+	d.Lock()
+	defer d.Unlock()
+
+	var lifted []cipher.SHA256
+	for _, h := range hashes {
+		if _, ok := d.embargoes[h]; ok {
+			delete(d.embargoes, h)
+			lifted = append(lifted, h)
+		}
+	}
+
+	return lifted
+}
+
+// expireEmbargoes lifts the embargoes that expired before now and returns their hashes
+func (d *dandelion) expireEmbargoes(now time.Time) []cipher.SHA256 {
+	d.Lock()
+	defer d.Unlock()
+
+	var expired []cipher.SHA256
+	for h, t := range d.embargoes {
+		if !now.Before(t) {
+			delete(d.embargoes, h)
+			expired = append(expired, h)
+		}
+	}
+
+	return expired
+}
+
+// supportsDandelion returns true if the connection accepts StemTxnMessage
+func (dm *Daemon) supportsDandelion(c *connection) bool {
+	return !dm.config.DisableDandelion && c.HasIntroduced() && c.Capabilities.HasServices(ServiceDandelion)
+}
+
+// stemPeers returns the addresses of the outgoing connections that accept StemTxnMessage
+func (dm *Daemon) stemPeers() []string {
+	conns := dm.connections.all()
+	var addrs []string
+	for _, c := range conns {
+		if c.Outgoing && dm.supportsDandelion(&c) {
+			addrs = append(addrs, c.Addr)
+		}
+	}
+	return addrs
+}
+
+// stemTransaction relays a transaction to the stem peer of the peer that sent it, and embargoes it.
+// Transactions created by us have an empty from address.
+// Returns the connection that the transaction was sent to, or nil if it must be fluffed instead.
+func (dm *Daemon) stemTransaction(from string, txn coin.Transaction) *connection {
+	if dm.config.DisableDandelion {
+		return nil
+	}
+
+	to, ok := dm.dandelion.route(from, dm.stemPeers(), time.Now())
+	if !ok {
+		return nil
+	}
+
+	c := dm.connections.get(to)
+	if c == nil {
+		return nil
+	}
+
+	h := txn.Hash()
+	dm.dandelion.embargo(h, time.Now())
+
+	if err := dm.sendMessage(to, NewStemTxnMessage(txn)); err != nil {
+		logger.WithError(err).WithField("addr", to).Warning("Send StemTxnMessage failed")
+		dm.dandelion.fluffed([]cipher.SHA256{h})
+		return nil
+	}
+
+	logger.WithFields(logrus.Fields{
+		"addr":   to,
+		"gnetID": c.gnetID,
+		"txid":   h.Hex(),
+	}).Debug("Relayed stem transaction")
+
+	return c
+}
+
+// receiveStemTransaction records a stem transaction received from a peer,
+// then relays it to the next stem peer or fluffs it
+func (dm *Daemon) receiveStemTransaction(addr string, txn coin.Transaction) {
+	h := txn.Hash()
+	fields := logrus.Fields{
+		"addr": addr,
+		"txid": h.Hex(),
+	}
+
+	known, softErr, err := dm.injectTransaction(txn)
+	if err != nil {
+		logger.WithError(err).WithFields(fields).Warning("Failed to record stem transaction")
+		if _, ok := err.(transaction.ErrTxnViolatesHardConstraint); ok {
+			dm.adjustPeerScore(addr, behaviorInvalidTransaction)
+		}
+		return
+	} else if softErr != nil {
+		logger.WithError(softErr).WithFields(fields).Warning("Stem transaction soft violation")
+		// Allow soft txn violations to relay
+	} else if known {
+		logger.WithFields(fields).Debug("Duplicate stem transaction")
+		return
+	}
+
+	if c := dm.stemTransaction(addr, txn); c != nil {
+		return
+	}
+
+	if err := dm.announceTxnHashes([]cipher.SHA256{h}); err != nil {
+		logger.WithError(err).WithFields(fields).Warning("Fluff stem transaction failed")
+	}
+}
+
+// txnsFluffed lifts the embargo of transactions that were announced by a peer, and announces them
+func (dm *Daemon) txnsFluffed(hashes []cipher.SHA256) {
+	lifted := dm.dandelion.fluffed(hashes)
+	if len(lifted) == 0 {
+		return
+	}
+
+	if err := dm.announceTxnHashes(lifted); err != nil {
+		logger.WithError(err).Warning("announceTxnHashes failed")
+	}
+}
+
+// fluffExpiredEmbargoes announces the stem transactions whose embargo expired without
+// being seen fluffed by another node
+func (dm *Daemon) fluffExpiredEmbargoes() {
+	expired := dm.dandelion.expireEmbargoes(time.Now())
+	if len(expired) == 0 {
+		return
+	}
+
+	logger.Infof("Fluffing %d stem transactions whose embargo expired", len(expired))
+
+	if err := dm.announceTxnHashes(expired); err != nil {
+		logger.WithError(err).Warning("announceTxnHashes failed")
+	}
+}
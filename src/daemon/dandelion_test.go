@@ -0,0 +1,274 @@
+package daemon
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/testutil"
+)
+
+func TestDandelionRoute(t *testing.T) {
+	now := time.Now()
+	peers := []string{"1.1.1.1:6000", "2.2.2.2:6000", "3.3.3.3:6000"}
+
+	// Stem mode
+	d := newDandelion(0, time.Minute, time.Second*30)
+
+	// No stem peers, the transaction is fluffed
+	_, ok := d.route("", nil, now)
+	require.False(t, ok)
+
+	to, ok := d.route("", peers, now)
+	require.True(t, ok)
+	require.Contains(t, peers, to)
+	require.Len(t, d.destinations, dandelionStemPeers)
+
+	// Routes are stable during an epoch
+	for i := 0; i < 10; i++ {
+		to2, ok := d.route("", peers, now)
+		require.True(t, ok)
+		require.Equal(t, to, to2)
+	}
+
+	// Each source is routed to one of the stem peers
+	routes := make(map[string]string)
+	for i := 0; i < 20; i++ {
+		from := fmt.Sprintf("10.0.0.%d:6000", i)
+		to, ok := d.route(from, peers, now)
+		require.True(t, ok)
+		require.Contains(t, d.destinations, to)
+		routes[from] = to
+	}
+	for from, to := range routes {
+		to2, ok := d.route(from, peers, now)
+		require.True(t, ok)
+		require.Equal(t, to, to2)
+	}
+
+	// A disconnected stem peer is replaced
+	removed := d.destinations[0]
+	d.removePeer(removed)
+	require.Len(t, d.destinations, dandelionStemPeers-1)
+	remaining := make([]string, 0, len(peers))
+	for _, p := range peers {
+		if p != removed {
+			remaining = append(remaining, p)
+		}
+	}
+	for from := range routes {
+		to, ok := d.route(from, remaining, now)
+		require.True(t, ok)
+		require.NotEqual(t, removed, to)
+	}
+	require.ElementsMatch(t, remaining, d.destinations)
+
+	// Stem peers that are not available anymore are not used
+	to, ok = d.route("10.0.0.1:6000", remaining[:1], now)
+	require.True(t, ok)
+	require.Equal(t, remaining[0], to)
+	require.Equal(t, remaining[:1], d.destinations)
+
+	// A new epoch chooses new routes
+	d.route("", peers, now.Add(time.Minute))
+	require.Len(t, d.routes, 1)
+
+	// Fluff mode, transactions from peers are fluffed but our transactions are stemmed
+	d = newDandelion(1, time.Minute, time.Second*30)
+	_, ok = d.route("10.0.0.1:6000", peers, now)
+	require.False(t, ok)
+	_, ok = d.route("", peers, now)
+	require.True(t, ok)
+}
+
+func TestDandelionEmbargo(t *testing.T) {
+	now := time.Now()
+	d := newDandelion(0, time.Minute, time.Second*30)
+
+	h1 := testutil.RandSHA256(t)
+	h2 := testutil.RandSHA256(t)
+	h3 := testutil.RandSHA256(t)
+
+	require.Equal(t, []cipher.SHA256{h1, h2}, d.filterEmbargoed([]cipher.SHA256{h1, h2}))
+
+	d.embargo(h1, now)
+	d.embargo(h2, now)
+	require.True(t, d.isEmbargoed(h1))
+	require.False(t, d.isEmbargoed(h3))
+	require.Equal(t, []cipher.SHA256{h3}, d.filterEmbargoed([]cipher.SHA256{h1, h2, h3}))
+
+	// Embargoes expire between the embargo duration and twice the embargo duration
+	require.Empty(t, d.expireEmbargoes(now.Add(time.Second*29)))
+	for _, exp := range d.embargoes {
+		require.False(t, exp.Before(now.Add(time.Second*30)))
+		require.True(t, exp.Before(now.Add(time.Minute)))
+	}
+
+	// A transaction seen fluffed is not embargoed anymore
+	require.Equal(t, []cipher.SHA256{h1}, d.fluffed([]cipher.SHA256{h1, h3}))
+	require.False(t, d.isEmbargoed(h1))
+	require.Empty(t, d.fluffed([]cipher.SHA256{h1}))
+
+	require.Equal(t, []cipher.SHA256{h2}, d.expireEmbargoes(now.Add(time.Minute)))
+	require.Empty(t, d.embargoes)
+}
+
+// dandelionTestNode simulates the Dandelion++ relay of an in-process node
+type dandelionTestNode struct {
+	addr     string
+	peers    []string
+	d        *dandelion
+	pool     map[cipher.SHA256]struct{}
+	fluffed  map[cipher.SHA256]struct{}
+	dropStem bool
+}
+
+// dandelionTestNetwork delivers the stem transactions and announcements between the nodes
+type dandelionTestNetwork struct {
+	nodes map[string]*dandelionTestNode
+	// stems are the nodes that received the stem transaction, in order
+	stems []string
+	// fluffer is the node that fluffed the stem transaction in fluff mode
+	fluffer string
+}
+
+func newDandelionTestNetwork(n int, fluffProbability float64) *dandelionTestNetwork {
+	net := &dandelionTestNetwork{
+		nodes: make(map[string]*dandelionTestNode, n),
+	}
+
+	addrs := make([]string, n)
+	for i := range addrs {
+		addrs[i] = fmt.Sprintf("10.0.0.%d:6000", i+1)
+	}
+
+	for i, addr := range addrs {
+		var peers []string
+		for j, p := range addrs {
+			if i != j {
+				peers = append(peers, p)
+			}
+		}
+
+		net.nodes[addr] = &dandelionTestNode{
+			addr:    addr,
+			peers:   peers,
+			d:       newDandelion(fluffProbability, time.Minute, time.Second*30),
+			pool:    make(map[cipher.SHA256]struct{}),
+			fluffed: make(map[cipher.SHA256]struct{}),
+		}
+	}
+
+	return net
+}
+
+// receiveStem mirrors Daemon.receiveStemTransaction
+func (net *dandelionTestNetwork) receiveStem(n *dandelionTestNode, from string, h cipher.SHA256, now time.Time) {
+	if _, ok := n.pool[h]; ok {
+		return
+	}
+	n.pool[h] = struct{}{}
+
+	if from != "" {
+		net.stems = append(net.stems, n.addr)
+		if n.dropStem {
+			return
+		}
+	}
+
+	if to, ok := n.d.route(from, n.peers, now); ok {
+		n.d.embargo(h, now)
+		net.receiveStem(net.nodes[to], n.addr, h, now)
+		return
+	}
+
+	net.fluffer = n.addr
+	net.fluff(n, h)
+}
+
+// fluff floods an announcement to all nodes, lifting their embargoes like Daemon.txnsFluffed
+func (net *dandelionTestNetwork) fluff(n *dandelionTestNode, h cipher.SHA256) {
+	n.fluffed[h] = struct{}{}
+	for _, p := range n.peers {
+		m := net.nodes[p]
+		m.d.fluffed([]cipher.SHA256{h})
+		if _, ok := m.fluffed[h]; !ok {
+			m.pool[h] = struct{}{}
+			net.fluff(m, h)
+		}
+	}
+}
+
+// expireEmbargoes mirrors Daemon.fluffExpiredEmbargoes on all nodes
+func (net *dandelionTestNetwork) expireEmbargoes(now time.Time) {
+	for _, n := range net.nodes {
+		for _, h := range n.d.expireEmbargoes(now) {
+			net.fluff(n, h)
+		}
+	}
+}
+
+func TestDandelionStemPropagation(t *testing.T) {
+	now := time.Now()
+	net := newDandelionTestNetwork(10, 0.25)
+	origin := net.nodes["10.0.0.1:6000"]
+
+	for i := 0; i < 20; i++ {
+		net.stems = nil
+		net.fluffer = ""
+		h := testutil.RandSHA256(t)
+		net.receiveStem(origin, "", h, now)
+
+		// The transaction is relayed along a stem before it is fluffed by another node
+		require.NotEmpty(t, net.stems)
+		if net.fluffer != "" {
+			require.NotEqual(t, origin.addr, net.fluffer)
+			require.Equal(t, net.stems[len(net.stems)-1], net.fluffer)
+		} else {
+			// The stem looped back to a node that already had the transaction,
+			// it is fluffed when the embargoes expire
+			net.expireEmbargoes(now.Add(time.Minute))
+		}
+
+		// All nodes have the transaction and no embargo is left
+		for _, n := range net.nodes {
+			require.Contains(t, n.pool, h)
+			require.Contains(t, n.fluffed, h)
+			require.False(t, n.d.isEmbargoed(h))
+		}
+	}
+}
+
+func TestDandelionEmbargoFallback(t *testing.T) {
+	now := time.Now()
+	net := newDandelionTestNetwork(5, 0)
+	origin := net.nodes["10.0.0.1:6000"]
+
+	// All other nodes drop stem transactions
+	for _, n := range net.nodes {
+		if n != origin {
+			n.dropStem = true
+		}
+	}
+
+	h := testutil.RandSHA256(t)
+	net.receiveStem(origin, "", h, now)
+	require.Len(t, net.stems, 1)
+	require.True(t, origin.d.isEmbargoed(h))
+	for _, n := range net.nodes {
+		require.NotContains(t, n.fluffed, h)
+	}
+
+	// The origin fluffs the transaction when its embargo expires
+	net.expireEmbargoes(now.Add(time.Second * 29))
+	require.Empty(t, origin.fluffed)
+
+	net.expireEmbargoes(now.Add(time.Minute))
+	for _, n := range net.nodes {
+		require.Contains(t, n.pool, h)
+		require.Contains(t, n.fluffed, h)
+	}
+}
@@ -84,6 +84,7 @@ var (
 		gnet.ErrDisconnectShutdown:               1005,
 		gnet.ErrDisconnectMessageDecodeUnderflow: 1006,
 		gnet.ErrDisconnectTruncatedMessageID:     1007,
+		gnet.ErrDisconnectEncryptionNotEnabled:   1008,
 	}
 
 	disconnectCodeReasons map[uint16]gnet.DisconnectReason
@@ -0,0 +1,187 @@
+package gnet
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrUploadCapReached is returned in a SendResult when a message is not sent because the daily upload cap was reached
+	ErrUploadCapReached = errors.New("Daily upload cap reached")
+)
+
+// uploadCapCycle is the period of the daily upload cap
+const uploadCapCycle = time.Hour * 24
+
+// Traffic counts the bytes and messages sent and received.
+// The bytes are the length of the messages including their length prefix and message type,
+// without the overhead of the encrypted transport
+type Traffic struct {
+	BytesSent        uint64
+	BytesReceived    uint64
+	MessagesSent     uint64
+	MessagesReceived uint64
+}
+
+// ConnectionTraffic is the traffic of a connection, in total and by message type prefix
+type ConnectionTraffic struct {
+	Traffic
+	MessageTypes map[string]Traffic
+}
+
+func newConnectionTraffic() ConnectionTraffic {
+	return ConnectionTraffic{
+		MessageTypes: make(map[string]Traffic),
+	}
+}
+
+// addSent counts a sent message
+func (t *ConnectionTraffic) addSent(msgType string, n int) {
+	t.BytesSent += uint64(n)
+	t.MessagesSent++
+
+	mt := t.MessageTypes[msgType]
+	mt.BytesSent += uint64(n)
+	mt.MessagesSent++
+	t.MessageTypes[msgType] = mt
+}
+
+// addReceived counts a received message
+func (t *ConnectionTraffic) addReceived(msgType string, n int) {
+	t.BytesReceived += uint64(n)
+	t.MessagesReceived++
+
+	mt := t.MessageTypes[msgType]
+	mt.BytesReceived += uint64(n)
+	mt.MessagesReceived++
+	t.MessageTypes[msgType] = mt
+}
+
+// clone returns a copy that does not share the MessageTypes map
+func (t ConnectionTraffic) clone() ConnectionTraffic {
+	c := t
+	c.MessageTypes = make(map[string]Traffic, len(t.MessageTypes))
+	for k, v := range t.MessageTypes {
+		c.MessageTypes[k] = v
+	}
+	return c
+}
+
+// messageType returns the prefix of a registered message
+func messageType(m Message) string {
+	t := reflect.TypeOf(m)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	id, ok := MessageIDMap[t]
+	if !ok {
+		return ""
+	}
+	return string(id[:])
+}
+
+// rateLimiter limits a byte rate with a token bucket holding up to one second of tokens.
+// Sends larger than the bucket are allowed and leave the bucket in debt, delaying the following sends
+type rateLimiter struct {
+	sync.Mutex
+	// rate in bytes per second. 0 means no limit
+	rate    int64
+	tokens  float64
+	updated time.Time
+}
+
+func newRateLimiter(rate int64) *rateLimiter {
+	return &rateLimiter{
+		rate:    rate,
+		tokens:  float64(rate),
+		updated: time.Now(),
+	}
+}
+
+// reserve takes n bytes from the limiter and returns how long to wait before sending them
+func (l *rateLimiter) reserve(n int, now time.Time) time.Duration {
+	if l == nil || l.rate <= 0 {
+		return 0
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	if now.After(l.updated) {
+		l.tokens += now.Sub(l.updated).Seconds() * float64(l.rate)
+		l.updated = now
+	}
+	if l.tokens > float64(l.rate) {
+		l.tokens = float64(l.rate)
+	}
+
+	l.tokens -= float64(n)
+	if l.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-l.tokens / float64(l.rate) * float64(time.Second))
+}
+
+// uploadCap limits the bytes sent per cycle. Once the cap is reached, only the allowed message types are sent until the cycle ends
+type uploadCap struct {
+	sync.Mutex
+	// max bytes per cycle. 0 means no limit
+	max     uint64
+	allowed map[string]struct{}
+	start   time.Time
+	sent    uint64
+}
+
+func newUploadCap(max uint64, allowed []string) *uploadCap {
+	c := &uploadCap{
+		max:     max,
+		allowed: make(map[string]struct{}, len(allowed)),
+		start:   time.Now(),
+	}
+	for _, a := range allowed {
+		c.allowed[a] = struct{}{}
+	}
+	return c
+}
+
+// reset starts a new cycle if the current cycle ended. The caller must hold the lock
+func (c *uploadCap) reset(now time.Time) {
+	if now.Sub(c.start) >= uploadCapCycle {
+		c.start = now
+		c.sent = 0
+	}
+}
+
+// allow returns true if a message of the type can be sent
+func (c *uploadCap) allow(msgType string, now time.Time) bool {
+	if c == nil || c.max == 0 {
+		return true
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.reset(now)
+	if c.sent < c.max {
+		return true
+	}
+
+	_, ok := c.allowed[msgType]
+	return ok
+}
+
+// add counts sent bytes
+func (c *uploadCap) add(n int, now time.Time) {
+	if c == nil || c.max == 0 {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.reset(now)
+	c.sent += uint64(n)
+}
@@ -0,0 +1,220 @@
+package gnet
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Now()
+
+	// No limit
+	l := newRateLimiter(0)
+	require.Equal(t, time.Duration(0), l.reserve(1e9, now))
+
+	var nilLimiter *rateLimiter
+	require.Equal(t, time.Duration(0), nilLimiter.reserve(1e9, now))
+
+	l = newRateLimiter(1000)
+	l.updated = now
+
+	// The bucket starts full, holding one second of bytes
+	require.Equal(t, time.Duration(0), l.reserve(500, now))
+	require.Equal(t, time.Duration(0), l.reserve(500, now))
+
+	// The bucket is empty, the next send must wait
+	require.Equal(t, time.Millisecond*500, l.reserve(500, now))
+
+	// A send larger than the bucket puts it further in debt
+	require.Equal(t, time.Millisecond*2500, l.reserve(2000, now))
+
+	// The bucket refills over time
+	now = now.Add(time.Millisecond * 2500)
+	require.Equal(t, time.Duration(0), l.reserve(0, now))
+	require.Equal(t, time.Millisecond*100, l.reserve(100, now))
+
+	// The bucket does not refill beyond one second of bytes
+	now = now.Add(time.Hour)
+	require.Equal(t, time.Duration(0), l.reserve(1000, now))
+	require.Equal(t, time.Millisecond, l.reserve(1, now))
+}
+
+func TestUploadCap(t *testing.T) {
+	now := time.Now()
+
+	// No limit
+	c := newUploadCap(0, nil)
+	c.add(1e9, now)
+	require.True(t, c.allow("GIVB", now))
+
+	c = newUploadCap(1000, []string{"ANNB", "GIVH"})
+	c.start = now
+
+	require.True(t, c.allow("GIVB", now))
+	c.add(999, now)
+	require.True(t, c.allow("GIVB", now))
+	c.add(1, now)
+
+	// Only the allowed messages are sent once the cap is reached
+	require.False(t, c.allow("GIVB", now))
+	require.False(t, c.allow("GIVT", now))
+	require.True(t, c.allow("ANNB", now))
+	require.True(t, c.allow("GIVH", now))
+
+	// The cap resets after a day
+	now = now.Add(uploadCapCycle - time.Second)
+	require.False(t, c.allow("GIVB", now))
+	now = now.Add(time.Second)
+	require.True(t, c.allow("GIVB", now))
+	require.Equal(t, uint64(0), c.sent)
+}
+
+func TestConnectionTraffic(t *testing.T) {
+	tr := newConnectionTraffic()
+	tr.addSent("GIVB", 100)
+	tr.addSent("GIVB", 50)
+	tr.addSent("PING", 8)
+	tr.addReceived("PONG", 8)
+	tr.addReceived("GETB", 24)
+
+	require.Equal(t, Traffic{
+		BytesSent:        158,
+		BytesReceived:    32,
+		MessagesSent:     3,
+		MessagesReceived: 2,
+	}, tr.Traffic)
+
+	require.Equal(t, map[string]Traffic{
+		"GIVB": {
+			BytesSent:    150,
+			MessagesSent: 2,
+		},
+		"PING": {
+			BytesSent:    8,
+			MessagesSent: 1,
+		},
+		"PONG": {
+			BytesReceived:    8,
+			MessagesReceived: 1,
+		},
+		"GETB": {
+			BytesReceived:    24,
+			MessagesReceived: 1,
+		},
+	}, tr.MessageTypes)
+
+	// The clone does not share the MessageTypes map
+	c := tr.clone()
+	require.Equal(t, tr, c)
+	c.addSent("GIVB", 1)
+	require.Equal(t, uint64(150), tr.MessageTypes["GIVB"].BytesSent)
+	require.Equal(t, uint64(151), c.MessageTypes["GIVB"].BytesSent)
+}
+
+func TestPoolTraffic(t *testing.T) {
+	resetHandler()
+	EraseMessages()
+	RegisterMessage(BytePrefix, ByteMessage{})
+	VerifyMessages()
+
+	cfg := newTestConfig()
+	cfg.MaxDailyUpload = 9
+	p, err := NewConnectionPool(cfg, nil)
+	require.NoError(t, err)
+
+	cc := make(chan *Connection, 1)
+	p.Config.ConnectCallback = func(addr string, id uint64, solicited bool) {
+		cc <- p.pool[1]
+	}
+
+	disconnected := make(chan struct{}, 1)
+	p.Config.DisconnectCallback = func(addr string, id uint64, reason DisconnectReason) {
+		disconnected <- struct{}{}
+	}
+
+	q := make(chan struct{})
+	go func() {
+		defer close(q)
+		err := p.Run()
+		require.NoError(t, err)
+	}()
+	wait()
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	c := <-cc
+	require.NotNil(t, c)
+
+	// Receive a message from the peer
+	b, err := EncodeMessage(NewByteMessage(7))
+	require.NoError(t, err)
+	_, err = conn.Write(b)
+	require.NoError(t, err)
+
+	// Wait for the message to be handled
+	for i := 0; i < 20; i++ {
+		gc, err := p.GetConnection(c.Addr())
+		require.NoError(t, err)
+		if gc.Traffic.MessagesReceived == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond * 100)
+	}
+
+	// Send a message to the peer, which reaches the upload cap
+	m := NewByteMessage(88)
+	err = p.SendMessage(c.Addr(), m)
+	require.NoError(t, err)
+
+	var sr SendResult
+	select {
+	case sr = <-p.SendResults:
+	case <-time.After(time.Second * 2):
+		t.Fatal("No send results, would block")
+	}
+	require.NoError(t, sr.Error)
+
+	// The next message is not sent but the connection is kept
+	err = p.SendMessage(c.Addr(), m)
+	require.NoError(t, err)
+
+	select {
+	case sr = <-p.SendResults:
+	case <-time.After(time.Second * 2):
+		t.Fatal("No send results, would block")
+	}
+	require.Equal(t, ErrUploadCapReached, sr.Error)
+
+	select {
+	case <-disconnected:
+		t.Fatal("Connection should not be disconnected")
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	gc, err := p.GetConnection(c.Addr())
+	require.NoError(t, err)
+	require.NotNil(t, gc)
+
+	require.Equal(t, Traffic{
+		BytesSent:        9,
+		BytesReceived:    9,
+		MessagesSent:     1,
+		MessagesReceived: 1,
+	}, gc.Traffic.Traffic)
+	require.Equal(t, map[string]Traffic{
+		"BYTE": {
+			BytesSent:        9,
+			BytesReceived:    9,
+			MessagesSent:     1,
+			MessagesReceived: 1,
+		},
+	}, gc.Traffic.MessageTypes)
+
+	p.Shutdown()
+	<-q
+}
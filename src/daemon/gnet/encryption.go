@@ -0,0 +1,695 @@
+package gnet
+
+import (
+	"bytes"
+	gocipher "crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/chacha20poly1305"
+)
+
+/*
+Encrypted transport
+
+When encryption is enabled, the outgoing side of a connection starts with a hello made of
+handshakeMagic, the transport version and an ephemeral secp256k1 public key.
+The incoming side replies with its own hello. Both sides derive a send key and a receive key
+from the ECDH secret of the ephemeral keys and the hashed hellos, and exchange a finished record
+carrying the hash of the hellos to confirm the keys. This happens before the connection is added
+to the pool, so all gnet messages, starting with the daemon's IntroductionMessage, are encrypted.
+
+After the handshake, data is sent in records of the form:
+
+	[4 byte length][chacha20poly1305 sealed: [1 byte record type][payload]]
+
+The length is authenticated as additional data and the nonce is a counter of the records sent
+with the key. After RekeyInterval records, the sender sends a rekey record and both sides hash
+the key of that direction into a new key.
+
+A legacy peer reads handshakeMagic as an invalid message length and disconnects, then the
+outgoing side reconnects without encryption, unless encryption is required.
+A peer that does not enable encryption disconnects with ErrDisconnectEncryptionNotEnabled instead,
+so that the hello is not mistaken for an oversize message.
+A legacy peer sends its first message without waiting for the outgoing side, while a peer that
+supports the encrypted transport waits for the hello. The peer is only treated as a legacy peer
+once it has sent a legacy message on the new connection, so that a connection closed by an attacker
+does not downgrade the transport. An address is not downgraded while it has an open encrypted connection,
+and legacy peers are retried with encryption after legacyPeerExpiry.
+The incoming side treats a connection that does not start with handshakeMagic as a legacy connection.
+
+The handshake only uses ephemeral keys, so peers are not authenticated. The transport protects
+against passive observers, but an on-path attacker can run a handshake with each side and relay,
+read or tamper with the traffic. The records are authenticated with the negotiated keys only.
+*/
+
+const (
+	// Version of the encrypted transport
+	encryptionVersion = 1
+	// Size of the hello sent by both sides of the handshake
+	helloSize = len(handshakeMagic) + 1 + len(cipher.PubKey{})
+	// Byte size of the length prefix of a record
+	recordLengthPrefixSize = 4
+	// Maximum payload size of a record. Larger writes are split in several records
+	maxRecordPayloadSize = 16 * 1024
+
+	// How long a peer is remembered as a legacy peer before the encrypted transport is tried again
+	legacyPeerExpiry = time.Hour
+	// Maximum number of legacy peers remembered. The oldest mark is dropped to add a new one
+	maxLegacyPeers = 4096
+
+	recordTypeData     byte = 0
+	recordTypeRekey    byte = 1
+	recordTypeFinished byte = 2
+)
+
+var (
+	// handshakeMagic starts the hello. It decodes to a message length larger than any
+	// sane MaxIncomingMessageLength, so that legacy peers disconnect right away
+	handshakeMagic = [4]byte{0xFF, 'S', 'K', 'E'}
+
+	initiatorKeyLabel = []byte("skycoin gnet initiator key")
+	responderKeyLabel = []byte("skycoin gnet responder key")
+	rekeyLabel        = []byte("skycoin gnet rekey")
+
+	// ErrEncryptionRequired the peer does not support the encrypted transport, which is required by the config
+	ErrEncryptionRequired = errors.New("Peer does not support the encrypted transport")
+	// ErrHandshakeFailed the encrypted transport handshake failed
+	ErrHandshakeFailed = errors.New("Encrypted transport handshake failed")
+	// ErrDisconnectInvalidRecord an encrypted record is malformed or failed authentication
+	ErrDisconnectInvalidRecord DisconnectReason = errors.New("Invalid encrypted record")
+	// ErrDisconnectEncryptionNotEnabled the peer started the encrypted transport handshake, but encryption is not enabled
+	ErrDisconnectEncryptionNotEnabled DisconnectReason = errors.New("Peer started an encrypted transport handshake, but encryption is not enabled")
+
+	errLegacyPeer      = errors.New("Peer does not support the encrypted transport")
+	errHandshakeClosed = errors.New("Peer closed the connection during the encrypted transport handshake")
+)
+
+// cipherState is the key and nonce counter of one direction of an encrypted connection
+type cipherState struct {
+	key    cipher.SHA256
+	aead   gocipher.AEAD
+	nonce  uint64
+	rekeys uint64
+}
+
+func newCipherState(key cipher.SHA256) (*cipherState, error) {
+	aead, err := chacha20poly1305.New(key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &cipherState{
+		key:  key,
+		aead: aead,
+	}, nil
+}
+
+func (cs *cipherState) nextNonce() []byte {
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	binary.LittleEndian.PutUint64(nonce[chacha20poly1305.NonceSize-8:], cs.nonce)
+	cs.nonce++
+	return nonce
+}
+
+// rekey replaces the key with the hash of the key and resets the nonce counter
+func (cs *cipherState) rekey() error {
+	key := cipher.SumSHA256(append(cs.key[:], rekeyLabel...))
+	aead, err := chacha20poly1305.New(key[:])
+	if err != nil {
+		return err
+	}
+
+	cs.key = key
+	cs.aead = aead
+	cs.nonce = 0
+	cs.rekeys++
+	return nil
+}
+
+// writeRecord seals the payload in a record and writes it to w
+func writeRecord(w io.Writer, cs *cipherState, recordType byte, payload []byte) error {
+	plaintext := make([]byte, 1+len(payload))
+	plaintext[0] = recordType
+	copy(plaintext[1:], payload)
+
+	prefix := make([]byte, recordLengthPrefixSize)
+	binary.LittleEndian.PutUint32(prefix, uint32(len(plaintext)+cs.aead.Overhead()))
+
+	record := cs.aead.Seal(prefix, cs.nextNonce(), plaintext, prefix)
+	_, err := w.Write(record)
+	return err
+}
+
+// readRecord reads a record from r and opens it
+func readRecord(r io.Reader, cs *cipherState) (byte, []byte, error) {
+	prefix := make([]byte, recordLengthPrefixSize)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return 0, nil, err
+	}
+
+	length := int(binary.LittleEndian.Uint32(prefix))
+	if length < 1+cs.aead.Overhead() || length > 1+maxRecordPayloadSize+cs.aead.Overhead() {
+		return 0, nil, ErrDisconnectInvalidRecord
+	}
+
+	sealed := make([]byte, length)
+	if _, err := io.ReadFull(r, sealed); err != nil {
+		return 0, nil, err
+	}
+
+	plaintext, err := cs.aead.Open(sealed[:0], cs.nextNonce(), sealed, prefix)
+	if err != nil {
+		return 0, nil, ErrDisconnectInvalidRecord
+	}
+
+	return plaintext[0], plaintext[1:], nil
+}
+
+// secureConn is a net.Conn which encrypts the data written to the underlying connection
+// and decrypts the data read from it
+type secureConn struct {
+	net.Conn
+	rekeyInterval uint64
+
+	readLock  sync.Mutex
+	recv      *cipherState
+	pending   []byte
+	writeLock sync.Mutex
+	send      *cipherState
+}
+
+// Read reads decrypted data
+func (c *secureConn) Read(b []byte) (int, error) {
+	c.readLock.Lock()
+	defer c.readLock.Unlock()
+
+	for len(c.pending) == 0 {
+		recordType, payload, err := readRecord(c.Conn, c.recv)
+		if err != nil {
+			return 0, err
+		}
+
+		switch recordType {
+		case recordTypeData:
+			c.pending = payload
+		case recordTypeRekey:
+			if err := c.recv.rekey(); err != nil {
+				return 0, err
+			}
+		default:
+			return 0, ErrDisconnectInvalidRecord
+		}
+	}
+
+	n := copy(b, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+// Write encrypts the data in records of at most maxRecordPayloadSize bytes
+func (c *secureConn) Write(b []byte) (int, error) {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	var n int
+	for len(b) > 0 {
+		payload := b
+		if len(payload) > maxRecordPayloadSize {
+			payload = payload[:maxRecordPayloadSize]
+		}
+
+		if err := c.writeRecord(recordTypeData, payload); err != nil {
+			return n, err
+		}
+
+		n += len(payload)
+		b = b[len(payload):]
+	}
+
+	return n, nil
+}
+
+func (c *secureConn) writeRecord(recordType byte, payload []byte) error {
+	// Rotate the key before writing once it has sealed rekeyInterval records.
+	// The rekey record is sealed with the old key, the peer switches to the new key after opening it
+	if c.rekeyInterval != 0 && c.send.nonce >= c.rekeyInterval {
+		if err := writeRecord(c.Conn, c.send, recordTypeRekey, nil); err != nil {
+			return err
+		}
+
+		if err := c.send.rekey(); err != nil {
+			return err
+		}
+	}
+
+	return writeRecord(c.Conn, c.send, recordType, payload)
+}
+
+// prefixConn is a net.Conn which returns the bytes read while detecting a legacy peer before the rest of the stream
+type prefixConn struct {
+	net.Conn
+	prefix []byte
+}
+
+// Read reads the prefix, then the underlying connection
+func (c *prefixConn) Read(b []byte) (int, error) {
+	if len(c.prefix) == 0 {
+		return c.Conn.Read(b)
+	}
+
+	n := copy(b, c.prefix)
+	c.prefix = c.prefix[n:]
+	return n, nil
+}
+
+// handshake is the state of an encrypted transport handshake
+type handshake struct {
+	conn      net.Conn
+	initiator bool
+	pubKey    cipher.PubKey
+	secKey    cipher.SecKey
+	hello     []byte
+}
+
+func newHandshake(conn net.Conn, initiator bool) *handshake {
+	pubKey, secKey := cipher.GenerateKeyPair()
+
+	hello := make([]byte, 0, helloSize)
+	hello = append(hello, handshakeMagic[:]...)
+	hello = append(hello, encryptionVersion)
+	hello = append(hello, pubKey[:]...)
+
+	return &handshake{
+		conn:      conn,
+		initiator: initiator,
+		pubKey:    pubKey,
+		secKey:    secKey,
+		hello:     hello,
+	}
+}
+
+// readHello reads the rest of the peer's hello after handshakeMagic
+func (h *handshake) readHello() ([]byte, error) {
+	hello := make([]byte, helloSize)
+	copy(hello, handshakeMagic[:])
+	if _, err := io.ReadFull(h.conn, hello[len(handshakeMagic):]); err != nil {
+		return nil, err
+	}
+
+	if hello[len(handshakeMagic)] != encryptionVersion {
+		return nil, ErrHandshakeFailed
+	}
+
+	return hello, nil
+}
+
+// finish derives the keys from the peer's hello and exchanges the finished records
+func (h *handshake) finish(peerHello []byte, rekeyInterval uint64) (*secureConn, error) {
+	peerPubKey, err := cipher.NewPubKey(peerHello[len(handshakeMagic)+1:])
+	if err != nil {
+		return nil, ErrHandshakeFailed
+	}
+
+	secret, err := cipher.ECDH(peerPubKey, h.secKey)
+	if err != nil {
+		return nil, ErrHandshakeFailed
+	}
+
+	initiatorHello, responderHello := h.hello, peerHello
+	if !h.initiator {
+		initiatorHello, responderHello = peerHello, h.hello
+	}
+
+	transcript := cipher.SumSHA256(append(append([]byte{}, initiatorHello...), responderHello...))
+
+	deriveKey := func(label []byte) cipher.SHA256 {
+		b := make([]byte, 0, len(secret)+len(transcript)+len(label))
+		b = append(b, secret...)
+		b = append(b, transcript[:]...)
+		b = append(b, label...)
+		return cipher.SumSHA256(b)
+	}
+
+	sendKey, recvKey := deriveKey(initiatorKeyLabel), deriveKey(responderKeyLabel)
+	if !h.initiator {
+		sendKey, recvKey = recvKey, sendKey
+	}
+
+	send, err := newCipherState(sendKey)
+	if err != nil {
+		return nil, err
+	}
+
+	recv, err := newCipherState(recvKey)
+	if err != nil {
+		return nil, err
+	}
+
+	writeFinished := func() error {
+		if err := writeRecord(h.conn, send, recordTypeFinished, transcript[:]); err != nil {
+			return &WriteError{
+				Err: err,
+			}
+		}
+		return nil
+	}
+
+	readFinished := func() error {
+		recordType, payload, err := readRecord(h.conn, recv)
+		switch err {
+		case nil:
+		case ErrDisconnectInvalidRecord:
+			return ErrHandshakeFailed
+		default:
+			return err
+		}
+
+		if recordType != recordTypeFinished || !bytes.Equal(payload, transcript[:]) {
+			return ErrHandshakeFailed
+		}
+		return nil
+	}
+
+	// The initiator sends its finished record first, the responder replies once it verified it
+	steps := []func() error{writeFinished, readFinished}
+	if !h.initiator {
+		steps = []func() error{readFinished, writeFinished}
+	}
+
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &secureConn{
+		Conn:          h.conn,
+		rekeyInterval: rekeyInterval,
+		send:          send,
+		recv:          recv,
+	}, nil
+}
+
+// dialHandshake runs the handshake of an outgoing connection.
+// Returns errLegacyPeer if the peer answered with a legacy message,
+// or errHandshakeClosed if the peer closed the connection without answering.
+func dialHandshake(conn net.Conn, timeout time.Duration, rekeyInterval uint64) (net.Conn, error) {
+	if err := setHandshakeDeadline(conn, timeout); err != nil {
+		return nil, err
+	}
+
+	h := newHandshake(conn, true)
+	if _, err := conn.Write(h.hello); err != nil {
+		return nil, &WriteError{
+			Err: err,
+		}
+	}
+
+	var magic [len(handshakeMagic)]byte
+	if _, err := io.ReadFull(conn, magic[:]); err != nil {
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return nil, err
+		}
+		return nil, errHandshakeClosed
+	}
+
+	if magic != handshakeMagic {
+		var prefix MessagePrefix
+		if _, err := io.ReadFull(conn, prefix[:]); err != nil {
+			return nil, ErrHandshakeFailed
+		}
+		if !isLegacyMessageHeader(append(magic[:], prefix[:]...)) {
+			return nil, ErrHandshakeFailed
+		}
+		return nil, errLegacyPeer
+	}
+
+	peerHello, err := h.readHello()
+	if err != nil {
+		return nil, err
+	}
+
+	sc, err := h.finish(peerHello, rekeyInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := setHandshakeDeadline(conn, 0); err != nil {
+		return nil, err
+	}
+
+	return sc, nil
+}
+
+// acceptHandshake runs the handshake of an incoming connection.
+// If the peer does not start with a hello, it is a legacy peer and an unencrypted connection is returned,
+// unless encryption is required.
+func acceptHandshake(conn net.Conn, timeout time.Duration, rekeyInterval uint64, required bool) (net.Conn, error) {
+	if err := setHandshakeDeadline(conn, timeout); err != nil {
+		return nil, err
+	}
+
+	var magic [len(handshakeMagic)]byte
+	if _, err := io.ReadFull(conn, magic[:]); err != nil {
+		return nil, err
+	}
+
+	var c net.Conn
+	if magic != handshakeMagic {
+		if required {
+			return nil, ErrEncryptionRequired
+		}
+
+		c = &prefixConn{
+			Conn:   conn,
+			prefix: magic[:],
+		}
+	} else {
+		h := newHandshake(conn, false)
+		peerHello, err := h.readHello()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := conn.Write(h.hello); err != nil {
+			return nil, &WriteError{
+				Err: err,
+			}
+		}
+
+		c, err = h.finish(peerHello, rekeyInterval)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := setHandshakeDeadline(conn, 0); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// readLegacyMessageHeader reads the header of the first message of an unencrypted outgoing connection.
+// Returns the connection with the header read again, or ErrHandshakeFailed if the peer
+// did not send a legacy message.
+func readLegacyMessageHeader(conn net.Conn, timeout time.Duration) (net.Conn, error) {
+	if err := setHandshakeDeadline(conn, timeout); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, messageLengthPrefixSize+messagePrefixLength)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return nil, err
+		}
+		return nil, ErrHandshakeFailed
+	}
+
+	if !isLegacyMessageHeader(header) {
+		return nil, ErrHandshakeFailed
+	}
+
+	if err := setHandshakeDeadline(conn, 0); err != nil {
+		return nil, err
+	}
+
+	return &prefixConn{
+		Conn:   conn,
+		prefix: header,
+	}, nil
+}
+
+// isLegacyMessageHeader returns true if b is the length prefix and the prefix of a registered message
+func isLegacyMessageHeader(b []byte) bool {
+	length := binary.LittleEndian.Uint32(b[:messageLengthPrefixSize])
+	if length < messagePrefixLength {
+		return false
+	}
+
+	var prefix MessagePrefix
+	copy(prefix[:], b[messageLengthPrefixSize:])
+	_, ok := MessageIDReverseMap[prefix]
+	return ok
+}
+
+// setHandshakeDeadline sets the read and write deadline of the handshake.
+// A timeout of 0 clears the deadline
+func setHandshakeDeadline(conn net.Conn, timeout time.Duration) error {
+	deadline := time.Time{}
+	if timeout != 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	return conn.SetDeadline(deadline)
+}
+
+// setupTransport negotiates the encrypted transport of a new connection.
+// It returns the connection to exchange the gnet messages on, which is unencrypted
+// if encryption is disabled or the peer is a legacy peer.
+// An outgoing connection to a legacy peer is replaced by a new unencrypted connection.
+func (pool *ConnectionPool) setupTransport(conn net.Conn, solicited bool) (net.Conn, error) {
+	if !pool.Config.EnableEncryption {
+		return conn, nil
+	}
+
+	cfg := pool.Config
+	if !solicited {
+		return acceptHandshake(conn, cfg.HandshakeTimeout, cfg.RekeyInterval, cfg.RequireEncryption)
+	}
+
+	addr := conn.RemoteAddr().String()
+	if !cfg.RequireEncryption && pool.isLegacyPeer(addr) {
+		return conn, nil
+	}
+
+	c, err := dialHandshake(conn, cfg.HandshakeTimeout, cfg.RekeyInterval)
+	switch err {
+	case nil:
+		return c, nil
+	case errLegacyPeer:
+		if cfg.RequireEncryption {
+			return nil, ErrEncryptionRequired
+		}
+	case errHandshakeClosed:
+		// A legacy peer can disconnect before sending its first message
+		if cfg.RequireEncryption {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	if pool.isEncryptedPeer(addr) {
+		logger.WithField("addr", addr).Warning("Peer has an open encrypted connection, not reconnecting without encryption")
+		return nil, ErrHandshakeFailed
+	}
+
+	// The legacy peer disconnects after reading the hello, reconnect without encryption
+	// and wait for its first message to confirm that it is a legacy peer
+	legacyConn, err := pool.dial(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err = readLegacyMessageHeader(legacyConn, cfg.HandshakeTimeout)
+	if err != nil {
+		if err := legacyConn.Close(); err != nil {
+			logger.WithError(err).WithField("addr", addr).Warning("legacyConn.Close")
+		}
+		return nil, err
+	}
+
+	if err := conn.Close(); err != nil {
+		logger.WithError(err).WithField("addr", addr).Warning("conn.Close")
+	}
+
+	logger.WithField("addr", addr).Info("Peer does not support the encrypted transport, connected without encryption")
+	pool.setLegacyPeer(addr)
+
+	return c, nil
+}
+
+// isLegacyPeer returns true if the peer at addr did not support the encrypted transport
+// less than legacyPeerExpiry ago
+func (pool *ConnectionPool) isLegacyPeer(addr string) bool {
+	pool.transportPeersLock.Lock()
+	defer pool.transportPeersLock.Unlock()
+
+	t, ok := pool.legacyPeers[addr]
+	if !ok {
+		return false
+	}
+
+	if time.Since(t) >= legacyPeerExpiry {
+		delete(pool.legacyPeers, addr)
+		return false
+	}
+
+	return true
+}
+
+func (pool *ConnectionPool) setLegacyPeer(addr string) {
+	pool.transportPeersLock.Lock()
+	defer pool.transportPeersLock.Unlock()
+
+	if _, ok := pool.legacyPeers[addr]; !ok && len(pool.legacyPeers) >= maxLegacyPeers {
+		pool.pruneLegacyPeers()
+	}
+
+	if _, ok := pool.legacyPeers[addr]; !ok && len(pool.legacyPeers) >= maxLegacyPeers {
+		var oldest string
+		var oldestTime time.Time
+		for a, t := range pool.legacyPeers {
+			if oldest == "" || t.Before(oldestTime) {
+				oldest = a
+				oldestTime = t
+			}
+		}
+		delete(pool.legacyPeers, oldest)
+	}
+
+	pool.legacyPeers[addr] = time.Now()
+}
+
+// PruneLegacyPeers forgets the legacy peers whose mark is older than legacyPeerExpiry
+func (pool *ConnectionPool) PruneLegacyPeers() {
+	pool.transportPeersLock.Lock()
+	defer pool.transportPeersLock.Unlock()
+	pool.pruneLegacyPeers()
+}
+
+// pruneLegacyPeers forgets the expired legacy peers. The caller must hold transportPeersLock
+func (pool *ConnectionPool) pruneLegacyPeers() {
+	for addr, t := range pool.legacyPeers {
+		if time.Since(t) >= legacyPeerExpiry {
+			delete(pool.legacyPeers, addr)
+		}
+	}
+}
+
+// isEncryptedPeer returns true if the peer at addr has an open connection that completed the encrypted transport handshake
+func (pool *ConnectionPool) isEncryptedPeer(addr string) bool {
+	pool.transportPeersLock.Lock()
+	defer pool.transportPeersLock.Unlock()
+	_, ok := pool.encryptedPeers[addr]
+	return ok
+}
+
+func (pool *ConnectionPool) setEncryptedPeer(addr string) {
+	pool.transportPeersLock.Lock()
+	defer pool.transportPeersLock.Unlock()
+	pool.encryptedPeers[addr] = struct{}{}
+	delete(pool.legacyPeers, addr)
+}
+
+func (pool *ConnectionPool) removeEncryptedPeer(addr string) {
+	pool.transportPeersLock.Lock()
+	defer pool.transportPeersLock.Unlock()
+	delete(pool.encryptedPeers, addr)
+}
@@ -0,0 +1,685 @@
+package gnet
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+type secureTestMessage struct {
+	Data []byte
+}
+
+var secureTestPrefix = MessagePrefix{'S', 'E', 'C', 'T'}
+
+func (m *secureTestMessage) EncodeSize() uint64 {
+	return uint64(encoder.Size(m))
+}
+
+func (m *secureTestMessage) Encode(buf []byte) error {
+	b := encoder.Serialize(m)
+	if len(buf) < len(b) {
+		return errors.New("Not enough buffer data to encode")
+	}
+	copy(buf, b)
+	return nil
+}
+
+func (m *secureTestMessage) Decode(buf []byte) (uint64, error) {
+	return encoder.DeserializeRaw(buf, m)
+}
+
+func (m *secureTestMessage) Handle(c *MessageContext, state interface{}) error {
+	state.(chan []byte) <- m.Data
+	return nil
+}
+
+// handshakePipe runs the handshake of both sides of an in-memory connection
+func handshakePipe(t *testing.T, rekeyInterval uint64) (net.Conn, net.Conn) {
+	c1, c2 := net.Pipe()
+
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	rc := make(chan result, 1)
+	go func() {
+		c, err := acceptHandshake(c2, time.Second*3, rekeyInterval, true)
+		rc <- result{c, err}
+	}()
+
+	dc, err := dialHandshake(c1, time.Second*3, rekeyInterval)
+	require.NoError(t, err)
+
+	r := <-rc
+	require.NoError(t, r.err)
+
+	return dc, r.conn
+}
+
+func TestSecureConnHandshake(t *testing.T) {
+	dc, ac := handshakePipe(t, 0)
+	defer dc.Close()
+	defer ac.Close()
+
+	d := dc.(*secureConn)
+	a := ac.(*secureConn)
+	require.Equal(t, d.send.key, a.recv.key)
+	require.Equal(t, d.recv.key, a.send.key)
+	require.NotEqual(t, d.send.key, d.recv.key)
+
+	// Each handshake uses new ephemeral keys
+	dc2, ac2 := handshakePipe(t, 0)
+	defer dc2.Close()
+	defer ac2.Close()
+	require.NotEqual(t, d.send.key, dc2.(*secureConn).send.key)
+}
+
+func TestSecureConnReadWrite(t *testing.T) {
+	cases := []struct {
+		name          string
+		rekeyInterval uint64
+		sizes         []int
+		rekeys        uint64
+	}{
+		{
+			name:  "small writes",
+			sizes: []int{1, 10, 100},
+		},
+		{
+			name:   "writes split in records",
+			sizes:  []int{maxRecordPayloadSize, maxRecordPayloadSize + 1, 3*maxRecordPayloadSize + 7},
+			rekeys: 0,
+		},
+		{
+			name:          "rekeying",
+			rekeyInterval: 2,
+			sizes:         []int{5, 5, 5, 5, 5, 5, 5},
+			rekeys:        3,
+		},
+		{
+			name:          "rekeying within a write",
+			rekeyInterval: 3,
+			sizes:         []int{10*maxRecordPayloadSize + 1},
+			rekeys:        3,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dc, ac := handshakePipe(t, tc.rekeyInterval)
+			defer dc.Close()
+			defer ac.Close()
+
+			var sent []byte
+			errC := make(chan error, 1)
+			go func() {
+				for i, n := range tc.sizes {
+					b := bytes.Repeat([]byte{byte(i + 1)}, n)
+					sent = append(sent, b...)
+					if _, err := dc.Write(b); err != nil {
+						errC <- err
+						return
+					}
+				}
+				errC <- nil
+			}()
+
+			var total int
+			for _, n := range tc.sizes {
+				total += n
+			}
+
+			received := make([]byte, total)
+			_, err := io.ReadFull(ac, received)
+			require.NoError(t, err)
+			require.NoError(t, <-errC)
+			require.Equal(t, sent, received)
+
+			d := dc.(*secureConn)
+			a := ac.(*secureConn)
+			require.Equal(t, tc.rekeys, d.send.rekeys)
+			require.Equal(t, tc.rekeys, a.recv.rekeys)
+			require.Equal(t, d.send.key, a.recv.key)
+		})
+	}
+}
+
+func TestSecureConnTamperedRecord(t *testing.T) {
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	rc := make(chan result, 1)
+	go func() {
+		c, err := acceptHandshake(c2, time.Second*3, 0, true)
+		rc <- result{c, err}
+	}()
+
+	dc, err := dialHandshake(c1, time.Second*3, 0)
+	require.NoError(t, err)
+	r := <-rc
+	require.NoError(t, r.err)
+
+	// Seal a record, flip a bit of the ciphertext and write it on the raw connection
+	var buf bytes.Buffer
+	err = writeRecord(&buf, dc.(*secureConn).send, recordTypeData, []byte("hello"))
+	require.NoError(t, err)
+	record := buf.Bytes()
+	record[len(record)-1] ^= 0x01
+
+	go func() {
+		_, err := c1.Write(record)
+		require.NoError(t, err)
+	}()
+
+	_, err = r.conn.Read(make([]byte, 16))
+	require.Equal(t, ErrDisconnectInvalidRecord, err)
+}
+
+func TestAcceptHandshakeLegacy(t *testing.T) {
+	msg := []byte{10, 0, 0, 0, 'I', 'N', 'T', 'R', 1, 2, 3, 4, 5, 6}
+
+	for _, required := range []bool{false, true} {
+		t.Run(fmt.Sprintf("required=%v", required), func(t *testing.T) {
+			c1, c2 := net.Pipe()
+			defer c1.Close()
+			defer c2.Close()
+
+			// The write fails when the connection is closed after rejecting the peer
+			go c1.Write(msg) // nolint: errcheck
+
+			c, err := acceptHandshake(c2, time.Second*3, 0, required)
+			if required {
+				require.Equal(t, ErrEncryptionRequired, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.False(t, isEncrypted(c))
+
+			// The bytes read to detect the legacy peer are read again
+			received := make([]byte, len(msg))
+			_, err = io.ReadFull(c, received)
+			require.NoError(t, err)
+			require.Equal(t, msg, received)
+		})
+	}
+}
+
+func TestDialHandshakeLegacy(t *testing.T) {
+	setupSecureTestMessages()
+
+	t.Run("legacy message", func(t *testing.T) {
+		c1, c2 := net.Pipe()
+		defer c1.Close()
+		defer c2.Close()
+
+		go func() {
+			// A legacy peer sends its introduction message
+			_, err := io.ReadFull(c2, make([]byte, helloSize))
+			require.NoError(t, err)
+			// The write fails when the connection is closed after detecting the legacy peer
+			c2.Write([]byte{10, 0, 0, 0, 'S', 'E', 'C', 'T'}) // nolint: errcheck
+		}()
+
+		_, err := dialHandshake(c1, time.Second*3, 0)
+		require.Equal(t, errLegacyPeer, err)
+	})
+
+	t.Run("unknown message", func(t *testing.T) {
+		c1, c2 := net.Pipe()
+		defer c1.Close()
+		defer c2.Close()
+
+		go func() {
+			_, err := io.ReadFull(c2, make([]byte, helloSize))
+			require.NoError(t, err)
+			c2.Write([]byte{10, 0, 0, 0, 'I', 'N', 'T', 'R'}) // nolint: errcheck
+		}()
+
+		_, err := dialHandshake(c1, time.Second*3, 0)
+		require.Equal(t, ErrHandshakeFailed, err)
+	})
+
+	t.Run("disconnected", func(t *testing.T) {
+		c1, c2 := net.Pipe()
+		defer c1.Close()
+
+		go func() {
+			// A legacy peer disconnects after reading an invalid message length
+			_, err := io.ReadFull(c2, make([]byte, helloSize))
+			require.NoError(t, err)
+			c2.Close()
+		}()
+
+		_, err := dialHandshake(c1, time.Second*3, 0)
+		require.Equal(t, errHandshakeClosed, err)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		c1, c2 := net.Pipe()
+		defer c1.Close()
+		defer c2.Close()
+
+		// The peer reads the hello and never replies
+		go io.ReadFull(c2, make([]byte, helloSize)) // nolint: errcheck
+
+		_, err := dialHandshake(c1, time.Millisecond*500, 0)
+		require.Error(t, err)
+		require.NotEqual(t, errLegacyPeer, err)
+		netErr, ok := err.(net.Error)
+		require.True(t, ok)
+		require.True(t, netErr.Timeout())
+	})
+}
+
+type testPeer struct {
+	pool     *ConnectionPool
+	addr     string
+	received chan []byte
+	connects chan string
+	failures chan error
+	done     chan struct{}
+}
+
+func newTestPeer(t *testing.T, port uint16, enableEncryption, requireEncryption bool) *testPeer {
+	cfg := newTestConfig()
+	cfg.Port = port
+	cfg.EnableEncryption = enableEncryption
+	cfg.RequireEncryption = requireEncryption
+	return newTestPeerConfig(t, cfg)
+}
+
+func newTestPeerConfig(t *testing.T, cfg Config) *testPeer {
+	if cfg.HandshakeTimeout == 0 {
+		cfg.HandshakeTimeout = time.Second * 3
+	}
+	cfg.RekeyInterval = 3
+
+	port := cfg.Port
+	p := &testPeer{
+		addr:     fmt.Sprintf("%s:%d", address, port),
+		received: make(chan []byte, 100),
+		connects: make(chan string, 10),
+		failures: make(chan error, 10),
+		done:     make(chan struct{}),
+	}
+
+	cfg.ConnectCallback = func(addr string, id uint64, solicited bool) {
+		p.connects <- addr
+
+		// Like the daemon, a legacy peer sends its introduction message as soon as it is connected
+		if !cfg.EnableEncryption && !solicited {
+			go p.pool.SendMessage(addr, &secureTestMessage{Data: []byte("intro")}) // nolint: errcheck
+		}
+	}
+	cfg.ConnectFailureCallback = func(addr string, solicited bool, err error) {
+		p.failures <- err
+	}
+
+	var err error
+	p.pool, err = NewConnectionPool(cfg, p.received)
+	require.NoError(t, err)
+
+	go func() {
+		defer close(p.done)
+		err := p.pool.Run()
+		require.NoError(t, err)
+	}()
+
+	return p
+}
+
+func (p *testPeer) shutdown() {
+	p.pool.Shutdown()
+	<-p.done
+}
+
+func waitConnect(t *testing.T, p *testPeer) string {
+	select {
+	case addr := <-p.connects:
+		return addr
+	case err := <-p.failures:
+		t.Fatalf("Connection failed: %v", err)
+	case <-time.After(time.Second * 3):
+		t.Fatal("Timed out waiting for connection")
+	}
+	return ""
+}
+
+func waitFailure(t *testing.T, p *testPeer) error {
+	select {
+	case err := <-p.failures:
+		return err
+	case addr := <-p.connects:
+		t.Fatalf("Connection to %s should fail", addr)
+	case <-time.After(time.Second * 3):
+		t.Fatal("Timed out waiting for connection failure")
+	}
+	return nil
+}
+
+func waitReceived(t *testing.T, p *testPeer) []byte {
+	select {
+	case data := <-p.received:
+		return data
+	case <-time.After(time.Second * 3):
+		t.Fatal("Timed out waiting for message")
+	}
+	return nil
+}
+
+func setupSecureTestMessages() {
+	resetHandler()
+	EraseMessages()
+	RegisterMessage(secureTestPrefix, secureTestMessage{})
+	VerifyMessages()
+}
+
+func TestPoolEncryptedConnection(t *testing.T) {
+	setupSecureTestMessages()
+
+	p1 := newTestPeer(t, port+20, true, false)
+	defer p1.shutdown()
+	p2 := newTestPeer(t, port+21, true, true)
+	defer p2.shutdown()
+	wait()
+
+	err := p1.pool.Connect(p2.addr)
+	require.NoError(t, err)
+
+	require.Equal(t, p2.addr, waitConnect(t, p1))
+	p1Addr := waitConnect(t, p2)
+
+	c1, err := p1.pool.GetConnection(p2.addr)
+	require.NoError(t, err)
+	require.True(t, c1.Encrypted)
+	c2, err := p2.pool.GetConnection(p1Addr)
+	require.NoError(t, err)
+	require.True(t, c2.Encrypted)
+
+	// Send enough messages in both directions to rotate the keys a few times
+	for i := 1; i <= 10; i++ {
+		data := bytes.Repeat([]byte{byte(i)}, i*1000)
+		err := p1.pool.SendMessage(p2.addr, &secureTestMessage{Data: data})
+		require.NoError(t, err)
+		require.Equal(t, data, waitReceived(t, p2))
+
+		err = p2.pool.SendMessage(p1Addr, &secureTestMessage{Data: data})
+		require.NoError(t, err)
+		require.Equal(t, data, waitReceived(t, p1))
+	}
+
+	var rekeys uint64
+	err = p1.pool.strand("rekeys", func() error {
+		c := p1.pool.addresses[p2.addr].Conn.(*secureConn)
+		c.writeLock.Lock()
+		defer c.writeLock.Unlock()
+		rekeys = c.send.rekeys
+		return nil
+	})
+	require.NoError(t, err)
+	require.True(t, rekeys > 0)
+
+	// The encrypted peer is only remembered while it is connected
+	require.True(t, p1.pool.isEncryptedPeer(p2.addr))
+	err = p1.pool.Disconnect(p2.addr, ErrDisconnectUnknownMessage)
+	require.NoError(t, err)
+	require.False(t, p1.pool.isEncryptedPeer(p2.addr))
+}
+
+func TestPoolEncryptionLegacyPeer(t *testing.T) {
+	setupSecureTestMessages()
+
+	p1 := newTestPeer(t, port+22, true, false)
+	defer p1.shutdown()
+	legacy := newTestPeer(t, port+23, false, false)
+	defer legacy.shutdown()
+	wait()
+
+	// The outgoing connection falls back to an unencrypted connection
+	err := p1.pool.Connect(legacy.addr)
+	require.NoError(t, err)
+
+	require.Equal(t, legacy.addr, waitConnect(t, p1))
+
+	// The legacy peer disconnected the connection which started with the hello
+	first := waitConnect(t, legacy)
+	p1Addr := waitConnect(t, legacy)
+	require.NotEqual(t, first, p1Addr)
+
+	c, err := p1.pool.GetConnection(legacy.addr)
+	require.NoError(t, err)
+	require.False(t, c.Encrypted)
+	require.True(t, p1.pool.isLegacyPeer(legacy.addr))
+
+	// The introduction message that confirmed the legacy peer is received
+	require.Equal(t, []byte("intro"), waitReceived(t, p1))
+
+	err = p1.pool.SendMessage(legacy.addr, &secureTestMessage{Data: []byte("foo")})
+	require.NoError(t, err)
+	require.Equal(t, []byte("foo"), waitReceived(t, legacy))
+
+	err = legacy.pool.SendMessage(p1Addr, &secureTestMessage{Data: []byte("bar")})
+	require.NoError(t, err)
+	require.Equal(t, []byte("bar"), waitReceived(t, p1))
+
+	// An incoming connection from a legacy peer is accepted unencrypted.
+	// It is added to the pool once the legacy peer sends its first message
+	err = legacy.pool.Disconnect(p1Addr, ErrDisconnectShutdown)
+	require.NoError(t, err)
+	wait()
+
+	err = legacy.pool.Connect(p1.addr)
+	require.NoError(t, err)
+	require.Equal(t, p1.addr, waitConnect(t, legacy))
+
+	err = legacy.pool.SendMessage(p1.addr, &secureTestMessage{Data: []byte("baz")})
+	require.NoError(t, err)
+
+	legacyAddr := waitConnect(t, p1)
+	require.Equal(t, []byte("baz"), waitReceived(t, p1))
+
+	c, err = p1.pool.GetConnection(legacyAddr)
+	require.NoError(t, err)
+	require.False(t, c.Encrypted)
+}
+
+func TestPoolEncryptionRequired(t *testing.T) {
+	setupSecureTestMessages()
+
+	p1 := newTestPeer(t, port+24, true, true)
+	defer p1.shutdown()
+	legacy := newTestPeer(t, port+25, false, false)
+	defer legacy.shutdown()
+	wait()
+
+	// Outgoing connection to a legacy peer
+	err := p1.pool.Connect(legacy.addr)
+	require.NoError(t, err)
+
+	select {
+	case err := <-p1.failures:
+		// The legacy peer either sent its introduction message or disconnected first
+		require.True(t, err == ErrEncryptionRequired || err == errHandshakeClosed, "%v", err)
+	case <-p1.connects:
+		t.Fatal("Connection to a legacy peer should fail")
+	case <-time.After(time.Second * 3):
+		t.Fatal("Timed out waiting for connection failure")
+	}
+
+	// Incoming connection from a legacy peer
+	err = legacy.pool.Connect(p1.addr)
+	require.NoError(t, err)
+	for waitConnect(t, legacy) != p1.addr {
+	}
+
+	err = legacy.pool.SendMessage(p1.addr, &secureTestMessage{Data: []byte("foo")})
+	require.NoError(t, err)
+
+	select {
+	case err := <-p1.failures:
+		require.Equal(t, ErrEncryptionRequired, err)
+	case <-p1.connects:
+		t.Fatal("Connection from a legacy peer should fail")
+	case <-time.After(time.Second * 3):
+		t.Fatal("Timed out waiting for connection failure")
+	}
+
+	n, err := p1.pool.Size()
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+}
+
+func TestNewConnectionPoolRequireEncryption(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.RequireEncryption = true
+	_, err := NewConnectionPool(cfg, nil)
+	require.Error(t, err)
+
+	cfg.EnableEncryption = true
+	_, err = NewConnectionPool(cfg, nil)
+	require.NoError(t, err)
+}
+
+func TestPoolEncryptionNoDowngrade(t *testing.T) {
+	setupSecureTestMessages()
+
+	cfg := newTestConfig()
+	cfg.Port = port + 28
+	cfg.EnableEncryption = true
+	cfg.HandshakeTimeout = time.Millisecond * 500
+	p1 := newTestPeerConfig(t, cfg)
+	defer p1.shutdown()
+
+	// A peer that supports the encrypted transport, whose first connection is closed by an attacker
+	l, err := net.Listen("tcp", fmt.Sprintf("%s:%d", address, port+29))
+	require.NoError(t, err)
+	defer l.Close()
+	addr := l.Addr().String()
+
+	go func() {
+		for i := 0; i < 2; i++ {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+
+			_, err = io.ReadFull(conn, make([]byte, helloSize))
+			if i == 0 {
+				require.NoError(t, err)
+				conn.Close()
+			}
+		}
+	}()
+
+	// The unencrypted connection made after the first one was closed does not receive a legacy message
+	err = p1.pool.Connect(addr)
+	require.NoError(t, err)
+	waitFailure(t, p1)
+	require.False(t, p1.pool.isLegacyPeer(addr))
+
+	// An address with an open encrypted connection is not downgraded
+	legacy := newTestPeer(t, port+30, false, false)
+	defer legacy.shutdown()
+	wait()
+
+	p1.pool.setEncryptedPeer(legacy.addr)
+	err = p1.pool.Connect(legacy.addr)
+	require.NoError(t, err)
+	require.Equal(t, ErrHandshakeFailed, waitFailure(t, p1))
+	require.False(t, p1.pool.isLegacyPeer(legacy.addr))
+}
+
+func TestLegacyPeerExpiry(t *testing.T) {
+	p, err := NewConnectionPool(newTestConfig(), nil)
+	require.NoError(t, err)
+
+	p.setLegacyPeer(addr)
+	require.True(t, p.isLegacyPeer(addr))
+
+	// The encrypted transport is tried again after legacyPeerExpiry
+	p.legacyPeers[addr] = time.Now().Add(-legacyPeerExpiry)
+	require.False(t, p.isLegacyPeer(addr))
+	require.Empty(t, p.legacyPeers)
+
+	// A successful encrypted handshake clears the legacy mark
+	p.setLegacyPeer(addr)
+	p.setEncryptedPeer(addr)
+	require.False(t, p.isLegacyPeer(addr))
+	require.True(t, p.isEncryptedPeer(addr))
+
+	// Expired marks are pruned
+	p.setLegacyPeer("127.0.0.1:1")
+	p.setLegacyPeer("127.0.0.1:2")
+	p.legacyPeers["127.0.0.1:1"] = time.Now().Add(-legacyPeerExpiry)
+	p.PruneLegacyPeers()
+	require.Len(t, p.legacyPeers, 1)
+	require.True(t, p.isLegacyPeer("127.0.0.1:2"))
+
+	// The oldest mark is dropped to add a new one when the limit is reached
+	for i := 0; len(p.legacyPeers) < maxLegacyPeers; i++ {
+		p.setLegacyPeer(fmt.Sprintf("127.0.0.2:%d", i))
+	}
+	p.legacyPeers["127.0.0.1:2"] = time.Now().Add(-time.Minute)
+	p.setLegacyPeer("127.0.0.3:1")
+	require.Len(t, p.legacyPeers, maxLegacyPeers)
+	require.False(t, p.isLegacyPeer("127.0.0.1:2"))
+	require.True(t, p.isLegacyPeer("127.0.0.3:1"))
+}
+
+func TestPoolEncryptionLimitsBeforeHandshake(t *testing.T) {
+	setupSecureTestMessages()
+
+	acceptErr := errors.New("rejected")
+	var accepted int32
+
+	cfg := newTestConfig()
+	cfg.Port = port + 31
+	cfg.EnableEncryption = true
+	cfg.AcceptCallback = func(addr string) error {
+		atomic.AddInt32(&accepted, 1)
+		return acceptErr
+	}
+	p := newTestPeerConfig(t, cfg)
+	defer p.shutdown()
+
+	cfg = newTestConfig()
+	cfg.Port = port + 32
+	cfg.EnableEncryption = true
+	cfg.MaxIncomingConnections = 0
+	full := newTestPeerConfig(t, cfg)
+	defer full.shutdown()
+	wait()
+
+	// A rejected connection is closed without waiting for the hello of the handshake
+	for _, tp := range []*testPeer{p, full} {
+		conn, err := net.Dial("tcp", tp.addr)
+		require.NoError(t, err)
+
+		err = conn.SetReadDeadline(time.Now().Add(time.Second))
+		require.NoError(t, err)
+		_, err = conn.Read(make([]byte, 1))
+		require.Equal(t, io.EOF, err)
+		conn.Close()
+	}
+
+	require.Equal(t, ErrMaxIncomingConnectionsReached, waitFailure(t, full))
+	require.Equal(t, acceptErr, waitFailure(t, p))
+	require.Equal(t, int32(1), atomic.LoadInt32(&accepted))
+}
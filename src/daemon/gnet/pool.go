@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"net"
 	"reflect"
+	"strings"
 	"sync"
 	"time"
 
@@ -21,6 +22,7 @@ import (
 	"github.com/skycoin/skycoin/src/daemon/strand"
 	"github.com/skycoin/skycoin/src/util/elapse"
 	"github.com/skycoin/skycoin/src/util/logging"
+	"github.com/skycoin/skycoin/src/util/socks5"
 )
 
 // DisconnectReason is passed to ConnectionPool's DisconnectCallback
@@ -113,6 +115,14 @@ type Config struct {
 	// Timeout is the timeout for dialing new connections.  Use a
 	// timeout of 0 to ignore timeout.
 	DialTimeout time.Duration
+	// Address of a SOCKS5 proxy to make outgoing connections through, e.g. Tor.
+	// Leave empty to connect directly
+	Proxy string
+	// Username and password for the SOCKS5 proxy. Leave empty if the proxy does not require authentication
+	ProxyUsername string
+	ProxyPassword string
+	// Only connections to onion addresses are made through the proxy, other connections are made directly
+	ProxyOnlyOnion bool
 	// Timeout for reading from a connection. Set to 0 to default to the
 	// system's timeout
 	ReadTimeout time.Duration
@@ -130,8 +140,27 @@ type Config struct {
 	ConnectCallback ConnectCallback
 	// Triggered on client connect failure
 	ConnectFailureCallback ConnectFailureCallback
+	// Triggered on an incoming connection before the transport handshake
+	AcceptCallback AcceptCallback
 	// Print debug logs
 	DebugPrint bool
+	// Encrypt the connections with peers that support the encrypted transport
+	EnableEncryption bool
+	// Reject the connections with peers that do not support the encrypted transport. Requires EnableEncryption
+	RequireEncryption bool
+	// Timeout for the encrypted transport handshake. Set to 0 to ignore timeout
+	HandshakeTimeout time.Duration
+	// Number of records sent with an encryption key before the key is rotated. Set to 0 to never rotate keys
+	RekeyInterval uint64
+	// Maximum upload rate of all connections, in bytes per second. Set to 0 for no limit
+	MaxUploadRate int64
+	// Maximum upload rate of each connection, in bytes per second. Set to 0 for no limit
+	MaxPeerUploadRate int64
+	// Maximum bytes uploaded per day. Once reached, only the message types in UploadCapAllowedMessages
+	// are sent until the day ends. Set to 0 for no limit
+	MaxDailyUpload uint64
+	// Prefixes of the message types that are still sent once MaxDailyUpload is reached
+	UploadCapAllowedMessages []string
 	// Default "trusted" peers
 	DefaultConnections []string
 	// Default connections map
@@ -157,6 +186,10 @@ func NewConfig() Config {
 		DisconnectCallback:                nil,
 		ConnectCallback:                   nil,
 		DebugPrint:                        false,
+		EnableEncryption:                  false,
+		RequireEncryption:                 false,
+		HandshakeTimeout:                  time.Second * 10,
+		RekeyInterval:                     4096,
 		defaultConnections:                make(map[string]struct{}),
 	}
 }
@@ -183,10 +216,21 @@ type Connection struct {
 	// Message send queue.
 	WriteQueue chan Message
 	Solicited  bool
+	// Whether the connection uses the encrypted transport
+	Encrypted bool
+	// Bytes and messages sent and received
+	Traffic ConnectionTraffic
+	// Limits the upload rate of the connection
+	uploadLimiter *rateLimiter
 }
 
 // NewConnection creates a new Connection tied to a ConnectionPool
 func NewConnection(pool *ConnectionPool, id uint64, conn net.Conn, writeQueueSize int, solicited bool) *Connection {
+	var uploadLimiter *rateLimiter
+	if pool != nil {
+		uploadLimiter = newRateLimiter(pool.Config.MaxPeerUploadRate)
+	}
+
 	return &Connection{
 		ID:             id,
 		Conn:           conn,
@@ -196,9 +240,18 @@ func NewConnection(pool *ConnectionPool, id uint64, conn net.Conn, writeQueueSiz
 		LastSent:       Now(),
 		WriteQueue:     make(chan Message, writeQueueSize),
 		Solicited:      solicited,
+		Encrypted:      isEncrypted(conn),
+		Traffic:        newConnectionTraffic(),
+		uploadLimiter:  uploadLimiter,
 	}
 }
 
+// isEncrypted returns true if the connection uses the encrypted transport
+func isEncrypted(conn net.Conn) bool {
+	_, ok := conn.(*secureConn)
+	return ok
+}
+
 // Addr returns remote address
 func (conn *Connection) Addr() string {
 	return conn.Conn.RemoteAddr().String()
@@ -226,6 +279,10 @@ type ConnectCallback func(addr string, id uint64, solicited bool)
 // ConnectFailureCallback trigger on client connect failure
 type ConnectFailureCallback func(addr string, solicited bool, err error)
 
+// AcceptCallback triggered on an incoming connection before the transport handshake.
+// The connection is closed if it returns an error
+type AcceptCallback func(addr string) error
+
 // ConnectionPool connection pool
 type ConnectionPool struct {
 	// Configuration parameters
@@ -249,6 +306,16 @@ type ConnectionPool struct {
 	// Listening connection
 	listener     net.Listener
 	listenerLock sync.Mutex
+	// Peers that did not support the encrypted transport, and when they were detected.
+	// Limited to maxLegacyPeers entries
+	legacyPeers map[string]time.Time
+	// Outgoing connections that completed the encrypted transport handshake, removed on disconnect
+	encryptedPeers     map[string]struct{}
+	transportPeersLock sync.Mutex
+	// Limits the upload rate of all connections
+	uploadLimiter *rateLimiter
+	// Limits the bytes uploaded per day
+	uploadCap *uploadCap
 	// operations channel
 	reqC chan strand.Request
 	// quit channel
@@ -268,6 +335,12 @@ func NewConnectionPool(c Config, state interface{}) (*ConnectionPool, error) {
 	if c.MaxConnections < c.MaxOutgoingConnections+c.MaxIncomingConnections {
 		return nil, errors.New("MaxConnections must be >= MaxOutgoingConnections + MaxIncomingConnections")
 	}
+	if c.RequireEncryption && !c.EnableEncryption {
+		return nil, errors.New("RequireEncryption requires EnableEncryption")
+	}
+	if c.MaxUploadRate < 0 || c.MaxPeerUploadRate < 0 {
+		return nil, errors.New("MaxUploadRate and MaxPeerUploadRate must be >= 0")
+	}
 
 	return &ConnectionPool{
 		Config:                     c,
@@ -276,6 +349,10 @@ func NewConnectionPool(c Config, state interface{}) (*ConnectionPool, error) {
 		defaultOutgoingConnections: make(map[string]struct{}),
 		outgoingConnections:        make(map[string]struct{}),
 		incomingConnections:        make(map[string]struct{}),
+		legacyPeers:                make(map[string]time.Time),
+		encryptedPeers:             make(map[string]struct{}),
+		uploadLimiter:              newRateLimiter(c.MaxUploadRate),
+		uploadCap:                  newUploadCap(c.MaxDailyUpload, c.UploadCapAllowedMessages),
 		SendResults:                make(chan SendResult, c.SendResultsSize),
 		messageState:               state,
 		quit:                       make(chan struct{}),
@@ -494,6 +571,26 @@ func (pool *ConnectionPool) handleConnection(conn net.Conn, solicited bool) erro
 			}
 		}()
 
+		// Check the connection limits before the transport handshake, so that a connection
+		// that is rejected doesn't cost a key exchange. They are checked again by newConnection
+		if err := pool.strand("canConnect", func() error {
+			return pool.canConnect(addr, solicited)
+		}); err != nil {
+			return nil, err
+		}
+
+		if !solicited && pool.Config.AcceptCallback != nil {
+			if err := pool.Config.AcceptCallback(addr); err != nil {
+				return nil, err
+			}
+		}
+
+		tc, err := pool.setupTransport(conn, solicited)
+		if err != nil {
+			return nil, err
+		}
+		conn = tc
+
 		err = pool.strand("handleConnection", func() error {
 			var err error
 			c, err = pool.newConnection(conn, solicited)
@@ -501,6 +598,10 @@ func (pool *ConnectionPool) handleConnection(conn net.Conn, solicited bool) erro
 				return err
 			}
 
+			if _, ok := conn.(*secureConn); ok && solicited {
+				pool.setEncryptedPeer(addr)
+			}
+
 			if pool.Config.ConnectCallback != nil {
 				pool.Config.ConnectCallback(c.Addr(), c.ID, solicited)
 			}
@@ -612,6 +713,11 @@ func (pool *ConnectionPool) readLoop(conn *Connection, msgChan chan []byte, qc c
 	defer elapser.CheckForDone()
 	defer sendInMsgChanElapser.CheckForDone()
 
+	// An encrypting peer starts an incoming connection with handshakeMagic, which would
+	// otherwise decode to an invalid message length. Check the first bytes of an unencrypted
+	// incoming connection so that this peer is not treated as sending an oversize message.
+	checkHello := !conn.Encrypted && !conn.Solicited
+
 	for {
 		elapser.Register(fmt.Sprintf("readLoop addr=%s", conn.Addr()))
 		deadline := time.Time{}
@@ -634,6 +740,12 @@ func (pool *ConnectionPool) readLoop(conn *Connection, msgChan chan []byte, qc c
 		if _, err := conn.Buffer.Write(data); err != nil {
 			return err
 		}
+		if checkHello && conn.Buffer.Len() >= len(handshakeMagic) {
+			if bytes.HasPrefix(conn.Buffer.Bytes(), handshakeMagic[:]) {
+				return ErrDisconnectEncryptionNotEnabled
+			}
+			checkHello = false
+		}
 		// decode data
 		datas, err := decodeData(conn.Buffer, pool.Config.MaxIncomingMessageLength)
 		if err != nil {
@@ -673,13 +785,39 @@ func (pool *ConnectionPool) sendLoop(conn *Connection, timeout time.Duration, ma
 				continue
 			}
 
-			err := sendMessage(conn.Conn, m, timeout, maxMsgLength)
+			msgType := messageType(m)
+			size := int(m.EncodeSize()) + messageLengthPrefixSize + messagePrefixLength
+
+			var err error
+			if !pool.uploadCap.allow(msgType, Now()) {
+				// The message is dropped but the connection is kept, so that it can still
+				// receive the messages allowed after the upload cap is reached
+				err = ErrUploadCapReached
+			} else {
+				now := time.Now()
+				delay := pool.uploadLimiter.reserve(size, now)
+				if d := conn.uploadLimiter.reserve(size, now); d > delay {
+					delay = d
+				}
+				if delay > 0 {
+					select {
+					case <-pool.quit:
+						return nil
+					case <-qc:
+						return nil
+					case <-time.After(delay):
+					}
+				}
+
+				err = sendMessage(conn.Conn, m, timeout, maxMsgLength)
+			}
 
 			// Update last sent before writing to SendResult,
 			// this allows a write to SendResult to be used as a sync marker,
 			// since no further action in this block will happen after the write.
 			if err == nil {
-				if err := pool.updateLastSent(conn.Addr(), Now()); err != nil {
+				pool.uploadCap.add(size, Now())
+				if err := pool.updateLastSent(conn.Addr(), msgType, size, Now()); err != nil {
 					logger.WithField("addr", conn.Addr()).WithError(err).Warning("updateLastSent failed")
 				}
 			}
@@ -693,7 +831,7 @@ func (pool *ConnectionPool) sendLoop(conn *Connection, timeout time.Duration, ma
 				logger.WithField("addr", conn.Addr()).Warning("SendResults queue full")
 			}
 
-			if err != nil {
+			if err != nil && err != ErrUploadCapReached {
 				return err
 			}
 		}
@@ -783,19 +921,21 @@ func (pool *ConnectionPool) IsMaxOutgoingDefaultConnectionsReached() bool {
 	return len(pool.defaultOutgoingConnections) >= pool.Config.MaxDefaultPeerOutgoingConnections
 }
 
-func (pool *ConnectionPool) updateLastSent(addr string, t time.Time) error {
+func (pool *ConnectionPool) updateLastSent(addr, msgType string, size int, t time.Time) error {
 	return pool.strand("updateLastSent", func() error {
 		if conn, ok := pool.addresses[addr]; ok {
 			conn.LastSent = t
+			conn.Traffic.addSent(msgType, size)
 		}
 		return nil
 	})
 }
 
-func (pool *ConnectionPool) updateLastRecv(addr string, t time.Time) error {
+func (pool *ConnectionPool) updateLastRecv(addr, msgType string, size int, t time.Time) error {
 	return pool.strand("updateLastRecv", func() error {
 		if conn, ok := pool.addresses[addr]; ok {
 			conn.LastReceived = t
+			conn.Traffic.addReceived(msgType, size)
 		}
 		return nil
 	})
@@ -808,6 +948,7 @@ func (pool *ConnectionPool) GetConnection(addr string) (*Connection, error) {
 		if c, ok := pool.addresses[addr]; ok {
 			// copy connection
 			cc := *c
+			cc.Traffic = c.Traffic.clone()
 			conn = &cc
 		}
 		return nil
@@ -818,6 +959,62 @@ func (pool *ConnectionPool) GetConnection(addr string) (*Connection, error) {
 	return conn, nil
 }
 
+// dial makes a TCP connection to an address, through the proxy if one is configured.
+// The RemoteAddr of the connection is the dialed address, so that connections to hostnames
+// such as onion addresses are identified by the hostname rather than the resolved IP.
+func (pool *ConnectionPool) dial(address string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	if pool.Config.Proxy != "" && (!pool.Config.ProxyOnlyOnion || strings.HasSuffix(strings.ToLower(host), ".onion")) {
+		d := socks5.Dialer{
+			Proxy:    pool.Config.Proxy,
+			Username: pool.Config.ProxyUsername,
+			Password: pool.Config.ProxyPassword,
+			Timeout:  pool.Config.DialTimeout,
+		}
+		return d.Dial(address)
+	}
+
+	conn, err := net.DialTimeout("tcp", address, pool.Config.DialTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	if net.ParseIP(host) == nil {
+		return &hostnameConn{
+			Conn: conn,
+			addr: address,
+		}, nil
+	}
+
+	return conn, nil
+}
+
+// hostnameConn is a connection dialed by hostname, whose RemoteAddr is the hostname address
+type hostnameConn struct {
+	net.Conn
+	addr string
+}
+
+// RemoteAddr returns the dialed address
+func (c *hostnameConn) RemoteAddr() net.Addr {
+	return hostnameAddr(c.addr)
+}
+
+// hostnameAddr is a host:port net.Addr
+type hostnameAddr string
+
+func (a hostnameAddr) Network() string {
+	return "tcp"
+}
+
+func (a hostnameAddr) String() string {
+	return string(a)
+}
+
 // Connect to an address
 func (pool *ConnectionPool) Connect(address string) error {
 	if err := pool.strand("canConnect", func() error {
@@ -827,7 +1024,7 @@ func (pool *ConnectionPool) Connect(address string) error {
 	}
 
 	logger.WithField("addr", address).Debugf("Making TCP connection")
-	conn, err := net.DialTimeout("tcp", address, pool.Config.DialTimeout)
+	conn, err := pool.dial(address)
 	if err != nil {
 		return err
 	}
@@ -896,6 +1093,7 @@ func (pool *ConnectionPool) disconnect(addr string, r DisconnectReason) *Connect
 	delete(pool.defaultOutgoingConnections, addr)
 	delete(pool.outgoingConnections, addr)
 	delete(pool.incomingConnections, addr)
+	pool.removeEncryptedPeer(addr)
 	if err := conn.Close(); err != nil {
 		logger.WithError(err).WithFields(fields).Error("conn.Close")
 	}
@@ -1022,7 +1220,7 @@ func (pool *ConnectionPool) receiveMessage(c *Connection, msg []byte) error {
 	if err != nil {
 		return err
 	}
-	if err := pool.updateLastRecv(c.Addr(), Now()); err != nil {
+	if err := pool.updateLastRecv(c.Addr(), messageType(m), len(msg)+messageLengthPrefixSize, Now()); err != nil {
 		return err
 	}
 	return m.Handle(NewMessageContext(c), pool.messageState)
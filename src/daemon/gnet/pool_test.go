@@ -13,6 +13,8 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/skycoin/skycoin/src/util/logging"
+	"github.com/skycoin/skycoin/src/util/socks5"
+	"github.com/skycoin/skycoin/src/util/socks5/socks5test"
 )
 
 const (
@@ -375,6 +377,62 @@ func TestConnectNoTimeout(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestConnectProxy(t *testing.T) {
+	setupSecureTestMessages()
+
+	proxy, err := socks5test.NewServer()
+	require.NoError(t, err)
+	defer proxy.Close()
+
+	legacy := newTestPeer(t, port+26, false, false)
+	defer legacy.shutdown()
+
+	onion := "pg6mmjiyjmcrsslvykfwnntlaru7p5svn6y2ymmju6nubxndf4pscryd.onion"
+	onionAddr := onion + ":6000"
+	proxy.Resolve[onion] = legacy.addr
+
+	cfg := newTestConfig()
+	cfg.Port = port + 27
+	cfg.EnableEncryption = true
+	cfg.Proxy = proxy.Addr()
+	p1 := newTestPeerConfig(t, cfg)
+	defer p1.shutdown()
+	wait()
+
+	// The connection is made through the proxy and is identified by the onion address.
+	// The legacy peer is redialed through the proxy too
+	err = p1.pool.Connect(onionAddr)
+	require.NoError(t, err)
+	require.Equal(t, onionAddr, waitConnect(t, p1))
+	require.Equal(t, []string{onionAddr, onionAddr}, proxy.Destinations())
+
+	c, err := p1.pool.GetConnection(onionAddr)
+	require.NoError(t, err)
+	require.NotNil(t, c)
+	require.True(t, p1.pool.isLegacyPeer(onionAddr))
+
+	err = p1.pool.SendMessage(onionAddr, &secureTestMessage{Data: []byte("foo")})
+	require.NoError(t, err)
+	require.Equal(t, []byte("foo"), waitReceived(t, legacy))
+
+	// The proxy fails to connect to an unknown onion address
+	err = p1.pool.Connect("unknown.onion:6000")
+	require.Equal(t, socks5.ReplyError{Code: 4}, err)
+	require.Len(t, proxy.Destinations(), 3)
+
+	// With ProxyOnlyOnion, other addresses are dialed directly
+	p1.pool.Config.ProxyOnlyOnion = true
+	conn, err := p1.pool.dial(legacy.addr)
+	require.NoError(t, err)
+	require.Equal(t, legacy.addr, conn.RemoteAddr().String())
+	require.NoError(t, conn.Close())
+	require.Len(t, proxy.Destinations(), 3)
+
+	_, err = p1.pool.dial("unknown.onion:6000")
+	require.Equal(t, socks5.ReplyError{Code: 4}, err)
+	require.Len(t, proxy.Destinations(), 4)
+}
+
 func TestDisconnect(t *testing.T) {
 	cfg := newTestConfig()
 	p, err := NewConnectionPool(cfg, nil)
@@ -15,8 +15,10 @@ import (
 	"github.com/skycoin/skycoin/src/daemon/gnet"
 	"github.com/skycoin/skycoin/src/daemon/pex"
 	"github.com/skycoin/skycoin/src/params"
+	"github.com/skycoin/skycoin/src/transaction"
 	"github.com/skycoin/skycoin/src/util/iputil"
 	"github.com/skycoin/skycoin/src/util/useragent"
+	"github.com/skycoin/skycoin/src/visor/blockdb"
 )
 
 // Message represent a packet to be serialized over the network by
@@ -46,14 +48,23 @@ func NewMessageConfig(prefix string, m interface{}) MessageConfig {
 
 //go:generate skyencoder -unexported -struct IntroductionMessage
 //go:generate skyencoder -unexported -struct GivePeersMessage
+//go:generate skyencoder -unexported -struct GiveAddrsMessage
 //go:generate skyencoder -unexported -struct GetBlocksMessage
 //go:generate skyencoder -unexported -struct GiveBlocksMessage
 //go:generate skyencoder -unexported -struct AnnounceBlocksMessage
+//go:generate skyencoder -unexported -struct PrunedBlocksMessage
+//go:generate skyencoder -unexported -struct GetHeadersMessage
+//go:generate skyencoder -unexported -struct GiveHeadersMessage
+//go:generate skyencoder -unexported -struct CompactBlockMessage
+//go:generate skyencoder -unexported -struct GetBlockTxnsMessage
+//go:generate skyencoder -unexported -struct GiveBlockTxnsMessage
 //go:generate skyencoder -unexported -struct GetTxnsMessage
 //go:generate skyencoder -unexported -struct GiveTxnsMessage
 //go:generate skyencoder -unexported -struct AnnounceTxnsMessage
+//go:generate skyencoder -unexported -struct StemTxnMessage
 //go:generate skyencoder -unexported -struct DisconnectMessage
 //go:generate skyencoder -unexported -struct IPAddr
+//go:generate skyencoder -unexported -struct NetAddr
 //go:generate skyencoder -unexported -output-path . -package daemon -struct SignedBlock github.com/skycoin/skycoin/src/coin
 //go:generate skyencoder -unexported -output-path . -package daemon -struct Transaction github.com/skycoin/skycoin/src/coin
 
@@ -63,18 +74,47 @@ func getMessageConfigs() []MessageConfig {
 		NewMessageConfig("INTR", IntroductionMessage{}),
 		NewMessageConfig("GETP", GetPeersMessage{}),
 		NewMessageConfig("GIVP", GivePeersMessage{}),
+		NewMessageConfig("GIVA", GiveAddrsMessage{}),
 		NewMessageConfig("PING", PingMessage{}),
 		NewMessageConfig("PONG", PongMessage{}),
 		NewMessageConfig("GETB", GetBlocksMessage{}),
 		NewMessageConfig("GIVB", GiveBlocksMessage{}),
 		NewMessageConfig("ANNB", AnnounceBlocksMessage{}),
+		NewMessageConfig("PRUN", PrunedBlocksMessage{}),
+		NewMessageConfig("GETH", GetHeadersMessage{}),
+		NewMessageConfig("GIVH", GiveHeadersMessage{}),
+		NewMessageConfig("CMPB", CompactBlockMessage{}),
+		NewMessageConfig("GETX", GetBlockTxnsMessage{}),
+		NewMessageConfig("GIVX", GiveBlockTxnsMessage{}),
 		NewMessageConfig("GETT", GetTxnsMessage{}),
 		NewMessageConfig("GIVT", GiveTxnsMessage{}),
 		NewMessageConfig("ANNT", AnnounceTxnsMessage{}),
+		NewMessageConfig("STEM", StemTxnMessage{}),
 		NewMessageConfig("DISC", DisconnectMessage{}),
 	}
 }
 
+// uploadCapAllowedMessages are the messages still sent once the daily upload cap is reached.
+// Blocks and transactions are no longer served, but the node keeps serving headers and
+// announcing blocks and transactions, and can still request data from its peers.
+var uploadCapAllowedMessages = []string{
+	"INTR",
+	"GETP",
+	"GIVP",
+	"GIVA",
+	"PING",
+	"PONG",
+	"GETB",
+	"ANNB",
+	"PRUN",
+	"GETH",
+	"GIVH",
+	"GETX",
+	"GETT",
+	"ANNT",
+	"DISC",
+}
+
 // MessagesConfig slice of MessageConfig
 type MessagesConfig struct {
 	// Message ID prefices
@@ -320,7 +360,225 @@ func (gpm *GivePeersMessage) process(d daemoner) {
 		"count":  len(peers),
 	}).Debug("Received peers via PEX")
 
-	d.addPeers(peers)
+	// GivePeersMessage has no timestamps, the peers are considered seen by the sender now
+	addrs := make([]pex.GossipAddr, len(peers))
+	for i, p := range peers {
+		addrs[i] = pex.GossipAddr{
+			Addr: p,
+		}
+	}
+
+	d.addGossipedPeers(gpm.c.Addr, addrs)
+}
+
+var (
+	// ErrNetAddrUnsupported the address can't be encoded in a NetAddr, or the NetAddr network is unknown
+	ErrNetAddrUnsupported = errors.New("Unsupported NetAddr network")
+	// ErrNetAddrInvalid the NetAddr address bytes are invalid for its network
+	ErrNetAddrInvalid = errors.New("Invalid NetAddr address")
+)
+
+// Networks of the addresses of NetAddr
+const (
+	// NetAddrIPv4 is an IPv4 address, 4 bytes
+	NetAddrIPv4 uint8 = 1
+	// NetAddrTorV3 is a version 3 onion service name, 35 bytes: the base32 decoded name without the ".onion" suffix
+	NetAddrTorV3 uint8 = 4
+)
+
+// NetAddr is a peer address with the unix timestamp when the sender last saw the peer.
+// Like the addrv2 encoding of bitcoin (BIP155), the address is made of a network ID and the address bytes
+// of that network, so that peers on networks other than IPv4 can be exchanged.
+// Addresses of unknown networks are skipped by the receiver.
+type NetAddr struct {
+	Network  uint8
+	Addr     []byte
+	Port     uint16
+	LastSeen int64
+}
+
+// NewNetAddr creates a NetAddr from an ip:port or onion:port address
+func NewNetAddr(addr string, lastSeen int64) (NetAddr, error) {
+	host, port, err := iputil.SplitAddr(addr)
+	if err != nil {
+		return NetAddr{}, err
+	}
+
+	if pex.IsOnion(host) {
+		b, err := pex.DecodeOnion(host)
+		if err != nil {
+			return NetAddr{}, err
+		}
+		return NetAddr{
+			Network:  NetAddrTorV3,
+			Addr:     b,
+			Port:     port,
+			LastSeen: lastSeen,
+		}, nil
+	}
+
+	ip := net.ParseIP(host).To4()
+	if ip == nil {
+		return NetAddr{}, ErrNetAddrUnsupported
+	}
+
+	return NetAddr{
+		Network:  NetAddrIPv4,
+		Addr:     []byte(ip),
+		Port:     port,
+		LastSeen: lastSeen,
+	}, nil
+}
+
+// String returns the NetAddr as "ip:port" or "onion:port"
+func (na NetAddr) String() (string, error) {
+	var host string
+	switch na.Network {
+	case NetAddrIPv4:
+		if len(na.Addr) != net.IPv4len {
+			return "", ErrNetAddrInvalid
+		}
+		host = net.IP(na.Addr).String()
+	case NetAddrTorV3:
+		var err error
+		host, err = pex.EncodeOnion(na.Addr)
+		if err != nil {
+			return "", ErrNetAddrInvalid
+		}
+	default:
+		return "", ErrNetAddrUnsupported
+	}
+
+	return fmt.Sprintf("%s:%d", host, na.Port), nil
+}
+
+// GiveAddrsMessage is sent in response to GetPeersMessage instead of GivePeersMessage,
+// to peers that set ServicePeerTimestamps in their capabilities.
+// Each peer includes the time when the sender last saw it, so that the receiver can tell fresh peers from stale ones
+type GiveAddrsMessage struct {
+	Addrs []NetAddr            `enc:",maxlen=512"`
+	c     *gnet.MessageContext `enc:"-"`
+}
+
+// NewGiveAddrsMessage []pex.Peer is converted to []NetAddr for binary transmission
+// If the size of the message would exceed maxMsgLength, the NetAddr slice is truncated.
+// Peers with an address that can't be encoded, such as a DNS hostname, are skipped
+func NewGiveAddrsMessage(peers []pex.Peer, maxMsgLength uint64) *GiveAddrsMessage {
+	if len(peers) > 512 {
+		peers = peers[:512]
+	}
+
+	addrs := make([]NetAddr, 0, len(peers))
+	for _, ps := range peers {
+		na, err := NewNetAddr(ps.Addr, ps.LastSeen)
+		if err != nil {
+			logger.WithError(err).WithField("addr", ps.Addr).Debug("GiveAddrsMessage skipping address")
+			continue
+		}
+		addrs = append(addrs, na)
+	}
+
+	m := &GiveAddrsMessage{
+		Addrs: addrs,
+	}
+	truncateGiveAddrsMessage(m, maxMsgLength)
+	return m
+}
+
+// truncateGiveAddrsMessage truncates the addresses in GiveAddrsMessage to fit inside of MaxOutgoingMessageLength
+func truncateGiveAddrsMessage(m *GiveAddrsMessage, maxMsgLength uint64) {
+	// The message length will include a 4 byte message type prefix.
+	// Panic if the prefix can't fit, otherwise we can't adjust the uint64 safely
+	if maxMsgLength < 4 {
+		logger.Panic("maxMsgLength must be >= 4")
+	}
+
+	maxMsgLength -= 4
+
+	// Measure the current message size, if it fits, return
+	n := m.EncodeSize()
+	if n <= maxMsgLength {
+		return
+	}
+
+	// Measure the size of an empty message
+	var mm GiveAddrsMessage
+	size := mm.EncodeSize()
+
+	// Measure the size of the addresses, advancing the slice index until it reaches capacity
+	index := -1
+	for i, a := range m.Addrs {
+		x := encodeSizeNetAddr(&a)
+		if size+x > maxMsgLength {
+			break
+		}
+		size += x
+		index = i
+	}
+
+	m.Addrs = m.Addrs[:index+1]
+
+	if len(m.Addrs) == 0 {
+		logger.Critical().Error("truncateGiveAddrsMessage truncated addresses to an empty slice")
+	}
+}
+
+// EncodeSize implements gnet.Serializer
+func (gam *GiveAddrsMessage) EncodeSize() uint64 {
+	return encodeSizeGiveAddrsMessage(gam)
+}
+
+// Encode implements gnet.Serializer
+func (gam *GiveAddrsMessage) Encode(buf []byte) error {
+	return encodeGiveAddrsMessageToBuffer(buf, gam)
+}
+
+// Decode implements gnet.Serializer
+func (gam *GiveAddrsMessage) Decode(buf []byte) (uint64, error) {
+	return decodeGiveAddrsMessage(buf, gam)
+}
+
+// GossipAddrs returns the addresses contained in the message, with their timestamps.
+// Addresses of unknown networks and invalid addresses are skipped
+func (gam *GiveAddrsMessage) GossipAddrs() []pex.GossipAddr {
+	addrs := make([]pex.GossipAddr, 0, len(gam.Addrs))
+	for _, a := range gam.Addrs {
+		addr, err := a.String()
+		if err != nil {
+			continue
+		}
+		addrs = append(addrs, pex.GossipAddr{
+			Addr:      addr,
+			Timestamp: a.LastSeen,
+		})
+	}
+	return addrs
+}
+
+// Handle handle message
+func (gam *GiveAddrsMessage) Handle(mc *gnet.MessageContext, daemon interface{}) error {
+	gam.c = mc
+	return daemon.(daemoner).recordMessageEvent(gam, mc)
+}
+
+// process Notifies the Pex instance that timestamped peers were received
+func (gam *GiveAddrsMessage) process(d daemoner) {
+	if d.pexConfig().Disabled {
+		return
+	}
+
+	addrs := gam.GossipAddrs()
+	if len(addrs) == 0 {
+		return
+	}
+
+	logger.WithFields(logrus.Fields{
+		"addr":   gam.c.Addr,
+		"gnetID": gam.c.ConnID,
+		"count":  len(addrs),
+	}).Debug("Received timestamped peers via PEX")
+
+	d.addGossipedPeers(gam.c.Addr, addrs)
 }
 
 // IntroductionMessage is sent on first connect by both parties
@@ -329,6 +587,7 @@ type IntroductionMessage struct {
 	UserAgent            useragent.Data       `enc:"-"`
 	UnconfirmedVerifyTxn params.VerifyTxn     `enc:"-"`
 	GenesisHash          cipher.SHA256        `enc:"-"`
+	Capabilities         Capabilities         `enc:"-"`
 
 	// Mirror is a random value generated on client startup that is used to identify self-connections
 	Mirror uint32
@@ -348,20 +607,21 @@ type IntroductionMessage struct {
 	// MaxDropletPrecision uint8 // maximum number of decimal places for announced txns
 	// UserAgent           string `enc:",maxlen=256"`
 	// GenesisHash         cipher.SHA256 // genesis block hash
+	// Capabilities        []byte // "CAPS" followed by TLV records of optional protocol features, see Capabilities. Omitted if empty
 	Extra []byte `enc:",omitempty"`
 }
 
 // NewIntroductionMessage creates introduction message
-func NewIntroductionMessage(mirror uint32, version int32, port uint16, pubkey cipher.PubKey, userAgent string, verifyParams params.VerifyTxn, genesisHash cipher.SHA256) *IntroductionMessage {
+func NewIntroductionMessage(mirror uint32, version int32, port uint16, pubkey cipher.PubKey, userAgent string, verifyParams params.VerifyTxn, genesisHash cipher.SHA256, capabilities Capabilities) *IntroductionMessage {
 	return &IntroductionMessage{
 		Mirror:          mirror,
 		ProtocolVersion: version,
 		ListenPort:      port,
-		Extra:           newIntroductionMessageExtra(pubkey, userAgent, verifyParams, genesisHash),
+		Extra:           newIntroductionMessageExtra(pubkey, userAgent, verifyParams, genesisHash, capabilities),
 	}
 }
 
-func newIntroductionMessageExtra(pubkey cipher.PubKey, userAgent string, verifyParams params.VerifyTxn, genesisHash cipher.SHA256) []byte {
+func newIntroductionMessageExtra(pubkey cipher.PubKey, userAgent string, verifyParams params.VerifyTxn, genesisHash cipher.SHA256, capabilities Capabilities) []byte {
 	if len(userAgent) > useragent.MaxLen {
 		logger.WithFields(logrus.Fields{
 			"userAgent": userAgent,
@@ -390,6 +650,10 @@ func newIntroductionMessageExtra(pubkey cipher.PubKey, userAgent string, verifyP
 	i += len(userAgentSerialized)
 	copy(extra[i:i+len(genesisHash)], genesisHash[:])
 
+	if !capabilities.empty() {
+		extra = append(extra, encodeCapabilities(capabilities)...)
+	}
+
 	return extra
 }
 
@@ -579,6 +843,18 @@ func (intro *IntroductionMessage) Verify(dc DaemonConfig, logFields logrus.Field
 		return ErrDisconnectInvalidExtraData
 	}
 	copy(intro.GenesisHash[:], intro.Extra[i:])
+	i += len(intro.GenesisHash)
+
+	// v28 adds the capabilities section, which is omitted if no capabilities are set.
+	// Other additional data is ignored
+	if extraLen > i && hasCapabilities(intro.Extra[i:]) {
+		capabilities, err := decodeCapabilities(intro.Extra[i:])
+		if err != nil {
+			logger.WithError(err).WithFields(logFields).Warning("Extra data capabilities could not be deserialized")
+			return ErrDisconnectInvalidExtraData
+		}
+		intro.Capabilities = capabilities
+	}
 
 	return nil
 }
@@ -776,7 +1052,15 @@ func (gbm *GetBlocksMessage) process(d daemoner) {
 	// Fetch and return signed blocks since LastBlock
 	blocks, err := d.getSignedBlocksSince(gbm.LastBlock, requestedBlocks)
 	if err != nil {
-		logger.WithFields(fields).WithError(err).Error("getSignedBlocksSince failed")
+		switch err.(type) {
+		case blockdb.ErrBlockPruned:
+			// The peer asked for blocks that we pruned since we introduced ourselves,
+			// tell it the lowest block seq that we can send now
+			logger.WithFields(fields).WithError(err).Debug("GetBlocksMessage requested pruned blocks")
+			d.sendPrunedBlocks(gbm.c.Addr)
+		default:
+			logger.WithFields(fields).WithError(err).Error("getSignedBlocksSince failed")
+		}
 		return
 	}
 
@@ -881,6 +1165,19 @@ func (m *GiveBlocksMessage) process(d daemoner) {
 		return
 	}
 
+	d.recordBlocksResponse(m.c.Addr)
+
+	// Pruned blocks requested by the backfill of a node bootstrapped from a snapshot
+	if d.receiveBackfillBlocks(m.c.Addr, m.Blocks) {
+		return
+	}
+
+	// Blocks requested by the headers-first sync are verified against the synced headers
+	// and executed in order by the sync
+	if d.receiveSyncBlocks(m.c.Addr, m.Blocks) {
+		return
+	}
+
 	// These DB queries are not performed in a transaction for performance reasons.
 	// It is not necessary that the blocks be executed together in a single transaction.
 
@@ -895,6 +1192,17 @@ func (m *GiveBlocksMessage) process(d daemoner) {
 		return
 	}
 
+	// Verify the signatures of the new blocks together, before executing them one by one
+	var blocks []coin.SignedBlock
+	for _, b := range m.Blocks {
+		if b.Seq() > maxSeq {
+			blocks = append(blocks, b)
+		}
+	}
+	if len(blocks) > 0 {
+		d.verifyBlockSignatures(blocks)
+	}
+
 	for _, b := range m.Blocks {
 		// To minimize waste when receiving multiple responses from peers
 		// we only break out of the loop if the block itself is invalid.
@@ -910,8 +1218,10 @@ func (m *GiveBlocksMessage) process(d daemoner) {
 		if err == nil {
 			logger.Critical().WithField("seq", b.Block.Head.BkSeq).Info("Added new block")
 			processed++
+			d.adjustPeerScore(m.c.Addr, behaviorUsefulBlock)
 		} else {
 			logger.Critical().WithError(err).WithField("seq", b.Block.Head.BkSeq).Error("Failed to execute received block")
+			d.adjustPeerScore(m.c.Addr, behaviorInvalidBlock)
 			// Blocks must be received in order, so if one fails its assumed
 			// the rest are failing
 			break
@@ -950,6 +1260,384 @@ func (m *GiveBlocksMessage) process(d daemoner) {
 	}
 }
 
+// SignedBlockHeader a block header with the signature of its block.
+// The signature signs the header hash, so a header can be verified without the block body.
+type SignedBlockHeader struct {
+	Header coin.BlockHeader
+	Sig    cipher.Sig
+}
+
+// NewSignedBlockHeader returns the SignedBlockHeader of a signed block
+func NewSignedBlockHeader(b coin.SignedBlock) SignedBlockHeader {
+	return SignedBlockHeader{
+		Header: b.Block.Head,
+		Sig:    b.Sig,
+	}
+}
+
+// Hash returns the hash of the block header
+func (h SignedBlockHeader) Hash() cipher.SHA256 {
+	return h.Header.Hash()
+}
+
+// VerifySignature verifies that the block header is signed by the blockchain pubkey
+func (h SignedBlockHeader) VerifySignature(pubkey cipher.PubKey) error {
+	sb := coin.SignedBlock{
+		Block: coin.Block{
+			Head: h.Header,
+		},
+		Sig: h.Sig,
+	}
+	return sb.VerifySignature(pubkey)
+}
+
+// GetHeadersMessage sent to request the signed block headers since LastBlock.
+// Only sent to peers with a protocol version of at least headersSyncProtocolVersion
+type GetHeadersMessage struct {
+	LastBlock        uint64
+	RequestedHeaders uint64
+	c                *gnet.MessageContext `enc:"-"`
+}
+
+// NewGetHeadersMessage creates GetHeadersMessage
+func NewGetHeadersMessage(lastBlock, requestedHeaders uint64) *GetHeadersMessage {
+	return &GetHeadersMessage{
+		LastBlock:        lastBlock,
+		RequestedHeaders: requestedHeaders,
+	}
+}
+
+// EncodeSize implements gnet.Serializer
+func (m *GetHeadersMessage) EncodeSize() uint64 {
+	return encodeSizeGetHeadersMessage(m)
+}
+
+// Encode implements gnet.Serializer
+func (m *GetHeadersMessage) Encode(buf []byte) error {
+	return encodeGetHeadersMessageToBuffer(buf, m)
+}
+
+// Decode implements gnet.Serializer
+func (m *GetHeadersMessage) Decode(buf []byte) (uint64, error) {
+	return decodeGetHeadersMessage(buf, m)
+}
+
+// Handle handles message
+func (m *GetHeadersMessage) Handle(mc *gnet.MessageContext, daemon interface{}) error {
+	m.c = mc
+	return daemon.(daemoner).recordMessageEvent(m, mc)
+}
+
+// process replies with the signed block headers since LastBlock
+func (m *GetHeadersMessage) process(d daemoner) {
+	dc := d.DaemonConfig()
+	if dc.DisableNetworking {
+		return
+	}
+
+	fields := logrus.Fields{
+		"addr":   m.c.Addr,
+		"gnetID": m.c.ConnID,
+	}
+
+	// Record this as this peer's highest block
+	d.recordPeerHeight(m.c.Addr, m.c.ConnID, m.LastBlock)
+
+	requestedHeaders := m.RequestedHeaders
+	if requestedHeaders > dc.MaxGetHeadersResponseCount {
+		logger.WithFields(logrus.Fields{
+			"requestedHeaders":    requestedHeaders,
+			"maxRequestedHeaders": dc.MaxGetHeadersResponseCount,
+		}).WithFields(fields).Debug("GetHeadersMessage.RequestedHeaders value exceeds configured limit, reducing")
+		requestedHeaders = dc.MaxGetHeadersResponseCount
+	}
+
+	headers, err := d.getSignedBlockHeadersSince(m.LastBlock, requestedHeaders)
+	if err != nil {
+		logger.WithFields(fields).WithError(err).Error("getSignedBlockHeadersSince failed")
+		return
+	}
+
+	// Reply even if there are no headers, so that the peer knows that we have no more blocks
+	logger.WithFields(fields).Debugf("GetHeadersMessage: replying with %d headers after block %d", len(headers), m.LastBlock)
+
+	gm := NewGiveHeadersMessage(headers, dc.MaxOutgoingMessageLength)
+	if len(gm.Headers) != len(headers) {
+		logger.WithFields(fields).Debugf("NewGiveHeadersMessage truncated %d headers to %d headers", len(headers), len(gm.Headers))
+	}
+
+	if err := d.sendMessage(m.c.Addr, gm); err != nil {
+		logger.WithFields(fields).WithError(err).Error("Send GiveHeadersMessage failed")
+	}
+}
+
+// GiveHeadersMessage sent in response to GetHeadersMessage
+type GiveHeadersMessage struct {
+	Headers []SignedBlockHeader  `enc:",maxlen=1024"`
+	c       *gnet.MessageContext `enc:"-"`
+}
+
+// NewGiveHeadersMessage creates GiveHeadersMessage.
+// If the size of message would exceed maxMsgLength, the header slice is truncated.
+func NewGiveHeadersMessage(headers []SignedBlockHeader, maxMsgLength uint64) *GiveHeadersMessage {
+	if len(headers) > 1024 {
+		headers = headers[:1024]
+	}
+	m := &GiveHeadersMessage{
+		Headers: headers,
+	}
+	truncateGiveHeadersMessage(m, maxMsgLength)
+	return m
+}
+
+// truncateGiveHeadersMessage truncates the headers in GiveHeadersMessage to fit inside of MaxOutgoingMessageLength
+func truncateGiveHeadersMessage(m *GiveHeadersMessage, maxMsgLength uint64) {
+	// The message length will include a 4 byte message type prefix.
+	// Panic if the prefix can't fit, otherwise we can't adjust the uint64 safely
+	if maxMsgLength < 4 {
+		logger.Panic("maxMsgLength must be >= 4")
+	}
+
+	maxMsgLength -= 4
+
+	// Measure the current message size, if it fits, return
+	n := m.EncodeSize()
+	if n <= maxMsgLength {
+		return
+	}
+
+	// Headers have a fixed size, so the number of headers that fit can be computed
+	var mm GiveHeadersMessage
+	size := mm.EncodeSize()
+	mm.Headers = make([]SignedBlockHeader, 1)
+	headerSize := mm.EncodeSize() - size
+
+	m.Headers = m.Headers[:(maxMsgLength-size)/headerSize]
+}
+
+// EncodeSize implements gnet.Serializer
+func (m *GiveHeadersMessage) EncodeSize() uint64 {
+	return encodeSizeGiveHeadersMessage(m)
+}
+
+// Encode implements gnet.Serializer
+func (m *GiveHeadersMessage) Encode(buf []byte) error {
+	return encodeGiveHeadersMessageToBuffer(buf, m)
+}
+
+// Decode implements gnet.Serializer
+func (m *GiveHeadersMessage) Decode(buf []byte) (uint64, error) {
+	return decodeGiveHeadersMessage(buf, m)
+}
+
+// Handle handle message
+func (m *GiveHeadersMessage) Handle(mc *gnet.MessageContext, daemon interface{}) error {
+	m.c = mc
+	return daemon.(daemoner).recordMessageEvent(m, mc)
+}
+
+// process verifies the headers and schedules the download of their blocks
+func (m *GiveHeadersMessage) process(d daemoner) {
+	if d.DaemonConfig().DisableNetworking {
+		return
+	}
+
+	d.receiveHeaders(m.c.Addr, m.c.ConnID, m.Headers)
+}
+
+// PrefilledTransaction is a transaction of a compact block that is sent in full, with its index in the block
+type PrefilledTransaction struct {
+	Index       uint32
+	Transaction coin.Transaction
+}
+
+// CompactBlockMessage sends a new block without the transactions that the peer likely has in its unconfirmed pool.
+// These transactions are identified by short IDs, derived from the transaction hash and a key computed from
+// the block header hash and Nonce. The short IDs fill the positions of the block transactions that are not prefilled, in order.
+// Only sent to peers that set ServiceCompactBlocks in their IntroductionMessage capabilities
+type CompactBlockMessage struct {
+	Header    coin.BlockHeader
+	Sig       cipher.Sig
+	Nonce     uint64
+	ShortIDs  []uint64               `enc:",maxlen=65535"`
+	Prefilled []PrefilledTransaction `enc:",maxlen=65535"`
+	c         *gnet.MessageContext   `enc:"-"`
+}
+
+// NewCompactBlockMessage creates CompactBlockMessage. The transactions whose hash is in prefill are sent in full
+func NewCompactBlockMessage(b coin.SignedBlock, nonce uint64, prefill map[cipher.SHA256]struct{}) *CompactBlockMessage {
+	m := &CompactBlockMessage{
+		Header: b.Block.Head,
+		Sig:    b.Sig,
+		Nonce:  nonce,
+	}
+
+	key := compactBlockShortIDKey(b.HashHeader(), nonce)
+	for i, txn := range b.Block.Body.Transactions {
+		h := txn.Hash()
+		if _, ok := prefill[h]; ok {
+			m.Prefilled = append(m.Prefilled, PrefilledTransaction{
+				Index:       uint32(i),
+				Transaction: txn,
+			})
+		} else {
+			m.ShortIDs = append(m.ShortIDs, compactBlockShortID(key, h))
+		}
+	}
+
+	return m
+}
+
+// EncodeSize implements gnet.Serializer
+func (m *CompactBlockMessage) EncodeSize() uint64 {
+	return encodeSizeCompactBlockMessage(m)
+}
+
+// Encode implements gnet.Serializer
+func (m *CompactBlockMessage) Encode(buf []byte) error {
+	return encodeCompactBlockMessageToBuffer(buf, m)
+}
+
+// Decode implements gnet.Serializer
+func (m *CompactBlockMessage) Decode(buf []byte) (uint64, error) {
+	return decodeCompactBlockMessage(buf, m)
+}
+
+// Handle handle message
+func (m *CompactBlockMessage) Handle(mc *gnet.MessageContext, daemon interface{}) error {
+	m.c = mc
+	return daemon.(daemoner).recordMessageEvent(m, mc)
+}
+
+// process reconstructs the block from the unconfirmed pool and requests the missing transactions
+func (m *CompactBlockMessage) process(d daemoner) {
+	if d.DaemonConfig().DisableNetworking {
+		return
+	}
+
+	d.receiveCompactBlock(m.c.Addr, m)
+}
+
+// GetBlockTxnsMessage sent to request the transactions of a compact block that could not be reconstructed
+type GetBlockTxnsMessage struct {
+	BlockHash cipher.SHA256
+	Indexes   []uint32             `enc:",maxlen=65535"`
+	c         *gnet.MessageContext `enc:"-"`
+}
+
+// NewGetBlockTxnsMessage creates GetBlockTxnsMessage
+func NewGetBlockTxnsMessage(blockHash cipher.SHA256, indexes []uint32) *GetBlockTxnsMessage {
+	return &GetBlockTxnsMessage{
+		BlockHash: blockHash,
+		Indexes:   indexes,
+	}
+}
+
+// EncodeSize implements gnet.Serializer
+func (m *GetBlockTxnsMessage) EncodeSize() uint64 {
+	return encodeSizeGetBlockTxnsMessage(m)
+}
+
+// Encode implements gnet.Serializer
+func (m *GetBlockTxnsMessage) Encode(buf []byte) error {
+	return encodeGetBlockTxnsMessageToBuffer(buf, m)
+}
+
+// Decode implements gnet.Serializer
+func (m *GetBlockTxnsMessage) Decode(buf []byte) (uint64, error) {
+	return decodeGetBlockTxnsMessage(buf, m)
+}
+
+// Handle handle message
+func (m *GetBlockTxnsMessage) Handle(mc *gnet.MessageContext, daemon interface{}) error {
+	m.c = mc
+	return daemon.(daemoner).recordMessageEvent(m, mc)
+}
+
+// process replies with the requested transactions of the block.
+// If the block is not found, replies without transactions so that the peer requests the full block
+func (m *GetBlockTxnsMessage) process(d daemoner) {
+	if d.DaemonConfig().DisableNetworking {
+		return
+	}
+
+	fields := logrus.Fields{
+		"addr":      m.c.Addr,
+		"gnetID":    m.c.ConnID,
+		"blockHash": m.BlockHash.Hex(),
+	}
+
+	b, err := d.getSignedBlockByHash(m.BlockHash)
+	if err != nil {
+		logger.WithError(err).WithFields(fields).Error("getSignedBlockByHash failed")
+		return
+	}
+
+	var txns coin.Transactions
+	if b == nil {
+		logger.WithFields(fields).Debug("GetBlockTxnsMessage block not found")
+	} else {
+		txns = make(coin.Transactions, 0, len(m.Indexes))
+		for _, i := range m.Indexes {
+			if int(i) >= len(b.Block.Body.Transactions) {
+				logger.WithFields(fields).WithField("index", i).Info("GetBlockTxnsMessage transaction index out of range")
+				d.adjustPeerScore(m.c.Addr, behaviorProtocolViolation)
+				return
+			}
+			txns = append(txns, b.Block.Body.Transactions[i])
+		}
+	}
+
+	if err := d.sendMessage(m.c.Addr, NewGiveBlockTxnsMessage(m.BlockHash, txns)); err != nil {
+		logger.WithError(err).WithFields(fields).Error("Send GiveBlockTxnsMessage failed")
+	}
+}
+
+// GiveBlockTxnsMessage sent in response to GetBlockTxnsMessage
+type GiveBlockTxnsMessage struct {
+	BlockHash    cipher.SHA256
+	Transactions coin.Transactions    `enc:",maxlen=65535"`
+	c            *gnet.MessageContext `enc:"-"`
+}
+
+// NewGiveBlockTxnsMessage creates GiveBlockTxnsMessage
+func NewGiveBlockTxnsMessage(blockHash cipher.SHA256, txns coin.Transactions) *GiveBlockTxnsMessage {
+	return &GiveBlockTxnsMessage{
+		BlockHash:    blockHash,
+		Transactions: txns,
+	}
+}
+
+// EncodeSize implements gnet.Serializer
+func (m *GiveBlockTxnsMessage) EncodeSize() uint64 {
+	return encodeSizeGiveBlockTxnsMessage(m)
+}
+
+// Encode implements gnet.Serializer
+func (m *GiveBlockTxnsMessage) Encode(buf []byte) error {
+	return encodeGiveBlockTxnsMessageToBuffer(buf, m)
+}
+
+// Decode implements gnet.Serializer
+func (m *GiveBlockTxnsMessage) Decode(buf []byte) (uint64, error) {
+	return decodeGiveBlockTxnsMessage(buf, m)
+}
+
+// Handle handle message
+func (m *GiveBlockTxnsMessage) Handle(mc *gnet.MessageContext, daemon interface{}) error {
+	m.c = mc
+	return daemon.(daemoner).recordMessageEvent(m, mc)
+}
+
+// process completes the pending compact block with the transactions and executes it
+func (m *GiveBlockTxnsMessage) process(d daemoner) {
+	if d.DaemonConfig().DisableNetworking {
+		return
+	}
+
+	d.receiveBlockTxns(m.c.Addr, m.BlockHash, m.Transactions)
+}
+
 // AnnounceBlocksMessage tells a peer our highest known BkSeq. The receiving peer can choose
 // to send GetBlocksMessage in response
 type AnnounceBlocksMessage struct {
@@ -1006,18 +1694,65 @@ func (abm *AnnounceBlocksMessage) process(d daemoner) {
 		return
 	}
 
+	// Record this as this peer's highest block
+	d.recordPeerHeight(abm.c.Addr, abm.c.ConnID, abm.MaxBkSeq)
+
 	if headBkSeq >= abm.MaxBkSeq {
 		return
 	}
 
 	// TODO: Should this be block get request for current sequence?
 	// If client is not caught up, won't attempt to get block
-	m := NewGetBlocksMessage(headBkSeq, d.DaemonConfig().GetBlocksRequestCount)
-	if err := d.sendMessage(abm.c.Addr, m); err != nil {
-		logger.WithError(err).WithFields(fields).Error("Send GetBlocksMessage")
+	if err := d.requestBlocksFromAddr(abm.c.Addr); err != nil {
+		logger.WithError(err).WithFields(fields).Error("requestBlocksFromAddr")
 	}
 }
 
+// PrunedBlocksMessage is sent in reply to a GetBlocksMessage that requested blocks whose bodies were pruned.
+// The LowestBlockSeq of the capabilities in the IntroductionMessage moves up as a node prunes blocks,
+// so this tells the peer the lowest seq of the blocks, other than the genesis block, that the node can send now
+type PrunedBlocksMessage struct {
+	LowestBlockSeq uint64
+	c              *gnet.MessageContext `enc:"-"`
+}
+
+// NewPrunedBlocksMessage creates message
+func NewPrunedBlocksMessage(lowestBlockSeq uint64) *PrunedBlocksMessage {
+	return &PrunedBlocksMessage{
+		LowestBlockSeq: lowestBlockSeq,
+	}
+}
+
+// EncodeSize implements gnet.Serializer
+func (pbm *PrunedBlocksMessage) EncodeSize() uint64 {
+	return encodeSizePrunedBlocksMessage(pbm)
+}
+
+// Encode implements gnet.Serializer
+func (pbm *PrunedBlocksMessage) Encode(buf []byte) error {
+	return encodePrunedBlocksMessageToBuffer(buf, pbm)
+}
+
+// Decode implements gnet.Serializer
+func (pbm *PrunedBlocksMessage) Decode(buf []byte) (uint64, error) {
+	return decodePrunedBlocksMessage(buf, pbm)
+}
+
+// Handle handles message
+func (pbm *PrunedBlocksMessage) Handle(mc *gnet.MessageContext, daemon interface{}) error {
+	pbm.c = mc
+	return daemon.(daemoner).recordMessageEvent(pbm, mc)
+}
+
+// process records the lowest block seq of the peer
+func (pbm *PrunedBlocksMessage) process(d daemoner) {
+	if d.DaemonConfig().DisableNetworking {
+		return
+	}
+
+	d.receivePrunedBlocks(pbm.c.Addr, pbm.c.ConnID, pbm.LowestBlockSeq)
+}
+
 // SendingTxnsMessage send transaction message interface
 type SendingTxnsMessage interface {
 	GetFiltered() []cipher.SHA256
@@ -1132,6 +1867,9 @@ func (atm *AnnounceTxnsMessage) process(d daemoner) {
 		"gnetID": atm.c.ConnID,
 	}
 
+	// Stem transactions that are announced by a peer were fluffed by another node
+	d.txnsFluffed(atm.Transactions)
+
 	unknown, err := d.filterKnownUnconfirmed(atm.Transactions)
 	if err != nil {
 		logger.WithError(err).Error("AnnounceTxnsMessage d.filterKnownUnconfirmed failed")
@@ -1351,6 +2089,9 @@ func (gtm *GiveTxnsMessage) process(d daemoner) {
 		return
 	}
 
+	// Stem transactions that are broadcast by a peer were fluffed by another node
+	d.txnsFluffed(gtm.GetFiltered())
+
 	hashes := make([]cipher.SHA256, 0, len(gtm.Transactions))
 	// Update unconfirmed pool with these transactions
 	for _, txn := range gtm.Transactions {
@@ -1360,6 +2101,9 @@ func (gtm *GiveTxnsMessage) process(d daemoner) {
 		known, softErr, err := d.injectTransaction(txn)
 		if err != nil {
 			logger.WithError(err).WithField("txid", txn.Hash().Hex()).Warning("Failed to record transaction")
+			if _, ok := err.(transaction.ErrTxnViolatesHardConstraint); ok {
+				d.adjustPeerScore(gtm.c.Addr, behaviorInvalidTransaction)
+			}
 			continue
 		} else if softErr != nil {
 			logger.WithError(softErr).WithField("txid", txn.Hash().Hex()).Warning("Transaction soft violation")
@@ -1388,3 +2132,48 @@ func (gtm *GiveTxnsMessage) process(d daemoner) {
 		logger.Debugf("Announced %d transactions to %d peers", len(hashes), len(ids))
 	}
 }
+
+// StemTxnMessage relays a transaction along a Dandelion++ stem.
+// The receiver relays it to a single stem peer, or announces it to all peers if it is in fluff mode.
+// Only sent to peers that set ServiceDandelion in their IntroductionMessage capabilities
+type StemTxnMessage struct {
+	Transaction coin.Transaction
+	c           *gnet.MessageContext `enc:"-"`
+}
+
+// NewStemTxnMessage creates StemTxnMessage
+func NewStemTxnMessage(txn coin.Transaction) *StemTxnMessage {
+	return &StemTxnMessage{
+		Transaction: txn,
+	}
+}
+
+// EncodeSize implements gnet.Serializer
+func (m *StemTxnMessage) EncodeSize() uint64 {
+	return encodeSizeStemTxnMessage(m)
+}
+
+// Encode implements gnet.Serializer
+func (m *StemTxnMessage) Encode(buf []byte) error {
+	return encodeStemTxnMessageToBuffer(buf, m)
+}
+
+// Decode implements gnet.Serializer
+func (m *StemTxnMessage) Decode(buf []byte) (uint64, error) {
+	return decodeStemTxnMessage(buf, m)
+}
+
+// Handle handle message
+func (m *StemTxnMessage) Handle(mc *gnet.MessageContext, daemon interface{}) error {
+	m.c = mc
+	return daemon.(daemoner).recordMessageEvent(m, mc)
+}
+
+// process records the transaction, then relays it to the next stem peer or fluffs it
+func (m *StemTxnMessage) process(d daemoner) {
+	if d.DaemonConfig().DisableNetworking {
+		return
+	}
+
+	d.receiveStemTransaction(m.c.Addr, m.Transaction)
+}
@@ -1,6 +1,7 @@
 package daemon
 
 import (
+	"bytes"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -17,6 +18,7 @@ import (
 	"github.com/skycoin/skycoin/src/params"
 	"github.com/skycoin/skycoin/src/testutil"
 	"github.com/skycoin/skycoin/src/util/useragent"
+	"github.com/skycoin/skycoin/src/visor/blockdb"
 )
 
 func TestIntroductionMessage(t *testing.T) {
@@ -31,7 +33,7 @@ func TestIntroductionMessage(t *testing.T) {
 		BurnFactor:          4,
 		MaxTransactionSize:  32768,
 		MaxDropletPrecision: 3,
-	}, genesisHash)
+	}, genesisHash, Capabilities{})
 	invalidGenesisHashExtra = invalidGenesisHashExtra[:len(invalidGenesisHashExtra)-2]
 
 	type daemonMockValue struct {
@@ -144,7 +146,7 @@ func TestIntroductionMessage(t *testing.T) {
 					BurnFactor:          4,
 					MaxTransactionSize:  32768,
 					MaxDropletPrecision: 3,
-				}, genesisHash),
+				}, genesisHash, Capabilities{}),
 			},
 		},
 		{
@@ -187,7 +189,7 @@ func TestIntroductionMessage(t *testing.T) {
 					BurnFactor:          4,
 					MaxTransactionSize:  32768,
 					MaxDropletPrecision: 3,
-				}, genesisHash), []byte("additional data")...),
+				}, genesisHash, Capabilities{}), []byte("additional data")...),
 			},
 		},
 		{
@@ -232,7 +234,7 @@ func TestIntroductionMessage(t *testing.T) {
 					BurnFactor:          4,
 					MaxTransactionSize:  32768,
 					MaxDropletPrecision: 3,
-				}, genesisHash),
+				}, genesisHash, Capabilities{}),
 			},
 		},
 		{
@@ -392,7 +394,7 @@ func TestIntroductionMessage(t *testing.T) {
 					BurnFactor:          4,
 					MaxTransactionSize:  32768,
 					MaxDropletPrecision: 3,
-				}, genesisHash),
+				}, genesisHash, Capabilities{}),
 			},
 		},
 		{
@@ -435,7 +437,7 @@ func TestIntroductionMessage(t *testing.T) {
 					BurnFactor:          4,
 					MaxTransactionSize:  32768,
 					MaxDropletPrecision: 3,
-				}, genesisHash),
+				}, genesisHash, Capabilities{}),
 			},
 		},
 		{
@@ -477,7 +479,7 @@ func TestIntroductionMessage(t *testing.T) {
 					BurnFactor:          4,
 					MaxTransactionSize:  32768,
 					MaxDropletPrecision: 3,
-				}, genesisHash),
+				}, genesisHash, Capabilities{}),
 			},
 		},
 	}
@@ -568,7 +570,26 @@ func TestMessageEncodeDecode(t *testing.T) {
 					BurnFactor:          2,
 					MaxTransactionSize:  32768,
 					MaxDropletPrecision: 3,
-				}, introGenesisHash),
+				}, introGenesisHash, Capabilities{}),
+			},
+		},
+		{
+			goldenFile: "intro-msg-capabilities.golden",
+			obj:        &IntroductionMessage{},
+			msg: &IntroductionMessage{
+				Mirror:          99998888,
+				ListenPort:      8888,
+				ProtocolVersion: 12341234,
+				Extra: newIntroductionMessageExtra(introPubKey, "skycoin:0.26.0(foo)", params.VerifyTxn{
+					BurnFactor:          2,
+					MaxTransactionSize:  32768,
+					MaxDropletPrecision: 3,
+				}, introGenesisHash, Capabilities{
+					Services:                 ServiceCompactBlocks | ServiceDandelion,
+					MaxIncomingMessageLength: 1024 * 1024,
+					MaxOutgoingMessageLength: 256 * 1024,
+					MessageTypes:             []string{"INTR", "GETB", "GIVB"},
+				}),
 			},
 		},
 		{
@@ -592,6 +613,26 @@ func TestMessageEncodeDecode(t *testing.T) {
 				},
 			},
 		},
+		{
+			goldenFile: "give-addrs-msg.golden",
+			obj:        &GiveAddrsMessage{},
+			msg: &GiveAddrsMessage{
+				Addrs: []NetAddr{
+					{
+						Network:  NetAddrIPv4,
+						Addr:     []byte{1, 2, 3, 4},
+						Port:     1234,
+						LastSeen: 1500000000,
+					},
+					{
+						Network:  NetAddrTorV3,
+						Addr:     bytes.Repeat([]byte{5}, 35),
+						Port:     4321,
+						LastSeen: 1600000000,
+					},
+				},
+			},
+		},
 		{
 			goldenFile: "ping-msg.golden",
 			obj:        &PingMessage{},
@@ -610,6 +651,13 @@ func TestMessageEncodeDecode(t *testing.T) {
 				RequestedBlocks: 888899997777,
 			},
 		},
+		{
+			goldenFile: "pruned-blocks-msg.golden",
+			obj:        &PrunedBlocksMessage{},
+			msg: &PrunedBlocksMessage{
+				LowestBlockSeq: 54369,
+			},
+		},
 		{
 			goldenFile: "give-blocks-msg.golden",
 			obj:        &GiveBlocksMessage{},
@@ -754,6 +802,122 @@ func TestMessageEncodeDecode(t *testing.T) {
 				},
 			},
 		},
+		{
+			goldenFile: "get-headers-msg.golden",
+			obj:        &GetHeadersMessage{},
+			msg: &GetHeadersMessage{
+				LastBlock:        999988887777,
+				RequestedHeaders: 888899997777,
+			},
+		},
+		{
+			goldenFile: "give-headers-msg.golden",
+			obj:        &GiveHeadersMessage{},
+			msg: &GiveHeadersMessage{
+				Headers: []SignedBlockHeader{
+					{
+						Sig: cipher.MustSigFromHex("8cf145e9ef4a4a5254bc57798a7a61dfed238768f94edc5635175c6b91bccd8ec1555da603c5e31b018e135b82b1525be8a92973c468a74b5b40b8da189cb465eb"),
+						Header: coin.BlockHeader{
+							Version:  1,
+							Time:     1538036613,
+							BkSeq:    9999999999,
+							Fee:      1234123412341234,
+							PrevHash: cipher.MustSHA256FromHex("59cb7d0e2ce8a03d1054afcc28a22fe864a8813460d241db38c59d10e7c29132"),
+							BodyHash: cipher.MustSHA256FromHex("6d421469409591f0c3112884c8cf10f8bca5d8ab87c9c30dea2ea73b6751bbf9"),
+							UxHash:   cipher.MustSHA256FromHex("6ea6a972cf06d25908b29953aeddb68c3b6f3a9903e8f964dc89b0abc0645dea"),
+						},
+					},
+					{
+						Sig: cipher.MustSigFromHex("8015c8776de577d89c29d1cbd1d558ba4855dec94ba58f6c67d55ece5c85708b9906bd0b72b451e27008f3938fcec42c1a28ddac336ae8206d8e6443b95dde966c"),
+						Header: coin.BlockHeader{
+							Version:  0,
+							Time:     1427248825,
+							BkSeq:    100,
+							Fee:      120939323123,
+							PrevHash: cipher.MustSHA256FromHex("04d40b5d27c539ab9d98934628604baef7dbfb1c35ddf9c0f96a67f6b061fa26"),
+							BodyHash: cipher.MustSHA256FromHex("9a67fbb00216ae99f334d4efa2c9c42a25aac5d1a5bbb2058fe5705cfe0e30ea"),
+							UxHash:   cipher.MustSHA256FromHex("58981d30da11be3c8e9dd8fdb7b51b48ba13dc0214cf211251308985bf089f76"),
+						},
+					},
+				},
+			},
+		},
+		{
+			goldenFile: "compact-block-msg.golden",
+			obj:        &CompactBlockMessage{},
+			msg: &CompactBlockMessage{
+				Header: coin.BlockHeader{
+					Version:  1,
+					Time:     1538036613,
+					BkSeq:    9999999999,
+					Fee:      1234123412341234,
+					PrevHash: cipher.MustSHA256FromHex("59cb7d0e2ce8a03d1054afcc28a22fe864a8813460d241db38c59d10e7c29132"),
+					BodyHash: cipher.MustSHA256FromHex("6d421469409591f0c3112884c8cf10f8bca5d8ab87c9c30dea2ea73b6751bbf9"),
+					UxHash:   cipher.MustSHA256FromHex("6ea6a972cf06d25908b29953aeddb68c3b6f3a9903e8f964dc89b0abc0645dea"),
+				},
+				Sig:      cipher.MustSigFromHex("8cf145e9ef4a4a5254bc57798a7a61dfed238768f94edc5635175c6b91bccd8ec1555da603c5e31b018e135b82b1525be8a92973c468a74b5b40b8da189cb465eb"),
+				Nonce:    8877665544332211,
+				ShortIDs: []uint64{1234567890123456789, 9876543210987654321, 42},
+				Prefilled: []PrefilledTransaction{
+					{
+						Index: 1,
+						Transaction: coin.Transaction{
+							Length:    256,
+							Type:      0,
+							InnerHash: cipher.MustSHA256FromHex("1773d8901df96bba4c6d65499e11e6ec73a9978c611d1463898ffbc2b49773fc"),
+							Sigs: []cipher.Sig{
+								cipher.MustSigFromHex("a711880ae54d1b6b9adade2ef1e743d6d539a78b0cecf1af08107e467956de80ef1d49fb5e896c9d0870ef8bf8a4d328ca0ecf7c1956866867ec56064e68f8a374"),
+							},
+							In: []cipher.SHA256{
+								cipher.MustSHA256FromHex("703f84ee0702b44fc89ce573a239d5fbf185bf5d4e7fc8f4930262bcda1e8fb0"),
+							},
+							Out: []coin.TransactionOutput{
+								{
+									Address: cipher.MustDecodeBase58Address("29VEn56iRr2TpVVpPoPxUJPfFWuhbLSBRdU"),
+									Coins:   1111111111111111111,
+									Hours:   9999999999999999999,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			goldenFile: "get-block-txns-msg.golden",
+			obj:        &GetBlockTxnsMessage{},
+			msg: &GetBlockTxnsMessage{
+				BlockHash: cipher.MustSHA256FromHex("04d40b5d27c539ab9d98934628604baef7dbfb1c35ddf9c0f96a67f6b061fa26"),
+				Indexes:   []uint32{0, 2, 65534},
+			},
+		},
+		{
+			goldenFile: "give-block-txns-msg.golden",
+			obj:        &GiveBlockTxnsMessage{},
+			msg: &GiveBlockTxnsMessage{
+				BlockHash: cipher.MustSHA256FromHex("04d40b5d27c539ab9d98934628604baef7dbfb1c35ddf9c0f96a67f6b061fa26"),
+				Transactions: coin.Transactions{
+					{
+						Length:    13043,
+						Type:      128,
+						InnerHash: cipher.MustSHA256FromHex("a9da3e4acb1892a000c1b658a64d4e420d0c381862928ab820fb3f3a534a9674"),
+						Sigs: []cipher.Sig{
+							cipher.MustSigFromHex("7bbbdfd58c0533aed95f18d9413e0e0517892350eaf132eadf7a9a03d4a974ca0bc074abc001f86a34cf66c10f832dbcca20c2c67b5e8517f4ff0e1d0123fecb21"),
+						},
+						In: []cipher.SHA256{
+							cipher.MustSHA256FromHex("766d6f6ed56599a91759c75466e3f09b9d6d5995b58dd5bbfba5af10b1a8cdea"),
+						},
+						Out: []coin.TransactionOutput{
+							{
+								Address: cipher.MustDecodeBase58Address("24iFsYHzVfYXo8cvWg1jhetpTMNvHH7j6AX"),
+								Coins:   1123103123,
+								Hours:   123000,
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			goldenFile: "announce-blocks-msg.golden",
 			obj:        &AnnounceBlocksMessage{},
@@ -839,6 +1003,30 @@ func TestMessageEncodeDecode(t *testing.T) {
 				},
 			},
 		},
+		{
+			goldenFile: "stem-txn-msg.golden",
+			obj:        &StemTxnMessage{},
+			msg: &StemTxnMessage{
+				Transaction: coin.Transaction{
+					Length:    256,
+					Type:      0,
+					InnerHash: cipher.MustSHA256FromHex("1773d8901df96bba4c6d65499e11e6ec73a9978c611d1463898ffbc2b49773fc"),
+					Sigs: []cipher.Sig{
+						cipher.MustSigFromHex("a711880ae54d1b6b9adade2ef1e743d6d539a78b0cecf1af08107e467956de80ef1d49fb5e896c9d0870ef8bf8a4d328ca0ecf7c1956866867ec56064e68f8a374"),
+					},
+					In: []cipher.SHA256{
+						cipher.MustSHA256FromHex("703f84ee0702b44fc89ce573a239d5fbf185bf5d4e7fc8f4930262bcda1e8fb0"),
+					},
+					Out: []coin.TransactionOutput{
+						{
+							Address: cipher.MustDecodeBase58Address("29VEn56iRr2TpVVpPoPxUJPfFWuhbLSBRdU"),
+							Coins:   1111111111111111111,
+							Hours:   9999999999999999999,
+						},
+					},
+				},
+			},
+		},
 	}
 
 	if update {
@@ -912,6 +1100,48 @@ func TestTruncateGivePeersMessage(t *testing.T) {
 	require.True(t, n <= maxLen)
 }
 
+func TestTruncateGiveAddrsMessage(t *testing.T) {
+	maxLen := uint64(1024)
+	m := &GiveAddrsMessage{}
+
+	// Empty message, no truncation
+	prevLen := len(m.Addrs)
+	truncateGiveAddrsMessage(m, maxLen)
+	require.Equal(t, prevLen, len(m.Addrs))
+
+	n := encodeSizeGiveAddrsMessage(m)
+	require.True(t, n <= maxLen)
+
+	// One address, no truncation
+	m.Addrs = append(m.Addrs, NetAddr{})
+	prevLen = len(m.Addrs)
+	truncateGiveAddrsMessage(m, maxLen)
+	require.Equal(t, prevLen, len(m.Addrs))
+
+	n = encodeSizeGiveAddrsMessage(m)
+	require.True(t, n <= maxLen)
+
+	// Too many addresses of varying sizes, truncated to the most that fit
+	m.Addrs = make([]NetAddr, 512)
+	for i := range m.Addrs {
+		if i%2 == 0 {
+			m.Addrs[i].Addr = make([]byte, 4)
+		} else {
+			m.Addrs[i].Addr = make([]byte, 35)
+		}
+	}
+	addrs := m.Addrs
+	truncateGiveAddrsMessage(m, maxLen)
+	require.True(t, len(m.Addrs) < 512)
+	require.NotEmpty(t, m.Addrs)
+
+	n = encodeSizeGiveAddrsMessage(m)
+	require.True(t, n+4 <= maxLen)
+	m.Addrs = append(m.Addrs, addrs[len(m.Addrs)])
+	n = encodeSizeGiveAddrsMessage(m)
+	require.True(t, n+4 > maxLen)
+}
+
 func TestTruncateGiveBlocksMessage(t *testing.T) {
 	maxLen := uint64(1024)
 	m := &GiveBlocksMessage{}
@@ -945,6 +1175,41 @@ func TestTruncateGiveBlocksMessage(t *testing.T) {
 	require.True(t, n <= maxLen)
 }
 
+func TestTruncateGiveHeadersMessage(t *testing.T) {
+	maxLen := uint64(1024)
+	m := &GiveHeadersMessage{}
+
+	// Empty message, no truncation
+	prevLen := len(m.Headers)
+	truncateGiveHeadersMessage(m, maxLen)
+	require.Equal(t, prevLen, len(m.Headers))
+
+	n := encodeSizeGiveHeadersMessage(m)
+	require.True(t, n <= maxLen)
+
+	// One header, no truncation
+	m.Headers = append(m.Headers, SignedBlockHeader{})
+	prevLen = len(m.Headers)
+	truncateGiveHeadersMessage(m, maxLen)
+	require.Equal(t, prevLen, len(m.Headers))
+
+	n = encodeSizeGiveHeadersMessage(m)
+	require.True(t, n <= maxLen)
+
+	// Too many headers, truncated to the most headers that fit
+	m.Headers = make([]SignedBlockHeader, 100)
+	truncateGiveHeadersMessage(m, maxLen)
+	require.True(t, len(m.Headers) < 100)
+	require.NotEmpty(t, m.Headers)
+
+	n = encodeSizeGiveHeadersMessage(m)
+	require.True(t, n <= maxLen-4)
+
+	m.Headers = append(m.Headers, SignedBlockHeader{})
+	n = encodeSizeGiveHeadersMessage(m)
+	require.True(t, n > maxLen-4)
+}
+
 func TestTruncateGiveTransactionsMessage(t *testing.T) {
 	maxLen := uint64(1024)
 	m := &GiveTxnsMessage{}
@@ -1086,8 +1351,169 @@ func TestGetBlocksMessageProcess(t *testing.T) {
 	d.AssertExpectations(t)
 }
 
+func TestGetBlocksMessageProcessPruned(t *testing.T) {
+	d := &mockDaemoner{}
+
+	m := &GetBlocksMessage{
+		LastBlock:       7,
+		RequestedBlocks: 10,
+		c: &gnet.MessageContext{
+			ConnID: 10,
+			Addr:   "127.0.0.1:1234",
+		},
+	}
+
+	// The peer is told the lowest block that can be sent when it requests pruned blocks
+	d.On("DaemonConfig").Return(DaemonConfig{
+		MaxGetBlocksResponseCount: 20,
+	})
+	d.On("recordPeerHeight", "127.0.0.1:1234", uint64(10), uint64(7)).Return()
+	d.On("getSignedBlocksSince", uint64(7), uint64(10)).Return(nil, blockdb.NewErrBlockPruned(8))
+	d.On("sendPrunedBlocks", "127.0.0.1:1234").Return()
+
+	m.process(d)
+
+	d.AssertExpectations(t)
+}
+
+func TestPrunedBlocksMessageProcess(t *testing.T) {
+	d := &mockDaemoner{}
+
+	m := &PrunedBlocksMessage{
+		LowestBlockSeq: 100,
+		c: &gnet.MessageContext{
+			ConnID: 10,
+			Addr:   "127.0.0.1:1234",
+		},
+	}
+
+	d.On("DaemonConfig").Return(DaemonConfig{})
+	d.On("receivePrunedBlocks", "127.0.0.1:1234", uint64(10), uint64(100)).Return()
+
+	m.process(d)
+
+	d.AssertExpectations(t)
+}
+
+func TestGivePeersMessageProcess(t *testing.T) {
+	addr := "127.0.0.1:1234"
+	mc := &gnet.MessageContext{
+		ConnID: 10,
+		Addr:   addr,
+	}
+
+	onion := "pg6mmjiyjmcrsslvykfwnntlaru7p5svn6y2ymmju6nubxndf4pscryd.onion:6000"
+
+	ip1, err := NewIPAddr("112.32.32.14:6000")
+	require.NoError(t, err)
+	ip2, err := NewIPAddr("113.32.32.14:6000")
+	require.NoError(t, err)
+
+	// GivePeersMessage peers have no timestamps
+	d := &mockDaemoner{}
+	d.On("pexConfig").Return(pex.Config{})
+	d.On("addGossipedPeers", addr, []pex.GossipAddr{
+		{Addr: "112.32.32.14:6000"},
+		{Addr: "113.32.32.14:6000"},
+	}).Return(2)
+
+	gpm := &GivePeersMessage{
+		Peers: []IPAddr{ip1, ip2},
+		c:     mc,
+	}
+	gpm.process(d)
+	d.AssertExpectations(t)
+
+	// GiveAddrsMessage peers have the timestamps of the sender
+	d = &mockDaemoner{}
+	d.On("pexConfig").Return(pex.Config{})
+	d.On("addGossipedPeers", addr, []pex.GossipAddr{
+		{Addr: "112.32.32.14:6000", Timestamp: 1500000000},
+		{Addr: onion, Timestamp: 1600000000},
+	}).Return(2)
+
+	// DNS hostnames can't be encoded and are skipped
+	gam := NewGiveAddrsMessage([]pex.Peer{
+		{Addr: "112.32.32.14:6000", LastSeen: 1500000000},
+		{Addr: "seed.example.com:6000", LastSeen: 1500000000},
+		{Addr: onion, LastSeen: 1600000000},
+	}, 1024)
+	require.Len(t, gam.Addrs, 2)
+	require.Equal(t, NetAddr{
+		Network:  NetAddrIPv4,
+		Addr:     []byte{112, 32, 32, 14},
+		Port:     6000,
+		LastSeen: 1500000000,
+	}, gam.Addrs[0])
+	require.Equal(t, NetAddrTorV3, gam.Addrs[1].Network)
+	require.Len(t, gam.Addrs[1].Addr, pex.OnionV3AddrLen)
+
+	// Addresses of unknown networks and invalid addresses are skipped
+	gam.Addrs = append(gam.Addrs, NetAddr{
+		Network: 99,
+		Addr:    []byte{1, 2},
+		Port:    6000,
+	}, NetAddr{
+		Network: NetAddrIPv4,
+		Addr:    []byte{1, 2, 3},
+		Port:    6000,
+	})
+	gam.c = mc
+	gam.process(d)
+	d.AssertExpectations(t)
+
+	// Peers are ignored if pex is disabled
+	d = &mockDaemoner{}
+	d.On("pexConfig").Return(pex.Config{Disabled: true})
+	gam.process(d)
+	d.AssertExpectations(t)
+	d.AssertNotCalled(t, "addGossipedPeers", addr, mock.Anything)
+}
+
 func setupMsgEncoding() {
 	gnet.EraseMessages()
 	var messagesConfig = NewMessagesConfig()
 	messagesConfig.Register()
 }
+
+func TestGetHeadersMessageProcess(t *testing.T) {
+	d := &mockDaemoner{}
+
+	m := &GetHeadersMessage{
+		LastBlock: 7,
+		// request more headers than MaxGetHeadersResponseCount to verify capping
+		RequestedHeaders: 100,
+		c: &gnet.MessageContext{
+			ConnID: 10,
+			Addr:   "127.0.0.1:1234",
+		},
+	}
+
+	config := DaemonConfig{
+		DisableNetworking:          false,
+		MaxGetHeadersResponseCount: 20,
+		MaxOutgoingMessageLength:   1024,
+	}
+
+	blocks := make([]coin.SignedBlock, 20)
+	headers := make([]SignedBlockHeader, 20)
+	for i := range blocks {
+		blocks[i].Block.Head.BkSeq = uint64(8 + i)
+		blocks[i].Block.Body.Transactions = coin.Transactions{{}}
+		headers[i] = NewSignedBlockHeader(blocks[i])
+	}
+
+	// The headers are truncated to fit in the message
+	ghm := NewGiveHeadersMessage(headers, config.MaxOutgoingMessageLength)
+	require.True(t, len(ghm.Headers) < len(headers), "headers should be truncated")
+	require.Equal(t, uint64(8), ghm.Headers[0].Header.BkSeq)
+
+	d.On("DaemonConfig").Return(config)
+	d.On("recordPeerHeight", "127.0.0.1:1234", uint64(10), uint64(7)).Return()
+	d.On("getSignedBlockHeadersSince", uint64(7), uint64(20)).Return(headers, nil)
+	d.On("sendMessage", "127.0.0.1:1234", ghm).Return(nil)
+
+	m.process(d)
+
+	d.AssertExpectations(t)
+}
@@ -48,13 +48,13 @@ func (_m *mockDaemoner) Disconnect(addr string, r gnet.DisconnectReason) error {
 	return r0
 }
 
-// addPeers provides a mock function with given fields: addrs
-func (_m *mockDaemoner) addPeers(addrs []string) int {
-	ret := _m.Called(addrs)
+// addGossipedPeers provides a mock function with given fields: source, addrs
+func (_m *mockDaemoner) addGossipedPeers(source string, addrs []pex.GossipAddr) int {
+	ret := _m.Called(source, addrs)
 
 	var r0 int
-	if rf, ok := ret.Get(0).(func([]string) int); ok {
-		r0 = rf(addrs)
+	if rf, ok := ret.Get(0).(func(string, []pex.GossipAddr) int); ok {
+		r0 = rf(source, addrs)
 	} else {
 		r0 = ret.Get(0).(int)
 	}
@@ -62,6 +62,11 @@ func (_m *mockDaemoner) addPeers(addrs []string) int {
 	return r0
 }
 
+// adjustPeerScore provides a mock function with given fields: addr, b
+func (_m *mockDaemoner) adjustPeerScore(addr string, b peerBehavior) {
+	_m.Called(addr, b)
+}
+
 // announceAllValidTxns provides a mock function with given fields:
 func (_m *mockDaemoner) announceAllValidTxns() error {
 	ret := _m.Called()
@@ -196,6 +201,29 @@ func (_m *mockDaemoner) getKnownUnconfirmed(txns []cipher.SHA256) (coin.Transact
 	return r0, r1
 }
 
+// getSignedBlockByHash provides a mock function with given fields: hash
+func (_m *mockDaemoner) getSignedBlockByHash(hash cipher.SHA256) (*coin.SignedBlock, error) {
+	ret := _m.Called(hash)
+
+	var r0 *coin.SignedBlock
+	if rf, ok := ret.Get(0).(func(cipher.SHA256) *coin.SignedBlock); ok {
+		r0 = rf(hash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*coin.SignedBlock)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(cipher.SHA256) error); ok {
+		r1 = rf(hash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // getSignedBlocksSince provides a mock function with given fields: seq, count
 func (_m *mockDaemoner) getSignedBlocksSince(seq uint64, count uint64) ([]coin.SignedBlock, error) {
 	ret := _m.Called(seq, count)
@@ -219,6 +247,29 @@ func (_m *mockDaemoner) getSignedBlocksSince(seq uint64, count uint64) ([]coin.S
 	return r0, r1
 }
 
+// getSignedBlockHeadersSince provides a mock function with given fields: seq, count
+func (_m *mockDaemoner) getSignedBlockHeadersSince(seq uint64, count uint64) ([]SignedBlockHeader, error) {
+	ret := _m.Called(seq, count)
+
+	var r0 []SignedBlockHeader
+	if rf, ok := ret.Get(0).(func(uint64, uint64) []SignedBlockHeader); ok {
+		r0 = rf(seq, count)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]SignedBlockHeader)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(uint64, uint64) error); ok {
+		r1 = rf(seq, count)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // headBkSeq provides a mock function with given fields:
 func (_m *mockDaemoner) headBkSeq() (uint64, bool, error) {
 	ret := _m.Called()
@@ -291,6 +342,64 @@ func (_m *mockDaemoner) pexConfig() pex.Config {
 	return r0
 }
 
+// receiveBackfillBlocks provides a mock function with given fields: addr, blocks
+func (_m *mockDaemoner) receiveBackfillBlocks(addr string, blocks []coin.SignedBlock) bool {
+	ret := _m.Called(addr, blocks)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string, []coin.SignedBlock) bool); ok {
+		r0 = rf(addr, blocks)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// receiveBlockTxns provides a mock function with given fields: addr, hash, txns
+func (_m *mockDaemoner) receiveBlockTxns(addr string, hash cipher.SHA256, txns coin.Transactions) {
+	_m.Called(addr, hash, txns)
+}
+
+// receiveCompactBlock provides a mock function with given fields: addr, m
+func (_m *mockDaemoner) receiveCompactBlock(addr string, m *CompactBlockMessage) {
+	_m.Called(addr, m)
+}
+
+// receiveHeaders provides a mock function with given fields: addr, gnetID, headers
+func (_m *mockDaemoner) receiveHeaders(addr string, gnetID uint64, headers []SignedBlockHeader) {
+	_m.Called(addr, gnetID, headers)
+}
+
+// receivePrunedBlocks provides a mock function with given fields: addr, gnetID, lowestSeq
+func (_m *mockDaemoner) receivePrunedBlocks(addr string, gnetID uint64, lowestSeq uint64) {
+	_m.Called(addr, gnetID, lowestSeq)
+}
+
+// receiveStemTransaction provides a mock function with given fields: addr, txn
+func (_m *mockDaemoner) receiveStemTransaction(addr string, txn coin.Transaction) {
+	_m.Called(addr, txn)
+}
+
+// receiveSyncBlocks provides a mock function with given fields: addr, blocks
+func (_m *mockDaemoner) receiveSyncBlocks(addr string, blocks []coin.SignedBlock) bool {
+	ret := _m.Called(addr, blocks)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string, []coin.SignedBlock) bool); ok {
+		r0 = rf(addr, blocks)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// recordBlocksResponse provides a mock function with given fields: addr
+func (_m *mockDaemoner) recordBlocksResponse(addr string) {
+	_m.Called(addr)
+}
+
 // recordMessageEvent provides a mock function with given fields: m, c
 func (_m *mockDaemoner) recordMessageEvent(m asyncMessage, c *gnet.MessageContext) error {
 	ret := _m.Called(m, c)
@@ -338,6 +447,11 @@ func (_m *mockDaemoner) sendMessage(addr string, msg gnet.Message) error {
 	return r0
 }
 
+// sendPrunedBlocks provides a mock function with given fields: addr
+func (_m *mockDaemoner) sendPrunedBlocks(addr string) {
+	_m.Called(addr)
+}
+
 // sendRandomPeers provides a mock function with given fields: addr
 func (_m *mockDaemoner) sendRandomPeers(addr string) error {
 	ret := _m.Called(addr)
@@ -351,3 +465,13 @@ func (_m *mockDaemoner) sendRandomPeers(addr string) error {
 
 	return r0
 }
+
+// txnsFluffed provides a mock function with given fields: hashes
+func (_m *mockDaemoner) txnsFluffed(hashes []cipher.SHA256) {
+	_m.Called(hashes)
+}
+
+// verifyBlockSignatures provides a mock function with given fields: blocks
+func (_m *mockDaemoner) verifyBlockSignatures(blocks []coin.SignedBlock) {
+	_m.Called(blocks)
+}
@@ -0,0 +1,243 @@
+package pex
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/skycoin/skycoin/src/util/file"
+	"github.com/skycoin/skycoin/src/util/iputil"
+)
+
+const (
+	// BanCacheFilename filename for disk-cached bans, saved alongside the peers cache
+	BanCacheFilename = "bans.json"
+)
+
+var (
+	// ErrNotBanned is returned when unbanning an IP that is not banned
+	ErrNotBanned = errors.New("IP is not banned")
+	// ErrInvalidIP is returned when an IP appears malformed
+	ErrInvalidIP = errors.New("Invalid IP")
+)
+
+// Ban is a banned peer IP. All of the peers with this IP are refused until the ban expires
+type Ban struct {
+	IP        string // The banned IP
+	Reason    string // Why the IP was banned
+	CreatedAt int64  // Unix timestamp when the ban was created
+	ExpiresAt int64  // Unix timestamp when the ban expires
+}
+
+// Expired returns true if the ban has expired at time t
+func (b Ban) Expired(t time.Time) bool {
+	return t.Unix() >= b.ExpiresAt
+}
+
+// banIP returns the IP of an ip or ip:port address.
+// Peers with an onion or DNS hostname are banned by their hostname
+func banIP(addr string) (string, error) {
+	ip := addr
+	if strings.Contains(addr, ":") {
+		var err error
+		ip, _, err = iputil.SplitAddr(addr)
+		if err != nil {
+			return "", ErrInvalidIP
+		}
+	}
+
+	if net.ParseIP(ip) == nil {
+		host, err := validateHostname(ip)
+		if err != nil {
+			return "", ErrInvalidIP
+		}
+		return host, nil
+	}
+
+	return ip, nil
+}
+
+// loadBansFile loads bans from the cached bans.json file
+func loadBansFile(path string) (map[string]Ban, error) {
+	var bans []Ban
+	if err := file.LoadJSON(path, &bans); err != nil {
+		switch {
+		case os.IsNotExist(err):
+			logger.WithField("path", path).Info("File does not exist")
+			return nil, nil
+		case err == io.EOF:
+			logger.WithField("path", path).Error("Corrupt or empty file")
+			return nil, nil
+		default:
+			return nil, err
+		}
+	}
+
+	now := time.Now().UTC()
+	m := make(map[string]Ban, len(bans))
+	for _, b := range bans {
+		ip, err := banIP(b.IP)
+		if err != nil {
+			logger.WithError(err).WithField("ip", b.IP).Error("Invalid IP in bans JSON file")
+			continue
+		}
+
+		if b.Expired(now) {
+			continue
+		}
+
+		b.IP = ip
+		m[ip] = b
+	}
+
+	return m, nil
+}
+
+func (px *Pex) loadBans() error {
+	px.Lock()
+	defer px.Unlock()
+
+	fp := filepath.Join(px.Config.DataDirectory, BanCacheFilename)
+	bans, err := loadBansFile(fp)
+	if err != nil {
+		logger.WithField("path", fp).WithError(err).Error("Failed to load bans file")
+		return err
+	}
+
+	if bans != nil {
+		px.bans = bans
+	}
+
+	return nil
+}
+
+// saveBans saves the bans to <DataDirectory>/<BanCacheFilename>. The caller must hold the lock
+func (px *Pex) saveBans() error {
+	fn := filepath.Join(px.Config.DataDirectory, BanCacheFilename)
+	if err := file.SaveJSON(fn, px.sortedBans(), 0600); err != nil {
+		return fmt.Errorf("save bans failed: %v", err)
+	}
+	return nil
+}
+
+// sortedBans returns the unexpired bans sorted by IP. The caller must hold the lock
+func (px *Pex) sortedBans() []Ban {
+	now := time.Now().UTC()
+	bans := make([]Ban, 0, len(px.bans))
+	for _, b := range px.bans {
+		if !b.Expired(now) {
+			bans = append(bans, b)
+		}
+	}
+
+	sort.Slice(bans, func(i, j int) bool {
+		return bans[i].IP < bans[j].IP
+	})
+
+	return bans
+}
+
+// isBanned returns true if the IP of addr is banned. The caller must hold the lock
+func (px *Pex) isBanned(addr string) bool {
+	ip, err := banIP(addr)
+	if err != nil {
+		return false
+	}
+
+	b, ok := px.bans[ip]
+	return ok && !b.Expired(time.Now().UTC())
+}
+
+// notBanned filters peers with a banned IP. The caller must hold the lock
+func (px *Pex) notBanned(p Peer) bool {
+	return !px.isBanned(p.Addr)
+}
+
+// Ban bans the IP of addr for the duration. addr can be an ip or an ip:port address.
+// If the IP is already banned, the ban is replaced
+func (px *Pex) Ban(addr string, duration time.Duration, reason string) error {
+	px.Lock()
+	defer px.Unlock()
+
+	ip, err := banIP(addr)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	px.bans[ip] = Ban{
+		IP:        ip,
+		Reason:    reason,
+		CreatedAt: now.Unix(),
+		ExpiresAt: now.Add(duration).Unix(),
+	}
+
+	return px.saveBans()
+}
+
+// Unban removes the ban of the IP of addr. Returns ErrNotBanned if the IP is not banned
+func (px *Pex) Unban(addr string) error {
+	px.Lock()
+	defer px.Unlock()
+
+	ip, err := banIP(addr)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := px.bans[ip]; !ok {
+		return ErrNotBanned
+	}
+
+	delete(px.bans, ip)
+	return px.saveBans()
+}
+
+// UnbanAll removes all bans
+func (px *Pex) UnbanAll() error {
+	px.Lock()
+	defer px.Unlock()
+
+	px.bans = make(map[string]Ban)
+	return px.saveBans()
+}
+
+// IsBanned returns true if the IP of addr is banned. addr can be an ip or an ip:port address
+func (px *Pex) IsBanned(addr string) bool {
+	px.RLock()
+	defer px.RUnlock()
+	return px.isBanned(addr)
+}
+
+// Bans returns the unexpired bans, sorted by IP
+func (px *Pex) Bans() []Ban {
+	px.RLock()
+	defer px.RUnlock()
+	return px.sortedBans()
+}
+
+// clearExpiredBans removes the expired bans
+func (px *Pex) clearExpiredBans() error {
+	px.Lock()
+	defer px.Unlock()
+
+	now := time.Now().UTC()
+	n := len(px.bans)
+	for ip, b := range px.bans {
+		if b.Expired(now) {
+			delete(px.bans, ip)
+		}
+	}
+
+	if len(px.bans) == n {
+		return nil
+	}
+
+	return px.saveBans()
+}
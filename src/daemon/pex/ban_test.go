@@ -0,0 +1,97 @@
+package pex
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/util/file"
+)
+
+func TestPexBan(t *testing.T) {
+	dir, removeDir := preparePeerlistDir(t)
+	defer removeDir()
+
+	cfg := NewConfig()
+	cfg.DataDirectory = dir
+	cfg.DefaultConnections = []string{}
+
+	px, err := New(cfg)
+	require.NoError(t, err)
+
+	peers := []string{"112.32.32.14:7200", "112.32.32.14:7300", "113.32.32.14:7200"}
+	require.Equal(t, len(peers), px.AddPeers(peers))
+
+	err = px.Ban("112.32.32.14:7200", time.Hour, "Sent an invalid block")
+	require.NoError(t, err)
+	err = px.Ban("not an ip", time.Hour, "")
+	require.Equal(t, ErrInvalidIP, err)
+
+	// All of the ports of the banned IP are banned
+	require.True(t, px.IsBanned("112.32.32.14"))
+	require.True(t, px.IsBanned("112.32.32.14:7300"))
+	require.False(t, px.IsBanned("113.32.32.14:7200"))
+
+	bans := px.Bans()
+	require.Len(t, bans, 1)
+	require.Equal(t, "112.32.32.14", bans[0].IP)
+	require.Equal(t, "Sent an invalid block", bans[0].Reason)
+	require.Equal(t, bans[0].CreatedAt+3600, bans[0].ExpiresAt)
+
+	// Banned peers are not returned for connecting or exchanging, and can't be added
+	require.Equal(t, []string{"113.32.32.14:7200"}, px.Random(0).ToAddrs())
+	require.Equal(t, ErrBlacklistedAddress, px.AddPeer("112.32.32.14:7400"))
+	require.Equal(t, 0, px.AddPeers([]string{"112.32.32.14:7400"}))
+
+	// The bans are persisted and loaded again
+	var saved []Ban
+	err = file.LoadJSON(filepath.Join(dir, BanCacheFilename), &saved)
+	require.NoError(t, err)
+	require.Equal(t, bans, saved)
+
+	px2, err := New(cfg)
+	require.NoError(t, err)
+	require.Equal(t, bans, px2.Bans())
+
+	err = px.Unban("113.32.32.14")
+	require.Equal(t, ErrNotBanned, err)
+	err = px.Unban("112.32.32.14")
+	require.NoError(t, err)
+	require.False(t, px.IsBanned("112.32.32.14:7200"))
+	require.Empty(t, px.Bans())
+
+	require.NoError(t, px.Ban("112.32.32.14", time.Hour, ""))
+	require.NoError(t, px.Ban("113.32.32.14", time.Hour, ""))
+	require.NoError(t, px.UnbanAll())
+	require.Empty(t, px.Bans())
+
+	px2, err = New(cfg)
+	require.NoError(t, err)
+	require.Empty(t, px2.Bans())
+}
+
+func TestPexClearExpiredBans(t *testing.T) {
+	dir, removeDir := preparePeerlistDir(t)
+	defer removeDir()
+
+	cfg := NewConfig()
+	cfg.DataDirectory = dir
+	cfg.DefaultConnections = []string{}
+
+	px, err := New(cfg)
+	require.NoError(t, err)
+
+	require.NoError(t, px.Ban("112.32.32.14", time.Hour, ""))
+	require.NoError(t, px.Ban("113.32.32.14", -time.Second, ""))
+
+	// Expired bans are ignored, then removed
+	require.False(t, px.IsBanned("113.32.32.14"))
+	require.Len(t, px.Bans(), 1)
+	require.Len(t, px.bans, 2)
+
+	require.NoError(t, px.clearExpiredBans())
+	require.Len(t, px.bans, 1)
+	require.True(t, px.IsBanned("112.32.32.14"))
+}
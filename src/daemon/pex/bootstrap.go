@@ -0,0 +1,135 @@
+package pex
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+const (
+	// peerListSignaturePrefix prefixes the signature line of a signed peers list
+	peerListSignaturePrefix = "# signature:"
+	// dnsSeedTimeout is the timeout for resolving a DNS seed
+	dnsSeedTimeout = time.Second * 30
+)
+
+var (
+	// ErrPeerListUnsigned is returned when a downloaded peers list is not signed but a signature is required
+	ErrPeerListUnsigned = errors.New("Peers list is not signed")
+	// ErrPeerListInvalidSignature is returned when the signature of a downloaded peers list is invalid
+	ErrPeerListInvalidSignature = errors.New("Peers list signature is invalid")
+)
+
+// lookupHost resolves a hostname to IP addresses. It is replaced by tests with a local resolver stub
+var lookupHost = net.DefaultResolver.LookupHost
+
+// resolveDNSSeeds resolves the DNS seeds and adds the returned addresses to the peer list
+func (px *Pex) resolveDNSSeeds() {
+	for _, seed := range px.Config.DNSSeeds {
+		addrs, err := resolveDNSSeed(seed, px.Config.DNSSeedPort)
+		if err != nil {
+			logger.WithError(err).WithField("seed", seed).Error("Failed to resolve DNS seed")
+			continue
+		}
+
+		n := px.AddPeers(addrs)
+		logger.WithField("seed", seed).Infof("Added %d/%d peers from DNS seed", n, len(addrs))
+	}
+}
+
+// resolveDNSSeed resolves a DNS seed to peer addresses. The seed is a hostname, optionally with a port.
+// If the seed has no port, defaultPort is used
+func resolveDNSSeed(seed string, defaultPort uint16) ([]string, error) {
+	host := seed
+	port := strconv.Itoa(int(defaultPort))
+	if strings.Contains(seed, ":") {
+		var err error
+		host, port, err = net.SplitHostPort(seed)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dnsSeedTimeout)
+	defer cancel()
+
+	ips, err := lookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs := make([]string, len(ips))
+	for i, ip := range ips {
+		addrs[i] = net.JoinHostPort(ip, port)
+	}
+
+	return addrs, nil
+}
+
+// SignPeerList appends the signature line to a peers list body.
+// The signature is of the SHA256 hash of the body, which must end with a newline
+func SignPeerList(body string, seckey cipher.SecKey) (string, error) {
+	if body != "" && !strings.HasSuffix(body, "\n") {
+		body += "\n"
+	}
+
+	sig, err := cipher.SignHash(cipher.SumSHA256([]byte(body)), seckey)
+	if err != nil {
+		return "", err
+	}
+
+	return body + peerListSignaturePrefix + " " + sig.Hex() + "\n", nil
+}
+
+// splitPeerListSignature splits a peers list body into the signed content and the signature.
+// The signature is the last non-empty line, "# signature: <hex>".
+// If the body is not signed, the body is returned with a null signature
+func splitPeerListSignature(body string) (string, cipher.Sig, bool, error) {
+	trimmed := strings.TrimRight(body, " \t\r\n")
+	i := strings.LastIndex(trimmed, "\n") + 1
+	line := strings.TrimSpace(trimmed[i:])
+
+	if !strings.HasPrefix(line, peerListSignaturePrefix) {
+		return body, cipher.Sig{}, false, nil
+	}
+
+	sig, err := cipher.SigFromHex(strings.TrimSpace(strings.TrimPrefix(line, peerListSignaturePrefix)))
+	if err != nil {
+		return "", cipher.Sig{}, false, err
+	}
+
+	return trimmed[:i], sig, true, nil
+}
+
+// verifyPeerList checks the signature of a downloaded peers list and returns the peers list without the signature.
+// If no pubkey is configured, the signature is not checked.
+// If a pubkey is configured, a signed list must be signed by it, and an unsigned list is rejected if RequireSignedPeerList is set
+func (px *Pex) verifyPeerList(body string) (string, error) {
+	content, sig, signed, err := splitPeerListSignature(body)
+	if err != nil {
+		return "", ErrPeerListInvalidSignature
+	}
+
+	if px.Config.PeerListPubKey.Null() {
+		return content, nil
+	}
+
+	if !signed {
+		if px.Config.RequireSignedPeerList {
+			return "", ErrPeerListUnsigned
+		}
+		logger.WithField("url", px.Config.PeerListURL).Warning("Downloaded peers list is not signed")
+		return content, nil
+	}
+
+	if err := cipher.VerifyPubKeySignedHash(px.Config.PeerListPubKey, sig, cipher.SumSHA256([]byte(content))); err != nil {
+		return "", ErrPeerListInvalidSignature
+	}
+
+	return content, nil
+}
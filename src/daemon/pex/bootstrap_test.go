@@ -0,0 +1,289 @@
+package pex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+// stubLookupHost replaces lookupHost with a resolver of the records, and returns a function to restore it
+func stubLookupHost(records map[string][]string) func() {
+	lookup := lookupHost
+	lookupHost = func(ctx context.Context, host string) ([]string, error) {
+		ips, ok := records[host]
+		if !ok {
+			return nil, fmt.Errorf("lookup %s: no such host", host)
+		}
+		return ips, nil
+	}
+	return func() {
+		lookupHost = lookup
+	}
+}
+
+func TestResolveDNSSeed(t *testing.T) {
+	defer stubLookupHost(map[string][]string{
+		"seed.example.com":  {"11.22.33.44", "55.66.77.88"},
+		"seed6.example.com": {"2001:db8::1"},
+	})()
+
+	cases := []struct {
+		name  string
+		seed  string
+		addrs []string
+		err   error
+	}{
+		{
+			name:  "default port",
+			seed:  "seed.example.com",
+			addrs: []string{"11.22.33.44:6000", "55.66.77.88:6000"},
+		},
+		{
+			name:  "seed port",
+			seed:  "seed.example.com:7000",
+			addrs: []string{"11.22.33.44:7000", "55.66.77.88:7000"},
+		},
+		{
+			name:  "ipv6",
+			seed:  "seed6.example.com",
+			addrs: []string{"[2001:db8::1]:6000"},
+		},
+		{
+			name: "unknown host",
+			seed: "unknown.example.com",
+			err:  errors.New("lookup unknown.example.com: no such host"),
+		},
+		{
+			name: "invalid seed",
+			seed: "seed.example.com:7000:8000",
+			err:  errors.New("address seed.example.com:7000:8000: too many colons in address"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			addrs, err := resolveDNSSeed(tc.seed, 6000)
+			if tc.err != nil {
+				require.EqualError(t, err, tc.err.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.addrs, addrs)
+		})
+	}
+}
+
+func TestPexResolveDNSSeeds(t *testing.T) {
+	defer stubLookupHost(map[string][]string{
+		"seed1.example.com": {"11.22.33.44", "127.0.0.1"},
+		"seed2.example.com": {"55.66.77.88"},
+	})()
+
+	dir, err := ioutil.TempDir("", "peerlist")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	config := NewConfig()
+	config.DataDirectory = dir
+	config.NetworkDisabled = true
+	config.DNSSeeds = []string{"seed1.example.com", "unknown.example.com", "seed2.example.com:7000"}
+	config.DNSSeedPort = 6000
+
+	px, err := New(config)
+	require.NoError(t, err)
+
+	px.resolveDNSSeeds()
+
+	// Localhost addresses returned by a seed are not added
+	var addrs []string
+	for _, p := range px.peerlist.getPeers(nil) {
+		addrs = append(addrs, p.Addr)
+	}
+	sort.Strings(addrs)
+	require.Equal(t, []string{"11.22.33.44:6000", "55.66.77.88:7000"}, addrs)
+}
+
+func TestSplitPeerListSignature(t *testing.T) {
+	_, seckey := cipher.GenerateKeyPair()
+
+	body := "11.22.33.44:5555\n66.55.44.33:2020\n"
+	signed, err := SignPeerList(body, seckey)
+	require.NoError(t, err)
+
+	content, sig, ok, err := splitPeerListSignature(signed)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, body, content)
+	require.False(t, sig.Null())
+
+	// Trailing empty lines after the signature are ignored
+	content, _, ok, err = splitPeerListSignature(signed + "\n\n")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, body, content)
+
+	// A body without a trailing newline is signed with one
+	signed2, err := SignPeerList("11.22.33.44:5555\n66.55.44.33:2020", seckey)
+	require.NoError(t, err)
+	content, _, ok, err = splitPeerListSignature(signed2)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, body, content)
+
+	content, _, ok, err = splitPeerListSignature(body)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Equal(t, body, content)
+
+	_, _, _, err = splitPeerListSignature(body + "# signature: abcd\n")
+	require.Error(t, err)
+}
+
+func TestVerifyPeerList(t *testing.T) {
+	pubkey, seckey := cipher.GenerateKeyPair()
+	_, otherSeckey := cipher.GenerateKeyPair()
+
+	body := "11.22.33.44:5555\n66.55.44.33:2020\n"
+
+	signed, err := SignPeerList(body, seckey)
+	require.NoError(t, err)
+
+	signedByOther, err := SignPeerList(body, otherSeckey)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name       string
+		body       string
+		pubkey     cipher.PubKey
+		requireSig bool
+		content    string
+		err        error
+	}{
+		{
+			name:    "no pubkey, unsigned",
+			body:    body,
+			content: body,
+		},
+		{
+			name:    "no pubkey, signed",
+			body:    signed,
+			content: body,
+		},
+		{
+			name:    "signed",
+			body:    signed,
+			pubkey:  pubkey,
+			content: body,
+		},
+		{
+			name:       "signed, signature required",
+			body:       signed,
+			pubkey:     pubkey,
+			requireSig: true,
+			content:    body,
+		},
+		{
+			name:    "unsigned, signature not required",
+			body:    body,
+			pubkey:  pubkey,
+			content: body,
+		},
+		{
+			name:       "unsigned, signature required",
+			body:       body,
+			pubkey:     pubkey,
+			requireSig: true,
+			err:        ErrPeerListUnsigned,
+		},
+		{
+			name:   "signed by another key",
+			body:   signedByOther,
+			pubkey: pubkey,
+			err:    ErrPeerListInvalidSignature,
+		},
+		{
+			name:   "modified after signing",
+			body:   "1.2.3.4:6000\n" + signed,
+			pubkey: pubkey,
+			err:    ErrPeerListInvalidSignature,
+		},
+		{
+			name:   "malformed signature",
+			body:   body + "# signature: abcd\n",
+			pubkey: pubkey,
+			err:    ErrPeerListInvalidSignature,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			px := &Pex{
+				Config: Config{
+					PeerListPubKey:        tc.pubkey,
+					RequireSignedPeerList: tc.requireSig,
+				},
+			}
+
+			content, err := px.verifyPeerList(tc.body)
+			if tc.err != nil {
+				require.Equal(t, tc.err, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.content, content)
+		})
+	}
+}
+
+func TestPexDownloadSignedPeers(t *testing.T) {
+	pubkey, seckey := cipher.GenerateKeyPair()
+
+	signed, err := SignPeerList("11.22.33.44:5555\n66.55.44.33:2020\n", seckey)
+	require.NoError(t, err)
+
+	body := signed
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, body)
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "peerlist")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	config := NewConfig()
+	config.DataDirectory = dir
+	config.PeerListURL = srv.URL
+	config.PeerListPubKey = pubkey
+	config.RequireSignedPeerList = true
+
+	px, err := New(config)
+	require.NoError(t, err)
+
+	// An unsigned peers list is rejected
+	body = "1.2.3.4:6000\n"
+	err = px.downloadPeers()
+	require.Equal(t, ErrPeerListUnsigned, err)
+	require.Equal(t, 0, px.peerlist.len())
+
+	// A signed peers list is accepted
+	body = signed
+	err = px.downloadPeers()
+	require.NoError(t, err)
+	require.Equal(t, 2, px.peerlist.len())
+
+	// RequireSignedPeerList requires PeerListPubKey
+	config.PeerListPubKey = cipher.PubKey{}
+	_, err = New(config)
+	require.EqualError(t, err, "RequireSignedPeerList requires PeerListPubKey")
+}
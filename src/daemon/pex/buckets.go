@@ -0,0 +1,215 @@
+package pex
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The peerlist is split in two tables, like the address manager of bitcoin.
+// The new table holds the addresses that we have not connected to. An address is placed in a bucket
+// chosen by its /16 address group and by the address group of the peer that sent it to us,
+// so that a single source can fill only newBucketsPerSourceGroup buckets of the new table.
+// The tried table holds the addresses that we have successfully connected to. An address is placed in a bucket
+// chosen by its address group, so that a single /16 subnet can fill only triedBucketsPerGroup buckets.
+// Random peers are chosen from random buckets rather than uniformly, which limits the share of
+// outgoing connections that an attacker with many addresses in a few subnets can obtain.
+const (
+	// newBucketCount is the number of buckets of the new table
+	newBucketCount = 1024
+	// triedBucketCount is the number of buckets of the tried table
+	triedBucketCount = 256
+	// bucketSize is the maximum number of untrusted peers in a bucket
+	bucketSize = 64
+	// newBucketsPerSourceGroup is the number of new table buckets that the addresses from one source address group can be placed in
+	newBucketsPerSourceGroup = 64
+	// triedBucketsPerGroup is the number of tried table buckets that the addresses of one address group can be placed in
+	triedBucketsPerGroup = 8
+	// terriblePeerAge is the age after which a peer that was not seen can be evicted from a full bucket
+	terriblePeerAge = time.Hour * 24 * 7
+	// maxGossipTimestampDrift is the maximum time in the future of a gossiped timestamp
+	maxGossipTimestampDrift = time.Minute * 10
+	// gossipTimestampPenalty is subtracted from the timestamps of the gossiped addresses,
+	// so that addresses relayed by peers are considered older than addresses that we have seen ourselves
+	gossipTimestampPenalty = time.Hour * 2
+	// invalidGossipTimestampAge is the age given to gossiped addresses with a timestamp in the future
+	invalidGossipTimestampAge = time.Hour * 24 * 5
+)
+
+// AddrGroup returns the address group of an ip:port address: the /16 subnet of an IPv4 address
+// or the /32 subnet of an IPv6 address. Onion addresses are grouped by the first character of their name,
+// because an attacker can create many onion addresses cheaply. The group of a DNS hostname is the hostname.
+// If the address can't be parsed, the address itself is returned.
+func AddrGroup(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		if host == "" {
+			return addr
+		}
+		if IsOnion(host) {
+			return "onion/" + strings.ToLower(host[:1])
+		}
+		return strings.ToLower(host)
+	}
+
+	if ip4 := ip.To4(); ip4 != nil {
+		return (&net.IPNet{
+			IP:   ip4.Mask(net.CIDRMask(16, 32)),
+			Mask: net.CIDRMask(16, 32),
+		}).String()
+	}
+
+	return (&net.IPNet{
+		IP:   ip.Mask(net.CIDRMask(32, 128)),
+		Mask: net.CIDRMask(32, 128),
+	}).String()
+}
+
+// bucketKey identifies a bucket of the new or tried table
+type bucketKey struct {
+	tried bool
+	index int
+}
+
+// buckets indexes the addresses of the peers in the buckets of the new and tried tables
+type buckets struct {
+	// key is a secret that randomizes the bucket placement, so that an attacker can't
+	// choose addresses that fill a specific bucket
+	key     [32]byte
+	buckets map[bucketKey]map[string]struct{}
+}
+
+func newBuckets() buckets {
+	var key [32]byte
+	if _, err := rand.Read(key[:]); err != nil {
+		logger.Panicf("crypto/rand.Read failed: %v", err)
+	}
+
+	return buckets{
+		key:     key,
+		buckets: make(map[bucketKey]map[string]struct{}),
+	}
+}
+
+// hash returns a keyed hash of strings
+func (b *buckets) hash(parts ...string) uint64 {
+	data := append([]byte{}, b.key[:]...)
+	for _, p := range parts {
+		var n [4]byte
+		binary.LittleEndian.PutUint32(n[:], uint32(len(p)))
+		data = append(data, n[:]...)
+		data = append(data, p...)
+	}
+
+	h := sha256.Sum256(data)
+	return binary.LittleEndian.Uint64(h[:8])
+}
+
+// sourceGroup returns the address group of the source of a peer.
+// Peers from local sources (default and custom peers, downloaded peers and incoming connections) are their own source.
+func sourceGroup(p Peer) string {
+	if p.Source == "" {
+		return AddrGroup(p.Addr)
+	}
+	return p.Source
+}
+
+// bucketOf returns the bucket of a peer in the table it belongs to
+func (b *buckets) bucketOf(p Peer) bucketKey {
+	group := AddrGroup(p.Addr)
+
+	if p.Tried {
+		i := b.hash(p.Addr) % triedBucketsPerGroup
+		return bucketKey{
+			tried: true,
+			index: int(b.hash(group, strconv.FormatUint(i, 10)) % triedBucketCount),
+		}
+	}
+
+	src := sourceGroup(p)
+	i := b.hash(group, src) % newBucketsPerSourceGroup
+	return bucketKey{
+		index: int(b.hash(src, strconv.FormatUint(i, 10)) % newBucketCount),
+	}
+}
+
+func (b *buckets) add(p Peer) {
+	k := b.bucketOf(p)
+	if b.buckets[k] == nil {
+		b.buckets[k] = make(map[string]struct{})
+	}
+	b.buckets[k][p.Addr] = struct{}{}
+}
+
+func (b *buckets) remove(p Peer) {
+	k := b.bucketOf(p)
+	delete(b.buckets[k], p.Addr)
+	if len(b.buckets[k]) == 0 {
+		delete(b.buckets, k)
+	}
+}
+
+// get returns the addresses in a bucket
+func (b *buckets) get(k bucketKey) map[string]struct{} {
+	return b.buckets[k]
+}
+
+// isTerrible returns true if a peer can be evicted from a full bucket by a gossiped address
+func isTerrible(p Peer, now time.Time) bool {
+	if p.Trusted {
+		return false
+	}
+
+	if p.RetryTimes >= MaxPeerRetryTimes {
+		return true
+	}
+
+	lastSeen := time.Unix(p.LastSeen, 0)
+	return now.Sub(lastSeen) > terriblePeerAge || lastSeen.Sub(now) > maxGossipTimestampDrift
+}
+
+// gossipTimestamp returns the LastSeen of a gossiped address from its gossiped timestamp.
+// A zero timestamp means that the address was gossiped without a timestamp and is considered seen by the source now.
+func gossipTimestamp(ts int64, now time.Time) int64 {
+	t := now
+	if ts != 0 {
+		t = time.Unix(ts, 0)
+		if ts < 0 || t.Sub(now) > maxGossipTimestampDrift {
+			t = now.Add(-invalidGossipTimestampAge)
+		}
+	}
+
+	return t.Add(-gossipTimestampPenalty).Unix()
+}
+
+// sourceAllowance limits the number of gossiped addresses accepted from a source
+type sourceAllowance struct {
+	tokens  float64
+	updated time.Time
+}
+
+// take refills the allowance and takes up to n tokens from it, returning the number of tokens taken
+func (a *sourceAllowance) take(n int, max int, rate time.Duration, now time.Time) int {
+	if rate > 0 {
+		a.tokens += float64(now.Sub(a.updated)) / float64(rate)
+	}
+	if a.tokens > float64(max) {
+		a.tokens = float64(max)
+	}
+	a.updated = now
+
+	if float64(n) > a.tokens {
+		n = int(a.tokens)
+	}
+	a.tokens -= float64(n)
+	return n
+}
@@ -0,0 +1,450 @@
+package pex
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddrGroup(t *testing.T) {
+	tt := []struct {
+		addr  string
+		group string
+	}{
+		{"112.32.32.14:7200", "112.32.0.0/16"},
+		{"112.32.200.1:6000", "112.32.0.0/16"},
+		{"112.33.32.14:7200", "112.33.0.0/16"},
+		{"127.0.0.1:6000", "127.0.0.0/16"},
+		{"[2001:db8:1:2::1]:6000", "2001:db8::/32"},
+		{"112.32.32.14", "112.32.0.0/16"},
+		{"seed.Example.com:6000", "seed.example.com"},
+		{"pg6mmjiyjmcrsslvykfwnntlaru7p5svn6y2ymmju6nubxndf4pscryd.onion:6000", "onion/p"},
+		{":6000", ":6000"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.addr, func(t *testing.T) {
+			require.Equal(t, tc.group, AddrGroup(tc.addr))
+		})
+	}
+}
+
+func TestGossipTimestamp(t *testing.T) {
+	now := time.Now().UTC()
+
+	// No timestamp, the address is considered seen by the source now
+	require.Equal(t, now.Add(-gossipTimestampPenalty).Unix(), gossipTimestamp(0, now))
+
+	ts := now.Add(-time.Hour).Unix()
+	require.Equal(t, ts-int64(gossipTimestampPenalty/time.Second), gossipTimestamp(ts, now))
+
+	// Timestamps slightly in the future are allowed
+	ts = now.Add(time.Minute).Unix()
+	require.Equal(t, ts-int64(gossipTimestampPenalty/time.Second), gossipTimestamp(ts, now))
+
+	// Timestamps far in the future or negative are invalid
+	invalid := now.Add(-invalidGossipTimestampAge - gossipTimestampPenalty).Unix()
+	require.Equal(t, invalid, gossipTimestamp(now.Add(time.Hour).Unix(), now))
+	require.Equal(t, invalid, gossipTimestamp(-1, now))
+}
+
+func TestSourceAllowance(t *testing.T) {
+	now := time.Now()
+	a := &sourceAllowance{
+		tokens:  10,
+		updated: now,
+	}
+
+	require.Equal(t, 4, a.take(4, 10, time.Second, now))
+	require.Equal(t, 6, a.take(8, 10, time.Second, now))
+	require.Equal(t, 0, a.take(1, 10, time.Second, now))
+
+	// The allowance refills at the rate, up to the max
+	require.Equal(t, 3, a.take(5, 10, time.Second, now.Add(time.Second*3)))
+	require.Equal(t, 10, a.take(20, 10, time.Second, now.Add(time.Hour)))
+}
+
+// bucketTestAddrs returns n addresses in the /16 address group a.b
+func bucketTestAddrs(a, b, n int) []string {
+	addrs := make([]string, n)
+	for i := range addrs {
+		addrs[i] = fmt.Sprintf("%d.%d.%d.%d:6000", a, b, i/250, i%250+1)
+	}
+	return addrs
+}
+
+func TestPeerlistAddGossipedPeer(t *testing.T) {
+	now := time.Now().UTC()
+	pl := newPeerlist()
+
+	source := "200.1.1.1:6000"
+	addr := testPeers[0]
+	lastSeen := now.Add(-time.Hour).Unix()
+
+	require.True(t, pl.addGossipedPeer(addr, source, lastSeen, now))
+	p, ok := pl.getPeer(addr)
+	require.True(t, ok)
+	require.Equal(t, Peer{
+		Addr:     addr,
+		LastSeen: lastSeen,
+		Source:   "200.1.0.0/16",
+	}, p)
+
+	// The LastSeen of a known peer only moves forward
+	require.False(t, pl.addGossipedPeer(addr, source, lastSeen-100, now))
+	p, _ = pl.getPeer(addr)
+	require.Equal(t, lastSeen, p.LastSeen)
+
+	require.False(t, pl.addGossipedPeer(addr, "201.1.1.1:6000", lastSeen+100, now))
+	p, _ = pl.getPeer(addr)
+	require.Equal(t, lastSeen+100, p.LastSeen)
+	require.Equal(t, "200.1.0.0/16", p.Source)
+
+	// The addresses of an address group from a source group fill a single bucket
+	pl = newPeerlist()
+	var added int
+	for _, a := range bucketTestAddrs(66, 66, 1000) {
+		if pl.addGossipedPeer(a, source, lastSeen, now) {
+			added++
+		}
+	}
+	require.Equal(t, bucketSize, added)
+	require.Equal(t, added, pl.len())
+	require.Len(t, pl.buckets.buckets, 1)
+
+	// The addresses from a source group fill a limited number of buckets
+	for i := 0; i < 200; i++ {
+		for _, a := range bucketTestAddrs(100, i, 100) {
+			pl.addGossipedPeer(a, source, lastSeen, now)
+		}
+	}
+	require.True(t, len(pl.buckets.buckets) <= newBucketsPerSourceGroup)
+	for _, addrs := range pl.buckets.buckets {
+		require.True(t, len(addrs) <= bucketSize)
+	}
+
+	// A full bucket only accepts a gossiped peer if it can evict a terrible peer
+	k := pl.buckets.bucketOf(Peer{Addr: "66.66.0.1:6000", Source: AddrGroup(source)})
+	require.Len(t, pl.buckets.get(k), bucketSize)
+
+	extra := "66.66.200.1:6000"
+	require.False(t, pl.hasPeer(extra))
+	require.False(t, pl.addGossipedPeer(extra, source, lastSeen, now))
+
+	var old string
+	for a := range pl.buckets.get(k) {
+		old = a
+		break
+	}
+	pl.peers[old].LastSeen = now.Add(-terriblePeerAge - time.Hour).Unix()
+	require.True(t, pl.addGossipedPeer(extra, source, lastSeen, now))
+	require.False(t, pl.hasPeer(old))
+	require.Len(t, pl.buckets.get(k), bucketSize)
+
+	// A peer from a local source evicts the oldest peer of a full bucket
+	var oldest string
+	for a := range pl.buckets.get(k) {
+		oldest = a
+		break
+	}
+	pl.peers[oldest].LastSeen = now.Add(-time.Hour * 3).Unix()
+	require.True(t, pl.makeRoom(k, true, now))
+	require.False(t, pl.hasPeer(oldest))
+	require.Len(t, pl.buckets.get(k), bucketSize-1)
+}
+
+func TestPeerlistMarkTried(t *testing.T) {
+	now := time.Now().UTC()
+	pl := newPeerlist()
+
+	require.Error(t, pl.markTried(testPeers[0], now))
+
+	pl.addPeer(testPeers[0])
+	newKey := pl.buckets.bucketOf(*pl.peers[testPeers[0]])
+	require.False(t, newKey.tried)
+
+	require.NoError(t, pl.markTried(testPeers[0], now))
+	p, ok := pl.getPeer(testPeers[0])
+	require.True(t, ok)
+	require.True(t, p.Tried)
+
+	triedKey := pl.buckets.bucketOf(p)
+	require.True(t, triedKey.tried)
+	require.Contains(t, pl.buckets.get(triedKey), testPeers[0])
+	require.NotContains(t, pl.buckets.get(newKey), testPeers[0])
+
+	// Marking a tried peer again does nothing
+	require.NoError(t, pl.markTried(testPeers[0], now))
+	require.Equal(t, 1, pl.len())
+
+	// A single address group fills a limited number of tried buckets.
+	// Peers evicted from a full tried bucket go back to the new table
+	pl = newPeerlist()
+	addrs := bucketTestAddrs(66, 66, 2000)
+	for _, a := range addrs {
+		pl.addPeer(a)
+		require.NoError(t, pl.markTried(a, now))
+	}
+	require.True(t, pl.len() <= (triedBucketsPerGroup+1)*bucketSize)
+
+	var tried int
+	for k, bucket := range pl.buckets.buckets {
+		if k.tried {
+			require.True(t, len(bucket) <= bucketSize)
+			tried += len(bucket)
+		}
+	}
+	require.True(t, tried <= triedBucketsPerGroup*bucketSize)
+	for _, p := range pl.peers {
+		require.Equal(t, p.Tried, pl.buckets.bucketOf(*p).tried)
+		require.Contains(t, pl.buckets.get(pl.buckets.bucketOf(*p)), p.Addr)
+	}
+
+	// Removing a peer removes it from its bucket
+	for _, a := range addrs {
+		pl.removePeer(a)
+	}
+	require.Empty(t, pl.buckets.buckets)
+}
+
+func TestPeerlistRandomBuckets(t *testing.T) {
+	now := time.Now().UTC()
+	pl := newPeerlist()
+
+	// One peer in the tried table and many peers from one source in the new table
+	pl.addPeer(testPeers[0])
+	require.NoError(t, pl.markTried(testPeers[0], now))
+	for _, a := range bucketTestAddrs(66, 66, 1000) {
+		pl.addGossipedPeer(a, "66.66.200.1:6000", now.Unix(), now)
+	}
+
+	// The tried peer is chosen about half of the time
+	var triedChosen int
+	for i := 0; i < 1000; i++ {
+		ps := pl.random(1, nil)
+		require.Len(t, ps, 1)
+		if ps[0].Addr == testPeers[0] {
+			triedChosen++
+		}
+	}
+	require.True(t, triedChosen > 350 && triedChosen < 650, "triedChosen=%d", triedChosen)
+
+	// All of the peers are returned once
+	ps := pl.random(0, nil)
+	require.Len(t, ps, pl.len())
+	seen := make(map[string]struct{}, len(ps))
+	for _, p := range ps {
+		seen[p.Addr] = struct{}{}
+	}
+	require.Len(t, seen, pl.len())
+}
+
+func TestPexAddGossipedPeers(t *testing.T) {
+	dir, removeDir := preparePeerlistDir(t)
+	defer removeDir()
+
+	cfg := NewConfig()
+	cfg.DataDirectory = dir
+	cfg.MaxSourceAddrs = 100
+	cfg.SourceAddrsRate = time.Second
+	px, err := New(cfg)
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	source := "200.1.1.1:6000"
+
+	gossip := func(addrs []string, ts int64) []GossipAddr {
+		ga := make([]GossipAddr, len(addrs))
+		for i, a := range addrs {
+			ga[i] = GossipAddr{
+				Addr:      a,
+				Timestamp: ts,
+			}
+		}
+		return ga
+	}
+
+	// Invalid and banned addresses are skipped
+	require.NoError(t, px.Ban(testPeers[1], time.Hour, "test"))
+	n := px.addGossipedPeers(source, gossip([]string{testPeers[0], testPeers[1], wrongPortPeer}, now.Unix()), now)
+	require.Equal(t, 1, n)
+	p, ok := px.GetPeer(testPeers[0])
+	require.True(t, ok)
+	require.Equal(t, now.Add(-gossipTimestampPenalty).Unix(), p.LastSeen)
+	require.Equal(t, "200.1.0.0/16", p.Source)
+	require.False(t, px.peerlist.hasPeer(testPeers[1]))
+
+	// The number of addresses accepted from a source is limited.
+	// The addresses are spread over many address groups so that the buckets don't fill up
+	addrs := make([]string, 0, 2000)
+	for i := 0; i < 200; i++ {
+		addrs = append(addrs, bucketTestAddrs(100+i%100, 1+i/100, 10)...)
+	}
+	n = px.addGossipedPeers(source, gossip(addrs[:500], 0), now)
+	require.Equal(t, 99, n)
+
+	// The limit is per source IP
+	n = px.addGossipedPeers("200.1.1.1:6001", gossip(addrs[500:1000], 0), now)
+	require.Equal(t, 0, n)
+	n = px.addGossipedPeers("200.1.1.2:6000", gossip(addrs[500:1000], 0), now)
+	require.Equal(t, 100, n)
+
+	// The allowance of the source refills over time
+	n = px.addGossipedPeers(source, gossip(addrs[1000:1500], 0), now.Add(time.Second*10))
+	require.Equal(t, 10, n)
+
+	// Refilled allowances are cleared
+	require.Len(t, px.sources, 2)
+	px.clearSources(now.Add(time.Second * 50))
+	require.Len(t, px.sources, 2)
+	px.clearSources(now.Add(time.Second * 200))
+	require.Empty(t, px.sources)
+
+	// Peers are not added if the peer list is full
+	px.Config.Max = px.peerlist.len()
+	n = px.addGossipedPeers("201.1.1.1:6000", gossip(addrs[1500:], 0), now)
+	require.Equal(t, 0, n)
+}
+
+// eclipseSimulation gossips the addresses of honest peers and of an attacker to a Pex,
+// to measure the share of the outgoing connection candidates that the attacker obtains
+type eclipseSimulation struct {
+	px  *Pex
+	now time.Time
+	// honest are the addresses of the honest peers, tried are the ones that we connected to
+	honest map[string]struct{}
+	tried  []string
+	// attacker are the addresses of the attacker, sources are the attacker peers that gossip them
+	attacker        map[string]struct{}
+	attackerSources []string
+}
+
+func newEclipseSimulation(t *testing.T, px *Pex) *eclipseSimulation {
+	sim := &eclipseSimulation{
+		px:       px,
+		now:      time.Now().UTC(),
+		honest:   make(map[string]struct{}),
+		attacker: make(map[string]struct{}),
+	}
+
+	// 50 honest sources in different subnets each gossip 20 addresses in different subnets
+	for i := 0; i < 50; i++ {
+		source := fmt.Sprintf("%d.%d.1.1:6000", 10+i/200, i%200+1)
+		addrs := make([]GossipAddr, 20)
+		for j := range addrs {
+			addr := fmt.Sprintf("%d.%d.2.1:6000", 20+i, j+1)
+			sim.honest[addr] = struct{}{}
+			addrs[j] = GossipAddr{
+				Addr:      addr,
+				Timestamp: sim.now.Unix(),
+			}
+		}
+		require.Equal(t, len(addrs), px.addGossipedPeers(source, addrs, sim.now))
+	}
+
+	// We connected to some of the honest peers
+	for addr := range sim.honest {
+		require.NoError(t, px.peerlist.markTried(addr, sim.now))
+		sim.tried = append(sim.tried, addr)
+		if len(sim.tried) == 30 {
+			break
+		}
+	}
+
+	// The attacker controls 4 subnets and 8 peers in them
+	for i := 0; i < 8; i++ {
+		sim.attackerSources = append(sim.attackerSources, fmt.Sprintf("66.%d.1.%d:6000", 66+i%4, i+1))
+	}
+
+	return sim
+}
+
+// attack makes each attacker source gossip new attacker addresses every minute for a duration
+func (sim *eclipseSimulation) attack(d time.Duration) {
+	end := sim.now.Add(d)
+	var next int
+	for ; sim.now.Before(end); sim.now = sim.now.Add(time.Minute) {
+		for _, source := range sim.attackerSources {
+			addrs := make([]GossipAddr, 512)
+			for i := range addrs {
+				addr := fmt.Sprintf("66.%d.%d.%d:6000", 66+next%4, (next/4/250)%250, next/4%250+1)
+				next++
+				sim.attacker[addr] = struct{}{}
+				addrs[i] = GossipAddr{
+					Addr:      addr,
+					Timestamp: sim.now.Unix(),
+				}
+			}
+			sim.px.addGossipedPeers(source, addrs, sim.now)
+		}
+	}
+}
+
+// attackerShare returns the share of the attacker peers in the peer list and in the peers chosen by Random
+func (sim *eclipseSimulation) attackerShare(samples, n int) (float64, float64) {
+	var known int
+	for addr := range sim.px.peerlist.peers {
+		if _, ok := sim.attacker[addr]; ok {
+			known++
+		}
+	}
+
+	var chosen, total int
+	for i := 0; i < samples; i++ {
+		for _, p := range sim.px.Random(n) {
+			total++
+			if _, ok := sim.attacker[p.Addr]; ok {
+				chosen++
+			}
+		}
+	}
+
+	return float64(known) / float64(sim.px.peerlist.len()), float64(chosen) / float64(total)
+}
+
+func TestPexEclipseSimulation(t *testing.T) {
+	dir, removeDir := preparePeerlistDir(t)
+	defer removeDir()
+
+	cfg := NewConfig()
+	cfg.DataDirectory = dir
+	px, err := New(cfg)
+	require.NoError(t, err)
+
+	sim := newEclipseSimulation(t, px)
+	sim.attack(time.Hour)
+
+	// The attacker sent far more addresses than the per source limit allows
+	require.True(t, len(sim.attacker) > len(sim.attackerSources)*(cfg.MaxSourceAddrs+int(time.Hour/cfg.SourceAddrsRate)))
+
+	// The attacker addresses fill one new bucket per pair of address group and source group
+	attackerBuckets := make(map[bucketKey]struct{})
+	var attackerPeers int
+	for addr := range sim.attacker {
+		if p, ok := px.peerlist.peers[addr]; ok {
+			attackerBuckets[px.peerlist.buckets.bucketOf(*p)] = struct{}{}
+			attackerPeers++
+		}
+	}
+	require.True(t, len(attackerBuckets) <= 4*4)
+	require.True(t, attackerPeers <= 4*4*bucketSize)
+
+	// The attacker obtains a share of the outgoing connection candidates much smaller
+	// than its share of the peer list, which a uniform choice would give it
+	known, chosen := sim.attackerShare(200, 8)
+	t.Logf("attacker share: peer list %.2f, chosen %.2f", known, chosen)
+	require.True(t, known > 0.3, "known=%.2f", known)
+	require.True(t, chosen < known/3, "known=%.2f chosen=%.2f", known, chosen)
+
+	// No honest peer was evicted
+	for addr := range sim.honest {
+		require.True(t, px.peerlist.hasPeer(addr), addr)
+	}
+	for _, addr := range sim.tried {
+		p, ok := px.GetPeer(addr)
+		require.True(t, ok)
+		require.True(t, p.Tried)
+	}
+}
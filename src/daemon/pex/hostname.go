@@ -0,0 +1,113 @@
+package pex
+
+import (
+	"encoding/base32"
+	"errors"
+	"strings"
+)
+
+const (
+	// onionSuffix is the top level domain of Tor onion services
+	onionSuffix = ".onion"
+	// onionV3Len is the length of the name of a version 3 onion service, without the suffix
+	onionV3Len = 56
+	// OnionV3AddrLen is the length of the decoded name of a version 3 onion service:
+	// the 32 byte public key, a 2 byte checksum and the version byte
+	OnionV3AddrLen = 35
+	// onionV3Version is the version byte of a version 3 onion service name
+	onionV3Version = 3
+	// maxHostnameLen is the maximum length of a DNS hostname
+	maxHostnameLen = 253
+	// maxHostnameLabelLen is the maximum length of a label of a DNS hostname
+	maxHostnameLabelLen = 63
+)
+
+var (
+	// ErrInvalidOnion is returned when an onion address is not a valid version 3 onion service name
+	ErrInvalidOnion = errors.New("Invalid onion address")
+
+	onionEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+)
+
+// IsOnion returns true if the host of a host:port address is an onion address
+func IsOnion(addr string) bool {
+	host := addr
+	if i := strings.LastIndex(addr, ":"); i != -1 {
+		host = addr[:i]
+	}
+	return strings.HasSuffix(strings.ToLower(host), onionSuffix)
+}
+
+// DecodeOnion decodes the name of a version 3 onion service, e.g. "<56 characters>.onion"
+func DecodeOnion(host string) ([]byte, error) {
+	host = strings.ToLower(host)
+	if !strings.HasSuffix(host, onionSuffix) {
+		return nil, ErrInvalidOnion
+	}
+
+	name := strings.TrimSuffix(host, onionSuffix)
+	if len(name) != onionV3Len {
+		return nil, ErrInvalidOnion
+	}
+
+	b, err := onionEncoding.DecodeString(strings.ToUpper(name))
+	if err != nil {
+		return nil, ErrInvalidOnion
+	}
+
+	// The checksum is a SHA3 hash, which is not checked.
+	// An address with an invalid checksum fails to connect through Tor
+	if len(b) != OnionV3AddrLen || b[OnionV3AddrLen-1] != onionV3Version {
+		return nil, ErrInvalidOnion
+	}
+
+	return b, nil
+}
+
+// EncodeOnion encodes the decoded name of a version 3 onion service to its hostname
+func EncodeOnion(b []byte) (string, error) {
+	if len(b) != OnionV3AddrLen || b[OnionV3AddrLen-1] != onionV3Version {
+		return "", ErrInvalidOnion
+	}
+
+	return strings.ToLower(onionEncoding.EncodeToString(b)) + onionSuffix, nil
+}
+
+// validateHostname returns the lowercased hostname if it is a valid onion address or fully qualified DNS hostname.
+// The last label of a DNS hostname must contain a letter, so that malformed IP addresses are not hostnames.
+func validateHostname(host string) (string, error) {
+	host = strings.ToLower(host)
+
+	if strings.HasSuffix(host, onionSuffix) {
+		if _, err := DecodeOnion(host); err != nil {
+			return "", err
+		}
+		return host, nil
+	}
+
+	if len(host) > maxHostnameLen {
+		return "", ErrInvalidAddress
+	}
+
+	labels := strings.Split(host, ".")
+	if len(labels) < 2 {
+		return "", ErrInvalidAddress
+	}
+
+	for _, l := range labels {
+		if len(l) == 0 || len(l) > maxHostnameLabelLen || l[0] == '-' || l[len(l)-1] == '-' {
+			return "", ErrInvalidAddress
+		}
+		for _, c := range l {
+			if !(c >= 'a' && c <= 'z') && !(c >= '0' && c <= '9') && c != '-' {
+				return "", ErrInvalidAddress
+			}
+		}
+	}
+
+	if !strings.ContainsAny(labels[len(labels)-1], "abcdefghijklmnopqrstuvwxyz") {
+		return "", ErrInvalidAddress
+	}
+
+	return host, nil
+}
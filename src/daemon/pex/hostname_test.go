@@ -0,0 +1,80 @@
+package pex
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeOnion(t *testing.T) {
+	host := "pg6mmjiyjmcrsslvykfwnntlaru7p5svn6y2ymmju6nubxndf4pscryd.onion"
+
+	b, err := DecodeOnion(host)
+	require.NoError(t, err)
+	require.Len(t, b, OnionV3AddrLen)
+	require.Equal(t, byte(onionV3Version), b[OnionV3AddrLen-1])
+
+	h, err := EncodeOnion(b)
+	require.NoError(t, err)
+	require.Equal(t, host, h)
+
+	b2, err := DecodeOnion(strings.ToUpper(host))
+	require.NoError(t, err)
+	require.Equal(t, b, b2)
+
+	for _, h := range []string{
+		"pg6mmjiyjmcrsslvykfwnntlaru7p5svn6y2ymmju6nubxndf4pscryd",
+		"expyuzz4wqqyqhjn.onion",
+		"pg6mmjiyjmcrsslvykfwnntlaru7p5svn6y2ymmju6nubxndf4pscry1.onion",
+		// Version byte 2
+		"53yghlfzzm2eoowfoow3mkwd2yvnn3nxw2bvc2v33sfstcdfzxnbenac.onion",
+	} {
+		_, err := DecodeOnion(h)
+		require.Equal(t, ErrInvalidOnion, err, h)
+	}
+
+	b[OnionV3AddrLen-1] = 2
+	_, err = EncodeOnion(b)
+	require.Equal(t, ErrInvalidOnion, err)
+
+	_, err = EncodeOnion(b[:32])
+	require.Equal(t, ErrInvalidOnion, err)
+}
+
+func TestIsOnion(t *testing.T) {
+	require.True(t, IsOnion("pg6mmjiyjmcrsslvykfwnntlaru7p5svn6y2ymmju6nubxndf4pscryd.onion:6000"))
+	require.True(t, IsOnion("foo.ONION"))
+	require.False(t, IsOnion("seed.example.com:6000"))
+	require.False(t, IsOnion("1.2.3.4:6000"))
+}
+
+func TestPexOnionPeers(t *testing.T) {
+	onion := "pg6mmjiyjmcrsslvykfwnntlaru7p5svn6y2ymmju6nubxndf4pscryd.onion:6000"
+	ip := "112.32.32.14:6000"
+
+	dir, removeDir := preparePeerlistDir(t)
+	defer removeDir()
+
+	cfg := NewConfig()
+	cfg.DataDirectory = dir
+	px, err := New(cfg)
+	require.NoError(t, err)
+
+	require.NoError(t, px.AddPeer(onion))
+	require.NoError(t, px.AddPeer(ip))
+
+	// Onion peers are exchanged, but are not connected to without a proxy
+	require.NoError(t, px.SetHasIncomingPort(onion, true))
+	require.Equal(t, []string{onion}, px.RandomExchangeable(0).ToAddrs())
+	require.Equal(t, []string{ip}, px.Random(0).ToAddrs())
+
+	px.Config.Proxy = "127.0.0.1:9050"
+	require.ElementsMatch(t, []string{onion, ip}, px.Random(0).ToAddrs())
+
+	// Onion peers are banned by their hostname
+	require.NoError(t, px.Ban(onion, time.Hour, "test"))
+	require.Equal(t, []string{ip}, px.Random(0).ToAddrs())
+	require.Equal(t, "pg6mmjiyjmcrsslvykfwnntlaru7p5svn6y2ymmju6nubxndf4pscryd.onion", px.Bans()[0].IP)
+}
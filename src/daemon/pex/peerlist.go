@@ -26,14 +26,16 @@ func (ps Peers) ToAddrs() []string {
 	return addrs
 }
 
-// peerlist is a map of addresses to *PeerStates
+// peerlist is a map of addresses to *PeerStates, indexed by the buckets of the new and tried tables
 type peerlist struct {
-	peers map[string]*Peer
+	peers   map[string]*Peer
+	buckets buckets
 }
 
 func newPeerlist() peerlist {
 	return peerlist{
-		peers: make(map[string]*Peer),
+		peers:   make(map[string]*Peer),
+		buckets: newBuckets(),
 	}
 }
 
@@ -90,18 +92,76 @@ func loadCachedPeersFile(path string) (map[string]*Peer, error) {
 	return peers, nil
 }
 
+// setPeers sets peers loaded from disk. The buckets are not limited, like the max number of peers,
+// so that no peer is lost if the bucket placement changed
 func (pl *peerlist) setPeers(peers []Peer) {
 	for _, p := range peers {
 		np := p
-		pl.peers[p.Addr] = &np
+		pl.insert(&np)
 	}
 }
 
+// insert adds a peer to the peer list and to its bucket, replacing an existing peer with the same address
+func (pl *peerlist) insert(p *Peer) {
+	if old, ok := pl.peers[p.Addr]; ok && old != nil {
+		pl.buckets.remove(*old)
+	}
+
+	pl.peers[p.Addr] = p
+	pl.buckets.add(*p)
+}
+
+// worstPeer returns the untrusted peer of a bucket that should be evicted first, and the number of untrusted peers in the bucket.
+// Terrible peers are evicted first, then the peers with the oldest LastSeen.
+func (pl *peerlist) worstPeer(k bucketKey, now time.Time) (*Peer, int) {
+	var worst *Peer
+	var n int
+	for addr := range pl.buckets.get(k) {
+		p := pl.peers[addr]
+		if p.Trusted {
+			continue
+		}
+		n++
+
+		if worst == nil {
+			worst = p
+			continue
+		}
+
+		pTerrible := isTerrible(*p, now)
+		worstTerrible := isTerrible(*worst, now)
+		if (pTerrible && !worstTerrible) || (pTerrible == worstTerrible && p.LastSeen < worst.LastSeen) {
+			worst = p
+		}
+	}
+
+	return worst, n
+}
+
+// makeRoom evicts a peer from a bucket if it is full. A terrible peer is evicted if there is one,
+// otherwise the peer with the oldest LastSeen is evicted only if evictAny is true.
+// Returns false if the bucket is still full.
+func (pl *peerlist) makeRoom(k bucketKey, evictAny bool, now time.Time) bool {
+	worst, n := pl.worstPeer(k, now)
+	if n < bucketSize {
+		return true
+	}
+
+	if !evictAny && !isTerrible(*worst, now) {
+		return false
+	}
+
+	pl.removePeer(worst.Addr)
+	return true
+}
+
 func (pl *peerlist) hasPeer(addr string) bool {
 	p, ok := pl.peers[addr]
 	return ok && p != nil
 }
 
+// addPeer adds a peer from a local source to the new table, or marks it as seen if it exists.
+// If the bucket of the peer is full, the worst peer of the bucket is evicted.
 func (pl *peerlist) addPeer(addr string) {
 	if p, ok := pl.peers[addr]; ok && p != nil {
 		p.Seen()
@@ -109,7 +169,8 @@ func (pl *peerlist) addPeer(addr string) {
 	}
 
 	peer := NewPeer(addr)
-	pl.peers[addr] = peer
+	pl.makeRoom(pl.buckets.bucketOf(*peer), true, time.Now().UTC())
+	pl.insert(peer)
 }
 
 func (pl *peerlist) addPeers(addrs []string) {
@@ -118,6 +179,62 @@ func (pl *peerlist) addPeers(addrs []string) {
 	}
 }
 
+// addGossipedPeer adds a peer received via PEX from a source to the new table.
+// The LastSeen of a known peer is only moved forward to lastSeen; its bucket does not change.
+// A new peer can only evict a terrible peer from a full bucket.
+// Returns true if the peer was added.
+func (pl *peerlist) addGossipedPeer(addr, source string, lastSeen int64, now time.Time) bool {
+	if p, ok := pl.peers[addr]; ok && p != nil {
+		if lastSeen > p.LastSeen {
+			p.LastSeen = lastSeen
+		}
+		return false
+	}
+
+	peer := &Peer{
+		Addr:     addr,
+		LastSeen: lastSeen,
+		Source:   AddrGroup(source),
+	}
+
+	if !pl.makeRoom(pl.buckets.bucketOf(*peer), false, now) {
+		return false
+	}
+
+	pl.insert(peer)
+	return true
+}
+
+// markTried moves a peer that we connected to into the tried table.
+// If its tried bucket is full, the worst peer of the bucket is moved back to the new table.
+func (pl *peerlist) markTried(addr string, now time.Time) error {
+	p, ok := pl.peers[addr]
+	if !ok {
+		return fmt.Errorf("mark peer tried failed: %v does not exist in peer list", addr)
+	}
+
+	if p.Tried {
+		return nil
+	}
+
+	pl.buckets.remove(*p)
+	p.Tried = true
+
+	k := pl.buckets.bucketOf(*p)
+	if worst, n := pl.worstPeer(k, now); n >= bucketSize {
+		pl.buckets.remove(*worst)
+		worst.Tried = false
+		if pl.makeRoom(pl.buckets.bucketOf(*worst), false, now) {
+			pl.buckets.add(*worst)
+		} else {
+			delete(pl.peers, worst.Addr)
+		}
+	}
+
+	pl.buckets.add(*p)
+	return nil
+}
+
 func (pl *peerlist) seen(addr string) {
 	if p, ok := pl.peers[addr]; ok && p != nil {
 		p.Seen()
@@ -177,6 +294,9 @@ var isExchangeable = []Filter{hasIncomingPort}
 
 // removePeer removes peer
 func (pl *peerlist) removePeer(addr string) {
+	if p, ok := pl.peers[addr]; ok && p != nil {
+		pl.buckets.remove(*p)
+	}
 	delete(pl.peers, addr)
 }
 
@@ -239,29 +359,68 @@ func (pl *peerlist) clearOld(timeAgo time.Duration) {
 	for addr, peer := range pl.peers {
 		lastSeen := time.Unix(peer.LastSeen, 0)
 		if !peer.Trusted && t.Sub(lastSeen) > timeAgo {
-			delete(pl.peers, addr)
+			pl.removePeer(addr)
 		}
 	}
 }
 
 // Returns n random peers, or all of the peers, whichever is lower.
 // If count is 0, all of the peers are returned, shuffled.
+// Each peer is chosen from the tried or new table with equal probability, then from a random bucket
+// of the table, so that the peers of a few subnets or sources are not chosen more often
+// than the peers of other buckets however many they are.
 func (pl *peerlist) random(count int, flts []Filter) Peers {
-	keys := pl.getCanTryPeers(flts).ToAddrs()
-	if len(keys) == 0 {
+	candidates := pl.getCanTryPeers(flts)
+	if len(candidates) == 0 {
 		return Peers{}
 	}
 
 	max := count
-	if max == 0 || max > len(keys) {
-		max = len(keys)
+	if max == 0 || max > len(candidates) {
+		max = len(candidates)
 	}
 
-	ps := make(Peers, max)
-	perm := rand.Perm(len(keys))
-	for i, j := range perm[:max] {
-		ps[i] = *pl.peers[keys[j]]
+	// Group the candidates by table and bucket
+	var tables [2][]Peers
+	bucketIndexes := make(map[bucketKey]int)
+	for _, p := range candidates {
+		k := pl.buckets.bucketOf(p)
+		t := 0
+		if k.tried {
+			t = 1
+		}
+
+		i, ok := bucketIndexes[k]
+		if !ok {
+			i = len(tables[t])
+			bucketIndexes[k] = i
+			tables[t] = append(tables[t], nil)
+		}
+		tables[t][i] = append(tables[t][i], p)
 	}
+
+	ps := make(Peers, 0, max)
+	for len(ps) < max {
+		t := rand.Intn(2)
+		if len(tables[t]) == 0 {
+			t = 1 - t
+		}
+
+		bs := tables[t]
+		i := rand.Intn(len(bs))
+		j := rand.Intn(len(bs[i]))
+		ps = append(ps, bs[i][j])
+
+		// Remove the chosen peer, and its bucket if it is empty
+		last := len(bs[i]) - 1
+		bs[i][j] = bs[i][last]
+		bs[i] = bs[i][:last]
+		if len(bs[i]) == 0 {
+			bs[i] = bs[len(bs)-1]
+			tables[t] = bs[:len(bs)-1]
+		}
+	}
+
 	return ps
 }
 
@@ -339,6 +498,8 @@ type PeerJSON struct {
 	HasIncomePort   *bool `json:"HasIncomePort,omitempty"` // Whether this peer has incoming port [DEPRECATED]
 	HasIncomingPort *bool // Whether this peer has incoming port
 	UserAgent       useragent.Data
+	Source          string // Address group of the peer that sent this peer via PEX
+	Tried           bool   // Whether this peer is in the tried table
 }
 
 // newPeerJSON returns a PeerJSON from a Peer
@@ -349,6 +510,8 @@ func newPeerJSON(p Peer) PeerJSON {
 		Trusted:         p.Trusted,
 		HasIncomingPort: &p.HasIncomingPort,
 		UserAgent:       p.UserAgent,
+		Source:          p.Source,
+		Tried:           p.Tried,
 	}
 }
 
@@ -392,5 +555,7 @@ func newPeerFromJSON(p PeerJSON) (*Peer, error) {
 		Trusted:         p.Trusted,
 		HasIncomingPort: hasIncomingPort,
 		UserAgent:       p.UserAgent,
+		Source:          p.Source,
+		Tried:           p.Tried,
 	}, nil
 }
@@ -2,6 +2,7 @@
 package pex
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -20,7 +21,9 @@ import (
 	"github.com/cenkalti/backoff"
 	"github.com/sirupsen/logrus"
 
+	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/util/logging"
+	"github.com/skycoin/skycoin/src/util/socks5"
 	"github.com/skycoin/skycoin/src/util/useragent"
 )
 
@@ -65,7 +68,8 @@ var (
 	whitespaceFilter = regexp.MustCompile(`\s`)
 )
 
-// validateAddress returns a sanitized address if valid, otherwise an error
+// validateAddress returns a sanitized address if valid, otherwise an error.
+// The host can be an IP address, an onion address or a fully qualified DNS hostname.
 func validateAddress(ipPort string, allowLocalhost bool) (string, error) {
 	ipPort = whitespaceFilter.ReplaceAllString(ipPort, "")
 	pts := strings.Split(ipPort, ":")
@@ -75,7 +79,12 @@ func validateAddress(ipPort string, allowLocalhost bool) (string, error) {
 
 	ip := net.ParseIP(pts[0])
 	if ip == nil {
-		return "", ErrInvalidAddress
+		host, err := validateHostname(pts[0])
+		if err != nil {
+			return "", err
+		}
+		pts[0] = host
+		ipPort = host + ":" + pts[1]
 	} else if ip.IsLoopback() {
 		if !allowLocalhost {
 			return "", ErrNoLocalhost
@@ -103,9 +112,18 @@ type Peer struct {
 	Trusted         bool           // Whether this peer is trusted
 	HasIncomingPort bool           // Whether this peer has accessible public port
 	UserAgent       useragent.Data // Peer's last reported user agent
+	Source          string         // Address group of the peer that sent this peer via PEX, empty for local sources
+	Tried           bool           // Whether this peer is in the tried table, after a successful outgoing connection
 	RetryTimes      int            `json:"-"` // records the retry times
 }
 
+// GossipAddr is an address received via PEX, with the unix timestamp when the sender last saw it.
+// A zero Timestamp means that the sender did not send a timestamp.
+type GossipAddr struct {
+	Addr      string
+	Timestamp int64
+}
+
 // NewPeer returns a *Peer initialized by an address string of the form ip:port
 func NewPeer(address string) *Peer {
 	p := &Peer{
@@ -182,12 +200,30 @@ type Config struct {
 	DownloadPeerList bool
 	// Download peers list from this URL
 	PeerListURL string
+	// Verify the signature of the downloaded peers list with this pubkey. Leave null to not verify
+	PeerListPubKey cipher.PubKey
+	// Reject a downloaded peers list that is not signed. Requires PeerListPubKey
+	RequireSignedPeerList bool
+	// Hostnames of DNS seeds, resolved to peer addresses on startup. A seed can be "host" or "host:port"
+	DNSSeeds []string
+	// Port of the peers returned by a DNS seed that has no port
+	DNSSeedPort uint16
 	// Set all peers as untrusted (even if loaded from DefaultConnections)
 	DisableTrustedPeers bool
 	// Load peers from this file on disk. NOTE: this is different from the peers file cache in the data directory
 	CustomPeersFile string
 	// Default "trusted" connections
 	DefaultConnections []string
+	// Maximum number of addresses accepted at once from a single PEX source
+	MaxSourceAddrs int
+	// A PEX source is allowed one more address on this interval, up to MaxSourceAddrs
+	SourceAddrsRate time.Duration
+	// Address of a SOCKS5 proxy that outgoing connections are made through. If set, the peers list is downloaded
+	// through the proxy and onion peers can be connected to. Onion peers are exchanged either way
+	Proxy string
+	// Username and password for the SOCKS5 proxy. Leave empty if the proxy does not require authentication
+	ProxyUsername string
+	ProxyPassword string
 }
 
 // NewConfig creates default pex config.
@@ -208,6 +244,8 @@ func NewConfig() Config {
 		PeerListURL:         DefaultPeerListURL,
 		DisableTrustedPeers: false,
 		CustomPeersFile:     "",
+		MaxSourceAddrs:      1000,
+		SourceAddrsRate:     time.Second,
 	}
 }
 
@@ -216,16 +254,26 @@ type Pex struct {
 	sync.RWMutex
 	// All known peers
 	peerlist peerlist
-	Config   Config
-	quit     chan struct{}
-	done     chan struct{}
+	// Banned IPs
+	bans map[string]Ban
+	// Allowances of gossiped addresses, by PEX source IP
+	sources map[string]*sourceAllowance
+	Config  Config
+	quit    chan struct{}
+	done    chan struct{}
 }
 
 // New creates pex
 func New(cfg Config) (*Pex, error) {
+	if cfg.RequireSignedPeerList && cfg.PeerListPubKey.Null() {
+		return nil, errors.New("RequireSignedPeerList requires PeerListPubKey")
+	}
+
 	pex := &Pex{
 		Config:   cfg,
 		peerlist: newPeerlist(),
+		bans:     make(map[string]Ban),
+		sources:  make(map[string]*sourceAllowance),
 		quit:     make(chan struct{}),
 		done:     make(chan struct{}),
 	}
@@ -236,6 +284,12 @@ func New(cfg Config) (*Pex, error) {
 		return nil, err
 	}
 
+	// Load bans from disk
+	if err := pex.loadBans(); err != nil {
+		logger.Critical().WithError(err).Error("pex.loadBans failed")
+		return nil, err
+	}
+
 	// Unset trusted status from any existing peers, regenerate
 	// them from the DefaultConnections
 	pex.setAllUntrusted()
@@ -280,6 +334,11 @@ func New(cfg Config) (*Pex, error) {
 		}()
 	}
 
+	// Resolve the DNS seeds if networking is enabled
+	if len(pex.Config.DNSSeeds) > 0 && !pex.Config.NetworkDisabled {
+		go pex.resolveDNSSeeds()
+	}
+
 	return pex, nil
 }
 
@@ -298,6 +357,9 @@ func (px *Pex) Run() error {
 	}()
 
 	clearOldTicker := time.NewTicker(px.Config.ClearOldRate)
+	defer clearOldTicker.Stop()
+	updateBlacklistTicker := time.NewTicker(px.Config.UpdateBlacklistRate)
+	defer updateBlacklistTicker.Stop()
 
 	for {
 		select {
@@ -308,8 +370,14 @@ func (px *Pex) Run() error {
 					px.Lock()
 					defer px.Unlock()
 					px.peerlist.clearOld(px.Config.Expiration)
+					px.clearSources(time.Now().UTC())
 				}()
 			}
+		case <-updateBlacklistTicker.C:
+			// Remove expired bans
+			if err := px.clearExpiredBans(); err != nil {
+				logger.WithError(err).Error("Clear expired bans failed")
+			}
 		case <-px.quit:
 			return nil
 		}
@@ -325,12 +393,23 @@ func (px *Pex) Shutdown() {
 }
 
 func (px *Pex) downloadPeers() error {
-	body, err := backoffDownloadText(px.Config.PeerListURL)
+	body, err := backoffDownloadText(px.Config.PeerListURL, socks5.Dialer{
+		Proxy:    px.Config.Proxy,
+		Username: px.Config.ProxyUsername,
+		Password: px.Config.ProxyPassword,
+		Timeout:  time.Second * 30,
+	})
 	if err != nil {
 		logger.WithError(err).WithField("url", px.Config.PeerListURL).Error("Failed to download peers")
 		return err
 	}
 
+	body, err = px.verifyPeerList(body)
+	if err != nil {
+		logger.WithError(err).WithField("url", px.Config.PeerListURL).Error("Rejected downloaded peers list")
+		return err
+	}
+
 	peers := parseRemotePeerList(body)
 	logger.WithField("url", px.Config.PeerListURL).Infof("Downloaded peers list, got %d peers", len(peers))
 
@@ -434,6 +513,10 @@ func (px *Pex) AddPeer(addr string) error {
 		return ErrInvalidAddress
 	}
 
+	if px.isBanned(cleanAddr) {
+		return ErrBlacklistedAddress
+	}
+
 	if px.peerlist.hasPeer(cleanAddr) {
 		px.peerlist.seen(cleanAddr)
 		return nil
@@ -480,6 +563,10 @@ func (px *Pex) AddPeers(addrs []string) int {
 			logger.WithField("addr", addr).WithError(err).Info("Add peers sees an invalid address")
 			continue
 		}
+		if px.isBanned(a) {
+			logger.WithField("addr", addr).Info("Add peers sees a banned address")
+			continue
+		}
 		validAddrs = append(validAddrs, a)
 	}
 	addrs = validAddrs
@@ -500,6 +587,114 @@ func (px *Pex) AddPeers(addrs []string) int {
 	return len(addrs)
 }
 
+// AddGossipedPeers adds peers received via PEX from a source peer to the new table.
+// The number of addresses accepted from the IP of the source is limited by MaxSourceAddrs and SourceAddrsRate.
+// The gossiped timestamps are penalized, so that relayed addresses are considered older than the addresses we saw,
+// and can't refresh known peers past that. Invalid addresses are logged, but not returned.
+// Returns the number of new peers that were added.
+func (px *Pex) AddGossipedPeers(source string, addrs []GossipAddr) int {
+	px.Lock()
+	defer px.Unlock()
+
+	return px.addGossipedPeers(source, addrs, time.Now().UTC())
+}
+
+func (px *Pex) addGossipedPeers(source string, addrs []GossipAddr, now time.Time) int {
+	if px.isFull() {
+		logger.Warning("Add gossiped peers failed, peer list is full")
+		return 0
+	}
+
+	// validate the addresses
+	validAddrs := make([]GossipAddr, 0, len(addrs))
+	for _, a := range addrs {
+		addr, err := validateAddress(a.Addr, px.Config.AllowLocalhost)
+		if err != nil {
+			logger.WithField("addr", a.Addr).WithError(err).Info("Add gossiped peers sees an invalid address")
+			continue
+		}
+		if px.isBanned(addr) {
+			logger.WithField("addr", a.Addr).Info("Add gossiped peers sees a banned address")
+			continue
+		}
+		a.Addr = addr
+		validAddrs = append(validAddrs, a)
+	}
+
+	// Shuffle the addresses before capping them
+	rand.Shuffle(len(validAddrs), func(i, j int) {
+		validAddrs[i], validAddrs[j] = validAddrs[j], validAddrs[i]
+	})
+
+	if px.Config.MaxSourceAddrs > 0 {
+		ip := sourceIP(source)
+		allowance, ok := px.sources[ip]
+		if !ok {
+			allowance = &sourceAllowance{
+				tokens:  float64(px.Config.MaxSourceAddrs),
+				updated: now,
+			}
+			px.sources[ip] = allowance
+		}
+
+		n := allowance.take(len(validAddrs), px.Config.MaxSourceAddrs, px.Config.SourceAddrsRate, now)
+		if n < len(validAddrs) {
+			logger.WithFields(logrus.Fields{
+				"source":   source,
+				"received": len(validAddrs),
+				"accepted": n,
+			}).Info("PEX source exceeded its address allowance")
+			validAddrs = validAddrs[:n]
+		}
+	}
+
+	var added int
+	for _, a := range validAddrs {
+		if px.isFull() {
+			break
+		}
+
+		if px.peerlist.addGossipedPeer(a.Addr, source, gossipTimestamp(a.Timestamp, now), now) {
+			added++
+		}
+	}
+
+	return added
+}
+
+// sourceIP returns the IP of a PEX source address
+func sourceIP(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+// clearSources removes the allowances of the PEX sources that have refilled
+func (px *Pex) clearSources(now time.Time) {
+	for ip, a := range px.sources {
+		a.take(0, px.Config.MaxSourceAddrs, px.Config.SourceAddrsRate, now)
+		if a.tokens >= float64(px.Config.MaxSourceAddrs) {
+			delete(px.sources, ip)
+		}
+	}
+}
+
+// MarkTried moves a peer into the tried table, after a successful outgoing connection
+func (px *Pex) MarkTried(addr string) error {
+	px.Lock()
+	defer px.Unlock()
+
+	cleanAddr, err := validateAddress(addr, px.Config.AllowLocalhost)
+	if err != nil {
+		logger.WithError(err).WithField("addr", addr).Error("Invalid address")
+		return ErrInvalidAddress
+	}
+
+	return px.peerlist.markTried(cleanAddr, time.Now().UTC())
+}
+
 // setTrusted marks a peer as a default peer by setting its trusted flag to true
 func (px *Pex) setTrusted(addr string) error {
 	px.Lock()
@@ -581,23 +776,28 @@ func (px *Pex) AllTrusted() Peers {
 func (px *Pex) Trusted() Peers {
 	px.RLock()
 	defer px.RUnlock()
-	return px.peerlist.getCanTryPeers([]Filter{isTrusted})
+	return px.peerlist.getCanTryPeers([]Filter{isTrusted, px.reachable})
 }
 
-// Random returns N random untrusted peers
+// Random returns N random untrusted peers that can be connected to
 func (px *Pex) Random(n int) Peers {
 	px.RLock()
 	defer px.RUnlock()
 	return px.peerlist.random(n, []Filter{func(p Peer) bool {
 		return !p.Trusted
-	}})
+	}, px.notBanned, px.reachable})
+}
+
+// reachable filters the peers that can be connected to: onion peers are reachable only through a proxy
+func (px *Pex) reachable(p Peer) bool {
+	return px.Config.Proxy != "" || !IsOnion(p.Addr)
 }
 
 // RandomExchangeable returns N random exchangeable peers
 func (px *Pex) RandomExchangeable(n int) Peers {
 	px.RLock()
 	defer px.RUnlock()
-	return px.peerlist.random(n, isExchangeable)
+	return px.peerlist.random(n, append([]Filter{px.notBanned}, isExchangeable...))
 }
 
 // IncreaseRetryTimes increases retry times
@@ -632,11 +832,22 @@ func (px *Pex) isFull() bool {
 	return px.Config.Max > 0 && px.peerlist.len() >= px.Config.Max
 }
 
-// downloadText downloads a text format file from url.
+// downloadText downloads a text format file from url, through a SOCKS5 proxy if proxy is not empty.
 // Returns the raw response body as a string.
 // TODO -- move to util, add backoff options
-func downloadText(url string) (string, error) {
-	resp, err := http.Get(url) //nolint:gosec
+func downloadText(url string, proxy socks5.Dialer) (string, error) {
+	client := http.DefaultClient
+	if proxy.Proxy != "" {
+		client = &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+					return proxy.Dial(addr)
+				},
+			},
+		}
+	}
+
+	resp, err := client.Get(url) //nolint:gosec
 	if err != nil {
 		return "", err
 	}
@@ -650,7 +861,7 @@ func downloadText(url string) (string, error) {
 	return string(body), nil
 }
 
-func backoffDownloadText(url string) (string, error) {
+func backoffDownloadText(url string, proxy socks5.Dialer) (string, error) {
 	var body string
 
 	b := backoff.NewExponentialBackOff()
@@ -662,7 +873,7 @@ func backoffDownloadText(url string) (string, error) {
 	operation := func() error {
 		logger.WithField("url", url).Info("Trying to download peers list")
 		var err error
-		body, err = downloadText(url)
+		body, err = downloadText(url, proxy)
 		return err
 	}
 
@@ -3,6 +3,8 @@ package pex
 import (
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
@@ -11,6 +13,8 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/skycoin/skycoin/src/util/file"
+	"github.com/skycoin/skycoin/src/util/socks5"
+	"github.com/skycoin/skycoin/src/util/socks5/socks5test"
 )
 
 func TestValidateAddress(t *testing.T) {
@@ -140,6 +144,45 @@ func TestValidateAddress(t *testing.T) {
 			allowLocalhost: false,
 			cleanAddr:      "11.22.33.44:8080",
 		},
+		{
+			addr:           "pg6mmjiyjmcrsslvykfwnntlaru7p5svn6y2ymmju6nubxndf4pscryd.onion:6000",
+			allowLocalhost: false,
+		},
+		{
+			addr:           "PG6MMJIYJMCRSSLVYKFWNNTLARU7P5SVN6Y2YMMJU6NUBXNDF4PSCRYD.onion:6000",
+			allowLocalhost: false,
+			cleanAddr:      "pg6mmjiyjmcrsslvykfwnntlaru7p5svn6y2ymmju6nubxndf4pscryd.onion:6000",
+		},
+		{
+			addr:           "pg6mmjiyjmcrsslvykfwnntlaru7p5svn6y2ymmju6nubxndf4pscryd.onion:100",
+			allowLocalhost: false,
+			err:            ErrPortTooLow,
+		},
+		{
+			addr:           "expyuzz4wqqyqhjn.onion:6000",
+			allowLocalhost: false,
+			err:            ErrInvalidOnion,
+		},
+		{
+			addr:           "seed.Example.com:6000",
+			allowLocalhost: false,
+			cleanAddr:      "seed.example.com:6000",
+		},
+		{
+			addr:           "localhost:6000",
+			allowLocalhost: true,
+			err:            ErrInvalidAddress,
+		},
+		{
+			addr:           "seed-.example.com:6000",
+			allowLocalhost: false,
+			err:            ErrInvalidAddress,
+		},
+		{
+			addr:           "seed_1.example.com:6000",
+			allowLocalhost: false,
+			err:            ErrInvalidAddress,
+		},
 	}
 
 	for _, tc := range cases {
@@ -1355,6 +1398,37 @@ func TestPexIsFull(t *testing.T) {
 	require.True(t, pex.IsFull())
 }
 
+func TestDownloadTextProxy(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "112.32.32.14:6000\n")
+	}))
+	defer srv.Close()
+
+	proxy, err := socks5test.NewServer()
+	require.NoError(t, err)
+	defer proxy.Close()
+	proxy.Username = "user"
+	proxy.Password = "pass"
+
+	body, err := downloadText(srv.URL, socks5.Dialer{
+		Proxy:    proxy.Addr(),
+		Username: "user",
+		Password: "pass",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "112.32.32.14:6000\n", body)
+	require.Equal(t, []string{srv.Listener.Addr().String()}, proxy.Destinations())
+
+	// The proxy rejects wrong credentials
+	_, err = downloadText(srv.URL, socks5.Dialer{
+		Proxy:    proxy.Addr(),
+		Username: "user",
+		Password: "wrong",
+	})
+	require.Error(t, err)
+	require.Len(t, proxy.Destinations(), 1)
+}
+
 func TestParseRemotePeerList(t *testing.T) {
 	body := `11.22.33.44:5555
 66.55.44.33:2020
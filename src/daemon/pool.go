@@ -36,6 +36,29 @@ type PoolConfig struct {
 	MaxIncomingMessageLength int
 	// Maximum length of outgoing messages in bytes
 	MaxOutgoingMessageLength int
+	// Encrypt the connections with peers that support the encrypted transport
+	EnableEncryption bool
+	// Reject the connections with peers that do not support the encrypted transport
+	RequireEncryption bool
+	// Timeout for the encrypted transport handshake
+	HandshakeTimeout time.Duration
+	// Number of records sent with an encryption key before the key is rotated
+	RekeyInterval uint64
+	// Address of a SOCKS5 proxy, e.g. Tor. Connections to onion peers are made through it,
+	// and all outgoing connections if ProxyOnly is set in the daemon config
+	Proxy string
+	// Username and password for the SOCKS5 proxy
+	ProxyUsername string
+	ProxyPassword string
+	// Only connections to onion peers are made through the proxy. Set in preprocess() from the daemon config
+	proxyOnlyOnion bool
+	// Maximum upload rate of all connections, in bytes per second. 0 means no limit
+	MaxUploadRate int64
+	// Maximum upload rate of each connection, in bytes per second. 0 means no limit
+	MaxPeerUploadRate int64
+	// Maximum bytes uploaded per day. Once reached, blocks and transactions are no longer sent
+	// until the day ends, only headers and announcements. 0 means no limit
+	MaxDailyUpload uint64
 	// These should be assigned by the controlling daemon
 	address string
 	port    int
@@ -59,6 +82,10 @@ func NewPoolConfig() PoolConfig {
 		MaxDefaultPeerOutgoingConnections: 2,
 		MaxOutgoingMessageLength:          256 * 1024,
 		MaxIncomingMessageLength:          1024 * 1024,
+		EnableEncryption:                  false,
+		RequireEncryption:                 false,
+		HandshakeTimeout:                  time.Second * 10,
+		RekeyInterval:                     4096,
 	}
 }
 
@@ -77,6 +104,7 @@ func NewPool(cfg PoolConfig, d *Daemon) (*Pool, error) {
 	gnetCfg.ConnectCallback = d.onGnetConnect
 	gnetCfg.DisconnectCallback = d.onGnetDisconnect
 	gnetCfg.ConnectFailureCallback = d.onGnetConnectFailure
+	gnetCfg.AcceptCallback = d.onGnetAccept
 	gnetCfg.MaxConnections = cfg.MaxConnections
 	gnetCfg.MaxOutgoingConnections = cfg.MaxOutgoingConnections
 	gnetCfg.MaxIncomingConnections = cfg.MaxIncomingConnections
@@ -84,6 +112,18 @@ func NewPool(cfg PoolConfig, d *Daemon) (*Pool, error) {
 	gnetCfg.DefaultConnections = cfg.DefaultConnections
 	gnetCfg.MaxIncomingMessageLength = cfg.MaxIncomingMessageLength
 	gnetCfg.MaxOutgoingMessageLength = cfg.MaxOutgoingMessageLength
+	gnetCfg.EnableEncryption = cfg.EnableEncryption
+	gnetCfg.RequireEncryption = cfg.RequireEncryption
+	gnetCfg.HandshakeTimeout = cfg.HandshakeTimeout
+	gnetCfg.RekeyInterval = cfg.RekeyInterval
+	gnetCfg.Proxy = cfg.Proxy
+	gnetCfg.ProxyUsername = cfg.ProxyUsername
+	gnetCfg.ProxyPassword = cfg.ProxyPassword
+	gnetCfg.ProxyOnlyOnion = cfg.proxyOnlyOnion
+	gnetCfg.MaxUploadRate = cfg.MaxUploadRate
+	gnetCfg.MaxPeerUploadRate = cfg.MaxPeerUploadRate
+	gnetCfg.MaxDailyUpload = cfg.MaxDailyUpload
+	gnetCfg.UploadCapAllowedMessages = uploadCapAllowedMessages
 
 	pool, err := gnet.NewConnectionPool(gnetCfg, d)
 	if err != nil {
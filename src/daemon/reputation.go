@@ -0,0 +1,255 @@
+package daemon
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/skycoin/skycoin/src/daemon/gnet"
+	"github.com/skycoin/skycoin/src/daemon/pex"
+	"github.com/skycoin/skycoin/src/util/iputil"
+)
+
+// maxPeerScore caps the reputation score that a peer can earn with useful behavior,
+// so that a long-lived peer can still be banned if it starts misbehaving
+const maxPeerScore = 50
+
+// peerBehavior is a change to the reputation score of a peer
+type peerBehavior struct {
+	score  int
+	reason string
+}
+
+var (
+	behaviorInvalidBlock       = peerBehavior{-50, "Sent an invalid block"}
+	behaviorInvalidTransaction = peerBehavior{-10, "Sent an invalid transaction"}
+	behaviorOversizeMessage    = peerBehavior{-50, "Sent an oversize message"}
+	behaviorProtocolViolation  = peerBehavior{-25, "Violated the protocol"}
+	behaviorSlowBlocksResponse = peerBehavior{-5, "Did not respond to a blocks request in time"}
+	behaviorUsefulBlock        = peerBehavior{1, "Sent a new block"}
+)
+
+// disconnectBehavior returns the behavior that caused a disconnect, if the peer misbehaved
+func disconnectBehavior(r gnet.DisconnectReason) (peerBehavior, bool) {
+	switch r {
+	case gnet.ErrDisconnectInvalidMessageLength:
+		return behaviorOversizeMessage, true
+	case gnet.ErrDisconnectMalformedMessage,
+		gnet.ErrDisconnectUnknownMessage,
+		gnet.ErrDisconnectMessageDecodeUnderflow,
+		gnet.ErrDisconnectTruncatedMessageID,
+		gnet.ErrDisconnectInvalidRecord,
+		ErrDisconnectNoIntroduction,
+		ErrDisconnectInvalidExtraData:
+		return behaviorProtocolViolation, true
+	default:
+		return peerBehavior{}, false
+	}
+}
+
+// peerReputation records the reputation scores of peers, keyed by IP,
+// and the blocks requests that peers have not responded to yet
+type peerReputation struct {
+	sync.Mutex
+	scores         map[string]int
+	blocksRequests map[string]time.Time
+}
+
+func newPeerReputation() *peerReputation {
+	return &peerReputation{
+		scores:         make(map[string]int),
+		blocksRequests: make(map[string]time.Time),
+	}
+}
+
+// adjust adds delta to the score of an IP and returns the new score
+func (r *peerReputation) adjust(ip string, delta int) int {
+	r.Lock()
+	defer r.Unlock()
+
+	score := r.scores[ip] + delta
+	if score > maxPeerScore {
+		score = maxPeerScore
+	}
+
+	if score == 0 {
+		delete(r.scores, ip)
+	} else {
+		r.scores[ip] = score
+	}
+
+	return score
+}
+
+// reset resets the score of an IP
+func (r *peerReputation) reset(ip string) {
+	r.Lock()
+	defer r.Unlock()
+	delete(r.scores, ip)
+}
+
+// resetAll resets the scores of all IPs
+func (r *peerReputation) resetAll() {
+	r.Lock()
+	defer r.Unlock()
+	r.scores = make(map[string]int)
+}
+
+// blocksRequested records a blocks request sent to addr, unless an older request is pending
+func (r *peerReputation) blocksRequested(addr string, t time.Time) {
+	r.Lock()
+	defer r.Unlock()
+
+	if _, ok := r.blocksRequests[addr]; !ok {
+		r.blocksRequests[addr] = t
+	}
+}
+
+// blocksReceived clears the pending blocks request of addr
+func (r *peerReputation) blocksReceived(addr string) {
+	r.Lock()
+	defer r.Unlock()
+	delete(r.blocksRequests, addr)
+}
+
+// expiredBlocksRequests removes and returns the addresses with a blocks request pending since before t
+func (r *peerReputation) expiredBlocksRequests(t time.Time) []string {
+	r.Lock()
+	defer r.Unlock()
+
+	var addrs []string
+	for addr, requestedAt := range r.blocksRequests {
+		if requestedAt.Before(t) {
+			addrs = append(addrs, addr)
+			delete(r.blocksRequests, addr)
+		}
+	}
+
+	return addrs
+}
+
+// adjustPeerScore applies a behavior to the reputation score of the peer's IP.
+// If the score falls to -BanScore, the IP is banned for BanDuration and its connections are closed.
+// Trusted peers are never banned.
+func (dm *Daemon) adjustPeerScore(addr string, b peerBehavior) {
+	ip, _, err := iputil.SplitAddr(addr)
+	if err != nil {
+		logger.Critical().WithError(err).WithField("addr", addr).Error("adjustPeerScore called with invalid addr")
+		return
+	}
+
+	score := dm.reputation.adjust(ip, b.score)
+
+	fields := logrus.Fields{
+		"addr":   addr,
+		"score":  score,
+		"reason": b.reason,
+	}
+
+	if b.score > 0 {
+		logger.WithFields(fields).Debug("Peer score increased")
+		return
+	}
+
+	logger.WithFields(fields).Info("Peer score decreased")
+
+	if dm.config.BanScore <= 0 || score > -dm.config.BanScore || dm.isTrustedPeer(addr) {
+		return
+	}
+
+	if err := dm.banIP(ip, b.reason); err != nil {
+		logger.WithError(err).WithFields(fields).Error("banIP failed")
+	}
+}
+
+// banIP bans an IP for BanDuration and disconnects its connections
+func (dm *Daemon) banIP(ip, reason string) error {
+	logger.WithFields(logrus.Fields{
+		"ip":       ip,
+		"reason":   reason,
+		"duration": dm.config.BanDuration,
+	}).Info("Banning peer")
+
+	dm.reputation.reset(ip)
+
+	if err := dm.pex.Ban(ip, dm.config.BanDuration, reason); err != nil {
+		return err
+	}
+
+	for _, c := range dm.connections.all() {
+		cip, _, err := iputil.SplitAddr(c.Addr)
+		if err != nil || cip != ip {
+			continue
+		}
+
+		if err := dm.Disconnect(c.Addr, ErrDisconnectIsBlacklisted); err != nil {
+			logger.WithError(err).WithField("addr", c.Addr).Error("Disconnect")
+		}
+	}
+
+	return nil
+}
+
+// recordBlocksRequest records that a GetBlocksMessage for blocks after headSeq was sent to a peer.
+// If the peer has introduced itself with more blocks, it is penalized if it does not respond in time
+func (dm *Daemon) recordBlocksRequest(c *connection, headSeq uint64) {
+	if c == nil || !c.HasIntroduced() || c.Height <= headSeq {
+		return
+	}
+
+	dm.reputation.blocksRequested(c.Addr, time.Now().UTC())
+}
+
+// recordBlocksResponse clears the pending blocks request of a peer
+func (dm *Daemon) recordBlocksResponse(addr string) {
+	dm.reputation.blocksReceived(addr)
+}
+
+// penalizeSlowBlocksResponses penalizes the peers that did not respond to a blocks request
+// within BlocksResponseTimeout
+func (dm *Daemon) penalizeSlowBlocksResponses() {
+	addrs := dm.reputation.expiredBlocksRequests(time.Now().UTC().Add(-dm.config.BlocksResponseTimeout))
+	for _, addr := range addrs {
+		if dm.connections.get(addr) == nil {
+			continue
+		}
+
+		dm.adjustPeerScore(addr, behaviorSlowBlocksResponse)
+	}
+}
+
+// GetBans returns the banned peer IPs
+func (dm *Daemon) GetBans() []pex.Ban {
+	return dm.pex.Bans()
+}
+
+// ClearBans removes the bans of the IPs and resets their reputation scores.
+// If no IPs are given, all bans are removed.
+// Returns pex.ErrNotBanned if one of the IPs is not banned.
+func (dm *Daemon) ClearBans(ips []string) error {
+	if len(ips) == 0 {
+		if err := dm.pex.UnbanAll(); err != nil {
+			return err
+		}
+
+		dm.reputation.resetAll()
+		return nil
+	}
+
+	for _, ip := range ips {
+		if !dm.pex.IsBanned(ip) {
+			return pex.ErrNotBanned
+		}
+	}
+
+	for _, ip := range ips {
+		if err := dm.pex.Unban(ip); err != nil {
+			return err
+		}
+
+		dm.reputation.reset(ip)
+	}
+
+	return nil
+}
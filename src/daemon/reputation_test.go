@@ -0,0 +1,142 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/daemon/gnet"
+)
+
+func TestPeerReputationAdjust(t *testing.T) {
+	r := newPeerReputation()
+
+	require.Equal(t, -50, r.adjust("1.2.3.4", behaviorInvalidBlock.score))
+	require.Equal(t, -60, r.adjust("1.2.3.4", behaviorInvalidTransaction.score))
+	require.Equal(t, 1, r.adjust("5.6.7.8", behaviorUsefulBlock.score))
+
+	// Useful behavior can't raise the score above maxPeerScore
+	for i := 0; i < maxPeerScore*2; i++ {
+		r.adjust("5.6.7.8", behaviorUsefulBlock.score)
+	}
+	require.Equal(t, maxPeerScore, r.scores["5.6.7.8"])
+
+	// A score of zero is not stored
+	require.Equal(t, 0, r.adjust("9.9.9.9", 0))
+	require.NotContains(t, r.scores, "9.9.9.9")
+
+	r.reset("1.2.3.4")
+	require.NotContains(t, r.scores, "1.2.3.4")
+	require.Contains(t, r.scores, "5.6.7.8")
+
+	r.resetAll()
+	require.Empty(t, r.scores)
+}
+
+func TestPeerReputationBlocksRequests(t *testing.T) {
+	r := newPeerReputation()
+	now := time.Now().UTC()
+
+	r.blocksRequested("1.2.3.4:6000", now.Add(-time.Minute))
+	r.blocksRequested("5.6.7.8:6000", now)
+
+	// A newer request does not replace a pending request
+	r.blocksRequested("1.2.3.4:6000", now)
+	require.Equal(t, now.Add(-time.Minute), r.blocksRequests["1.2.3.4:6000"])
+
+	addrs := r.expiredBlocksRequests(now.Add(-time.Second))
+	require.Equal(t, []string{"1.2.3.4:6000"}, addrs)
+	require.NotContains(t, r.blocksRequests, "1.2.3.4:6000")
+
+	r.blocksReceived("5.6.7.8:6000")
+	require.Empty(t, r.blocksRequests)
+	require.Empty(t, r.expiredBlocksRequests(now.Add(time.Second)))
+}
+
+func TestDisconnectBehavior(t *testing.T) {
+	cases := []struct {
+		reason   gnet.DisconnectReason
+		behavior peerBehavior
+		ok       bool
+	}{
+		{gnet.ErrDisconnectInvalidMessageLength, behaviorOversizeMessage, true},
+		{gnet.ErrDisconnectMalformedMessage, behaviorProtocolViolation, true},
+		{gnet.ErrDisconnectUnknownMessage, behaviorProtocolViolation, true},
+		{ErrDisconnectNoIntroduction, behaviorProtocolViolation, true},
+		{ErrDisconnectInvalidExtraData, behaviorProtocolViolation, true},
+		{gnet.ErrDisconnectEncryptionNotEnabled, peerBehavior{}, false},
+		{ErrDisconnectIdle, peerBehavior{}, false},
+		{ErrDisconnectRequestedByOperator, peerBehavior{}, false},
+		{errors.New("read failed"), peerBehavior{}, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.reason.Error(), func(t *testing.T) {
+			b, ok := disconnectBehavior(tc.reason)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.behavior, b)
+		})
+	}
+}
+
+func TestEncryptingPeerNotPenalized(t *testing.T) {
+	// An encrypting peer connects to a peer that does not enable encryption.
+	// The hello that starts the handshake must not be penalized as an oversize message
+	r := newPeerReputation()
+	reasons := make(chan gnet.DisconnectReason, 10)
+
+	newPool := func(port uint16, enableEncryption bool) (*gnet.ConnectionPool, chan struct{}) {
+		cfg := gnet.NewConfig()
+		cfg.Address = "127.0.0.1"
+		cfg.Port = port
+		cfg.EnableEncryption = enableEncryption
+		cfg.HandshakeTimeout = time.Millisecond * 500
+		if !enableEncryption {
+			cfg.DisconnectCallback = func(addr string, id uint64, reason gnet.DisconnectReason) {
+				if b, ok := disconnectBehavior(reason); ok {
+					r.adjust(addr, b.score)
+				}
+				reasons <- reason
+			}
+		}
+
+		pool, err := gnet.NewConnectionPool(cfg, nil)
+		require.NoError(t, err)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			err := pool.Run()
+			require.NoError(t, err)
+		}()
+
+		return pool, done
+	}
+
+	encrypting, encryptingDone := newPool(50901, true)
+	plaintext, plaintextDone := newPool(50902, false)
+	defer func() {
+		encrypting.Shutdown()
+		<-encryptingDone
+		plaintext.Shutdown()
+		<-plaintextDone
+	}()
+	time.Sleep(time.Millisecond * 100)
+
+	err := encrypting.Connect(fmt.Sprintf("127.0.0.1:%d", 50902))
+	require.NoError(t, err)
+
+	select {
+	case reason := <-reasons:
+		require.Equal(t, gnet.ErrDisconnectEncryptionNotEnabled, reason)
+	case <-time.After(time.Second * 3):
+		t.Fatal("Timed out waiting for disconnect")
+	}
+
+	r.Lock()
+	defer r.Unlock()
+	require.Empty(t, r.scores)
+}
@@ -0,0 +1,668 @@
+package daemon
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+)
+
+// headersSyncProtocolVersion is the minimum protocol version of peers that support GetHeadersMessage
+const headersSyncProtocolVersion = 3
+
+var (
+	// ErrSyncHeadersNotContiguous headers do not extend the synced header chain
+	ErrSyncHeadersNotContiguous = errors.New("Headers are not contiguous with the synced header chain")
+	// ErrSyncHeaderPrevHashMismatch a header's PrevHash does not match the hash of the previous header
+	ErrSyncHeaderPrevHashMismatch = errors.New("Header PrevHash does not match the previous header")
+	// ErrSyncHeaderConflict a header conflicts with the synced header of the same seq
+	ErrSyncHeaderConflict = errors.New("Header conflicts with the synced header chain")
+	// ErrSyncBlockHeaderMismatch a block does not match the synced header of the same seq
+	ErrSyncBlockHeaderMismatch = errors.New("Block does not match the synced header")
+	// ErrSyncBlockBodyHashMismatch a block's body does not match the body hash of its header
+	ErrSyncBlockBodyHashMismatch = errors.New("Block body does not match the header body hash")
+)
+
+// syncWindow is a range of blocks to download from a peer
+type syncWindow struct {
+	start       uint64    // seq of the first block of the window
+	end         uint64    // seq of the last block of the window
+	addr        string    // peer that the window was requested from, empty if the window is not assigned
+	requestedAt time.Time // when the window was requested
+}
+
+// syncPeer is a connected peer that blocks can be requested from
+type syncPeer struct {
+	addr      string
+	height    uint64
+	lowestSeq uint64 // lowest block seq that the peer can send, the peer pruned the older blocks
+}
+
+// syncBlocksRequest is a request for a window of blocks to send to a peer
+type syncBlocksRequest struct {
+	addr      string
+	lastBlock uint64
+	count     uint64
+}
+
+// blockSync implements headers-first block synchronization.
+// Signed block headers are downloaded from several peers and verified against the blockchain pubkey,
+// which builds a header chain ahead of the local blockchain.
+// The block bodies of the header chain are then downloaded in parallel windows from multiple peers,
+// verified against the synced headers and executed in order.
+// Windows that are not delivered in time are requested again from another peer.
+type blockSync struct {
+	sync.Mutex
+	pubkey       cipher.PubKey
+	windowSize   uint64
+	maxWindows   int
+	stallTimeout time.Duration
+
+	// Head of the local blockchain
+	headSeq  uint64
+	headHash cipher.SHA256
+	// Tip of the synced header chain, at or ahead of the local head
+	tipSeq  uint64
+	tipHash cipher.SHA256
+	// Hashes of the synced headers after the local head, keyed by seq
+	hashes map[uint64]cipher.SHA256
+	// Downloaded blocks waiting to be executed, keyed by seq
+	blocks map[uint64]coin.SignedBlock
+	// Windows being downloaded, ordered by start seq
+	windows []*syncWindow
+	// Seq of the first block after the last window
+	nextSeq uint64
+	// Pending GetHeadersMessage requests, keyed by peer address
+	headersRequests map[string]time.Time
+}
+
+func newBlockSync(pubkey cipher.PubKey, windowSize uint64, maxWindows int, stallTimeout time.Duration) *blockSync {
+	return &blockSync{
+		pubkey:          pubkey,
+		windowSize:      windowSize,
+		maxWindows:      maxWindows,
+		stallTimeout:    stallTimeout,
+		hashes:          make(map[uint64]cipher.SHA256),
+		blocks:          make(map[uint64]coin.SignedBlock),
+		headersRequests: make(map[string]time.Time),
+	}
+}
+
+// setHead records the head of the local blockchain and discards the state of the blocks up to it
+func (s *blockSync) setHead(seq uint64, hash cipher.SHA256) {
+	s.Lock()
+	defer s.Unlock()
+	s.setHeadLocked(seq, hash)
+}
+
+func (s *blockSync) setHeadLocked(seq uint64, hash cipher.SHA256) {
+	s.headSeq = seq
+	s.headHash = hash
+
+	for i := range s.hashes {
+		if i <= seq {
+			delete(s.hashes, i)
+		}
+	}
+
+	for i := range s.blocks {
+		if i <= seq {
+			delete(s.blocks, i)
+		}
+	}
+
+	if seq >= s.tipSeq {
+		s.tipSeq = seq
+		s.tipHash = hash
+	}
+
+	windows := s.windows[:0]
+	for _, w := range s.windows {
+		if w.end <= seq {
+			continue
+		}
+		if w.start <= seq {
+			w.start = seq + 1
+		}
+		windows = append(windows, w)
+	}
+	s.windows = windows
+
+	if s.nextSeq <= seq {
+		s.nextSeq = seq + 1
+	}
+}
+
+// reset discards the synced headers and downloaded blocks
+func (s *blockSync) reset() {
+	s.Lock()
+	defer s.Unlock()
+
+	s.hashes = make(map[uint64]cipher.SHA256)
+	s.blocks = make(map[uint64]coin.SignedBlock)
+	s.windows = nil
+	s.tipSeq = s.headSeq
+	s.tipHash = s.headHash
+	s.nextSeq = s.headSeq + 1
+}
+
+// tip returns the seq of the tip of the synced header chain
+func (s *blockSync) tip() uint64 {
+	s.Lock()
+	defer s.Unlock()
+	return s.tipSeq
+}
+
+// active returns true if the synced header chain is ahead of the local blockchain
+func (s *blockSync) active() bool {
+	s.Lock()
+	defer s.Unlock()
+	return s.tipSeq > s.headSeq
+}
+
+// addHeaders verifies headers and appends them to the synced header chain.
+// Headers that are already synced are skipped, after checking that they match the synced header chain.
+// Returns the number of headers appended.
+func (s *blockSync) addHeaders(headers []SignedBlockHeader) (int, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	added := 0
+	for _, h := range headers {
+		seq := h.Header.BkSeq
+		hash := h.Hash()
+
+		if seq <= s.tipSeq {
+			if known, ok := s.hashes[seq]; ok && known != hash {
+				return added, ErrSyncHeaderConflict
+			}
+			continue
+		}
+
+		if seq != s.tipSeq+1 {
+			return added, ErrSyncHeadersNotContiguous
+		}
+
+		if h.Header.PrevHash != s.tipHash {
+			return added, ErrSyncHeaderPrevHashMismatch
+		}
+
+		if err := h.VerifySignature(s.pubkey); err != nil {
+			return added, err
+		}
+
+		s.hashes[seq] = hash
+		s.tipSeq = seq
+		s.tipHash = hash
+		added++
+	}
+
+	return added, nil
+}
+
+// receiveBlocks stores the blocks of the synced header chain received from a peer.
+// Returns false if the synced header chain is not ahead of the local blockchain, in which case
+// the blocks are not handled by the sync.
+// Returns the number of new blocks stored, and an error if a block does not match its synced header.
+func (s *blockSync) receiveBlocks(addr string, blocks []coin.SignedBlock) (bool, int, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.tipSeq <= s.headSeq {
+		return false, 0, nil
+	}
+
+	stored := 0
+	for _, b := range blocks {
+		seq := b.Seq()
+		hash, ok := s.hashes[seq]
+		if !ok {
+			// Block is already executed or is beyond the synced header chain
+			continue
+		}
+
+		if b.HashHeader() != hash {
+			return true, stored, ErrSyncBlockHeaderMismatch
+		}
+
+		if b.Block.Body.Hash() != b.Block.Head.BodyHash {
+			return true, stored, ErrSyncBlockBodyHashMismatch
+		}
+
+		if _, ok := s.blocks[seq]; !ok {
+			s.blocks[seq] = b
+			stored++
+		}
+	}
+
+	// Remove the windows that are fully downloaded.
+	// If the peer delivered its window partially, e.g. because the response was truncated,
+	// the rest of the window is requested again.
+	windows := s.windows[:0]
+	for _, w := range s.windows {
+		start := w.start
+		for start <= w.end {
+			if _, ok := s.blocks[start]; !ok {
+				break
+			}
+			start++
+		}
+
+		if start > w.end {
+			continue
+		}
+
+		if w.addr == addr && start != w.start {
+			w.start = start
+			w.addr = ""
+		}
+
+		windows = append(windows, w)
+	}
+	s.windows = windows
+
+	return true, stored, nil
+}
+
+// popBlocks removes and returns the downloaded blocks that follow the local head, in order
+func (s *blockSync) popBlocks() []coin.SignedBlock {
+	s.Lock()
+	defer s.Unlock()
+
+	var blocks []coin.SignedBlock
+	for seq := s.headSeq + 1; ; seq++ {
+		b, ok := s.blocks[seq]
+		if !ok {
+			break
+		}
+		delete(s.blocks, seq)
+		blocks = append(blocks, b)
+	}
+
+	return blocks
+}
+
+// schedule assigns windows of the synced header chain to idle peers that have the blocks.
+// Pruned peers are not assigned windows that start below their lowest block seq.
+// Unassigned windows are assigned first, then new windows are created up to maxWindows.
+// Returns the requests to send.
+func (s *blockSync) schedule(peers []syncPeer, now time.Time) []syncBlocksRequest {
+	s.Lock()
+	defer s.Unlock()
+
+	busy := make(map[string]struct{}, len(s.windows))
+	for _, w := range s.windows {
+		if w.addr != "" {
+			busy[w.addr] = struct{}{}
+		}
+	}
+
+	// Limit the number of blocks that are downloaded ahead of the local head
+	maxSeq := s.headSeq + uint64(s.maxWindows)*s.windowSize
+
+	var requests []syncBlocksRequest
+	for _, p := range peers {
+		if _, ok := busy[p.addr]; ok {
+			continue
+		}
+
+		var window *syncWindow
+		for _, w := range s.windows {
+			if w.addr == "" && w.end <= p.height && w.start >= p.lowestSeq {
+				window = w
+				break
+			}
+		}
+
+		if window == nil && len(s.windows) < s.maxWindows && s.nextSeq <= s.tipSeq && s.nextSeq <= maxSeq {
+			end := s.nextSeq + s.windowSize - 1
+			if end > s.tipSeq {
+				end = s.tipSeq
+			}
+
+			if end <= p.height && s.nextSeq >= p.lowestSeq {
+				window = &syncWindow{
+					start: s.nextSeq,
+					end:   end,
+				}
+				s.windows = append(s.windows, window)
+				s.nextSeq = end + 1
+			}
+		}
+
+		if window == nil {
+			continue
+		}
+
+		window.addr = p.addr
+		window.requestedAt = now
+		busy[p.addr] = struct{}{}
+
+		requests = append(requests, syncBlocksRequest{
+			addr:      p.addr,
+			lastBlock: window.start - 1,
+			count:     window.end - window.start + 1,
+		})
+	}
+
+	return requests
+}
+
+// expireStalls unassigns the windows and removes the headers requests that were not delivered
+// within stallTimeout. Returns the addresses of the peers that stalled a window.
+func (s *blockSync) expireStalls(now time.Time) []string {
+	s.Lock()
+	defer s.Unlock()
+
+	var addrs []string
+	for _, w := range s.windows {
+		if w.addr != "" && now.Sub(w.requestedAt) > s.stallTimeout {
+			addrs = append(addrs, w.addr)
+			w.addr = ""
+		}
+	}
+
+	for addr, requestedAt := range s.headersRequests {
+		if now.Sub(requestedAt) > s.stallTimeout {
+			delete(s.headersRequests, addr)
+		}
+	}
+
+	sort.Strings(addrs)
+
+	return addrs
+}
+
+// removePeer unassigns the windows and removes the headers request of a peer
+func (s *blockSync) removePeer(addr string) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.unassignLocked(addr)
+	delete(s.headersRequests, addr)
+}
+
+// unassign unassigns the windows of a peer
+func (s *blockSync) unassign(addr string) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.unassignLocked(addr)
+}
+
+func (s *blockSync) unassignLocked(addr string) {
+	for _, w := range s.windows {
+		if w.addr == addr {
+			w.addr = ""
+		}
+	}
+}
+
+// requestHeaders records a GetHeadersMessage request sent to a peer.
+// Returns false if a request to the peer is already pending, or if maxPeers requests are pending.
+func (s *blockSync) requestHeaders(addr string, maxPeers int, now time.Time) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.headersRequests[addr]; ok {
+		return false
+	}
+
+	if len(s.headersRequests) >= maxPeers {
+		return false
+	}
+
+	s.headersRequests[addr] = now
+	return true
+}
+
+// headersReceived clears the pending headers request of a peer
+func (s *blockSync) headersReceived(addr string) {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.headersRequests, addr)
+}
+
+// supportsHeadersSync returns true if headers can be requested from a connection
+func (dm *Daemon) supportsHeadersSync(c *connection) bool {
+	return !dm.config.DisableHeadersSync && c.HasIntroduced() && c.ProtocolVersion >= headersSyncProtocolVersion
+}
+
+// updateSyncHead records the head of the local blockchain in the block sync
+func (dm *Daemon) updateSyncHead() error {
+	headSeq, ok, err := dm.visor.HeadBkSeq()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("Cannot sync blocks, there is no head block")
+	}
+
+	head, err := dm.visor.GetSignedBlockBySeq(headSeq)
+	if err != nil {
+		return err
+	}
+	if head == nil {
+		return errors.New("Cannot sync blocks, head block not found")
+	}
+
+	dm.blockSync.setHead(headSeq, head.HashHeader())
+	return nil
+}
+
+// requestHeadersFromAddr sends a GetHeadersMessage for the headers after the synced header chain to one connected address.
+// No request is sent if a request to the address is pending, or if HeadersSyncPeers requests are pending.
+func (dm *Daemon) requestHeadersFromAddr(addr string) error {
+	if err := dm.updateSyncHead(); err != nil {
+		return err
+	}
+
+	if !dm.blockSync.requestHeaders(addr, dm.config.HeadersSyncPeers, time.Now().UTC()) {
+		return nil
+	}
+
+	m := NewGetHeadersMessage(dm.blockSync.tip(), dm.config.GetHeadersRequestCount)
+	if err := dm.sendMessage(addr, m); err != nil {
+		dm.blockSync.headersReceived(addr)
+		return err
+	}
+
+	return nil
+}
+
+// receiveHeaders verifies the headers received from a peer and appends them to the synced header chain.
+// If the headers extend the synced header chain, more headers are requested from the peer,
+// and the blocks of the new headers are requested from idle peers.
+func (dm *Daemon) receiveHeaders(addr string, gnetID uint64, headers []SignedBlockHeader) {
+	dm.blockSync.headersReceived(addr)
+
+	if len(headers) == 0 {
+		return
+	}
+
+	fields := logrus.Fields{
+		"addr":       addr,
+		"gnetID":     gnetID,
+		"numHeaders": len(headers),
+	}
+
+	if err := dm.updateSyncHead(); err != nil {
+		logger.WithError(err).WithFields(fields).Error("updateSyncHead failed")
+		return
+	}
+
+	added, err := dm.blockSync.addHeaders(headers)
+	if err != nil {
+		logger.WithError(err).WithFields(fields).Warning("Received invalid headers")
+		// Responses to stale requests may not be contiguous, only penalize invalid headers
+		if err != ErrSyncHeadersNotContiguous {
+			dm.adjustPeerScore(addr, behaviorInvalidBlock)
+		}
+		return
+	}
+
+	// The peer has the blocks of the headers it sent
+	lastSeq := headers[len(headers)-1].Header.BkSeq
+	if c := dm.connections.get(addr); c != nil && c.Height < lastSeq {
+		dm.recordPeerHeight(addr, gnetID, lastSeq)
+	}
+
+	if added == 0 {
+		return
+	}
+
+	logger.WithFields(fields).WithField("tip", dm.blockSync.tip()).Debugf("Synced %d headers", added)
+
+	if err := dm.requestHeadersFromAddr(addr); err != nil {
+		logger.WithError(err).WithFields(fields).Warning("requestHeadersFromAddr failed")
+	}
+
+	dm.scheduleSync()
+}
+
+// receiveSyncBlocks verifies the blocks received from a peer against the synced headers and
+// executes the downloaded blocks that follow the local head.
+// Returns false if the blocks are not handled by the sync, because the synced header chain is not
+// ahead of the local blockchain.
+func (dm *Daemon) receiveSyncBlocks(addr string, blocks []coin.SignedBlock) bool {
+	if dm.config.DisableHeadersSync {
+		return false
+	}
+
+	handled, stored, err := dm.blockSync.receiveBlocks(addr, blocks)
+	if !handled {
+		return false
+	}
+
+	if err != nil {
+		logger.WithError(err).WithField("addr", addr).Warning("Received blocks that do not match the synced headers")
+		dm.adjustPeerScore(addr, behaviorInvalidBlock)
+	}
+
+	for i := 0; i < stored; i++ {
+		dm.adjustPeerScore(addr, behaviorUsefulBlock)
+	}
+
+	dm.executeSyncBlocks()
+	dm.scheduleSync()
+
+	return true
+}
+
+// executeSyncBlocks executes the downloaded blocks that follow the local head and announces the new head
+func (dm *Daemon) executeSyncBlocks() {
+	blocks := dm.blockSync.popBlocks()
+	if len(blocks) == 0 {
+		return
+	}
+
+	dm.verifyBlockSignatures(blocks)
+
+	var headSeq uint64
+	executed := 0
+	for _, b := range blocks {
+		if err := dm.executeSignedBlock(b); err != nil {
+			// The block matches a header signed by the blockchain pubkey, so this should not happen
+			logger.Critical().WithError(err).WithField("seq", b.Seq()).Error("Failed to execute synced block")
+			dm.blockSync.reset()
+			break
+		}
+
+		logger.Critical().WithField("seq", b.Seq()).Info("Added new block")
+		dm.blockSync.setHead(b.Seq(), b.HashHeader())
+		headSeq = b.Seq()
+		executed++
+	}
+
+	if executed == 0 {
+		return
+	}
+
+	abm := NewAnnounceBlocksMessage(headSeq)
+	if _, err := dm.broadcastMessage(abm); err != nil {
+		logger.WithError(err).Warning("Broadcast AnnounceBlocksMessage failed")
+	}
+}
+
+// scheduleSync requests the windows of blocks of the synced header chain from idle peers
+func (dm *Daemon) scheduleSync() {
+	if !dm.blockSync.active() {
+		return
+	}
+
+	var peers []syncPeer
+	for _, c := range dm.connections.all() {
+		if c.HasIntroduced() {
+			peers = append(peers, syncPeer{
+				addr:      c.Addr,
+				height:    c.Height,
+				lowestSeq: c.Capabilities.LowestBlockSeq,
+			})
+		}
+	}
+
+	// Spread the windows across peers
+	rand.Shuffle(len(peers), func(i, j int) {
+		peers[i], peers[j] = peers[j], peers[i]
+	})
+
+	for _, r := range dm.blockSync.schedule(peers, time.Now().UTC()) {
+		m := NewGetBlocksMessage(r.lastBlock, r.count)
+		if err := dm.sendMessage(r.addr, m); err != nil {
+			logger.WithError(err).WithField("addr", r.addr).Warning("Send GetBlocksMessage failed")
+			dm.blockSync.removePeer(r.addr)
+		}
+	}
+}
+
+// sendPrunedBlocks tells a peer that requested pruned blocks the lowest block seq that we can send
+func (dm *Daemon) sendPrunedBlocks(addr string) {
+	lowestSeq, err := dm.visor.LowestBlockSeq()
+	if err != nil {
+		logger.WithError(err).Error("visor.LowestBlockSeq failed")
+		return
+	}
+
+	if err := dm.sendMessage(addr, NewPrunedBlocksMessage(lowestSeq)); err != nil && err != ErrMessageTypeNotSupported {
+		logger.WithError(err).WithField("addr", addr).Error("Send PrunedBlocksMessage failed")
+	}
+}
+
+// receivePrunedBlocks records the lowest block seq of a peer that replied that it pruned the requested blocks,
+// and requests its window of the block sync from other peers
+func (dm *Daemon) receivePrunedBlocks(addr string, gnetID, lowestSeq uint64) {
+	fields := logrus.Fields{
+		"addr":      addr,
+		"lowestSeq": lowestSeq,
+	}
+	logger.WithFields(fields).Debug("Peer pruned the requested blocks")
+
+	if err := dm.connections.SetLowestBlockSeq(addr, gnetID, lowestSeq); err != nil {
+		logger.WithError(err).WithFields(fields).Error("connections.SetLowestBlockSeq failed")
+		return
+	}
+
+	// The peer answered the blocks request, so it is not penalized for a slow response
+	dm.recordBlocksResponse(addr)
+	dm.blockSync.unassign(addr)
+	dm.scheduleSync()
+}
+
+// checkSyncStalls penalizes the peers that did not deliver their window of blocks in time
+// and requests the stalled windows from other peers
+func (dm *Daemon) checkSyncStalls() {
+	for _, addr := range dm.blockSync.expireStalls(time.Now().UTC()) {
+		logger.WithField("addr", addr).Info("Peer stalled the block sync")
+		if dm.connections.get(addr) != nil {
+			dm.adjustPeerScore(addr, behaviorSlowBlocksResponse)
+		}
+	}
+
+	dm.scheduleSync()
+}
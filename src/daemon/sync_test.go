@@ -0,0 +1,305 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+)
+
+// makeSignedChain creates a chain of n+1 signed blocks, starting with a genesis block
+func makeSignedChain(t *testing.T, n int) (cipher.PubKey, cipher.SecKey, []coin.SignedBlock) {
+	pk, sk := cipher.GenerateKeyPair()
+
+	blocks := make([]coin.SignedBlock, 0, n+1)
+	var prevHash cipher.SHA256
+	for i := 0; i <= n; i++ {
+		body := coin.BlockBody{
+			Transactions: coin.Transactions{
+				{
+					Length:    uint32(i),
+					InnerHash: cipher.SumSHA256([]byte{byte(i)}),
+				},
+			},
+		}
+
+		b := coin.Block{
+			Head: coin.BlockHeader{
+				Time:     uint64(1000 + i),
+				BkSeq:    uint64(i),
+				PrevHash: prevHash,
+				BodyHash: body.Hash(),
+			},
+			Body: body,
+		}
+
+		blocks = append(blocks, coin.SignedBlock{
+			Block: b,
+			Sig:   cipher.MustSignHash(b.HashHeader(), sk),
+		})
+
+		prevHash = b.HashHeader()
+	}
+
+	return pk, sk, blocks
+}
+
+func signedHeaders(blocks []coin.SignedBlock) []SignedBlockHeader {
+	headers := make([]SignedBlockHeader, len(blocks))
+	for i, b := range blocks {
+		headers[i] = NewSignedBlockHeader(b)
+	}
+	return headers
+}
+
+func TestBlockSyncAddHeaders(t *testing.T) {
+	pk, sk, blocks := makeSignedChain(t, 10)
+
+	newSync := func() *blockSync {
+		s := newBlockSync(pk, 3, 4, time.Second)
+		s.setHead(0, blocks[0].HashHeader())
+		return s
+	}
+
+	s := newSync()
+	require.False(t, s.active())
+
+	added, err := s.addHeaders(signedHeaders(blocks[1:6]))
+	require.NoError(t, err)
+	require.Equal(t, 5, added)
+	require.Equal(t, uint64(5), s.tip())
+	require.True(t, s.active())
+
+	// Overlapping headers are skipped
+	added, err = s.addHeaders(signedHeaders(blocks[3:8]))
+	require.NoError(t, err)
+	require.Equal(t, 2, added)
+	require.Equal(t, uint64(7), s.tip())
+
+	// A gap in the headers is rejected
+	added, err = s.addHeaders(signedHeaders(blocks[9:]))
+	require.Equal(t, ErrSyncHeadersNotContiguous, err)
+	require.Equal(t, 0, added)
+
+	// A header that conflicts with a synced header is rejected
+	conflict := NewSignedBlockHeader(blocks[4])
+	conflict.Header.Fee = 1
+	conflict.Sig = cipher.MustSignHash(conflict.Hash(), sk)
+	_, err = s.addHeaders([]SignedBlockHeader{conflict})
+	require.Equal(t, ErrSyncHeaderConflict, err)
+
+	// A header that does not follow the previous header is rejected
+	s = newSync()
+	h := NewSignedBlockHeader(blocks[1])
+	h.Header.PrevHash = cipher.SHA256{1}
+	h.Sig = cipher.MustSignHash(h.Hash(), sk)
+	_, err = s.addHeaders([]SignedBlockHeader{h})
+	require.Equal(t, ErrSyncHeaderPrevHashMismatch, err)
+
+	// A header that is not signed by the blockchain pubkey is rejected
+	_, otherSK := cipher.GenerateKeyPair()
+	h = NewSignedBlockHeader(blocks[1])
+	h.Sig = cipher.MustSignHash(h.Hash(), otherSK)
+	_, err = s.addHeaders([]SignedBlockHeader{h})
+	require.Error(t, err)
+	require.Equal(t, uint64(0), s.tip())
+}
+
+func TestBlockSyncDownload(t *testing.T) {
+	pk, _, blocks := makeSignedChain(t, 10)
+
+	s := newBlockSync(pk, 3, 2, time.Second)
+	s.setHead(0, blocks[0].HashHeader())
+
+	// Not syncing, blocks are not handled by the sync
+	handled, _, err := s.receiveBlocks("1.1.1.1:6000", blocks[1:4])
+	require.NoError(t, err)
+	require.False(t, handled)
+
+	_, err = s.addHeaders(signedHeaders(blocks[1:]))
+	require.NoError(t, err)
+
+	now := time.Now()
+	peers := []syncPeer{
+		{addr: "1.1.1.1:6000", height: 10},
+		{addr: "2.2.2.2:6000", height: 10},
+		{addr: "3.3.3.3:6000", height: 10},
+		{addr: "4.4.4.4:6000", height: 2},
+	}
+
+	// Windows are requested in parallel, up to maxWindows
+	requests := s.schedule(peers, now)
+	require.Equal(t, []syncBlocksRequest{
+		{addr: "1.1.1.1:6000", lastBlock: 0, count: 3},
+		{addr: "2.2.2.2:6000", lastBlock: 3, count: 3},
+	}, requests)
+
+	// Busy peers are not assigned another window
+	require.Empty(t, s.schedule(peers, now))
+
+	// The second window arrives first, it can't be executed yet
+	handled, stored, err := s.receiveBlocks("2.2.2.2:6000", blocks[4:7])
+	require.NoError(t, err)
+	require.True(t, handled)
+	require.Equal(t, 3, stored)
+	require.Empty(t, s.popBlocks())
+
+	// The first window is delivered partially, the rest is requested again
+	_, stored, err = s.receiveBlocks("1.1.1.1:6000", blocks[1:3])
+	require.NoError(t, err)
+	require.Equal(t, 2, stored)
+	popped := s.popBlocks()
+	require.Equal(t, blocks[1:3], popped)
+	s.setHead(2, blocks[2].HashHeader())
+
+	requests = s.schedule(peers, now)
+	require.Equal(t, []syncBlocksRequest{
+		{addr: "1.1.1.1:6000", lastBlock: 2, count: 1},
+		{addr: "2.2.2.2:6000", lastBlock: 6, count: 3},
+	}, requests)
+
+	// A block that does not match its header is rejected
+	bad := blocks[3]
+	bad.Block.Head.Fee = 99
+	_, _, err = s.receiveBlocks("1.1.1.1:6000", []coin.SignedBlock{bad})
+	require.Equal(t, ErrSyncBlockHeaderMismatch, err)
+
+	bad = blocks[3]
+	bad.Block.Body.Transactions = nil
+	_, _, err = s.receiveBlocks("1.1.1.1:6000", []coin.SignedBlock{bad})
+	require.Equal(t, ErrSyncBlockBodyHashMismatch, err)
+
+	// Stalled windows are reassigned to another peer
+	addrs := s.expireStalls(now.Add(time.Second * 2))
+	require.Equal(t, []string{"1.1.1.1:6000", "2.2.2.2:6000"}, addrs)
+
+	requests = s.schedule(peers[2:], now)
+	require.Equal(t, []syncBlocksRequest{
+		{addr: "3.3.3.3:6000", lastBlock: 2, count: 1},
+	}, requests)
+
+	_, _, err = s.receiveBlocks("3.3.3.3:6000", blocks[3:4])
+	require.NoError(t, err)
+	require.Equal(t, blocks[3:7], s.popBlocks())
+	s.setHead(6, blocks[6].HashHeader())
+
+	// Windows of a disconnected peer are reassigned
+	requests = s.schedule(peers[2:3], now)
+	require.Equal(t, []syncBlocksRequest{
+		{addr: "3.3.3.3:6000", lastBlock: 6, count: 3},
+	}, requests)
+	s.removePeer("3.3.3.3:6000")
+	requests = s.schedule(peers[:1], now)
+	require.Equal(t, []syncBlocksRequest{
+		{addr: "1.1.1.1:6000", lastBlock: 6, count: 3},
+	}, requests)
+
+	_, _, err = s.receiveBlocks("1.1.1.1:6000", blocks[7:10])
+	require.NoError(t, err)
+	require.Equal(t, blocks[7:10], s.popBlocks())
+	s.setHead(9, blocks[9].HashHeader())
+
+	// The last window is shorter than the window size
+	requests = s.schedule(peers[:1], now)
+	require.Equal(t, []syncBlocksRequest{
+		{addr: "1.1.1.1:6000", lastBlock: 9, count: 1},
+	}, requests)
+	_, _, err = s.receiveBlocks("1.1.1.1:6000", blocks[10:])
+	require.NoError(t, err)
+	require.Equal(t, blocks[10:], s.popBlocks())
+	s.setHead(10, blocks[10].HashHeader())
+	require.False(t, s.active())
+	require.Empty(t, s.windows)
+}
+
+func TestBlockSyncSchedulePrunedPeers(t *testing.T) {
+	pk, _, blocks := makeSignedChain(t, 10)
+
+	s := newBlockSync(pk, 3, 2, time.Second)
+	s.setHead(0, blocks[0].HashHeader())
+	_, err := s.addHeaders(signedHeaders(blocks[1:]))
+	require.NoError(t, err)
+
+	now := time.Now()
+
+	// A pruned peer is not assigned windows below its lowest block seq
+	requests := s.schedule([]syncPeer{
+		{addr: "1.1.1.1:6000", height: 10, lowestSeq: 4},
+	}, now)
+	require.Empty(t, requests)
+
+	requests = s.schedule([]syncPeer{
+		{addr: "1.1.1.1:6000", height: 10, lowestSeq: 4},
+		{addr: "2.2.2.2:6000", height: 10},
+	}, now)
+	require.Equal(t, []syncBlocksRequest{
+		{addr: "2.2.2.2:6000", lastBlock: 0, count: 3},
+	}, requests)
+
+	// The next window starts at the pruned peer's lowest block seq
+	requests = s.schedule([]syncPeer{
+		{addr: "1.1.1.1:6000", height: 10, lowestSeq: 4},
+	}, now)
+	require.Equal(t, []syncBlocksRequest{
+		{addr: "1.1.1.1:6000", lastBlock: 3, count: 3},
+	}, requests)
+
+	// A stalled window below the lowest block seq is not reassigned to the pruned peer
+	addrs := s.expireStalls(now.Add(time.Second * 2))
+	require.Equal(t, []string{"1.1.1.1:6000", "2.2.2.2:6000"}, addrs)
+	requests = s.schedule([]syncPeer{
+		{addr: "1.1.1.1:6000", height: 10, lowestSeq: 4},
+	}, now)
+	require.Equal(t, []syncBlocksRequest{
+		{addr: "1.1.1.1:6000", lastBlock: 3, count: 3},
+	}, requests)
+}
+
+func TestBlockSyncUnassign(t *testing.T) {
+	pk, _, blocks := makeSignedChain(t, 10)
+
+	s := newBlockSync(pk, 3, 2, time.Second)
+	s.setHead(0, blocks[0].HashHeader())
+	_, err := s.addHeaders(signedHeaders(blocks[1:]))
+	require.NoError(t, err)
+
+	now := time.Now()
+
+	requests := s.schedule([]syncPeer{
+		{addr: "1.1.1.1:6000", height: 10},
+	}, now)
+	require.Equal(t, []syncBlocksRequest{
+		{addr: "1.1.1.1:6000", lastBlock: 0, count: 3},
+	}, requests)
+
+	// The peer reports that it pruned the blocks of its window,
+	// which is reassigned to another peer without waiting for the stall timeout
+	s.unassign("1.1.1.1:6000")
+	requests = s.schedule([]syncPeer{
+		{addr: "1.1.1.1:6000", height: 10, lowestSeq: 7},
+		{addr: "2.2.2.2:6000", height: 10},
+	}, now)
+	require.Equal(t, []syncBlocksRequest{
+		{addr: "2.2.2.2:6000", lastBlock: 0, count: 3},
+	}, requests)
+}
+
+func TestBlockSyncHeadersRequests(t *testing.T) {
+	s := newBlockSync(cipher.PubKey{}, 3, 2, time.Second)
+	now := time.Now()
+
+	require.True(t, s.requestHeaders("1.1.1.1:6000", 2, now))
+	require.False(t, s.requestHeaders("1.1.1.1:6000", 2, now))
+	require.True(t, s.requestHeaders("2.2.2.2:6000", 2, now))
+	require.False(t, s.requestHeaders("3.3.3.3:6000", 2, now))
+
+	s.headersReceived("1.1.1.1:6000")
+	require.True(t, s.requestHeaders("3.3.3.3:6000", 2, now))
+
+	// Stalled headers requests are removed
+	s.expireStalls(now.Add(time.Second * 2))
+	require.Empty(t, s.headersRequests)
+}
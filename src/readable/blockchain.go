@@ -13,14 +13,18 @@ type BlockchainMetadata struct {
 	Unspents uint64 `json:"unspents"`
 	// Number of known unconfirmed txns
 	Unconfirmed uint64 `json:"unconfirmed"`
+	// Lowest seq of the blocks whose bodies are available, other than the genesis block.
+	// 0 if no block has been pruned
+	LowestBlockSeq uint64 `json:"lowest_block_seq"`
 }
 
 // NewBlockchainMetadata creates blockchain metadata
 func NewBlockchainMetadata(bm visor.BlockchainMetadata) BlockchainMetadata {
 	return BlockchainMetadata{
-		Head:        NewBlockHeader(bm.HeadBlock.Head),
-		Unspents:    bm.Unspents,
-		Unconfirmed: bm.Unconfirmed,
+		Head:           NewBlockHeader(bm.HeadBlock.Head),
+		Unspents:       bm.Unspents,
+		Unconfirmed:    bm.Unconfirmed,
+		LowestBlockSeq: bm.LowestBlockSeq,
 	}
 }
 
@@ -32,6 +36,27 @@ type BlockchainProgress struct {
 	Highest uint64 `json:"highest"`
 	// Individual blockchain length reports from peers
 	Peers []PeerBlockchainHeight `json:"peers"`
+	// Progress of the rebuild of the history database
+	HistoryIndex HistoryIndexStatus `json:"history_index"`
+}
+
+// HistoryIndexStatus is the progress of the rebuild of the history database
+type HistoryIndexStatus struct {
+	// Is the history database being rebuilt. The transaction history is not available until the rebuild is finished
+	Indexing bool `json:"indexing"`
+	// Number of blocks parsed into the history database
+	ParsedBlocks uint64 `json:"parsed_blocks"`
+	// Number of blocks of the blockchain
+	TotalBlocks uint64 `json:"total_blocks"`
+}
+
+// NewHistoryIndexStatus copies visor.HistoryIndexStatus to a struct with json tags
+func NewHistoryIndexStatus(s visor.HistoryIndexStatus) HistoryIndexStatus {
+	return HistoryIndexStatus{
+		Indexing:     s.Indexing,
+		ParsedBlocks: s.ParsedBlocks,
+		TotalBlocks:  s.TotalBlocks,
+	}
 }
 
 // PeerBlockchainHeight is a peer's IP address with their reported blockchain height
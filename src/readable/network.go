@@ -2,6 +2,8 @@ package readable
 
 import (
 	"github.com/skycoin/skycoin/src/daemon"
+	"github.com/skycoin/skycoin/src/daemon/gnet"
+	"github.com/skycoin/skycoin/src/daemon/pex"
 	"github.com/skycoin/skycoin/src/params"
 	"github.com/skycoin/skycoin/src/util/useragent"
 )
@@ -21,6 +23,8 @@ type Connection struct {
 	UserAgent            useragent.Data         `json:"user_agent"`
 	IsTrustedPeer        bool                   `json:"is_trusted_peer"`
 	UnconfirmedVerifyTxn VerifyTxn              `json:"unconfirmed_verify_transaction"`
+	Capabilities         Capabilities           `json:"capabilities"`
+	Traffic              ConnectionTraffic      `json:"traffic"`
 }
 
 // NewConnection copies daemon.Connection to a struct with json tags
@@ -53,6 +57,72 @@ func NewConnection(c *daemon.Connection) Connection {
 		UserAgent:            c.UserAgent,
 		IsTrustedPeer:        c.Pex.Trusted,
 		UnconfirmedVerifyTxn: NewVerifyTxn(c.UnconfirmedVerifyTxn),
+		Capabilities:         NewCapabilities(c.Capabilities),
+		Traffic:              NewConnectionTraffic(c.Gnet.Traffic),
+	}
+}
+
+// Traffic bytes and messages sent and received
+type Traffic struct {
+	BytesSent        uint64 `json:"bytes_sent"`
+	BytesReceived    uint64 `json:"bytes_received"`
+	MessagesSent     uint64 `json:"messages_sent"`
+	MessagesReceived uint64 `json:"messages_received"`
+}
+
+// NewTraffic copies gnet.Traffic to a struct with json tags
+func NewTraffic(t gnet.Traffic) Traffic {
+	return Traffic{
+		BytesSent:        t.BytesSent,
+		BytesReceived:    t.BytesReceived,
+		MessagesSent:     t.MessagesSent,
+		MessagesReceived: t.MessagesReceived,
+	}
+}
+
+// ConnectionTraffic traffic of a connection, in total and by message type
+type ConnectionTraffic struct {
+	Traffic
+	MessageTypes map[string]Traffic `json:"message_types"`
+}
+
+// NewConnectionTraffic copies gnet.ConnectionTraffic to a struct with json tags
+func NewConnectionTraffic(t gnet.ConnectionTraffic) ConnectionTraffic {
+	messageTypes := make(map[string]Traffic, len(t.MessageTypes))
+	for k, v := range t.MessageTypes {
+		messageTypes[k] = NewTraffic(v)
+	}
+
+	return ConnectionTraffic{
+		Traffic:      NewTraffic(t.Traffic),
+		MessageTypes: messageTypes,
+	}
+}
+
+// Capabilities optional protocol features advertised by a peer in its introduction message
+type Capabilities struct {
+	Services                 []string `json:"services"`
+	MaxIncomingMessageLength uint64   `json:"max_incoming_message_length"`
+	MaxOutgoingMessageLength uint64   `json:"max_outgoing_message_length"`
+	MessageTypes             []string `json:"message_types"`
+	OnionAddress             string   `json:"onion_address"`
+	LowestBlockSeq           uint64   `json:"lowest_block_seq"`
+}
+
+// NewCapabilities converts daemon.Capabilities to Capabilities
+func NewCapabilities(c daemon.Capabilities) Capabilities {
+	messageTypes := c.MessageTypes
+	if messageTypes == nil {
+		messageTypes = []string{}
+	}
+
+	return Capabilities{
+		Services:                 c.ServiceNames(),
+		MaxIncomingMessageLength: c.MaxIncomingMessageLength,
+		MaxOutgoingMessageLength: c.MaxOutgoingMessageLength,
+		MessageTypes:             messageTypes,
+		OnionAddress:             c.OnionAddress,
+		LowestBlockSeq:           c.LowestBlockSeq,
 	}
 }
 
@@ -71,3 +141,30 @@ func NewVerifyTxn(p params.VerifyTxn) VerifyTxn {
 		MaxDropletPrecision: p.MaxDropletPrecision,
 	}
 }
+
+// Ban a banned peer IP
+type Ban struct {
+	IP        string `json:"ip"`
+	Reason    string `json:"reason"`
+	CreatedAt int64  `json:"created_at"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// NewBan copies pex.Ban to a struct with json tags
+func NewBan(b pex.Ban) Ban {
+	return Ban{
+		IP:        b.IP,
+		Reason:    b.Reason,
+		CreatedAt: b.CreatedAt,
+		ExpiresAt: b.ExpiresAt,
+	}
+}
+
+// NewBans copies []pex.Ban to []Ban
+func NewBans(bans []pex.Ban) []Ban {
+	rbans := make([]Ban, len(bans))
+	for i, b := range bans {
+		rbans[i] = NewBan(b)
+	}
+	return rbans
+}
@@ -67,4 +67,5 @@ type WalletMeta struct {
 	Encrypted  bool              `json:"encrypted"`
 	Bip44Coin  *bip44.CoinType   `json:"bip44_coin,omitempty"` // For bip44
 	XPub       string            `json:"xpub,omitempty"`       // For xpub
+	XPubs      []string          `json:"xpubs,omitempty"`      // For watch-only
 }
@@ -25,6 +25,8 @@ import (
 	"github.com/skycoin/skycoin/src/util/droplet"
 	"github.com/skycoin/skycoin/src/util/file"
 	"github.com/skycoin/skycoin/src/util/useragent"
+	"github.com/skycoin/skycoin/src/visor"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
 )
 
 var (
@@ -48,12 +50,45 @@ type NodeConfig struct {
 	DownloadPeerList bool
 	// Download the peers list from this URL
 	PeerListURL string
+	// Verify the signature of the downloaded peers list with this hex-encoded pubkey
+	PeerListPubkeyStr string
+	peerListPubkey    cipher.PubKey
+	// Reject a downloaded peers list that is not signed by PeerListPubkeyStr
+	RequireSignedPeerList bool
+	// Comma-separated hostnames of DNS seeds to resolve to peer addresses on startup
+	DNSSeeds string
+	dnsSeeds []string
+	// Port of the peers returned by DNS seeds that don't specify a port. Set to the default port of the network
+	dnsSeedPort int
 	// Don't make any outgoing connections
 	DisableOutgoingConnections bool
 	// Don't allowing incoming connections
 	DisableIncomingConnections bool
 	// Disables networking altogether
 	DisableNetworking bool
+	// Encrypt the connections with peers that support encryption
+	EnablePeerEncryption bool
+	// Reject the connections with peers that don't support encryption
+	RequirePeerEncryption bool
+	// Address of a SOCKS5 proxy, e.g. Tor. Connections to onion peers are made through it
+	Proxy string
+	// Username and password for the SOCKS5 proxy
+	ProxyUsername string
+	ProxyPassword string
+	// Make all outgoing connections through the proxy and disable incoming connections
+	ProxyOnly bool
+	// Onion address (onion:port) of a Tor onion service that forwards to the listening port, advertised to peers
+	OnionAddress string
+	// Don't use headers-first parallel block synchronization
+	DisableHeadersSync bool
+	// Don't send or request compact blocks
+	DisableCompactBlocks bool
+	// Broadcast transactions to all peers instead of relaying them along a Dandelion++ stem
+	DisableDandelion bool
+	// Probability that the node fluffs the stem transactions it receives during an epoch
+	DandelionFluffProbability float64
+	// Minimum time to wait for a stem transaction to be fluffed by another node before fluffing it
+	DandelionEmbargoDuration time.Duration
 	// Enable GUI
 	EnableGUI bool
 	// Disable CSRF check in the wallet API
@@ -91,10 +126,20 @@ type NodeConfig struct {
 	MaxDefaultPeerOutgoingConnections int
 	// How often to make outgoing connections
 	OutgoingConnectionsRate time.Duration
+	// Ban a peer's IP when its reputation score falls to -BanScore. Set to 0 to disable banning
+	BanScore int
+	// How long to ban a misbehaving peer's IP for
+	BanDuration time.Duration
 	// MaxOutgoingMessageLength maximum size of outgoing messages
 	MaxOutgoingMessageLength int
 	// MaxIncomingMessageLength maximum size of incoming messages
 	MaxIncomingMessageLength int
+	// Maximum upload rate of all connections, in bytes per second. Set to 0 for no limit
+	MaxUploadRate int64
+	// Maximum upload rate of each connection, in bytes per second. Set to 0 for no limit
+	MaxPeerUploadRate int64
+	// Maximum bytes uploaded per day, after which only headers and announcements are sent. Set to 0 for no limit
+	MaxDailyUpload uint64
 	// MaxLastBlocksCount maximum number of blocks to response to API /api/v1/last_blocks
 	MaxLastBlocksCount uint64
 	// PeerlistSize represents the maximum number of peers that the pex would maintain
@@ -145,8 +190,20 @@ type NodeConfig struct {
 
 	// Verify the database integrity after loading
 	VerifyDB bool
-	// Reset the database if integrity checks fail, and continue running
+	// Repair the database if integrity checks fail, or reset it if it can't be repaired, and continue running
 	ResetCorruptDB bool
+	// Import the blocks of a blocks file on startup
+	ImportBlocks string
+	// Bootstrap the blockchain from a snapshot file on startup
+	ImportSnapshot string
+	// Number of most recent blocks whose bodies are kept, older block bodies and their history are pruned.
+	// 0 disables pruning
+	PruneDepth uint64
+	// Number of verified input signatures kept by the signature cache, 0 disables the cache
+	SignatureCacheSize int
+	// Number of blocks parsed in each database transaction when the history database is rebuilt,
+	// and of transactions added when its transaction indexes are backfilled
+	HistoryRebuildBatchSize int
 
 	// Transaction verification parameters for unconfirmed transactions
 	UnconfirmedVerifyTxn params.VerifyTxn
@@ -168,6 +225,10 @@ type NodeConfig struct {
 	WalletDirectory string
 	// Wallet crypto type
 	WalletCryptoType string
+	// Maximum duration an encrypted wallet can be unlocked for by the API, 0 disables unlocking
+	WalletMaxSessionTimeout time.Duration
+	// Disable the background gap limit address discovery of wallets
+	DisableWalletAddressDiscovery bool
 
 	// Key-value storage
 	// Default to ${DataDirectory}/data
@@ -194,6 +255,7 @@ type NodeConfig struct {
 
 	DBPath     string
 	DBReadOnly bool
+	DBBackend  string // one of dbutil.Backends
 	LogToFile  bool
 	Version    bool // show node version
 
@@ -234,6 +296,23 @@ func NewNodeConfig(mode string, node fiber.NodeConfig) NodeConfig {
 		DisableIncomingConnections: false,
 		// Disables networking altogether
 		DisableNetworking: false,
+		// Encrypt the connections with peers that support encryption
+		EnablePeerEncryption:  false,
+		RequirePeerEncryption: false,
+		// Connect to onion peers through this SOCKS5 proxy
+		Proxy:         "",
+		ProxyUsername: "",
+		ProxyPassword: "",
+		ProxyOnly:     false,
+		OnionAddress:  "",
+		// Use headers-first parallel block synchronization with peers that support it
+		DisableHeadersSync: false,
+		// Relay new blocks as compact blocks to peers that support them
+		DisableCompactBlocks: false,
+		// Relay new transactions along a Dandelion++ stem before broadcasting them
+		DisableDandelion:          false,
+		DandelionFluffProbability: 0.1,
+		DandelionEmbargoDuration:  time.Second * 30,
 		// Enable GUI
 		EnableGUI: false,
 		// Disable CSRF check in the wallet API
@@ -259,12 +338,22 @@ func NewNodeConfig(mode string, node fiber.NodeConfig) NodeConfig {
 		MaxDefaultPeerOutgoingConnections: 2,
 		DownloadPeerList:                  true,
 		PeerListURL:                       node.PeerListURL,
+		PeerListPubkeyStr:                 "",
+		RequireSignedPeerList:             false,
+		DNSSeeds:                          "",
+		dnsSeedPort:                       node.Port,
 		// How often to make outgoing connections, in seconds
 		OutgoingConnectionsRate:  time.Second * 5,
+		BanScore:                 100,
+		BanDuration:              time.Hour * 24,
 		MaxOutgoingMessageLength: 256 * 1024,
 		MaxIncomingMessageLength: 1024 * 1024,
-		MaxLastBlocksCount:       256,
-		PeerlistSize:             65535,
+		// Don't limit the upload
+		MaxUploadRate:      0,
+		MaxPeerUploadRate:  0,
+		MaxDailyUpload:     0,
+		MaxLastBlocksCount: 256,
+		PeerlistSize:       65535,
 		// Wallet Address Version
 		// AddressVersion: "test",
 		// Remote web interface
@@ -295,6 +384,11 @@ func NewNodeConfig(mode string, node fiber.NodeConfig) NodeConfig {
 		VerifyDB:       false,
 		ResetCorruptDB: false,
 
+		DBBackend: dbutil.BackendBolt,
+
+		SignatureCacheSize:      visor.DefaultSignatureCacheSize,
+		HistoryRebuildBatchSize: visor.DefaultHistoryRebuildBatchSize,
+
 		// Blockchain/transaction validation
 		UnconfirmedVerifyTxn: params.VerifyTxn{
 			BurnFactor:          node.UnconfirmedBurnFactor,
@@ -312,6 +406,8 @@ func NewNodeConfig(mode string, node fiber.NodeConfig) NodeConfig {
 		WalletDirectory:  "",
 		WalletCryptoType: string(crypto.DefaultCryptoType),
 
+		WalletMaxSessionTimeout: time.Hour,
+
 		// Key-value storage
 		KVStorageDirectory: "",
 		EnabledStorageTypes: []kvstorage.Type{
@@ -431,6 +527,12 @@ func (c *Config) postProcess() error {
 		c.Node.DBPath = replaceHome(c.Node.DBPath, home)
 	}
 
+	switch c.Node.DBBackend {
+	case dbutil.BackendBolt, dbutil.BackendMemory:
+	default:
+		return fmt.Errorf("Invalid -db-backend %q, must be one of: %s", c.Node.DBBackend, strings.Join(dbutil.Backends, ", "))
+	}
+
 	userAgentData := useragent.Data{
 		Coin:    c.Node.CoinName,
 		Version: c.Build.Version,
@@ -463,6 +565,22 @@ func (c *Config) postProcess() error {
 		c.Node.DefaultConnections = nil
 	}
 
+	if c.Node.PeerListPubkeyStr != "" {
+		pk, err := cipher.PubKeyFromHex(c.Node.PeerListPubkeyStr)
+		if err != nil {
+			return fmt.Errorf("Invalid -peerlist-pubkey: %v", err)
+		}
+		c.Node.peerListPubkey = pk
+	}
+
+	if c.Node.RequireSignedPeerList && c.Node.PeerListPubkeyStr == "" {
+		return errors.New("-require-signed-peerlist requires -peerlist-pubkey")
+	}
+
+	if c.Node.DNSSeeds != "" {
+		c.Node.dnsSeeds = strings.Split(c.Node.DNSSeeds, ",")
+	}
+
 	if c.Node.HostWhitelist != "" {
 		if c.Node.DisableHeaderCheck {
 			return errors.New("host whitelist should be empty when header check is disabled")
@@ -475,6 +593,38 @@ func (c *Config) postProcess() error {
 		return errors.New("Web interface auth enabled but HTTPS is not enabled. Use -web-interface-plaintext-auth=true if this is desired")
 	}
 
+	if c.Node.RequirePeerEncryption && !c.Node.EnablePeerEncryption {
+		return errors.New("-require-peer-encryption requires -enable-peer-encryption")
+	}
+
+	if c.Node.ImportBlocks != "" && c.Node.DBReadOnly {
+		return errors.New("-import-blocks cannot be combined with -db-read-only")
+	}
+
+	if c.Node.ImportSnapshot != "" && c.Node.DBReadOnly {
+		return errors.New("-import-snapshot cannot be combined with -db-read-only")
+	}
+
+	if c.Node.PruneDepth != 0 && c.Node.PruneDepth < visor.MinPruneDepth {
+		return fmt.Errorf("Invalid -prune-depth, must be 0 or >= %d", visor.MinPruneDepth)
+	}
+
+	if c.Node.PruneDepth != 0 && c.Node.DBReadOnly {
+		return errors.New("-prune-depth cannot be combined with -db-read-only")
+	}
+
+	if c.Node.SignatureCacheSize < 0 {
+		return errors.New("-signature-cache-size must be >= 0")
+	}
+
+	if c.Node.HistoryRebuildBatchSize <= 0 {
+		return errors.New("-history-rebuild-batch-size must be > 0")
+	}
+
+	if c.Node.ProxyOnly && c.Node.Proxy == "" {
+		return errors.New("-proxy-only requires -proxy")
+	}
+
 	if c.Node.MaxConnections < c.Node.MaxOutgoingConnections+c.Node.MaxIncomingConnections {
 		return errors.New("-max-connections must be >= -max-outgoing-connections + -max-incoming-connections")
 	}
@@ -487,6 +637,18 @@ func (c *Config) postProcess() error {
 		return errors.New("-max-incoming-connections cannot be higher than -max-connections")
 	}
 
+	if c.Node.BanScore < 0 {
+		return errors.New("-ban-score cannot be negative")
+	}
+
+	if c.Node.MaxUploadRate < 0 {
+		return errors.New("-max-upload-rate cannot be negative")
+	}
+
+	if c.Node.MaxPeerUploadRate < 0 {
+		return errors.New("-max-peer-upload-rate cannot be negative")
+	}
+
 	if c.Node.maxBlockSize > math.MaxUint32 {
 		return errors.New("-max-block-size exceeds MaxUint32")
 	}
@@ -649,9 +811,24 @@ func (c *NodeConfig) RegisterFlags() {
 	flag.BoolVar(&c.DisablePEX, "disable-pex", c.DisablePEX, "disable PEX peer discovery")
 	flag.BoolVar(&c.DownloadPeerList, "download-peerlist", c.DownloadPeerList, "download a peers.txt from -peerlist-url")
 	flag.StringVar(&c.PeerListURL, "peerlist-url", c.PeerListURL, "with -download-peerlist=true, download a peers.txt file from this url")
+	flag.StringVar(&c.PeerListPubkeyStr, "peerlist-pubkey", c.PeerListPubkeyStr, "Verify the signature of the downloaded peers list with this hex-encoded pubkey")
+	flag.BoolVar(&c.RequireSignedPeerList, "require-signed-peerlist", c.RequireSignedPeerList, "Reject a downloaded peers list that is not signed by -peerlist-pubkey")
+	flag.StringVar(&c.DNSSeeds, "dns-seeds", c.DNSSeeds, "Comma-separated hostnames of DNS seeds to resolve to peer addresses on startup. A seed without a port uses the default port of the network")
 	flag.BoolVar(&c.DisableOutgoingConnections, "disable-outgoing", c.DisableOutgoingConnections, "Don't make outgoing connections")
 	flag.BoolVar(&c.DisableIncomingConnections, "disable-incoming", c.DisableIncomingConnections, "Don't allow incoming connections")
 	flag.BoolVar(&c.DisableNetworking, "disable-networking", c.DisableNetworking, "Disable all network activity")
+	flag.BoolVar(&c.EnablePeerEncryption, "enable-peer-encryption", c.EnablePeerEncryption, "Encrypt the connections with peers that support encryption. Peers are not authenticated, so this doesn't protect against a man-in-the-middle")
+	flag.BoolVar(&c.RequirePeerEncryption, "require-peer-encryption", c.RequirePeerEncryption, "Reject the connections with peers that don't support encryption")
+	flag.StringVar(&c.Proxy, "proxy", c.Proxy, "Connect to onion peers through this SOCKS5 proxy, e.g. Tor at 127.0.0.1:9050")
+	flag.StringVar(&c.ProxyUsername, "proxy-username", c.ProxyUsername, "Username for the SOCKS5 proxy")
+	flag.StringVar(&c.ProxyPassword, "proxy-password", c.ProxyPassword, "Password for the SOCKS5 proxy")
+	flag.BoolVar(&c.ProxyOnly, "proxy-only", c.ProxyOnly, "Make all outgoing connections through -proxy and disable incoming connections")
+	flag.StringVar(&c.OnionAddress, "onion-address", c.OnionAddress, "Onion address (onion:port) of a Tor onion service that forwards to the listening port, advertised to peers")
+	flag.BoolVar(&c.DisableHeadersSync, "disable-headers-sync", c.DisableHeadersSync, "Don't use headers-first parallel block synchronization")
+	flag.BoolVar(&c.DisableCompactBlocks, "disable-compact-blocks", c.DisableCompactBlocks, "Don't send or request compact blocks")
+	flag.BoolVar(&c.DisableDandelion, "disable-dandelion", c.DisableDandelion, "Broadcast transactions to all peers instead of relaying them along a Dandelion++ stem")
+	flag.Float64Var(&c.DandelionFluffProbability, "dandelion-fluff-probability", c.DandelionFluffProbability, "Probability that the node fluffs the stem transactions it receives during an epoch")
+	flag.DurationVar(&c.DandelionEmbargoDuration, "dandelion-embargo", c.DandelionEmbargoDuration, "Minimum time to wait for a stem transaction to be fluffed by another node before fluffing it")
 	flag.BoolVar(&c.EnableGUI, "enable-gui", c.EnableGUI, "Enable GUI")
 	flag.BoolVar(&c.DisableCSRF, "disable-csrf", c.DisableCSRF, "disable CSRF check")
 	flag.BoolVar(&c.DisableHeaderCheck, "disable-header-check", c.DisableHeaderCheck, "disables the host, origin and referer header checks.")
@@ -688,6 +865,7 @@ func (c *NodeConfig) RegisterFlags() {
 	flag.StringVar(&c.DataDirectory, "data-dir", c.DataDirectory, "directory to store app data (defaults to ~/.skycoin)")
 	flag.StringVar(&c.DBPath, "db-path", c.DBPath, "path of database file (defaults to ~/.skycoin/data.db)")
 	flag.BoolVar(&c.DBReadOnly, "db-read-only", c.DBReadOnly, "open bolt db read-only")
+	flag.StringVar(&c.DBBackend, "db-backend", c.DBBackend, "database backend. Choices are: bolt, memory. The memory backend loads the db-path file if it exists and does not write to it")
 	flag.BoolVar(&c.ProfileCPU, "profile-cpu", c.ProfileCPU, "enable cpu profiling")
 	flag.StringVar(&c.ProfileCPUFile, "profile-cpu-file", c.ProfileCPUFile, "where to write the cpu profile file")
 	flag.BoolVar(&c.HTTPProf, "http-prof", c.HTTPProf, "run the HTTP profiling interface")
@@ -699,7 +877,12 @@ func (c *NodeConfig) RegisterFlags() {
 	flag.StringVar(&c.GUIDirectory, "gui-dir", c.GUIDirectory, "static content directory for the HTML interface")
 
 	flag.BoolVar(&c.VerifyDB, "verify-db", c.VerifyDB, "check the database for corruption")
-	flag.BoolVar(&c.ResetCorruptDB, "reset-corrupt-db", c.ResetCorruptDB, "reset the database if corrupted, and continue running instead of exiting")
+	flag.BoolVar(&c.ResetCorruptDB, "reset-corrupt-db", c.ResetCorruptDB, "repair the database if corrupted, or reset it if it can't be repaired, and continue running instead of exiting")
+	flag.StringVar(&c.ImportBlocks, "import-blocks", c.ImportBlocks, "import the blocks of a blocks file created by skycoin-cli exportBlocks on startup")
+	flag.StringVar(&c.ImportSnapshot, "import-snapshot", c.ImportSnapshot, "bootstrap the blockchain from a snapshot file created by skycoin-cli exportSnapshot on startup. The snapshot must come from a trusted source")
+	flag.Uint64Var(&c.PruneDepth, "prune-depth", c.PruneDepth, "discard the bodies and the history of the blocks deeper than this number of blocks, keeping the unspent outputs and the block headers. 0 disables pruning. Pruning can't be undone")
+	flag.IntVar(&c.SignatureCacheSize, "signature-cache-size", c.SignatureCacheSize, "number of verified transaction input signatures cached, so that they are not verified again when transactions are refreshed or included in blocks. 0 disables the cache")
+	flag.IntVar(&c.HistoryRebuildBatchSize, "history-rebuild-batch-size", c.HistoryRebuildBatchSize, "number of blocks parsed in each database transaction when the history database is rebuilt in the background")
 
 	flag.BoolVar(&c.DisableDefaultPeers, "disable-default-peers", c.DisableDefaultPeers, "disable the hardcoded default peers")
 	flag.StringVar(&c.CustomPeersFile, "custom-peers-file", c.CustomPeersFile, "load custom peers from a newline separate list of ip:port in a file. Note that this is different from the peers.json file in the data directory")
@@ -731,10 +914,17 @@ func (c *NodeConfig) RegisterFlags() {
 	flag.IntVar(&c.MaxDefaultPeerOutgoingConnections, "max-default-peer-outgoing-connections", c.MaxDefaultPeerOutgoingConnections, "The maximum default peer outgoing connections allowed")
 	flag.IntVar(&c.PeerlistSize, "peerlist-size", c.PeerlistSize, "Max number of peers to track in peerlist")
 	flag.DurationVar(&c.OutgoingConnectionsRate, "connection-rate", c.OutgoingConnectionsRate, "How often to make an outgoing connection")
+	flag.IntVar(&c.BanScore, "ban-score", c.BanScore, "Ban a peer's IP when its reputation score falls to -ban-score. Set to 0 to disable banning")
+	flag.DurationVar(&c.BanDuration, "ban-duration", c.BanDuration, "How long to ban a misbehaving peer's IP for")
 	flag.IntVar(&c.MaxOutgoingMessageLength, "max-out-msg-len", c.MaxOutgoingMessageLength, "Maximum length of outgoing wire messages")
 	flag.IntVar(&c.MaxIncomingMessageLength, "max-in-msg-len", c.MaxIncomingMessageLength, "Maximum length of incoming wire messages")
+	flag.Int64Var(&c.MaxUploadRate, "max-upload-rate", c.MaxUploadRate, "Maximum upload rate of all connections, in bytes per second. Set to 0 for no limit")
+	flag.Int64Var(&c.MaxPeerUploadRate, "max-peer-upload-rate", c.MaxPeerUploadRate, "Maximum upload rate of each connection, in bytes per second. Set to 0 for no limit")
+	flag.Uint64Var(&c.MaxDailyUpload, "max-daily-upload", c.MaxDailyUpload, "Maximum bytes uploaded per day, after which blocks and transactions are no longer served. Set to 0 for no limit")
 	flag.BoolVar(&c.LocalhostOnly, "localhost-only", c.LocalhostOnly, "Run on localhost and only connect to localhost peers")
 	flag.StringVar(&c.WalletCryptoType, "wallet-crypto-type", c.WalletCryptoType, "wallet crypto type. Can be sha256-xor or scrypt-chacha20poly1305")
+	flag.DurationVar(&c.WalletMaxSessionTimeout, "wallet-max-session-timeout", c.WalletMaxSessionTimeout, "Maximum duration an encrypted wallet can be unlocked for with /api/v2/wallet/unlock. Set to 0 to disable unlocking")
+	flag.BoolVar(&c.DisableWalletAddressDiscovery, "disable-wallet-address-discovery", c.DisableWalletAddressDiscovery, "Disable the background gap limit address discovery of wallets")
 	flag.BoolVar(&c.Version, "version", false, "show node version")
 }
 
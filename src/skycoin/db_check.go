@@ -23,7 +23,7 @@ const (
 type dbCheckConfig struct {
 	// ForceVerify force verify DB
 	ForceVerify bool
-	// ResetCorruptDB reset the DB if it is corrupted
+	// ResetCorruptDB repair the DB if it is corrupted, or reset it if it can't be repaired
 	ResetCorruptDB bool
 	// AppVersion is the current wallet version
 	AppVersion *semver.Version
@@ -56,11 +56,11 @@ func (dv dbVerify) CheckDatabase(db *dbutil.DB) error {
 }
 
 func (dv *dbVerify) ResetCorruptDB(db *dbutil.DB) (*dbutil.DB, error) {
-	dv.logger.Info("Checking database and resetting if corrupted")
-	newDB, err := visor.ResetCorruptDB(db, dv.blockchainPubkey, dv.quit)
+	dv.logger.Info("Checking database and repairing or resetting it if corrupted")
+	newDB, err := visor.RepairCorruptDB(db, dv.blockchainPubkey, dv.quit)
 	if err != nil {
 		if err != visor.ErrVerifyStopped {
-			dv.logger.WithError(err).Error("visor.ResetCorruptDB failed")
+			dv.logger.WithError(err).Error("visor.RepairCorruptDB failed")
 		}
 		return nil, err
 	}
@@ -110,7 +110,7 @@ func checkAndUpdateDB(db *dbutil.DB, c dbCheckConfig, dv dbCheckCorruptResetter)
 			return nil, err
 		}
 	case doResetCorruptDB:
-		// Check the database integrity and recreate it if necessary
+		// Check the database integrity and repair or recreate it if necessary
 		newDB, err := dv.ResetCorruptDB(db)
 		if err != nil {
 			return nil, err
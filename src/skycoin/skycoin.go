@@ -4,6 +4,7 @@ Package skycoin implements the main daemon cmd's configuration and setup
 package skycoin
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -148,8 +149,8 @@ func (c *Coin) Run() error {
 	sconf := c.ConfigureStorage()
 
 	// Open the database
-	c.logger.Infof("Opening database %s", c.config.Node.DBPath)
-	db, err = visor.OpenDB(c.config.Node.DBPath, c.config.Node.DBReadOnly)
+	c.logger.Infof("Opening %s database %s", c.config.Node.DBBackend, c.config.Node.DBPath)
+	db, err = visor.OpenDBBackend(c.config.Node.DBBackend, c.config.Node.DBPath, c.config.Node.DBReadOnly)
 	if err != nil {
 		c.logger.Errorf("Database failed to open: %v. Is another skycoin instance running?", err)
 		return err
@@ -242,6 +243,41 @@ func (c *Coin) Run() error {
 		return err
 	}
 
+	if c.config.Node.ImportSnapshot != "" {
+		if err := c.importSnapshot(v, quit); err != nil {
+			c.logger.WithError(err).Error("importSnapshot failed")
+			return err
+		}
+	}
+
+	if c.config.Node.ImportBlocks != "" {
+		if err := c.importBlocks(v, quit); err != nil {
+			c.logger.WithError(err).Error("importBlocks failed")
+			return err
+		}
+	}
+
+	historyRebuildQuit := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		c.logger.Info("visor.RebuildHistory")
+		if err := v.RebuildHistory(historyRebuildQuit); err != nil {
+			c.logger.WithError(err).Error("visor.RebuildHistory failed")
+			errC <- err
+		}
+	}()
+
+	walletDiscoveryQuit := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		c.logger.Info("wallet.RunAddressDiscovery")
+		w.RunAddressDiscovery(v.TransactionsFinder(), walletDiscoveryQuit)
+	}()
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -289,6 +325,8 @@ func (c *Coin) Run() error {
 	case <-quit:
 	case retErr = <-errC:
 		c.logger.WithError(err).Error("Received error from errC (something prior has failed)")
+	case retErr = <-db.Failed():
+		c.logger.WithError(retErr).Error("The database failed and can't be used anymore")
 	}
 
 	c.logger.Info("Shutting down...")
@@ -301,6 +339,12 @@ func (c *Coin) Run() error {
 	c.logger.Info("Closing daemon")
 	d.Shutdown()
 
+	c.logger.Info("Stopping wallet address discovery")
+	close(walletDiscoveryQuit)
+
+	c.logger.Info("Stopping the history rebuild")
+	close(historyRebuildQuit)
+
 	c.logger.Info("Waiting for goroutines to finish")
 	wg.Wait()
 
@@ -338,6 +382,46 @@ func (c *Coin) initLogFile() (*os.File, error) {
 	return f, nil
 }
 
+// importBlocks imports the blocks of the -import-blocks blocks file
+func (c *Coin) importBlocks(v *visor.Visor, quit chan struct{}) error {
+	c.logger.Infof("Importing blocks from %s", c.config.Node.ImportBlocks)
+
+	f, err := os.Open(c.config.Node.ImportBlocks)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	n, err := v.ImportBlocks(bufio.NewReader(f), quit)
+	c.logger.Infof("Imported %d blocks from %s", n, c.config.Node.ImportBlocks)
+	if err == visor.ErrBlocksFileStopped {
+		return nil
+	}
+	return err
+}
+
+// importSnapshot bootstraps the blockchain from the -import-snapshot snapshot file
+func (c *Coin) importSnapshot(v *visor.Visor, quit chan struct{}) error {
+	c.logger.Infof("Importing snapshot from %s", c.config.Node.ImportSnapshot)
+
+	f, err := os.Open(c.config.Node.ImportSnapshot)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hdr, err := v.ImportSnapshot(bufio.NewReader(f), quit)
+	if err != nil {
+		if err == visor.ErrSnapshotStopped {
+			return nil
+		}
+		return err
+	}
+
+	c.logger.Infof("Imported snapshot at block %d with %d outputs and outputs hash %s from %s", hdr.Seq, hdr.OutputsCount, hdr.OutputsHash.Hex(), c.config.Node.ImportSnapshot)
+	return nil
+}
+
 // ConfigureVisor sets the visor config values
 func (c *Coin) ConfigureVisor() visor.Config {
 	vc := visor.NewConfig()
@@ -359,6 +443,11 @@ func (c *Coin) ConfigureVisor() visor.Config {
 	vc.GenesisTimestamp = c.config.Node.GenesisTimestamp
 	vc.GenesisCoinVolume = c.config.Node.GenesisCoinVolume
 
+	vc.PruneDepth = c.config.Node.PruneDepth
+	vc.SignatureCacheSize = c.config.Node.SignatureCacheSize
+	vc.HistoryRebuildBatchSize = c.config.Node.HistoryRebuildBatchSize
+	vc.DataDirectory = c.config.Node.DataDirectory
+
 	return vc
 }
 
@@ -377,6 +466,8 @@ func (c *Coin) ConfigureWallet() wallet.Config {
 	}
 
 	wc.CryptoType = cryptoType
+	wc.MaxSessionTimeout = c.config.Node.WalletMaxSessionTimeout
+	wc.DisableAddressDiscovery = c.config.Node.DisableWalletAddressDiscovery
 
 	bc := c.config.Node.Fiber.Bip44Coin
 	wc.Bip44Coin = &bc
@@ -406,6 +497,14 @@ func (c *Coin) ConfigureDaemon() daemon.Config {
 	dc.Pool.MaxIncomingConnections = c.config.Node.MaxIncomingConnections
 	dc.Pool.MaxIncomingMessageLength = c.config.Node.MaxIncomingMessageLength
 	dc.Pool.MaxOutgoingMessageLength = c.config.Node.MaxOutgoingMessageLength
+	dc.Pool.MaxUploadRate = c.config.Node.MaxUploadRate
+	dc.Pool.MaxPeerUploadRate = c.config.Node.MaxPeerUploadRate
+	dc.Pool.MaxDailyUpload = c.config.Node.MaxDailyUpload
+	dc.Pool.EnableEncryption = c.config.Node.EnablePeerEncryption
+	dc.Pool.RequireEncryption = c.config.Node.RequirePeerEncryption
+	dc.Pool.Proxy = c.config.Node.Proxy
+	dc.Pool.ProxyUsername = c.config.Node.ProxyUsername
+	dc.Pool.ProxyPassword = c.config.Node.ProxyPassword
 
 	dc.Pex.DataDirectory = c.config.Node.DataDirectory
 	dc.Pex.Disabled = c.config.Node.DisablePEX
@@ -413,6 +512,10 @@ func (c *Coin) ConfigureDaemon() daemon.Config {
 	dc.Pex.Max = c.config.Node.PeerlistSize
 	dc.Pex.DownloadPeerList = c.config.Node.DownloadPeerList
 	dc.Pex.PeerListURL = c.config.Node.PeerListURL
+	dc.Pex.PeerListPubKey = c.config.Node.peerListPubkey
+	dc.Pex.RequireSignedPeerList = c.config.Node.RequireSignedPeerList
+	dc.Pex.DNSSeeds = c.config.Node.dnsSeeds
+	dc.Pex.DNSSeedPort = uint16(c.config.Node.dnsSeedPort)
 	dc.Pex.DisableTrustedPeers = c.config.Node.DisableDefaultPeers
 	dc.Pex.CustomPeersFile = c.config.Node.CustomPeersFile
 	dc.Pex.DefaultConnections = c.config.Node.DefaultConnections
@@ -425,9 +528,16 @@ func (c *Coin) ConfigureDaemon() daemon.Config {
 	dc.Daemon.DisableOutgoingConnections = c.config.Node.DisableOutgoingConnections
 	dc.Daemon.DisableIncomingConnections = c.config.Node.DisableIncomingConnections
 	dc.Daemon.DisableNetworking = c.config.Node.DisableNetworking
+	dc.Daemon.DisableHeadersSync = c.config.Node.DisableHeadersSync
+	dc.Daemon.DisableCompactBlocks = c.config.Node.DisableCompactBlocks
+	dc.Daemon.DisableDandelion = c.config.Node.DisableDandelion
+	dc.Daemon.DandelionFluffProbability = c.config.Node.DandelionFluffProbability
+	dc.Daemon.DandelionEmbargoDuration = c.config.Node.DandelionEmbargoDuration
 	dc.Daemon.Port = c.config.Node.Port
 	dc.Daemon.Address = c.config.Node.Address
 	dc.Daemon.LocalhostOnly = c.config.Node.LocalhostOnly
+	dc.Daemon.ProxyOnly = c.config.Node.ProxyOnly
+	dc.Daemon.OnionAddress = c.config.Node.OnionAddress
 	dc.Daemon.MaxConnections = c.config.Node.MaxConnections
 	dc.Daemon.MaxOutgoingConnections = c.config.Node.MaxOutgoingConnections
 	dc.Daemon.DataDirectory = c.config.Node.DataDirectory
@@ -436,6 +546,8 @@ func (c *Coin) ConfigureDaemon() daemon.Config {
 	dc.Daemon.GenesisHash = c.config.Node.genesisHash
 	dc.Daemon.UserAgent = c.config.Node.userAgent
 	dc.Daemon.UnconfirmedVerifyTxn = c.config.Node.UnconfirmedVerifyTxn
+	dc.Daemon.BanScore = c.config.Node.BanScore
+	dc.Daemon.BanDuration = c.config.Node.BanDuration
 
 	if c.config.Node.OutgoingConnectionsRate == 0 {
 		c.config.Node.OutgoingConnectionsRate = time.Millisecond
@@ -466,6 +578,7 @@ func (c *Coin) createGUI(gw *api.Gateway, host string) (*api.Server, error) {
 			Fiber:           c.config.Node.Fiber,
 			DaemonUserAgent: c.config.Node.userAgent,
 			BlockPublisher:  c.config.Node.RunBlockPublisher,
+			PruneDepth:      c.config.Node.PruneDepth,
 		},
 		Username: c.config.Node.WebInterfaceUsername,
 		Password: c.config.Node.WebInterfacePassword,
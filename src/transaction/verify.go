@@ -82,6 +82,9 @@ const (
 	TxnSigned TxnSignedFlag = 1
 	// TxnUnsigned is used for unsigned transactions
 	TxnUnsigned TxnSignedFlag = 2
+	// TxnSignedVerified is used for signed transactions whose input signatures were already verified
+	// with txn.VerifyInputSignatures() against the same inputs, so the signatures are not verified again
+	TxnSignedVerified TxnSignedFlag = 3
 )
 
 // ErrTxnViolatesHardConstraint is returned when a transaction violates hard constraints
@@ -245,8 +248,13 @@ func VerifySingleTxnHardConstraints(txn coin.Transaction, head coin.BlockHeader,
 // NOTE: Double spends are checked against the unspent output pool when querying for uxIn
 // NOTE: output hours overflow is treated as a soft constraint for transactions inside of a block, due to a bug
 //       which allowed some blocks to be published with overflowing output hours.
-func VerifyBlockTxnConstraints(txn coin.Transaction, head coin.BlockHeader, uxIn coin.UxArray) error {
-	if err := verifyTxnHardConstraints(txn, head, uxIn, TxnSigned); err != nil {
+// NOTE: signed must be TxnSigned, or TxnSignedVerified if the signatures were already verified
+func VerifyBlockTxnConstraints(txn coin.Transaction, head coin.BlockHeader, uxIn coin.UxArray, signed TxnSignedFlag) error {
+	if signed == TxnUnsigned {
+		logger.Panic("VerifyBlockTxnConstraints requires a signed transaction")
+	}
+
+	if err := verifyTxnHardConstraints(txn, head, uxIn, signed); err != nil {
 		return NewErrTxnViolatesHardConstraint(err)
 	}
 
@@ -285,6 +293,11 @@ func verifyTxnHardConstraints(txn coin.Transaction, head coin.BlockHeader, uxIn
 		if err := txn.VerifyInputSignatures(uxIn); err != nil {
 			return err
 		}
+	case TxnSignedVerified:
+		// The input signatures were already verified against these inputs
+		if err := txn.VerifyWithoutSignatures(); err != nil {
+			return err
+		}
 	case TxnUnsigned:
 		if err := txn.VerifyUnsigned(); err != nil {
 			return err
@@ -0,0 +1,76 @@
+/*
+Package mlock provides buffers of memory that is locked into RAM, so that the secrets
+kept in them are not written to swap, and that are zeroed when they are destroyed
+*/
+package mlock
+
+import (
+	"errors"
+	"os"
+	"sync"
+)
+
+var (
+	// ErrUnsupported locked memory is not supported on this platform
+	ErrUnsupported = errors.New("Locked memory is not supported on this platform")
+	// ErrDestroyed the buffer was destroyed
+	ErrDestroyed = errors.New("Locked buffer was destroyed")
+)
+
+// Buffer is a byte buffer allocated outside of the Go heap and locked into RAM.
+// It must be destroyed to zero and release its memory.
+type Buffer struct {
+	lock sync.Mutex
+	mem  []byte
+	size int
+}
+
+// NewBuffer copies data into a new locked buffer
+func NewBuffer(data []byte) (*Buffer, error) {
+	// The memory is allocated in whole pages, which are the unit of locking
+	pageSize := os.Getpagesize()
+	n := ((len(data) / pageSize) + 1) * pageSize
+
+	mem, err := alloc(n)
+	if err != nil {
+		return nil, err
+	}
+
+	copy(mem, data)
+
+	return &Buffer{
+		mem:  mem,
+		size: len(data),
+	}, nil
+}
+
+// View calls f with the content of the buffer. The content must not be retained after f returns
+func (b *Buffer) View(f func([]byte) error) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.mem == nil {
+		return ErrDestroyed
+	}
+
+	return f(b.mem[:b.size])
+}
+
+// Destroy zeroes the buffer and releases its memory. Destroying a buffer twice has no effect
+func (b *Buffer) Destroy() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.mem == nil {
+		return nil
+	}
+
+	for i := range b.mem {
+		b.mem[i] = 0
+	}
+
+	err := free(b.mem)
+	b.mem = nil
+	b.size = 0
+	return err
+}
@@ -0,0 +1,12 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris && !windows
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris,!windows
+
+package mlock
+
+func alloc(n int) ([]byte, error) {
+	return nil, ErrUnsupported
+}
+
+func free(mem []byte) error {
+	return ErrUnsupported
+}
@@ -0,0 +1,41 @@
+package mlock
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuffer(t *testing.T) {
+	for _, n := range []int{0, 1, 100, os.Getpagesize(), os.Getpagesize() + 1} {
+		data := bytes.Repeat([]byte{0xAB}, n)
+
+		b, err := NewBuffer(data)
+		require.NoError(t, err)
+
+		// The buffer holds a copy of the data
+		for i := range data {
+			data[i] = 0
+		}
+
+		err = b.View(func(v []byte) error {
+			require.Equal(t, bytes.Repeat([]byte{0xAB}, n), v)
+			return nil
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, b.Destroy())
+		require.Nil(t, b.mem)
+
+		err = b.View(func(v []byte) error {
+			t.Fatal("View of a destroyed buffer should fail")
+			return nil
+		})
+		require.Equal(t, ErrDestroyed, err)
+
+		// Destroying a buffer twice has no effect
+		require.NoError(t, b.Destroy())
+	}
+}
@@ -0,0 +1,33 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package mlock
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// alloc maps anonymous memory and locks it into RAM
+func alloc(n int) ([]byte, error) {
+	mem, err := unix.Mmap(-1, 0, n, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_ANON|unix.MAP_PRIVATE)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := unix.Mlock(mem); err != nil {
+		if err := unix.Munmap(mem); err != nil {
+			return nil, err
+		}
+		return nil, err
+	}
+
+	return mem, nil
+}
+
+// free unlocks and unmaps memory returned by alloc
+func free(mem []byte) error {
+	if err := unix.Munlock(mem); err != nil {
+		return err
+	}
+	return unix.Munmap(mem)
+}
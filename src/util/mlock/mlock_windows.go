@@ -0,0 +1,43 @@
+//go:build windows
+// +build windows
+
+package mlock
+
+import (
+	"reflect"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// alloc allocates virtual memory and locks it into RAM
+func alloc(n int) ([]byte, error) {
+	addr, err := windows.VirtualAlloc(0, uintptr(n), windows.MEM_COMMIT|windows.MEM_RESERVE, windows.PAGE_READWRITE)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := windows.VirtualLock(addr, uintptr(n)); err != nil {
+		if err := windows.VirtualFree(addr, 0, windows.MEM_RELEASE); err != nil {
+			return nil, err
+		}
+		return nil, err
+	}
+
+	var mem []byte
+	h := (*reflect.SliceHeader)(unsafe.Pointer(&mem))
+	h.Data = addr
+	h.Len = n
+	h.Cap = n
+
+	return mem, nil
+}
+
+// free unlocks and releases memory returned by alloc
+func free(mem []byte) error {
+	addr := uintptr(unsafe.Pointer(&mem[0]))
+	if err := windows.VirtualUnlock(addr, uintptr(len(mem))); err != nil {
+		return err
+	}
+	return windows.VirtualFree(addr, 0, windows.MEM_RELEASE)
+}
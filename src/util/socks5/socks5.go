@@ -0,0 +1,261 @@
+/*
+Package socks5 implements a SOCKS5 client (RFC 1928) with username/password authentication (RFC 1929),
+for connecting to peers through a proxy such as Tor
+*/
+package socks5
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+const (
+	socksVersion = 5
+
+	authNone         = 0x00
+	authUserPassword = 0x02
+	authNoAcceptable = 0xff
+
+	userPasswordVersion = 1
+
+	cmdConnect = 0x01
+
+	atypIPv4   = 0x01
+	atypDomain = 0x03
+	atypIPv6   = 0x04
+)
+
+var (
+	// ErrInvalidVersion the proxy replied with a version other than SOCKS5
+	ErrInvalidVersion = errors.New("SOCKS5 proxy replied with an invalid version")
+	// ErrNoAcceptableAuth the proxy does not accept our authentication methods
+	ErrNoAcceptableAuth = errors.New("SOCKS5 proxy does not accept the authentication methods")
+	// ErrAuthFailed the proxy rejected the username and password
+	ErrAuthFailed = errors.New("SOCKS5 proxy authentication failed")
+	// ErrCredentialsTooLong the username or password is longer than 255 bytes
+	ErrCredentialsTooLong = errors.New("SOCKS5 username or password is too long")
+	// ErrHostTooLong the destination hostname is longer than 255 bytes
+	ErrHostTooLong = errors.New("SOCKS5 destination hostname is too long")
+	// ErrInvalidAddressType the proxy replied with an unknown address type
+	ErrInvalidAddressType = errors.New("SOCKS5 proxy replied with an invalid address type")
+)
+
+// replyMessages are the messages of the reply codes of RFC 1928
+var replyMessages = map[byte]string{
+	0x01: "general SOCKS server failure",
+	0x02: "connection not allowed by ruleset",
+	0x03: "network unreachable",
+	0x04: "host unreachable",
+	0x05: "connection refused",
+	0x06: "TTL expired",
+	0x07: "command not supported",
+	0x08: "address type not supported",
+}
+
+// ReplyError is returned when the proxy fails to connect to the destination
+type ReplyError struct {
+	Code byte
+}
+
+func (e ReplyError) Error() string {
+	msg, ok := replyMessages[e.Code]
+	if !ok {
+		msg = "unknown error"
+	}
+	return fmt.Sprintf("SOCKS5 proxy error %d: %s", e.Code, msg)
+}
+
+// Dialer connects to addresses through a SOCKS5 proxy
+type Dialer struct {
+	// Proxy is the host:port address of the proxy
+	Proxy string
+	// Username and Password authenticate to the proxy. If Username is empty, no authentication is used.
+	// Tor isolates the circuits of connections with different credentials.
+	Username string
+	Password string
+	// Timeout is the maximum time to connect to the proxy and to the destination through it. 0 means no timeout
+	Timeout time.Duration
+}
+
+// Dial connects to a host:port address through the proxy. The host can be an IP address or a hostname,
+// which is resolved by the proxy (e.g. an onion address with Tor).
+// The RemoteAddr of the returned connection is the destination address, not the address of the proxy.
+func (d Dialer) Dial(addr string) (net.Conn, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %q", portStr)
+	}
+
+	conn, err := net.DialTimeout("tcp", d.Proxy, d.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.Timeout > 0 {
+		if err := conn.SetDeadline(time.Now().Add(d.Timeout)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	if err := d.handshake(conn, host, uint16(port)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if d.Timeout > 0 {
+		if err := conn.SetDeadline(time.Time{}); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return &Conn{
+		Conn: conn,
+		addr: destAddr(addr),
+	}, nil
+}
+
+// handshake negotiates the authentication and sends the CONNECT request
+func (d Dialer) handshake(conn net.Conn, host string, port uint16) error {
+	method := byte(authNone)
+	if d.Username != "" {
+		method = authUserPassword
+	}
+
+	if _, err := conn.Write([]byte{socksVersion, 1, method}); err != nil {
+		return err
+	}
+
+	var reply [2]byte
+	if _, err := io.ReadFull(conn, reply[:]); err != nil {
+		return err
+	}
+	if reply[0] != socksVersion {
+		return ErrInvalidVersion
+	}
+
+	switch reply[1] {
+	case authNone:
+	case authUserPassword:
+		if method != authUserPassword {
+			return ErrNoAcceptableAuth
+		}
+		if err := d.authenticate(conn); err != nil {
+			return err
+		}
+	default:
+		return ErrNoAcceptableAuth
+	}
+
+	req := []byte{socksVersion, cmdConnect, 0}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip4 := ip.To4(); ip4 != nil {
+			req = append(req, atypIPv4)
+			req = append(req, ip4...)
+		} else {
+			req = append(req, atypIPv6)
+			req = append(req, ip.To16()...)
+		}
+	} else {
+		if len(host) > 255 {
+			return ErrHostTooLong
+		}
+		req = append(req, atypDomain, byte(len(host)))
+		req = append(req, host...)
+	}
+	req = append(req, byte(port>>8), byte(port))
+
+	if _, err := conn.Write(req); err != nil {
+		return err
+	}
+
+	// The reply is the version, the reply code, a reserved byte and the bound address
+	var head [4]byte
+	if _, err := io.ReadFull(conn, head[:]); err != nil {
+		return err
+	}
+	if head[0] != socksVersion {
+		return ErrInvalidVersion
+	}
+	if head[1] != 0 {
+		return ReplyError{Code: head[1]}
+	}
+
+	var n int
+	switch head[3] {
+	case atypIPv4:
+		n = net.IPv4len
+	case atypIPv6:
+		n = net.IPv6len
+	case atypDomain:
+		var l [1]byte
+		if _, err := io.ReadFull(conn, l[:]); err != nil {
+			return err
+		}
+		n = int(l[0])
+	default:
+		return ErrInvalidAddressType
+	}
+
+	// Discard the bound address and port
+	_, err := io.ReadFull(conn, make([]byte, n+2))
+	return err
+}
+
+// authenticate sends the username and password
+func (d Dialer) authenticate(conn net.Conn) error {
+	if len(d.Username) > 255 || len(d.Password) > 255 {
+		return ErrCredentialsTooLong
+	}
+
+	req := []byte{userPasswordVersion, byte(len(d.Username))}
+	req = append(req, d.Username...)
+	req = append(req, byte(len(d.Password)))
+	req = append(req, d.Password...)
+
+	if _, err := conn.Write(req); err != nil {
+		return err
+	}
+
+	var reply [2]byte
+	if _, err := io.ReadFull(conn, reply[:]); err != nil {
+		return err
+	}
+	if reply[1] != 0 {
+		return ErrAuthFailed
+	}
+
+	return nil
+}
+
+// Conn is a connection through the proxy
+type Conn struct {
+	net.Conn
+	addr destAddr
+}
+
+// RemoteAddr returns the destination address
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.addr
+}
+
+// destAddr is the net.Addr of a destination, which can be a hostname
+type destAddr string
+
+func (a destAddr) Network() string {
+	return "tcp"
+}
+
+func (a destAddr) String() string {
+	return string(a)
+}
@@ -0,0 +1,150 @@
+package socks5
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/util/socks5/socks5test"
+)
+
+// listenEcho starts a server that echoes back what it receives
+func listenEcho(t *testing.T) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn) //nolint:errcheck
+			}()
+		}
+	}()
+
+	return l
+}
+
+func TestDial(t *testing.T) {
+	echo := listenEcho(t)
+	defer echo.Close()
+
+	onion := "pg6mmjiyjmcrsslvykfwnntlaru7p5svn6y2ymmju6nubxndf4pscryd.onion:6000"
+
+	cases := []struct {
+		name        string
+		username    string
+		password    string
+		srvUsername string
+		srvPassword string
+		addr        string
+		dest        string
+		err         error
+	}{
+		{
+			name: "ip",
+			addr: echo.Addr().String(),
+			dest: echo.Addr().String(),
+		},
+		{
+			name: "onion",
+			addr: onion,
+			dest: onion,
+		},
+		{
+			name:        "auth",
+			username:    "user",
+			password:    "pass",
+			srvUsername: "user",
+			srvPassword: "pass",
+			addr:        onion,
+			dest:        onion,
+		},
+		{
+			name:        "auth wrong password",
+			username:    "user",
+			password:    "wrong",
+			srvUsername: "user",
+			srvPassword: "pass",
+			addr:        onion,
+			err:         ErrAuthFailed,
+		},
+		{
+			name:        "auth required",
+			srvUsername: "user",
+			srvPassword: "pass",
+			addr:        onion,
+			err:         ErrNoAcceptableAuth,
+		},
+		{
+			name: "unreachable",
+			addr: "unknown.onion:6000",
+			dest: "unknown.onion:6000",
+			err:  ReplyError{Code: 4},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, err := socks5test.NewServer()
+			require.NoError(t, err)
+			defer srv.Close()
+
+			srv.Username = tc.srvUsername
+			srv.Password = tc.srvPassword
+			srv.Resolve["pg6mmjiyjmcrsslvykfwnntlaru7p5svn6y2ymmju6nubxndf4pscryd.onion"] = echo.Addr().String()
+
+			d := Dialer{
+				Proxy:    srv.Addr(),
+				Username: tc.username,
+				Password: tc.password,
+				Timeout:  time.Second * 5,
+			}
+
+			conn, err := d.Dial(tc.addr)
+			if tc.err != nil {
+				require.Equal(t, tc.err, err)
+				if tc.dest != "" {
+					require.Equal(t, []string{tc.dest}, srv.Destinations())
+				}
+				return
+			}
+			require.NoError(t, err)
+			defer conn.Close()
+
+			require.Equal(t, tc.addr, conn.RemoteAddr().String())
+			require.Equal(t, []string{tc.dest}, srv.Destinations())
+
+			_, err = conn.Write([]byte("hello"))
+			require.NoError(t, err)
+			b := make([]byte, 5)
+			_, err = io.ReadFull(conn, b)
+			require.NoError(t, err)
+			require.Equal(t, "hello", string(b))
+		})
+	}
+}
+
+func TestDialInvalidAddress(t *testing.T) {
+	d := Dialer{
+		Proxy: "127.0.0.1:1",
+	}
+
+	_, err := d.Dial("example.onion")
+	require.Error(t, err)
+
+	_, err = d.Dial("example.onion:70000")
+	require.Error(t, err)
+}
+
+func TestReplyError(t *testing.T) {
+	require.Equal(t, "SOCKS5 proxy error 5: connection refused", ReplyError{Code: 5}.Error())
+	require.Equal(t, "SOCKS5 proxy error 99: unknown error", ReplyError{Code: 99}.Error())
+}
@@ -0,0 +1,215 @@
+/*
+Package socks5test provides a SOCKS5 server for tests, standing in for a proxy such as Tor
+*/
+package socks5test
+
+import (
+	"io"
+	"net"
+	"strconv"
+	"sync"
+)
+
+// Server is a SOCKS5 server that supports the CONNECT command, with optional username/password authentication.
+// Hostnames can be mapped to local addresses with Resolve, so that tests can connect to onion addresses.
+type Server struct {
+	// Username and Password are required if Username is not empty
+	Username string
+	Password string
+	// Resolve maps destination hostnames to the host:port addresses that the server connects to
+	Resolve map[string]string
+
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu    sync.Mutex
+	dests []string
+}
+
+// NewServer starts a server listening on a random local port
+func NewServer() (*Server, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		Resolve:  make(map[string]string),
+		listener: l,
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.serve(conn)
+			}()
+		}
+	}()
+
+	return s, nil
+}
+
+// Addr returns the address of the server
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Destinations returns the destination addresses of the CONNECT requests, in order
+func (s *Server) Destinations() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.dests...)
+}
+
+// Close stops the server. Connections that are being relayed are closed when either side closes them.
+func (s *Server) Close() error {
+	return s.listener.Close()
+}
+
+func (s *Server) serve(conn net.Conn) {
+	defer conn.Close()
+
+	var head [2]byte
+	if _, err := io.ReadFull(conn, head[:]); err != nil || head[0] != 5 {
+		return
+	}
+	methods := make([]byte, head[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return
+	}
+
+	method := byte(0x00)
+	if s.Username != "" {
+		method = 0x02
+	}
+	accepted := false
+	for _, m := range methods {
+		if m == method {
+			accepted = true
+		}
+	}
+	if !accepted {
+		conn.Write([]byte{5, 0xff}) //nolint:errcheck
+		return
+	}
+	if _, err := conn.Write([]byte{5, method}); err != nil {
+		return
+	}
+
+	if method == 0x02 && !s.authenticate(conn) {
+		return
+	}
+
+	var req [4]byte
+	if _, err := io.ReadFull(conn, req[:]); err != nil || req[1] != 1 {
+		return
+	}
+
+	var host string
+	switch req[3] {
+	case 0x01, 0x04:
+		n := net.IPv4len
+		if req[3] == 0x04 {
+			n = net.IPv6len
+		}
+		ip := make([]byte, n)
+		if _, err := io.ReadFull(conn, ip); err != nil {
+			return
+		}
+		host = net.IP(ip).String()
+	case 0x03:
+		var l [1]byte
+		if _, err := io.ReadFull(conn, l[:]); err != nil {
+			return
+		}
+		h := make([]byte, l[0])
+		if _, err := io.ReadFull(conn, h); err != nil {
+			return
+		}
+		host = string(h)
+	default:
+		return
+	}
+
+	var port [2]byte
+	if _, err := io.ReadFull(conn, port[:]); err != nil {
+		return
+	}
+
+	dest := net.JoinHostPort(host, strconv.Itoa(int(port[0])<<8|int(port[1])))
+	s.mu.Lock()
+	s.dests = append(s.dests, dest)
+	s.mu.Unlock()
+
+	target := dest
+	if r, ok := s.Resolve[host]; ok {
+		target = r
+	}
+
+	out, err := net.Dial("tcp", target)
+	if err != nil {
+		// Reply "host unreachable"
+		conn.Write([]byte{5, 4, 0, 1, 0, 0, 0, 0, 0, 0}) //nolint:errcheck
+		return
+	}
+	defer out.Close()
+
+	if _, err := conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0}); err != nil {
+		return
+	}
+
+	done := make(chan struct{}, 2)
+	relay := func(dst, src net.Conn) {
+		io.Copy(dst, src) //nolint:errcheck
+		dst.Close()
+		src.Close()
+		done <- struct{}{}
+	}
+	go relay(out, conn)
+	go relay(conn, out)
+	<-done
+	<-done
+}
+
+func (s *Server) authenticate(conn net.Conn) bool {
+	read := func() (string, bool) {
+		var l [1]byte
+		if _, err := io.ReadFull(conn, l[:]); err != nil {
+			return "", false
+		}
+		b := make([]byte, l[0])
+		if _, err := io.ReadFull(conn, b); err != nil {
+			return "", false
+		}
+		return string(b), true
+	}
+
+	var v [1]byte
+	if _, err := io.ReadFull(conn, v[:]); err != nil || v[0] != 1 {
+		return false
+	}
+	user, ok := read()
+	if !ok {
+		return false
+	}
+	password, ok := read()
+	if !ok {
+		return false
+	}
+
+	if user != s.Username || password != s.Password {
+		conn.Write([]byte{1, 1}) //nolint:errcheck
+		return false
+	}
+
+	_, err := conn.Write([]byte{1, 0})
+	return err == nil
+}
@@ -75,6 +75,12 @@ type chainStore interface {
 	GetGenesisBlock(*dbutil.Tx) (*coin.SignedBlock, error)
 	GetBlockSignature(*dbutil.Tx, *coin.Block) (cipher.Sig, bool, error)
 	ForEachBlock(*dbutil.Tx, func(*coin.Block) error) error
+	GetSignedBlockHeaderBySeq(*dbutil.Tx, uint64) (*coin.BlockHeader, cipher.Sig, error)
+	LowestBlockSeq(*dbutil.Tx) (uint64, error)
+	PruneBlock(*dbutil.Tx, uint64) (*coin.SignedBlock, error)
+	BackfilledBlockSeq(*dbutil.Tx) (uint64, error)
+	BackfillBlock(*dbutil.Tx, *coin.SignedBlock) error
+	AddPrunedBlock(*dbutil.Tx, coin.BlockHeader, cipher.Sig) error
 }
 
 // DefaultWalker default blockchain walker
@@ -110,6 +116,11 @@ type BlockchainConfig struct {
 	// node will throw the error and return.
 	Arbitrating bool
 	Pubkey      cipher.PubKey
+	// Number of workers that verify the input signatures of blocks before they are executed.
+	// 0 uses one worker per CPU
+	SignatureVerifyWorkers int
+	// Number of verified input signatures kept by the signature cache, 0 disables the cache
+	SignatureCacheSize int
 }
 
 // Blockchain maintains blockchain and provides apis for accessing the chain.
@@ -117,6 +128,8 @@ type Blockchain struct {
 	db    *dbutil.DB
 	cfg   BlockchainConfig
 	store chainStore
+	// sigs caches the verified input signatures of transactions
+	sigs *sigCache
 }
 
 // NewBlockchain creates a Blockchain
@@ -130,6 +143,7 @@ func NewBlockchain(db *dbutil.DB, cfg BlockchainConfig) (*Blockchain, error) {
 		cfg:   cfg,
 		db:    db,
 		store: chainstore,
+		sigs:  newSigCache(cfg.SignatureCacheSize),
 	}, nil
 }
 
@@ -148,6 +162,40 @@ func (bc *Blockchain) GetSignedBlockBySeq(tx *dbutil.Tx, seq uint64) (*coin.Sign
 	return bc.store.GetSignedBlockBySeq(tx, seq)
 }
 
+// GetSignedBlockHeaderBySeq returns the header and signature of the block of given seq,
+// which are kept when the block is pruned
+func (bc *Blockchain) GetSignedBlockHeaderBySeq(tx *dbutil.Tx, seq uint64) (*coin.BlockHeader, cipher.Sig, error) {
+	return bc.store.GetSignedBlockHeaderBySeq(tx, seq)
+}
+
+// LowestBlockSeq returns the lowest seq of the blocks whose bodies are stored, other than the genesis block.
+// Returns 0 if no block has been pruned
+func (bc *Blockchain) LowestBlockSeq(tx *dbutil.Tx) (uint64, error) {
+	return bc.store.LowestBlockSeq(tx)
+}
+
+// PruneBlock discards the body of the block of given seq, which must be the lowest block seq.
+// Returns the block as it was before pruning
+func (bc *Blockchain) PruneBlock(tx *dbutil.Tx, seq uint64) (*coin.SignedBlock, error) {
+	return bc.store.PruneBlock(tx, seq)
+}
+
+// BackfilledBlockSeq returns the seq of the last pruned block whose body was restored by BackfillBlock.
+// Returns 0 if no block has been backfilled
+func (bc *Blockchain) BackfilledBlockSeq(tx *dbutil.Tx) (uint64, error) {
+	return bc.store.BackfilledBlockSeq(tx)
+}
+
+// BackfillBlock restores the body of the pruned block that follows the backfilled block seq
+func (bc *Blockchain) BackfillBlock(tx *dbutil.Tx, sb *coin.SignedBlock) error {
+	return bc.store.BackfillBlock(tx, sb)
+}
+
+// AddPrunedBlock adds the signed header of a block on top of the head block, without its body
+func (bc *Blockchain) AddPrunedBlock(tx *dbutil.Tx, h coin.BlockHeader, sig cipher.Sig) error {
+	return bc.store.AddPrunedBlock(tx, h, sig)
+}
+
 // Head returns the most recent confirmed block
 func (bc Blockchain) Head(tx *dbutil.Tx) (*coin.SignedBlock, error) {
 	return bc.store.Head(tx)
@@ -292,6 +340,9 @@ func (bc *Blockchain) ExecuteBlock(tx *dbutil.Tx, sb *coin.SignedBlock) error {
 		return err
 	}
 
+	// The inputs of the block's transactions are spent, so their signatures won't be verified again
+	bc.sigs.removeTxns(nb.Body.Transactions)
+
 	return nil
 }
 
@@ -354,7 +405,8 @@ func (bc Blockchain) VerifyBlockTxnConstraints(tx *dbutil.Tx, txn coin.Transacti
 }
 
 func (bc Blockchain) verifyBlockTxnHardConstraints(tx *dbutil.Tx, txn coin.Transaction, head *coin.SignedBlock, uxIn coin.UxArray) error {
-	if err := transaction.VerifyBlockTxnConstraints(txn, head.Head, uxIn); err != nil {
+	signed := bc.txnSignedFlag(txn, uxIn)
+	if err := transaction.VerifyBlockTxnConstraints(txn, head.Head, uxIn, signed); err != nil {
 		return err
 	}
 
@@ -431,6 +483,10 @@ func (bc Blockchain) VerifySingleTxnSoftHardConstraints(tx *dbutil.Tx, txn coin.
 }
 
 func (bc Blockchain) verifySingleTxnHardConstraints(tx *dbutil.Tx, txn coin.Transaction, head *coin.SignedBlock, uxIn coin.UxArray, signed transaction.TxnSignedFlag) error {
+	if signed == transaction.TxnSigned {
+		signed = bc.txnSignedFlag(txn, uxIn)
+	}
+
 	if err := transaction.VerifySingleTxnHardConstraints(txn, head.Head, uxIn, signed); err != nil {
 		return err
 	}
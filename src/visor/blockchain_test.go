@@ -143,6 +143,30 @@ func (fcs *fakeChainStore) ForEachBlock(tx *dbutil.Tx, f func(*coin.Block) error
 	return nil
 }
 
+func (fcs *fakeChainStore) GetSignedBlockHeaderBySeq(tx *dbutil.Tx, seq uint64) (*coin.BlockHeader, cipher.Sig, error) {
+	return nil, cipher.Sig{}, nil
+}
+
+func (fcs *fakeChainStore) LowestBlockSeq(tx *dbutil.Tx) (uint64, error) {
+	return 0, nil
+}
+
+func (fcs *fakeChainStore) PruneBlock(tx *dbutil.Tx, seq uint64) (*coin.SignedBlock, error) {
+	return nil, nil
+}
+
+func (fcs *fakeChainStore) BackfilledBlockSeq(tx *dbutil.Tx) (uint64, error) {
+	return 0, nil
+}
+
+func (fcs *fakeChainStore) BackfillBlock(tx *dbutil.Tx, sb *coin.SignedBlock) error {
+	return nil
+}
+
+func (fcs *fakeChainStore) AddPrunedBlock(tx *dbutil.Tx, h coin.BlockHeader, sig cipher.Sig) error {
+	return nil
+}
+
 func makeBlock(t *testing.T, preBlock coin.Block, tm uint64) *coin.Block {
 	uxHash := testutil.RandSHA256(t)
 	tx := coin.Transaction{}
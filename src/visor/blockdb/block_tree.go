@@ -127,6 +127,43 @@ func (bt *blockTree) RemoveBlock(tx *dbutil.Tx, b *coin.Block) error {
 	return setHashPairInDepth(tx, b.Seq(), ps)
 }
 
+// PruneBlock replaces the block with its header, discarding the body of the block.
+// The block is kept in the block tree
+func (bt *blockTree) PruneBlock(tx *dbutil.Tx, hash cipher.SHA256) error {
+	b, err := bt.GetBlock(tx, hash)
+	if err != nil {
+		return err
+	} else if b == nil {
+		return fmt.Errorf("prune block failed, block %s does not exist", hash.Hex())
+	}
+
+	buf, err := encodeBlock(&coin.Block{
+		Head: b.Head,
+	})
+	if err != nil {
+		return err
+	}
+
+	return dbutil.PutBucketValue(tx, BlocksBkt, hash[:], buf)
+}
+
+// RestoreBlock replaces a pruned block with the full block of the same header hash
+func (bt *blockTree) RestoreBlock(tx *dbutil.Tx, b *coin.Block) error {
+	hash := b.HashHeader()
+	if ok, err := dbutil.BucketHasKey(tx, BlocksBkt, hash[:]); err != nil {
+		return err
+	} else if !ok {
+		return fmt.Errorf("restore block failed, block %s does not exist", hash.Hex())
+	}
+
+	buf, err := encodeBlock(b)
+	if err != nil {
+		return err
+	}
+
+	return dbutil.PutBucketValue(tx, BlocksBkt, hash[:], buf)
+}
+
 // GetBlock get block by hash, return nil on not found
 func (bt *blockTree) GetBlock(tx *dbutil.Tx, hash cipher.SHA256) (*coin.Block, error) {
 	var b coin.Block
@@ -18,6 +18,10 @@ var (
 
 	// ErrNoHeadBlock is returned when calling Blockchain.Head() when no head block exists
 	ErrNoHeadBlock = fmt.Errorf("found no head block")
+	// ErrBackfillBlockMismatch is returned when a backfilled block does not match the header kept for its seq
+	ErrBackfillBlockMismatch = errors.New("Backfilled block does not match the pruned block header")
+	// ErrBackfillBlockBodyHashMismatch is returned when a backfilled block's body does not match the body hash of its header
+	ErrBackfillBlockBodyHashMismatch = errors.New("Backfilled block body does not match the header body hash")
 )
 
 //go:generate skyencoder -unexported -struct Block -output-path . -package blockdb github.com/skycoin/skycoin/src/coin
@@ -57,6 +61,22 @@ func (e ErrMissingSignature) Error() string {
 	return fmt.Sprintf("Signature not found for block seq=%d hash=%s", e.b.Head.BkSeq, e.b.HashHeader().Hex())
 }
 
+// ErrBlockPruned is returned when the body of a block was discarded by pruning
+type ErrBlockPruned struct {
+	Seq uint64
+}
+
+// NewErrBlockPruned creates ErrBlockPruned from a block seq
+func NewErrBlockPruned(seq uint64) error {
+	return ErrBlockPruned{
+		Seq: seq,
+	}
+}
+
+func (e ErrBlockPruned) Error() string {
+	return fmt.Sprintf("The body of block seq=%d has been pruned", e.Seq)
+}
+
 // CreateBuckets creates bolt.DB buckets used by the blockdb
 func CreateBuckets(tx *dbutil.Tx) error {
 	return dbutil.CreateBuckets(tx, [][]byte{
@@ -73,15 +93,19 @@ func CreateBuckets(tx *dbutil.Tx) error {
 // BlockTree block storage
 type BlockTree interface {
 	AddBlock(*dbutil.Tx, *coin.Block) error
+	RemoveBlock(*dbutil.Tx, *coin.Block) error
 	GetBlock(*dbutil.Tx, cipher.SHA256) (*coin.Block, error)
 	GetBlockInDepth(*dbutil.Tx, uint64, Walker) (*coin.Block, error)
 	ForEachBlock(*dbutil.Tx, func(*coin.Block) error) error
+	PruneBlock(*dbutil.Tx, cipher.SHA256) error
+	RestoreBlock(*dbutil.Tx, *coin.Block) error
 }
 
 // BlockSigs block signature storage
 type BlockSigs interface {
 	Add(*dbutil.Tx, cipher.SHA256, cipher.Sig) error
 	Get(*dbutil.Tx, cipher.SHA256) (cipher.Sig, bool, error)
+	Delete(*dbutil.Tx, cipher.SHA256) error
 	ForEach(*dbutil.Tx, func(cipher.SHA256, cipher.Sig) error) error
 }
 
@@ -99,6 +123,7 @@ type UnspentPooler interface {
 	GetUnspentsOfAddrs(*dbutil.Tx, []cipher.Address) (coin.AddressUxOuts, error)
 	GetUnspentHashesOfAddrs(*dbutil.Tx, []cipher.Address) (AddressHashes, error)
 	ProcessBlock(*dbutil.Tx, *coin.SignedBlock) error
+	LoadSnapshot(*dbutil.Tx, coin.UxArray, uint64) error
 	AddressCount(*dbutil.Tx) (uint64, error)
 }
 
@@ -106,6 +131,10 @@ type UnspentPooler interface {
 type ChainMeta interface {
 	GetHeadSeq(*dbutil.Tx) (uint64, bool, error)
 	SetHeadSeq(*dbutil.Tx, uint64) error
+	GetLowestBlockSeq(*dbutil.Tx) (uint64, error)
+	SetLowestBlockSeq(*dbutil.Tx, uint64) error
+	GetBackfilledBlockSeq(*dbutil.Tx) (uint64, error)
+	SetBackfilledBlockSeq(*dbutil.Tx, uint64) error
 }
 
 // Blockchain maintain the buckets for blockchain
@@ -161,6 +190,37 @@ func (bc *Blockchain) AddBlock(tx *dbutil.Tx, sb *coin.SignedBlock) error {
 	return nil
 }
 
+// Truncate removes the blocks above the block of given seq and their signatures, making it the head block.
+// The unspent pool is not updated, it must be replaced with the unspent outputs of the new head block
+func (bc *Blockchain) Truncate(tx *dbutil.Tx, seq uint64) error {
+	headSeq, ok, err := bc.HeadSeq(tx)
+	if err != nil {
+		return err
+	} else if !ok {
+		return ErrNoHeadBlock
+	}
+
+	for s := headSeq; s > seq; s-- {
+		b, err := bc.tree.GetBlockInDepth(tx, s, bc.walker)
+		if err != nil {
+			return fmt.Errorf("bc.tree.GetBlockInDepth failed: %v", err)
+		}
+		if b == nil {
+			continue
+		}
+
+		if err := bc.tree.RemoveBlock(tx, b); err != nil {
+			return fmt.Errorf("remove block %d failed: %v", s, err)
+		}
+
+		if err := bc.sigs.Delete(tx, b.HashHeader()); err != nil {
+			return fmt.Errorf("remove signature of block %d failed: %v", s, err)
+		}
+	}
+
+	return bc.meta.SetHeadSeq(tx, seq)
+}
+
 // processBlock processes a block and updates the db
 func (bc *Blockchain) processBlock(tx *dbutil.Tx, b *coin.SignedBlock) error {
 	if err := bc.unspent.ProcessBlock(tx, b); err != nil {
@@ -179,7 +239,8 @@ func (bc *Blockchain) Head(tx *dbutil.Tx) (*coin.SignedBlock, error) {
 		return nil, ErrNoHeadBlock
 	}
 
-	b, err := bc.GetSignedBlockBySeq(tx, seq)
+	// The head block is read even if it is pruned, which is only the case while a snapshot is loaded
+	b, err := bc.getSignedBlockBySeq(tx, seq)
 	if err != nil {
 		return nil, err
 	}
@@ -233,6 +294,10 @@ func (bc *Blockchain) GetSignedBlockByHash(tx *dbutil.Tx, hash cipher.SHA256) (*
 		return nil, nil
 	}
 
+	if err := bc.checkPruned(tx, b); err != nil {
+		return nil, err
+	}
+
 	// get signature
 	sig, ok, err := bc.sigs.Get(tx, hash)
 	if err != nil {
@@ -251,6 +316,19 @@ func (bc *Blockchain) GetSignedBlockByHash(tx *dbutil.Tx, hash cipher.SHA256) (*
 
 // GetSignedBlockBySeq returns signed block of given seq
 func (bc *Blockchain) GetSignedBlockBySeq(tx *dbutil.Tx, seq uint64) (*coin.SignedBlock, error) {
+	b, err := bc.getSignedBlockBySeq(tx, seq)
+	if err != nil || b == nil {
+		return nil, err
+	}
+
+	if err := bc.checkPruned(tx, &b.Block); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func (bc *Blockchain) getSignedBlockBySeq(tx *dbutil.Tx, seq uint64) (*coin.SignedBlock, error) {
 	b, err := bc.tree.GetBlockInDepth(tx, seq, bc.walker)
 	if err != nil {
 		return nil, fmt.Errorf("bc.tree.GetBlockInDepth failed: %v", err)
@@ -274,6 +352,207 @@ func (bc *Blockchain) GetSignedBlockBySeq(tx *dbutil.Tx, seq uint64) (*coin.Sign
 	}, nil
 }
 
+// GetSignedBlockHeaderBySeq returns the header and signature of the block of given seq,
+// which are kept when the block is pruned. Returns a nil header if the block does not exist
+func (bc *Blockchain) GetSignedBlockHeaderBySeq(tx *dbutil.Tx, seq uint64) (*coin.BlockHeader, cipher.Sig, error) {
+	b, err := bc.tree.GetBlockInDepth(tx, seq, bc.walker)
+	if err != nil {
+		return nil, cipher.Sig{}, fmt.Errorf("bc.tree.GetBlockInDepth failed: %v", err)
+	}
+	if b == nil {
+		return nil, cipher.Sig{}, nil
+	}
+
+	sig, ok, err := bc.sigs.Get(tx, b.HashHeader())
+	if err != nil {
+		return nil, cipher.Sig{}, fmt.Errorf("find signature of block: %v failed: %v", seq, err)
+	}
+
+	if !ok {
+		return nil, cipher.Sig{}, NewErrMissingSignature(b)
+	}
+
+	return &b.Head, sig, nil
+}
+
+// LowestBlockSeq returns the lowest seq of the blocks whose bodies are stored, other than the genesis block.
+// The bodies of the blocks between the genesis block and this block have been pruned.
+// Returns 0 if no block has been pruned
+func (bc *Blockchain) LowestBlockSeq(tx *dbutil.Tx) (uint64, error) {
+	return bc.meta.GetLowestBlockSeq(tx)
+}
+
+// PruneBlock discards the body of the block of given seq, keeping its header and signature.
+// Blocks are pruned in order after the genesis block, so seq must be the lowest block seq.
+// The head block can't be pruned. Returns the block as it was before pruning
+func (bc *Blockchain) PruneBlock(tx *dbutil.Tx, seq uint64) (*coin.SignedBlock, error) {
+	lowestSeq, err := bc.LowestBlockSeq(tx)
+	if err != nil {
+		return nil, err
+	}
+	if lowestSeq == 0 {
+		lowestSeq = 1
+	}
+
+	if seq != lowestSeq {
+		return nil, fmt.Errorf("Can't prune block %d, the lowest block is %d", seq, lowestSeq)
+	}
+
+	headSeq, ok, err := bc.HeadSeq(tx)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || seq >= headSeq {
+		return nil, fmt.Errorf("Can't prune block %d, it is not below the head block", seq)
+	}
+
+	b, err := bc.GetSignedBlockBySeq(tx, seq)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return nil, fmt.Errorf("Can't prune block %d, it does not exist", seq)
+	}
+
+	if err := bc.tree.PruneBlock(tx, b.HashHeader()); err != nil {
+		return nil, err
+	}
+
+	if err := bc.meta.SetLowestBlockSeq(tx, seq+1); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// BackfilledBlockSeq returns the seq of the last pruned block whose body was restored by BackfillBlock.
+// The bodies of the blocks after it and below the lowest block seq are missing.
+// Returns 0 if no block has been backfilled
+func (bc *Blockchain) BackfilledBlockSeq(tx *dbutil.Tx) (uint64, error) {
+	return bc.meta.GetBackfilledBlockSeq(tx)
+}
+
+// AddPrunedBlock adds the signed header of a block on top of the head block, without its body.
+// The block is added as pruned, which is used to load the header chain of a snapshot.
+// Only pruned blocks can be added after the genesis block
+func (bc *Blockchain) AddPrunedBlock(tx *dbutil.Tx, h coin.BlockHeader, sig cipher.Sig) error {
+	headSeq, ok, err := bc.HeadSeq(tx)
+	if err != nil {
+		return err
+	}
+	if !ok || h.BkSeq != headSeq+1 {
+		return fmt.Errorf("Can't add pruned block %d on top of the head block", h.BkSeq)
+	}
+
+	lowestSeq, err := bc.LowestBlockSeq(tx)
+	if err != nil {
+		return err
+	}
+	if lowestSeq == 0 {
+		lowestSeq = 1
+	}
+	if h.BkSeq != lowestSeq {
+		return fmt.Errorf("Can't add pruned block %d, the lowest block is %d", h.BkSeq, lowestSeq)
+	}
+
+	b := coin.Block{
+		Head: h,
+	}
+
+	if err := bc.sigs.Add(tx, b.HashHeader(), sig); err != nil {
+		return fmt.Errorf("save signature failed: %v", err)
+	}
+
+	if err := bc.tree.AddBlock(tx, &b); err != nil {
+		return fmt.Errorf("save block failed: %v", err)
+	}
+
+	if err := bc.meta.SetLowestBlockSeq(tx, h.BkSeq+1); err != nil {
+		return err
+	}
+
+	return bc.meta.SetHeadSeq(tx, h.BkSeq)
+}
+
+// BackfillBlock restores the body of a pruned block.
+// Pruned blocks are backfilled in order, so the block must follow the backfilled block seq,
+// and its header must match the header kept for its seq.
+// Once all pruned blocks are backfilled, the blockchain is no longer pruned
+func (bc *Blockchain) BackfillBlock(tx *dbutil.Tx, sb *coin.SignedBlock) error {
+	lowestSeq, err := bc.LowestBlockSeq(tx)
+	if err != nil {
+		return err
+	}
+
+	backfilledSeq, err := bc.BackfilledBlockSeq(tx)
+	if err != nil {
+		return err
+	}
+
+	seq := sb.Seq()
+	if lowestSeq == 0 || seq != backfilledSeq+1 || seq >= lowestSeq {
+		return fmt.Errorf("Can't backfill block %d, the next pruned block is %d", seq, backfilledSeq+1)
+	}
+
+	b, err := bc.tree.GetBlockInDepth(tx, seq, bc.walker)
+	if err != nil {
+		return fmt.Errorf("bc.tree.GetBlockInDepth failed: %v", err)
+	}
+	if b == nil {
+		return fmt.Errorf("Can't backfill block %d, it does not exist", seq)
+	}
+
+	if b.HashHeader() != sb.HashHeader() {
+		return ErrBackfillBlockMismatch
+	}
+
+	if sb.Block.Body.Hash() != sb.Block.Head.BodyHash {
+		return ErrBackfillBlockBodyHashMismatch
+	}
+
+	if err := bc.tree.RestoreBlock(tx, &sb.Block); err != nil {
+		return err
+	}
+
+	if seq+1 < lowestSeq {
+		return bc.meta.SetBackfilledBlockSeq(tx, seq)
+	}
+
+	// All pruned blocks are backfilled
+	if err := bc.meta.SetBackfilledBlockSeq(tx, 0); err != nil {
+		return err
+	}
+
+	return bc.meta.SetLowestBlockSeq(tx, 0)
+}
+
+// checkPruned returns ErrBlockPruned if the body of the block has been pruned
+func (bc *Blockchain) checkPruned(tx *dbutil.Tx, b *coin.Block) error {
+	if b.Seq() == 0 {
+		return nil
+	}
+
+	lowestSeq, err := bc.LowestBlockSeq(tx)
+	if err != nil {
+		return err
+	}
+
+	if b.Seq() >= lowestSeq {
+		return nil
+	}
+
+	backfilledSeq, err := bc.BackfilledBlockSeq(tx)
+	if err != nil {
+		return err
+	}
+
+	if b.Seq() > backfilledSeq {
+		return NewErrBlockPruned(b.Seq())
+	}
+
+	return nil
+}
+
 // GetGenesisBlock returns genesis block
 func (bc *Blockchain) GetGenesisBlock(tx *dbutil.Tx) (*coin.SignedBlock, error) {
 	return bc.GetSignedBlockBySeq(tx, 0)
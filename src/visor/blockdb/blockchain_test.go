@@ -83,6 +83,11 @@ func (bt *fakeBlockTree) AddBlock(tx *dbutil.Tx, b *coin.Block) error {
 	return nil
 }
 
+func (bt *fakeBlockTree) RemoveBlock(tx *dbutil.Tx, b *coin.Block) error {
+	delete(bt.blocks, b.HashHeader().Hex())
+	return nil
+}
+
 func (bt *fakeBlockTree) GetBlock(tx *dbutil.Tx, hash cipher.SHA256) (*coin.Block, error) {
 	if bt.failedWhenSaved != nil && *bt.failedWhenSaved {
 		return nil, nil
@@ -108,6 +113,25 @@ func (bt *fakeBlockTree) ForEachBlock(tx *dbutil.Tx, f func(*coin.Block) error)
 	return nil
 }
 
+func (bt *fakeBlockTree) PruneBlock(tx *dbutil.Tx, hash cipher.SHA256) error {
+	b, ok := bt.blocks[hash.Hex()]
+	if !ok {
+		return errors.New("block does not exist")
+	}
+	bt.blocks[hash.Hex()] = &coin.Block{
+		Head: b.Head,
+	}
+	return nil
+}
+
+func (bt *fakeBlockTree) RestoreBlock(tx *dbutil.Tx, b *coin.Block) error {
+	if _, ok := bt.blocks[b.HashHeader().Hex()]; !ok {
+		return errors.New("block does not exist")
+	}
+	bt.blocks[b.HashHeader().Hex()] = b
+	return nil
+}
+
 type fakeSignatureStore struct {
 	sigs       map[string]cipher.Sig
 	saveFailed bool
@@ -150,6 +174,11 @@ func (ss *fakeSignatureStore) Get(tx *dbutil.Tx, hash cipher.SHA256) (cipher.Sig
 	return sig, ok, nil
 }
 
+func (ss *fakeSignatureStore) Delete(tx *dbutil.Tx, hash cipher.SHA256) error {
+	delete(ss.sigs, hash.Hex())
+	return nil
+}
+
 func (ss *fakeSignatureStore) ForEach(tx *dbutil.Tx, f func(cipher.SHA256, cipher.Sig) error) error {
 	return nil
 }
@@ -253,6 +282,14 @@ func (fup *fakeUnspentPool) ProcessBlock(tx *dbutil.Tx, b *coin.SignedBlock) err
 	return nil
 }
 
+func (fup *fakeUnspentPool) LoadSnapshot(tx *dbutil.Tx, uxs coin.UxArray, height uint64) error {
+	fup.outs = make(map[cipher.SHA256]coin.UxOut, len(uxs))
+	for _, ux := range uxs {
+		fup.outs[ux.Hash()] = ux
+	}
+	return nil
+}
+
 func (fup *fakeUnspentPool) Contains(tx *dbutil.Tx, h cipher.SHA256) (bool, error) {
 	_, ok := fup.outs[h]
 	return ok, nil
@@ -268,8 +305,10 @@ func (fup *fakeUnspentPool) AddressCount(tx *dbutil.Tx) (uint64, error) {
 }
 
 type fakeChainMeta struct {
-	headSeq   uint64
-	didSetSeq bool
+	headSeq        uint64
+	didSetSeq      bool
+	lowestBlockSeq uint64
+	backfilledSeq  uint64
 }
 
 func newFakeChainMeta() *fakeChainMeta {
@@ -290,6 +329,24 @@ func (fcm *fakeChainMeta) SetHeadSeq(tx *dbutil.Tx, seq uint64) error {
 	return nil
 }
 
+func (fcm *fakeChainMeta) GetLowestBlockSeq(tx *dbutil.Tx) (uint64, error) {
+	return fcm.lowestBlockSeq, nil
+}
+
+func (fcm *fakeChainMeta) SetLowestBlockSeq(tx *dbutil.Tx, seq uint64) error {
+	fcm.lowestBlockSeq = seq
+	return nil
+}
+
+func (fcm *fakeChainMeta) GetBackfilledBlockSeq(tx *dbutil.Tx) (uint64, error) {
+	return fcm.backfilledSeq, nil
+}
+
+func (fcm *fakeChainMeta) SetBackfilledBlockSeq(tx *dbutil.Tx, seq uint64) error {
+	fcm.backfilledSeq = seq
+	return nil
+}
+
 func DefaultWalker(tx *dbutil.Tx, hps []coin.HashPair) (cipher.SHA256, bool) {
 	return hps[0].Hash, true
 }
@@ -615,3 +672,197 @@ func TestBlockchainGetBlockByHash(t *testing.T) {
 		})
 	}
 }
+
+// makeChainBlocks creates a genesis block and n signed blocks on top of it
+func makeChainBlocks(t *testing.T, n uint64) []coin.SignedBlock {
+	blocks := []coin.SignedBlock{makeGenesisBlock(t)}
+	for i := uint64(1); i <= n; i++ {
+		prev := blocks[i-1]
+		b := coin.Block{
+			Head: coin.BlockHeader{
+				BkSeq:    i,
+				Time:     prev.Time() + 10,
+				PrevHash: prev.HashHeader(),
+			},
+			Body: coin.BlockBody{
+				Transactions: coin.Transactions{
+					{
+						Out: []coin.TransactionOutput{
+							{
+								Address: genAddress,
+								Coins:   i * 1e6,
+							},
+						},
+					},
+				},
+			},
+		}
+		b.Head.BodyHash = b.Body.Hash()
+
+		blocks = append(blocks, coin.SignedBlock{
+			Block: b,
+			Sig:   cipher.MustSignHash(b.HashHeader(), genSecret),
+		})
+	}
+
+	return blocks
+}
+
+func TestBlockchainPruneBlock(t *testing.T) {
+	db, closeDB := prepareDB(t)
+	defer closeDB()
+
+	bc, err := NewBlockchain(db, DefaultWalker)
+	require.NoError(t, err)
+	bc.unspent = newFakeUnspentPool(nil)
+
+	blocks := makeChainBlocks(t, 3)
+
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		for i := range blocks {
+			require.NoError(t, bc.AddBlock(tx, &blocks[i]))
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		lowestSeq, err := bc.LowestBlockSeq(tx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(0), lowestSeq)
+
+		// Blocks are pruned in order
+		_, err = bc.PruneBlock(tx, 2)
+		require.Equal(t, errors.New("Can't prune block 2, the lowest block is 1"), err)
+
+		b, err := bc.PruneBlock(tx, 1)
+		require.NoError(t, err)
+		require.Equal(t, blocks[1], *b)
+
+		lowestSeq, err = bc.LowestBlockSeq(tx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), lowestSeq)
+
+		_, err = bc.GetSignedBlockBySeq(tx, 1)
+		require.Equal(t, NewErrBlockPruned(1), err)
+
+		_, err = bc.GetSignedBlockByHash(tx, blocks[1].HashHeader())
+		require.Equal(t, NewErrBlockPruned(1), err)
+
+		// The header and signature of the pruned block are kept
+		head, sig, err := bc.GetSignedBlockHeaderBySeq(tx, 1)
+		require.NoError(t, err)
+		require.Equal(t, blocks[1].Head, *head)
+		require.Equal(t, blocks[1].Sig, sig)
+
+		// The genesis block is not pruned
+		gb, err := bc.GetGenesisBlock(tx)
+		require.NoError(t, err)
+		require.Equal(t, blocks[0], *gb)
+
+		_, err = bc.PruneBlock(tx, 2)
+		require.NoError(t, err)
+
+		// The head block can't be pruned
+		_, err = bc.PruneBlock(tx, 3)
+		require.Equal(t, errors.New("Can't prune block 3, it is not below the head block"), err)
+
+		b, err = bc.GetSignedBlockBySeq(tx, 3)
+		require.NoError(t, err)
+		require.Equal(t, blocks[3], *b)
+
+		head, _, err = bc.GetSignedBlockHeaderBySeq(tx, 4)
+		require.NoError(t, err)
+		require.Nil(t, head)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestBlockchainAddPrunedBlockBackfillBlock(t *testing.T) {
+	db, closeDB := prepareDB(t)
+	defer closeDB()
+
+	bc, err := NewBlockchain(db, DefaultWalker)
+	require.NoError(t, err)
+	bc.unspent = newFakeUnspentPool(nil)
+
+	blocks := makeChainBlocks(t, 3)
+
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		require.NoError(t, bc.AddBlock(tx, &blocks[0]))
+
+		// Pruned blocks are added on top of the head block
+		err := bc.AddPrunedBlock(tx, blocks[2].Head, blocks[2].Sig)
+		require.Equal(t, errors.New("Can't add pruned block 2 on top of the head block"), err)
+
+		for i := 1; i < 3; i++ {
+			require.NoError(t, bc.AddPrunedBlock(tx, blocks[i].Head, blocks[i].Sig))
+		}
+
+		lowestSeq, err := bc.LowestBlockSeq(tx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), lowestSeq)
+
+		// The head block is available while it is pruned
+		head, err := bc.Head(tx)
+		require.NoError(t, err)
+		require.Equal(t, blocks[2].HashHeader(), head.HashHeader())
+
+		require.NoError(t, bc.AddBlock(tx, &blocks[3]))
+
+		_, err = bc.GetSignedBlockBySeq(tx, 2)
+		require.Equal(t, NewErrBlockPruned(2), err)
+
+		// Pruned blocks are backfilled in order
+		err = bc.BackfillBlock(tx, &blocks[2])
+		require.Equal(t, errors.New("Can't backfill block 2, the next pruned block is 1"), err)
+
+		b := blocks[1]
+		b.Body.Transactions = nil
+		err = bc.BackfillBlock(tx, &b)
+		require.Equal(t, ErrBackfillBlockBodyHashMismatch, err)
+
+		b = blocks[1]
+		b.Head.Time++
+		err = bc.BackfillBlock(tx, &b)
+		require.Equal(t, ErrBackfillBlockMismatch, err)
+
+		require.NoError(t, bc.BackfillBlock(tx, &blocks[1]))
+
+		backfilledSeq, err := bc.BackfilledBlockSeq(tx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), backfilledSeq)
+
+		sb, err := bc.GetSignedBlockBySeq(tx, 1)
+		require.NoError(t, err)
+		require.Equal(t, blocks[1], *sb)
+
+		_, err = bc.GetSignedBlockBySeq(tx, 2)
+		require.Equal(t, NewErrBlockPruned(2), err)
+
+		// Once the last pruned block is backfilled, the blockchain is not pruned
+		require.NoError(t, bc.BackfillBlock(tx, &blocks[2]))
+
+		lowestSeq, err = bc.LowestBlockSeq(tx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(0), lowestSeq)
+
+		backfilledSeq, err = bc.BackfilledBlockSeq(tx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(0), backfilledSeq)
+
+		for i := range blocks {
+			sb, err := bc.GetSignedBlockBySeq(tx, uint64(i))
+			require.NoError(t, err)
+			require.Equal(t, blocks[i], *sb)
+		}
+
+		err = bc.BackfillBlock(tx, &blocks[1])
+		require.Equal(t, errors.New("Can't backfill block 1, the next pruned block is 1"), err)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
@@ -51,6 +51,11 @@ func (bs *blockSigs) Add(tx *dbutil.Tx, hash cipher.SHA256, sig cipher.Sig) erro
 	return dbutil.PutBucketValue(tx, BlockSigsBkt, hash[:], buf)
 }
 
+// Delete removes the signature of a block
+func (bs *blockSigs) Delete(tx *dbutil.Tx, hash cipher.SHA256) error {
+	return dbutil.Delete(tx, BlockSigsBkt, hash[:])
+}
+
 // ForEach iterates all signatures and calls f on them
 func (bs *blockSigs) ForEach(tx *dbutil.Tx, f func(cipher.SHA256, cipher.Sig) error) error {
 	return dbutil.ForEach(tx, BlockSigsBkt, func(k, v []byte) error {
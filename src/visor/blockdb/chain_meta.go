@@ -9,6 +9,10 @@ var (
 	BlockchainMetaBkt = []byte("blockchain_meta")
 	// blockchain head sequence number
 	headSeqKey = []byte("head_seq")
+	// lowest sequence number of the non-genesis blocks whose bodies are stored
+	lowestBlockSeqKey = []byte("lowest_block_seq")
+	// sequence number of the last pruned block whose body was downloaded again
+	backfilledBlockSeqKey = []byte("backfilled_block_seq")
 )
 
 type chainMeta struct{}
@@ -27,3 +31,33 @@ func (m chainMeta) GetHeadSeq(tx *dbutil.Tx) (uint64, bool, error) {
 
 	return dbutil.Btoi(v), true, nil
 }
+
+func (m chainMeta) SetLowestBlockSeq(tx *dbutil.Tx, seq uint64) error {
+	return dbutil.PutBucketValue(tx, BlockchainMetaBkt, lowestBlockSeqKey, dbutil.Itob(seq))
+}
+
+func (m chainMeta) GetLowestBlockSeq(tx *dbutil.Tx) (uint64, error) {
+	v, err := dbutil.GetBucketValue(tx, BlockchainMetaBkt, lowestBlockSeqKey)
+	if err != nil {
+		return 0, err
+	} else if v == nil {
+		return 0, nil
+	}
+
+	return dbutil.Btoi(v), nil
+}
+
+func (m chainMeta) SetBackfilledBlockSeq(tx *dbutil.Tx, seq uint64) error {
+	return dbutil.PutBucketValue(tx, BlockchainMetaBkt, backfilledBlockSeqKey, dbutil.Itob(seq))
+}
+
+func (m chainMeta) GetBackfilledBlockSeq(tx *dbutil.Tx) (uint64, error) {
+	v, err := dbutil.GetBucketValue(tx, BlockchainMetaBkt, backfilledBlockSeqKey)
+	if err != nil {
+		return 0, err
+	} else if v == nil {
+		return 0, nil
+	}
+
+	return dbutil.Btoi(v), nil
+}
@@ -38,6 +38,11 @@ func (e ErrUnspentNotExist) Error() string {
 	return fmt.Sprintf("unspent output of %s does not exist", e.UxID)
 }
 
+// ErrAddrIndexDrift is returned if the address index does not match the unspent pool
+type ErrAddrIndexDrift struct {
+	error
+}
+
 // AddressHashes maps addresses to a set of hashes
 type AddressHashes map[cipher.Address][]cipher.SHA256
 
@@ -343,6 +348,88 @@ func (up *Unspents) buildAddrIndex(tx *dbutil.Tx) error {
 	return nil
 }
 
+// RebuildIndexes rebuilds the address index from the unspent outputs of the pool,
+// marking it as indexed up to the block of seq headSeq
+func (up *Unspents) RebuildIndexes(tx *dbutil.Tx, headSeq uint64) error {
+	if err := up.buildAddrIndex(tx); err != nil {
+		return err
+	}
+
+	return up.meta.setAddrIndexHeight(tx, headSeq)
+}
+
+// VerifyIndexes checks that the address index is indexed up to the block of seq headSeq
+// and matches the unspent outputs of the pool. Returns ErrAddrIndexDrift if it does not
+func (up *Unspents) VerifyIndexes(tx *dbutil.Tx, headSeq uint64) error {
+	addrIndexHeight, ok, err := up.meta.getAddrIndexHeight(tx)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrAddrIndexDrift{errors.New("address index height is not set")}
+	}
+
+	if addrIndexHeight != headSeq {
+		return ErrAddrIndexDrift{fmt.Errorf("address index height %d does not match head block seq %d", addrIndexHeight, headSeq)}
+	}
+
+	addrHashes := make(map[cipher.Address]map[cipher.SHA256]struct{})
+	if err := dbutil.ForEach(tx, UnspentPoolBkt, func(_, v []byte) error {
+		var ux coin.UxOut
+		if err := decodeUxOutExact(v, &ux); err != nil {
+			return err
+		}
+
+		hashes, ok := addrHashes[ux.Body.Address]
+		if !ok {
+			hashes = make(map[cipher.SHA256]struct{})
+			addrHashes[ux.Body.Address] = hashes
+		}
+		hashes[ux.Hash()] = struct{}{}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if err := dbutil.ForEach(tx, UnspentPoolAddrIndexBkt, func(k, v []byte) error {
+		addr, err := cipher.AddressFromBytes(k)
+		if err != nil {
+			return ErrAddrIndexDrift{fmt.Errorf("invalid address in address index: %v", err)}
+		}
+
+		var hashes hashesWrapper
+		if err := decodeHashesWrapperExact(v, &hashes); err != nil {
+			return err
+		}
+
+		poolHashes := addrHashes[addr]
+		if len(poolHashes) != len(hashes.Hashes) {
+			return ErrAddrIndexDrift{fmt.Errorf("address index has %d unspent outputs for address %s, the unspent pool has %d",
+				len(hashes.Hashes), addr, len(poolHashes))}
+		}
+
+		for _, h := range hashes.Hashes {
+			if _, ok := poolHashes[h]; !ok {
+				return ErrAddrIndexDrift{fmt.Errorf("address index has unspent output %s for address %s, which is not in the unspent pool", h.Hex(), addr)}
+			}
+		}
+
+		delete(addrHashes, addr)
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for addr := range addrHashes {
+		return ErrAddrIndexDrift{fmt.Errorf("unspent outputs of address %s are not in the address index", addr)}
+	}
+
+	return nil
+}
+
 // ProcessBlock adds unspents from a block to the unspent pool
 func (up *Unspents) ProcessBlock(tx *dbutil.Tx, b *coin.SignedBlock) error {
 	// Gather all transaction inputs
@@ -449,6 +536,50 @@ func (up *Unspents) ProcessBlock(tx *dbutil.Tx, b *coin.SignedBlock) error {
 	return up.meta.setAddrIndexHeight(tx, b.Block.Head.BkSeq)
 }
 
+// LoadSnapshot replaces the unspent pool with the unspent outputs of a snapshot,
+// which are the unspent outputs after the block of seq height is executed.
+// The address index is rebuilt and the XOR hash is recalculated from the outputs
+func (up *Unspents) LoadSnapshot(tx *dbutil.Tx, uxs coin.UxArray, height uint64) error {
+	if err := dbutil.Reset(tx, UnspentPoolBkt); err != nil {
+		return err
+	}
+
+	if err := dbutil.Reset(tx, UnspentPoolAddrIndexBkt); err != nil {
+		return err
+	}
+
+	var xorHash cipher.SHA256
+	addrHashes := make(map[cipher.Address][]cipher.SHA256)
+	for _, ux := range uxs {
+		h := ux.Hash()
+
+		if hasKey, err := up.Contains(tx, h); err != nil {
+			return err
+		} else if hasKey {
+			return fmt.Errorf("attempted to insert uxout:%v twice into the unspent pool", h.Hex())
+		}
+
+		if err := up.pool.put(tx, h, ux); err != nil {
+			return err
+		}
+
+		xorHash = xorHash.Xor(ux.SnapshotHash())
+		addrHashes[ux.Body.Address] = append(addrHashes[ux.Body.Address], h)
+	}
+
+	for addr, hashes := range addrHashes {
+		if err := up.poolAddrIndex.put(tx, addr, hashes); err != nil {
+			return err
+		}
+	}
+
+	if err := up.meta.setXorHash(tx, xorHash); err != nil {
+		return err
+	}
+
+	return up.meta.setAddrIndexHeight(tx, height)
+}
+
 // GetArray returns UxOut for a set of hashes, will return error if any of the hashes do not exist in the pool.
 func (up *Unspents) GetArray(tx *dbutil.Tx, hashes []cipher.SHA256) (coin.UxArray, error) {
 	var uxa coin.UxArray
@@ -535,6 +666,25 @@ func (up *Unspents) GetUxHash(tx *dbutil.Tx) (cipher.SHA256, error) {
 	return up.meta.getXorHash(tx)
 }
 
+// CalcUxHash calculates the unspent output checksum from the unspent outputs of the pool,
+// which should match GetUxHash
+func (up *Unspents) CalcUxHash(tx *dbutil.Tx) (cipher.SHA256, error) {
+	var xorHash cipher.SHA256
+	if err := dbutil.ForEach(tx, UnspentPoolBkt, func(_, v []byte) error {
+		var ux coin.UxOut
+		if err := decodeUxOutExact(v, &ux); err != nil {
+			return err
+		}
+
+		xorHash = xorHash.Xor(ux.SnapshotHash())
+		return nil
+	}); err != nil {
+		return cipher.SHA256{}, err
+	}
+
+	return xorHash, nil
+}
+
 // AddressCount returns the total number of addresses with unspents
 func (up *Unspents) AddressCount(tx *dbutil.Tx) (uint64, error) {
 	return dbutil.Len(tx, UnspentPoolAddrIndexBkt)
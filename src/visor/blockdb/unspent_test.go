@@ -1087,6 +1087,74 @@ func TestUnspentMaybeBuildIndexesNoRebuild(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestUnspentVerifyIndexes(t *testing.T) {
+	db, shutdown := setupNoUnspentAddrIndexDB(t)
+	defer shutdown()
+
+	u := NewUnspentPool()
+	headSeq := uint64(180)
+
+	verify := func(seq uint64) error {
+		return db.View("", func(tx *dbutil.Tx) error {
+			return u.VerifyIndexes(tx, seq)
+		})
+	}
+
+	err := db.Update("", func(tx *dbutil.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(UnspentPoolAddrIndexBkt)
+		return err
+	})
+	require.NoError(t, err)
+
+	// The address index has not been built
+	err = verify(headSeq)
+	require.IsType(t, ErrAddrIndexDrift{}, err)
+
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		return u.RebuildIndexes(tx, headSeq)
+	})
+	require.NoError(t, err)
+
+	err = verify(headSeq)
+	require.NoError(t, err)
+
+	// The address index height does not match the head block
+	err = verify(headSeq + 1)
+	require.IsType(t, ErrAddrIndexDrift{}, err)
+
+	// The unspent pool checksum matches the unspent outputs
+	err = db.View("", func(tx *dbutil.Tx) error {
+		uxHash, err := u.GetUxHash(tx)
+		require.NoError(t, err)
+
+		poolUxHash, err := u.CalcUxHash(tx)
+		require.NoError(t, err)
+		require.Equal(t, uxHash, poolUxHash)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// An unspent output is missing from the address index
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		uxs, err := u.GetAll(tx)
+		require.NoError(t, err)
+		require.NotEmpty(t, uxs)
+
+		addr := uxs[0].Body.Address
+		hashes, err := u.poolAddrIndex.get(tx, addr)
+		require.NoError(t, err)
+
+		if len(hashes) == 1 {
+			return dbutil.Delete(tx, UnspentPoolAddrIndexBkt, addr.Bytes())
+		}
+		return u.poolAddrIndex.put(tx, addr, hashes[1:])
+	})
+	require.NoError(t, err)
+
+	err = verify(headSeq)
+	require.IsType(t, ErrAddrIndexDrift{}, err)
+}
+
 func setupNoUnspentAddrIndexDB(t *testing.T) (*dbutil.DB, func()) {
 	// Open a test database file that lacks UnspentPoolAddrIndexBkt,
 	// copy it to a temp file and open a database around the temp file
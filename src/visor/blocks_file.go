@@ -0,0 +1,334 @@
+package visor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/visor/blockdb"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+const (
+	// BlocksFileVersion is the version of the blocks file format
+	BlocksFileVersion = 1
+
+	// maxBlocksFileBlockSize is the maximum size of an encoded block in a blocks file
+	maxBlocksFileBlockSize = 32 * 1024 * 1024
+
+	// blocksFileProgressInterval is the number of blocks between progress logs
+	blocksFileProgressInterval = 1000
+)
+
+// blocksFileMagic is written at the start of a blocks file
+var blocksFileMagic = [8]byte{'S', 'K', 'Y', 'B', 'L', 'O', 'C', 'K'}
+
+var (
+	// ErrBlocksFileInvalid is returned when a file is not a blocks file
+	ErrBlocksFileInvalid = errors.New("Not a blocks file")
+	// ErrBlocksFileVersion is returned when a blocks file has an unsupported version
+	ErrBlocksFileVersion = errors.New("Unsupported blocks file version")
+	// ErrBlocksFileChecksum is returned when the header checksum of a blocks file does not match
+	ErrBlocksFileChecksum = errors.New("Blocks file header checksum mismatch")
+	// ErrBlocksFileCorrupt is returned when a block in a blocks file cannot be decoded or is out of sequence
+	ErrBlocksFileCorrupt = errors.New("Blocks file is corrupt")
+	// ErrBlocksFileTruncated is returned when a blocks file ends before the last block of its header
+	ErrBlocksFileTruncated = errors.New("Blocks file is truncated")
+	// ErrBlocksFilePubkeyMismatch is returned when a blocks file is for a different blockchain pubkey
+	ErrBlocksFilePubkeyMismatch = errors.New("Blocks file blockchain pubkey does not match")
+	// ErrBlocksFileGenesisMismatch is returned when a blocks file is for a different genesis block
+	ErrBlocksFileGenesisMismatch = errors.New("Blocks file genesis block does not match")
+	// ErrBlocksFileForked is returned when a block in a blocks file differs from the block of the same seq in the blockchain
+	ErrBlocksFileForked = errors.New("Blocks file does not match the blockchain")
+	// ErrBlocksFileStopped is returned when a blocks export or import is interrupted
+	ErrBlocksFileStopped = errors.New("blocks file processing stopped")
+	// ErrNoBlocks is returned when exporting blocks from an empty blockchain
+	ErrNoBlocks = errors.New("Blockchain has no blocks")
+)
+
+// BlocksFileHeader is the header of a blocks file. A blocks file is a portable
+// file of signed blocks, used to bootstrap a node without downloading the blocks from peers.
+//
+// The file is the 8 byte magic "SKYBLOCK", the encoded header, the SHA256 checksum of the encoded header,
+// and the blocks from StartSeq to EndSeq, each encoded with a 4 byte little-endian length prefix
+type BlocksFileHeader struct {
+	Version     uint32
+	GenesisHash cipher.SHA256
+	Pubkey      cipher.PubKey
+	StartSeq    uint64
+	EndSeq      uint64
+}
+
+// WriteBlocksFileHeader writes the magic, the header and its checksum
+func WriteBlocksFileHeader(w io.Writer, hdr BlocksFileHeader) error {
+	b := encoder.Serialize(hdr)
+	checksum := cipher.SumSHA256(b)
+
+	var buf bytes.Buffer
+	buf.Write(blocksFileMagic[:])
+	buf.Write(b)
+	buf.Write(checksum[:])
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// ReadBlocksFileHeader reads the header of a blocks file and verifies its checksum
+func ReadBlocksFileHeader(r io.Reader) (BlocksFileHeader, error) {
+	var magic [8]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return BlocksFileHeader{}, ErrBlocksFileInvalid
+		}
+		return BlocksFileHeader{}, err
+	}
+	if magic != blocksFileMagic {
+		return BlocksFileHeader{}, ErrBlocksFileInvalid
+	}
+
+	b := make([]byte, encoder.Size(BlocksFileHeader{}))
+	var checksum cipher.SHA256
+	if _, err := io.ReadFull(r, b); err != nil {
+		return BlocksFileHeader{}, ErrBlocksFileInvalid
+	}
+	if _, err := io.ReadFull(r, checksum[:]); err != nil {
+		return BlocksFileHeader{}, ErrBlocksFileInvalid
+	}
+
+	if cipher.SumSHA256(b) != checksum {
+		return BlocksFileHeader{}, ErrBlocksFileChecksum
+	}
+
+	var hdr BlocksFileHeader
+	if err := encoder.DeserializeRawExact(b, &hdr); err != nil {
+		return BlocksFileHeader{}, ErrBlocksFileInvalid
+	}
+
+	if hdr.Version != BlocksFileVersion {
+		return BlocksFileHeader{}, ErrBlocksFileVersion
+	}
+
+	if hdr.StartSeq > hdr.EndSeq {
+		return BlocksFileHeader{}, ErrBlocksFileInvalid
+	}
+
+	return hdr, nil
+}
+
+// writeBlocksFileBlock writes a length prefixed signed block
+func writeBlocksFileBlock(w io.Writer, b *coin.SignedBlock) error {
+	data := encoder.Serialize(*b)
+	if _, err := w.Write(encoder.SerializeUint32(uint32(len(data)))); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// readBlocksFileBlock reads a length prefixed signed block. Returns io.EOF if there are no more blocks
+func readBlocksFileBlock(r io.Reader) (*coin.SignedBlock, error) {
+	var prefix [4]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, ErrBlocksFileTruncated
+	}
+
+	n, _, err := encoder.DeserializeUint32(prefix[:])
+	if err != nil {
+		return nil, ErrBlocksFileCorrupt
+	}
+	if n > maxBlocksFileBlockSize {
+		return nil, ErrBlocksFileCorrupt
+	}
+
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, ErrBlocksFileTruncated
+	}
+
+	var b coin.SignedBlock
+	if err := encoder.DeserializeRawExact(data, &b); err != nil {
+		return nil, ErrBlocksFileCorrupt
+	}
+
+	return &b, nil
+}
+
+// ExportBlocks writes the signed blocks from start to end seq to a blocks file.
+// If end is beyond the head block, the blocks up to the head block are written
+func ExportBlocks(db *dbutil.DB, pubkey cipher.PubKey, w io.Writer, start, end uint64, quit chan struct{}) (*BlocksFileHeader, error) {
+	var hdr *BlocksFileHeader
+	if err := db.View("ExportBlocks", func(tx *dbutil.Tx) error {
+		if !dbutil.Exists(tx, blockdb.BlocksBkt) {
+			return ErrNoBlocks
+		}
+
+		bc, err := NewBlockchain(db, BlockchainConfig{Pubkey: pubkey})
+		if err != nil {
+			return err
+		}
+
+		headSeq, ok, err := bc.HeadSeq(tx)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrNoBlocks
+		}
+
+		if end > headSeq {
+			end = headSeq
+		}
+		if start > end {
+			return fmt.Errorf("Invalid block range %d-%d, the head block is %d", start, end, headSeq)
+		}
+
+		gb, err := bc.GetGenesisBlock(tx)
+		if err != nil {
+			return err
+		}
+		if gb == nil {
+			return ErrNoBlocks
+		}
+
+		hdr = &BlocksFileHeader{
+			Version:     BlocksFileVersion,
+			GenesisHash: gb.HashHeader(),
+			Pubkey:      pubkey,
+			StartSeq:    start,
+			EndSeq:      end,
+		}
+
+		if err := WriteBlocksFileHeader(w, *hdr); err != nil {
+			return err
+		}
+
+		for seq := start; seq <= end; seq++ {
+			select {
+			case <-quit:
+				return ErrBlocksFileStopped
+			default:
+			}
+
+			b, err := bc.GetSignedBlockBySeq(tx, seq)
+			if err != nil {
+				return err
+			}
+			if b == nil {
+				return fmt.Errorf("Block %d not found", seq)
+			}
+
+			if err := writeBlocksFileBlock(w, b); err != nil {
+				return err
+			}
+
+			if (seq-start+1)%blocksFileProgressInterval == 0 {
+				logger.Infof("Exported blocks %d/%d", seq, end)
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return hdr, nil
+}
+
+// ImportBlocks executes the blocks of a blocks file with ExecuteSignedBlock, which verifies their signatures.
+// Blocks already in the blockchain are skipped, so an interrupted import is resumed by importing the file again.
+// Returns the number of blocks executed
+func (vs *Visor) ImportBlocks(r io.Reader, quit chan struct{}) (uint64, error) {
+	hdr, err := ReadBlocksFileHeader(r)
+	if err != nil {
+		return 0, err
+	}
+
+	if hdr.Pubkey != vs.Config.BlockchainPubkey {
+		return 0, ErrBlocksFilePubkeyMismatch
+	}
+
+	var headSeq uint64
+	var hasHead bool
+	var head *coin.SignedBlock
+	if err := vs.db.View("ImportBlocks", func(tx *dbutil.Tx) error {
+		var err error
+		headSeq, hasHead, err = vs.blockchain.HeadSeq(tx)
+		if err != nil || !hasHead {
+			return err
+		}
+
+		gb, err := vs.blockchain.GetGenesisBlock(tx)
+		if err != nil {
+			return err
+		}
+		if gb == nil || gb.HashHeader() != hdr.GenesisHash {
+			return ErrBlocksFileGenesisMismatch
+		}
+
+		head, err = vs.blockchain.Head(tx)
+		return err
+	}); err != nil {
+		return 0, err
+	}
+
+	if !hasHead && hdr.StartSeq != 0 {
+		return 0, fmt.Errorf("Blocks file starts at block %d but the blockchain has no blocks", hdr.StartSeq)
+	}
+	if hasHead && hdr.StartSeq > headSeq+1 {
+		return 0, fmt.Errorf("Blocks file starts at block %d but the head block is %d", hdr.StartSeq, headSeq)
+	}
+
+	if hasHead && hdr.EndSeq <= headSeq {
+		logger.Infof("Blocks file ends at block %d, the head block is %d, nothing to import", hdr.EndSeq, headSeq)
+	}
+
+	var imported uint64
+	for seq := hdr.StartSeq; seq <= hdr.EndSeq; seq++ {
+		select {
+		case <-quit:
+			return imported, ErrBlocksFileStopped
+		default:
+		}
+
+		b, err := readBlocksFileBlock(r)
+		if err != nil {
+			if err == io.EOF {
+				return imported, ErrBlocksFileTruncated
+			}
+			return imported, err
+		}
+
+		if b.Head.BkSeq != seq {
+			return imported, ErrBlocksFileCorrupt
+		}
+		if seq == 0 && b.HashHeader() != hdr.GenesisHash {
+			return imported, ErrBlocksFileGenesisMismatch
+		}
+
+		if hasHead && seq <= headSeq {
+			// The head block must match, so that the remaining blocks extend the blockchain
+			if seq == headSeq && b.HashHeader() != head.HashHeader() {
+				return imported, ErrBlocksFileForked
+			}
+			continue
+		}
+
+		if err := vs.ExecuteSignedBlock(*b); err != nil {
+			return imported, fmt.Errorf("Execute block %d failed: %v", seq, err)
+		}
+		imported++
+
+		if seq%blocksFileProgressInterval == 0 {
+			logger.Infof("Imported blocks %d/%d", seq, hdr.EndSeq)
+		}
+	}
+
+	return imported, nil
+}
@@ -0,0 +1,350 @@
+package visor
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/params"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+// makeBlocksFileVisor creates a block publisher visor with a genesis block and n more blocks
+func makeBlocksFileVisor(t *testing.T, db *dbutil.DB, n int) *Visor {
+	cfg := NewConfig()
+	cfg.Distribution = params.MainNetDistribution
+	cfg.IsBlockPublisher = true
+	cfg.BlockchainPubkey = genPublic
+	cfg.BlockchainSeckey = genSecret
+	cfg.GenesisAddress = genAddress
+	cfg.GenesisCoinVolume = genCoins
+	cfg.GenesisTimestamp = genTime
+
+	v, err := New(cfg, db, nil)
+	require.NoError(t, err)
+
+	sb := addGenesisBlockToVisor(t, v)
+	for i := 0; i < n; i++ {
+		uxs := coin.CreateUnspents(sb.Head, sb.Body.Transactions[0])
+		txn := makeSpendTxn(t, uxs, []cipher.SecKey{genSecret}, genAddress, genCoins)
+
+		_, softErr, err := v.InjectForeignTransaction(txn)
+		require.NoError(t, err)
+		require.Nil(t, softErr)
+
+		var b coin.SignedBlock
+		err = db.Update("", func(tx *dbutil.Tx) error {
+			var err error
+			b, err = v.createBlock(tx, genTime+uint64(i+1)*3600)
+			if err != nil {
+				return err
+			}
+			return v.executeSignedBlock(tx, b)
+		})
+		require.NoError(t, err)
+		sb = &b
+	}
+
+	return v
+}
+
+// newImportVisor creates a visor that is not a block publisher, with an empty blockchain
+func newImportVisor(t *testing.T, db *dbutil.DB) *Visor {
+	cfg := NewConfig()
+	cfg.Distribution = params.MainNetDistribution
+	cfg.BlockchainPubkey = genPublic
+
+	v, err := New(cfg, db, nil)
+	require.NoError(t, err)
+	return v
+}
+
+func requireSameBlocks(t *testing.T, src, dst *Visor) {
+	srcHead, ok, err := src.HeadBkSeq()
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	dstHead, ok, err := dst.HeadBkSeq()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, srcHead, dstHead)
+
+	for seq := uint64(0); seq <= srcHead; seq++ {
+		a, err := src.GetSignedBlockBySeq(seq)
+		require.NoError(t, err)
+		b, err := dst.GetSignedBlockBySeq(seq)
+		require.NoError(t, err)
+		require.Equal(t, a.HashHeader(), b.HashHeader())
+		require.Equal(t, a.Sig, b.Sig)
+	}
+}
+
+func TestBlocksFileHeader(t *testing.T) {
+	hdr := BlocksFileHeader{
+		Version:     BlocksFileVersion,
+		GenesisHash: cipher.SumSHA256([]byte("genesis")),
+		Pubkey:      genPublic,
+		StartSeq:    10,
+		EndSeq:      20,
+	}
+
+	var buf bytes.Buffer
+	err := WriteBlocksFileHeader(&buf, hdr)
+	require.NoError(t, err)
+
+	b := buf.Bytes()
+
+	hdr2, err := ReadBlocksFileHeader(bytes.NewReader(b))
+	require.NoError(t, err)
+	require.Equal(t, hdr, hdr2)
+
+	// Bad magic
+	bad := append([]byte{}, b...)
+	bad[0] = 'X'
+	_, err = ReadBlocksFileHeader(bytes.NewReader(bad))
+	require.Equal(t, ErrBlocksFileInvalid, err)
+
+	// Modified header
+	bad = append([]byte{}, b...)
+	bad[20]++
+	_, err = ReadBlocksFileHeader(bytes.NewReader(bad))
+	require.Equal(t, ErrBlocksFileChecksum, err)
+
+	// Truncated header
+	_, err = ReadBlocksFileHeader(bytes.NewReader(b[:len(b)-1]))
+	require.Equal(t, ErrBlocksFileInvalid, err)
+
+	_, err = ReadBlocksFileHeader(bytes.NewReader(nil))
+	require.Equal(t, ErrBlocksFileInvalid, err)
+
+	// Unsupported version
+	hdr.Version = BlocksFileVersion + 1
+	buf.Reset()
+	err = WriteBlocksFileHeader(&buf, hdr)
+	require.NoError(t, err)
+	_, err = ReadBlocksFileHeader(&buf)
+	require.Equal(t, ErrBlocksFileVersion, err)
+
+	// Invalid range
+	hdr.Version = BlocksFileVersion
+	hdr.StartSeq = 21
+	buf.Reset()
+	err = WriteBlocksFileHeader(&buf, hdr)
+	require.NoError(t, err)
+	_, err = ReadBlocksFileHeader(&buf)
+	require.Equal(t, ErrBlocksFileInvalid, err)
+}
+
+func TestExportImportBlocks(t *testing.T) {
+	srcDB, shutdown := prepareDB(t)
+	defer shutdown()
+
+	src := makeBlocksFileVisor(t, srcDB, 5)
+
+	var buf bytes.Buffer
+	hdr, err := ExportBlocks(srcDB, genPublic, &buf, 0, 100, nil)
+	require.NoError(t, err)
+
+	gb, err := src.GetSignedBlockBySeq(0)
+	require.NoError(t, err)
+	require.Equal(t, BlocksFileHeader{
+		Version:     BlocksFileVersion,
+		GenesisHash: gb.HashHeader(),
+		Pubkey:      genPublic,
+		StartSeq:    0,
+		EndSeq:      5,
+	}, *hdr)
+
+	file := buf.Bytes()
+
+	dstDB, shutdown2 := prepareDB(t)
+	defer shutdown2()
+	dst := newImportVisor(t, dstDB)
+
+	// An interrupted import stops with the blocks imported so far
+	n, err := dst.ImportBlocks(bytes.NewReader(file[:len(file)-10]), nil)
+	require.Equal(t, ErrBlocksFileTruncated, err)
+	require.Equal(t, uint64(5), n)
+
+	// Importing again resumes from the head block
+	n, err = dst.ImportBlocks(bytes.NewReader(file), nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), n)
+	requireSameBlocks(t, src, dst)
+
+	// Importing a complete blockchain executes nothing
+	n, err = dst.ImportBlocks(bytes.NewReader(file), nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), n)
+
+	// A stopped import executes nothing
+	quit := make(chan struct{})
+	close(quit)
+	dstDB2, shutdown3 := prepareDB(t)
+	defer shutdown3()
+	dst2 := newImportVisor(t, dstDB2)
+	n, err = dst2.ImportBlocks(bytes.NewReader(file), quit)
+	require.Equal(t, ErrBlocksFileStopped, err)
+	require.Equal(t, uint64(0), n)
+
+	// A range that does not start from the genesis block can't be imported into an empty blockchain
+	buf.Reset()
+	_, err = ExportBlocks(srcDB, genPublic, &buf, 3, 4, nil)
+	require.NoError(t, err)
+	_, err = dst2.ImportBlocks(bytes.NewReader(buf.Bytes()), nil)
+	require.EqualError(t, err, "Blocks file starts at block 3 but the blockchain has no blocks")
+
+	// A range can be imported after the blocks before it
+	buf.Reset()
+	_, err = ExportBlocks(srcDB, genPublic, &buf, 0, 2, nil)
+	require.NoError(t, err)
+	n, err = dst2.ImportBlocks(bytes.NewReader(buf.Bytes()), nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), n)
+
+	buf.Reset()
+	_, err = ExportBlocks(srcDB, genPublic, &buf, 4, 5, nil)
+	require.NoError(t, err)
+	_, err = dst2.ImportBlocks(bytes.NewReader(buf.Bytes()), nil)
+	require.EqualError(t, err, "Blocks file starts at block 4 but the head block is 2")
+
+	buf.Reset()
+	_, err = ExportBlocks(srcDB, genPublic, &buf, 3, 5, nil)
+	require.NoError(t, err)
+	n, err = dst2.ImportBlocks(bytes.NewReader(buf.Bytes()), nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), n)
+	requireSameBlocks(t, src, dst2)
+
+	// Invalid ranges
+	_, err = ExportBlocks(srcDB, genPublic, &buf, 6, 10, nil)
+	require.EqualError(t, err, "Invalid block range 6-5, the head block is 5")
+
+	emptyDB, shutdown4 := prepareDB(t)
+	defer shutdown4()
+	_, err = ExportBlocks(emptyDB, genPublic, &buf, 0, 10, nil)
+	require.Equal(t, ErrNoBlocks, err)
+}
+
+func TestImportBlocksVerify(t *testing.T) {
+	srcDB, shutdown := prepareDB(t)
+	defer shutdown()
+
+	src := makeBlocksFileVisor(t, srcDB, 2)
+
+	var buf bytes.Buffer
+	_, err := ExportBlocks(srcDB, genPublic, &buf, 0, 2, nil)
+	require.NoError(t, err)
+	file := buf.Bytes()
+
+	gb, err := src.GetSignedBlockBySeq(0)
+	require.NoError(t, err)
+
+	writeFile := func(hdr BlocksFileHeader, blocks []coin.SignedBlock) []byte {
+		var buf bytes.Buffer
+		err := WriteBlocksFileHeader(&buf, hdr)
+		require.NoError(t, err)
+		for i := range blocks {
+			err := writeBlocksFileBlock(&buf, &blocks[i])
+			require.NoError(t, err)
+		}
+		return buf.Bytes()
+	}
+
+	var blocks []coin.SignedBlock
+	for seq := uint64(0); seq <= 2; seq++ {
+		b, err := src.GetSignedBlockBySeq(seq)
+		require.NoError(t, err)
+		blocks = append(blocks, *b)
+	}
+
+	hdr := BlocksFileHeader{
+		Version:     BlocksFileVersion,
+		GenesisHash: gb.HashHeader(),
+		Pubkey:      genPublic,
+		StartSeq:    0,
+		EndSeq:      2,
+	}
+
+	t.Run("pubkey mismatch", func(t *testing.T) {
+		db, shutdown := prepareDB(t)
+		defer shutdown()
+
+		pubkey, _ := cipher.GenerateKeyPair()
+		cfg := NewConfig()
+		cfg.Distribution = params.MainNetDistribution
+		cfg.BlockchainPubkey = pubkey
+		v, err := New(cfg, db, nil)
+		require.NoError(t, err)
+
+		_, err = v.ImportBlocks(bytes.NewReader(file), nil)
+		require.Equal(t, ErrBlocksFilePubkeyMismatch, err)
+	})
+
+	t.Run("genesis mismatch", func(t *testing.T) {
+		db, shutdown := prepareDB(t)
+		defer shutdown()
+
+		v := newImportVisor(t, db)
+
+		h := hdr
+		h.GenesisHash = cipher.SumSHA256([]byte("genesis"))
+		_, err := v.ImportBlocks(bytes.NewReader(writeFile(h, blocks)), nil)
+		require.Equal(t, ErrBlocksFileGenesisMismatch, err)
+
+		// The blockchain has a different genesis block
+		n, err := v.ImportBlocks(bytes.NewReader(writeFile(hdr, blocks[:1])), nil)
+		require.Equal(t, ErrBlocksFileTruncated, err)
+		require.Equal(t, uint64(1), n)
+
+		_, err = v.ImportBlocks(bytes.NewReader(writeFile(h, blocks)), nil)
+		require.Equal(t, ErrBlocksFileGenesisMismatch, err)
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		db, shutdown := prepareDB(t)
+		defer shutdown()
+
+		v := newImportVisor(t, db)
+
+		_, seckey := cipher.GenerateKeyPair()
+		bad := append([]coin.SignedBlock{}, blocks...)
+		bad[2].Sig = cipher.MustSignHash(bad[2].HashHeader(), seckey)
+
+		n, err := v.ImportBlocks(bytes.NewReader(writeFile(hdr, bad)), nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Execute block 2 failed")
+		require.Equal(t, uint64(2), n)
+	})
+
+	t.Run("out of sequence", func(t *testing.T) {
+		db, shutdown := prepareDB(t)
+		defer shutdown()
+
+		v := newImportVisor(t, db)
+
+		n, err := v.ImportBlocks(bytes.NewReader(writeFile(hdr, []coin.SignedBlock{blocks[0], blocks[2]})), nil)
+		require.Equal(t, ErrBlocksFileCorrupt, err)
+		require.Equal(t, uint64(1), n)
+	})
+
+	t.Run("forked", func(t *testing.T) {
+		db, shutdown := prepareDB(t)
+		defer shutdown()
+
+		v := newImportVisor(t, db)
+
+		_, err := v.ImportBlocks(bytes.NewReader(file), nil)
+		require.NoError(t, err)
+
+		forked := append([]coin.SignedBlock{}, blocks...)
+		forked[2].Head.Time++
+		forked[2].Sig = cipher.MustSignHash(forked[2].HashHeader(), genSecret)
+
+		_, err = v.ImportBlocks(bytes.NewReader(writeFile(hdr, forked)), nil)
+		require.Equal(t, ErrBlocksFileForked, err)
+	})
+}
@@ -44,6 +44,19 @@ type Config struct {
 	GenesisCoinVolume uint64
 	// enable arbitrating mode
 	Arbitrating bool
+	// Number of most recent blocks whose bodies are kept, older block bodies and their history are pruned.
+	// 0 disables pruning
+	PruneDepth uint64
+	// Number of workers that verify the input signatures of blocks before they are executed.
+	// 0 uses one worker per CPU
+	SignatureVerifyWorkers int
+	// Number of verified input signatures kept by the signature cache, 0 disables the cache
+	SignatureCacheSize int
+	// Number of blocks parsed in each database transaction when the history database is rebuilt,
+	// and of transactions added when its transaction indexes are backfilled. 0 uses DefaultHistoryRebuildBatchSize
+	HistoryRebuildBatchSize int
+	// Data directory of the node. Database backups can only be written in this directory
+	DataDirectory string
 }
 
 // NewConfig creates Config
@@ -62,6 +75,9 @@ func NewConfig() Config {
 		GenesisSignature:  cipher.Sig{},
 		GenesisTimestamp:  0,
 		GenesisCoinVolume: 0, //100e12, 100e6 * 10e6
+
+		SignatureCacheSize:      DefaultSignatureCacheSize,
+		HistoryRebuildBatchSize: DefaultHistoryRebuildBatchSize,
 	}
 
 	return c
@@ -111,6 +127,18 @@ func (c Config) Verify() error {
 		return errors.New("MaxBlockTransactionsSize must be >= CreateBlockVerifyTxn.MaxTransactionSize")
 	}
 
+	if c.PruneDepth != 0 && c.PruneDepth < MinPruneDepth {
+		return fmt.Errorf("PruneDepth must be 0 or >= %d", MinPruneDepth)
+	}
+
+	if c.SignatureCacheSize < 0 {
+		return errors.New("SignatureCacheSize must be >= 0")
+	}
+
+	if c.HistoryRebuildBatchSize < 0 {
+		return errors.New("HistoryRebuildBatchSize must be >= 0")
+	}
+
 	if err := c.Distribution.Validate(); err != nil {
 		return err
 	}
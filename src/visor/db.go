@@ -9,11 +9,10 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"sync"
 	"time"
 
-	"github.com/boltdb/bolt"
-
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/cipher/encoder"
 	"github.com/skycoin/skycoin/src/coin"
@@ -26,6 +25,9 @@ import (
 var (
 	// BlockchainVerifyTheadNum number of goroutines to use for signature and historydb verification
 	BlockchainVerifyTheadNum = 4
+
+	// ErrBackupOutsideDataDirectory is returned when the path of a database backup is not in the data directory
+	ErrBackupOutsideDataDirectory = errors.New("Backup file must be in the data directory")
 )
 
 // ErrCorruptDB is returned if the database is corrupted
@@ -140,7 +142,7 @@ func rebuildHistoryDB(db *dbutil.DB, history *historydb.HistoryDB, bc *Blockchai
 }
 
 // backupDB makes a backup copy of the DB
-func backupDB(db *dbutil.DB) (*dbutil.DB, error) { //nolint:unused,megacheck
+func backupDB(db *dbutil.DB) (*dbutil.DB, error) {
 	// backup the corrupted database
 	dbReadOnly := db.IsReadOnly()
 
@@ -223,15 +225,72 @@ func resetCorruptDB(db *dbutil.DB) (*dbutil.DB, error) {
 
 // OpenDB opens the blockdb
 func OpenDB(dbFile string, readOnly bool) (*dbutil.DB, error) {
-	db, err := bolt.Open(dbFile, 0600, &bolt.Options{
-		Timeout:  5000 * time.Millisecond,
-		ReadOnly: readOnly,
-	})
+	return OpenDBBackend(dbutil.BackendBolt, dbFile, readOnly)
+}
+
+// OpenDBBackend opens the blockdb with a backend, see dbutil.OpenBackend
+func OpenDBBackend(backend, dbFile string, readOnly bool) (*dbutil.DB, error) {
+	b, err := dbutil.OpenBackend(backend, dbFile, readOnly)
+	if err != nil {
+		return nil, fmt.Errorf("Open %s db failed, %v", backend, err)
+	}
+
+	return dbutil.NewDB(b), nil
+}
+
+// DBStats returns the storage statistics of the database
+func (vs *Visor) DBStats() (dbutil.Stats, error) {
+	return vs.db.Stats()
+}
+
+// BackupDB writes a consistent copy of the database to a new file at path while the node is running.
+// The path must be in the data directory. Returns the size of the backup file
+func (vs *Visor) BackupDB(path string) (int64, error) {
+	if !isInDirectory(path, vs.Config.DataDirectory) {
+		return 0, ErrBackupOutsideDataDirectory
+	}
+
+	logger.Infof("Backing up the database to %s", path)
+	return vs.db.Backup(path)
+}
+
+// isInDirectory returns true if path is an absolute path below dir
+func isInDirectory(path, dir string) bool {
+	if dir == "" || !filepath.IsAbs(path) {
+		return false
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+
+	rel, err := filepath.Rel(dir, filepath.Clean(path))
+	if err != nil {
+		return false
+	}
+
+	return rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// CompactDB rewrites the database file without its free pages, pausing writes while the database is copied
+// and reads while the new file replaces it.
+// Returns the size of the database file before and after compaction
+func (vs *Visor) CompactDB() (int64, int64, error) {
+	if vs.db.IsReadOnly() {
+		return 0, 0, dbutil.ErrDatabaseReadOnly
+	}
+
+	logger.Info("Compacting the database")
+	t := time.Now()
+
+	before, after, err := vs.db.Compact()
 	if err != nil {
-		return nil, fmt.Errorf("Open boltdb failed, %v", err)
+		return 0, 0, err
 	}
 
-	return dbutil.WrapDB(db), nil
+	logger.Infof("Compacted the database from %d to %d bytes in %s", before, after, time.Since(t))
+	return before, after, nil
 }
 
 // moveCorruptDB moves a file to makeCorruptDBPath(dbPath)
@@ -250,7 +309,7 @@ func moveCorruptDB(dbPath string) (string, error) {
 }
 
 // copyCorruptDB copy a file to makeCorruptDBPath(dbPath)
-func copyCorruptDB(dbPath string) (string, error) { //nolint:unused,megacheck
+func copyCorruptDB(dbPath string) (string, error) {
 	newDBPath, err := makeCorruptDBPath(dbPath)
 	if err != nil {
 		return "", err
@@ -269,7 +328,7 @@ func copyCorruptDB(dbPath string) (string, error) { //nolint:unused,megacheck
 	defer out.Close()
 	logger.Critical().Info(out.Name())
 
-	_, err = io.Copy(in, out)
+	_, err = io.Copy(out, in)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,546 @@
+package visor
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/visor/blockdb"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+)
+
+// DBIssueKind is the kind of an inconsistency found in the database
+type DBIssueKind string
+
+const (
+	// DBIssueMissingBlock a block below the head block does not exist
+	DBIssueMissingBlock DBIssueKind = "missing_block"
+	// DBIssueMissingSignature the signature of a block does not exist
+	DBIssueMissingSignature DBIssueKind = "missing_signature"
+	// DBIssueInvalidSignature the signature of a block is not signed by the blockchain pubkey
+	DBIssueInvalidSignature DBIssueKind = "invalid_signature"
+	// DBIssueUxHashMismatch the unspent output checksum does not match the unspent outputs
+	DBIssueUxHashMismatch DBIssueKind = "uxhash_mismatch"
+	// DBIssueUnspentMismatch the unspent pool does not match the unspent outputs created by the blocks
+	DBIssueUnspentMismatch DBIssueKind = "unspent_mismatch"
+	// DBIssueAddressIndexDrift the address index does not match the unspent pool
+	DBIssueAddressIndexDrift DBIssueKind = "address_index_drift"
+	// DBIssueHistoryGap the history database is missing blocks or does not match them
+	DBIssueHistoryGap DBIssueKind = "history_gap"
+)
+
+// DBRepair is a repair action that fixes an inconsistency of the database without resetting it
+type DBRepair string
+
+const (
+	// DBRepairNone the inconsistency can't be repaired, the database must be reset
+	DBRepairNone DBRepair = ""
+	// DBRepairTruncateChain removes the blocks after the last good block and rebuilds the unspent pool,
+	// the address index and the history database from the remaining blocks
+	DBRepairTruncateChain DBRepair = "truncate_chain"
+	// DBRepairRebuildUnspentPool rebuilds the unspent pool and the address index from the blocks
+	DBRepairRebuildUnspentPool DBRepair = "rebuild_unspent_pool"
+	// DBRepairRebuildAddressIndex rebuilds the address index from the unspent pool
+	DBRepairRebuildAddressIndex DBRepair = "rebuild_address_index"
+	// DBRepairRebuildHistory erases the history database, which is rebuilt in the background when the node starts
+	DBRepairRebuildHistory DBRepair = "rebuild_history"
+)
+
+// dbRepairOrder is the order in which the repairs are applied
+var dbRepairOrder = []DBRepair{
+	DBRepairTruncateChain,
+	DBRepairRebuildUnspentPool,
+	DBRepairRebuildAddressIndex,
+	DBRepairRebuildHistory,
+}
+
+var (
+	// ErrDBNotRepairable is returned by RepairDatabase if an issue of the report can't be repaired
+	ErrDBNotRepairable = errors.New("the database can't be repaired, it must be reset")
+)
+
+// DBIssue is an inconsistency found in the database
+type DBIssue struct {
+	Kind DBIssueKind `json:"kind"`
+	// Seq of the block where the issue was found, the head block for issues of the unspent pool
+	BlockSeq uint64   `json:"block_seq"`
+	Message  string   `json:"message"`
+	Repair   DBRepair `json:"repair"`
+}
+
+// DBReport is the result of CheckDatabaseReport
+type DBReport struct {
+	HeadSeq uint64 `json:"head_seq"`
+	// Seq of the last block that is consistent with the blocks before it, the head block if the chain is consistent
+	LastGoodBlockSeq uint64    `json:"last_good_block_seq"`
+	Issues           []DBIssue `json:"issues"`
+}
+
+// OK returns true if no issue was found
+func (r DBReport) OK() bool {
+	return len(r.Issues) == 0
+}
+
+// Repairable returns true if all issues can be repaired without resetting the database
+func (r DBReport) Repairable() bool {
+	for _, i := range r.Issues {
+		if i.Repair == DBRepairNone {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Repairs returns the repairs of the issues, in the order they are applied by RepairDatabase
+func (r DBReport) Repairs() []DBRepair {
+	var repairs []DBRepair
+	for _, repair := range dbRepairOrder {
+		if r.hasRepair(repair) {
+			repairs = append(repairs, repair)
+		}
+	}
+
+	return repairs
+}
+
+func (r DBReport) hasRepair(repair DBRepair) bool {
+	for _, i := range r.Issues {
+		if i.Repair == repair {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *DBReport) add(kind DBIssueKind, seq uint64, repair DBRepair, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, DBIssue{
+		Kind:     kind,
+		BlockSeq: seq,
+		Message:  fmt.Sprintf(format, args...),
+		Repair:   repair,
+	})
+}
+
+// unspentReplay recreates the unspent outputs by executing the blocks from the genesis block
+type unspentReplay struct {
+	uxs     map[cipher.SHA256]coin.UxOut
+	xorHash cipher.SHA256
+}
+
+func newUnspentReplay() *unspentReplay {
+	return &unspentReplay{
+		uxs: make(map[cipher.SHA256]coin.UxOut),
+	}
+}
+
+// apply spends the inputs of the transactions of the block and adds their outputs
+func (r *unspentReplay) apply(b *coin.Block) error {
+	for _, txn := range b.Body.Transactions {
+		for _, in := range txn.In {
+			ux, ok := r.uxs[in]
+			if !ok {
+				return fmt.Errorf("transaction %s spends output %s, which is not unspent", txn.Hash().Hex(), in.Hex())
+			}
+
+			r.xorHash = r.xorHash.Xor(ux.SnapshotHash())
+			delete(r.uxs, in)
+		}
+
+		for _, ux := range coin.CreateUnspents(b.Head, txn) {
+			h := ux.Hash()
+			if _, ok := r.uxs[h]; ok {
+				return fmt.Errorf("transaction %s creates output %s twice", txn.Hash().Hex(), h.Hex())
+			}
+
+			r.xorHash = r.xorHash.Xor(ux.SnapshotHash())
+			r.uxs[h] = ux
+		}
+	}
+
+	return nil
+}
+
+func (r *unspentReplay) array() coin.UxArray {
+	uxs := make(coin.UxArray, 0, len(r.uxs))
+	for _, ux := range r.uxs {
+		uxs = append(uxs, ux)
+	}
+
+	return uxs
+}
+
+// CheckDatabaseReport checks the consistency of the database and reports the issues that are found,
+// with the repair action of each issue. Unlike CheckDatabase, it does not stop at the first issue.
+// The blocks are checked in order, the unspent outputs are recreated from the blocks unless they are pruned
+// and compared with the unspent pool, the address index and the history database are compared
+// with the unspent pool and the blocks.
+// Returns a nil report if the database has no blocks
+func CheckDatabaseReport(db *dbutil.DB, pubkey cipher.PubKey, quit <-chan struct{}) (*DBReport, error) {
+	if quit == nil {
+		quit = make(chan struct{})
+	}
+
+	var report *DBReport
+	if err := db.View("CheckDatabaseReport", func(tx *dbutil.Tx) error {
+		if !dbutil.Exists(tx, blockdb.BlocksBkt) {
+			return nil
+		}
+
+		var err error
+		report, err = checkDatabaseReport(db, tx, pubkey, quit)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func checkDatabaseReport(db *dbutil.DB, tx *dbutil.Tx, pubkey cipher.PubKey, quit <-chan struct{}) (*DBReport, error) {
+	store, err := blockdb.NewBlockchain(db, DefaultWalker)
+	if err != nil {
+		return nil, err
+	}
+	unspent := blockdb.NewUnspentPool()
+	history := historydb.New()
+
+	headSeq, ok, err := store.HeadSeq(tx)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, nil
+	}
+
+	lowestSeq, err := store.LowestBlockSeq(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The unspent outputs can be recreated only if no block body has been pruned
+	pruned := lowestSeq != 0
+	truncateRepair := DBRepairTruncateChain
+	rebuildPoolRepair := DBRepairRebuildUnspentPool
+	rebuildHistoryRepair := DBRepairRebuildHistory
+	if pruned {
+		truncateRepair = DBRepairNone
+		rebuildPoolRepair = DBRepairNone
+		rebuildHistoryRepair = DBRepairNone
+	}
+
+	report := &DBReport{
+		HeadSeq:          headSeq,
+		LastGoodBlockSeq: headSeq,
+	}
+
+	// The history database is not checked while it is rebuilt
+	historyRebuilding, err := history.Rebuilding(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var historyParsed bool
+	var historyParsedSeq uint64
+	if !historyRebuilding {
+		historyParsedSeq, historyParsed, err = history.ParsedBlockSeq(tx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	checkHistory := !historyRebuilding
+	indexesMap := historydb.NewIndexesMap()
+
+	replay := newUnspentReplay()
+
+	// addBlockIssue records an issue of the block of given seq, which makes the block before it the last good block
+	addBlockIssue := func(kind DBIssueKind, seq uint64, format string, args ...interface{}) {
+		repair := truncateRepair
+		if seq == 0 {
+			repair = DBRepairNone
+		} else {
+			report.LastGoodBlockSeq = seq - 1
+		}
+		report.add(kind, seq, repair, format, args...)
+	}
+
+	chainOK := true
+	for seq := uint64(0); seq <= headSeq; seq++ {
+		select {
+		case <-quit:
+			return nil, ErrVerifyStopped
+		default:
+		}
+
+		head, sig, err := store.GetSignedBlockHeaderBySeq(tx, seq)
+		if err != nil {
+			if _, ok := err.(blockdb.ErrMissingSignature); ok {
+				addBlockIssue(DBIssueMissingSignature, seq, "Signature of block %d does not exist", seq)
+				chainOK = false
+				break
+			}
+			return nil, err
+		}
+
+		if head == nil {
+			addBlockIssue(DBIssueMissingBlock, seq, "Block %d does not exist", seq)
+			chainOK = false
+			break
+		}
+
+		if err := cipher.VerifyPubKeySignedHash(pubkey, sig, head.Hash()); err != nil {
+			addBlockIssue(DBIssueInvalidSignature, seq, "Signature of block %d is invalid: %v", seq, err)
+			chainOK = false
+			break
+		}
+
+		if pruned && seq != 0 && seq < lowestSeq {
+			continue
+		}
+
+		b, err := store.GetSignedBlockBySeq(tx, seq)
+		if err != nil {
+			switch err.(type) {
+			case blockdb.ErrBlockPruned:
+				continue
+			}
+			return nil, err
+		}
+
+		if !pruned {
+			if seq != 0 && b.Head.UxHash != replay.xorHash {
+				addBlockIssue(DBIssueUxHashMismatch, seq, "UxHash of block %d is %s, the unspent outputs of the blocks before it hash to %s",
+					seq, b.Head.UxHash.Hex(), replay.xorHash.Hex())
+				chainOK = false
+				break
+			}
+
+			if err := replay.apply(&b.Block); err != nil {
+				addBlockIssue(DBIssueUnspentMismatch, seq, "Block %d is inconsistent with the blocks before it: %v", seq, err)
+				chainOK = false
+				break
+			}
+		}
+
+		if checkHistory && historyParsed && seq <= historyParsedSeq {
+			if err := history.Verify(tx, b, indexesMap); err != nil {
+				switch err.(type) {
+				case historydb.ErrHistoryDBCorrupted:
+					report.add(DBIssueHistoryGap, seq, rebuildHistoryRepair, "History of block %d is corrupted: %v", seq, err)
+					checkHistory = false
+				default:
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if checkHistory && (!historyParsed || historyParsedSeq < headSeq) {
+		if historyParsed {
+			report.add(DBIssueHistoryGap, historyParsedSeq+1, rebuildHistoryRepair,
+				"History database has parsed blocks up to %d, the head block is %d", historyParsedSeq, headSeq)
+		} else {
+			report.add(DBIssueHistoryGap, 0, rebuildHistoryRepair, "History database has not parsed any block")
+		}
+	}
+
+	// The unspent pool is not checked against the blocks if they are truncated, since the truncation rebuilds it
+	if !chainOK {
+		return report, nil
+	}
+
+	poolUxHash, err := unspent.CalcUxHash(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	uxHash, err := unspent.GetUxHash(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if poolUxHash != uxHash {
+		report.add(DBIssueUxHashMismatch, headSeq, rebuildPoolRepair,
+			"Unspent pool checksum is %s, the unspent outputs of the pool hash to %s", uxHash.Hex(), poolUxHash.Hex())
+	}
+
+	if !pruned {
+		poolUxs, err := unspent.GetAll(tx)
+		if err != nil {
+			return nil, err
+		}
+
+		var missing, unexpected int
+		poolHashes := make(map[cipher.SHA256]struct{}, len(poolUxs))
+		for _, ux := range poolUxs {
+			h := ux.Hash()
+			poolHashes[h] = struct{}{}
+			if _, ok := replay.uxs[h]; !ok {
+				unexpected++
+			}
+		}
+
+		for h := range replay.uxs {
+			if _, ok := poolHashes[h]; !ok {
+				missing++
+			}
+		}
+
+		if missing != 0 || unexpected != 0 {
+			report.add(DBIssueUnspentMismatch, headSeq, rebuildPoolRepair,
+				"Unspent pool is missing %d unspent outputs of the blocks and has %d outputs that are not unspent", missing, unexpected)
+		}
+	}
+
+	if err := unspent.VerifyIndexes(tx, headSeq); err != nil {
+		switch err.(type) {
+		case blockdb.ErrAddrIndexDrift:
+			report.add(DBIssueAddressIndexDrift, headSeq, DBRepairRebuildAddressIndex, "Address index is inconsistent: %v", err)
+		default:
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+// RepairDatabase applies the repairs of the issues of a report made by CheckDatabaseReport.
+// The chain is truncated to the last good block of the report, the unspent pool and the address index
+// are rebuilt, and the history database is erased, to be rebuilt in the background by the node.
+// Returns ErrDBNotRepairable if an issue of the report can't be repaired
+func RepairDatabase(db *dbutil.DB, report *DBReport, quit <-chan struct{}) error {
+	if report == nil || report.OK() {
+		return nil
+	}
+
+	if !report.Repairable() {
+		return ErrDBNotRepairable
+	}
+
+	if quit == nil {
+		quit = make(chan struct{})
+	}
+
+	store, err := blockdb.NewBlockchain(db, DefaultWalker)
+	if err != nil {
+		return err
+	}
+	unspent := blockdb.NewUnspentPool()
+	history := historydb.New()
+
+	return db.Update("RepairDatabase", func(tx *dbutil.Tx) error {
+		headSeq := report.HeadSeq
+		rebuildHistory := false
+
+		for _, repair := range report.Repairs() {
+			logger.Critical().Infof("Repairing database: %s", repair)
+
+			switch repair {
+			case DBRepairTruncateChain, DBRepairRebuildUnspentPool:
+				if repair == DBRepairTruncateChain {
+					headSeq = report.LastGoodBlockSeq
+					if err := store.Truncate(tx, headSeq); err != nil {
+						return err
+					}
+					rebuildHistory = true
+				} else if report.hasRepair(DBRepairTruncateChain) {
+					// The unspent pool was rebuilt by the truncation
+					continue
+				}
+
+				replay := newUnspentReplay()
+				for seq := uint64(0); seq <= headSeq; seq++ {
+					select {
+					case <-quit:
+						return ErrVerifyStopped
+					default:
+					}
+
+					b, err := store.GetSignedBlockBySeq(tx, seq)
+					if err != nil {
+						return err
+					} else if b == nil {
+						return fmt.Errorf("no block exists in depth: %d", seq)
+					}
+
+					if err := replay.apply(&b.Block); err != nil {
+						return err
+					}
+				}
+
+				// Loading the unspent outputs rebuilds the address index
+				if err := unspent.LoadSnapshot(tx, replay.array(), headSeq); err != nil {
+					return err
+				}
+
+			case DBRepairRebuildAddressIndex:
+				if report.hasRepair(DBRepairTruncateChain) || report.hasRepair(DBRepairRebuildUnspentPool) {
+					continue
+				}
+
+				if err := unspent.RebuildIndexes(tx, headSeq); err != nil {
+					return err
+				}
+
+			case DBRepairRebuildHistory:
+				rebuildHistory = true
+
+			default:
+				return fmt.Errorf("unknown database repair %q", repair)
+			}
+		}
+
+		if rebuildHistory {
+			if err := history.Erase(tx); err != nil {
+				return err
+			}
+
+			if err := history.SetRebuilding(tx, true); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// RepairCorruptDB checks the database with CheckDatabaseReport and repairs the issues that are found
+// with RepairDatabase, after making a copy of the corrupted database.
+// If an issue can't be repaired, the database is reset like ResetCorruptDB
+func RepairCorruptDB(db *dbutil.DB, pubkey cipher.PubKey, quit chan struct{}) (*dbutil.DB, error) {
+	report, err := CheckDatabaseReport(db, pubkey, quit)
+	if err == encoder.ErrBufferUnderflow || err == encoder.ErrMaxLenExceeded {
+		logger.Critical().Errorf("Database is corrupted (encoder error), recreating db: %v", err)
+		return resetCorruptDB(db)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if report == nil || report.OK() {
+		return db, nil
+	}
+
+	for _, i := range report.Issues {
+		logger.Critical().Errorf("Database is corrupted: %s (block %d): %s", i.Kind, i.BlockSeq, i.Message)
+	}
+
+	if !report.Repairable() || db.IsReadOnly() {
+		logger.Critical().Error("Database can't be repaired, recreating db")
+		return resetCorruptDB(db)
+	}
+
+	db, err = backupDB(db)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := RepairDatabase(db, report, quit); err != nil {
+		return nil, err
+	}
+
+	logger.Critical().Infof("Database repaired, the blockchain head is block %d", report.LastGoodBlockSeq)
+	return db, nil
+}
@@ -0,0 +1,152 @@
+package visor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/visor/blockdb"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+func TestCheckDatabaseReport(t *testing.T) {
+	getBlock := func(t *testing.T, v *Visor, seq uint64) *coin.SignedBlock {
+		var b *coin.SignedBlock
+		err := v.db.View("", func(tx *dbutil.Tx) error {
+			var err error
+			b, err = v.blockchain.GetSignedBlockBySeq(tx, seq)
+			return err
+		})
+		require.NoError(t, err)
+		require.NotNil(t, b)
+		return b
+	}
+
+	tt := []struct {
+		name     string
+		corrupt  func(t *testing.T, v *Visor, tx *dbutil.Tx) error
+		kinds    []DBIssueKind
+		lastGood uint64
+		repairs  []DBRepair
+		headSeq  uint64
+	}{
+		{
+			name:     "ok",
+			corrupt:  func(t *testing.T, v *Visor, tx *dbutil.Tx) error { return nil },
+			lastGood: 5,
+			headSeq:  5,
+		},
+		{
+			name: "missing signature",
+			corrupt: func(t *testing.T, v *Visor, tx *dbutil.Tx) error {
+				b := getBlock(t, v, 3)
+				h := b.HashHeader()
+				return dbutil.Delete(tx, blockdb.BlockSigsBkt, h[:])
+			},
+			kinds:    []DBIssueKind{DBIssueMissingSignature},
+			lastGood: 2,
+			repairs:  []DBRepair{DBRepairTruncateChain},
+			headSeq:  2,
+		},
+		{
+			name: "invalid signature",
+			corrupt: func(t *testing.T, v *Visor, tx *dbutil.Tx) error {
+				b := getBlock(t, v, 4)
+				sig := getBlock(t, v, 3).Sig
+				h := b.HashHeader()
+				return dbutil.PutBucketValue(tx, blockdb.BlockSigsBkt, h[:], sig[:])
+			},
+			kinds:    []DBIssueKind{DBIssueInvalidSignature},
+			lastGood: 3,
+			repairs:  []DBRepair{DBRepairTruncateChain},
+			headSeq:  3,
+		},
+		{
+			name: "address index drift",
+			corrupt: func(t *testing.T, v *Visor, tx *dbutil.Tx) error {
+				return dbutil.Delete(tx, blockdb.UnspentPoolAddrIndexBkt, genAddress.Bytes())
+			},
+			kinds:    []DBIssueKind{DBIssueAddressIndexDrift},
+			lastGood: 5,
+			repairs:  []DBRepair{DBRepairRebuildAddressIndex},
+			headSeq:  5,
+		},
+		{
+			name: "unspent pool mismatch",
+			corrupt: func(t *testing.T, v *Visor, tx *dbutil.Tx) error {
+				b := getBlock(t, v, 5)
+				uxs := coin.CreateUnspents(b.Head, b.Body.Transactions[0])
+				h := uxs[0].Hash()
+				return dbutil.Delete(tx, blockdb.UnspentPoolBkt, h[:])
+			},
+			kinds: []DBIssueKind{
+				DBIssueUxHashMismatch,
+				DBIssueUnspentMismatch,
+				DBIssueAddressIndexDrift,
+			},
+			lastGood: 5,
+			repairs:  []DBRepair{DBRepairRebuildUnspentPool, DBRepairRebuildAddressIndex},
+			headSeq:  5,
+		},
+		{
+			name: "history gap",
+			corrupt: func(t *testing.T, v *Visor, tx *dbutil.Tx) error {
+				return v.history.Erase(tx)
+			},
+			kinds:    []DBIssueKind{DBIssueHistoryGap},
+			lastGood: 5,
+			repairs:  []DBRepair{DBRepairRebuildHistory},
+			headSeq:  5,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			db, shutdown := prepareDB(t)
+			defer shutdown()
+
+			v := makeBlocksFileVisor(t, db, 5)
+
+			err := db.Update("", func(tx *dbutil.Tx) error {
+				return tc.corrupt(t, v, tx)
+			})
+			require.NoError(t, err)
+
+			report, err := CheckDatabaseReport(db, genPublic, nil)
+			require.NoError(t, err)
+			require.NotNil(t, report)
+			require.Equal(t, uint64(5), report.HeadSeq)
+			require.Equal(t, tc.lastGood, report.LastGoodBlockSeq)
+			require.Equal(t, tc.repairs, report.Repairs())
+			require.True(t, report.Repairable())
+			require.Equal(t, len(tc.kinds) == 0, report.OK())
+
+			var kinds []DBIssueKind
+			for _, i := range report.Issues {
+				kinds = append(kinds, i.Kind)
+			}
+			require.Equal(t, tc.kinds, kinds)
+
+			err = RepairDatabase(db, report, nil)
+			require.NoError(t, err)
+
+			// The database is consistent after the repairs, with the history database to be rebuilt
+			report, err = CheckDatabaseReport(db, genPublic, nil)
+			require.NoError(t, err)
+			require.True(t, report.OK(), "%v", report.Issues)
+			require.Equal(t, tc.headSeq, report.HeadSeq)
+
+			// The repaired database is loaded and its history is rebuilt
+			v, err = New(v.Config, db, nil)
+			require.NoError(t, err)
+
+			err = v.RebuildHistory(make(chan struct{}))
+			require.NoError(t, err)
+
+			num, err := v.GetTransactionsNum()
+			require.NoError(t, err)
+			require.Equal(t, tc.headSeq+1, num)
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package dbutil
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+const (
+	// BackendBolt is the name of the boltdb backend, which stores the database in a file
+	BackendBolt = "bolt"
+	// BackendMemory is the name of the in-memory backend, which does not persist the database
+	BackendMemory = "memory"
+)
+
+// boltOpenTimeout is how long to wait for the lock on a bolt database file
+const boltOpenTimeout = time.Second * 5
+
+// Backends are the names of the available backends
+var Backends = []string{
+	BackendBolt,
+	BackendMemory,
+}
+
+// The errors returned by the backends. The bolt errors are used, so that the errors of each backend are the same
+var (
+	// ErrDatabaseNotOpen is returned when a transaction is started on a closed database
+	ErrDatabaseNotOpen = bolt.ErrDatabaseNotOpen
+	// ErrDatabaseReadOnly is returned when an update transaction is started on a read-only database
+	ErrDatabaseReadOnly = bolt.ErrDatabaseReadOnly
+	// ErrTxNotWritable is returned when writing in a read-only transaction
+	ErrTxNotWritable = bolt.ErrTxNotWritable
+	// ErrBucketNotFound is returned when deleting a bucket that does not exist
+	ErrBucketNotFound = bolt.ErrBucketNotFound
+	// ErrBucketExists is returned when creating a bucket that already exists
+	ErrBucketExists = bolt.ErrBucketExists
+	// ErrBucketNameRequired is returned when creating a bucket with an empty name
+	ErrBucketNameRequired = bolt.ErrBucketNameRequired
+	// ErrKeyRequired is returned when putting an empty key
+	ErrKeyRequired = bolt.ErrKeyRequired
+)
+
+// Backend is a key-value store of buckets, accessed in transactions.
+// Any number of View transactions can run concurrently, and one Update transaction at a time.
+// An Update transaction is rolled back if its function returns an error
+type Backend interface {
+	View(f func(BackendTx) error) error
+	Update(f func(BackendTx) error) error
+	Close() error
+	// Path returns the path of the database file, or an empty string if the backend has no file
+	Path() string
+	IsReadOnly() bool
+	// Stats returns the storage statistics of the database
+	Stats() (Stats, error)
+}
+
+// BackendTx is a transaction of a Backend
+type BackendTx interface {
+	// Bucket returns the bucket, or nil if the bucket does not exist
+	Bucket(name []byte) Bucket
+	CreateBucket(name []byte) (Bucket, error)
+	CreateBucketIfNotExists(name []byte) (Bucket, error)
+	DeleteBucket(name []byte) error
+	// ForEachBucket calls f for each bucket in the order of their names
+	ForEachBucket(f func(name []byte, b Bucket) error) error
+	Writable() bool
+}
+
+// Bucket is a collection of key-value pairs ordered by key.
+// The byte slices returned by a Bucket are only valid during the transaction and must not be modified
+type Bucket interface {
+	// Get returns the value of the key, or nil if the key does not exist
+	Get(key []byte) []byte
+	Put(key, value []byte) error
+	Delete(key []byte) error
+	// ForEach calls f for each key-value pair in the order of the keys.
+	// The bucket must not be modified during the iteration
+	ForEach(f func(k, v []byte) error) error
+	// Cursor returns a Cursor for ordered iteration over the bucket
+	Cursor() Cursor
+	// NextSequence returns an autoincrementing integer for the bucket
+	NextSequence() (uint64, error)
+	// Sequence returns the current integer for the bucket without incrementing it
+	Sequence() uint64
+	// SetSequence sets the current integer for the bucket
+	SetSequence(v uint64) error
+	// KeyN returns the number of keys in the bucket
+	KeyN() int
+}
+
+// Cursor iterates over the key-value pairs of a bucket in the order of the keys.
+// The methods return a nil key when there are no more pairs
+type Cursor interface {
+	First() (key, value []byte)
+	Last() (key, value []byte)
+	Next() (key, value []byte)
+	Prev() (key, value []byte)
+	// Seek moves to the key, or to the next key if the key does not exist
+	Seek(seek []byte) (key, value []byte)
+}
+
+// OpenBackend opens a backend by name.
+// The bolt backend opens the database file at path.
+// The memory backend loads the database file at path if the file exists, and does not write to it
+func OpenBackend(name, path string, readOnly bool) (Backend, error) {
+	switch name {
+	case BackendBolt:
+		db, err := bolt.Open(path, 0600, &bolt.Options{
+			Timeout:  boltOpenTimeout,
+			ReadOnly: readOnly,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return NewBoltBackend(db), nil
+	case BackendMemory:
+		return LoadMemoryBackend(path, readOnly)
+	default:
+		return nil, fmt.Errorf("Invalid database backend %q", name)
+	}
+}
+
+// CopyBackend copies the buckets of src into dst
+func CopyBackend(dst, src Backend) error {
+	return src.View(func(srcTx BackendTx) error {
+		return dst.Update(func(dstTx BackendTx) error {
+			return srcTx.ForEachBucket(func(name []byte, srcBkt Bucket) error {
+				dstBkt, err := dstTx.CreateBucketIfNotExists(name)
+				if err != nil {
+					return err
+				}
+
+				if err := srcBkt.ForEach(func(k, v []byte) error {
+					return dstBkt.Put(k, v)
+				}); err != nil {
+					return err
+				}
+
+				return dstBkt.SetSequence(srcBkt.Sequence())
+			})
+		})
+	})
+}
@@ -0,0 +1,441 @@
+package dbutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/boltdb/bolt"
+	"github.com/stretchr/testify/require"
+)
+
+var testBkt = []byte("test")
+
+// testBackends calls f with an empty backend of each kind
+func testBackends(t *testing.T, f func(t *testing.T, b Backend)) {
+	t.Run(BackendBolt, func(t *testing.T) {
+		tmp, err := ioutil.TempFile("", "testdb")
+		require.NoError(t, err)
+		defer os.Remove(tmp.Name())
+		require.NoError(t, tmp.Close())
+
+		b, err := OpenBackend(BackendBolt, tmp.Name(), false)
+		require.NoError(t, err)
+		defer b.Close()
+
+		f(t, b)
+	})
+
+	t.Run(BackendMemory, func(t *testing.T) {
+		b := NewMemoryBackend()
+		defer b.Close()
+
+		f(t, b)
+	})
+}
+
+func TestBackendBuckets(t *testing.T) {
+	testBackends(t, func(t *testing.T, b Backend) {
+		err := b.Update(func(tx BackendTx) error {
+			require.True(t, tx.Writable())
+			require.Nil(t, tx.Bucket(testBkt))
+
+			_, err := tx.CreateBucket(nil)
+			require.Equal(t, ErrBucketNameRequired, err)
+
+			_, err = tx.CreateBucket(testBkt)
+			require.NoError(t, err)
+			require.NotNil(t, tx.Bucket(testBkt))
+
+			_, err = tx.CreateBucket(testBkt)
+			require.Equal(t, ErrBucketExists, err)
+
+			_, err = tx.CreateBucketIfNotExists(testBkt)
+			require.NoError(t, err)
+
+			_, err = tx.CreateBucket([]byte("a"))
+			require.NoError(t, err)
+
+			var names []string
+			err = tx.ForEachBucket(func(name []byte, b Bucket) error {
+				names = append(names, string(name))
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, []string{"a", "test"}, names)
+
+			require.NoError(t, tx.DeleteBucket([]byte("a")))
+			require.Nil(t, tx.Bucket([]byte("a")))
+			require.Equal(t, ErrBucketNotFound, tx.DeleteBucket([]byte("a")))
+
+			return nil
+		})
+		require.NoError(t, err)
+
+		err = b.View(func(tx BackendTx) error {
+			require.False(t, tx.Writable())
+			require.NotNil(t, tx.Bucket(testBkt))
+			require.Nil(t, tx.Bucket([]byte("a")))
+
+			_, err := tx.CreateBucket([]byte("b"))
+			require.Equal(t, ErrTxNotWritable, err)
+
+			return nil
+		})
+		require.NoError(t, err)
+	})
+}
+
+func TestBackendBucketValues(t *testing.T) {
+	testBackends(t, func(t *testing.T, b Backend) {
+		err := b.Update(func(tx BackendTx) error {
+			bkt, err := tx.CreateBucket(testBkt)
+			require.NoError(t, err)
+
+			require.Nil(t, bkt.Get([]byte("a")))
+			require.Equal(t, 0, bkt.KeyN())
+
+			require.Equal(t, ErrKeyRequired, bkt.Put(nil, []byte("v")))
+
+			require.NoError(t, bkt.Put([]byte("b"), []byte("2")))
+			require.NoError(t, bkt.Put([]byte("a"), []byte("1")))
+			require.NoError(t, bkt.Put([]byte("c"), []byte{}))
+			require.NoError(t, bkt.Put([]byte("b"), []byte("22")))
+
+			require.Equal(t, []byte("1"), bkt.Get([]byte("a")))
+			require.Equal(t, []byte("22"), bkt.Get([]byte("b")))
+			require.NotNil(t, bkt.Get([]byte("c")))
+			require.Empty(t, bkt.Get([]byte("c")))
+
+			require.NoError(t, bkt.Delete([]byte("a")))
+			require.NoError(t, bkt.Delete([]byte("x")))
+			require.Nil(t, bkt.Get([]byte("a")))
+
+			seq, err := bkt.NextSequence()
+			require.NoError(t, err)
+			require.Equal(t, uint64(1), seq)
+			require.NoError(t, bkt.SetSequence(10))
+			seq, err = bkt.NextSequence()
+			require.NoError(t, err)
+			require.Equal(t, uint64(11), seq)
+
+			return nil
+		})
+		require.NoError(t, err)
+
+		err = b.View(func(tx BackendTx) error {
+			bkt := tx.Bucket(testBkt)
+			require.Equal(t, 2, bkt.KeyN())
+			require.Equal(t, uint64(11), bkt.Sequence())
+
+			var keys []string
+			err := bkt.ForEach(func(k, v []byte) error {
+				keys = append(keys, string(k))
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, []string{"b", "c"}, keys)
+
+			require.Equal(t, ErrTxNotWritable, bkt.Put([]byte("d"), []byte("4")))
+			require.Equal(t, ErrTxNotWritable, bkt.Delete([]byte("b")))
+
+			return nil
+		})
+		require.NoError(t, err)
+	})
+}
+
+func TestBackendRollback(t *testing.T) {
+	testBackends(t, func(t *testing.T, b Backend) {
+		err := b.Update(func(tx BackendTx) error {
+			bkt, err := tx.CreateBucket(testBkt)
+			require.NoError(t, err)
+			return bkt.Put([]byte("a"), []byte("1"))
+		})
+		require.NoError(t, err)
+
+		rollbackErr := errors.New("rollback")
+		err = b.Update(func(tx BackendTx) error {
+			bkt := tx.Bucket(testBkt)
+			require.NoError(t, bkt.Put([]byte("a"), []byte("2")))
+			require.NoError(t, bkt.Put([]byte("b"), []byte("3")))
+			_, err := bkt.NextSequence()
+			require.NoError(t, err)
+
+			_, err = tx.CreateBucket([]byte("other"))
+			require.NoError(t, err)
+
+			// The transaction reads its own writes
+			require.Equal(t, []byte("2"), bkt.Get([]byte("a")))
+			return rollbackErr
+		})
+		require.Equal(t, rollbackErr, err)
+
+		err = b.View(func(tx BackendTx) error {
+			require.Nil(t, tx.Bucket([]byte("other")))
+
+			bkt := tx.Bucket(testBkt)
+			require.Equal(t, []byte("1"), bkt.Get([]byte("a")))
+			require.Nil(t, bkt.Get([]byte("b")))
+			require.Equal(t, uint64(0), bkt.Sequence())
+			return nil
+		})
+		require.NoError(t, err)
+
+		// A deleted bucket is restored on rollback
+		err = b.Update(func(tx BackendTx) error {
+			require.NoError(t, tx.DeleteBucket(testBkt))
+			return rollbackErr
+		})
+		require.Equal(t, rollbackErr, err)
+
+		err = b.View(func(tx BackendTx) error {
+			require.Equal(t, []byte("1"), tx.Bucket(testBkt).Get([]byte("a")))
+			return nil
+		})
+		require.NoError(t, err)
+	})
+}
+
+func TestBackendCursor(t *testing.T) {
+	testBackends(t, func(t *testing.T, b Backend) {
+		rnd := rand.New(rand.NewSource(1))
+
+		var keys []string
+		err := b.Update(func(tx BackendTx) error {
+			bkt, err := tx.CreateBucket(testBkt)
+			require.NoError(t, err)
+
+			// Empty bucket
+			c := bkt.Cursor()
+			k, _ := c.First()
+			require.Nil(t, k)
+			k, _ = c.Last()
+			require.Nil(t, k)
+			k, _ = c.Seek([]byte("a"))
+			require.Nil(t, k)
+
+			for i := 0; i < 300; i++ {
+				key := fmt.Sprintf("%04d", rnd.Intn(1000)*2)
+				require.NoError(t, bkt.Put([]byte(key), []byte("v"+key)))
+			}
+
+			// Delete some keys
+			for i := 0; i < 100; i++ {
+				key := fmt.Sprintf("%04d", rnd.Intn(1000)*2)
+				require.NoError(t, bkt.Delete([]byte(key)))
+			}
+
+			return bkt.ForEach(func(k, v []byte) error {
+				keys = append(keys, string(k))
+				require.Equal(t, "v"+string(k), string(v))
+				return nil
+			})
+		})
+		require.NoError(t, err)
+		require.True(t, sort.StringsAreSorted(keys))
+
+		err = b.View(func(tx BackendTx) error {
+			bkt := tx.Bucket(testBkt)
+			require.Equal(t, len(keys), bkt.KeyN())
+
+			c := bkt.Cursor()
+
+			// Forward
+			var got []string
+			for k, _ := c.First(); k != nil; k, _ = c.Next() {
+				got = append(got, string(k))
+			}
+			require.Equal(t, keys, got)
+			k, _ := c.Next()
+			require.Nil(t, k)
+
+			// Backward
+			got = nil
+			for k, _ := c.Last(); k != nil; k, _ = c.Prev() {
+				got = append([]string{string(k)}, got...)
+			}
+			require.Equal(t, keys, got)
+
+			// Seek to existing and missing keys, then step in both directions
+			for i := 0; i < 100; i++ {
+				seek := fmt.Sprintf("%04d", rnd.Intn(2002))
+				j := sort.SearchStrings(keys, seek)
+
+				k, v := c.Seek([]byte(seek))
+				if j == len(keys) {
+					require.Nil(t, k, seek)
+					continue
+				}
+				require.Equal(t, keys[j], string(k), seek)
+				require.Equal(t, "v"+keys[j], string(v))
+
+				k, _ = c.Next()
+				if j+1 < len(keys) {
+					require.Equal(t, keys[j+1], string(k))
+				} else {
+					require.Nil(t, k)
+				}
+
+				k, _ = c.Seek([]byte(seek))
+				require.Equal(t, keys[j], string(k))
+				k, _ = c.Prev()
+				if j > 0 {
+					require.Equal(t, keys[j-1], string(k))
+				} else {
+					require.Nil(t, k)
+				}
+			}
+
+			return nil
+		})
+		require.NoError(t, err)
+	})
+}
+
+func TestMemoryBackendSnapshot(t *testing.T) {
+	b := NewMemoryBackend()
+
+	err := b.Update(func(tx BackendTx) error {
+		bkt, err := tx.CreateBucket(testBkt)
+		require.NoError(t, err)
+		return bkt.Put([]byte("a"), []byte("1"))
+	})
+	require.NoError(t, err)
+
+	// A View transaction reads the database as it was when it started,
+	// and does not block an Update transaction
+	err = b.View(func(tx BackendTx) error {
+		bkt := tx.Bucket(testBkt)
+
+		err := b.Update(func(tx BackendTx) error {
+			return tx.Bucket(testBkt).Put([]byte("a"), []byte("2"))
+		})
+		require.NoError(t, err)
+
+		require.Equal(t, []byte("1"), bkt.Get([]byte("a")))
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = b.View(func(tx BackendTx) error {
+		require.Equal(t, []byte("2"), tx.Bucket(testBkt).Get([]byte("a")))
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, b.Close())
+
+	err = b.View(func(tx BackendTx) error {
+		return nil
+	})
+	require.Equal(t, ErrDatabaseNotOpen, err)
+
+	err = b.Update(func(tx BackendTx) error {
+		return nil
+	})
+	require.Equal(t, ErrDatabaseNotOpen, err)
+}
+
+func TestLoadMemoryBackend(t *testing.T) {
+	tmp, err := ioutil.TempFile("", "testdb")
+	require.NoError(t, err)
+	defer os.Remove(tmp.Name())
+	require.NoError(t, tmp.Close())
+
+	db, err := bolt.Open(tmp.Name(), 0600, nil)
+	require.NoError(t, err)
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		bkt, err := tx.CreateBucket(testBkt)
+		require.NoError(t, err)
+		require.NoError(t, bkt.SetSequence(5))
+		for i := 0; i < 10; i++ {
+			require.NoError(t, bkt.Put([]byte{byte(i)}, []byte{byte(i * 2)}))
+		}
+		_, err = tx.CreateBucket([]byte("empty"))
+		return err
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	checkLoaded := func(b Backend) {
+		err := b.View(func(tx BackendTx) error {
+			require.NotNil(t, tx.Bucket([]byte("empty")))
+			require.Equal(t, 0, tx.Bucket([]byte("empty")).KeyN())
+
+			bkt := tx.Bucket(testBkt)
+			require.Equal(t, 10, bkt.KeyN())
+			require.Equal(t, uint64(5), bkt.Sequence())
+			for i := 0; i < 10; i++ {
+				require.Equal(t, []byte{byte(i * 2)}, bkt.Get([]byte{byte(i)}))
+			}
+			return nil
+		})
+		require.NoError(t, err)
+	}
+
+	b, err := OpenBackend(BackendMemory, tmp.Name(), false)
+	require.NoError(t, err)
+	require.False(t, b.IsReadOnly())
+	require.Empty(t, b.Path())
+	checkLoaded(b)
+
+	// Updates are not written to the file
+	err = b.Update(func(tx BackendTx) error {
+		return tx.DeleteBucket(testBkt)
+	})
+	require.NoError(t, err)
+
+	b, err = OpenBackend(BackendMemory, tmp.Name(), true)
+	require.NoError(t, err)
+	require.True(t, b.IsReadOnly())
+	checkLoaded(b)
+
+	err = b.Update(func(tx BackendTx) error {
+		return nil
+	})
+	require.Equal(t, ErrDatabaseReadOnly, err)
+
+	// A missing file is an empty database
+	b, err = OpenBackend(BackendMemory, tmp.Name()+".missing", false)
+	require.NoError(t, err)
+	err = b.View(func(tx BackendTx) error {
+		return tx.ForEachBucket(func(name []byte, b Bucket) error {
+			return errors.New("unexpected bucket")
+		})
+	})
+	require.NoError(t, err)
+
+	_, err = OpenBackend("leveldb", tmp.Name(), false)
+	require.EqualError(t, err, `Invalid database backend "leveldb"`)
+}
+
+func TestCopyBackend(t *testing.T) {
+	src := NewMemoryBackend()
+	err := src.Update(func(tx BackendTx) error {
+		for _, name := range []string{"a", "b"} {
+			bkt, err := tx.CreateBucket([]byte(name))
+			require.NoError(t, err)
+			require.NoError(t, bkt.Put([]byte("k"), []byte(name)))
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	dst := NewMemoryBackend()
+	require.NoError(t, CopyBackend(dst, src))
+
+	err = dst.View(func(tx BackendTx) error {
+		for _, name := range []string{"a", "b"} {
+			require.True(t, bytes.Equal([]byte(name), tx.Bucket([]byte(name)).Get([]byte("k"))))
+		}
+		return nil
+	})
+	require.NoError(t, err)
+}
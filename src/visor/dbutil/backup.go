@@ -0,0 +1,301 @@
+package dbutil
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/boltdb/bolt"
+)
+
+// copyTxMaxSize is the number of bytes of keys and values copied in one transaction when writing a bolt file,
+// so that copying a large database does not keep all of it in memory
+var copyTxMaxSize = 64 * 1024 * 1024
+
+// compactCopiedHook is called by tests after Compact copied the database, while View transactions are allowed
+var compactCopiedHook func(tmpPath string)
+
+var (
+	// ErrCompactUnsupported is returned when compacting a database that is not stored in a bolt file
+	ErrCompactUnsupported = errors.New("Only a bolt database file can be compacted")
+	// ErrBackupFileExists is returned when the backup file already exists
+	ErrBackupFileExists = errors.New("Backup file already exists")
+)
+
+// ErrCompactReopenFailed is returned when the database file can't be reopened after compaction.
+// The database is closed and can't be used anymore
+type ErrCompactReopenFailed struct {
+	Err error
+}
+
+func (e ErrCompactReopenFailed) Error() string {
+	return fmt.Sprintf("Reopening the database after compaction failed: %v", e.Err)
+}
+
+// Stats are the storage statistics of a database
+type Stats struct {
+	// Size of the database file in bytes, 0 if the backend has no file
+	Size int64
+	// Size of a page of the database file in bytes
+	PageSize int
+	// Number of free pages of the database file. Free pages are reused before the file grows,
+	// and are released by compaction
+	FreePages int
+}
+
+// Stats returns the storage statistics of the database
+func (db *DB) Stats() (Stats, error) {
+	db.shutdownLock.RLock()
+	defer db.shutdownLock.RUnlock()
+
+	return db.Backend.Stats()
+}
+
+// Backup writes a consistent copy of the database to a new bolt file at path.
+// The copy is made in a View transaction, so the database stays available while it is backed up.
+// Returns the size of the backup file
+func (db *DB) Backup(path string) (int64, error) {
+	if _, err := os.Stat(path); err == nil {
+		return 0, ErrBackupFileExists
+	} else if !os.IsNotExist(err) {
+		return 0, err
+	}
+
+	// Write to a new temporary file, so that an interrupted backup does not leave a partial backup file
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	tmpPath := f.Name()
+
+	if err := db.View("Backup", func(tx *Tx) error {
+		if btx, ok := tx.BackendTx.(boltTx); ok {
+			return writeBoltTx(f, btx.tx)
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		return writeBoltFile(tmpPath, tx.BackendTx)
+	}); err != nil {
+		f.Close()
+		removeTmpFile(tmpPath)
+		return 0, err
+	}
+
+	// Unlike a rename, a link fails if a file was created at path during the backup
+	err = os.Link(tmpPath, path)
+	removeTmpFile(tmpPath)
+	if err != nil {
+		if os.IsExist(err) {
+			return 0, ErrBackupFileExists
+		}
+		return 0, err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
+
+// Compact rewrites the bolt database file into a new file without free pages, and replaces the database file with it.
+// Update transactions are paused while the database is copied, View transactions are only paused
+// while the database file is replaced.
+// If the database file can't be reopened, ErrCompactReopenFailed is returned and also sent to the Failed channel.
+// Returns the size of the database file before and after compaction
+func (db *DB) Compact() (int64, int64, error) {
+	db.writeLock.Lock()
+	defer db.writeLock.Unlock()
+
+	sizeBefore, tmpPath, err := db.compactCopy()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	db.shutdownLock.Lock()
+	defer db.shutdownLock.Unlock()
+
+	// The database was closed during the copy
+	if db.closed {
+		removeTmpFile(tmpPath)
+		return 0, 0, ErrDatabaseNotOpen
+	}
+
+	b := db.Backend.(*boltBackend)
+	path := b.db.Path()
+
+	if err := b.db.Close(); err != nil {
+		removeTmpFile(tmpPath)
+		return 0, 0, err
+	}
+
+	// If the compacted file can't replace the database file, the database file is reopened unchanged
+	renameErr := os.Rename(tmpPath, path)
+
+	bdb, err := bolt.Open(path, 0600, &bolt.Options{
+		Timeout: boltOpenTimeout,
+	})
+	if err != nil {
+		// The closed database is kept, transactions fail with ErrDatabaseNotOpen
+		db.closed = true
+		err = ErrCompactReopenFailed{
+			Err: err,
+		}
+		logger.Critical().WithError(err).Error("Compact")
+		db.Fail(err)
+		return 0, 0, err
+	}
+	b.db = bdb
+
+	if renameErr != nil {
+		removeTmpFile(tmpPath)
+		return 0, 0, renameErr
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return sizeBefore, fi.Size(), nil
+}
+
+// compactCopy writes a copy of the database without free pages to a temporary file, while reads can continue.
+// writeLock must be locked. Returns the size of the database file and the path of the copy
+func (db *DB) compactCopy() (int64, string, error) {
+	db.shutdownLock.RLock()
+	defer db.shutdownLock.RUnlock()
+
+	if db.closed {
+		return 0, "", ErrDatabaseNotOpen
+	}
+
+	b, ok := db.Backend.(*boltBackend)
+	if !ok {
+		return 0, "", ErrCompactUnsupported
+	}
+	if b.db.IsReadOnly() {
+		return 0, "", ErrDatabaseReadOnly
+	}
+
+	path := b.db.Path()
+	fi, err := os.Stat(path)
+	if err != nil {
+		return 0, "", err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*.compact")
+	if err != nil {
+		return 0, "", err
+	}
+	tmpPath := f.Name()
+	if err := f.Close(); err != nil {
+		removeTmpFile(tmpPath)
+		return 0, "", err
+	}
+
+	if err := b.db.View(func(tx *bolt.Tx) error {
+		return writeBoltFile(tmpPath, boltTx{tx})
+	}); err != nil {
+		removeTmpFile(tmpPath)
+		return 0, "", err
+	}
+
+	if compactCopiedHook != nil {
+		compactCopiedHook(tmpPath)
+	}
+
+	return fi.Size(), tmpPath, nil
+}
+
+// writeBoltTx writes a copy of the bolt database file read by a transaction to an empty file, and closes the file
+func writeBoltTx(f *os.File, tx *bolt.Tx) error {
+	if _, err := tx.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// writeBoltFile copies the buckets read by a transaction to a new or empty bolt file at path.
+// The buckets are copied in transactions of at most copyTxMaxSize bytes
+func writeBoltFile(path string, src BackendTx) error {
+	dst, err := bolt.Open(path, 0600, &bolt.Options{
+		Timeout: boltOpenTimeout,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := copyBoltBuckets(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+
+	return dst.Close()
+}
+
+func copyBoltBuckets(dst *bolt.DB, src BackendTx) error {
+	tx, err := dst.Begin(true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Rolling back a committed transaction does nothing
+		if tx != nil {
+			tx.Rollback()
+		}
+	}()
+
+	size := 0
+	if err := src.ForEachBucket(func(name []byte, srcBkt Bucket) error {
+		dstBkt, err := tx.CreateBucketIfNotExists(name)
+		if err != nil {
+			return err
+		}
+
+		if err := srcBkt.ForEach(func(k, v []byte) error {
+			if size+len(k)+len(v) > copyTxMaxSize {
+				if err := tx.Commit(); err != nil {
+					return err
+				}
+
+				tx, err = dst.Begin(true)
+				if err != nil {
+					return err
+				}
+
+				// Buckets are only valid during the transaction that returned them
+				dstBkt = tx.Bucket(name)
+				size = 0
+			}
+
+			size += len(k) + len(v)
+			return dstBkt.Put(k, v)
+		}); err != nil {
+			return err
+		}
+
+		return dstBkt.SetSequence(srcBkt.Sequence())
+	}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// removeTmpFile removes the temporary file of a failed backup or compaction
+func removeTmpFile(path string) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		logger.WithError(err).Warningf("os.Remove(%s) failed", path)
+	}
+}
@@ -0,0 +1,250 @@
+package dbutil
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// putTestValues puts n values of 1KB in each of the buckets
+func putTestValues(t *testing.T, db *DB, buckets []string, n int) {
+	err := db.Update("", func(tx *Tx) error {
+		for _, name := range buckets {
+			bkt, err := tx.CreateBucketIfNotExists([]byte(name))
+			require.NoError(t, err)
+			for i := 0; i < n; i++ {
+				require.NoError(t, bkt.Put(Itob(uint64(i)), make([]byte, 1024)))
+			}
+			_, err = bkt.NextSequence()
+			require.NoError(t, err)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+// requireTestValues checks the number of values put by putTestValues in each bucket of a bolt file
+func requireTestValues(t *testing.T, path string, buckets map[string]int) {
+	b, err := OpenBackend(BackendBolt, path, true)
+	require.NoError(t, err)
+	defer b.Close()
+
+	err = b.View(func(tx BackendTx) error {
+		n := 0
+		err := tx.ForEachBucket(func(name []byte, bkt Bucket) error {
+			n++
+			require.Equal(t, buckets[string(name)], bkt.KeyN())
+			require.Equal(t, uint64(1), bkt.Sequence())
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, len(buckets), n)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestDBBackup(t *testing.T) {
+	buckets := []string{"a", "b", "c"}
+
+	testBackends(t, func(t *testing.T, b Backend) {
+		dir, err := ioutil.TempDir("", "backup")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		db := NewDB(b)
+		putTestValues(t, db, buckets, 100)
+
+		// Existing files are never removed
+		path := filepath.Join(dir, "backup.db")
+		err = ioutil.WriteFile(path+".tmp", []byte("foo"), 0600)
+		require.NoError(t, err)
+
+		size, err := db.Backup(path)
+		require.NoError(t, err)
+
+		fi, err := os.Stat(path)
+		require.NoError(t, err)
+		require.Equal(t, fi.Size(), size)
+
+		// The temporary file is removed
+		files, err := ioutil.ReadDir(dir)
+		require.NoError(t, err)
+		require.Len(t, files, 2)
+
+		data, err := ioutil.ReadFile(path + ".tmp")
+		require.NoError(t, err)
+		require.Equal(t, []byte("foo"), data)
+
+		requireTestValues(t, path, map[string]int{
+			"a": 100,
+			"b": 100,
+			"c": 100,
+		})
+
+		// The backup file is not overwritten
+		_, err = db.Backup(path)
+		require.Equal(t, ErrBackupFileExists, err)
+	})
+}
+
+func TestDBCompact(t *testing.T) {
+	buckets := []string{"a", "b", "c"}
+
+	tmp, err := ioutil.TempFile("", "testdb")
+	require.NoError(t, err)
+	defer os.Remove(tmp.Name())
+	require.NoError(t, tmp.Close())
+
+	b, err := OpenBackend(BackendBolt, tmp.Name(), false)
+	require.NoError(t, err)
+	db := NewDB(b)
+	defer db.Close()
+
+	putTestValues(t, db, buckets, 1000)
+	putTestValues(t, db, []string{"deleted"}, 8000)
+	err = db.Update("", func(tx *Tx) error {
+		return tx.DeleteBucket([]byte("deleted"))
+	})
+	require.NoError(t, err)
+
+	stats, err := db.Stats()
+	require.NoError(t, err)
+	require.NotEqual(t, 0, stats.PageSize)
+	require.NotEqual(t, 0, stats.FreePages)
+
+	// Copy in many transactions
+	defer func(n int) {
+		copyTxMaxSize = n
+	}(copyTxMaxSize)
+	copyTxMaxSize = 100 * 1024
+
+	before, after, err := db.Compact()
+	require.NoError(t, err)
+	require.Equal(t, stats.Size, before)
+	require.True(t, after < before, fmt.Sprintf("%d >= %d", after, before))
+
+	tmpFiles, err := filepath.Glob(tmp.Name() + ".*.compact")
+	require.NoError(t, err)
+	require.Empty(t, tmpFiles)
+
+	// The database is usable after compaction
+	stats, err = db.Stats()
+	require.NoError(t, err)
+	require.Equal(t, after, stats.Size)
+	putTestValues(t, db, []string{"d"}, 10)
+
+	require.NoError(t, db.Close())
+	requireTestValues(t, tmp.Name(), map[string]int{
+		"a": 1000,
+		"b": 1000,
+		"c": 1000,
+		"d": 10,
+	})
+}
+
+func TestDBCompactUnsupported(t *testing.T) {
+	db := NewDB(NewMemoryBackend())
+	_, _, err := db.Compact()
+	require.Equal(t, ErrCompactUnsupported, err)
+
+	stats, err := db.Stats()
+	require.NoError(t, err)
+	require.Equal(t, Stats{}, stats)
+}
+
+func TestDBCompactConcurrency(t *testing.T) {
+	tmp, err := ioutil.TempFile("", "testdb")
+	require.NoError(t, err)
+	defer os.Remove(tmp.Name())
+	require.NoError(t, tmp.Close())
+
+	b, err := OpenBackend(BackendBolt, tmp.Name(), false)
+	require.NoError(t, err)
+	db := NewDB(b)
+	defer db.Close()
+
+	putTestValues(t, db, []string{"a"}, 100)
+
+	updated := make(chan error, 1)
+	defer func() {
+		compactCopiedHook = nil
+	}()
+	compactCopiedHook = func(string) {
+		// The database can be read while it is copied
+		err := db.View("", func(tx *Tx) error {
+			return nil
+		})
+		require.NoError(t, err)
+
+		// Updates wait for the compaction to finish
+		go func() {
+			updated <- db.Update("", func(tx *Tx) error {
+				return tx.Bucket([]byte("a")).Put([]byte("k"), []byte("v"))
+			})
+		}()
+
+		select {
+		case <-updated:
+			t.Fatal("Update should wait for the compaction")
+		case <-time.After(time.Millisecond * 100):
+		}
+	}
+
+	_, _, err = db.Compact()
+	require.NoError(t, err)
+	require.NoError(t, <-updated)
+
+	err = db.View("", func(tx *Tx) error {
+		require.Equal(t, []byte("v"), tx.Bucket([]byte("a")).Get([]byte("k")))
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestDBCompactReopenFailed(t *testing.T) {
+	tmp, err := ioutil.TempFile("", "testdb")
+	require.NoError(t, err)
+	defer os.Remove(tmp.Name())
+	require.NoError(t, tmp.Close())
+
+	b, err := OpenBackend(BackendBolt, tmp.Name(), false)
+	require.NoError(t, err)
+	db := NewDB(b)
+	defer db.Close()
+
+	putTestValues(t, db, []string{"a"}, 100)
+
+	defer func() {
+		compactCopiedHook = nil
+	}()
+	compactCopiedHook = func(tmpPath string) {
+		// The compacted file replaces the database file but can't be opened
+		require.NoError(t, ioutil.WriteFile(tmpPath, []byte("invalid"), 0600))
+	}
+
+	_, _, err = db.Compact()
+	require.IsType(t, ErrCompactReopenFailed{}, err)
+
+	select {
+	case failedErr := <-db.Failed():
+		require.Equal(t, err, failedErr)
+	default:
+		t.Fatal("The failure should be sent to the Failed channel")
+	}
+
+	// The database can't be used anymore
+	err = db.View("", func(tx *Tx) error {
+		return nil
+	})
+	require.Equal(t, ErrDatabaseNotOpen, err)
+
+	_, _, err = db.Compact()
+	require.Equal(t, ErrDatabaseNotOpen, err)
+	require.NoError(t, db.Close())
+}
@@ -0,0 +1,112 @@
+package dbutil
+
+import (
+	"os"
+
+	"github.com/boltdb/bolt"
+)
+
+// boltBackend is a Backend that stores the database in a boltdb file
+type boltBackend struct {
+	db *bolt.DB
+}
+
+// NewBoltBackend returns a Backend for a *bolt.DB
+func NewBoltBackend(db *bolt.DB) Backend {
+	return &boltBackend{
+		db: db,
+	}
+}
+
+func (b *boltBackend) View(f func(BackendTx) error) error {
+	return b.db.View(func(tx *bolt.Tx) error {
+		return f(boltTx{tx})
+	})
+}
+
+func (b *boltBackend) Update(f func(BackendTx) error) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return f(boltTx{tx})
+	})
+}
+
+func (b *boltBackend) Close() error {
+	return b.db.Close()
+}
+
+func (b *boltBackend) Path() string {
+	return b.db.Path()
+}
+
+func (b *boltBackend) IsReadOnly() bool {
+	return b.db.IsReadOnly()
+}
+
+func (b *boltBackend) Stats() (Stats, error) {
+	fi, err := os.Stat(b.db.Path())
+	if err != nil {
+		return Stats{}, err
+	}
+
+	// The free pages include the pages freed by the last transactions, which are reused once no transaction reads them
+	stats := b.db.Stats()
+	return Stats{
+		Size:      fi.Size(),
+		PageSize:  b.db.Info().PageSize,
+		FreePages: stats.FreePageN + stats.PendingPageN,
+	}, nil
+}
+
+type boltTx struct {
+	tx *bolt.Tx
+}
+
+func (tx boltTx) Bucket(name []byte) Bucket {
+	bkt := tx.tx.Bucket(name)
+	if bkt == nil {
+		return nil
+	}
+	return boltBucket{bkt}
+}
+
+func (tx boltTx) CreateBucket(name []byte) (Bucket, error) {
+	bkt, err := tx.tx.CreateBucket(name)
+	if err != nil {
+		return nil, err
+	}
+	return boltBucket{bkt}, nil
+}
+
+func (tx boltTx) CreateBucketIfNotExists(name []byte) (Bucket, error) {
+	bkt, err := tx.tx.CreateBucketIfNotExists(name)
+	if err != nil {
+		return nil, err
+	}
+	return boltBucket{bkt}, nil
+}
+
+func (tx boltTx) DeleteBucket(name []byte) error {
+	return tx.tx.DeleteBucket(name)
+}
+
+func (tx boltTx) ForEachBucket(f func(name []byte, b Bucket) error) error {
+	return tx.tx.ForEach(func(name []byte, bkt *bolt.Bucket) error {
+		return f(name, boltBucket{bkt})
+	})
+}
+
+func (tx boltTx) Writable() bool {
+	return tx.tx.Writable()
+}
+
+type boltBucket struct {
+	*bolt.Bucket
+}
+
+func (b boltBucket) Cursor() Cursor {
+	return b.Bucket.Cursor()
+}
+
+func (b boltBucket) KeyN() int {
+	return b.Bucket.Stats().KeyN
+}
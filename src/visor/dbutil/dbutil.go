@@ -1,5 +1,6 @@
 /*
-Package dbutil provides boltdb utility methods
+Package dbutil provides database utility methods over a key-value Backend.
+The backend is boltdb by default, or an in-memory store
 */
 package dbutil
 
@@ -28,19 +29,19 @@ var (
 	txDurationReportingThreshold = time.Millisecond * 100
 )
 
-// Tx wraps a Tx
+// Tx wraps a BackendTx
 type Tx struct {
-	*bolt.Tx
+	BackendTx
 }
 
 // String is implemented to prevent a panic when mocking methods with *Tx arguments.
 // The mock library forces arguments to be printed with %s which causes Tx to panic.
 // See https://github.com/stretchr/testify/pull/596
 func (tx *Tx) String() string {
-	return fmt.Sprintf("%v", tx.Tx)
+	return fmt.Sprintf("%v", tx.BackendTx)
 }
 
-// DB wraps a bolt.DB to add logging
+// DB wraps a Backend to add logging
 type DB struct {
 	ViewLog                    bool
 	ViewTrace                  bool
@@ -49,7 +50,7 @@ type DB struct {
 	DurationLog                bool
 	DurationReportingThreshold time.Duration
 
-	*bolt.DB
+	Backend
 
 	// shutdownLock is added to prevent closing the database while a View transaction is in progress
 	// bolt.DB will block for Update transactions but not for View transactions, and if
@@ -58,10 +59,22 @@ type DB struct {
 	// https://github.com/coreos/bbolt/pull/91
 	// When coreos has this feature, we can switch to coreos's bbolt and remove this lock
 	shutdownLock sync.RWMutex
+	// writeLock serializes the Update transactions with the copy made by Compact,
+	// so that the database can be read while it is compacted. It is locked before shutdownLock
+	writeLock sync.Mutex
+	// closed is set when the database is closed, under shutdownLock
+	closed bool
+	// failed receives the error that made the database unusable
+	failed chan error
 }
 
-// WrapDB returns WrapDB
+// WrapDB returns a DB for a *bolt.DB
 func WrapDB(db *bolt.DB) *DB {
+	return NewDB(NewBoltBackend(db))
+}
+
+// NewDB returns a DB for a Backend
+func NewDB(b Backend) *DB {
 	return &DB{
 		ViewLog:                    txViewLog,
 		UpdateLog:                  txUpdateLog,
@@ -69,11 +82,27 @@ func WrapDB(db *bolt.DB) *DB {
 		UpdateTrace:                txUpdateTrace,
 		DurationLog:                txDurationLog,
 		DurationReportingThreshold: txDurationReportingThreshold,
-		DB:                         db,
+		Backend:                    b,
+		failed:                     make(chan error, 1),
+	}
+}
+
+// Failed returns a channel that receives an error if the database becomes unusable,
+// in which case the program must shut down
+func (db *DB) Failed() <-chan error {
+	return db.failed
+}
+
+// Fail reports an error that makes the database unusable on the Failed channel.
+// Only the first error is reported
+func (db *DB) Fail(err error) {
+	select {
+	case db.failed <- err:
+	default:
 	}
 }
 
-// View wraps *bolt.DB.View to add logging
+// View wraps Backend.View to add logging
 func (db *DB) View(name string, f func(*Tx) error) error {
 	db.shutdownLock.RLock()
 	defer db.shutdownLock.RUnlock()
@@ -88,7 +117,7 @@ func (db *DB) View(name string, f func(*Tx) error) error {
 
 	t0 := time.Now()
 
-	err := db.DB.View(func(tx *bolt.Tx) error {
+	err := db.Backend.View(func(tx BackendTx) error {
 		return f(&Tx{tx})
 	})
 
@@ -101,8 +130,11 @@ func (db *DB) View(name string, f func(*Tx) error) error {
 	return err
 }
 
-// Update wraps *bolt.DB.Update to add logging
+// Update wraps Backend.Update to add logging
 func (db *DB) Update(name string, f func(*Tx) error) error {
+	db.writeLock.Lock()
+	defer db.writeLock.Unlock()
+
 	db.shutdownLock.RLock()
 	defer db.shutdownLock.RUnlock()
 
@@ -116,7 +148,7 @@ func (db *DB) Update(name string, f func(*Tx) error) error {
 
 	t0 := time.Now()
 
-	err := db.DB.Update(func(tx *bolt.Tx) error {
+	err := db.Backend.Update(func(tx BackendTx) error {
 		return f(&Tx{tx})
 	})
 
@@ -129,15 +161,16 @@ func (db *DB) Update(name string, f func(*Tx) error) error {
 	return err
 }
 
-// Close closes the underlying *bolt.DB
+// Close closes the underlying Backend
 func (db *DB) Close() error {
 	db.shutdownLock.Lock()
 	defer db.shutdownLock.Unlock()
 
-	return db.DB.Close()
+	db.closed = true
+	return db.Backend.Close()
 }
 
-// ErrCreateBucketFailed is returned if creating a bolt.DB bucket fails
+// ErrCreateBucketFailed is returned if creating a bucket fails
 type ErrCreateBucketFailed struct {
 	Bucket string
 	Err    error
@@ -155,7 +188,7 @@ func NewErrCreateBucketFailed(bucket []byte, err error) error {
 	}
 }
 
-// ErrBucketNotExist is returned if a bolt.DB bucket does not exist
+// ErrBucketNotExist is returned if a bucket does not exist
 type ErrBucketNotExist struct {
 	Bucket string
 }
@@ -236,7 +269,7 @@ func GetBucketValue(tx *Tx, bktName, key []byte) ([]byte, error) {
 		return nil, nil
 	}
 
-	// Bytes returned from boltdb are not valid outside of the transaction
+	// Bytes returned from the backend are not valid outside of the transaction
 	// they are called in, make a copy
 	w := make([]byte, len(v))
 	copy(w[:], v[:])
@@ -314,13 +347,13 @@ func Len(tx *Tx, bktName []byte) (uint64, error) {
 		return 0, NewErrBucketNotExist(bktName)
 	}
 
-	bstats := bkt.Stats()
+	n := bkt.KeyN()
 
-	if bstats.KeyN < 0 {
+	if n < 0 {
 		return 0, errors.New("Negative length queried from db stats")
 	}
 
-	return uint64(bstats.KeyN), nil
+	return uint64(n), nil
 }
 
 // IsEmpty returns true if the bucket is empty
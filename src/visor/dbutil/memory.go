@@ -0,0 +1,541 @@
+package dbutil
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/boltdb/bolt"
+)
+
+// memoryBackend is a Backend that keeps the database in memory.
+//
+// Each bucket is a persistent treap: an update copies the nodes on the path to the changed key
+// instead of modifying them. An Update transaction works on copies of the bucket roots and
+// replaces the committed buckets when it succeeds, so that View transactions read a snapshot without locking,
+// and rolling back an Update transaction is discarding its buckets
+type memoryBackend struct {
+	// updateLock allows one Update transaction at a time
+	updateLock sync.Mutex
+	// lock protects buckets and closed
+	lock     sync.RWMutex
+	buckets  map[string]*memoryBucket
+	closed   bool
+	readOnly bool
+}
+
+// NewMemoryBackend returns an empty in-memory Backend
+func NewMemoryBackend() Backend {
+	return &memoryBackend{
+		buckets: make(map[string]*memoryBucket),
+	}
+}
+
+// LoadMemoryBackend returns an in-memory Backend with the contents of the bolt database file at path.
+// If path is empty or the file does not exist, the backend is empty.
+// The file is not modified
+func LoadMemoryBackend(path string, readOnly bool) (Backend, error) {
+	b := &memoryBackend{
+		buckets: make(map[string]*memoryBucket),
+	}
+
+	if path != "" {
+		if _, err := os.Stat(path); err == nil {
+			db, err := bolt.Open(path, 0600, &bolt.Options{
+				Timeout:  boltOpenTimeout,
+				ReadOnly: true,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			if err := CopyBackend(b, NewBoltBackend(db)); err != nil {
+				db.Close()
+				return nil, err
+			}
+
+			if err := db.Close(); err != nil {
+				return nil, err
+			}
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	b.readOnly = readOnly
+	return b, nil
+}
+
+// snapshot returns the committed buckets
+func (b *memoryBackend) snapshot() (map[string]*memoryBucket, error) {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	if b.closed {
+		return nil, ErrDatabaseNotOpen
+	}
+
+	return b.buckets, nil
+}
+
+func (b *memoryBackend) View(f func(BackendTx) error) error {
+	buckets, err := b.snapshot()
+	if err != nil {
+		return err
+	}
+
+	return f(&memoryTx{
+		buckets: buckets,
+	})
+}
+
+func (b *memoryBackend) Update(f func(BackendTx) error) error {
+	if b.readOnly {
+		return ErrDatabaseReadOnly
+	}
+
+	b.updateLock.Lock()
+	defer b.updateLock.Unlock()
+
+	committed, err := b.snapshot()
+	if err != nil {
+		return err
+	}
+
+	// The transaction changes copies of the buckets, the committed buckets are not modified
+	buckets := make(map[string]*memoryBucket, len(committed))
+	for name, bkt := range committed {
+		c := *bkt
+		buckets[name] = &c
+	}
+
+	if err := f(&memoryTx{
+		buckets:  buckets,
+		writable: true,
+	}); err != nil {
+		return err
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.closed {
+		return ErrDatabaseNotOpen
+	}
+
+	b.buckets = buckets
+	return nil
+}
+
+func (b *memoryBackend) Close() error {
+	b.updateLock.Lock()
+	defer b.updateLock.Unlock()
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.closed = true
+	b.buckets = nil
+	return nil
+}
+
+func (b *memoryBackend) Path() string {
+	return ""
+}
+
+func (b *memoryBackend) IsReadOnly() bool {
+	return b.readOnly
+}
+
+// Stats returns empty statistics, since the backend has no file
+func (b *memoryBackend) Stats() (Stats, error) {
+	if _, err := b.snapshot(); err != nil {
+		return Stats{}, err
+	}
+	return Stats{}, nil
+}
+
+type memoryTx struct {
+	buckets  map[string]*memoryBucket
+	writable bool
+}
+
+func (tx *memoryTx) Bucket(name []byte) Bucket {
+	bkt, ok := tx.buckets[string(name)]
+	if !ok {
+		return nil
+	}
+	return &memoryTxBucket{
+		tx:     tx,
+		bucket: bkt,
+	}
+}
+
+func (tx *memoryTx) CreateBucket(name []byte) (Bucket, error) {
+	if !tx.writable {
+		return nil, ErrTxNotWritable
+	}
+	if len(name) == 0 {
+		return nil, ErrBucketNameRequired
+	}
+	if _, ok := tx.buckets[string(name)]; ok {
+		return nil, ErrBucketExists
+	}
+
+	bkt := &memoryBucket{}
+	tx.buckets[string(name)] = bkt
+
+	return &memoryTxBucket{
+		tx:     tx,
+		bucket: bkt,
+	}, nil
+}
+
+func (tx *memoryTx) CreateBucketIfNotExists(name []byte) (Bucket, error) {
+	bkt, err := tx.CreateBucket(name)
+	if err == ErrBucketExists {
+		return tx.Bucket(name), nil
+	}
+	return bkt, err
+}
+
+func (tx *memoryTx) DeleteBucket(name []byte) error {
+	if !tx.writable {
+		return ErrTxNotWritable
+	}
+	if _, ok := tx.buckets[string(name)]; !ok {
+		return ErrBucketNotFound
+	}
+
+	delete(tx.buckets, string(name))
+	return nil
+}
+
+func (tx *memoryTx) ForEachBucket(f func(name []byte, b Bucket) error) error {
+	names := make([]string, 0, len(tx.buckets))
+	for name := range tx.buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := f([]byte(name), tx.Bucket([]byte(name))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (tx *memoryTx) Writable() bool {
+	return tx.writable
+}
+
+// memoryBucket is a bucket of the memory backend
+type memoryBucket struct {
+	root     *memoryNode
+	sequence uint64
+}
+
+// memoryTxBucket is a bucket in a transaction
+type memoryTxBucket struct {
+	tx     *memoryTx
+	bucket *memoryBucket
+}
+
+func (b *memoryTxBucket) Get(key []byte) []byte {
+	n := b.bucket.root
+	for n != nil {
+		switch c := bytes.Compare(key, n.key); {
+		case c < 0:
+			n = n.left
+		case c > 0:
+			n = n.right
+		default:
+			return n.value
+		}
+	}
+	return nil
+}
+
+func (b *memoryTxBucket) Put(key, value []byte) error {
+	if !b.tx.writable {
+		return ErrTxNotWritable
+	}
+	if len(key) == 0 {
+		return ErrKeyRequired
+	}
+
+	k := make([]byte, len(key))
+	copy(k, key)
+	v := make([]byte, len(value))
+	copy(v, value)
+
+	b.bucket.root = b.bucket.root.insert(k, v, rand.Uint32())
+	return nil
+}
+
+func (b *memoryTxBucket) Delete(key []byte) error {
+	if !b.tx.writable {
+		return ErrTxNotWritable
+	}
+
+	b.bucket.root, _ = b.bucket.root.remove(key)
+	return nil
+}
+
+func (b *memoryTxBucket) ForEach(f func(k, v []byte) error) error {
+	return b.bucket.root.forEach(f)
+}
+
+func (b *memoryTxBucket) Cursor() Cursor {
+	return &memoryCursor{
+		root: b.bucket.root,
+	}
+}
+
+func (b *memoryTxBucket) NextSequence() (uint64, error) {
+	if !b.tx.writable {
+		return 0, ErrTxNotWritable
+	}
+
+	b.bucket.sequence++
+	return b.bucket.sequence, nil
+}
+
+func (b *memoryTxBucket) Sequence() uint64 {
+	return b.bucket.sequence
+}
+
+func (b *memoryTxBucket) SetSequence(v uint64) error {
+	if !b.tx.writable {
+		return ErrTxNotWritable
+	}
+
+	b.bucket.sequence = v
+	return nil
+}
+
+func (b *memoryTxBucket) KeyN() int {
+	return b.bucket.root.len()
+}
+
+// memoryNode is a node of a persistent treap, ordered by key and heap ordered by priority.
+// A node is not modified once it is in a committed bucket
+type memoryNode struct {
+	key      []byte
+	value    []byte
+	priority uint32
+	size     int
+	left     *memoryNode
+	right    *memoryNode
+}
+
+func (n *memoryNode) len() int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+// with returns a copy of the node with different children
+func (n *memoryNode) with(left, right *memoryNode) *memoryNode {
+	return &memoryNode{
+		key:      n.key,
+		value:    n.value,
+		priority: n.priority,
+		size:     1 + left.len() + right.len(),
+		left:     left,
+		right:    right,
+	}
+}
+
+// insert returns the treap with the key set to the value
+func (n *memoryNode) insert(key, value []byte, priority uint32) *memoryNode {
+	if n == nil {
+		return &memoryNode{
+			key:      key,
+			value:    value,
+			priority: priority,
+			size:     1,
+		}
+	}
+
+	switch c := bytes.Compare(key, n.key); {
+	case c < 0:
+		left := n.left.insert(key, value, priority)
+		if left.priority > n.priority {
+			// Rotate right
+			return left.with(left.left, n.with(left.right, n.right))
+		}
+		return n.with(left, n.right)
+	case c > 0:
+		right := n.right.insert(key, value, priority)
+		if right.priority > n.priority {
+			// Rotate left
+			return right.with(n.with(n.left, right.left), right.right)
+		}
+		return n.with(n.left, right)
+	default:
+		c := n.with(n.left, n.right)
+		c.value = value
+		return c
+	}
+}
+
+// remove returns the treap without the key, and whether the key was removed
+func (n *memoryNode) remove(key []byte) (*memoryNode, bool) {
+	if n == nil {
+		return nil, false
+	}
+
+	switch c := bytes.Compare(key, n.key); {
+	case c < 0:
+		left, ok := n.left.remove(key)
+		if !ok {
+			return n, false
+		}
+		return n.with(left, n.right), true
+	case c > 0:
+		right, ok := n.right.remove(key)
+		if !ok {
+			return n, false
+		}
+		return n.with(n.left, right), true
+	default:
+		return mergeMemoryNodes(n.left, n.right), true
+	}
+}
+
+// mergeMemoryNodes merges two treaps, where all keys of a are less than the keys of b
+func mergeMemoryNodes(a, b *memoryNode) *memoryNode {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	case a.priority > b.priority:
+		return a.with(a.left, mergeMemoryNodes(a.right, b))
+	default:
+		return b.with(mergeMemoryNodes(a, b.left), b.right)
+	}
+}
+
+func (n *memoryNode) forEach(f func(k, v []byte) error) error {
+	if n == nil {
+		return nil
+	}
+	if err := n.left.forEach(f); err != nil {
+		return err
+	}
+	if err := f(n.key, n.value); err != nil {
+		return err
+	}
+	return n.right.forEach(f)
+}
+
+// memoryCursor iterates over a treap. The stack is the path from the root to the current node
+type memoryCursor struct {
+	root  *memoryNode
+	stack []*memoryNode
+}
+
+func (c *memoryCursor) current() ([]byte, []byte) {
+	if len(c.stack) == 0 {
+		return nil, nil
+	}
+	n := c.stack[len(c.stack)-1]
+	return n.key, n.value
+}
+
+func (c *memoryCursor) pushLeft(n *memoryNode) {
+	for ; n != nil; n = n.left {
+		c.stack = append(c.stack, n)
+	}
+}
+
+func (c *memoryCursor) pushRight(n *memoryNode) {
+	for ; n != nil; n = n.right {
+		c.stack = append(c.stack, n)
+	}
+}
+
+func (c *memoryCursor) First() ([]byte, []byte) {
+	c.stack = c.stack[:0]
+	c.pushLeft(c.root)
+	return c.current()
+}
+
+func (c *memoryCursor) Last() ([]byte, []byte) {
+	c.stack = c.stack[:0]
+	c.pushRight(c.root)
+	return c.current()
+}
+
+func (c *memoryCursor) Next() ([]byte, []byte) {
+	if len(c.stack) == 0 {
+		return nil, nil
+	}
+
+	n := c.stack[len(c.stack)-1]
+	if n.right != nil {
+		c.pushLeft(n.right)
+		return c.current()
+	}
+
+	// Go up until coming from a left child
+	c.stack = c.stack[:len(c.stack)-1]
+	for len(c.stack) > 0 && c.stack[len(c.stack)-1].right == n {
+		n = c.stack[len(c.stack)-1]
+		c.stack = c.stack[:len(c.stack)-1]
+	}
+
+	return c.current()
+}
+
+func (c *memoryCursor) Prev() ([]byte, []byte) {
+	if len(c.stack) == 0 {
+		return nil, nil
+	}
+
+	n := c.stack[len(c.stack)-1]
+	if n.left != nil {
+		c.pushRight(n.left)
+		return c.current()
+	}
+
+	// Go up until coming from a right child
+	c.stack = c.stack[:len(c.stack)-1]
+	for len(c.stack) > 0 && c.stack[len(c.stack)-1].left == n {
+		n = c.stack[len(c.stack)-1]
+		c.stack = c.stack[:len(c.stack)-1]
+	}
+
+	return c.current()
+}
+
+func (c *memoryCursor) Seek(seek []byte) ([]byte, []byte) {
+	c.stack = c.stack[:0]
+
+	n := c.root
+	for n != nil {
+		c.stack = append(c.stack, n)
+		switch cmp := bytes.Compare(seek, n.key); {
+		case cmp < 0:
+			n = n.left
+		case cmp > 0:
+			n = n.right
+		default:
+			return c.current()
+		}
+	}
+
+	// The last node is the closest key before or after the seek key
+	k, v := c.current()
+	if k != nil && bytes.Compare(k, seek) < 0 {
+		return c.Next()
+	}
+	return k, v
+}
@@ -0,0 +1,203 @@
+package visor
+
+import (
+	"fmt"
+
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+// DefaultHistoryRebuildBatchSize is the default number of blocks parsed in each database transaction
+// when the history database is rebuilt
+const DefaultHistoryRebuildBatchSize = 1000
+
+// HistoryIndexStatus is the progress of the rebuild of the history database
+type HistoryIndexStatus struct {
+	// Is the history database being rebuilt, or are its transaction indexes being backfilled.
+	// The history is not available until the rebuild is finished
+	Indexing bool
+	// Number of blocks parsed into the history database
+	ParsedBlocks uint64
+	// Number of blocks of the blockchain
+	TotalBlocks uint64
+}
+
+// GetHistoryIndexStatus returns the progress of the rebuild of the history database
+func (vs *Visor) GetHistoryIndexStatus() (*HistoryIndexStatus, error) {
+	var s HistoryIndexStatus
+	if err := vs.db.View("GetHistoryIndexStatus", func(tx *dbutil.Tx) error {
+		var err error
+		s.Indexing, err = vs.history.Rebuilding(tx)
+		if err != nil {
+			return err
+		}
+
+		if !s.Indexing {
+			s.Indexing, err = vs.history.MigratingTxnIndexes(tx)
+			if err != nil {
+				return err
+			}
+		}
+
+		parsedSeq, ok, err := vs.history.ParsedBlockSeq(tx)
+		if err != nil {
+			return err
+		}
+		if ok {
+			s.ParsedBlocks = parsedSeq + 1
+		}
+
+		headSeq, ok, err := vs.blockchain.HeadSeq(tx)
+		if err != nil {
+			return err
+		}
+		if ok {
+			s.TotalBlocks = headSeq + 1
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// RebuildHistory parses the blocks that are missing from the history database while it is being rebuilt,
+// in batches of Config.HistoryRebuildBatchSize blocks that are committed in separate database transactions,
+// so that the other database operations are not blocked by the rebuild.
+// The blocks executed during the rebuild are parsed by the rebuild, and the history APIs return
+// historydb.ErrHistoryIndexing until it is finished.
+// The missing transaction indexes of a database created by an older version are then backfilled
+// from the transactions of the history database, in batches of Config.HistoryRebuildBatchSize transactions,
+// and the transaction index APIs return historydb.ErrHistoryIndexing until the backfill is finished.
+// Returns immediately if the history database is not being rebuilt or backfilled. Returns when quit is closed,
+// the rebuild is resumed from the last committed batch by the next call, including after a restart
+func (vs *Visor) RebuildHistory(quit <-chan struct{}) error {
+	if vs.db.IsReadOnly() {
+		return nil
+	}
+
+	batchSize := vs.Config.HistoryRebuildBatchSize
+	if batchSize == 0 {
+		batchSize = DefaultHistoryRebuildBatchSize
+	}
+
+	for {
+		select {
+		case <-quit:
+			return nil
+		default:
+		}
+
+		var done bool
+		var parsed uint64
+		if err := vs.db.Update("RebuildHistory", func(tx *dbutil.Tx) error {
+			var err error
+			done, parsed, err = vs.rebuildHistoryBatch(tx, batchSize)
+			return err
+		}); err != nil {
+			logger.WithError(err).Error("RebuildHistory failed")
+			return err
+		}
+
+		if done {
+			break
+		}
+
+		logger.Infof("Rebuilding historyDB: %d blocks parsed", parsed)
+	}
+
+	for migrated := 0; ; migrated += batchSize {
+		select {
+		case <-quit:
+			return nil
+		default:
+		}
+
+		var done bool
+		if err := vs.db.Update("RebuildHistory", func(tx *dbutil.Tx) error {
+			var err error
+			done, err = vs.history.MigrateTxnIndexes(tx, batchSize, func(seq uint64) (uint64, error) {
+				h, _, err := vs.blockchain.GetSignedBlockHeaderBySeq(tx, seq)
+				if err != nil {
+					return 0, err
+				}
+
+				if h == nil {
+					return 0, fmt.Errorf("block header of seq %d not found", seq)
+				}
+
+				return h.Time, nil
+			})
+			return err
+		}); err != nil {
+			logger.WithError(err).Error("RebuildHistory failed")
+			return err
+		}
+
+		if done {
+			break
+		}
+
+		logger.Infof("Backfilling historyDB transaction indexes: %d transactions added", migrated+batchSize)
+	}
+
+	// The blocks are not pruned while the history database is rebuilt
+	return vs.pruneBlocks()
+}
+
+// rebuildHistoryBatch parses up to n blocks that follow the parsed block seq of the history database.
+// Once the head block is parsed, the history database is marked as rebuilt.
+// Returns true if the history database is not being rebuilt anymore, and the number of parsed blocks
+func (vs *Visor) rebuildHistoryBatch(tx *dbutil.Tx, n int) (bool, uint64, error) {
+	rebuilding, err := vs.history.Rebuilding(tx)
+	if err != nil {
+		return false, 0, err
+	}
+	if !rebuilding {
+		return true, 0, nil
+	}
+
+	var next uint64
+	parsedSeq, ok, err := vs.history.ParsedBlockSeq(tx)
+	if err != nil {
+		return false, 0, err
+	}
+	if ok {
+		next = parsedSeq + 1
+	}
+
+	headSeq, ok, err := vs.blockchain.HeadSeq(tx)
+	if err != nil {
+		return false, 0, err
+	}
+
+	for i := 0; ok && i < n && next <= headSeq; i++ {
+		b, err := vs.blockchain.GetSignedBlockBySeq(tx, next)
+		if err != nil {
+			return false, 0, err
+		}
+
+		if b == nil {
+			return false, 0, fmt.Errorf("no block exists in depth: %d", next)
+		}
+
+		if err := vs.history.ParseBlock(tx, b.Block); err != nil {
+			return false, 0, err
+		}
+
+		next++
+	}
+
+	if ok && next <= headSeq {
+		return false, next, nil
+	}
+
+	logger.Infof("HistoryDB rebuilt, %d blocks parsed", next)
+
+	if err := vs.history.SetRebuilding(tx, false); err != nil {
+		return false, 0, err
+	}
+
+	return true, next, nil
+}
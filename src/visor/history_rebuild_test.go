@@ -0,0 +1,113 @@
+package visor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+)
+
+func TestRebuildHistory(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	v := makeBlocksFileVisor(t, db, 5)
+
+	num, err := v.GetTransactionsNum()
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), num)
+
+	// Erase the history, so that it is rebuilt when the visor is created again
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		return v.history.Erase(tx)
+	})
+	require.NoError(t, err)
+
+	cfg := v.Config
+	cfg.HistoryRebuildBatchSize = 2
+
+	requireStatus := func(v *Visor, indexing bool, parsed, total uint64) {
+		s, err := v.GetHistoryIndexStatus()
+		require.NoError(t, err)
+		require.Equal(t, HistoryIndexStatus{
+			Indexing:     indexing,
+			ParsedBlocks: parsed,
+			TotalBlocks:  total,
+		}, *s)
+	}
+
+	v, err = New(cfg, db, nil)
+	require.NoError(t, err)
+	requireStatus(v, true, 0, 6)
+
+	// The history is not available while it is rebuilt
+	_, err = v.GetTransactionsNum()
+	require.Equal(t, historydb.ErrHistoryIndexing{}, err)
+
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		done, parsed, err := v.rebuildHistoryBatch(tx, 4)
+		require.False(t, done)
+		require.Equal(t, uint64(4), parsed)
+		return err
+	})
+	require.NoError(t, err)
+	requireStatus(v, true, 4, 6)
+
+	_, err = v.GetTransactionsNum()
+	require.Equal(t, historydb.ErrHistoryIndexing{ParsedBlocks: 4}, err)
+
+	// An interrupted rebuild is resumed without erasing the history
+	v, err = New(cfg, db, nil)
+	require.NoError(t, err)
+	requireStatus(v, true, 4, 6)
+
+	addBlock := func() {
+		var head *coin.SignedBlock
+		err := db.View("", func(tx *dbutil.Tx) error {
+			var err error
+			head, err = v.blockchain.Head(tx)
+			return err
+		})
+		require.NoError(t, err)
+
+		uxs := coin.CreateUnspents(head.Head, head.Body.Transactions[0])
+		txn := makeSpendTxn(t, uxs, []cipher.SecKey{genSecret}, genAddress, genCoins)
+
+		_, softErr, err := v.InjectForeignTransaction(txn)
+		require.NoError(t, err)
+		require.Nil(t, softErr)
+
+		err = db.Update("", func(tx *dbutil.Tx) error {
+			b, err := v.createBlock(tx, head.Time()+3600)
+			if err != nil {
+				return err
+			}
+			return v.executeSignedBlock(tx, b)
+		})
+		require.NoError(t, err)
+	}
+
+	// A block executed during the rebuild is parsed by the rebuild
+	addBlock()
+	requireStatus(v, true, 4, 7)
+
+	err = v.RebuildHistory(make(chan struct{}))
+	require.NoError(t, err)
+	requireStatus(v, false, 7, 7)
+
+	num, err = v.GetTransactionsNum()
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), num)
+
+	// The blocks are parsed as they are executed once the history is rebuilt
+	addBlock()
+	requireStatus(v, false, 8, 8)
+
+	num, err = v.GetTransactionsNum()
+	require.NoError(t, err)
+	require.Equal(t, uint64(8), num)
+}
@@ -1,6 +1,8 @@
 package historydb
 
 import (
+	"sort"
+
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/visor/dbutil"
 )
@@ -63,6 +65,83 @@ func (atx *addressTxns) add(tx *dbutil.Tx, addr cipher.Address, hash cipher.SHA2
 	return dbutil.PutBucketValue(tx, AddressTxnsBkt, addr.Bytes(), buf)
 }
 
+// insert inserts a hash into an address's hash list, before the hashes of the transactions of later blocks.
+// The hash list of an address is ordered by block seq, blockSeq returns the block seq of a hash in the list
+func (atx *addressTxns) insert(tx *dbutil.Tx, addr cipher.Address, hash cipher.SHA256, seq uint64, blockSeq func(cipher.SHA256) (uint64, error)) error {
+	hashes, err := atx.get(tx, addr)
+	if err != nil {
+		return err
+	}
+
+	// check for duplicates
+	for _, u := range hashes {
+		if u == hash {
+			return nil
+		}
+	}
+
+	var searchErr error
+	i := sort.Search(len(hashes), func(i int) bool {
+		if searchErr != nil {
+			return true
+		}
+		s, err := blockSeq(hashes[i])
+		if err != nil {
+			searchErr = err
+			return true
+		}
+		return s > seq
+	})
+	if searchErr != nil {
+		return searchErr
+	}
+
+	hashes = append(hashes, cipher.SHA256{})
+	copy(hashes[i+1:], hashes[i:])
+	hashes[i] = hash
+
+	buf, err := encodeHashesWrapper(&hashesWrapper{
+		Hashes: hashes,
+	})
+	if err != nil {
+		return err
+	}
+
+	return dbutil.PutBucketValue(tx, AddressTxnsBkt, addr.Bytes(), buf)
+}
+
+// addAddress adds an address with an empty hash list, if the address is not known
+func (atx *addressTxns) addAddress(tx *dbutil.Tx, addr cipher.Address) error {
+	if ok, err := atx.contains(tx, addr); err != nil || ok {
+		return err
+	}
+
+	buf, err := encodeHashesWrapper(&hashesWrapper{})
+	if err != nil {
+		return err
+	}
+
+	return dbutil.PutBucketValue(tx, AddressTxnsBkt, addr.Bytes(), buf)
+}
+
+// remove removes a hash from an address's hash list.
+// The address is kept with an empty list, so that it is still known to appear in the blockchain
+func (atx *addressTxns) remove(tx *dbutil.Tx, addr cipher.Address, hash cipher.SHA256) error {
+	hashes, err := atx.get(tx, addr)
+	if err != nil {
+		return err
+	}
+
+	buf, err := encodeHashesWrapper(&hashesWrapper{
+		Hashes: removeHash(hashes, hash),
+	})
+	if err != nil {
+		return err
+	}
+
+	return dbutil.PutBucketValue(tx, AddressTxnsBkt, addr.Bytes(), buf)
+}
+
 // contains returns true if an address has transactions
 func (atx *addressTxns) contains(tx *dbutil.Tx, addr cipher.Address) (bool, error) {
 	return dbutil.BucketHasKey(tx, AddressTxnsBkt, addr.Bytes())
@@ -77,3 +156,14 @@ func (atx *addressTxns) isEmpty(tx *dbutil.Tx) (bool, error) {
 func (atx *addressTxns) reset(tx *dbutil.Tx) error {
 	return dbutil.Reset(tx, AddressTxnsBkt)
 }
+
+// removeHash returns hashes without hash
+func removeHash(hashes []cipher.SHA256, hash cipher.SHA256) []cipher.SHA256 {
+	kept := make([]cipher.SHA256, 0, len(hashes))
+	for _, h := range hashes {
+		if h != hash {
+			kept = append(kept, h)
+		}
+	}
+	return kept
+}
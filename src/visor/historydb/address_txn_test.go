@@ -252,3 +252,54 @@ func TestGetAddressTxns(t *testing.T) {
 		})
 	}
 }
+
+func TestInsertAddressTxns(t *testing.T) {
+	db, td := prepareDB(t)
+	defer td()
+
+	addr := makeAddress()
+	seqs := make(map[cipher.SHA256]uint64)
+	hashes := make([]cipher.SHA256, 6)
+	for i := range hashes {
+		hashes[i] = cipher.SumSHA256([]byte(fmt.Sprintf("tx%d", i)))
+		seqs[hashes[i]] = uint64(i / 2)
+	}
+
+	blockSeq := func(h cipher.SHA256) (uint64, error) {
+		return seqs[h], nil
+	}
+
+	addrTxns := &addressTxns{}
+	err := db.Update("", func(tx *dbutil.Tx) error {
+		require.NoError(t, addrTxns.add(tx, addr, hashes[4]))
+		require.NoError(t, addrTxns.add(tx, addr, hashes[5]))
+
+		// Hashes are inserted before the hashes of later blocks, in order within a block
+		require.NoError(t, addrTxns.insert(tx, addr, hashes[0], 0, blockSeq))
+		require.NoError(t, addrTxns.insert(tx, addr, hashes[2], 1, blockSeq))
+		require.NoError(t, addrTxns.insert(tx, addr, hashes[1], 0, blockSeq))
+		require.NoError(t, addrTxns.insert(tx, addr, hashes[3], 1, blockSeq))
+
+		// Duplicates are ignored
+		require.NoError(t, addrTxns.insert(tx, addr, hashes[2], 1, blockSeq))
+
+		got, err := addrTxns.get(tx, addr)
+		require.NoError(t, err)
+		require.Equal(t, hashes, got)
+
+		// An address is added with no hashes, unless it is known
+		other := makeAddress()
+		require.NoError(t, addrTxns.addAddress(tx, other))
+		ok, err := addrTxns.contains(tx, other)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		require.NoError(t, addrTxns.addAddress(tx, addr))
+		got, err = addrTxns.get(tx, addr)
+		require.NoError(t, err)
+		require.Equal(t, hashes, got)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
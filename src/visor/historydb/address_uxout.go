@@ -55,6 +55,23 @@ func (au *addressUx) add(tx *dbutil.Tx, address cipher.Address, uxHash cipher.SH
 	return dbutil.PutBucketValue(tx, AddressUxBkt, address.Bytes(), buf)
 }
 
+// remove removes a hash from an address's hash list
+func (au *addressUx) remove(tx *dbutil.Tx, address cipher.Address, uxHash cipher.SHA256) error {
+	hashes, err := au.get(tx, address)
+	if err != nil {
+		return err
+	}
+
+	buf, err := encodeHashesWrapper(&hashesWrapper{
+		Hashes: removeHash(hashes, uxHash),
+	})
+	if err != nil {
+		return err
+	}
+
+	return dbutil.PutBucketValue(tx, AddressUxBkt, address.Bytes(), buf)
+}
+
 // isEmpty checks if the addressUx bucket is empty
 func (au *addressUx) isEmpty(tx *dbutil.Tx) (bool, error) {
 	return dbutil.IsEmpty(tx, AddressUxBkt)
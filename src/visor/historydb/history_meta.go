@@ -1,6 +1,7 @@
 package historydb
 
 import (
+	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/visor/dbutil"
 )
 
@@ -8,6 +9,8 @@ var (
 	// HistoryMetaBkt holds history metadata
 	HistoryMetaBkt  = []byte("history_meta")
 	parsedHeightKey = []byte("parsed_height")
+	rebuildingKey   = []byte("rebuilding")
+	txnIndexNextKey = []byte("txn_index_next")
 )
 
 // historyMeta bucket for storing block history meta info
@@ -30,6 +33,53 @@ func (hm *historyMeta) setParsedBlockSeq(tx *dbutil.Tx, h uint64) error {
 	return dbutil.PutBucketValue(tx, HistoryMetaBkt, parsedHeightKey, dbutil.Itob(h))
 }
 
+// rebuilding returns true if the history is being rebuilt
+func (hm *historyMeta) rebuilding(tx *dbutil.Tx) (bool, error) {
+	v, err := dbutil.GetBucketValue(tx, HistoryMetaBkt, rebuildingKey)
+	if err != nil {
+		return false, err
+	}
+
+	return v != nil, nil
+}
+
+// setRebuilding marks or unmarks the history as being rebuilt
+func (hm *historyMeta) setRebuilding(tx *dbutil.Tx, rebuilding bool) error {
+	if !rebuilding {
+		return dbutil.Delete(tx, HistoryMetaBkt, rebuildingKey)
+	}
+
+	return dbutil.PutBucketValue(tx, HistoryMetaBkt, rebuildingKey, []byte{1})
+}
+
+// txnIndexNext returns the hash of the next transaction to add to the transaction indexes
+// while they are backfilled. Returns false if the transaction indexes are not being backfilled
+func (hm *historyMeta) txnIndexNext(tx *dbutil.Tx) (cipher.SHA256, bool, error) {
+	v, err := dbutil.GetBucketValue(tx, HistoryMetaBkt, txnIndexNextKey)
+	if err != nil {
+		return cipher.SHA256{}, false, err
+	} else if v == nil {
+		return cipher.SHA256{}, false, nil
+	}
+
+	hash, err := cipher.SHA256FromBytes(v)
+	if err != nil {
+		return cipher.SHA256{}, false, err
+	}
+
+	return hash, true, nil
+}
+
+// setTxnIndexNext updates the hash of the next transaction to add to the transaction indexes
+func (hm *historyMeta) setTxnIndexNext(tx *dbutil.Tx, hash cipher.SHA256) error {
+	return dbutil.PutBucketValue(tx, HistoryMetaBkt, txnIndexNextKey, hash[:])
+}
+
+// deleteTxnIndexNext marks the transaction indexes as backfilled
+func (hm *historyMeta) deleteTxnIndexNext(tx *dbutil.Tx) error {
+	return dbutil.Delete(tx, HistoryMetaBkt, txnIndexNextKey)
+}
+
 // reset resets the bucket
 func (hm *historyMeta) reset(tx *dbutil.Tx) error {
 	return dbutil.Reset(tx, HistoryMetaBkt)
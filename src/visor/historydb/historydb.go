@@ -24,26 +24,32 @@ func CreateBuckets(tx *dbutil.Tx) error {
 		HistoryMetaBkt,
 		UxOutsBkt,
 		TransactionsBkt,
+		TxnsByTimeBkt,
+		TxnsByValueBkt,
 	})
 }
 
 // HistoryDB provides APIs for blockchain explorer
 type HistoryDB struct {
-	outputs  *uxOuts       // outputs bucket
-	txns     *transactions // transactions bucket
-	addrUx   *addressUx    // bucket which stores all UxOuts that address received
-	addrTxns *addressTxns  // address related transaction bucket
-	meta     *historyMeta  // stores history meta info
+	outputs     *uxOuts       // outputs bucket
+	txns        *transactions // transactions bucket
+	addrUx      *addressUx    // bucket which stores all UxOuts that address received
+	addrTxns    *addressTxns  // address related transaction bucket
+	txnsByTime  *txnIndex     // transactions indexed by block time
+	txnsByValue *txnIndex     // transactions indexed by output coins
+	meta        *historyMeta  // stores history meta info
 }
 
 // New create HistoryDB instance
 func New() *HistoryDB {
 	return &HistoryDB{
-		outputs:  &uxOuts{},
-		txns:     &transactions{},
-		addrUx:   &addressUx{},
-		addrTxns: &addressTxns{},
-		meta:     &historyMeta{},
+		outputs:     &uxOuts{},
+		txns:        &transactions{},
+		addrUx:      &addressUx{},
+		addrTxns:    &addressTxns{},
+		txnsByTime:  &txnIndex{bkt: TxnsByTimeBkt},
+		txnsByValue: &txnIndex{bkt: TxnsByValueBkt},
+		meta:        &historyMeta{},
 	}
 }
 
@@ -86,6 +92,102 @@ func (hd *HistoryDB) NeedsReset(tx *dbutil.Tx) (bool, error) {
 	return false, nil
 }
 
+// NeedsTxnIndexMigration returns true if the transaction indexes must be backfilled
+// from the transactions bucket, such as in a database created by an older version
+func (hd *HistoryDB) NeedsTxnIndexMigration(tx *dbutil.Tx) (bool, error) {
+	txnsEmpty, err := hd.txns.isEmpty(tx)
+	if err != nil {
+		return false, err
+	}
+
+	if txnsEmpty {
+		return false, nil
+	}
+
+	txnsByTimeEmpty, err := hd.txnsByTime.isEmpty(tx)
+	if err != nil {
+		return false, err
+	}
+
+	txnsByValueEmpty, err := hd.txnsByValue.isEmpty(tx)
+	if err != nil {
+		return false, err
+	}
+
+	return txnsByTimeEmpty || txnsByValueEmpty, nil
+}
+
+// StartTxnIndexMigration empties the transaction indexes and marks them as being backfilled
+// by MigrateTxnIndexes. The mark is kept in the database, so that an interrupted backfill is resumed
+func (hd *HistoryDB) StartTxnIndexMigration(tx *dbutil.Tx) error {
+	if err := hd.txnsByTime.reset(tx); err != nil {
+		return err
+	}
+
+	if err := hd.txnsByValue.reset(tx); err != nil {
+		return err
+	}
+
+	return hd.meta.setTxnIndexNext(tx, cipher.SHA256{})
+}
+
+// MigratingTxnIndexes returns true if the transaction indexes are being backfilled.
+// The transaction indexes are incomplete until the backfill is finished
+func (hd *HistoryDB) MigratingTxnIndexes(tx *dbutil.Tx) (bool, error) {
+	_, ok, err := hd.meta.txnIndexNext(tx)
+	return ok, err
+}
+
+// MigrateTxnIndexes adds up to n transactions of the transactions bucket to the transaction indexes,
+// resuming from the last transaction added while the indexes are being backfilled.
+// The blocks are not read, so that the indexes are rebuilt after the blocks are pruned.
+// blockTime returns the time of the block of given seq, which is kept in the header of a pruned block.
+// Returns true once all the transactions are added, or if the indexes are not being backfilled
+func (hd *HistoryDB) MigrateTxnIndexes(tx *dbutil.Tx, n int, blockTime func(seq uint64) (uint64, error)) (bool, error) {
+	from, ok, err := hd.meta.txnIndexNext(tx)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		return true, nil
+	}
+
+	times := make(map[uint64]uint64)
+	next, ok, err := hd.txns.forEachFrom(tx, from, n, func(hash cipher.SHA256, txn *Transaction) error {
+		t, ok := times[txn.BlockSeq]
+		if !ok {
+			var err error
+			t, err = blockTime(txn.BlockSeq)
+			if err != nil {
+				return err
+			}
+			times[txn.BlockSeq] = t
+		}
+
+		coins, err := txn.Txn.OutputCoins()
+		if err != nil {
+			return err
+		}
+
+		if err := hd.txnsByTime.put(tx, t, txn.BlockSeq, hash); err != nil {
+			return err
+		}
+
+		return hd.txnsByValue.put(tx, coins, txn.BlockSeq, hash)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if ok {
+		return false, hd.meta.setTxnIndexNext(tx, next)
+	}
+
+	logger.Info("HistoryDB transaction indexes backfilled")
+
+	return true, hd.meta.deleteTxnIndexNext(tx)
+}
+
 // Erase erases the entire HistoryDB
 func (hd *HistoryDB) Erase(tx *dbutil.Tx) error {
 	logger.Debug("HistoryDB.reset")
@@ -101,6 +203,14 @@ func (hd *HistoryDB) Erase(tx *dbutil.Tx) error {
 		return err
 	}
 
+	if err := hd.txnsByTime.reset(tx); err != nil {
+		return err
+	}
+
+	if err := hd.txnsByValue.reset(tx); err != nil {
+		return err
+	}
+
 	if err := hd.meta.reset(tx); err != nil {
 		return err
 	}
@@ -118,8 +228,73 @@ func (hd *HistoryDB) SetParsedBlockSeq(tx *dbutil.Tx, seq uint64) error {
 	return hd.meta.setParsedBlockSeq(tx, seq)
 }
 
+// Rebuilding returns true if the HistoryDB was erased and is being reparsed from the blockchain.
+// The history is incomplete until the rebuild is finished
+func (hd *HistoryDB) Rebuilding(tx *dbutil.Tx) (bool, error) {
+	return hd.meta.rebuilding(tx)
+}
+
+// SetRebuilding marks or unmarks the HistoryDB as being rebuilt. The mark is kept in the database,
+// so that an interrupted rebuild is resumed from the parsed block seq
+func (hd *HistoryDB) SetRebuilding(tx *dbutil.Tx, rebuilding bool) error {
+	return hd.meta.setRebuilding(tx, rebuilding)
+}
+
+// checkIndexed returns ErrHistoryIndexing if the HistoryDB is being rebuilt
+func (hd HistoryDB) checkIndexed(tx *dbutil.Tx) error {
+	rebuilding, err := hd.meta.rebuilding(tx)
+	if err != nil {
+		return err
+	}
+	if !rebuilding {
+		return nil
+	}
+
+	seq, ok, err := hd.meta.parsedBlockSeq(tx)
+	if err != nil {
+		return err
+	}
+
+	e := ErrHistoryIndexing{}
+	if ok {
+		e.ParsedBlocks = seq + 1
+	}
+	return e
+}
+
+// checkTxnIndexed returns ErrHistoryIndexing if the HistoryDB is being rebuilt
+// or if the transaction indexes are being backfilled
+func (hd HistoryDB) checkTxnIndexed(tx *dbutil.Tx) error {
+	if err := hd.checkIndexed(tx); err != nil {
+		return err
+	}
+
+	migrating, err := hd.MigratingTxnIndexes(tx)
+	if err != nil {
+		return err
+	}
+	if !migrating {
+		return nil
+	}
+
+	seq, ok, err := hd.meta.parsedBlockSeq(tx)
+	if err != nil {
+		return err
+	}
+
+	e := ErrHistoryIndexing{}
+	if ok {
+		e.ParsedBlocks = seq + 1
+	}
+	return e
+}
+
 // GetUxOuts get UxOut of specific uxIDs.
 func (hd *HistoryDB) GetUxOuts(tx *dbutil.Tx, uxIDs []cipher.SHA256) ([]UxOut, error) {
+	if err := hd.checkIndexed(tx); err != nil {
+		return nil, err
+	}
+
 	return hd.outputs.getArray(tx, uxIDs)
 }
 
@@ -137,6 +312,10 @@ func (hd *HistoryDB) ParseBlock(tx *dbutil.Tx, b coin.Block) error {
 			return err
 		}
 
+		if err := hd.indexTxn(tx, b, t, spentTxnID); err != nil {
+			return err
+		}
+
 		for _, in := range t.In {
 			o, err := hd.outputs.get(tx, in)
 			if err != nil {
@@ -182,13 +361,216 @@ func (hd *HistoryDB) ParseBlock(tx *dbutil.Tx, b coin.Block) error {
 	return hd.SetParsedBlockSeq(tx, b.Seq())
 }
 
+// PruneBlock removes the history of a block whose body is pruned.
+// The transactions of the block are removed from the indexes, and so are the outputs spent by the block,
+// except the outputs created in blocks up to keepSeq, whose history is kept, such as the genesis block.
+// The outputs created by the block are kept until the block that spends them is pruned.
+// Blocks must be pruned in order
+func (hd *HistoryDB) PruneBlock(tx *dbutil.Tx, b coin.Block, keepSeq uint64) error {
+	for _, t := range b.Body.Transactions {
+		txnHash := t.Hash()
+
+		if err := hd.txns.delete(tx, txnHash); err != nil {
+			return err
+		}
+
+		if err := hd.unindexTxn(tx, b, t, txnHash); err != nil {
+			return err
+		}
+
+		for _, in := range t.In {
+			o, err := hd.outputs.get(tx, in)
+			if err != nil {
+				return err
+			}
+
+			if o == nil {
+				return errors.New("HistoryDB.PruneBlock: transaction input not found in outputs bucket")
+			}
+
+			addr := o.Out.Body.Address
+			if err := hd.addrTxns.remove(tx, addr, txnHash); err != nil {
+				return err
+			}
+
+			if o.Out.Head.BkSeq <= keepSeq {
+				continue
+			}
+
+			if err := hd.addrUx.remove(tx, addr, in); err != nil {
+				return err
+			}
+
+			if err := hd.outputs.delete(tx, in); err != nil {
+				return err
+			}
+		}
+
+		for _, ux := range coin.CreateUnspents(b.Head, t) {
+			if err := hd.addrTxns.remove(tx, ux.Body.Address, txnHash); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// AddUnspents adds the unspent outputs of a snapshot, whose transactions are not in the history.
+// Outputs that are already in the history are not modified
+func (hd *HistoryDB) AddUnspents(tx *dbutil.Tx, uxs coin.UxArray) error {
+	for _, ux := range uxs {
+		if err := hd.putOutputIfMissing(tx, ux); err != nil {
+			return err
+		}
+
+		if err := hd.addrUx.add(tx, ux.Body.Address, ux.Hash()); err != nil {
+			return err
+		}
+
+		// The address has appeared in the blockchain, although its transactions are unknown
+		if err := hd.addrTxns.addAddress(tx, ux.Body.Address); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BackfillBlock adds the history of a pruned block whose body is restored.
+// Blocks must be backfilled in order, after the blocks that created their inputs.
+// Unlike ParseBlock, the outputs of the block that are already in the history are not modified,
+// as they may have been spent by a later block, and the transactions are inserted in the address
+// indexes in block order. The parsed block seq is not changed
+func (hd *HistoryDB) BackfillBlock(tx *dbutil.Tx, b coin.Block) error {
+	blockSeq := func(hash cipher.SHA256) (uint64, error) {
+		txn, err := hd.txns.get(tx, hash)
+		if err != nil {
+			return 0, err
+		}
+		if txn == nil {
+			return 0, fmt.Errorf("HistoryDB.BackfillBlock: transaction %s not found", hash.Hex())
+		}
+		return txn.BlockSeq, nil
+	}
+
+	for _, t := range b.Body.Transactions {
+		txn := Transaction{
+			Txn:      t,
+			BlockSeq: b.Seq(),
+		}
+
+		spentTxnID := t.Hash()
+
+		if err := hd.txns.put(tx, &txn); err != nil {
+			return err
+		}
+
+		if err := hd.indexTxn(tx, b, t, spentTxnID); err != nil {
+			return err
+		}
+
+		for _, in := range t.In {
+			o, err := hd.outputs.get(tx, in)
+			if err != nil {
+				return err
+			}
+
+			if o == nil {
+				return errors.New("HistoryDB.BackfillBlock: transaction input not found in outputs bucket")
+			}
+
+			o.SpentBlockSeq = b.Seq()
+			o.SpentTxnID = spentTxnID
+			if err := hd.outputs.put(tx, *o); err != nil {
+				return err
+			}
+
+			if err := hd.addrUx.add(tx, o.Out.Body.Address, in); err != nil {
+				return err
+			}
+
+			if err := hd.addrTxns.insert(tx, o.Out.Body.Address, spentTxnID, b.Seq(), blockSeq); err != nil {
+				return err
+			}
+		}
+
+		for _, ux := range coin.CreateUnspents(b.Head, t) {
+			if err := hd.putOutputIfMissing(tx, ux); err != nil {
+				return err
+			}
+
+			if err := hd.addrUx.add(tx, ux.Body.Address, ux.Hash()); err != nil {
+				return err
+			}
+
+			if err := hd.addrTxns.insert(tx, ux.Body.Address, spentTxnID, b.Seq(), blockSeq); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// indexTxn adds a transaction of a block to the transaction indexes
+func (hd *HistoryDB) indexTxn(tx *dbutil.Tx, b coin.Block, t coin.Transaction, hash cipher.SHA256) error {
+	coins, err := t.OutputCoins()
+	if err != nil {
+		return err
+	}
+
+	if err := hd.txnsByTime.put(tx, b.Time(), b.Seq(), hash); err != nil {
+		return err
+	}
+
+	return hd.txnsByValue.put(tx, coins, b.Seq(), hash)
+}
+
+// unindexTxn removes a transaction of a block from the transaction indexes
+func (hd *HistoryDB) unindexTxn(tx *dbutil.Tx, b coin.Block, t coin.Transaction, hash cipher.SHA256) error {
+	coins, err := t.OutputCoins()
+	if err != nil {
+		return err
+	}
+
+	if err := hd.txnsByTime.delete(tx, b.Time(), b.Seq(), hash); err != nil {
+		return err
+	}
+
+	return hd.txnsByValue.delete(tx, coins, b.Seq(), hash)
+}
+
+// putOutputIfMissing adds an unspent output to the outputs bucket, unless it is there already
+func (hd *HistoryDB) putOutputIfMissing(tx *dbutil.Tx, ux coin.UxOut) error {
+	o, err := hd.outputs.get(tx, ux.Hash())
+	if err != nil {
+		return err
+	}
+	if o != nil {
+		return nil
+	}
+
+	return hd.outputs.put(tx, UxOut{
+		Out: ux,
+	})
+}
+
 // GetTransaction get transaction by hash.
 func (hd HistoryDB) GetTransaction(tx *dbutil.Tx, hash cipher.SHA256) (*Transaction, error) {
+	if err := hd.checkIndexed(tx); err != nil {
+		return nil, err
+	}
+
 	return hd.txns.get(tx, hash)
 }
 
 // GetOutputsForAddress get all uxout that the address affected.
 func (hd HistoryDB) GetOutputsForAddress(tx *dbutil.Tx, addr cipher.Address) ([]UxOut, error) {
+	if err := hd.checkIndexed(tx); err != nil {
+		return nil, err
+	}
+
 	hashes, err := hd.addrUx.get(tx, addr)
 	if err != nil {
 		return nil, err
@@ -199,6 +581,10 @@ func (hd HistoryDB) GetOutputsForAddress(tx *dbutil.Tx, addr cipher.Address) ([]
 
 // GetTransactionHashesForAddresses returns transaction hashes of related addresses
 func (hd HistoryDB) GetTransactionHashesForAddresses(tx *dbutil.Tx, addrs []cipher.Address) ([]cipher.SHA256, error) {
+	if err := hd.checkIndexed(tx); err != nil {
+		return nil, err
+	}
+
 	var hashes []cipher.SHA256
 	hashMap := make(map[cipher.SHA256]struct{})
 	for _, addr := range addrs {
@@ -219,13 +605,41 @@ func (hd HistoryDB) GetTransactionHashesForAddresses(tx *dbutil.Tx, addrs []ciph
 	return hashes, nil
 }
 
+// GetTransactionsByTime returns the transactions of the blocks whose time is between from and to inclusive,
+// in block order
+func (hd HistoryDB) GetTransactionsByTime(tx *dbutil.Tx, from, to uint64) ([]TxnIndexItem, error) {
+	if err := hd.checkTxnIndexed(tx); err != nil {
+		return nil, err
+	}
+
+	return hd.txnsByTime.getRange(tx, from, to)
+}
+
+// GetTransactionsByValue returns the transactions whose outputs total between min and max coins inclusive,
+// ordered by coins then by block seq
+func (hd HistoryDB) GetTransactionsByValue(tx *dbutil.Tx, min, max uint64) ([]TxnIndexItem, error) {
+	if err := hd.checkTxnIndexed(tx); err != nil {
+		return nil, err
+	}
+
+	return hd.txnsByValue.getRange(tx, min, max)
+}
+
 // AddressSeen returns true if the address appears in the blockchain
 func (hd HistoryDB) AddressSeen(tx *dbutil.Tx, addr cipher.Address) (bool, error) {
+	if err := hd.checkIndexed(tx); err != nil {
+		return false, err
+	}
+
 	return hd.addrTxns.contains(tx, addr)
 }
 
 // ForEachTxn traverses the transactions bucket
 func (hd HistoryDB) ForEachTxn(tx *dbutil.Tx, f func(cipher.SHA256, *Transaction) error) error {
+	if err := hd.checkIndexed(tx); err != nil {
+		return err
+	}
+
 	return hd.txns.forEach(tx, f)
 }
 
@@ -397,6 +811,10 @@ func (hd HistoryDB) Verify(tx *dbutil.Tx, b *coin.SignedBlock, indexesMap *Index
 
 // GetTransactionsNum returns all transactions number
 func (hd HistoryDB) GetTransactionsNum(tx *dbutil.Tx) (uint64, error) {
+	if err := hd.checkIndexed(tx); err != nil {
+		return 0, err
+	}
+
 	return hd.txns.len(tx)
 }
 
@@ -405,6 +823,16 @@ type ErrHistoryDBCorrupted struct {
 	error
 }
 
+// ErrHistoryIndexing is returned when reading the history while the HistoryDB is being rebuilt
+type ErrHistoryIndexing struct {
+	// Number of blocks parsed by the rebuild
+	ParsedBlocks uint64
+}
+
+func (e ErrHistoryIndexing) Error() string {
+	return fmt.Sprintf("The history database is being indexed (%d blocks indexed so far), try again later", e.ParsedBlocks)
+}
+
 // NewErrHistoryDBCorrupted is for user to be able to create ErrHistoryDBCorrupted instance
 // outside of the package
 func NewErrHistoryDBCorrupted(err error) ErrHistoryDBCorrupted {
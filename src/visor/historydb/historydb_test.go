@@ -353,6 +353,143 @@ func TestProcessBlock(t *testing.T) {
 	testEngine(t, testData, bc, hisDB, db)
 }
 
+func TestPruneBlock(t *testing.T) {
+	db, teardown := prepareDB(t)
+	defer teardown()
+	bc := newBlockchain()
+	gb := bc.CreateGenesisBlock(genAddress, genCoins, genTime)
+
+	hisDB := New()
+
+	err := db.Update("", func(tx *dbutil.Tx) error {
+		return hisDB.ParseBlock(tx, gb)
+	})
+	require.NoError(t, err)
+
+	addr1 := "2RxP5N26GhDqHrP6SK45ZzEMSmSpeUeWxsS"
+	addr2 := "222uMeCeL1PbkJGZJDgAz5sib2uisv9hYUm"
+
+	testEngine(t, []testData{
+		{
+			PreBlockHash: gb.HashHeader(),
+			Vin: txIn{
+				SigKey:   genSecret.Hex(),
+				Addr:     genAddress.String(),
+				TxID:     gb.Body.Transactions[0].Hash(),
+				BlockSeq: 0,
+			},
+			Vouts: []txOut{
+				{
+					ToAddr: addr1,
+					Coins:  10e6,
+					Hours:  100,
+				},
+				{
+					ToAddr: addr2,
+					Coins:  genCoins - 10e6,
+					Hours:  400,
+				},
+			},
+			AddrInNum: map[string]int{
+				addr1: 1,
+				addr2: 1,
+			},
+		},
+		{
+			Vin: txIn{
+				Addr:     addr2,
+				SigKey:   "62f4d675d991c41a2819d908a4fcf4ba44ff0c31564039e80508c9d68197f90c",
+				BlockSeq: 1,
+			},
+			Vouts: []txOut{
+				{
+					ToAddr: addr1,
+					Coins:  10e6,
+					Hours:  100,
+				},
+				{
+					ToAddr: addr2,
+					Coins:  1000e6 - 20e6,
+					Hours:  100,
+				},
+			},
+			AddrInNum: map[string]int{
+				addr1: 2,
+				addr2: 2,
+			},
+		},
+	}, bc, hisDB, db)
+
+	b1 := bc.GetBlockInDepth(1)
+	b2 := bc.GetBlockInDepth(2)
+	txn1 := b1.Body.Transactions[0]
+	txn2 := b2.Body.Transactions[0]
+	genesisTxn := gb.Body.Transactions[0]
+
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		if err := hisDB.PruneBlock(tx, *b1, 0); err != nil {
+			return err
+		}
+
+		// The transaction of the pruned block is removed
+		txn, err := hisDB.GetTransaction(tx, txn1.Hash())
+		require.NoError(t, err)
+		require.Nil(t, txn)
+
+		txn, err = hisDB.GetTransaction(tx, txn2.Hash())
+		require.NoError(t, err)
+		require.NotNil(t, txn)
+
+		// The genesis output spent by the pruned block is kept
+		outs, err := hisDB.GetOutputsForAddress(tx, genAddress)
+		require.NoError(t, err)
+		require.Len(t, outs, 1)
+
+		// The outputs created by the pruned block are kept
+		outs, err = hisDB.GetOutputsForAddress(tx, cipher.MustDecodeBase58Address(addr2))
+		require.NoError(t, err)
+		require.Len(t, outs, 2)
+
+		hashes, err := hisDB.GetTransactionHashesForAddresses(tx, []cipher.Address{cipher.MustDecodeBase58Address(addr1)})
+		require.NoError(t, err)
+		require.Equal(t, []cipher.SHA256{txn2.Hash()}, hashes)
+
+		hashes, err = hisDB.GetTransactionHashesForAddresses(tx, []cipher.Address{genAddress})
+		require.NoError(t, err)
+		require.Equal(t, []cipher.SHA256{genesisTxn.Hash()}, hashes)
+
+		if err := hisDB.PruneBlock(tx, *b2, 0); err != nil {
+			return err
+		}
+
+		txn, err = hisDB.GetTransaction(tx, txn2.Hash())
+		require.NoError(t, err)
+		require.Nil(t, txn)
+
+		// The output spent by the pruned block is removed
+		outs, err = hisDB.GetOutputsForAddress(tx, cipher.MustDecodeBase58Address(addr2))
+		require.NoError(t, err)
+		require.Len(t, outs, 1)
+		require.Equal(t, uint64(2), outs[0].Out.Head.BkSeq)
+
+		outs, err = hisDB.GetOutputsForAddress(tx, cipher.MustDecodeBase58Address(addr1))
+		require.NoError(t, err)
+		require.Len(t, outs, 2)
+
+		// The addresses are still known
+		hashes, err = hisDB.GetTransactionHashesForAddresses(tx, []cipher.Address{cipher.MustDecodeBase58Address(addr1)})
+		require.NoError(t, err)
+		require.Empty(t, hashes)
+
+		seen, err := hisDB.AddressSeen(tx, cipher.MustDecodeBase58Address(addr1))
+		require.NoError(t, err)
+		require.True(t, seen)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
+
 func testEngine(t *testing.T, tds []testData, bc *fakeBlockchain, hdb *HistoryDB, db *dbutil.DB) {
 	for i, td := range tds {
 		b, txn, err := addBlock(bc, td, incTime*(uint64(i)+1))
@@ -91,6 +91,11 @@ func (ux *uxOuts) getArray(tx *dbutil.Tx, uxIDs []cipher.SHA256) ([]UxOut, error
 	return outs, nil
 }
 
+// delete deletes the UxOut of given id
+func (ux *uxOuts) delete(tx *dbutil.Tx, uxID cipher.SHA256) error {
+	return dbutil.Delete(tx, UxOutsBkt, uxID[:])
+}
+
 // isEmpty checks if the uxout bucekt is empty
 func (ux *uxOuts) isEmpty(tx *dbutil.Tx) (bool, error) {
 	return dbutil.IsEmpty(tx, UxOutsBkt)
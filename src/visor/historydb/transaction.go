@@ -79,6 +79,11 @@ func (txs *transactions) getArray(tx *dbutil.Tx, hashes []cipher.SHA256) ([]Tran
 	return txns, nil
 }
 
+// delete deletes the transaction of given hash
+func (txs *transactions) delete(tx *dbutil.Tx, hash cipher.SHA256) error {
+	return dbutil.Delete(tx, TransactionsBkt, hash[:])
+}
+
 // isEmpty checks if transaction bucket is empty
 func (txs *transactions) isEmpty(tx *dbutil.Tx) (bool, error) {
 	return dbutil.IsEmpty(tx, TransactionsBkt)
@@ -106,6 +111,46 @@ func (txs *transactions) forEach(tx *dbutil.Tx, f func(cipher.SHA256, *Transacti
 	})
 }
 
+// forEachFrom traverses up to n transactions in hash order, starting from the transaction of given hash.
+// Returns the hash of the next transaction, and false if there are no more transactions
+func (txs *transactions) forEachFrom(tx *dbutil.Tx, from cipher.SHA256, n int, f func(cipher.SHA256, *Transaction) error) (cipher.SHA256, bool, error) {
+	bkt := tx.Bucket(TransactionsBkt)
+	if bkt == nil {
+		return cipher.SHA256{}, false, dbutil.NewErrBucketNotExist(TransactionsBkt)
+	}
+
+	c := bkt.Cursor()
+	k, v := c.Seek(from[:])
+	for i := 0; k != nil && i < n; i++ {
+		hash, err := cipher.SHA256FromBytes(k)
+		if err != nil {
+			return cipher.SHA256{}, false, err
+		}
+
+		var txn Transaction
+		if err := decodeTransactionExact(v, &txn); err != nil {
+			return cipher.SHA256{}, false, err
+		}
+
+		if err := f(hash, &txn); err != nil {
+			return cipher.SHA256{}, false, err
+		}
+
+		k, v = c.Next()
+	}
+
+	if k == nil {
+		return cipher.SHA256{}, false, nil
+	}
+
+	next, err := cipher.SHA256FromBytes(k)
+	if err != nil {
+		return cipher.SHA256{}, false, err
+	}
+
+	return next, true, nil
+}
+
 // len returns the total number of all transactions
 func (txs *transactions) len(tx *dbutil.Tx) (uint64, error) {
 	return dbutil.Len(tx, TransactionsBkt)
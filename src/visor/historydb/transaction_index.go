@@ -0,0 +1,94 @@
+package historydb
+
+// transaction_index.go provides the buckets that index the transactions by a uint64 value,
+// such as the time of their block, so that a range of the value is read without traversing
+// the transactions bucket.
+
+import (
+	"errors"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+var (
+	// TxnsByTimeBkt indexes the transactions by the time of the block that executed them
+	TxnsByTimeBkt = []byte("txns_by_time")
+	// TxnsByValueBkt indexes the transactions by the total coins of their outputs
+	TxnsByValueBkt = []byte("txns_by_value")
+)
+
+// txnIndexKeyLen is the length of a key of a txnIndex: the value, the block seq and the transaction hash
+const txnIndexKeyLen = 8 + 8 + len(cipher.SHA256{})
+
+// TxnIndexItem is a transaction of a transaction index
+type TxnIndexItem struct {
+	Hash     cipher.SHA256
+	BlockSeq uint64
+}
+
+// txnIndex is a bucket that indexes the transactions by a uint64 value.
+// The keys are the value, the block seq and the transaction hash, so that they are ordered by the value,
+// then by block seq. The values are empty
+type txnIndex struct {
+	bkt []byte
+}
+
+func txnIndexKey(v, seq uint64, hash cipher.SHA256) []byte {
+	key := make([]byte, 0, txnIndexKeyLen)
+	key = append(key, dbutil.Itob(v)...)
+	key = append(key, dbutil.Itob(seq)...)
+	return append(key, hash[:]...)
+}
+
+// put adds a transaction to the index
+func (ti *txnIndex) put(tx *dbutil.Tx, v, seq uint64, hash cipher.SHA256) error {
+	return dbutil.PutBucketValue(tx, ti.bkt, txnIndexKey(v, seq, hash), []byte{})
+}
+
+// delete removes a transaction from the index
+func (ti *txnIndex) delete(tx *dbutil.Tx, v, seq uint64, hash cipher.SHA256) error {
+	return dbutil.Delete(tx, ti.bkt, txnIndexKey(v, seq, hash))
+}
+
+// getRange returns the transactions whose value is between from and to inclusive, ordered by value then by block seq
+func (ti *txnIndex) getRange(tx *dbutil.Tx, from, to uint64) ([]TxnIndexItem, error) {
+	bkt := tx.Bucket(ti.bkt)
+	if bkt == nil {
+		return nil, dbutil.NewErrBucketNotExist(ti.bkt)
+	}
+
+	var items []TxnIndexItem
+	c := bkt.Cursor()
+	for k, _ := c.Seek(dbutil.Itob(from)); k != nil; k, _ = c.Next() {
+		if len(k) != txnIndexKeyLen {
+			return nil, errors.New("Invalid transaction index key length")
+		}
+
+		if dbutil.Btoi(k[:8]) > to {
+			break
+		}
+
+		hash, err := cipher.SHA256FromBytes(k[16:])
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, TxnIndexItem{
+			Hash:     hash,
+			BlockSeq: dbutil.Btoi(k[8:16]),
+		})
+	}
+
+	return items, nil
+}
+
+// isEmpty checks if the index is empty
+func (ti *txnIndex) isEmpty(tx *dbutil.Tx) (bool, error) {
+	return dbutil.IsEmpty(tx, ti.bkt)
+}
+
+// reset resets the bucket
+func (ti *txnIndex) reset(tx *dbutil.Tx) error {
+	return dbutil.Reset(tx, ti.bkt)
+}
@@ -0,0 +1,194 @@
+package historydb
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+func TestTransactionIndexes(t *testing.T) {
+	db, teardown := prepareDB(t)
+	defer teardown()
+	bc := newBlockchain()
+	gb := bc.CreateGenesisBlock(genAddress, genCoins, genTime)
+
+	hisDB := New()
+
+	err := db.Update("", func(tx *dbutil.Tx) error {
+		return hisDB.ParseBlock(tx, gb)
+	})
+	require.NoError(t, err)
+
+	addr1 := "2RxP5N26GhDqHrP6SK45ZzEMSmSpeUeWxsS"
+	addr2 := "222uMeCeL1PbkJGZJDgAz5sib2uisv9hYUm"
+
+	testEngine(t, []testData{
+		{
+			PreBlockHash: gb.HashHeader(),
+			Vin: txIn{
+				SigKey:   genSecret.Hex(),
+				Addr:     genAddress.String(),
+				TxID:     gb.Body.Transactions[0].Hash(),
+				BlockSeq: 0,
+			},
+			Vouts: []txOut{
+				{
+					ToAddr: addr1,
+					Coins:  10e6,
+					Hours:  100,
+				},
+				{
+					ToAddr: addr2,
+					Coins:  genCoins - 10e6,
+					Hours:  400,
+				},
+			},
+			AddrInNum: map[string]int{
+				addr1: 1,
+				addr2: 1,
+			},
+		},
+		{
+			Vin: txIn{
+				Addr:     addr2,
+				SigKey:   "62f4d675d991c41a2819d908a4fcf4ba44ff0c31564039e80508c9d68197f90c",
+				BlockSeq: 1,
+			},
+			Vouts: []txOut{
+				{
+					ToAddr: addr1,
+					Coins:  10e6,
+					Hours:  100,
+				},
+				{
+					ToAddr: addr2,
+					Coins:  genCoins - 20e6,
+					Hours:  100,
+				},
+			},
+			AddrInNum: map[string]int{
+				addr1: 2,
+				addr2: 2,
+			},
+		},
+	}, bc, hisDB, db)
+
+	b1 := bc.GetBlockInDepth(1)
+	b2 := bc.GetBlockInDepth(2)
+	genesisItem := TxnIndexItem{Hash: gb.Body.Transactions[0].Hash(), BlockSeq: 0}
+	item1 := TxnIndexItem{Hash: b1.Body.Transactions[0].Hash(), BlockSeq: 1}
+	item2 := TxnIndexItem{Hash: b2.Body.Transactions[0].Hash(), BlockSeq: 2}
+
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		// The transactions are ordered by block time
+		items, err := hisDB.GetTransactionsByTime(tx, 0, math.MaxUint64)
+		require.NoError(t, err)
+		require.Equal(t, []TxnIndexItem{genesisItem, item1, item2}, items)
+
+		items, err = hisDB.GetTransactionsByTime(tx, b1.Time(), b2.Time()-1)
+		require.NoError(t, err)
+		require.Equal(t, []TxnIndexItem{item1}, items)
+
+		items, err = hisDB.GetTransactionsByTime(tx, b2.Time()+1, math.MaxUint64)
+		require.NoError(t, err)
+		require.Empty(t, items)
+
+		// The transactions are ordered by output coins, then by block seq
+		items, err = hisDB.GetTransactionsByValue(tx, 0, math.MaxUint64)
+		require.NoError(t, err)
+		require.Equal(t, []TxnIndexItem{item2, genesisItem, item1}, items)
+
+		items, err = hisDB.GetTransactionsByValue(tx, genCoins, genCoins)
+		require.NoError(t, err)
+		require.Equal(t, []TxnIndexItem{genesisItem, item1}, items)
+
+		items, err = hisDB.GetTransactionsByValue(tx, 0, genCoins-1)
+		require.NoError(t, err)
+		require.Equal(t, []TxnIndexItem{item2}, items)
+
+		// The transactions of a pruned block are removed from the indexes
+		if err := hisDB.PruneBlock(tx, *b1, 0); err != nil {
+			return err
+		}
+
+		items, err = hisDB.GetTransactionsByTime(tx, 0, math.MaxUint64)
+		require.NoError(t, err)
+		require.Equal(t, []TxnIndexItem{genesisItem, item2}, items)
+
+		items, err = hisDB.GetTransactionsByValue(tx, 0, math.MaxUint64)
+		require.NoError(t, err)
+		require.Equal(t, []TxnIndexItem{item2, genesisItem}, items)
+
+		// The indexes are backfilled in place from the transactions bucket if they are empty
+		needsMigration, err := hisDB.NeedsTxnIndexMigration(tx)
+		require.NoError(t, err)
+		require.False(t, needsMigration)
+
+		migrating, err := hisDB.MigratingTxnIndexes(tx)
+		require.NoError(t, err)
+		require.False(t, migrating)
+
+		if err := hisDB.txnsByTime.reset(tx); err != nil {
+			return err
+		}
+		if err := hisDB.txnsByValue.reset(tx); err != nil {
+			return err
+		}
+
+		needsReset, err := hisDB.NeedsReset(tx)
+		require.NoError(t, err)
+		require.False(t, needsReset)
+
+		needsMigration, err = hisDB.NeedsTxnIndexMigration(tx)
+		require.NoError(t, err)
+		require.True(t, needsMigration)
+
+		err = hisDB.StartTxnIndexMigration(tx)
+		require.NoError(t, err)
+
+		migrating, err = hisDB.MigratingTxnIndexes(tx)
+		require.NoError(t, err)
+		require.True(t, migrating)
+
+		blockTime := func(seq uint64) (uint64, error) {
+			return bc.GetBlockInDepth(seq).Time(), nil
+		}
+
+		// The indexes are not available until all the transactions are added in batches
+		done, err := hisDB.MigrateTxnIndexes(tx, 1, blockTime)
+		require.NoError(t, err)
+		require.False(t, done)
+
+		_, err = hisDB.GetTransactionsByTime(tx, 0, math.MaxUint64)
+		require.Equal(t, ErrHistoryIndexing{ParsedBlocks: 3}, err)
+
+		_, err = hisDB.GetTransactionsByValue(tx, 0, math.MaxUint64)
+		require.Equal(t, ErrHistoryIndexing{ParsedBlocks: 3}, err)
+
+		done, err = hisDB.MigrateTxnIndexes(tx, 1, blockTime)
+		require.NoError(t, err)
+		require.True(t, done)
+
+		migrating, err = hisDB.MigratingTxnIndexes(tx)
+		require.NoError(t, err)
+		require.False(t, migrating)
+
+		needsMigration, err = hisDB.NeedsTxnIndexMigration(tx)
+		require.NoError(t, err)
+		require.False(t, needsMigration)
+
+		items, err = hisDB.GetTransactionsByTime(tx, 0, math.MaxUint64)
+		require.NoError(t, err)
+		require.Equal(t, []TxnIndexItem{genesisItem, item2}, items)
+
+		items, err = hisDB.GetTransactionsByValue(tx, 0, math.MaxUint64)
+		require.NoError(t, err)
+		require.Equal(t, []TxnIndexItem{item2, genesisItem}, items)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
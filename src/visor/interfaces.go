@@ -18,14 +18,23 @@ import (
 type Historyer interface {
 	GetUxOuts(tx *dbutil.Tx, uxids []cipher.SHA256) ([]historydb.UxOut, error)
 	ParseBlock(tx *dbutil.Tx, b coin.Block) error
+	PruneBlock(tx *dbutil.Tx, b coin.Block, keepSeq uint64) error
+	BackfillBlock(tx *dbutil.Tx, b coin.Block) error
+	AddUnspents(tx *dbutil.Tx, uxs coin.UxArray) error
 	GetTransaction(tx *dbutil.Tx, hash cipher.SHA256) (*historydb.Transaction, error)
 	GetTransactionsNum(tx *dbutil.Tx) (uint64, error)
 	GetOutputsForAddress(tx *dbutil.Tx, address cipher.Address) ([]historydb.UxOut, error)
 	GetTransactionHashesForAddresses(tx *dbutil.Tx, addresses []cipher.Address) ([]cipher.SHA256, error)
+	GetTransactionsByTime(tx *dbutil.Tx, from, to uint64) ([]historydb.TxnIndexItem, error)
+	GetTransactionsByValue(tx *dbutil.Tx, min, max uint64) ([]historydb.TxnIndexItem, error)
 	AddressSeen(tx *dbutil.Tx, address cipher.Address) (bool, error)
 	NeedsReset(tx *dbutil.Tx) (bool, error)
 	Erase(tx *dbutil.Tx) error
 	ParsedBlockSeq(tx *dbutil.Tx) (uint64, bool, error)
+	Rebuilding(tx *dbutil.Tx) (bool, error)
+	SetRebuilding(tx *dbutil.Tx, rebuilding bool) error
+	MigratingTxnIndexes(tx *dbutil.Tx) (bool, error)
+	MigrateTxnIndexes(tx *dbutil.Tx, n int, blockTime func(seq uint64) (uint64, error)) (bool, error)
 	ForEachTxn(tx *dbutil.Tx, f func(cipher.SHA256, *historydb.Transaction) error) error
 }
 
@@ -37,6 +46,12 @@ type Blockchainer interface {
 	GetLastBlocks(tx *dbutil.Tx, n uint64) ([]coin.SignedBlock, error)
 	GetSignedBlockByHash(tx *dbutil.Tx, hash cipher.SHA256) (*coin.SignedBlock, error)
 	GetSignedBlockBySeq(tx *dbutil.Tx, seq uint64) (*coin.SignedBlock, error)
+	GetSignedBlockHeaderBySeq(tx *dbutil.Tx, seq uint64) (*coin.BlockHeader, cipher.Sig, error)
+	LowestBlockSeq(tx *dbutil.Tx) (uint64, error)
+	PruneBlock(tx *dbutil.Tx, seq uint64) (*coin.SignedBlock, error)
+	BackfilledBlockSeq(tx *dbutil.Tx) (uint64, error)
+	BackfillBlock(tx *dbutil.Tx, sb *coin.SignedBlock) error
+	AddPrunedBlock(tx *dbutil.Tx, h coin.BlockHeader, sig cipher.Sig) error
 	Unspent() blockdb.UnspentPooler
 	Len(tx *dbutil.Tx) (uint64, error)
 	Head(tx *dbutil.Tx) (*coin.SignedBlock, error)
@@ -45,6 +60,8 @@ type Blockchainer interface {
 	NewBlock(tx *dbutil.Tx, txns coin.Transactions, currentTime uint64) (*coin.Block, error)
 	ExecuteBlock(tx *dbutil.Tx, sb *coin.SignedBlock) error
 	VerifyBlock(tx *dbutil.Tx, sb *coin.SignedBlock) error
+	VerifyBlockSignatures(tx *dbutil.Tx, blocks []coin.SignedBlock) error
+	SignatureCacheStats() SignatureCacheStats
 	VerifyBlockTxnConstraints(tx *dbutil.Tx, txn coin.Transaction) error
 	VerifySingleTxnHardConstraints(tx *dbutil.Tx, txn coin.Transaction, signed transaction.TxnSignedFlag) error
 	VerifySingleTxnSoftHardConstraints(tx *dbutil.Tx, txn coin.Transaction, distParams params.Distribution, verifyParams params.VerifyTxn, signed transaction.TxnSignedFlag) (*coin.SignedBlock, coin.UxArray, error)
@@ -22,6 +22,55 @@ type MockBlockchainer struct {
 	mock.Mock
 }
 
+// AddPrunedBlock provides a mock function with given fields: tx, h, sig
+func (_m *MockBlockchainer) AddPrunedBlock(tx *dbutil.Tx, h coin.BlockHeader, sig cipher.Sig) error {
+	ret := _m.Called(tx, h, sig)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, coin.BlockHeader, cipher.Sig) error); ok {
+		r0 = rf(tx, h, sig)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// BackfillBlock provides a mock function with given fields: tx, sb
+func (_m *MockBlockchainer) BackfillBlock(tx *dbutil.Tx, sb *coin.SignedBlock) error {
+	ret := _m.Called(tx, sb)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, *coin.SignedBlock) error); ok {
+		r0 = rf(tx, sb)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// BackfilledBlockSeq provides a mock function with given fields: tx
+func (_m *MockBlockchainer) BackfilledBlockSeq(tx *dbutil.Tx) (uint64, error) {
+	ret := _m.Called(tx)
+
+	var r0 uint64
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx) uint64); ok {
+		r0 = rf(tx)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx) error); ok {
+		r1 = rf(tx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ExecuteBlock provides a mock function with given fields: tx, sb
 func (_m *MockBlockchainer) ExecuteBlock(tx *dbutil.Tx, sb *coin.SignedBlock) error {
 	ret := _m.Called(tx, sb)
@@ -174,6 +223,36 @@ func (_m *MockBlockchainer) GetSignedBlockBySeq(tx *dbutil.Tx, seq uint64) (*coi
 	return r0, r1
 }
 
+// GetSignedBlockHeaderBySeq provides a mock function with given fields: tx, seq
+func (_m *MockBlockchainer) GetSignedBlockHeaderBySeq(tx *dbutil.Tx, seq uint64) (*coin.BlockHeader, cipher.Sig, error) {
+	ret := _m.Called(tx, seq)
+
+	var r0 *coin.BlockHeader
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, uint64) *coin.BlockHeader); ok {
+		r0 = rf(tx, seq)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*coin.BlockHeader)
+		}
+	}
+
+	var r1 cipher.Sig
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx, uint64) cipher.Sig); ok {
+		r1 = rf(tx, seq)
+	} else {
+		r1 = ret.Get(1).(cipher.Sig)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(*dbutil.Tx, uint64) error); ok {
+		r2 = rf(tx, seq)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // Head provides a mock function with given fields: tx
 func (_m *MockBlockchainer) Head(tx *dbutil.Tx) (*coin.SignedBlock, error) {
 	ret := _m.Called(tx)
@@ -246,6 +325,27 @@ func (_m *MockBlockchainer) Len(tx *dbutil.Tx) (uint64, error) {
 	return r0, r1
 }
 
+// LowestBlockSeq provides a mock function with given fields: tx
+func (_m *MockBlockchainer) LowestBlockSeq(tx *dbutil.Tx) (uint64, error) {
+	ret := _m.Called(tx)
+
+	var r0 uint64
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx) uint64); ok {
+		r0 = rf(tx)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx) error); ok {
+		r1 = rf(tx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewBlock provides a mock function with given fields: tx, txns, currentTime
 func (_m *MockBlockchainer) NewBlock(tx *dbutil.Tx, txns coin.Transactions, currentTime uint64) (*coin.Block, error) {
 	ret := _m.Called(tx, txns, currentTime)
@@ -269,6 +369,43 @@ func (_m *MockBlockchainer) NewBlock(tx *dbutil.Tx, txns coin.Transactions, curr
 	return r0, r1
 }
 
+// PruneBlock provides a mock function with given fields: tx, seq
+func (_m *MockBlockchainer) PruneBlock(tx *dbutil.Tx, seq uint64) (*coin.SignedBlock, error) {
+	ret := _m.Called(tx, seq)
+
+	var r0 *coin.SignedBlock
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, uint64) *coin.SignedBlock); ok {
+		r0 = rf(tx, seq)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*coin.SignedBlock)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx, uint64) error); ok {
+		r1 = rf(tx, seq)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SignatureCacheStats provides a mock function with given fields:
+func (_m *MockBlockchainer) SignatureCacheStats() SignatureCacheStats {
+	ret := _m.Called()
+
+	var r0 SignatureCacheStats
+	if rf, ok := ret.Get(0).(func() SignatureCacheStats); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(SignatureCacheStats)
+	}
+
+	return r0
+}
+
 // Time provides a mock function with given fields: tx
 func (_m *MockBlockchainer) Time(tx *dbutil.Tx) (uint64, error) {
 	ret := _m.Called(tx)
@@ -336,6 +473,20 @@ func (_m *MockBlockchainer) VerifyBlock(tx *dbutil.Tx, sb *coin.SignedBlock) err
 	return r0
 }
 
+// VerifyBlockSignatures provides a mock function with given fields: tx, blocks
+func (_m *MockBlockchainer) VerifyBlockSignatures(tx *dbutil.Tx, blocks []coin.SignedBlock) error {
+	ret := _m.Called(tx, blocks)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, []coin.SignedBlock) error); ok {
+		r0 = rf(tx, blocks)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // VerifyBlockTxnConstraints provides a mock function with given fields: tx, txn
 func (_m *MockBlockchainer) VerifyBlockTxnConstraints(tx *dbutil.Tx, txn coin.Transaction) error {
 	ret := _m.Called(tx, txn)
@@ -18,6 +18,20 @@ type MockHistoryer struct {
 	mock.Mock
 }
 
+// AddUnspents provides a mock function with given fields: tx, uxs
+func (_m *MockHistoryer) AddUnspents(tx *dbutil.Tx, uxs coin.UxArray) error {
+	ret := _m.Called(tx, uxs)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, coin.UxArray) error); ok {
+		r0 = rf(tx, uxs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // AddressSeen provides a mock function with given fields: tx, address
 func (_m *MockHistoryer) AddressSeen(tx *dbutil.Tx, address cipher.Address) (bool, error) {
 	ret := _m.Called(tx, address)
@@ -39,6 +53,20 @@ func (_m *MockHistoryer) AddressSeen(tx *dbutil.Tx, address cipher.Address) (boo
 	return r0, r1
 }
 
+// BackfillBlock provides a mock function with given fields: tx, b
+func (_m *MockHistoryer) BackfillBlock(tx *dbutil.Tx, b coin.Block) error {
+	ret := _m.Called(tx, b)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, coin.Block) error); ok {
+		r0 = rf(tx, b)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Erase provides a mock function with given fields: tx
 func (_m *MockHistoryer) Erase(tx *dbutil.Tx) error {
 	ret := _m.Called(tx)
@@ -136,6 +164,52 @@ func (_m *MockHistoryer) GetTransactionHashesForAddresses(tx *dbutil.Tx, address
 	return r0, r1
 }
 
+// GetTransactionsByTime provides a mock function with given fields: tx, from, to
+func (_m *MockHistoryer) GetTransactionsByTime(tx *dbutil.Tx, from uint64, to uint64) ([]historydb.TxnIndexItem, error) {
+	ret := _m.Called(tx, from, to)
+
+	var r0 []historydb.TxnIndexItem
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, uint64, uint64) []historydb.TxnIndexItem); ok {
+		r0 = rf(tx, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]historydb.TxnIndexItem)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx, uint64, uint64) error); ok {
+		r1 = rf(tx, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTransactionsByValue provides a mock function with given fields: tx, min, max
+func (_m *MockHistoryer) GetTransactionsByValue(tx *dbutil.Tx, min uint64, max uint64) ([]historydb.TxnIndexItem, error) {
+	ret := _m.Called(tx, min, max)
+
+	var r0 []historydb.TxnIndexItem
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, uint64, uint64) []historydb.TxnIndexItem); ok {
+		r0 = rf(tx, min, max)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]historydb.TxnIndexItem)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx, uint64, uint64) error); ok {
+		r1 = rf(tx, min, max)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetTransactionsNum provides a mock function with given fields: tx
 func (_m *MockHistoryer) GetTransactionsNum(tx *dbutil.Tx) (uint64, error) {
 	ret := _m.Called(tx)
@@ -180,6 +254,48 @@ func (_m *MockHistoryer) GetUxOuts(tx *dbutil.Tx, uxids []cipher.SHA256) ([]hist
 	return r0, r1
 }
 
+// MigrateTxnIndexes provides a mock function with given fields: tx, n, blockTime
+func (_m *MockHistoryer) MigrateTxnIndexes(tx *dbutil.Tx, n int, blockTime func(uint64) (uint64, error)) (bool, error) {
+	ret := _m.Called(tx, n, blockTime)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, int, func(uint64) (uint64, error)) bool); ok {
+		r0 = rf(tx, n, blockTime)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx, int, func(uint64) (uint64, error)) error); ok {
+		r1 = rf(tx, n, blockTime)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MigratingTxnIndexes provides a mock function with given fields: tx
+func (_m *MockHistoryer) MigratingTxnIndexes(tx *dbutil.Tx) (bool, error) {
+	ret := _m.Called(tx)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx) bool); ok {
+		r0 = rf(tx)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx) error); ok {
+		r1 = rf(tx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NeedsReset provides a mock function with given fields: tx
 func (_m *MockHistoryer) NeedsReset(tx *dbutil.Tx) (bool, error) {
 	ret := _m.Called(tx)
@@ -215,6 +331,20 @@ func (_m *MockHistoryer) ParseBlock(tx *dbutil.Tx, b coin.Block) error {
 	return r0
 }
 
+// PruneBlock provides a mock function with given fields: tx, b, keepSeq
+func (_m *MockHistoryer) PruneBlock(tx *dbutil.Tx, b coin.Block, keepSeq uint64) error {
+	ret := _m.Called(tx, b, keepSeq)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, coin.Block, uint64) error); ok {
+		r0 = rf(tx, b, keepSeq)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ParsedBlockSeq provides a mock function with given fields: tx
 func (_m *MockHistoryer) ParsedBlockSeq(tx *dbutil.Tx) (uint64, bool, error) {
 	ret := _m.Called(tx)
@@ -242,3 +372,38 @@ func (_m *MockHistoryer) ParsedBlockSeq(tx *dbutil.Tx) (uint64, bool, error) {
 
 	return r0, r1, r2
 }
+
+// Rebuilding provides a mock function with given fields: tx
+func (_m *MockHistoryer) Rebuilding(tx *dbutil.Tx) (bool, error) {
+	ret := _m.Called(tx)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx) bool); ok {
+		r0 = rf(tx)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx) error); ok {
+		r1 = rf(tx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetRebuilding provides a mock function with given fields: tx, rebuilding
+func (_m *MockHistoryer) SetRebuilding(tx *dbutil.Tx, rebuilding bool) error {
+	ret := _m.Called(tx, rebuilding)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, bool) error); ok {
+		r0 = rf(tx, rebuilding)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
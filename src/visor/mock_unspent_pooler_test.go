@@ -218,6 +218,20 @@ func (_m *MockUnspentPooler) Len(_a0 *dbutil.Tx) (uint64, error) {
 	return r0, r1
 }
 
+// LoadSnapshot provides a mock function with given fields: _a0, _a1, _a2
+func (_m *MockUnspentPooler) LoadSnapshot(_a0 *dbutil.Tx, _a1 coin.UxArray, _a2 uint64) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, coin.UxArray, uint64) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // MaybeBuildIndexes provides a mock function with given fields: _a0, _a1
 func (_m *MockUnspentPooler) MaybeBuildIndexes(_a0 *dbutil.Tx, _a1 uint64) error {
 	ret := _m.Called(_a0, _a1)
@@ -3,6 +3,7 @@ package visor
 import (
 	"time"
 
+	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/coin"
 	"github.com/skycoin/skycoin/src/transaction"
 )
@@ -128,17 +129,27 @@ type BlockchainMetadata struct {
 	Unspents uint64
 	// Number of known unconfirmed txns
 	Unconfirmed uint64
+	// Lowest seq of the blocks whose bodies are available, other than the genesis block.
+	// 0 if no block has been pruned
+	LowestBlockSeq uint64
 }
 
 // NewBlockchainMetadata creates blockchain meta data
-func NewBlockchainMetadata(head coin.SignedBlock, unconfirmedLen, unspentsLen uint64) (*BlockchainMetadata, error) {
+func NewBlockchainMetadata(head coin.SignedBlock, unconfirmedLen, unspentsLen, lowestBlockSeq uint64) (*BlockchainMetadata, error) {
 	return &BlockchainMetadata{
-		HeadBlock:   head,
-		Unspents:    unspentsLen,
-		Unconfirmed: unconfirmedLen,
+		HeadBlock:      head,
+		Unspents:       unspentsLen,
+		Unconfirmed:    unconfirmedLen,
+		LowestBlockSeq: lowestBlockSeq,
 	}, nil
 }
 
+// SignedBlockHeader is a block header with the signature of the block
+type SignedBlockHeader struct {
+	Header coin.BlockHeader
+	Sig    cipher.Sig
+}
+
 // UnconfirmedTransaction unconfirmed transaction
 type UnconfirmedTransaction struct {
 	Transaction coin.Transaction
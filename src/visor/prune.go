@@ -0,0 +1,249 @@
+package visor
+
+import (
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+const (
+	// MinPruneDepth is the minimum number of most recent blocks whose bodies are kept by a pruned node
+	MinPruneDepth = 100
+	// pruneBatchSize is the maximum number of blocks pruned in one database transaction
+	pruneBatchSize = 1000
+)
+
+// LowestBlockSeq returns the lowest seq of the blocks whose bodies are available, other than the genesis block.
+// The bodies of the blocks between the genesis block and this block have been pruned.
+// Returns 0 if no block has been pruned
+func (vs *Visor) LowestBlockSeq() (uint64, error) {
+	var seq uint64
+	if err := vs.db.View("LowestBlockSeq", func(tx *dbutil.Tx) error {
+		var err error
+		seq, err = vs.blockchain.LowestBlockSeq(tx)
+		return err
+	}); err != nil {
+		return 0, err
+	}
+
+	return seq, nil
+}
+
+// GetSignedBlockHeadersSince returns the signed headers of up to ct blocks after seq.
+// The headers of pruned blocks are available
+func (vs *Visor) GetSignedBlockHeadersSince(seq, ct uint64) ([]SignedBlockHeader, error) {
+	var headers []SignedBlockHeader
+
+	if err := vs.db.View("GetSignedBlockHeadersSince", func(tx *dbutil.Tx) error {
+		headSeq, ok, err := vs.blockchain.HeadSeq(tx)
+		if err != nil {
+			return err
+		}
+		if !ok || headSeq <= seq {
+			return nil
+		}
+
+		if avail := headSeq - seq; avail < ct {
+			ct = avail
+		}
+
+		headers = make([]SignedBlockHeader, 0, ct)
+		for i := seq + 1; i <= seq+ct; i++ {
+			h, sig, err := vs.blockchain.GetSignedBlockHeaderBySeq(tx, i)
+			if err != nil {
+				return err
+			}
+			if h == nil {
+				return NewErrBlockNotExist(i)
+			}
+
+			headers = append(headers, SignedBlockHeader{
+				Header: *h,
+				Sig:    sig,
+			})
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return headers, nil
+}
+
+// pruneBlocks prunes the blocks that are deeper than Config.PruneDepth, in batches
+func (vs *Visor) pruneBlocks() error {
+	if vs.Config.PruneDepth == 0 {
+		return nil
+	}
+
+	total := 0
+	for {
+		var n int
+		if err := vs.db.Update("pruneBlocks", func(tx *dbutil.Tx) error {
+			var err error
+			n, err = vs.pruneBlocksTx(tx, pruneBatchSize)
+			return err
+		}); err != nil {
+			return err
+		}
+
+		if n == 0 {
+			break
+		}
+
+		total += n
+		logger.Infof("Pruned %d blocks", total)
+	}
+
+	return nil
+}
+
+// pruneBlocksTx prunes up to max blocks that are deeper than Config.PruneDepth.
+// The bodies of the blocks and their history are discarded, their headers and signatures are kept.
+// The bodies of the Config.PruneDepth most recent blocks and of the genesis block are kept.
+// Returns the number of pruned blocks
+func (vs *Visor) pruneBlocksTx(tx *dbutil.Tx, max int) (int, error) {
+	if vs.Config.PruneDepth == 0 {
+		return 0, nil
+	}
+
+	// The blocks are pruned after the history database is rebuilt, since their history is not parsed yet
+	rebuilding, err := vs.history.Rebuilding(tx)
+	if err != nil {
+		return 0, err
+	}
+	if rebuilding {
+		return 0, nil
+	}
+
+	headSeq, ok, err := vs.blockchain.HeadSeq(tx)
+	if err != nil {
+		return 0, err
+	}
+	if !ok || headSeq < vs.Config.PruneDepth {
+		return 0, nil
+	}
+
+	// The lowest block seq after pruning
+	end := headSeq - vs.Config.PruneDepth + 1
+
+	seq, err := vs.blockchain.LowestBlockSeq(tx)
+	if err != nil {
+		return 0, err
+	}
+	if seq == 0 {
+		seq = 1
+	}
+
+	// The history of the blocks that were backfilled before pruning was enabled is kept
+	backfilledSeq, err := vs.blockchain.BackfilledBlockSeq(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for ; seq < end && n < max; seq++ {
+		b, err := vs.blockchain.PruneBlock(tx, seq)
+		if err != nil {
+			return n, err
+		}
+
+		if err := vs.history.PruneBlock(tx, b.Block, backfilledSeq); err != nil {
+			return n, err
+		}
+
+		n++
+	}
+
+	return n, nil
+}
+
+// BackfillRange returns the seq of the last backfilled block and the seq of the last pruned block.
+// The bodies of the blocks between them are pruned, and are downloaded again from peers by a node
+// that was bootstrapped from a snapshot. Returns false if there are no blocks to backfill,
+// or if the node prunes blocks
+func (vs *Visor) BackfillRange() (uint64, uint64, bool, error) {
+	if vs.Config.PruneDepth != 0 || vs.db.IsReadOnly() {
+		return 0, 0, false, nil
+	}
+
+	var backfilledSeq, lowestSeq uint64
+	if err := vs.db.View("BackfillRange", func(tx *dbutil.Tx) error {
+		var err error
+		lowestSeq, err = vs.blockchain.LowestBlockSeq(tx)
+		if err != nil {
+			return err
+		}
+
+		backfilledSeq, err = vs.blockchain.BackfilledBlockSeq(tx)
+		return err
+	}); err != nil {
+		return 0, 0, false, err
+	}
+
+	if lowestSeq == 0 {
+		return 0, 0, false, nil
+	}
+
+	return backfilledSeq, lowestSeq - 1, true, nil
+}
+
+// BackfillBlocks restores the bodies and the history of pruned blocks downloaded from peers.
+// The blocks must be in order, blocks that are not the next block to backfill are skipped.
+// Each block must match the signed header kept for its seq.
+// Once all pruned blocks are backfilled, the unspent outputs imported from a snapshot are checked against them.
+// If they differ, the database is reported as failed on dbutil.DB.Failed, which shuts down the node.
+// Returns the number of backfilled blocks
+func (vs *Visor) BackfillBlocks(blocks []coin.SignedBlock) (int, error) {
+	n := 0
+	if err := vs.db.Update("BackfillBlocks", func(tx *dbutil.Tx) error {
+		for i := range blocks {
+			b := &blocks[i]
+
+			lowestSeq, err := vs.blockchain.LowestBlockSeq(tx)
+			if err != nil {
+				return err
+			}
+
+			backfilledSeq, err := vs.blockchain.BackfilledBlockSeq(tx)
+			if err != nil {
+				return err
+			}
+
+			if lowestSeq == 0 || b.Seq() >= lowestSeq {
+				return nil
+			}
+			if b.Seq() != backfilledSeq+1 {
+				continue
+			}
+
+			if err := vs.blockchain.BackfillBlock(tx, b); err != nil {
+				return err
+			}
+
+			if err := vs.history.BackfillBlock(tx, b.Block); err != nil {
+				return err
+			}
+
+			n++
+		}
+
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	if n == 0 {
+		return 0, nil
+	}
+
+	// The check is retried by Init if it fails for another reason
+	if err := vs.verifySnapshotBackfill(); err == ErrSnapshotBackfillMismatch {
+		logger.Critical().WithError(err).Error("The imported snapshot is forged or corrupt. Recreate the database without the snapshot")
+		vs.db.Fail(err)
+	} else if err != nil {
+		logger.WithError(err).Error("verifySnapshotBackfill failed")
+	}
+
+	return n, nil
+}
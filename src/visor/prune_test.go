@@ -0,0 +1,264 @@
+package visor
+
+import (
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/params"
+	"github.com/skycoin/skycoin/src/visor/blockdb"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+)
+
+// addPruneTestBlocks creates n blocks on top of the head block of a block publisher visor.
+// Each block spends the oldest unspent output of genAddress so that the spent output has accrued coin hours.
+// If genAddress has a single unspent output, it is split in two
+func addPruneTestBlocks(t *testing.T, v *Visor, n int) {
+	for i := 0; i < n; i++ {
+		head, err := v.GetHeadBlock()
+		require.NoError(t, err)
+
+		uxOuts, err := v.GetUnspentsOfAddrs([]cipher.Address{genAddress})
+		require.NoError(t, err)
+		uxa := uxOuts[genAddress]
+		require.NotEmpty(t, uxa)
+		sort.Slice(uxa, func(i, j int) bool {
+			return uxa[i].Head.BkSeq < uxa[j].Head.BkSeq
+		})
+
+		ux := uxa[0]
+		hours, err := ux.CoinHours(head.Time())
+		require.NoError(t, err)
+
+		txn := coin.Transaction{}
+		err = txn.PushInput(ux.Hash())
+		require.NoError(t, err)
+		if len(uxa) == 1 {
+			err = txn.PushOutput(genAddress, ux.Body.Coins/4, hours/4)
+			require.NoError(t, err)
+			err = txn.PushOutput(genAddress, ux.Body.Coins-ux.Body.Coins/4, hours/4)
+			require.NoError(t, err)
+		} else {
+			err = txn.PushOutput(genAddress, ux.Body.Coins, hours/2)
+			require.NoError(t, err)
+		}
+		txn.SignInputs([]cipher.SecKey{genSecret})
+		err = txn.UpdateHeader()
+		require.NoError(t, err)
+
+		_, softErr, err := v.InjectForeignTransaction(txn)
+		require.NoError(t, err)
+		require.Nil(t, softErr)
+
+		err = v.db.Update("", func(tx *dbutil.Tx) error {
+			b, err := v.createBlock(tx, head.Time()+3600)
+			if err != nil {
+				return err
+			}
+			return v.executeSignedBlock(tx, b)
+		})
+		require.NoError(t, err)
+	}
+}
+
+func TestPruneBlocks(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	v := makeBlocksFileVisor(t, db, 0)
+	addPruneTestBlocks(t, v, MinPruneDepth+5)
+
+	// Pruning is disabled by default
+	err := v.pruneBlocks()
+	require.NoError(t, err)
+	lowest, err := v.LowestBlockSeq()
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), lowest)
+
+	v.Config.PruneDepth = MinPruneDepth
+	err = v.pruneBlocks()
+	require.NoError(t, err)
+
+	lowest, err = v.LowestBlockSeq()
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), lowest)
+
+	// Pruning again is a no-op
+	err = v.pruneBlocks()
+	require.NoError(t, err)
+	lowest, err = v.LowestBlockSeq()
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), lowest)
+
+	// The genesis block and the most recent blocks are available
+	for _, seq := range []uint64{0, 6, MinPruneDepth + 5} {
+		b, err := v.GetSignedBlockBySeq(seq)
+		require.NoError(t, err)
+		require.NotNil(t, b)
+		require.Equal(t, seq, b.Seq())
+	}
+
+	// The bodies of the pruned blocks are not available
+	for seq := uint64(1); seq < 6; seq++ {
+		_, err := v.GetSignedBlockBySeq(seq)
+		require.Equal(t, blockdb.NewErrBlockPruned(seq), err)
+	}
+
+	// The headers of all blocks are available
+	headers, err := v.GetSignedBlockHeadersSince(0, 10)
+	require.NoError(t, err)
+	require.Len(t, headers, 10)
+	for i, h := range headers {
+		require.Equal(t, uint64(i+1), h.Header.BkSeq)
+	}
+
+	headers, err = v.GetSignedBlockHeadersSince(MinPruneDepth+4, 10)
+	require.NoError(t, err)
+	require.Len(t, headers, 1)
+
+	// The unspent pool is unaffected
+	meta, err := v.GetBlockchainMetadata()
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), meta.LowestBlockSeq)
+	require.Equal(t, uint64(2), meta.Unspents)
+
+	// Executing a new block prunes the block which becomes deeper than PruneDepth
+	addPruneTestBlocks(t, v, 1)
+
+	lowest, err = v.LowestBlockSeq()
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), lowest)
+
+	err = CheckDatabase(db, genPublic, nil)
+	require.NoError(t, err)
+}
+
+func TestInitHistoryMigrateTxnIndexesPruned(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	v := makeBlocksFileVisor(t, db, 0)
+	addPruneTestBlocks(t, v, MinPruneDepth+5)
+
+	v.Config.PruneDepth = MinPruneDepth
+	err := v.pruneBlocks()
+	require.NoError(t, err)
+
+	bc := v.blockchain.(*Blockchain)
+	history := v.history.(*historydb.HistoryDB)
+
+	var byTime, byValue []historydb.TxnIndexItem
+	err = db.View("", func(tx *dbutil.Tx) error {
+		var err error
+		byTime, err = history.GetTransactionsByTime(tx, 0, math.MaxUint64)
+		if err != nil {
+			return err
+		}
+		byValue, err = history.GetTransactionsByValue(tx, 0, math.MaxUint64)
+		return err
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, byTime)
+
+	// A database created before the transaction indexes were added has empty index buckets.
+	// They are backfilled in place in the background, since the history can't be reset after the blocks are pruned
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		if err := dbutil.Reset(tx, historydb.TxnsByTimeBkt); err != nil {
+			return err
+		}
+		if err := dbutil.Reset(tx, historydb.TxnsByValueBkt); err != nil {
+			return err
+		}
+		return initHistory(tx, bc, history)
+	})
+	require.NoError(t, err)
+
+	s, err := v.GetHistoryIndexStatus()
+	require.NoError(t, err)
+	require.True(t, s.Indexing)
+
+	err = db.View("", func(tx *dbutil.Tx) error {
+		_, err := history.GetTransactionsByTime(tx, 0, math.MaxUint64)
+		return err
+	})
+	require.IsType(t, historydb.ErrHistoryIndexing{}, err)
+
+	// An interrupted backfill is resumed without emptying the indexes
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		done, err := history.MigrateTxnIndexes(tx, 1, func(seq uint64) (uint64, error) {
+			h, _, err := bc.GetSignedBlockHeaderBySeq(tx, seq)
+			if err != nil {
+				return 0, err
+			}
+			return h.Time, nil
+		})
+		require.False(t, done)
+		if err != nil {
+			return err
+		}
+		return initHistory(tx, bc, history)
+	})
+	require.NoError(t, err)
+
+	err = db.View("", func(tx *dbutil.Tx) error {
+		n, err := dbutil.Len(tx, historydb.TxnsByTimeBkt)
+		require.Equal(t, uint64(1), n)
+		return err
+	})
+	require.NoError(t, err)
+
+	v.Config.HistoryRebuildBatchSize = 2
+	err = v.RebuildHistory(make(chan struct{}))
+	require.NoError(t, err)
+
+	s, err = v.GetHistoryIndexStatus()
+	require.NoError(t, err)
+	require.False(t, s.Indexing)
+
+	err = db.View("", func(tx *dbutil.Tx) error {
+		rebuilding, err := history.Rebuilding(tx)
+		require.NoError(t, err)
+		require.False(t, rebuilding)
+
+		items, err := history.GetTransactionsByTime(tx, 0, math.MaxUint64)
+		require.NoError(t, err)
+		require.Equal(t, byTime, items)
+
+		items, err = history.GetTransactionsByValue(tx, 0, math.MaxUint64)
+		require.NoError(t, err)
+		require.Equal(t, byValue, items)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestConfigVerifyPruneDepth(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Distribution = params.MainNetDistribution
+
+	for _, tc := range []struct {
+		depth uint64
+		err   bool
+	}{
+		{0, false},
+		{1, true},
+		{MinPruneDepth - 1, true},
+		{MinPruneDepth, false},
+		{MinPruneDepth * 10, false},
+	} {
+		cfg.PruneDepth = tc.depth
+		err := cfg.Verify()
+		if tc.err {
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "PruneDepth must be 0 or >=")
+		} else {
+			require.NoError(t, err)
+		}
+	}
+}
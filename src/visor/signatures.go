@@ -0,0 +1,326 @@
+package visor
+
+import (
+	"container/list"
+	"errors"
+	"runtime"
+	"sync"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/transaction"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+// DefaultSignatureCacheSize is the default number of verified input signatures kept by the signature cache
+const DefaultSignatureCacheSize = 100000
+
+var errMalformedSignedTxn = errors.New("Transaction inputs, signatures and spent outputs do not match")
+
+// SignatureCacheStats are the usage statistics of the signature cache
+type SignatureCacheStats struct {
+	// Maximum number of verified input signatures kept by the cache, 0 if the cache is disabled
+	Capacity int
+	// Number of verified input signatures in the cache
+	Size int
+	// Number of input signatures found in the cache, that were not verified again
+	Hits uint64
+	// Number of input signatures not found in the cache, that were verified
+	Misses uint64
+}
+
+// HitRate returns the ratio of the input signatures that were found in the cache, 0 if there was no lookup
+func (s SignatureCacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// sigCacheKey identifies the signature of an input of a transaction.
+// The signed hash is cipher.AddSHA256(innerHash, input), and the input is the hash of the spent output,
+// including the output's address. The validity of a signature therefore only depends on the key and the signature.
+type sigCacheKey struct {
+	innerHash cipher.SHA256
+	input     cipher.SHA256
+}
+
+type sigCacheEntry struct {
+	key sigCacheKey
+	sig cipher.Sig
+}
+
+// sigCache is a bounded LRU cache of the verified input signatures of transactions,
+// shared by the verification of unconfirmed transactions and the execution of blocks.
+// The entries of a transaction are removed once it is executed in a block, since its inputs are spent.
+// A nil *sigCache is a disabled cache that verifies every signature.
+type sigCache struct {
+	sync.Mutex
+	size    int
+	entries map[sigCacheKey]*list.Element
+	// lru holds the entries from the most to the least recently used
+	lru    *list.List
+	hits   uint64
+	misses uint64
+}
+
+// newSigCache creates a sigCache of size entries. Returns nil if size is 0, which disables the cache
+func newSigCache(size int) *sigCache {
+	if size <= 0 {
+		return nil
+	}
+
+	return &sigCache{
+		size:    size,
+		entries: make(map[sigCacheKey]*list.Element, size),
+		lru:     list.New(),
+	}
+}
+
+// verifyInputSignatures is txn.VerifyInputSignatures(uxIn) that only verifies the signatures which are not in the cache,
+// and caches the valid signatures. Signatures are verified outside of the cache lock, so it can be called concurrently.
+// Returns errMalformedSignedTxn, instead of panicking, if the transaction's inputs, signatures and spent outputs don't match
+func (c *sigCache) verifyInputSignatures(txn coin.Transaction, uxIn coin.UxArray) error {
+	if len(txn.In) != len(txn.Sigs) || len(txn.In) != len(uxIn) {
+		return errMalformedSignedTxn
+	}
+	for i := range txn.In {
+		if txn.In[i] != uxIn[i].Hash() {
+			return errMalformedSignedTxn
+		}
+	}
+
+	if c == nil {
+		return txn.VerifyInputSignatures(uxIn)
+	}
+
+	for i := range txn.In {
+		key := sigCacheKey{
+			innerHash: txn.InnerHash,
+			input:     txn.In[i],
+		}
+
+		if c.get(key, txn.Sigs[i]) {
+			continue
+		}
+
+		if txn.Sigs[i].Null() {
+			return errors.New("Unsigned input in transaction")
+		}
+
+		hash := cipher.AddSHA256(txn.InnerHash, txn.In[i])
+		if err := cipher.VerifyAddressSignedHash(uxIn[i].Body.Address, txn.Sigs[i], hash); err != nil {
+			return errors.New("Signature not valid for output being spent")
+		}
+
+		c.add(key, txn.Sigs[i])
+	}
+
+	return nil
+}
+
+// get returns true if the signature of the input was verified, and marks it as recently used
+func (c *sigCache) get(key sigCacheKey, sig cipher.Sig) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || e.Value.(*sigCacheEntry).sig != sig {
+		c.misses++
+		return false
+	}
+
+	c.hits++
+	c.lru.MoveToFront(e)
+	return true
+}
+
+// add records that the signature of the input is valid, evicting the least recently used signature if the cache is full
+func (c *sigCache) add(key sigCacheKey, sig cipher.Sig) {
+	c.Lock()
+	defer c.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*sigCacheEntry).sig = sig
+		c.lru.MoveToFront(e)
+		return
+	}
+
+	if c.lru.Len() >= c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*sigCacheEntry).key)
+	}
+
+	c.entries[key] = c.lru.PushFront(&sigCacheEntry{
+		key: key,
+		sig: sig,
+	})
+}
+
+// removeTxns removes the input signatures of transactions whose inputs were spent
+func (c *sigCache) removeTxns(txns coin.Transactions) {
+	if c == nil {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	for _, txn := range txns {
+		for _, in := range txn.In {
+			key := sigCacheKey{
+				innerHash: txn.InnerHash,
+				input:     in,
+			}
+
+			if e, ok := c.entries[key]; ok {
+				c.lru.Remove(e)
+				delete(c.entries, key)
+			}
+		}
+	}
+}
+
+// stats returns the usage statistics of the cache
+func (c *sigCache) stats() SignatureCacheStats {
+	if c == nil {
+		return SignatureCacheStats{}
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	return SignatureCacheStats{
+		Capacity: c.size,
+		Size:     c.lru.Len(),
+		Hits:     c.hits,
+		Misses:   c.misses,
+	}
+}
+
+// sigsJob is a transaction whose input signatures are verified against the outputs that it spends
+type sigsJob struct {
+	txn  coin.Transaction
+	uxIn coin.UxArray
+}
+
+// verifySignatures verifies the input signatures of the transactions with a pool of workers,
+// and caches the valid signatures
+func verifySignatures(c *sigCache, jobs []sigsJob, workers int) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(jobs) {
+		workers = len(jobs)
+	}
+
+	jobsC := make(chan sigsJob)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for j := range jobsC {
+				// Invalid signatures are reported when the transaction is verified again
+				c.verifyInputSignatures(j.txn, j.uxIn) //nolint:errcheck
+			}
+		}()
+	}
+
+	for _, j := range jobs {
+		jobsC <- j
+	}
+	close(jobsC)
+
+	wg.Wait()
+}
+
+// VerifyBlockSignatures verifies the input signatures of the transactions of consecutive blocks
+// that follow the head block, with a pool of workers, and caches the valid signatures.
+// The cached signatures are not verified again when the blocks are executed, so the verification
+// does not need to happen inside the write transaction that executes the blocks.
+// Invalid signatures are not reported, they are reported when the block that includes them is executed.
+// Transactions whose inputs can't be found are skipped.
+func (bc Blockchain) VerifyBlockSignatures(tx *dbutil.Tx, blocks []coin.SignedBlock) error {
+	if bc.sigs == nil {
+		return nil
+	}
+
+	// The outputs created by the blocks, which are spent by the transactions of the later blocks
+	created := make(map[cipher.SHA256]coin.UxOut)
+
+	var jobs []sigsJob
+	for _, b := range blocks {
+		for _, txn := range b.Block.Body.Transactions {
+			uxIn, err := bc.sigsJobInputs(tx, txn, created)
+			if err != nil {
+				return err
+			}
+
+			if uxIn != nil {
+				jobs = append(jobs, sigsJob{
+					txn:  txn,
+					uxIn: uxIn,
+				})
+			}
+
+			for _, ux := range coin.CreateUnspents(b.Block.Head, txn) {
+				created[ux.Hash()] = ux
+			}
+		}
+	}
+
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	verifySignatures(bc.sigs, jobs, bc.cfg.SignatureVerifyWorkers)
+
+	return nil
+}
+
+// sigsJobInputs returns the outputs spent by a transaction, from the outputs created by the preceding blocks
+// or from the unspent pool. Returns nil if any of the outputs is not found
+func (bc Blockchain) sigsJobInputs(tx *dbutil.Tx, txn coin.Transaction, created map[cipher.SHA256]coin.UxOut) (coin.UxArray, error) {
+	uxIn := make(coin.UxArray, len(txn.In))
+	for i, h := range txn.In {
+		if ux, ok := created[h]; ok {
+			uxIn[i] = ux
+			continue
+		}
+
+		ux, err := bc.Unspent().Get(tx, h)
+		if err != nil {
+			return nil, err
+		}
+		if ux == nil {
+			return nil, nil
+		}
+
+		uxIn[i] = *ux
+	}
+
+	return uxIn, nil
+}
+
+// txnSignedFlag verifies the input signatures of a signed transaction with the signature cache.
+// Returns TxnSignedVerified if they are valid, so that the transaction verification does not verify them again.
+// Otherwise returns TxnSigned, so that the transaction verification reports the invalid signature
+func (bc Blockchain) txnSignedFlag(txn coin.Transaction, uxIn coin.UxArray) transaction.TxnSignedFlag {
+	if bc.sigs == nil {
+		return transaction.TxnSigned
+	}
+
+	if err := bc.sigs.verifyInputSignatures(txn, uxIn); err != nil {
+		return transaction.TxnSigned
+	}
+
+	return transaction.TxnSignedVerified
+}
+
+// SignatureCacheStats returns the usage statistics of the signature cache
+func (bc Blockchain) SignatureCacheStats() SignatureCacheStats {
+	return bc.sigs.stats()
+}
@@ -0,0 +1,318 @@
+package visor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/testutil"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+func TestSigCache(t *testing.T) {
+	keys := make([]sigCacheKey, 5)
+	sigs := make([]cipher.Sig, 5)
+	for i := range keys {
+		keys[i] = sigCacheKey{
+			innerHash: testutil.RandSHA256(t),
+			input:     testutil.RandSHA256(t),
+		}
+		sigs[i] = testutil.RandSig(t)
+	}
+
+	c := newSigCache(3)
+	for i := range keys[:3] {
+		c.add(keys[i], sigs[i])
+	}
+	for i := range keys[:3] {
+		require.True(t, c.get(keys[i], sigs[i]))
+	}
+
+	// The signature must match
+	require.False(t, c.get(keys[0], sigs[1]))
+
+	// The least recently used signatures are evicted
+	require.True(t, c.get(keys[0], sigs[0]))
+	c.add(keys[3], sigs[3])
+	c.add(keys[4], sigs[4])
+	require.True(t, c.get(keys[0], sigs[0]))
+	require.False(t, c.get(keys[1], sigs[1]))
+	require.False(t, c.get(keys[2], sigs[2]))
+	require.True(t, c.get(keys[3], sigs[3]))
+	require.True(t, c.get(keys[4], sigs[4]))
+
+	require.Equal(t, SignatureCacheStats{
+		Capacity: 3,
+		Size:     3,
+		Hits:     7,
+		Misses:   3,
+	}, c.stats())
+	require.Equal(t, 0.7, c.stats().HitRate())
+
+	// A size of 0 disables the cache
+	require.Nil(t, newSigCache(0))
+	var nc *sigCache
+	nc.removeTxns(nil)
+	require.Equal(t, SignatureCacheStats{}, nc.stats())
+	require.Equal(t, float64(0), nc.stats().HitRate())
+}
+
+func TestSigCacheVerifyInputSignatures(t *testing.T) {
+	var uxs coin.UxArray
+	for i := 0; i < 2; i++ {
+		uxs = append(uxs, coin.UxOut{
+			Body: coin.UxBody{
+				SrcTransaction: testutil.RandSHA256(t),
+				Address:        GenesisAddress,
+				Coins:          1e6,
+			},
+		})
+	}
+
+	var txn coin.Transaction
+	for _, ux := range uxs {
+		require.NoError(t, txn.PushInput(ux.Hash()))
+	}
+	require.NoError(t, txn.PushOutput(GenesisAddress, 2e6, 0))
+	txn.SignInputs([]cipher.SecKey{GenesisSecret, GenesisSecret})
+	require.NoError(t, txn.UpdateHeader())
+
+	for _, c := range []*sigCache{nil, newSigCache(10)} {
+		require.NoError(t, c.verifyInputSignatures(txn, uxs))
+		require.NoError(t, c.verifyInputSignatures(txn, uxs))
+
+		// Malformed transactions are reported instead of panicking
+		require.Equal(t, errMalformedSignedTxn, c.verifyInputSignatures(txn, uxs[:1]))
+		require.Equal(t, errMalformedSignedTxn, c.verifyInputSignatures(txn, coin.UxArray{uxs[1], uxs[0]}))
+
+		// A cached input signature is not valid for another signature
+		badTxn := txn
+		badTxn.Sigs = []cipher.Sig{txn.Sigs[0], cipher.MustSignHash(testutil.RandSHA256(t), GenesisSecret)}
+		testutil.RequireError(t, c.verifyInputSignatures(badTxn, uxs), "Signature not valid for output being spent")
+
+		badTxn.Sigs = []cipher.Sig{txn.Sigs[0], {}}
+		testutil.RequireError(t, c.verifyInputSignatures(badTxn, uxs), "Unsigned input in transaction")
+	}
+
+	c := newSigCache(10)
+	require.NoError(t, c.verifyInputSignatures(txn, uxs))
+	require.NoError(t, c.verifyInputSignatures(txn, uxs))
+	require.Equal(t, SignatureCacheStats{
+		Capacity: 10,
+		Size:     2,
+		Hits:     2,
+		Misses:   2,
+	}, c.stats())
+
+	// The signatures of the transactions whose inputs are spent are removed
+	c.removeTxns(coin.Transactions{txn})
+	require.Equal(t, 0, c.stats().Size)
+}
+
+// newSigsTestBlockchain creates a blockchain with the genesis block of MakeBlockchain in an in-memory database
+func newSigsTestBlockchain(tb testing.TB, workers int) (*dbutil.DB, *Blockchain) {
+	db := dbutil.NewDB(dbutil.NewMemoryBackend())
+	require.NoError(tb, CreateBuckets(db))
+
+	bc, err := NewBlockchain(db, BlockchainConfig{
+		Pubkey:                 GenesisPublic,
+		SignatureVerifyWorkers: workers,
+		SignatureCacheSize:     DefaultSignatureCacheSize,
+	})
+	require.NoError(tb, err)
+
+	gb, err := coin.NewGenesisBlock(GenesisAddress, GenesisCoins, GenesisTime)
+	require.NoError(tb, err)
+
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		return bc.ExecuteBlock(tx, &coin.SignedBlock{
+			Block: *gb,
+			Sig:   cipher.MustSignHash(gb.HashHeader(), GenesisSecret),
+		})
+	})
+	require.NoError(tb, err)
+
+	return db, bc
+}
+
+// makeSigsTestChain creates a chain of nBlocks blocks that follow the genesis block of newSigsTestBlockchain.
+// The first block splits the genesis coins into outputs of sigsTestCoins, the next blocks have txnsPerBlock transactions
+// that spend inputsPerTxn of these outputs each
+const sigsTestCoins = 1e5
+
+func makeSigsTestChain(tb testing.TB, nBlocks, txnsPerBlock, inputsPerTxn int) []coin.SignedBlock {
+	db, bc := newSigsTestBlockchain(tb, 0)
+
+	nOutputs := (nBlocks - 1) * txnsPerBlock * inputsPerTxn
+	require.True(tb, uint64(nOutputs)*sigsTestCoins <= GenesisCoins)
+
+	var blocks []coin.SignedBlock
+	addBlock := func(txns coin.Transactions) coin.SignedBlock {
+		var sb coin.SignedBlock
+		err := db.Update("", func(tx *dbutil.Tx) error {
+			head, err := bc.Head(tx)
+			require.NoError(tb, err)
+
+			b, err := bc.NewBlock(tx, txns, head.Time()+TimeIncrement)
+			require.NoError(tb, err)
+
+			sb = coin.SignedBlock{
+				Block: *b,
+				Sig:   cipher.MustSignHash(b.HashHeader(), GenesisSecret),
+			}
+			return bc.ExecuteBlock(tx, &sb)
+		})
+		require.NoError(tb, err)
+
+		blocks = append(blocks, sb)
+		return sb
+	}
+
+	// Split the genesis output
+	var genesisUx coin.UxOut
+	err := db.View("", func(tx *dbutil.Tx) error {
+		uxs, err := bc.Unspent().GetAll(tx)
+		require.NoError(tb, err)
+		require.Len(tb, uxs, 1)
+		genesisUx = uxs[0]
+		return nil
+	})
+	require.NoError(tb, err)
+
+	var splitTxn coin.Transaction
+	require.NoError(tb, splitTxn.PushInput(genesisUx.Hash()))
+	for i := 0; i < nOutputs; i++ {
+		// Outputs must differ, the genesis output has enough hours to give each a different number of hours
+		require.NoError(tb, splitTxn.PushOutput(GenesisAddress, sigsTestCoins, uint64(i)))
+	}
+	if change := genesisUx.Body.Coins - uint64(nOutputs)*sigsTestCoins; change > 0 {
+		require.NoError(tb, splitTxn.PushOutput(GenesisAddress, change, uint64(nOutputs)))
+	}
+	splitTxn.SignInputs([]cipher.SecKey{GenesisSecret})
+	require.NoError(tb, splitTxn.UpdateHeader())
+
+	sb := addBlock(coin.Transactions{splitTxn})
+	uxs := coin.CreateUnspents(sb.Head, splitTxn)[:nOutputs]
+
+	// Spend the split outputs
+	for i := 1; i < nBlocks; i++ {
+		txns := make(coin.Transactions, txnsPerBlock)
+		for j := range txns {
+			var txn coin.Transaction
+			keys := make([]cipher.SecKey, inputsPerTxn)
+			for k := range keys {
+				require.NoError(tb, txn.PushInput(uxs[0].Hash()))
+				keys[k] = GenesisSecret
+				uxs = uxs[1:]
+			}
+			require.NoError(tb, txn.PushOutput(GenesisAddress, uint64(inputsPerTxn)*sigsTestCoins, 0))
+			txn.SignInputs(keys)
+			require.NoError(tb, txn.UpdateHeader())
+			txns[j] = txn
+		}
+
+		addBlock(txns)
+	}
+
+	return blocks
+}
+
+func TestBlockchainVerifyBlockSignatures(t *testing.T) {
+	blocks := makeSigsTestChain(t, 3, 2, 2)
+
+	// A copy of a transaction with a signature of the wrong hash
+	badTxn := blocks[2].Body.Transactions[0]
+	badTxn.Sigs = append([]cipher.Sig{}, badTxn.Sigs...)
+	badTxn.Sigs[0] = cipher.MustSignHash(testutil.RandSHA256(t), GenesisSecret)
+	require.NoError(t, badTxn.UpdateHeader())
+
+	// A transaction that spends an unknown output
+	var unknownTxn coin.Transaction
+	require.NoError(t, unknownTxn.PushInput(testutil.RandSHA256(t)))
+	require.NoError(t, unknownTxn.PushOutput(GenesisAddress, 1e6, 0))
+	unknownTxn.SignInputs([]cipher.SecKey{GenesisSecret})
+	require.NoError(t, unknownTxn.UpdateHeader())
+
+	badBlock := blocks[2]
+	badBlock.Body.Transactions = coin.Transactions{badTxn, unknownTxn}
+
+	db, bc := newSigsTestBlockchain(t, 2)
+
+	err := db.View("", func(tx *dbutil.Tx) error {
+		return bc.VerifyBlockSignatures(tx, append(blocks, badBlock))
+	})
+	require.NoError(t, err)
+
+	requireCached := func(txn coin.Transaction, i int, cached bool) {
+		e, ok := bc.sigs.entries[sigCacheKey{
+			innerHash: txn.InnerHash,
+			input:     txn.In[i],
+		}]
+		require.Equal(t, cached, ok && e.Value.(*sigCacheEntry).sig == txn.Sigs[i])
+	}
+
+	// The transactions of the later blocks spend the outputs of the earlier blocks
+	require.Equal(t, 9, bc.sigs.stats().Size)
+	for _, b := range blocks {
+		for _, txn := range b.Body.Transactions {
+			for i := range txn.In {
+				requireCached(txn, i, true)
+			}
+		}
+	}
+	requireCached(badTxn, 0, false)
+	requireCached(unknownTxn, 0, false)
+
+	// The blocks are executed with the cached signatures, which are removed once their inputs are spent
+	stats := bc.sigs.stats()
+	for i := range blocks {
+		err := db.Update("", func(tx *dbutil.Tx) error {
+			return bc.ExecuteBlock(tx, &blocks[i])
+		})
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, SignatureCacheStats{
+		Capacity: DefaultSignatureCacheSize,
+		Size:     0,
+		Hits:     stats.Hits + 9,
+		Misses:   stats.Misses,
+	}, bc.sigs.stats())
+}
+
+// benchmarkExecuteBlocks executes a chain of large blocks on a new blockchain in each iteration.
+// If parallel is true, the signatures of all the blocks are verified by VerifyBlockSignatures before the blocks are executed
+func benchmarkExecuteBlocks(b *testing.B, parallel bool) {
+	blocks := makeSigsTestChain(b, 11, 50, 4)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		db, bc := newSigsTestBlockchain(b, 0)
+		b.StartTimer()
+
+		if parallel {
+			err := db.View("", func(tx *dbutil.Tx) error {
+				return bc.VerifyBlockSignatures(tx, blocks)
+			})
+			require.NoError(b, err)
+		}
+
+		for j := range blocks {
+			err := db.Update("", func(tx *dbutil.Tx) error {
+				return bc.ExecuteBlock(tx, &blocks[j])
+			})
+			require.NoError(b, err)
+		}
+	}
+}
+
+func BenchmarkExecuteBlocksSerialSignatures(b *testing.B) {
+	benchmarkExecuteBlocks(b, false)
+}
+
+func BenchmarkExecuteBlocksParallelSignatures(b *testing.B) {
+	benchmarkExecuteBlocks(b, true)
+}
@@ -0,0 +1,843 @@
+package visor
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/visor/blockdb"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+	"github.com/skycoin/skycoin/src/visor/historydb"
+)
+
+const (
+	// SnapshotVersion is the version of the snapshot file format
+	SnapshotVersion = 2
+
+	// maxSnapshotItemSize is the maximum size of an encoded block or address index entry in a snapshot
+	maxSnapshotItemSize = 32 * 1024 * 1024
+
+	// snapshotProgressInterval is the number of headers or outputs between progress logs and quit checks
+	snapshotProgressInterval = 100000
+)
+
+// snapshotMagic is written at the start of a snapshot file
+var snapshotMagic = [8]byte{'S', 'K', 'Y', 'S', 'N', 'A', 'P', 'S'}
+
+// snapshotOutputsKey is the MetaBkt key of the imported snapshot outputs, which are checked against
+// the backfilled blocks once all pruned blocks are backfilled
+var snapshotOutputsKey = []byte("snapshot_outputs")
+
+var (
+	// ErrSnapshotInvalid is returned when a file is not a snapshot file
+	ErrSnapshotInvalid = errors.New("Not a snapshot file")
+	// ErrSnapshotVersion is returned when a snapshot file has an unsupported version
+	ErrSnapshotVersion = errors.New("Unsupported snapshot file version")
+	// ErrSnapshotChecksum is returned when the header checksum of a snapshot file does not match
+	ErrSnapshotChecksum = errors.New("Snapshot file header checksum mismatch")
+	// ErrSnapshotCorrupt is returned when the content of a snapshot file cannot be decoded or is out of order
+	ErrSnapshotCorrupt = errors.New("Snapshot file is corrupt")
+	// ErrSnapshotTruncated is returned when a snapshot file ends before the content described by its header
+	ErrSnapshotTruncated = errors.New("Snapshot file is truncated")
+	// ErrSnapshotPubkeyMismatch is returned when a snapshot file is for a different blockchain pubkey
+	ErrSnapshotPubkeyMismatch = errors.New("Snapshot file blockchain pubkey does not match")
+	// ErrSnapshotGenesisMismatch is returned when a snapshot file is for a different genesis block
+	ErrSnapshotGenesisMismatch = errors.New("Snapshot file genesis block does not match")
+	// ErrSnapshotInvalidSignature is returned when a block header of a snapshot is not signed by the blockchain pubkey
+	ErrSnapshotInvalidSignature = errors.New("Snapshot block header signature is invalid")
+	// ErrSnapshotHeadersNotContiguous is returned when the block headers of a snapshot do not form a chain
+	ErrSnapshotHeadersNotContiguous = errors.New("Snapshot block headers are not contiguous")
+	// ErrSnapshotUxHashMismatch is returned when the unspent outputs of a snapshot do not match the UxHash of the snapshot block
+	ErrSnapshotUxHashMismatch = errors.New("Snapshot unspent outputs do not match the block UxHash")
+	// ErrSnapshotOutputsHashMismatch is returned when the unspent outputs of a snapshot do not match the OutputsHash of its header
+	ErrSnapshotOutputsHashMismatch = errors.New("Snapshot unspent outputs do not match the header outputs hash")
+	// ErrSnapshotAddressIndexMismatch is returned when the address index of a snapshot does not match its unspent outputs
+	ErrSnapshotAddressIndexMismatch = errors.New("Snapshot address index does not match the unspent outputs")
+	// ErrSnapshotForked is returned when the blocks of a snapshot differ from the blocks of the same seq in the blockchain
+	ErrSnapshotForked = errors.New("Snapshot does not match the blockchain")
+	// ErrSnapshotOutputsMismatch is returned when the unspent outputs of a snapshot differ from the unspent outputs computed from the blockchain
+	ErrSnapshotOutputsMismatch = errors.New("Snapshot unspent outputs do not match the blockchain")
+	// ErrSnapshotBackfillMismatch is returned when the unspent outputs imported from a snapshot differ from the unspent outputs
+	// computed from the backfilled blocks. The snapshot was forged or corrupt, and the database must be recreated
+	ErrSnapshotBackfillMismatch = errors.New("Imported snapshot unspent outputs do not match the backfilled blocks")
+	// ErrSnapshotChainNotEmpty is returned when importing a snapshot into a blockchain that has blocks after the genesis block
+	ErrSnapshotChainNotEmpty = errors.New("A snapshot can only be imported into a blockchain without blocks after the genesis block")
+	// ErrSnapshotStopped is returned when a snapshot export, import or verification is interrupted
+	ErrSnapshotStopped = errors.New("snapshot processing stopped")
+)
+
+// SnapshotHeader is the header of a snapshot file. A snapshot is the unspent output set of the blockchain
+// before the block of seq Seq is executed, whose XOR hash is the UxHash of the signed header of that block.
+// A node bootstraps from a snapshot without downloading and executing the blocks before Seq.
+//
+// The XOR hash is not collision-resistant, so the signed UxHash does not prove that the outputs are authentic,
+// and a snapshot must come from a trusted source. OutputsHash is the SHA256 hash of the outputs, which can be
+// compared with the hash published by that source. A node that imported a snapshot checks the outputs
+// against the blocks before Seq once it has backfilled them, and shuts down if they differ.
+//
+// The file is the 8 byte magic "SKYSNAPS", the encoded header, the SHA256 checksum of the encoded header,
+// the genesis block, the signed headers of the blocks from 1 to Seq-1, the block of seq Seq,
+// the unspent outputs sorted by hash and the address index of the unspent outputs sorted by address.
+// Blocks and address index entries are encoded with a 4 byte little-endian length prefix
+type SnapshotHeader struct {
+	Version        uint32
+	GenesisHash    cipher.SHA256
+	Pubkey         cipher.PubKey
+	Seq            uint64
+	UxHash         cipher.SHA256
+	OutputsHash    cipher.SHA256
+	OutputsCount   uint64
+	AddressesCount uint64
+}
+
+// snapshotOutputs records the outputs imported from a snapshot until they are checked against the backfilled blocks
+type snapshotOutputs struct {
+	Seq         uint64
+	OutputsHash cipher.SHA256
+}
+
+// SnapshotAddress is an entry of the address index of a snapshot
+type SnapshotAddress struct {
+	Address cipher.Address
+	Hashes  []cipher.SHA256
+}
+
+// Snapshot is the content of a snapshot file
+type Snapshot struct {
+	Header    SnapshotHeader
+	Genesis   coin.SignedBlock
+	Headers   []SignedBlockHeader
+	Block     coin.SignedBlock
+	Outputs   coin.UxArray
+	Addresses []SnapshotAddress
+}
+
+// writeSnapshotHeader writes the magic, the header and its checksum
+func writeSnapshotHeader(w io.Writer, hdr SnapshotHeader) error {
+	b := encoder.Serialize(hdr)
+	checksum := cipher.SumSHA256(b)
+
+	var buf bytes.Buffer
+	buf.Write(snapshotMagic[:])
+	buf.Write(b)
+	buf.Write(checksum[:])
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// readSnapshotHeader reads the header of a snapshot file and verifies its checksum
+func readSnapshotHeader(r io.Reader) (SnapshotHeader, error) {
+	var magic [8]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return SnapshotHeader{}, ErrSnapshotInvalid
+		}
+		return SnapshotHeader{}, err
+	}
+	if magic != snapshotMagic {
+		return SnapshotHeader{}, ErrSnapshotInvalid
+	}
+
+	b := make([]byte, encoder.Size(SnapshotHeader{}))
+	var checksum cipher.SHA256
+	if _, err := io.ReadFull(r, b); err != nil {
+		return SnapshotHeader{}, ErrSnapshotInvalid
+	}
+	if _, err := io.ReadFull(r, checksum[:]); err != nil {
+		return SnapshotHeader{}, ErrSnapshotInvalid
+	}
+
+	if cipher.SumSHA256(b) != checksum {
+		return SnapshotHeader{}, ErrSnapshotChecksum
+	}
+
+	var hdr SnapshotHeader
+	if err := encoder.DeserializeRawExact(b, &hdr); err != nil {
+		return SnapshotHeader{}, ErrSnapshotInvalid
+	}
+
+	if hdr.Version != SnapshotVersion {
+		return SnapshotHeader{}, ErrSnapshotVersion
+	}
+
+	if hdr.Seq == 0 {
+		return SnapshotHeader{}, ErrSnapshotInvalid
+	}
+
+	return hdr, nil
+}
+
+// writeSnapshotItem writes a length prefixed encoded object
+func writeSnapshotItem(w io.Writer, obj interface{}) error {
+	data := encoder.Serialize(obj)
+	if _, err := w.Write(encoder.SerializeUint32(uint32(len(data)))); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// readSnapshotItem reads a length prefixed encoded object into obj
+func readSnapshotItem(r io.Reader, obj interface{}) error {
+	var prefix [4]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return ErrSnapshotTruncated
+	}
+
+	n, _, err := encoder.DeserializeUint32(prefix[:])
+	if err != nil || n > maxSnapshotItemSize {
+		return ErrSnapshotCorrupt
+	}
+
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return ErrSnapshotTruncated
+	}
+
+	if err := encoder.DeserializeRawExact(data, obj); err != nil {
+		return ErrSnapshotCorrupt
+	}
+
+	return nil
+}
+
+// snapshotAddresses returns the address index of unspent outputs sorted by hash, sorted by address
+func snapshotAddresses(uxs coin.UxArray) []SnapshotAddress {
+	index := make(map[cipher.Address][]cipher.SHA256)
+	for _, ux := range uxs {
+		index[ux.Body.Address] = append(index[ux.Body.Address], ux.Hash())
+	}
+
+	addrs := make([]SnapshotAddress, 0, len(index))
+	for addr, hashes := range index {
+		addrs = append(addrs, SnapshotAddress{
+			Address: addr,
+			Hashes:  hashes,
+		})
+	}
+
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i].Address.Bytes(), addrs[j].Address.Bytes()) < 0
+	})
+
+	return addrs
+}
+
+// snapshotUnspents returns the unspent outputs before the block of given seq is executed, sorted by hash.
+// The outputs are computed by reverting the blocks after seq from the unspent pool,
+// using the history of the outputs spent by these blocks, so the blocks must not be pruned
+func snapshotUnspents(tx *dbutil.Tx, bc *Blockchain, history Historyer, seq uint64, quit chan struct{}) (coin.UxArray, error) {
+	headSeq, ok, err := bc.HeadSeq(tx)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNoBlocks
+	}
+
+	uxs, err := bc.Unspent().GetAll(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := make(map[cipher.SHA256]coin.UxOut, len(uxs))
+	for _, ux := range uxs {
+		pool[ux.Hash()] = ux
+	}
+
+	for i := headSeq; i >= seq; i-- {
+		select {
+		case <-quit:
+			return nil, ErrSnapshotStopped
+		default:
+		}
+
+		b, err := bc.GetSignedBlockBySeq(tx, i)
+		if err != nil {
+			return nil, err
+		}
+		if b == nil {
+			return nil, NewErrBlockNotExist(i)
+		}
+
+		txns := b.Body.Transactions
+		for j := len(txns) - 1; j >= 0; j-- {
+			for _, ux := range coin.CreateUnspents(b.Head, txns[j]) {
+				h := ux.Hash()
+				if _, ok := pool[h]; !ok {
+					return nil, fmt.Errorf("Output %s created by block %d is not unspent", h.Hex(), i)
+				}
+				delete(pool, h)
+			}
+
+			outs, err := history.GetUxOuts(tx, txns[j].In)
+			if err != nil {
+				return nil, fmt.Errorf("Get the inputs of block %d failed: %v", i, err)
+			}
+
+			for _, o := range outs {
+				pool[o.Out.Hash()] = o.Out
+			}
+		}
+	}
+
+	return sortedUnspents(pool), nil
+}
+
+// replayUnspents returns the unspent outputs before the block of given seq is executed, sorted by hash.
+// The outputs are computed by executing the transactions of the blocks before seq from the genesis block,
+// so these blocks must not be pruned. Returns ErrSnapshotBackfillMismatch if a transaction spends an output
+// that is not unspent
+func replayUnspents(tx *dbutil.Tx, bc Blockchainer, seq uint64, quit chan struct{}) (coin.UxArray, error) {
+	pool := make(map[cipher.SHA256]coin.UxOut)
+
+	for i := uint64(0); i < seq; i++ {
+		if i%snapshotProgressInterval == 0 {
+			select {
+			case <-quit:
+				return nil, ErrSnapshotStopped
+			default:
+			}
+		}
+
+		b, err := bc.GetSignedBlockBySeq(tx, i)
+		if err != nil {
+			return nil, err
+		}
+		if b == nil {
+			return nil, NewErrBlockNotExist(i)
+		}
+
+		for _, txn := range b.Body.Transactions {
+			for _, h := range txn.In {
+				if _, ok := pool[h]; !ok {
+					return nil, ErrSnapshotBackfillMismatch
+				}
+				delete(pool, h)
+			}
+
+			for _, ux := range coin.CreateUnspents(b.Head, txn) {
+				pool[ux.Hash()] = ux
+			}
+		}
+	}
+
+	return sortedUnspents(pool), nil
+}
+
+// sortedUnspents returns the outputs of a pool sorted by hash
+func sortedUnspents(pool map[cipher.SHA256]coin.UxOut) coin.UxArray {
+	uxs := make(coin.UxArray, 0, len(pool))
+	for _, ux := range pool {
+		uxs = append(uxs, ux)
+	}
+
+	sort.Slice(uxs, func(i, j int) bool {
+		hi := uxs[i].Hash()
+		hj := uxs[j].Hash()
+		return bytes.Compare(hi[:], hj[:]) < 0
+	})
+
+	return uxs
+}
+
+// uxArrayHash returns the XOR hash of unspent outputs, which is the UxHash of the next block
+func uxArrayHash(uxs coin.UxArray) cipher.SHA256 {
+	var h cipher.SHA256
+	for _, ux := range uxs {
+		h = h.Xor(ux.SnapshotHash())
+	}
+	return h
+}
+
+// snapshotOutputsHash returns the SHA256 hash of the hashes of unspent outputs sorted by hash.
+// Unlike uxArrayHash, it is collision-resistant
+func snapshotOutputsHash(uxs coin.UxArray) cipher.SHA256 {
+	h := sha256.New()
+	for i := range uxs {
+		uh := uxs[i].SnapshotHash()
+		h.Write(uh[:]) // nolint: errcheck
+	}
+	return cipher.MustSHA256FromBytes(h.Sum(nil))
+}
+
+// ExportSnapshot writes a snapshot of the unspent outputs before the block of given seq is executed.
+// If seq is beyond the head block, the snapshot is taken at the head block.
+// The blocks from seq to the head block and their history must not be pruned
+func ExportSnapshot(db *dbutil.DB, pubkey cipher.PubKey, w io.Writer, seq uint64, quit chan struct{}) (*SnapshotHeader, error) {
+	var hdr *SnapshotHeader
+	if err := db.View("ExportSnapshot", func(tx *dbutil.Tx) error {
+		if !dbutil.Exists(tx, blockdb.BlocksBkt) {
+			return ErrNoBlocks
+		}
+
+		bc, err := NewBlockchain(db, BlockchainConfig{Pubkey: pubkey})
+		if err != nil {
+			return err
+		}
+
+		headSeq, ok, err := bc.HeadSeq(tx)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrNoBlocks
+		}
+
+		if seq > headSeq {
+			seq = headSeq
+		}
+		if seq == 0 {
+			return errors.New("A snapshot can't be taken at the genesis block")
+		}
+
+		gb, err := bc.GetGenesisBlock(tx)
+		if err != nil {
+			return err
+		}
+		if gb == nil {
+			return ErrNoBlocks
+		}
+
+		b, err := bc.GetSignedBlockBySeq(tx, seq)
+		if err != nil {
+			return err
+		}
+		if b == nil {
+			return NewErrBlockNotExist(seq)
+		}
+
+		uxs, err := snapshotUnspents(tx, bc, historydb.New(), seq, quit)
+		if err != nil {
+			return err
+		}
+
+		if uxArrayHash(uxs) != b.Head.UxHash {
+			return ErrSnapshotUxHashMismatch
+		}
+
+		addrs := snapshotAddresses(uxs)
+
+		hdr = &SnapshotHeader{
+			Version:        SnapshotVersion,
+			GenesisHash:    gb.HashHeader(),
+			Pubkey:         pubkey,
+			Seq:            seq,
+			UxHash:         b.Head.UxHash,
+			OutputsHash:    snapshotOutputsHash(uxs),
+			OutputsCount:   uint64(len(uxs)),
+			AddressesCount: uint64(len(addrs)),
+		}
+
+		if err := writeSnapshotHeader(w, *hdr); err != nil {
+			return err
+		}
+
+		if err := writeSnapshotItem(w, *gb); err != nil {
+			return err
+		}
+
+		for i := uint64(1); i < seq; i++ {
+			if i%snapshotProgressInterval == 0 {
+				select {
+				case <-quit:
+					return ErrSnapshotStopped
+				default:
+				}
+				logger.Infof("Exported block headers %d/%d", i, seq-1)
+			}
+
+			h, sig, err := bc.GetSignedBlockHeaderBySeq(tx, i)
+			if err != nil {
+				return err
+			}
+			if h == nil {
+				return NewErrBlockNotExist(i)
+			}
+
+			if _, err := w.Write(encoder.Serialize(SignedBlockHeader{
+				Header: *h,
+				Sig:    sig,
+			})); err != nil {
+				return err
+			}
+		}
+
+		if err := writeSnapshotItem(w, *b); err != nil {
+			return err
+		}
+
+		for _, ux := range uxs {
+			if _, err := w.Write(encoder.Serialize(ux)); err != nil {
+				return err
+			}
+		}
+
+		for _, a := range addrs {
+			if err := writeSnapshotItem(w, a); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return hdr, nil
+}
+
+// ReadSnapshot reads a snapshot file and verifies it: the block headers must be signed by pubkey and form a chain
+// from the genesis block to the snapshot block, the XOR hash of the unspent outputs must match the UxHash
+// of the snapshot block and their SHA256 hash must match the OutputsHash of the header.
+// The address index must match the unspent outputs.
+// The XOR hash does not prove that the outputs are authentic, so the snapshot must come from a trusted source
+func ReadSnapshot(r io.Reader, pubkey cipher.PubKey, quit chan struct{}) (*Snapshot, error) {
+	hdr, err := readSnapshotHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if hdr.Pubkey != pubkey {
+		return nil, ErrSnapshotPubkeyMismatch
+	}
+
+	s := &Snapshot{
+		Header: hdr,
+	}
+
+	if err := readSnapshotItem(r, &s.Genesis); err != nil {
+		return nil, err
+	}
+	if s.Genesis.Seq() != 0 || s.Genesis.HashHeader() != hdr.GenesisHash {
+		return nil, ErrSnapshotGenesisMismatch
+	}
+	if err := s.Genesis.VerifySignature(pubkey); err != nil {
+		return nil, ErrSnapshotInvalidSignature
+	}
+
+	prevHash := s.Genesis.HashHeader()
+	buf := make([]byte, encoder.Size(SignedBlockHeader{}))
+	for i := uint64(1); i < hdr.Seq; i++ {
+		if i%snapshotProgressInterval == 0 {
+			select {
+			case <-quit:
+				return nil, ErrSnapshotStopped
+			default:
+			}
+			logger.Infof("Verified block headers %d/%d", i, hdr.Seq-1)
+		}
+
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, ErrSnapshotTruncated
+		}
+
+		var h SignedBlockHeader
+		if err := encoder.DeserializeRawExact(buf, &h); err != nil {
+			return nil, ErrSnapshotCorrupt
+		}
+
+		if h.Header.BkSeq != i || h.Header.PrevHash != prevHash {
+			return nil, ErrSnapshotHeadersNotContiguous
+		}
+
+		prevHash = h.Header.Hash()
+		if err := cipher.VerifyPubKeySignedHash(pubkey, h.Sig, prevHash); err != nil {
+			return nil, ErrSnapshotInvalidSignature
+		}
+
+		s.Headers = append(s.Headers, h)
+	}
+
+	if err := readSnapshotItem(r, &s.Block); err != nil {
+		return nil, err
+	}
+	if s.Block.Seq() != hdr.Seq || s.Block.Head.PrevHash != prevHash {
+		return nil, ErrSnapshotHeadersNotContiguous
+	}
+	if err := s.Block.VerifySignature(pubkey); err != nil {
+		return nil, ErrSnapshotInvalidSignature
+	}
+	if s.Block.Body.Hash() != s.Block.Head.BodyHash {
+		return nil, ErrSnapshotCorrupt
+	}
+	if s.Block.Head.UxHash != hdr.UxHash {
+		return nil, ErrSnapshotUxHashMismatch
+	}
+
+	// The outputs are unique because they are sorted by hash
+	var prev cipher.SHA256
+	buf = make([]byte, encoder.Size(coin.UxOut{}))
+	for i := uint64(0); i < hdr.OutputsCount; i++ {
+		if i%snapshotProgressInterval == 0 {
+			select {
+			case <-quit:
+				return nil, ErrSnapshotStopped
+			default:
+			}
+		}
+
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, ErrSnapshotTruncated
+		}
+
+		var ux coin.UxOut
+		if err := encoder.DeserializeRawExact(buf, &ux); err != nil {
+			return nil, ErrSnapshotCorrupt
+		}
+
+		h := ux.Hash()
+		if (i > 0 && bytes.Compare(prev[:], h[:]) >= 0) || ux.Head.BkSeq >= hdr.Seq {
+			return nil, ErrSnapshotCorrupt
+		}
+		prev = h
+
+		s.Outputs = append(s.Outputs, ux)
+	}
+
+	if uxArrayHash(s.Outputs) != hdr.UxHash {
+		return nil, ErrSnapshotUxHashMismatch
+	}
+	if snapshotOutputsHash(s.Outputs) != hdr.OutputsHash {
+		return nil, ErrSnapshotOutputsHashMismatch
+	}
+
+	expectedAddrs := snapshotAddresses(s.Outputs)
+	if uint64(len(expectedAddrs)) != hdr.AddressesCount {
+		return nil, ErrSnapshotAddressIndexMismatch
+	}
+
+	for _, expected := range expectedAddrs {
+		var a SnapshotAddress
+		if err := readSnapshotItem(r, &a); err != nil {
+			return nil, err
+		}
+
+		if a.Address != expected.Address || len(a.Hashes) != len(expected.Hashes) {
+			return nil, ErrSnapshotAddressIndexMismatch
+		}
+		for i, h := range a.Hashes {
+			if h != expected.Hashes[i] {
+				return nil, ErrSnapshotAddressIndexMismatch
+			}
+		}
+
+		s.Addresses = append(s.Addresses, a)
+	}
+
+	var extra [1]byte
+	if n, _ := r.Read(extra[:]); n != 0 {
+		return nil, ErrSnapshotCorrupt
+	}
+
+	return s, nil
+}
+
+// VerifySnapshot verifies a snapshot file against the blockchain of a full node.
+// The block headers of the snapshot must match the blockchain, and the unspent outputs must match
+// the unspent outputs before the snapshot block computed from the blockchain
+func VerifySnapshot(db *dbutil.DB, pubkey cipher.PubKey, r io.Reader, quit chan struct{}) (*SnapshotHeader, error) {
+	s, err := ReadSnapshot(r, pubkey, quit)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.View("VerifySnapshot", func(tx *dbutil.Tx) error {
+		if !dbutil.Exists(tx, blockdb.BlocksBkt) {
+			return ErrNoBlocks
+		}
+
+		bc, err := NewBlockchain(db, BlockchainConfig{Pubkey: pubkey})
+		if err != nil {
+			return err
+		}
+
+		gb, err := bc.GetGenesisBlock(tx)
+		if err != nil {
+			return err
+		}
+		if gb == nil {
+			return ErrNoBlocks
+		}
+		if gb.HashHeader() != s.Header.GenesisHash {
+			return ErrSnapshotGenesisMismatch
+		}
+
+		// The headers form a chain, so the snapshot matches the blockchain if the snapshot block does
+		h, _, err := bc.GetSignedBlockHeaderBySeq(tx, s.Header.Seq)
+		if err != nil {
+			return err
+		}
+		if h == nil || h.Hash() != s.Block.HashHeader() {
+			return ErrSnapshotForked
+		}
+
+		uxs, err := snapshotUnspents(tx, bc, historydb.New(), s.Header.Seq, quit)
+		if err != nil {
+			return err
+		}
+
+		if len(uxs) != len(s.Outputs) {
+			return ErrSnapshotOutputsMismatch
+		}
+		for i, ux := range uxs {
+			if ux != s.Outputs[i] {
+				return ErrSnapshotOutputsMismatch
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return &s.Header, nil
+}
+
+// ImportSnapshot bootstraps the blockchain from a snapshot file.
+// The blockchain must be empty or have only the genesis block of the snapshot.
+// The headers of the blocks before the snapshot block are added as pruned blocks,
+// the unspent pool is loaded from the snapshot and the snapshot block is executed,
+// which verifies the unspent pool against the UxHash of the snapshot block.
+// The bodies of the pruned blocks and their history are backfilled from peers, and the unspent outputs
+// of the snapshot are checked against them once all pruned blocks are backfilled.
+// If the blockchain already has the snapshot block, nothing is imported
+func (vs *Visor) ImportSnapshot(r io.Reader, quit chan struct{}) (*SnapshotHeader, error) {
+	s, err := ReadSnapshot(r, vs.Config.BlockchainPubkey, quit)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-quit:
+		return nil, ErrSnapshotStopped
+	default:
+	}
+
+	if err := vs.db.Update("ImportSnapshot", func(tx *dbutil.Tx) error {
+		headSeq, ok, err := vs.blockchain.HeadSeq(tx)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			gb, err := vs.blockchain.GetGenesisBlock(tx)
+			if err != nil {
+				return err
+			}
+			if gb == nil || gb.HashHeader() != s.Header.GenesisHash {
+				return ErrSnapshotGenesisMismatch
+			}
+
+			if headSeq >= s.Header.Seq {
+				h, _, err := vs.blockchain.GetSignedBlockHeaderBySeq(tx, s.Header.Seq)
+				if err != nil {
+					return err
+				}
+				if h == nil || h.Hash() != s.Block.HashHeader() {
+					return ErrSnapshotForked
+				}
+
+				logger.Infof("The blockchain has the snapshot block %d already, nothing to import", s.Header.Seq)
+				return nil
+			}
+
+			if headSeq > 0 {
+				return ErrSnapshotChainNotEmpty
+			}
+		} else if err := vs.executeSignedBlock(tx, s.Genesis); err != nil {
+			return fmt.Errorf("Execute genesis block failed: %v", err)
+		}
+
+		for _, h := range s.Headers {
+			if err := vs.blockchain.AddPrunedBlock(tx, h.Header, h.Sig); err != nil {
+				return err
+			}
+		}
+
+		if err := vs.blockchain.Unspent().LoadSnapshot(tx, s.Outputs, s.Header.Seq-1); err != nil {
+			return err
+		}
+
+		if err := vs.history.AddUnspents(tx, s.Outputs); err != nil {
+			return err
+		}
+
+		if _, err := tx.CreateBucketIfNotExists(MetaBkt); err != nil {
+			return err
+		}
+		if err := dbutil.PutBucketValue(tx, MetaBkt, snapshotOutputsKey, encoder.Serialize(snapshotOutputs{
+			Seq:         s.Header.Seq,
+			OutputsHash: s.Header.OutputsHash,
+		})); err != nil {
+			return err
+		}
+
+		if err := vs.executeSignedBlock(tx, s.Block); err != nil {
+			return fmt.Errorf("Execute snapshot block %d failed: %v", s.Header.Seq, err)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	vs.triggerWalletDiscovery()
+
+	return &s.Header, nil
+}
+
+// verifySnapshotBackfill checks the unspent outputs imported from a snapshot against the unspent outputs
+// computed from the backfilled blocks, once all pruned blocks are backfilled.
+// Returns ErrSnapshotBackfillMismatch if they differ
+func (vs *Visor) verifySnapshotBackfill() error {
+	var matched bool
+	if err := vs.db.View("verifySnapshotBackfill", func(tx *dbutil.Tx) error {
+		if !dbutil.Exists(tx, MetaBkt) {
+			return nil
+		}
+
+		var so snapshotOutputs
+		if ok, err := dbutil.GetBucketObjectDecoded(tx, MetaBkt, snapshotOutputsKey, &so); err != nil || !ok {
+			return err
+		}
+
+		lowestSeq, err := vs.blockchain.LowestBlockSeq(tx)
+		if err != nil || lowestSeq != 0 {
+			return err
+		}
+
+		logger.Infof("Checking the unspent outputs of the imported snapshot at block %d against the backfilled blocks", so.Seq)
+
+		uxs, err := replayUnspents(tx, vs.blockchain, so.Seq, nil)
+		if err != nil {
+			return err
+		}
+
+		if snapshotOutputsHash(uxs) != so.OutputsHash {
+			return ErrSnapshotBackfillMismatch
+		}
+
+		matched = true
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if !matched {
+		return nil
+	}
+
+	logger.Info("The unspent outputs of the imported snapshot match the backfilled blocks")
+
+	return vs.db.Update("verifySnapshotBackfill", func(tx *dbutil.Tx) error {
+		return dbutil.Delete(tx, MetaBkt, snapshotOutputsKey)
+	})
+}
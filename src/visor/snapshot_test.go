@@ -0,0 +1,455 @@
+package visor
+
+import (
+	"bytes"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/visor/blockdb"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
+)
+
+// encodeTestSnapshot encodes a snapshot without verifying it
+func encodeTestSnapshot(t *testing.T, s *Snapshot) []byte {
+	var buf bytes.Buffer
+	err := writeSnapshotHeader(&buf, s.Header)
+	require.NoError(t, err)
+	err = writeSnapshotItem(&buf, s.Genesis)
+	require.NoError(t, err)
+	for _, h := range s.Headers {
+		buf.Write(encoder.Serialize(h))
+	}
+	err = writeSnapshotItem(&buf, s.Block)
+	require.NoError(t, err)
+	for _, ux := range s.Outputs {
+		buf.Write(encoder.Serialize(ux))
+	}
+	for _, a := range s.Addresses {
+		err = writeSnapshotItem(&buf, a)
+		require.NoError(t, err)
+	}
+	return buf.Bytes()
+}
+
+func getSnapshotOutputs(t *testing.T, v *Visor) *snapshotOutputs {
+	var so *snapshotOutputs
+	err := v.db.View("", func(tx *dbutil.Tx) error {
+		var s snapshotOutputs
+		ok, err := dbutil.GetBucketObjectDecoded(tx, MetaBkt, snapshotOutputsKey, &s)
+		if ok {
+			so = &s
+		}
+		return err
+	})
+	require.NoError(t, err)
+	return so
+}
+
+func getAddressTxnHashes(t *testing.T, v *Visor, addr cipher.Address) []cipher.SHA256 {
+	var hashes []cipher.SHA256
+	err := v.db.View("", func(tx *dbutil.Tx) error {
+		var err error
+		hashes, err = v.history.GetTransactionHashesForAddresses(tx, []cipher.Address{addr})
+		return err
+	})
+	require.NoError(t, err)
+	return hashes
+}
+
+func TestExportImportSnapshot(t *testing.T) {
+	srcDB, shutdown := prepareDB(t)
+	defer shutdown()
+
+	src := makeBlocksFileVisor(t, srcDB, 0)
+	addPruneTestBlocks(t, src, 20)
+
+	var buf bytes.Buffer
+	hdr, err := ExportSnapshot(srcDB, genPublic, &buf, 10, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), hdr.Seq)
+
+	b10, err := src.GetSignedBlockBySeq(10)
+	require.NoError(t, err)
+	require.Equal(t, b10.Head.UxHash, hdr.UxHash)
+	data := buf.Bytes()
+
+	// The snapshot matches the blockchain it was exported from
+	vhdr, err := VerifySnapshot(srcDB, genPublic, bytes.NewReader(data), nil)
+	require.NoError(t, err)
+	require.Equal(t, hdr, vhdr)
+
+	s, err := ReadSnapshot(bytes.NewReader(data), genPublic, nil)
+	require.NoError(t, err)
+	require.Len(t, s.Headers, 9)
+	require.Equal(t, hdr.OutputsCount, uint64(len(s.Outputs)))
+	require.Equal(t, hdr.AddressesCount, uint64(len(s.Addresses)))
+	require.Equal(t, snapshotOutputsHash(s.Outputs), hdr.OutputsHash)
+
+	// A snapshot beyond the head block is taken at the head block
+	var headBuf bytes.Buffer
+	hdr, err = ExportSnapshot(srcDB, genPublic, &headBuf, 1000, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(20), hdr.Seq)
+
+	// Bootstrap an empty blockchain from the snapshot
+	dstDB, shutdown2 := prepareDB(t)
+	defer shutdown2()
+
+	dst := newImportVisor(t, dstDB)
+	_, err = dst.ImportSnapshot(bytes.NewReader(data), nil)
+	require.NoError(t, err)
+
+	headSeq, ok, err := dst.HeadBkSeq()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, uint64(10), headSeq)
+
+	lowest, err := dst.LowestBlockSeq()
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), lowest)
+
+	_, err = dst.GetSignedBlockBySeq(5)
+	require.Equal(t, blockdb.NewErrBlockPruned(5), err)
+
+	headers, err := dst.GetSignedBlockHeadersSince(0, 20)
+	require.NoError(t, err)
+	require.Len(t, headers, 10)
+
+	// Importing the snapshot again is a no-op
+	_, err = dst.ImportSnapshot(bytes.NewReader(data), nil)
+	require.NoError(t, err)
+
+	// The blocks after the snapshot can be executed
+	blocks, err := src.GetSignedBlocksSince(10, 10)
+	require.NoError(t, err)
+	for _, b := range blocks {
+		err := dst.ExecuteSignedBlock(b)
+		require.NoError(t, err)
+	}
+
+	// A snapshot of a block that is in the blockchain already is not imported
+	_, err = dst.ImportSnapshot(bytes.NewReader(headBuf.Bytes()), nil)
+	require.NoError(t, err)
+
+	// Backfill the pruned blocks
+	start, end, ok, err := dst.BackfillRange()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, uint64(0), start)
+	require.Equal(t, uint64(9), end)
+
+	blocks, err = src.GetSignedBlocksSince(0, 9)
+	require.NoError(t, err)
+
+	n, err := dst.BackfillBlocks(blocks[:4])
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+
+	start, end, ok, err = dst.BackfillRange()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, uint64(4), start)
+	require.Equal(t, uint64(9), end)
+
+	b, err := dst.GetSignedBlockBySeq(4)
+	require.NoError(t, err)
+	require.Equal(t, blocks[3].HashHeader(), b.HashHeader())
+	_, err = dst.GetSignedBlockBySeq(5)
+	require.Equal(t, blockdb.NewErrBlockPruned(5), err)
+
+	// Blocks that were backfilled already are skipped
+	n, err = dst.BackfillBlocks(blocks)
+	require.NoError(t, err)
+	require.Equal(t, 5, n)
+
+	_, _, ok, err = dst.BackfillRange()
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	lowest, err = dst.LowestBlockSeq()
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), lowest)
+
+	requireSameBlocks(t, src, dst)
+	require.Equal(t, getAddressTxnHashes(t, src, genAddress), getAddressTxnHashes(t, dst, genAddress))
+
+	// The snapshot outputs matched the backfilled blocks
+	require.Nil(t, getSnapshotOutputs(t, dst))
+	select {
+	case err := <-dstDB.Failed():
+		t.Fatalf("Database failed: %v", err)
+	default:
+	}
+
+	err = CheckDatabase(dstDB, genPublic, nil)
+	require.NoError(t, err)
+}
+
+func TestBackfillPrunedBlocksMismatch(t *testing.T) {
+	srcDB, shutdown := prepareDB(t)
+	defer shutdown()
+
+	src := makeBlocksFileVisor(t, srcDB, 0)
+	addPruneTestBlocks(t, src, 5)
+
+	var buf bytes.Buffer
+	_, err := ExportSnapshot(srcDB, genPublic, &buf, 5, nil)
+	require.NoError(t, err)
+
+	dstDB, shutdown2 := prepareDB(t)
+	defer shutdown2()
+
+	dst := newImportVisor(t, dstDB)
+	_, err = dst.ImportSnapshot(&buf, nil)
+	require.NoError(t, err)
+
+	blocks, err := src.GetSignedBlocksSince(0, 4)
+	require.NoError(t, err)
+
+	// A block whose body does not match its header is rejected
+	b := blocks[0]
+	b.Body.Transactions = nil
+	n, err := dst.BackfillBlocks([]coin.SignedBlock{b})
+	require.Equal(t, blockdb.ErrBackfillBlockBodyHashMismatch, err)
+	require.Equal(t, 0, n)
+
+	// A block of a different chain is rejected
+	b = blocks[0]
+	b.Head.Time++
+	n, err = dst.BackfillBlocks([]coin.SignedBlock{b})
+	require.Equal(t, blockdb.ErrBackfillBlockMismatch, err)
+	require.Equal(t, 0, n)
+
+	// A node that prunes blocks does not backfill
+	dst.Config.PruneDepth = MinPruneDepth
+	_, _, ok, err := dst.BackfillRange()
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestBackfillSnapshotMismatch(t *testing.T) {
+	srcDB, shutdown := prepareDB(t)
+	defer shutdown()
+
+	src := makeBlocksFileVisor(t, srcDB, 0)
+	addPruneTestBlocks(t, src, 5)
+
+	var buf bytes.Buffer
+	_, err := ExportSnapshot(srcDB, genPublic, &buf, 5, nil)
+	require.NoError(t, err)
+
+	dstDB, shutdown2 := prepareDB(t)
+	defer shutdown2()
+
+	dst := newImportVisor(t, dstDB)
+	hdr, err := dst.ImportSnapshot(&buf, nil)
+	require.NoError(t, err)
+
+	so := getSnapshotOutputs(t, dst)
+	require.NotNil(t, so)
+	require.Equal(t, snapshotOutputs{
+		Seq:         5,
+		OutputsHash: hdr.OutputsHash,
+	}, *so)
+
+	// Simulate a forged snapshot whose outputs have the UxHash of the snapshot block
+	err = dstDB.Update("", func(tx *dbutil.Tx) error {
+		return dbutil.PutBucketValue(tx, MetaBkt, snapshotOutputsKey, encoder.Serialize(snapshotOutputs{
+			Seq:         5,
+			OutputsHash: cipher.SumSHA256([]byte("foo")),
+		}))
+	})
+	require.NoError(t, err)
+
+	blocks, err := src.GetSignedBlocksSince(0, 4)
+	require.NoError(t, err)
+
+	// The blocks are backfilled, then the database fails
+	n, err := dst.BackfillBlocks(blocks[:2])
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	select {
+	case err := <-dstDB.Failed():
+		t.Fatalf("Database failed before the backfill completed: %v", err)
+	default:
+	}
+
+	n, err = dst.BackfillBlocks(blocks)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	select {
+	case err := <-dstDB.Failed():
+		require.Equal(t, ErrSnapshotBackfillMismatch, err)
+	default:
+		t.Fatal("Database did not fail")
+	}
+
+	// The node can't be started again
+	require.NotNil(t, getSnapshotOutputs(t, dst))
+	err = dst.Init()
+	require.Equal(t, ErrSnapshotBackfillMismatch, err)
+}
+
+func TestReadSnapshotVerify(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	v := makeBlocksFileVisor(t, db, 0)
+	addPruneTestBlocks(t, v, 8)
+
+	var buf bytes.Buffer
+	_, err := ExportSnapshot(db, genPublic, &buf, 6, nil)
+	require.NoError(t, err)
+	data := buf.Bytes()
+
+	s, err := ReadSnapshot(bytes.NewReader(data), genPublic, nil)
+	require.NoError(t, err)
+	require.Equal(t, data, encodeTestSnapshot(t, s))
+
+	otherPubkey, _ := cipher.GenerateKeyPair()
+
+	for _, tc := range []struct {
+		name   string
+		data   func() []byte
+		pubkey cipher.PubKey
+		err    error
+	}{
+		{
+			name:   "not a snapshot",
+			data:   func() []byte { return []byte("SKYBLOCK") },
+			pubkey: genPublic,
+			err:    ErrSnapshotInvalid,
+		},
+		{
+			name:   "pubkey mismatch",
+			data:   func() []byte { return data },
+			pubkey: otherPubkey,
+			err:    ErrSnapshotPubkeyMismatch,
+		},
+		{
+			name:   "header checksum mismatch",
+			data:   func() []byte { d := append([]byte{}, data...); d[12]++; return d },
+			pubkey: genPublic,
+			err:    ErrSnapshotChecksum,
+		},
+		{
+			name:   "truncated",
+			data:   func() []byte { return data[:len(data)-10] },
+			pubkey: genPublic,
+			err:    ErrSnapshotTruncated,
+		},
+		{
+			name:   "trailing data",
+			data:   func() []byte { return append(append([]byte{}, data...), 0) },
+			pubkey: genPublic,
+			err:    ErrSnapshotCorrupt,
+		},
+		{
+			name: "invalid header signature",
+			data: func() []byte {
+				s2 := *s
+				s2.Headers = append([]SignedBlockHeader{}, s.Headers...)
+				s2.Headers[2].Sig = s2.Headers[1].Sig
+				return encodeTestSnapshot(t, &s2)
+			},
+			pubkey: genPublic,
+			err:    ErrSnapshotInvalidSignature,
+		},
+		{
+			name: "headers not contiguous",
+			data: func() []byte {
+				s2 := *s
+				s2.Headers = append([]SignedBlockHeader{}, s.Headers[:2]...)
+				s2.Headers = append(s2.Headers, s.Headers[3:]...)
+				s2.Headers = append(s2.Headers, s.Headers[2])
+				return encodeTestSnapshot(t, &s2)
+			},
+			pubkey: genPublic,
+			err:    ErrSnapshotHeadersNotContiguous,
+		},
+		{
+			name: "outputs do not match the UxHash",
+			data: func() []byte {
+				s2 := *s
+				s2.Outputs = append(coin.UxArray{}, s.Outputs...)
+				s2.Outputs[0].Body.Hours++
+				sort.Slice(s2.Outputs, func(i, j int) bool {
+					hi := s2.Outputs[i].Hash()
+					hj := s2.Outputs[j].Hash()
+					return bytes.Compare(hi[:], hj[:]) < 0
+				})
+				return encodeTestSnapshot(t, &s2)
+			},
+			pubkey: genPublic,
+			err:    ErrSnapshotUxHashMismatch,
+		},
+		{
+			name: "outputs do not match the outputs hash",
+			data: func() []byte {
+				s2 := *s
+				s2.Header.OutputsHash = cipher.SumSHA256([]byte("foo"))
+				return encodeTestSnapshot(t, &s2)
+			},
+			pubkey: genPublic,
+			err:    ErrSnapshotOutputsHashMismatch,
+		},
+		{
+			name: "outputs not sorted",
+			data: func() []byte {
+				s2 := *s
+				s2.Outputs = coin.UxArray{s.Outputs[1], s.Outputs[0]}
+				return encodeTestSnapshot(t, &s2)
+			},
+			pubkey: genPublic,
+			err:    ErrSnapshotCorrupt,
+		},
+		{
+			name: "address index does not match the outputs",
+			data: func() []byte {
+				s2 := *s
+				s2.Addresses = []SnapshotAddress{{
+					Address: genAddress,
+					Hashes:  []cipher.SHA256{s.Outputs[0].Hash()},
+				}}
+				return encodeTestSnapshot(t, &s2)
+			},
+			pubkey: genPublic,
+			err:    ErrSnapshotAddressIndexMismatch,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ReadSnapshot(bytes.NewReader(tc.data()), tc.pubkey, nil)
+			require.Equal(t, tc.err, err)
+		})
+	}
+}
+
+func TestVerifySnapshotForked(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	v := makeBlocksFileVisor(t, db, 0)
+	addPruneTestBlocks(t, v, 5)
+
+	var buf bytes.Buffer
+	_, err := ExportSnapshot(db, genPublic, &buf, 3, nil)
+	require.NoError(t, err)
+
+	otherDB, shutdown2 := prepareDB(t)
+	defer shutdown2()
+	makeBlocksFileVisor(t, otherDB, 5)
+
+	_, err = VerifySnapshot(otherDB, genPublic, bytes.NewReader(buf.Bytes()), nil)
+	require.Equal(t, ErrSnapshotForked, err)
+
+	// A snapshot can't be imported on top of other blocks
+	other := newImportVisor(t, otherDB)
+	_, err = other.ImportSnapshot(bytes.NewReader(buf.Bytes()), nil)
+	require.Equal(t, ErrSnapshotForked, err)
+}
@@ -279,9 +279,63 @@ func (tm transactionModel) GetTransactions(tx *dbutil.Tx, flts []TxFilter, order
 		}
 	}
 
+	otherFlts, err := tm.resolveSenderFlts(tx, otherFlts)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	return txnGetter.GetTransactions(tx, otherFlts, order, page)
 }
 
+// sendersTxFilter is a SenderAddrsFilter with the outputs received by its addresses,
+// it matches the transactions that spend any of these outputs
+type sendersTxFilter struct {
+	SenderAddrsFilter
+	uxs map[cipher.SHA256]struct{}
+}
+
+// Match implements the TxFilter interface
+func (sf sendersTxFilter) Match(tx *Transaction) bool {
+	for _, in := range tx.Transaction.In {
+		if _, ok := sf.uxs[in]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveSenderFlts replaces the SenderAddrsFilters with sendersTxFilters.
+// Both confirmed and unconfirmed transactions spend outputs that are in the history
+func (tm transactionModel) resolveSenderFlts(tx *dbutil.Tx, flts []TxFilter) ([]TxFilter, error) {
+	resolved := make([]TxFilter, len(flts))
+	for i, f := range flts {
+		sf, ok := f.(SenderAddrsFilter)
+		if !ok {
+			resolved[i] = f
+			continue
+		}
+
+		uxs := make(map[cipher.SHA256]struct{})
+		for _, addr := range sf.Addrs {
+			outs, err := tm.history.GetOutputsForAddress(tx, addr)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, o := range outs {
+				uxs[o.Out.Hash()] = struct{}{}
+			}
+		}
+
+		resolved[i] = sendersTxFilter{
+			SenderAddrsFilter: sf,
+			uxs:               uxs,
+		}
+	}
+
+	return resolved, nil
+}
+
 type transactionsGetter interface {
 	GetTransactions(tx *dbutil.Tx, flts []TxFilter, order SortOrder, page *PageIndex) ([]Transaction, uint64, error)
 }
@@ -293,7 +347,7 @@ type confirmedTxnsGetter struct {
 func (ct confirmedTxnsGetter) GetTransactions(tx *dbutil.Tx, flts []TxFilter, order SortOrder, page *PageIndex) ([]Transaction, uint64, error) {
 	addrs, otherFlts := getAddrsFromFlts(flts)
 
-	txnsHashesCon, err := ct.getTxnsHashes(tx, addrs)
+	txnsHashesCon, err := ct.getTxnsHashes(tx, addrs, otherFlts)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -372,10 +426,30 @@ func (ct confirmedTxnsGetter) convertConfirmedTxn(tx *dbutil.Tx, hisTxn *history
 	}, nil
 }
 
-func (ct confirmedTxnsGetter) getTxnsHashes(tx *dbutil.Tx, addrs []cipher.Address) (*txnHashesContainer, error) {
+func (ct confirmedTxnsGetter) getTxnsHashes(tx *dbutil.Tx, addrs []cipher.Address, flts []TxFilter) (*txnHashesContainer, error) {
 	hashCon := newTxnHashesContainer()
-	// if no address is specified, returns all confirmed transaction hashes
+	// if no address is specified, returns the transaction hashes of the sender or receiver addresses,
+	// or of the index that covers one of the filters, otherwise returns all confirmed transaction hashes
 	if len(addrs) == 0 {
+		senders, receivers := getRoleAddrsFromFlts(flts)
+		if len(senders) > 0 {
+			return ct.getTxnsHashes(tx, senders, nil)
+		}
+		if len(receivers) > 0 {
+			return ct.getTxnsHashes(tx, receivers, nil)
+		}
+
+		items, ok, err := ct.getIndexedTxns(tx, flts)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			for _, item := range items {
+				hashCon.Add(item.Hash, true, item.BlockSeq)
+			}
+			return hashCon, nil
+		}
+
 		if err := ct.history.ForEachTxn(tx, func(hash cipher.SHA256, txn *historydb.Transaction) error {
 			hashCon.Add(hash, true, txn.BlockSeq)
 			return nil
@@ -412,6 +486,69 @@ func (ct confirmedTxnsGetter) getTxnsHashes(tx *dbutil.Tx, addrs []cipher.Addres
 	return hashCon, nil
 }
 
+// getIndexedTxns returns the transactions of the history index that covers a time range, block range or coins filter.
+// Returns false if none of the filters is covered by an index
+func (ct confirmedTxnsGetter) getIndexedTxns(tx *dbutil.Tx, flts []TxFilter) ([]historydb.TxnIndexItem, bool, error) {
+	var coinsFlt *CoinsTxFilter
+	for _, f := range flts {
+		switch v := f.(type) {
+		case TimeRangeTxFilter:
+			items, err := ct.history.GetTransactionsByTime(tx, v.From, v.To)
+			return items, true, err
+		case BlockRangeTxFilter:
+			items, err := ct.getTxnsInBlockRange(tx, v.From, v.To)
+			return items, true, err
+		case CoinsTxFilter:
+			if coinsFlt == nil {
+				coinsFlt = &v
+			}
+		}
+	}
+
+	if coinsFlt == nil {
+		return nil, false, nil
+	}
+
+	items, err := ct.history.GetTransactionsByValue(tx, coinsFlt.Min, coinsFlt.Max)
+	return items, true, err
+}
+
+// getTxnsInBlockRange returns the transactions of the blocks from seq from to seq to inclusive.
+// The block time increases with the block seq, so these are the transactions of the time range
+// from the time of the first block to the time of the last block
+func (ct confirmedTxnsGetter) getTxnsInBlockRange(tx *dbutil.Tx, from, to uint64) ([]historydb.TxnIndexItem, error) {
+	headSeq, ok, err := ct.blockchain.HeadSeq(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok || from > to || from > headSeq {
+		return nil, nil
+	}
+
+	if to > headSeq {
+		to = headSeq
+	}
+
+	fromHead, _, err := ct.blockchain.GetSignedBlockHeaderBySeq(tx, from)
+	if err != nil {
+		return nil, err
+	}
+	if fromHead == nil {
+		return nil, fmt.Errorf("block seq=%d doesn't exist", from)
+	}
+
+	toHead, _, err := ct.blockchain.GetSignedBlockHeaderBySeq(tx, to)
+	if err != nil {
+		return nil, err
+	}
+	if toHead == nil {
+		return nil, fmt.Errorf("block seq=%d doesn't exist", to)
+	}
+
+	return ct.history.GetTransactionsByTime(tx, fromHead.Time, toHead.Time)
+}
+
 type unconfirmedTxnsGetter struct {
 	transactionModel
 }
@@ -419,7 +556,7 @@ type unconfirmedTxnsGetter struct {
 func (uct unconfirmedTxnsGetter) GetTransactions(tx *dbutil.Tx, flts []TxFilter, order SortOrder, page *PageIndex) ([]Transaction, uint64, error) {
 	addrs, otherFlts := getAddrsFromFlts(flts)
 
-	txnHashesCon, err := uct.getTxnsHashes(tx, addrs)
+	txnHashesCon, err := uct.getTxnsHashes(tx, addrs, otherFlts)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -464,9 +601,15 @@ func (uct unconfirmedTxnsGetter) getTransaction(tx *dbutil.Tx, hash cipher.SHA25
 	}, nil
 }
 
-func (uct unconfirmedTxnsGetter) getTxnsHashes(tx *dbutil.Tx, addrs []cipher.Address) (*txnHashesContainer, error) {
+func (uct unconfirmedTxnsGetter) getTxnsHashes(tx *dbutil.Tx, addrs []cipher.Address, flts []TxFilter) (*txnHashesContainer, error) {
 	txnHashCon := newTxnHashesContainer()
 
+	// The transactions of the receiver addresses are found by their outputs.
+	// The unconfirmed transactions are not indexed by their inputs, so all are returned for the sender addresses
+	if len(addrs) == 0 {
+		_, addrs = getRoleAddrsFromFlts(flts)
+	}
+
 	// Return all if there's no address filter
 	if len(addrs) == 0 {
 		if err := uct.unconfirmed.ForEach(tx, func(hash cipher.SHA256, txn UnconfirmedTransaction) error {
@@ -506,7 +649,7 @@ func newAllTxnsGetter(tm transactionModel) *fullTxnsGetter {
 
 func (ft fullTxnsGetter) GetTransactions(tx *dbutil.Tx, flts []TxFilter, order SortOrder, page *PageIndex) ([]Transaction, uint64, error) {
 	addrs, otherFlts := getAddrsFromFlts(flts)
-	txnsHashesCon, err := ft.getTxnsHashes(tx, addrs)
+	txnsHashesCon, err := ft.getTxnsHashes(tx, addrs, otherFlts)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -541,19 +684,23 @@ func (ft fullTxnsGetter) GetTransactions(tx *dbutil.Tx, flts []TxFilter, order S
 	return txns, totalPages, nil
 }
 
-func (ft fullTxnsGetter) getTxnsHashes(tx *dbutil.Tx, addrs []cipher.Address) (*txnHashesContainer, error) {
-	txnHashCon, err := ft.confirmedTxnsGetter.getTxnsHashes(tx, addrs)
+func (ft fullTxnsGetter) getTxnsHashes(tx *dbutil.Tx, addrs []cipher.Address, flts []TxFilter) (*txnHashesContainer, error) {
+	txnHashCon, err := ft.confirmedTxnsGetter.getTxnsHashes(tx, addrs, flts)
 	if err != nil {
 		return nil, err
 	}
 
-	unconfirmedTxnHashCon, err := ft.unconfirmedTxnsGetter.getTxnsHashes(tx, addrs)
+	unconfirmedTxnHashCon, err := ft.unconfirmedTxnsGetter.getTxnsHashes(tx, addrs, flts)
 	if err != nil {
 		return nil, err
 	}
 
 	// Update the seqs of unconfirmed txn. Unconfirmed txns are always the latest,
 	// therefore, update to make the unconfirmed txns seqs start from the last confirmed txn seq + 1.
+	// The confirmed txns of several addresses or of the output value index are not in block order, sort them first.
+	if err := txnHashCon.Sort(AscOrder); err != nil {
+		return nil, err
+	}
 	lastItem, ok := txnHashCon.LastItem()
 	if ok {
 		unconfirmedTxnHashCon.Update(func(i int, item *txnHashConfirm) {
@@ -582,6 +729,28 @@ func getAddrsFromFlts(flts []TxFilter) ([]cipher.Address, []TxFilter) {
 	return addrs, otherFlts
 }
 
+// getRoleAddrsFromFlts returns the addresses of the first sender filter and of the first receiver filter
+func getRoleAddrsFromFlts(flts []TxFilter) ([]cipher.Address, []cipher.Address) {
+	var senders, receivers []cipher.Address
+	for _, f := range flts {
+		switch v := f.(type) {
+		case SenderAddrsFilter:
+			if senders == nil {
+				senders = v.Addrs
+			}
+		case sendersTxFilter:
+			if senders == nil {
+				senders = v.Addrs
+			}
+		case ReceiverAddrsFilter:
+			if receivers == nil {
+				receivers = v.Addrs
+			}
+		}
+	}
+	return senders, receivers
+}
+
 func accumulateAddressInFilter(afs []AddrsFilter) []cipher.Address {
 	// Accumulate all addresses in address filters
 	addrMap := make(map[cipher.Address]struct{})
@@ -1,7 +1,15 @@
 package visor
 
 import (
+	"math"
+	"sort"
 	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/visor/dbutil"
 )
 
 func TestPage_Cal(t *testing.T) {
@@ -214,3 +222,164 @@ func TestPage_Cal(t *testing.T) {
 		})
 	}
 }
+
+func TestGetTransactionsIndexedFilters(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	v := makeBlocksFileVisor(t, db, 0)
+
+	pubA, secA := cipher.GenerateKeyPair()
+	addrA := cipher.AddressFromPubKey(pubA)
+	pubB, secB := cipher.GenerateKeyPair()
+	addrB := cipher.AddressFromPubKey(pubB)
+
+	// injectTxn sends coins from the newest output of an address, the change is sent back to the address
+	injectTxn := func(from cipher.Address, key cipher.SecKey, to cipher.Address, coins uint64) coin.Transaction {
+		uxOuts, err := v.GetUnspentsOfAddrs([]cipher.Address{from})
+		require.NoError(t, err)
+		uxa := uxOuts[from]
+		require.NotEmpty(t, uxa)
+		sort.Slice(uxa, func(i, j int) bool {
+			return uxa[i].Head.BkSeq > uxa[j].Head.BkSeq
+		})
+
+		txn := makeSpendTxn(t, uxa[:1], []cipher.SecKey{key}, to, coins)
+		_, softErr, err := v.InjectForeignTransaction(txn)
+		require.NoError(t, err)
+		require.Nil(t, softErr)
+		return txn
+	}
+
+	executeBlock := func() *coin.SignedBlock {
+		head, err := v.GetHeadBlock()
+		require.NoError(t, err)
+
+		var b coin.SignedBlock
+		err = db.Update("", func(tx *dbutil.Tx) error {
+			var err error
+			b, err = v.createBlock(tx, head.Time()+3600)
+			if err != nil {
+				return err
+			}
+			return v.executeSignedBlock(tx, b)
+		})
+		require.NoError(t, err)
+		return &b
+	}
+
+	gb, err := v.GetSignedBlockBySeq(0)
+	require.NoError(t, err)
+	txnGenesis := gb.Body.Transactions[0]
+
+	// Block 1 sends from the genesis address to A, block 2 sends from the genesis address to B,
+	// block 3 sends from A to B, and an unconfirmed transaction sends all of the output of block 3 from B to A
+	txnA := injectTxn(genAddress, genSecret, addrA, 10e6)
+	b1 := executeBlock()
+	txnB := injectTxn(genAddress, genSecret, addrB, 20e6)
+	b2 := executeBlock()
+	txnC := injectTxn(addrA, secA, addrB, 5e6)
+	b3 := executeBlock()
+	txnD := injectTxn(addrB, secB, addrA, 5e6)
+	require.Len(t, txnD.Out, 1)
+
+	cases := []struct {
+		name   string
+		flts   []TxFilter
+		expect []coin.Transaction
+	}{
+		{
+			name:   "time range",
+			flts:   []TxFilter{NewConfirmedTxFilter(true), NewTimeRangeTxFilter(b2.Time(), b3.Time())},
+			expect: []coin.Transaction{txnB, txnC},
+		},
+		{
+			name:   "time range from genesis",
+			flts:   []TxFilter{NewConfirmedTxFilter(true), NewTimeRangeTxFilter(0, b1.Time())},
+			expect: []coin.Transaction{txnGenesis, txnA},
+		},
+		{
+			name:   "block range",
+			flts:   []TxFilter{NewBlockRangeTxFilter(1, 2)},
+			expect: []coin.Transaction{txnA, txnB},
+		},
+		{
+			name:   "block range beyond head",
+			flts:   []TxFilter{NewBlockRangeTxFilter(3, math.MaxUint64)},
+			expect: []coin.Transaction{txnC},
+		},
+		{
+			name: "block range after head",
+			flts: []TxFilter{NewBlockRangeTxFilter(4, 10)},
+		},
+		{
+			name:   "coins",
+			flts:   []TxFilter{NewCoinsTxFilter(0, 10e6)},
+			expect: []coin.Transaction{txnC, txnD},
+		},
+		{
+			name:   "coins confirmed",
+			flts:   []TxFilter{NewConfirmedTxFilter(true), NewCoinsTxFilter(10e6, 10e6)},
+			expect: []coin.Transaction{txnC},
+		},
+		{
+			name:   "coins and time range",
+			flts:   []TxFilter{NewCoinsTxFilter(genCoins-10e6, genCoins), NewTimeRangeTxFilter(b1.Time(), math.MaxUint64)},
+			expect: []coin.Transaction{txnA, txnB},
+		},
+		{
+			name:   "sender",
+			flts:   []TxFilter{NewSenderAddrsFilter([]cipher.Address{addrA})},
+			expect: []coin.Transaction{txnC},
+		},
+		{
+			name:   "senders",
+			flts:   []TxFilter{NewSenderAddrsFilter([]cipher.Address{addrA, addrB})},
+			expect: []coin.Transaction{txnC, txnD},
+		},
+		{
+			name:   "receiver",
+			flts:   []TxFilter{NewReceiverAddrsFilter([]cipher.Address{addrB})},
+			expect: []coin.Transaction{txnB, txnC},
+		},
+		{
+			name:   "receiver with change",
+			flts:   []TxFilter{NewReceiverAddrsFilter([]cipher.Address{addrA})},
+			expect: []coin.Transaction{txnA, txnC, txnD},
+		},
+		{
+			name:   "sender and receiver",
+			flts:   []TxFilter{NewSenderAddrsFilter([]cipher.Address{addrA}), NewReceiverAddrsFilter([]cipher.Address{addrB})},
+			expect: []coin.Transaction{txnC},
+		},
+		{
+			name:   "address and sender",
+			flts:   []TxFilter{NewAddrsFilter([]cipher.Address{addrA}), NewSenderAddrsFilter([]cipher.Address{addrB})},
+			expect: []coin.Transaction{txnD},
+		},
+		{
+			name:   "unconfirmed sender",
+			flts:   []TxFilter{NewConfirmedTxFilter(false), NewSenderAddrsFilter([]cipher.Address{addrB})},
+			expect: []coin.Transaction{txnD},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			txns, _, err := v.GetTransactions(tc.flts, AscOrder, nil)
+			require.NoError(t, err)
+
+			hashes := make([]cipher.SHA256, len(txns))
+			for i, txn := range txns {
+				hashes[i] = txn.Transaction.Hash()
+			}
+
+			expect := make([]cipher.SHA256, len(tc.expect))
+			for i, txn := range tc.expect {
+				expect[i] = txn.Hash()
+			}
+
+			require.Equal(t, expect, hashes)
+		})
+	}
+}
@@ -72,8 +72,10 @@ func New(c Config, db *dbutil.DB, wltServ *wallet.Service) (*Visor, error) {
 	}
 
 	bc, err := NewBlockchain(db, BlockchainConfig{
-		Pubkey:      c.BlockchainPubkey,
-		Arbitrating: c.Arbitrating,
+		Pubkey:                 c.BlockchainPubkey,
+		Arbitrating:            c.Arbitrating,
+		SignatureVerifyWorkers: c.SignatureVerifyWorkers,
+		SignatureCacheSize:     c.SignatureCacheSize,
 	})
 	if err != nil {
 		return nil, err
@@ -138,7 +140,7 @@ func (vs *Visor) Init() error {
 		return nil
 	}
 
-	return vs.db.Update("visor init", func(tx *dbutil.Tx) error {
+	if err := vs.db.Update("visor init", func(tx *dbutil.Tx) error {
 		if err := vs.maybeCreateGenesisBlock(tx); err != nil {
 			return err
 		}
@@ -150,19 +152,47 @@ func (vs *Visor) Init() error {
 		logger.Infof("Removed %d invalid txns from pool", len(removed))
 
 		return nil
-	})
+	}); err != nil {
+		return err
+	}
+
+	if err := vs.pruneBlocks(); err != nil {
+		return err
+	}
+
+	// The check of an imported snapshot against the backfilled blocks may have been interrupted
+	return vs.verifySnapshotBackfill()
 }
 
 func initHistory(tx *dbutil.Tx, bc *Blockchain, history *historydb.HistoryDB) error {
 	logger.Info("Visor initHistory")
 
+	// Resume the rebuild of the history that was interrupted
+	rebuilding, err := history.Rebuilding(tx)
+	if err != nil {
+		return err
+	}
+
+	if rebuilding {
+		logger.Info("Resuming the historyDB rebuild")
+		return startTxnIndexMigration(tx, history)
+	}
+
 	shouldReset, err := history.NeedsReset(tx)
 	if err != nil {
 		return err
 	}
 
 	if !shouldReset {
-		return nil
+		return startTxnIndexMigration(tx, history)
+	}
+
+	lowestBlockSeq, err := bc.LowestBlockSeq(tx)
+	if err != nil {
+		return err
+	}
+	if lowestBlockSeq != 0 {
+		return errors.New("The historyDB must be reset, which is not possible after the blocks are pruned")
 	}
 
 	logger.Info("Resetting historyDB")
@@ -171,44 +201,47 @@ func initHistory(tx *dbutil.Tx, bc *Blockchain, history *historydb.HistoryDB) er
 		return err
 	}
 
-	// Reparse the history up to the blockchain head
-	headSeq, _, err := bc.HeadSeq(tx)
+	// The blocks are parsed by RebuildHistory in the background. If there are none,
+	// they are parsed as they are executed
+	_, ok, err := bc.HeadSeq(tx)
 	if err != nil {
 		return err
 	}
 
-	if err := parseHistoryTo(tx, history, bc, headSeq); err != nil {
-		logger.WithError(err).Error("parseHistoryTo failed")
-		return err
+	if !ok {
+		return nil
 	}
 
-	return nil
-}
+	logger.Info("The historyDB will be rebuilt in the background")
 
-func parseHistoryTo(tx *dbutil.Tx, history *historydb.HistoryDB, bc *Blockchain, height uint64) error {
-	logger.Info("Visor parseHistoryTo")
+	return history.SetRebuilding(tx, true)
+}
 
-	parsedBlockSeq, _, err := history.ParsedBlockSeq(tx)
+// startTxnIndexMigration marks the transaction indexes of the historyDB to be backfilled if they are missing.
+// The indexes are backfilled by RebuildHistory in the background
+func startTxnIndexMigration(tx *dbutil.Tx, history *historydb.HistoryDB) error {
+	migrating, err := history.MigratingTxnIndexes(tx)
 	if err != nil {
 		return err
 	}
 
-	for i := uint64(0); i < height-parsedBlockSeq; i++ {
-		b, err := bc.GetSignedBlockBySeq(tx, parsedBlockSeq+i+1)
-		if err != nil {
-			return err
-		}
+	if migrating {
+		logger.Info("Resuming the backfill of the historyDB transaction indexes")
+		return nil
+	}
 
-		if b == nil {
-			return fmt.Errorf("no block exists in depth: %d", parsedBlockSeq+i+1)
-		}
+	needsMigration, err := history.NeedsTxnIndexMigration(tx)
+	if err != nil {
+		return err
+	}
 
-		if err := history.ParseBlock(tx, b.Block); err != nil {
-			return err
-		}
+	if !needsMigration {
+		return nil
 	}
 
-	return nil
+	logger.Info("The historyDB transaction indexes will be backfilled in the background")
+
+	return history.StartTxnIndexMigration(tx)
 }
 
 // maybeCreateGenesisBlock creates a genesis block if necessary
@@ -395,6 +428,9 @@ func (vs *Visor) CreateAndExecuteBlock() (coin.SignedBlock, error) {
 
 		return vs.executeSignedBlock(tx, sb)
 	})
+	if err == nil {
+		vs.triggerWalletDiscovery()
+	}
 
 	return sb, err
 }
@@ -418,25 +454,73 @@ func (vs *Visor) CreateBlockFromTxns(txns coin.Transactions, when uint64) (coin.
 // VerifyBlock verifies specified block against local copy of blockchain.
 // Signature is not verified.
 func (vs *Visor) VerifyBlock(b coin.SignedBlock) error {
+	vs.verifyBlockSignatures([]coin.SignedBlock{b})
+
 	return vs.db.View("VerifyBlock", func(tx *dbutil.Tx) error {
 		return vs.blockchain.VerifyBlock(tx, &b)
 	})
 }
 
+// VerifyBlockSignatures verifies the input signatures of the transactions of consecutive blocks
+// that follow the head block in parallel, before the blocks are executed.
+// The blocks' transactions whose signatures are valid are not verified again when the blocks are executed.
+// Invalid signatures are not reported, they are reported when the blocks are executed.
+func (vs *Visor) VerifyBlockSignatures(blocks []coin.SignedBlock) error {
+	return vs.db.View("VerifyBlockSignatures", func(tx *dbutil.Tx) error {
+		return vs.blockchain.VerifyBlockSignatures(tx, blocks)
+	})
+}
+
+// SignatureCacheStats returns the usage statistics of the cache of verified input signatures
+func (vs *Visor) SignatureCacheStats() SignatureCacheStats {
+	return vs.blockchain.SignatureCacheStats()
+}
+
+// verifyBlockSignatures verifies the input signatures of blocks before they are executed.
+// A failure is logged, since the signatures are verified again when the blocks are executed
+func (vs *Visor) verifyBlockSignatures(blocks []coin.SignedBlock) {
+	if err := vs.VerifyBlockSignatures(blocks); err != nil {
+		logger.WithError(err).Warning("VerifyBlockSignatures failed")
+	}
+}
+
 // ExecuteSignedBlock adds a block to the blockchain, or returns error.
 // Blocks must be executed in sequence, and be signed by a block publisher node.
+// The input signatures are verified in parallel before the block is executed.
 func (vs *Visor) ExecuteSignedBlock(b coin.SignedBlock) error {
-	return vs.db.Update("ExecuteSignedBlock", func(tx *dbutil.Tx) error {
+	vs.verifyBlockSignatures([]coin.SignedBlock{b})
+
+	if err := vs.db.Update("ExecuteSignedBlock", func(tx *dbutil.Tx) error {
 		return vs.executeSignedBlock(tx, b)
-	})
+	}); err != nil {
+		return err
+	}
+
+	vs.triggerWalletDiscovery()
+	return nil
 }
 
 // ExecuteSignedBlockUnsafe adds block to the blockchain, or returns error.
 // Blocks must be executed in sequence. Block signature is not verified.
 func (vs *Visor) ExecuteSignedBlockUnsafe(b coin.SignedBlock) error {
-	return vs.db.Update("ExecuteSignedBlockUnsafe", func(tx *dbutil.Tx) error {
+	vs.verifyBlockSignatures([]coin.SignedBlock{b})
+
+	if err := vs.db.Update("ExecuteSignedBlockUnsafe", func(tx *dbutil.Tx) error {
 		return vs.executeSignedBlockUnsafe(tx, b)
-	})
+	}); err != nil {
+		return err
+	}
+
+	vs.triggerWalletDiscovery()
+	return nil
+}
+
+// triggerWalletDiscovery schedules the address discovery of the wallets,
+// since the new block may have activity on addresses beyond the gap limit
+func (vs *Visor) triggerWalletDiscovery() {
+	if vs.wallets != nil {
+		vs.wallets.TriggerAddressDiscovery()
+	}
 }
 
 // executeSignedBlock adds a block to the blockchain, or returns error.
@@ -466,8 +550,20 @@ func (vs *Visor) executeSignedBlockUnsafe(tx *dbutil.Tx, b coin.SignedBlock) err
 		return err
 	}
 
-	// Update the HistoryDB
-	return vs.history.ParseBlock(tx, b.Block)
+	// Update the HistoryDB, unless it is being rebuilt, in which case the block is parsed by the rebuild
+	rebuilding, err := vs.history.Rebuilding(tx)
+	if err != nil {
+		return err
+	}
+
+	if !rebuilding {
+		if err := vs.history.ParseBlock(tx, b.Block); err != nil {
+			return err
+		}
+	}
+
+	_, err = vs.pruneBlocksTx(tx, pruneBatchSize)
+	return err
 }
 
 // signBlock signs a block for a block publisher node. Will panic if anything is invalid
@@ -631,7 +727,7 @@ func (vs *Visor) HeadBkSeq() (uint64, bool, error) {
 // GetBlockchainMetadata returns descriptive blockchain information
 func (vs *Visor) GetBlockchainMetadata() (*BlockchainMetadata, error) {
 	var head *coin.SignedBlock
-	var unconfirmedLen, unspentsLen uint64
+	var unconfirmedLen, unspentsLen, lowestBlockSeq uint64
 
 	if err := vs.db.View("GetBlockchainMetadata", func(tx *dbutil.Tx) error {
 		var err error
@@ -646,12 +742,17 @@ func (vs *Visor) GetBlockchainMetadata() (*BlockchainMetadata, error) {
 		}
 
 		unspentsLen, err = vs.blockchain.Unspent().Len(tx)
+		if err != nil {
+			return err
+		}
+
+		lowestBlockSeq, err = vs.blockchain.LowestBlockSeq(tx)
 		return err
 	}); err != nil {
 		return nil, err
 	}
 
-	return NewBlockchainMetadata(*head, unconfirmedLen, unspentsLen)
+	return NewBlockchainMetadata(*head, unconfirmedLen, unspentsLen, lowestBlockSeq)
 }
 
 // GetBlock returns a copy of the block at seq. Returns error if seq out of range
@@ -1009,6 +1110,96 @@ func NewConfirmedTxFilter(isConfirmed bool) TxFilter {
 	return ConfirmedTxFilter{Confirmed: isConfirmed}
 }
 
+// TimeRangeTxFilter filters transactions by time, in unix seconds, from From to To inclusive.
+// The time of a confirmed transaction is the time of its block, the time of an unconfirmed transaction
+// is the time it was received
+type TimeRangeTxFilter struct {
+	From uint64
+	To   uint64
+}
+
+// Match implements the TxFilter interface. Confirmed transactions are selected with the block time index
+func (tf TimeRangeTxFilter) Match(tx *Transaction) bool {
+	return tx.Time >= tf.From && tx.Time <= tf.To
+}
+
+// NewTimeRangeTxFilter collects the transactions whose time is between from and to inclusive
+func NewTimeRangeTxFilter(from, to uint64) TxFilter {
+	return TimeRangeTxFilter{From: from, To: to}
+}
+
+// BlockRangeTxFilter filters confirmed transactions by the seq of their block, from From to To inclusive
+type BlockRangeTxFilter struct {
+	From uint64
+	To   uint64
+}
+
+// Match implements the TxFilter interface. Confirmed transactions are selected with the block time index
+func (bf BlockRangeTxFilter) Match(tx *Transaction) bool {
+	return tx.Status.Confirmed && tx.Status.BlockSeq >= bf.From && tx.Status.BlockSeq <= bf.To
+}
+
+// NewBlockRangeTxFilter collects the confirmed transactions whose block seq is between from and to inclusive
+func NewBlockRangeTxFilter(from, to uint64) TxFilter {
+	return BlockRangeTxFilter{From: from, To: to}
+}
+
+// CoinsTxFilter filters transactions by the total coins of their outputs, in droplets, from Min to Max inclusive
+type CoinsTxFilter struct {
+	Min uint64
+	Max uint64
+}
+
+// Match implements the TxFilter interface. Confirmed transactions are selected with the output value index
+func (cf CoinsTxFilter) Match(tx *Transaction) bool {
+	coins, err := tx.Transaction.OutputCoins()
+	if err != nil {
+		return false
+	}
+	return coins >= cf.Min && coins <= cf.Max
+}
+
+// NewCoinsTxFilter collects the transactions whose outputs total between min and max droplets inclusive
+func NewCoinsTxFilter(min, max uint64) TxFilter {
+	return CoinsTxFilter{Min: min, Max: max}
+}
+
+// SenderAddrsFilter filters transactions that spend outputs owned by any of the addresses
+type SenderAddrsFilter struct {
+	Addrs []cipher.Address
+}
+
+// Match implements the TxFilter interface, this actually won't be used, the transaction model
+// matches the outputs spent by the transaction with the outputs received by the addresses.
+func (sf SenderAddrsFilter) Match(tx *Transaction) bool { return true }
+
+// NewSenderAddrsFilter collects the transactions sent from any of the addresses
+func NewSenderAddrsFilter(addrs []cipher.Address) TxFilter {
+	return SenderAddrsFilter{Addrs: addrs}
+}
+
+// ReceiverAddrsFilter filters transactions that create outputs owned by any of the addresses
+type ReceiverAddrsFilter struct {
+	Addrs []cipher.Address
+}
+
+// Match implements the TxFilter interface
+func (rf ReceiverAddrsFilter) Match(tx *Transaction) bool {
+	for _, o := range tx.Transaction.Out {
+		for _, a := range rf.Addrs {
+			if o.Address == a {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NewReceiverAddrsFilter collects the transactions received by any of the addresses
+func NewReceiverAddrsFilter(addrs []cipher.Address) TxFilter {
+	return ReceiverAddrsFilter{Addrs: addrs}
+}
+
 // GetTransactions returns transactions that can pass the filters with page.
 // If no filters is provided, returns all transactions.
 func (vs *Visor) GetTransactions(flts []TxFilter, order SortOrder, page *PageIndex) ([]Transaction, uint64, error) {
@@ -2217,6 +2408,11 @@ func (vs *Visor) ScanWalletAddresses(wltID string, password []byte, num uint64)
 	return vs.wallets.ScanAddresses(wltID, password, num, vs.tf)
 }
 
+// DiscoverWalletAddresses runs the gap limit address discovery of a wallet
+func (vs *Visor) DiscoverWalletAddresses(wltID string) ([]cipher.Address, error) {
+	return vs.wallets.DiscoverAddresses(wltID, vs.tf)
+}
+
 // TransactionsFinder returns a transactions finder
 func (vs *Visor) TransactionsFinder() wallet.TransactionsFinder {
 	return newTransactionsFinder(vs)
@@ -2927,3 +2927,26 @@ func TestTransactionsFinder(t *testing.T) {
 		})
 	}
 }
+
+func TestIsInDirectory(t *testing.T) {
+	cases := []struct {
+		path string
+		dir  string
+		ok   bool
+	}{
+		{"/data/backup.db", "/data", true},
+		{"/data/backups/backup.db", "/data/", true},
+		{"/data", "/data", false},
+		{"/data/../etc/passwd", "/data", false},
+		{"/data2/backup.db", "/data", false},
+		{"/etc/backup.db", "/data", false},
+		{"backup.db", "/data", false},
+		{"/data/backup.db", "", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(fmt.Sprintf("%s in %s", tc.path, tc.dir), func(t *testing.T) {
+			require.Equal(t, tc.ok, isInDirectory(tc.path, tc.dir))
+		})
+	}
+}
@@ -49,22 +49,30 @@ var (
 	ErrNoSpendableOutputs = NewUserError(errors.New("All selected outputs are unavailable for spending"))
 )
 
-// GetWalletBalance returns balance pairs of specific wallet
-func (vs *Visor) GetWalletBalance(wltID string) (wallet.BalancePair, wallet.AddressBalances, error) {
+// GetWalletBalance returns balance pairs of specific wallet.
+// For a bip44 wallet, only the addresses of the account given with wallet.OptionAccount are counted, account 0 by default
+func (vs *Visor) GetWalletBalance(wltID string, options ...wallet.Option) (wallet.BalancePair, wallet.AddressBalances, error) {
 	var addressBalances wallet.AddressBalances
 	var walletBalance wallet.BalancePair
 	var addrsBalanceList []wallet.BalancePair
 	var addrs []cipher.Address
 
 	if err := vs.wallets.View(wltID, func(w wallet.Wallet) error {
+		if err := wallet.CheckAccountOption(w, options...); err != nil {
+			return err
+		}
+
 		var err error
 		addrs, err = func() ([]cipher.Address, error) {
-			addrs, err := w.GetAddresses()
+			addrs, err := w.GetAddresses(options...)
 			if err != nil {
 				return nil, err
 			}
 			return wallet.SkycoinAddresses(addrs), nil
 		}()
+		if err != nil {
+			return err
+		}
 
 		addrsBalanceList, err = vs.GetBalanceOfAddresses(addrs)
 		return err
@@ -153,6 +161,19 @@ func (vs *Visor) GetWalletUnconfirmedTransactionsVerbose(wltID string) ([]Unconf
 // WalletSignTransaction signs a transaction. Specific inputs may be signed by specifying signIndexes.
 // If signIndexes is empty, all inputs will be signed. The transaction must be fully valid and spendable.
 func (vs *Visor) WalletSignTransaction(wltID string, password []byte, txn *coin.Transaction, signIndexes []int) (*coin.Transaction, []TransactionInput, error) {
+	return vs.walletSignTransaction(func(f func(wallet.Wallet) error) error {
+		return vs.wallets.ViewSecrets(wltID, password, f)
+	}, txn, signIndexes)
+}
+
+// WalletSignTransactionWithSession signs a transaction with a wallet unlocked by wallet.Service.UnlockWallet
+func (vs *Visor) WalletSignTransactionWithSession(wltID, token string, txn *coin.Transaction, signIndexes []int) (*coin.Transaction, []TransactionInput, error) {
+	return vs.walletSignTransaction(func(f func(wallet.Wallet) error) error {
+		return vs.wallets.ViewSecretsWithSession(wltID, token, f)
+	}, txn, signIndexes)
+}
+
+func (vs *Visor) walletSignTransaction(viewSecrets func(func(wallet.Wallet) error) error, txn *coin.Transaction, signIndexes []int) (*coin.Transaction, []TransactionInput, error) {
 	var inputs []TransactionInput
 	var signedTxn *coin.Transaction
 
@@ -160,7 +181,7 @@ func (vs *Visor) WalletSignTransaction(wltID string, password []byte, txn *coin.
 		return nil, nil, ErrTransactionAlreadySigned
 	}
 
-	if err := vs.wallets.ViewSecrets(wltID, password, func(w wallet.Wallet) error {
+	if err := viewSecrets(func(w wallet.Wallet) error {
 		return vs.db.View("WalletSignTransaction", func(tx *dbutil.Tx) error {
 			// Verify the transaction before signing
 			if err := transaction.VerifySingleTxnUserConstraints(*txn); err != nil {
@@ -225,6 +246,8 @@ type CreateTransactionParams struct {
 	// IgnoreUnconfirmed if true, outputs matching Addresses or UxOuts spent by
 	// an unconfirmed transactions will be ignored, otherwise an error will be returned
 	IgnoreUnconfirmed bool
+	// Account is the bip44 account to spend from, only account 0 can be used for other wallet types
+	Account uint32
 }
 
 // Validate validates params
@@ -261,6 +284,24 @@ func (p CreateTransactionParams) Validate() error {
 
 // WalletCreateTransactionSigned creates a signed transaction based upon the parameters in CreateTransactionParams
 func (vs *Visor) WalletCreateTransactionSigned(wltID string, password []byte, p transaction.Params, wp CreateTransactionParams) (*coin.Transaction, []TransactionInput, error) {
+	return vs.walletCreateTransactionSigned(wltID, func(f func(wallet.Wallet) error) error {
+		return vs.wallets.Update(wltID, f)
+	}, func(f func(wallet.Wallet) error) error {
+		return vs.wallets.ViewSecrets(wltID, password, f)
+	}, p, wp)
+}
+
+// WalletCreateTransactionSignedWithSession creates a signed transaction with a wallet unlocked by wallet.Service.UnlockWallet
+func (vs *Visor) WalletCreateTransactionSignedWithSession(wltID, token string, p transaction.Params, wp CreateTransactionParams) (*coin.Transaction, []TransactionInput, error) {
+	return vs.walletCreateTransactionSigned(wltID, func(f func(wallet.Wallet) error) error {
+		// The change address is also peeked in the decrypted copy of the session, so that the session is kept
+		return vs.wallets.UpdateWithSession(wltID, token, f)
+	}, func(f func(wallet.Wallet) error) error {
+		return vs.wallets.ViewSecretsWithSession(wltID, token, f)
+	}, p, wp)
+}
+
+func (vs *Visor) walletCreateTransactionSigned(wltID string, update, viewSecrets func(func(wallet.Wallet) error) error, p transaction.Params, wp CreateTransactionParams) (*coin.Transaction, []TransactionInput, error) {
 	// Validate params before unlocking wallet
 	if err := p.Validate(); err != nil {
 		return nil, nil, err
@@ -282,9 +323,13 @@ func (vs *Visor) WalletCreateTransactionSigned(wltID string, password []byte, p
 		// we don't have to explicitly check the wallet type here.
 		//
 		// For bip44 wallet, peek a change address if p.ChangeAddress is nill
-		if err := vs.wallets.Update(wltID, func(w wallet.Wallet) error {
-			addr, err := w.(*bip44wallet.Wallet).PeekChangeAddress(vs.tf)
-			if err != nil {
+		if err := update(func(w wallet.Wallet) error {
+			addr, err := w.(*bip44wallet.Wallet).PeekChangeAddress(vs.tf, wallet.OptionAccount(wp.Account))
+			switch err {
+			case nil:
+			case wallet.ErrBip44AccountNotExist:
+				return err
+			default:
 				logger.Critical().WithError(err).Error("PeekChangeAddress failed")
 				return err
 			}
@@ -296,7 +341,7 @@ func (vs *Visor) WalletCreateTransactionSigned(wltID string, password []byte, p
 		}
 	}
 
-	if err := vs.wallets.ViewSecrets(wltID, password, func(w wallet.Wallet) error {
+	if err := viewSecrets(func(w wallet.Wallet) error {
 		var err error
 		txn, inputs, err = vs.walletCreateTransaction("WalletCreateTransactionSigned", w, p, wp, transaction.TxnSigned)
 		return err
@@ -328,8 +373,12 @@ func (vs *Visor) WalletCreateTransaction(wltID string, p transaction.Params, wp
 			// we don't have to explicitly check the wallet type here.
 			//
 			// For bip44 wallet, peek a change address if p.ChangeAddress is nill
-			addr, err := w.(*bip44wallet.Wallet).PeekChangeAddress(vs.tf)
-			if err != nil {
+			addr, err := w.(*bip44wallet.Wallet).PeekChangeAddress(vs.tf, wallet.OptionAccount(wp.Account))
+			switch err {
+			case nil:
+			case wallet.ErrBip44AccountNotExist:
+				return err
+			default:
 				logger.Critical().WithError(err).Error("PeekChangeAddress failed")
 				return err
 			}
@@ -355,9 +404,13 @@ func (vs *Visor) walletCreateTransaction(methodName string, w wallet.Wallet, p t
 		return nil, nil, err
 	}
 
-	// Get all addresses from the wallet for checking params against
+	if err := wallet.CheckAccountOption(w, wallet.OptionAccount(wp.Account)); err != nil {
+		return nil, nil, err
+	}
+
+	// Get all addresses of the selected account from the wallet for checking params against
 	walletAddresses, err := func() ([]cipher.Address, error) {
-		addrs, err := w.GetAddresses()
+		addrs, err := w.GetAddresses(wallet.OptionAccount(wp.Account))
 		if err != nil {
 			return nil, err
 		}
@@ -441,9 +494,9 @@ func (vs *Visor) walletCreateTransactionTx(tx *dbutil.Tx, methodName string,
 
 	switch signed {
 	case transaction.TxnSigned:
-		txn, uxb, err = wallet.CreateTransactionSigned(w, p, auxs, head.Time())
+		txn, uxb, err = wallet.CreateTransactionSigned(w, p, auxs, head.Time(), wallet.OptionAccount(wp.Account))
 	case transaction.TxnUnsigned:
-		txn, uxb, err = wallet.CreateTransaction(w, p, auxs, head.Time())
+		txn, uxb, err = wallet.CreateTransaction(w, p, auxs, head.Time(), wallet.OptionAccount(wp.Account))
 	default:
 		logger.Panic("Invalid TxnSignedFlag")
 	}
@@ -0,0 +1,164 @@
+package wallet
+
+import (
+	"path/filepath"
+
+	"github.com/skycoin/skycoin/src/util/file"
+)
+
+// bip44AccountsWallet is implemented by wallets that manage bip44 accounts
+type bip44AccountsWallet interface {
+	Wallet
+	NewAccount(name string) (uint32, error)
+	RenameAccount(index uint32, name string) error
+}
+
+// CheckAccountOption returns ErrWalletNotBip44 if a bip44 account other than
+// account 0 is selected with OptionAccount for a wallet of another type
+func CheckAccountOption(w Wallet, options ...Option) error {
+	var opts Bip44EntriesOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	if opts.Account != 0 && w.Type() != WalletTypeBip44 {
+		return ErrWalletNotBip44
+	}
+	return nil
+}
+
+// GetBip44Accounts returns the accounts of a bip44 wallet
+func (serv *Service) GetBip44Accounts(wltID string) ([]Bip44Account, error) {
+	serv.RLock()
+	defer serv.RUnlock()
+	if !serv.config.EnableWalletAPI {
+		return nil, ErrWalletAPIDisabled
+	}
+
+	w, err := serv.getWallet(wltID)
+	if err != nil {
+		return nil, err
+	}
+
+	if w.Type() != WalletTypeBip44 {
+		return nil, ErrWalletNotBip44
+	}
+
+	return w.Accounts(), nil
+}
+
+// NewBip44Account creates a new account in a bip44 wallet.
+// The account keys are derived from the wallet seed, so the password
+// must be provided if the wallet is encrypted.
+func (serv *Service) NewBip44Account(wltID, name string, password []byte) (*Bip44Account, error) {
+	serv.Lock()
+	defer serv.Unlock()
+	if !serv.config.EnableWalletAPI {
+		return nil, ErrWalletAPIDisabled
+	}
+
+	w, err := serv.getWallet(wltID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateBip44AccountName(w, name, nil); err != nil {
+		return nil, err
+	}
+
+	var index uint32
+	f := func(w Wallet) error {
+		var err error
+		index, err = w.(bip44AccountsWallet).NewAccount(name)
+		return err
+	}
+
+	if w.IsEncrypted() {
+		if err := GuardUpdate(w, password, f); err != nil {
+			return nil, err
+		}
+	} else if len(password) != 0 {
+		return nil, ErrWalletNotEncrypted
+	} else if err := f(w); err != nil {
+		return nil, err
+	}
+
+	if err := serv.saveWallet(w); err != nil {
+		return nil, err
+	}
+
+	return &Bip44Account{
+		Name:  name,
+		Index: index,
+	}, nil
+}
+
+// RenameBip44Account renames an account of a bip44 wallet
+func (serv *Service) RenameBip44Account(wltID string, index uint32, name string) error {
+	serv.Lock()
+	defer serv.Unlock()
+	if !serv.config.EnableWalletAPI {
+		return ErrWalletAPIDisabled
+	}
+
+	w, err := serv.getWallet(wltID)
+	if err != nil {
+		return err
+	}
+
+	if err := validateBip44AccountName(w, name, &index); err != nil {
+		return err
+	}
+
+	if err := w.(bip44AccountsWallet).RenameAccount(index, name); err != nil {
+		return err
+	}
+
+	return serv.saveWallet(w)
+}
+
+// saveWallet saves a wallet to disk and updates it in memory, temporary wallets are only updated in memory.
+// The service must be write locked.
+func (serv *Service) saveWallet(w Wallet) error {
+	if !w.IsTemp() {
+		// Checks if the wallet file is writable
+		wf := filepath.Join(serv.config.WalletDir, w.Filename())
+		if !file.IsWritable(wf) {
+			return ErrWalletPermission
+		}
+
+		if err := Save(w, serv.config.WalletDir); err != nil {
+			return err
+		}
+	}
+
+	serv.wallets.set(w)
+	return nil
+}
+
+// validateBip44AccountName checks that the wallet manages bip44 accounts and the
+// account name is not empty and not used by other accounts than the one of index skip
+func validateBip44AccountName(w Wallet, name string, skip *uint32) error {
+	if w.Type() != WalletTypeBip44 {
+		return ErrWalletNotBip44
+	}
+
+	if _, ok := w.(bip44AccountsWallet); !ok {
+		return ErrWalletNotBip44
+	}
+
+	if name == "" {
+		return ErrMissingBip44AccountName
+	}
+
+	for _, a := range w.Accounts() {
+		if skip != nil && a.Index == *skip {
+			continue
+		}
+		if a.Name == name {
+			return ErrBip44AccountNameConflict
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package wallet_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher/bip39"
+	"github.com/skycoin/skycoin/src/cipher/crypto"
+	"github.com/skycoin/skycoin/src/wallet"
+	"github.com/skycoin/skycoin/src/wallet/bip44wallet"
+)
+
+func TestServiceBip44Accounts(t *testing.T) {
+	for _, encrypt := range []bool{false, true} {
+		t.Run("encrypted="+map[bool]string{true: "true", false: "false"}[encrypt], func(t *testing.T) {
+			dir := prepareWltDir()
+			s, err := wallet.NewService(wallet.Config{
+				WalletDir:       dir,
+				CryptoType:      crypto.CryptoTypeSha256Xor,
+				EnableWalletAPI: true,
+			})
+			require.NoError(t, err)
+
+			var password []byte
+			if encrypt {
+				password = []byte("pwd")
+			}
+
+			seed := bip39.MustNewDefaultMnemonic()
+			_, err = s.CreateWallet("t.wlt", wallet.Options{
+				Seed:     seed,
+				Label:    "label",
+				Type:     wallet.WalletTypeBip44,
+				Encrypt:  encrypt,
+				Password: password,
+			})
+			require.NoError(t, err)
+
+			_, err = s.CreateWallet("d.wlt", wallet.Options{
+				Seed:  "fooseed",
+				Label: "label",
+				Type:  wallet.WalletTypeDeterministic,
+			})
+			require.NoError(t, err)
+
+			accounts, err := s.GetBip44Accounts("t.wlt")
+			require.NoError(t, err)
+			require.Equal(t, []wallet.Bip44Account{
+				{Name: bip44wallet.DefaultAccountName, Index: 0},
+			}, accounts)
+
+			_, err = s.GetBip44Accounts("d.wlt")
+			require.Equal(t, wallet.ErrWalletNotBip44, err)
+
+			_, err = s.GetBip44Accounts("none.wlt")
+			require.Equal(t, wallet.ErrWalletNotExist, err)
+
+			_, err = s.NewBip44Account("d.wlt", "savings", nil)
+			require.Equal(t, wallet.ErrWalletNotBip44, err)
+
+			_, err = s.NewBip44Account("t.wlt", "", password)
+			require.Equal(t, wallet.ErrMissingBip44AccountName, err)
+
+			_, err = s.NewBip44Account("t.wlt", bip44wallet.DefaultAccountName, password)
+			require.Equal(t, wallet.ErrBip44AccountNameConflict, err)
+
+			if encrypt {
+				_, err = s.NewBip44Account("t.wlt", "savings", nil)
+				require.Equal(t, wallet.ErrMissingPassword, err)
+
+				_, err = s.NewBip44Account("t.wlt", "savings", []byte("wrong"))
+				require.Equal(t, wallet.ErrInvalidPassword, err)
+			} else {
+				_, err = s.NewBip44Account("t.wlt", "savings", []byte("pwd"))
+				require.Equal(t, wallet.ErrWalletNotEncrypted, err)
+			}
+
+			a, err := s.NewBip44Account("t.wlt", "savings", password)
+			require.NoError(t, err)
+			require.Equal(t, &wallet.Bip44Account{Name: "savings", Index: 1}, a)
+
+			// Addresses are generated on the selected account
+			addrs, err := s.NewAddresses("t.wlt", password, wallet.OptionAccount(1), wallet.OptionGenerateN(2))
+			require.NoError(t, err)
+			require.Len(t, addrs, 2)
+
+			account1Addrs, err := s.GetAddresses("t.wlt", wallet.OptionAccount(1))
+			require.NoError(t, err)
+			require.Equal(t, addrs, account1Addrs)
+
+			account0Addrs, err := s.GetAddresses("t.wlt")
+			require.NoError(t, err)
+			require.NotContains(t, account0Addrs, addrs[0])
+
+			_, err = s.NewAddresses("t.wlt", password, wallet.OptionAccount(2))
+			require.Equal(t, wallet.ErrBip44AccountNotExist, err)
+
+			// Rename accounts
+			require.Equal(t, wallet.ErrBip44AccountNameConflict, s.RenameBip44Account("t.wlt", 1, bip44wallet.DefaultAccountName))
+			require.Equal(t, wallet.ErrMissingBip44AccountName, s.RenameBip44Account("t.wlt", 1, ""))
+			require.Equal(t, wallet.ErrBip44AccountNotExist, s.RenameBip44Account("t.wlt", 2, "foo"))
+			require.NoError(t, s.RenameBip44Account("t.wlt", 1, "savings"))
+			require.NoError(t, s.RenameBip44Account("t.wlt", 1, "vault"))
+
+			// Changes are persisted
+			s, err = wallet.NewService(wallet.Config{
+				WalletDir:       dir,
+				CryptoType:      crypto.CryptoTypeSha256Xor,
+				EnableWalletAPI: true,
+			})
+			require.NoError(t, err)
+
+			accounts, err = s.GetBip44Accounts("t.wlt")
+			require.NoError(t, err)
+			require.Equal(t, []wallet.Bip44Account{
+				{Name: bip44wallet.DefaultAccountName, Index: 0},
+				{Name: "vault", Index: 1},
+			}, accounts)
+
+			w, err := s.GetWallet("t.wlt")
+			require.NoError(t, err)
+			require.Equal(t, encrypt, w.IsEncrypted())
+
+			account1Addrs, err = s.GetAddresses("t.wlt", wallet.OptionAccount(1))
+			require.NoError(t, err)
+			require.Equal(t, addrs, account1Addrs)
+
+			if encrypt {
+				// The new account secrets are encrypted with the wallet
+				err = s.ViewSecrets("t.wlt", password, func(w wallet.Wallet) error {
+					e, err := w.GetEntry(addrs[0], wallet.OptionAccount(1))
+					require.NoError(t, err)
+					require.False(t, e.Secret.Null())
+					return nil
+				})
+				require.NoError(t, err)
+
+				// Accounts and their addresses are recreated when recovering the wallet
+				_, err = s.RecoverWallet("t.wlt", seed, "", []byte("pwd2"))
+				require.NoError(t, err)
+
+				accounts, err = s.GetBip44Accounts("t.wlt")
+				require.NoError(t, err)
+				require.Equal(t, []wallet.Bip44Account{
+					{Name: bip44wallet.DefaultAccountName, Index: 0},
+					{Name: "vault", Index: 1},
+				}, accounts)
+
+				account1Addrs, err = s.GetAddresses("t.wlt", wallet.OptionAccount(1))
+				require.NoError(t, err)
+				require.Equal(t, addrs, account1Addrs)
+			}
+		})
+	}
+}
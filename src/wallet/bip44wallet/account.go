@@ -387,7 +387,7 @@ func (a *bip44Accounts) newAddresses(account, chain, num uint32) ([]cipher.Addre
 func (a bip44Accounts) account(index uint32) (*bip44Account, error) {
 	accountLen := len(a.accounts)
 	if int(index) >= accountLen {
-		return nil, fmt.Errorf("account index %d out of range", index)
+		return nil, wallet.ErrBip44AccountNotExist
 	}
 
 	act := a.accounts[index]
@@ -253,6 +253,17 @@ func (w *Wallet) NewAccount(name string) (uint32, error) {
 	})
 }
 
+// RenameAccount sets the name of the account of given index
+func (w *Wallet) RenameAccount(index uint32, name string) error {
+	a, err := w.accountManager.account(index)
+	if err != nil {
+		return err
+	}
+
+	a.Name = name
+	return nil
+}
+
 // newExternalAddresses generates addresses on external chain of selected account
 func (w *Wallet) newExternalAddresses(account, n uint32) ([]cipher.Addresser, error) {
 	return w.newAddresses(account, bip44.ExternalChainIndex, n)
@@ -521,6 +532,9 @@ func (w *Wallet) Accounts() []wallet.Bip44Account {
 // GetEntries provides entries service to access the external chain of given account
 func (w *Wallet) GetEntries(options ...wallet.Option) (wallet.Entries, error) {
 	opts := getBip44Options(options...)
+	if _, err := w.account(opts.Account); err != nil {
+		return nil, err
+	}
 
 	var entries wallet.Entries
 	switch opts.ChainMode {
@@ -787,16 +801,19 @@ func (w *Wallet) reset() {
 
 // PeekChangeAddress returns the last entry address on change chain if
 // no transactions are found, otherwise, returns with a new address.
-func (w *Wallet) PeekChangeAddress(tf wallet.TransactionsFinder) (cipher.Addresser, error) {
-	onChangeChain := wallet.OptionChange()
-	entries, err := w.GetEntries(onChangeChain)
+// The account can be selected with wallet.OptionAccount, account 0 is used by default.
+func (w *Wallet) PeekChangeAddress(tf wallet.TransactionsFinder, options ...wallet.Option) (cipher.Addresser, error) {
+	onChangeChain := append([]wallet.Option{wallet.OptionChange()}, options...)
+	entries, err := w.GetEntries(onChangeChain...)
 	if err != nil {
 		return nil, err
 	}
 
+	generateOpts := append([]wallet.Option{wallet.OptionGenerateN(1)}, onChangeChain...)
+
 	if len(entries) == 0 {
 		// generate a new address and return
-		addrs, err := w.GenerateAddresses(wallet.OptionGenerateN(1), onChangeChain)
+		addrs, err := w.GenerateAddresses(generateOpts...)
 		if err != nil {
 			return nil, err
 		}
@@ -816,7 +833,7 @@ func (w *Wallet) PeekChangeAddress(tf wallet.TransactionsFinder) (cipher.Address
 	}
 
 	// generate a new address and return it
-	addrs, err := w.GenerateAddresses(wallet.OptionGenerateN(1), onChangeChain)
+	addrs, err := w.GenerateAddresses(generateOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -686,6 +686,22 @@ func TestWalletCreateAccount(t *testing.T) {
 	require.Equal(t, uint32(3), w.accountManager.len())
 }
 
+func TestWalletRenameAccount(t *testing.T) {
+	w, err := NewWallet("test.wlt", "test", testSeed, testSeedPassphrase)
+	require.NoError(t, err)
+
+	_, err = w.NewAccount("account1")
+	require.NoError(t, err)
+
+	require.NoError(t, w.RenameAccount(1, "savings"))
+	require.Equal(t, []wallet.Bip44Account{
+		{Name: DefaultAccountName, Index: 0},
+		{Name: "savings", Index: 1},
+	}, w.Accounts())
+
+	require.Equal(t, wallet.ErrBip44AccountNotExist, w.RenameAccount(2, "foo"))
+}
+
 func TestWalletAccountCreateAddresses(t *testing.T) {
 	w, err := NewWallet(
 		"test.wlt",
@@ -886,6 +902,27 @@ func TestPeekChangeAddress(t *testing.T) {
 	addr, err = w.PeekChangeAddress(mockTxnsFinder{skycoinChangeAddrs[1]: true})
 	require.NoError(t, err)
 	require.Equal(t, skycoinChangeAddrs[2], addr)
+
+	// Peeks the change address of another account
+	_, err = w.PeekChangeAddress(mockTxnsFinder{}, wallet.OptionAccount(1))
+	require.Equal(t, wallet.ErrBip44AccountNotExist, err)
+
+	_, err = w.NewAccount("account1")
+	require.NoError(t, err)
+
+	addr, err = w.PeekChangeAddress(mockTxnsFinder{}, wallet.OptionAccount(1))
+	require.NoError(t, err)
+	require.NotEqual(t, skycoinChangeAddrs[0], addr)
+
+	entries, err := w.GetEntries(wallet.OptionAccount(1), wallet.OptionChange())
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, addr, entries[0].Address)
+
+	// account 0 is not touched
+	entries, err = w.GetEntries(wallet.OptionChange())
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
 }
 
 func TestScanAddresses(t *testing.T) {
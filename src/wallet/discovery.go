@@ -0,0 +1,304 @@
+package wallet
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+// DefaultGapLimit is the number of consecutive unused addresses after which
+// address discovery stops scanning a chain, as recommended by BIP44
+const DefaultGapLimit = 20
+
+// DiscoveryStatus is the address discovery progress of a wallet
+type DiscoveryStatus struct {
+	WalletID string
+	GapLimit uint64
+	// Running is true while the discovery of the wallet is in progress
+	Running bool
+	// Scanned is the number of addresses checked by the current or last discovery
+	Scanned uint64
+	// Found is the number of addresses added to the wallet by the last discovery
+	Found uint64
+	// LastRun is the time the last discovery finished
+	LastRun time.Time
+	// Error is the error of the last discovery, if any
+	Error string
+}
+
+// DiscoverAddresses scans each address chain of the wallet in windows of gapLimit addresses
+// and extends the chain up to the last address with any transaction history, until a window
+// has no activity. All external and change chains of all accounts are scanned for bip44 wallets,
+// and all xpub keys are scanned for watch-only wallets.
+// Wallets that can't generate addresses without the password are not scanned.
+// progress is called with the total number of scanned addresses after each window.
+func DiscoverAddresses(w Wallet, gapLimit uint64, tf TransactionsFinder, progress func(scanned uint64)) ([]cipher.Addresser, error) {
+	if gapLimit == 0 {
+		return nil, ErrInvalidGapLimit
+	}
+
+	var found []cipher.Addresser
+	var scanned uint64
+	for _, chain := range discoveryChains(w) {
+		for {
+			// Generates the addresses to scan on a copy of the wallet
+			addrs, err := w.Clone().GenerateAddresses(append([]Option{OptionGenerateN(gapLimit)}, chain...)...)
+			if err != nil {
+				return nil, err
+			}
+
+			active, err := tf.AddressesActivity(addrs)
+			if err != nil {
+				return nil, err
+			}
+
+			scanned += uint64(len(addrs))
+			if progress != nil {
+				progress(scanned)
+			}
+
+			// Checks activity from the last one until we find the address that has activity
+			var keepNum uint64
+			for i := len(active) - 1; i >= 0; i-- {
+				if active[i] {
+					keepNum = uint64(i + 1)
+					break
+				}
+			}
+
+			if keepNum == 0 {
+				break
+			}
+
+			addrs, err = w.GenerateAddresses(append([]Option{OptionGenerateN(keepNum)}, chain...)...)
+			if err != nil {
+				return nil, err
+			}
+			found = append(found, addrs...)
+		}
+	}
+
+	return found, nil
+}
+
+// discoveryChains returns the options selecting each address chain scanned by address discovery
+func discoveryChains(w Wallet) [][]Option {
+	switch w.Type() {
+	case WalletTypeBip44:
+		var chains [][]Option
+		for _, a := range w.Accounts() {
+			chains = append(chains,
+				[]Option{OptionAccount(a.Index), OptionExternal()},
+				[]Option{OptionAccount(a.Index), OptionChange()})
+		}
+		return chains
+	case WalletTypeXPub:
+		return [][]Option{nil}
+	case WalletTypeWatchOnly:
+		ww, ok := w.(WatchWallet)
+		if !ok {
+			return nil
+		}
+		// The imported addresses are not derived from a key, only the xpub keys are scanned
+		chains := make([][]Option, len(ww.XPubs()))
+		for i := range chains {
+			chains[i] = []Option{OptionWatchXPub(uint32(i))}
+		}
+		return chains
+	case WalletTypeDeterministic:
+		// Deterministic wallets derive addresses from the seed, which is not visible in encrypted wallets
+		if w.IsEncrypted() {
+			return nil
+		}
+		return [][]Option{nil}
+	default:
+		return nil
+	}
+}
+
+// DiscoverAddresses runs the address discovery of a wallet, using the wallet's gap limit.
+// The addresses added to the wallet are returned.
+func (serv *Service) DiscoverAddresses(wltID string, tf TransactionsFinder) ([]cipher.Address, error) {
+	serv.Lock()
+	defer serv.Unlock()
+	if !serv.config.EnableWalletAPI {
+		return nil, ErrWalletAPIDisabled
+	}
+
+	return serv.discoverAddresses(wltID, tf)
+}
+
+func (serv *Service) discoverAddresses(wltID string, tf TransactionsFinder) ([]cipher.Address, error) {
+	w, err := serv.getWallet(wltID)
+	if err != nil {
+		return nil, err
+	}
+
+	gapLimit := w.GapLimit()
+	serv.updateDiscoveryStatus(wltID, func(s *DiscoveryStatus) {
+		s.GapLimit = gapLimit
+		s.Running = true
+		s.Scanned = 0
+	})
+
+	addrs, err := DiscoverAddresses(w, gapLimit, tf, func(scanned uint64) {
+		serv.updateDiscoveryStatus(wltID, func(s *DiscoveryStatus) {
+			s.Scanned = scanned
+		})
+	})
+	if err == nil && len(addrs) != 0 {
+		err = serv.saveWallet(w)
+	}
+
+	serv.updateDiscoveryStatus(wltID, func(s *DiscoveryStatus) {
+		s.Running = false
+		s.LastRun = time.Now().UTC()
+		s.Found = 0
+		s.Error = ""
+		if err != nil {
+			s.Error = err.Error()
+		} else {
+			s.Found = uint64(len(addrs))
+		}
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return SkycoinAddresses(addrs), nil
+}
+
+// DiscoverAllAddresses runs the address discovery of all loaded wallets.
+// The service is locked for one wallet at a time, so other wallet operations are not blocked for long.
+func (serv *Service) DiscoverAllAddresses(tf TransactionsFinder) {
+	serv.RLock()
+	wltIDs := make([]string, 0, len(serv.wallets))
+	for id := range serv.wallets {
+		wltIDs = append(wltIDs, id)
+	}
+	serv.RUnlock()
+
+	for _, id := range wltIDs {
+		serv.Lock()
+		addrs, err := serv.discoverAddresses(id, tf)
+		serv.Unlock()
+
+		switch err {
+		case nil:
+			if len(addrs) != 0 {
+				logger.WithFields(logrus.Fields{
+					"walletID": id,
+					"found":    len(addrs),
+				}).Info("Address discovery extended wallet")
+			}
+		case ErrWalletNotExist:
+			// The wallet was unloaded
+		default:
+			logger.WithError(err).WithField("walletID", id).Error("Address discovery failed")
+		}
+	}
+}
+
+// RunAddressDiscovery runs the address discovery of all wallets once, then each time
+// TriggerAddressDiscovery is called, until quit is closed
+func (serv *Service) RunAddressDiscovery(tf TransactionsFinder, quit <-chan struct{}) {
+	if !serv.config.EnableWalletAPI || serv.config.DisableAddressDiscovery {
+		return
+	}
+
+	serv.TriggerAddressDiscovery()
+
+	for {
+		select {
+		case <-quit:
+			return
+		case <-serv.discoveryC:
+			serv.DiscoverAllAddresses(tf)
+		}
+	}
+}
+
+// TriggerAddressDiscovery schedules the address discovery of all wallets, e.g. after new blocks
+// are executed. It does not block, and has no effect if RunAddressDiscovery is not running.
+func (serv *Service) TriggerAddressDiscovery() {
+	select {
+	case serv.discoveryC <- struct{}{}:
+	default:
+	}
+}
+
+// GetDiscoveryStatus returns the address discovery status of a wallet
+func (serv *Service) GetDiscoveryStatus(wltID string) (*DiscoveryStatus, error) {
+	serv.RLock()
+	defer serv.RUnlock()
+	if !serv.config.EnableWalletAPI {
+		return nil, ErrWalletAPIDisabled
+	}
+
+	w := serv.wallets.get(wltID)
+	if w == nil {
+		return nil, ErrWalletNotExist
+	}
+
+	serv.discoveryLock.Lock()
+	defer serv.discoveryLock.Unlock()
+
+	s := DiscoveryStatus{
+		WalletID: wltID,
+		GapLimit: w.GapLimit(),
+	}
+	if ds, ok := serv.discovery[wltID]; ok {
+		s = *ds
+		if !s.Running {
+			s.GapLimit = w.GapLimit()
+		}
+	}
+
+	return &s, nil
+}
+
+// SetGapLimit sets the address discovery gap limit of a wallet
+func (serv *Service) SetGapLimit(wltID string, gapLimit uint64) error {
+	serv.Lock()
+	defer serv.Unlock()
+	if !serv.config.EnableWalletAPI {
+		return ErrWalletAPIDisabled
+	}
+
+	if gapLimit == 0 {
+		return ErrInvalidGapLimit
+	}
+
+	w, err := serv.getWallet(wltID)
+	if err != nil {
+		return err
+	}
+
+	w.SetGapLimit(gapLimit)
+
+	if err := serv.saveWallet(w); err != nil {
+		return err
+	}
+
+	serv.TriggerAddressDiscovery()
+	return nil
+}
+
+// updateDiscoveryStatus updates the address discovery status of a wallet
+func (serv *Service) updateDiscoveryStatus(wltID string, f func(s *DiscoveryStatus)) {
+	serv.discoveryLock.Lock()
+	defer serv.discoveryLock.Unlock()
+
+	s, ok := serv.discovery[wltID]
+	if !ok {
+		s = &DiscoveryStatus{
+			WalletID: wltID,
+		}
+		serv.discovery[wltID] = s
+	}
+
+	f(s)
+}
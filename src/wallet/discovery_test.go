@@ -0,0 +1,213 @@
+package wallet_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/bip39"
+	"github.com/skycoin/skycoin/src/cipher/bip44"
+	"github.com/skycoin/skycoin/src/cipher/crypto"
+	"github.com/skycoin/skycoin/src/wallet"
+	"github.com/skycoin/skycoin/src/wallet/bip44wallet"
+)
+
+// chainAddresses returns the first n addresses of the chain selected by options
+func chainAddresses(t *testing.T, w wallet.Wallet, n int, options ...wallet.Option) []cipher.Addresser {
+	w2 := w.Clone()
+	l, err := w2.EntriesLen(options...)
+	require.NoError(t, err)
+	_, err = w2.GenerateAddresses(append(options, wallet.OptionGenerateN(uint64(n-l)))...)
+	require.NoError(t, err)
+	addrs, err := w2.GetAddresses(options...)
+	require.NoError(t, err)
+	require.Len(t, addrs, n)
+	return addrs
+}
+
+func TestDiscoverAddresses(t *testing.T) {
+	newBip44Wallet := func(t *testing.T) wallet.Wallet {
+		bc := bip44.CoinTypeSkycoin
+		w, err := wallet.NewWallet("t.wlt", "label", bip39.MustNewDefaultMnemonic(), wallet.Options{
+			Type:      wallet.WalletTypeBip44,
+			Coin:      wallet.CoinTypeSkycoin,
+			Bip44Coin: &bc,
+			GenerateN: 1,
+		})
+		require.NoError(t, err)
+		_, err = w.(*bip44wallet.Wallet).NewAccount("savings")
+		require.NoError(t, err)
+		return w
+	}
+
+	t.Run("bip44", func(t *testing.T) {
+		w := newBip44Wallet(t)
+
+		external := chainAddresses(t, w, 60, wallet.OptionAccount(0), wallet.OptionExternal())
+		change := chainAddresses(t, w, 60, wallet.OptionAccount(1), wallet.OptionChange())
+		tf := mockTxnsFinder{
+			external[5]:  true,
+			external[24]: true,
+			change[3]:    true,
+		}
+
+		var progress []uint64
+		addrs, err := wallet.DiscoverAddresses(w, 20, tf, func(scanned uint64) {
+			progress = append(progress, scanned)
+		})
+		require.NoError(t, err)
+
+		// The change address is not found by the discovery of the external chain
+		n, err := w.EntriesLen(wallet.OptionAccount(0), wallet.OptionExternal())
+		require.NoError(t, err)
+		require.Equal(t, 25, n)
+		n, err = w.EntriesLen(wallet.OptionAccount(0), wallet.OptionChange())
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+		n, err = w.EntriesLen(wallet.OptionAccount(1), wallet.OptionExternal())
+		require.NoError(t, err)
+		require.Equal(t, 0, n)
+		n, err = w.EntriesLen(wallet.OptionAccount(1), wallet.OptionChange())
+		require.NoError(t, err)
+		require.Equal(t, 4, n)
+
+		require.Equal(t, append(external[1:25:25], change[:4]...), addrs)
+		// 3 windows on the external chain of account 0, 1 window on the other chains
+		require.Equal(t, []uint64{20, 40, 60, 80, 100, 120, 140}, progress)
+
+		// Nothing more is found
+		addrs, err = wallet.DiscoverAddresses(w, 20, tf, nil)
+		require.NoError(t, err)
+		require.Empty(t, addrs)
+	})
+
+	t.Run("bip44 smaller gap limit", func(t *testing.T) {
+		w := newBip44Wallet(t)
+
+		external := chainAddresses(t, w, 30, wallet.OptionAccount(0), wallet.OptionExternal())
+		tf := mockTxnsFinder{
+			external[5]:  true,
+			external[24]: true,
+		}
+
+		addrs, err := wallet.DiscoverAddresses(w, 10, tf, nil)
+		require.NoError(t, err)
+		require.Equal(t, external[1:6], addrs)
+	})
+
+	t.Run("deterministic", func(t *testing.T) {
+		w, err := wallet.NewWallet("t.wlt", "label", "seed", wallet.Options{
+			Type:      wallet.WalletTypeDeterministic,
+			Coin:      wallet.CoinTypeSkycoin,
+			GenerateN: 1,
+		})
+		require.NoError(t, err)
+
+		addrs := chainAddresses(t, w, 20)
+		tf := mockTxnsFinder{
+			addrs[9]: true,
+		}
+
+		found, err := wallet.DiscoverAddresses(w, 10, tf, nil)
+		require.NoError(t, err)
+		require.Equal(t, addrs[1:10], found)
+
+		// Encrypted deterministic wallets are not scanned
+		w, err = wallet.NewWallet("t.wlt", "label", "seed", wallet.Options{
+			Type:       wallet.WalletTypeDeterministic,
+			Coin:       wallet.CoinTypeSkycoin,
+			GenerateN:  1,
+			Encrypt:    true,
+			Password:   []byte("pwd"),
+			CryptoType: crypto.CryptoTypeSha256Xor,
+		})
+		require.NoError(t, err)
+
+		found, err = wallet.DiscoverAddresses(w, 10, tf, nil)
+		require.NoError(t, err)
+		require.Empty(t, found)
+	})
+
+	t.Run("invalid gap limit", func(t *testing.T) {
+		_, err := wallet.DiscoverAddresses(newBip44Wallet(t), 0, mockTxnsFinder{}, nil)
+		require.Equal(t, wallet.ErrInvalidGapLimit, err)
+	})
+}
+
+func TestServiceDiscoverAddresses(t *testing.T) {
+	dir := prepareWltDir()
+	s, err := wallet.NewService(wallet.Config{
+		WalletDir:       dir,
+		CryptoType:      crypto.CryptoTypeSha256Xor,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+
+	w, err := s.CreateWallet("t.wlt", wallet.Options{
+		Seed:     bip39.MustNewDefaultMnemonic(),
+		Label:    "label",
+		Type:     wallet.WalletTypeBip44,
+		GapLimit: 5,
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), w.GapLimit())
+
+	status, err := s.GetDiscoveryStatus("t.wlt")
+	require.NoError(t, err)
+	require.Equal(t, &wallet.DiscoveryStatus{
+		WalletID: "t.wlt",
+		GapLimit: 5,
+	}, status)
+
+	_, err = s.GetDiscoveryStatus("none.wlt")
+	require.Equal(t, wallet.ErrWalletNotExist, err)
+
+	external := chainAddresses(t, w, 10, wallet.OptionExternal())
+	tf := mockTxnsFinder{
+		external[4]: true,
+	}
+
+	addrs, err := s.DiscoverAddresses("t.wlt", tf)
+	require.NoError(t, err)
+	require.Equal(t, wallet.SkycoinAddresses(external[1:5]), addrs)
+
+	status, err = s.GetDiscoveryStatus("t.wlt")
+	require.NoError(t, err)
+	require.False(t, status.Running)
+	require.Equal(t, uint64(4), status.Found)
+	require.Equal(t, uint64(15), status.Scanned)
+	require.Empty(t, status.Error)
+	require.False(t, status.LastRun.IsZero())
+
+	require.Equal(t, wallet.ErrInvalidGapLimit, s.SetGapLimit("t.wlt", 0))
+	require.Equal(t, wallet.ErrWalletNotExist, s.SetGapLimit("none.wlt", 10))
+	require.NoError(t, s.SetGapLimit("t.wlt", 10))
+
+	// The discovered addresses and the gap limit are persisted
+	s, err = wallet.NewService(wallet.Config{
+		WalletDir:       dir,
+		CryptoType:      crypto.CryptoTypeSha256Xor,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+
+	w, err = s.GetWallet("t.wlt")
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), w.GapLimit())
+
+	addrs2, err := s.GetAddresses("t.wlt", wallet.OptionExternal())
+	require.NoError(t, err)
+	require.Equal(t, wallet.SkycoinAddresses(external[:5]), addrs2)
+
+	_, err = s.DiscoverAddresses("none.wlt", tf)
+	require.Equal(t, wallet.ErrWalletNotExist, err)
+
+	// DiscoverAllAddresses scans all wallets
+	tf[external[9]] = true
+	s.DiscoverAllAddresses(tf)
+
+	addrs2, err = s.GetAddresses("t.wlt", wallet.OptionExternal())
+	require.NoError(t, err)
+	require.Equal(t, wallet.SkycoinAddresses(external), addrs2)
+}
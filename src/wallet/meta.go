@@ -27,6 +27,7 @@ const (
 	MetaSeedPassphrase = "seedPassphrase" // seed passphrase [bip44 wallets]
 	MetaXPub           = "xpub"           // xpub key [xpub wallets]
 	MetaTemp           = "temp"           // whether the wallet is a temporary wallet
+	MetaGapLimit       = "gapLimit"       // number of unused addresses scanned ahead by address discovery
 )
 
 //const (
@@ -316,6 +317,12 @@ func (m Meta) Validate() error {
 			return errors.New("secrets should not be in unencrypted wallets")
 		}
 	}
+
+	if s, ok := m[MetaGapLimit]; ok {
+		if n, err := strconv.ParseUint(s, 10, 64); err != nil || n == 0 {
+			return errors.New("invalid gap limit")
+		}
+	}
 	return nil
 }
 
@@ -331,6 +338,21 @@ func ResolveCoinType(s string) (CoinType, error) {
 	}
 }
 
+// GapLimit returns the number of unused addresses scanned ahead by address discovery,
+// defaults to DefaultGapLimit if not set
+func (m Meta) GapLimit() uint64 {
+	n, err := strconv.ParseUint(m[MetaGapLimit], 10, 64)
+	if err != nil || n == 0 {
+		return DefaultGapLimit
+	}
+	return n
+}
+
+// SetGapLimit sets the number of unused addresses scanned ahead by address discovery
+func (m Meta) SetGapLimit(n uint64) {
+	m[MetaGapLimit] = strconv.FormatUint(n, 10)
+}
+
 // SetTemp sets temp
 func (m Meta) SetTemp(temp bool) {
 	if temp {
@@ -171,6 +171,20 @@ func (_m *MockWallet) Fingerprint() string {
 	return r0
 }
 
+// GapLimit provides a mock function with given fields:
+func (_m *MockWallet) GapLimit() uint64 {
+	ret := _m.Called()
+
+	var r0 uint64
+	if rf, ok := ret.Get(0).(func() uint64); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	return r0
+}
+
 // GenerateAddresses provides a mock function with given fields: options
 func (_m *MockWallet) GenerateAddresses(options ...Option) ([]cipher.Addresser, error) {
 	_va := make([]interface{}, len(options))
@@ -525,6 +539,11 @@ func (_m *MockWallet) SetFilename(_a0 string) {
 	_m.Called(_a0)
 }
 
+// SetGapLimit provides a mock function with given fields: n
+func (_m *MockWallet) SetGapLimit(n uint64) {
+	_m.Called(n)
+}
+
 // SetLabel provides a mock function with given fields: _a0
 func (_m *MockWallet) SetLabel(_a0 string) {
 	_m.Called(_a0)
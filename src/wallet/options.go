@@ -28,7 +28,8 @@ type Bip44EntriesOptions struct {
 	ChainMode ChainMode
 }
 
-// OptionAccount is the option type for specifying a bip44 account
+// OptionAccount is the option type for specifying a bip44 account.
+// Account 0 is used if it is not set, and it is the only account allowed for other wallet types, see CheckAccountOption
 func OptionAccount(index uint32) Option {
 	return func(opts interface{}) {
 		bip44, ok := opts.(*Bip44EntriesOptions)
@@ -63,6 +64,27 @@ func OptionExternal() Option {
 	}
 }
 
+// WatchEntriesOptions represents the options that will be used
+// by watch-only wallets to select the entries of an xpub key
+type WatchEntriesOptions struct {
+	XPub    uint32
+	XPubSet bool
+}
+
+// OptionWatchXPub is the option for selecting the entries derived from the xpub key
+// of given index in a watch-only wallet
+func OptionWatchXPub(index uint32) Option {
+	return func(opts interface{}) {
+		o, ok := opts.(*WatchEntriesOptions)
+		if !ok {
+			return
+		}
+
+		o.XPub = index
+		o.XPubSet = true
+	}
+}
+
 func walletOptionFunc(f func(Wallet)) Option {
 	return func(v interface{}) {
 		w, ok := v.(Wallet)
@@ -116,7 +138,9 @@ type AdvancedOptions struct {
 	GenerateN               uint64
 	ScanN                   uint64
 	TF                      TransactionsFinder
-	PrivateKeys             []cipher.SecKey // private keys of collection wallet
+	PrivateKeys             []cipher.SecKey    // private keys of collection wallet
+	WatchAddresses          []cipher.Addresser // addresses of watch-only wallet
+	WatchXPubs              []string           // xpub keys of watch-only wallet
 }
 
 // advancedOptionFunc is a helper function that assert the
@@ -181,3 +205,17 @@ func OptionCollectionPrivateKeys(keys []cipher.SecKey) Option {
 		opts.PrivateKeys = keys
 	})
 }
+
+// OptionWatchAddresses can be used to set the addresses when creating a watch-only wallet
+func OptionWatchAddresses(addrs []cipher.Addresser) Option {
+	return advancedOptionFunc(func(opts *AdvancedOptions) {
+		opts.WatchAddresses = addrs
+	})
+}
+
+// OptionWatchXPubs can be used to set the xpub keys when creating a watch-only wallet
+func OptionWatchXPubs(xpubs []string) Option {
+	return advancedOptionFunc(func(opts *AdvancedOptions) {
+		opts.WatchXPubs = xpubs
+	})
+}
@@ -0,0 +1,101 @@
+package wallet
+
+import (
+	"errors"
+
+	"github.com/skycoin/skycoin/src/cipher/bip39"
+	"github.com/skycoin/skycoin/src/cipher/shamir"
+)
+
+// Wallet seeds are split with Shamir's secret sharing as a small envelope:
+//
+//	bip39 mnemonic seeds:  0x01 | entropy
+//	any other seed:        0x00 | length (1 byte) | seed bytes | zero padding up to shamir.MinSecretLength
+//
+// Storing the entropy of mnemonic seeds instead of the words keeps the shares short.
+const (
+	seedShareFormatRaw   byte = 0
+	seedShareFormatBip39 byte = 1
+)
+
+var (
+	// ErrSeedTooLongForShares is returned if a seed is too long to be split into shares
+	ErrSeedTooLongForShares = NewError(errors.New("seed is too long to be split into shares"))
+	// ErrInvalidSeedShares is returned if the secret recovered from seed shares is not a valid seed
+	ErrInvalidSeedShares = NewError(errors.New("seed shares do not contain a valid wallet seed"))
+	// ErrSeedAndSeedSharesSet is returned when creating a wallet with both a seed and seed shares
+	ErrSeedAndSeedSharesSet = NewError(errors.New("seed and seed shares can not be combined"))
+)
+
+// SplitSeed splits a wallet seed into count mnemonic shares, threshold of which are required to recover it.
+// The seed passphrase of bip44 wallets is not part of the shares and must be backed up separately.
+func SplitSeed(seed string, threshold, count int) ([]string, error) {
+	if seed == "" {
+		return nil, ErrMissingSeed
+	}
+
+	var secret []byte
+	if bip39.ValidateMnemonic(seed) == nil {
+		entropy, err := bip39.EntropyFromMnemonic(seed)
+		if err != nil {
+			return nil, err
+		}
+		secret = append([]byte{seedShareFormatBip39}, entropy...)
+	} else {
+		if len(seed) > shamir.MaxSecretLength-2 {
+			return nil, ErrSeedTooLongForShares
+		}
+		secret = append([]byte{seedShareFormatRaw, byte(len(seed))}, seed...)
+		for len(secret) < shamir.MinSecretLength {
+			secret = append(secret, 0)
+		}
+	}
+
+	defer func() {
+		for i := range secret {
+			secret[i] = 0
+		}
+	}()
+
+	shares, err := shamir.SplitMnemonics(secret, threshold, count)
+	if err != nil {
+		return nil, NewError(err)
+	}
+	return shares, nil
+}
+
+// CombineSeedShares recovers a wallet seed from mnemonic shares created by SplitSeed
+func CombineSeedShares(shares []string) (string, error) {
+	secret, err := shamir.CombineMnemonics(shares)
+	if err != nil {
+		return "", NewError(err)
+	}
+
+	defer func() {
+		for i := range secret {
+			secret[i] = 0
+		}
+	}()
+
+	switch secret[0] {
+	case seedShareFormatBip39:
+		seed, err := bip39.NewMnemonic(secret[1:])
+		if err != nil {
+			return "", ErrInvalidSeedShares
+		}
+		return seed, nil
+	case seedShareFormatRaw:
+		n := int(secret[1])
+		if n == 0 || n+2 > len(secret) {
+			return "", ErrInvalidSeedShares
+		}
+		for _, b := range secret[n+2:] {
+			if b != 0 {
+				return "", ErrInvalidSeedShares
+			}
+		}
+		return string(secret[2 : n+2]), nil
+	default:
+		return "", ErrInvalidSeedShares
+	}
+}
@@ -0,0 +1,119 @@
+package wallet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher/bip39"
+	"github.com/skycoin/skycoin/src/cipher/shamir"
+)
+
+func TestSplitCombineSeed(t *testing.T) {
+	tt := []struct {
+		name      string
+		seed      string
+		threshold int
+		count     int
+		err       error
+	}{
+		{
+			name:      "bip39 12 words",
+			seed:      bip39.MustNewDefaultMnemonic(),
+			threshold: 2,
+			count:     3,
+		},
+		{
+			name:      "bip39 24 words",
+			seed:      "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon art",
+			threshold: 3,
+			count:     5,
+		},
+		{
+			name:      "short seed",
+			seed:      "seed",
+			threshold: 2,
+			count:     2,
+		},
+		{
+			name:      "seed with trailing zero bytes",
+			seed:      "seed\x00\x00",
+			threshold: 2,
+			count:     2,
+		},
+		{
+			name:      "mnemonic with extra whitespace is kept verbatim",
+			seed:      " " + bip39.MustNewDefaultMnemonic(),
+			threshold: 1,
+			count:     2,
+		},
+		{
+			name:      "long seed",
+			seed:      strings.Repeat("s", shamir.MaxSecretLength-2),
+			threshold: 4,
+			count:     6,
+		},
+		{
+			name:      "seed too long",
+			seed:      strings.Repeat("s", shamir.MaxSecretLength-1),
+			threshold: 2,
+			count:     3,
+			err:       ErrSeedTooLongForShares,
+		},
+		{
+			name:      "missing seed",
+			threshold: 2,
+			count:     3,
+			err:       ErrMissingSeed,
+		},
+		{
+			name:      "invalid threshold",
+			seed:      "seed",
+			threshold: 4,
+			count:     3,
+			err:       NewError(shamir.ErrInvalidThreshold),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			shares, err := SplitSeed(tc.seed, tc.threshold, tc.count)
+			require.Equal(t, tc.err, err)
+			if err != nil {
+				return
+			}
+			require.Len(t, shares, tc.count)
+
+			seed, err := CombineSeedShares(shares[len(shares)-tc.threshold:])
+			require.NoError(t, err)
+			require.Equal(t, tc.seed, seed)
+
+			if tc.threshold > 1 {
+				_, err = CombineSeedShares(shares[:tc.threshold-1])
+				require.Equal(t, NewError(shamir.ErrNotEnoughShares), err)
+			}
+		})
+	}
+}
+
+func TestCombineSeedSharesInvalid(t *testing.T) {
+	_, err := CombineSeedShares([]string{"foo bar"})
+	require.Equal(t, NewError(shamir.ErrInvalidMnemonicLength), err)
+
+	// A valid share set whose secret is not a seed envelope
+	secret := make([]byte, shamir.MinSecretLength)
+	secret[0] = 0xFF
+	shares, err := shamir.SplitMnemonics(secret, 1, 1)
+	require.NoError(t, err)
+	_, err = CombineSeedShares(shares)
+	require.Equal(t, ErrInvalidSeedShares, err)
+
+	// Raw seed envelope with a bad length
+	secret[0] = seedShareFormatRaw
+	secret[1] = shamir.MinSecretLength
+	shares, err = shamir.SplitMnemonics(secret, 1, 1)
+	require.NoError(t, err)
+	_, err = CombineSeedShares(shares)
+	require.Equal(t, ErrInvalidSeedShares, err)
+}
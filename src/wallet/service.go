@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -29,6 +30,13 @@ type Service struct {
 	config  Config
 	// fingerprints is used to check for duplicate deterministic wallets
 	fingerprints map[string]string
+	// sessions are the unlocked wallet sessions, indexed by token
+	sessions map[string]*session
+	// discovery is the address discovery status of wallets, protected by discoveryLock
+	discovery     map[string]*DiscoveryStatus
+	discoveryLock sync.Mutex
+	// discoveryC schedules the address discovery of all wallets
+	discoveryC chan struct{}
 }
 
 // Config wallet service config
@@ -38,6 +46,10 @@ type Config struct {
 	EnableWalletAPI bool
 	EnableSeedAPI   bool
 	Bip44Coin       *bip44.CoinType
+	// MaxSessionTimeout is the maximum duration an encrypted wallet can be unlocked for, 0 disables sessions
+	MaxSessionTimeout time.Duration
+	// DisableAddressDiscovery disables the automatic address discovery of RunAddressDiscovery
+	DisableAddressDiscovery bool
 }
 
 // NewConfig creates a default Config
@@ -49,6 +61,8 @@ func NewConfig() Config {
 		EnableWalletAPI: false,
 		EnableSeedAPI:   false,
 		Bip44Coin:       &bc,
+
+		MaxSessionTimeout: time.Hour,
 	}
 }
 
@@ -57,6 +71,9 @@ func NewService(c Config) (*Service, error) {
 	serv := &Service{
 		config:       c,
 		fingerprints: make(map[string]string),
+		sessions:     make(map[string]*session),
+		discovery:    make(map[string]*DiscoveryStatus),
+		discoveryC:   make(chan struct{}, 1),
 	}
 
 	if !serv.config.EnableWalletAPI {
@@ -198,7 +215,25 @@ func (serv *Service) CreateWallet(wltName string, options Options) (Wallet, erro
 		wltName = serv.generateUniqueWalletFilename()
 	}
 
-	return serv.loadWallet(wltName, options)
+	if len(options.SeedShares) != 0 {
+		if options.Seed != "" {
+			return nil, ErrSeedAndSeedSharesSet
+		}
+
+		seed, err := CombineSeedShares(options.SeedShares)
+		if err != nil {
+			return nil, err
+		}
+		options.Seed = seed
+	}
+
+	w, err := serv.loadWallet(wltName, options)
+	if err != nil {
+		return nil, err
+	}
+
+	serv.TriggerAddressDiscovery()
+	return w, nil
 }
 
 func (serv *Service) createWallet(wltName string, options Options) (Wallet, error) {
@@ -223,6 +258,10 @@ func (serv *Service) loadWallet(wltName string, options Options) (Wallet, error)
 		return nil, err
 	}
 
+	if options.GapLimit > 0 {
+		w.SetGapLimit(options.GapLimit)
+	}
+
 	fingerprint := w.Fingerprint()
 	// Note: collection wallets do not have fingerprints
 	if fingerprint != "" {
@@ -291,6 +330,7 @@ func (serv *Service) EncryptWallet(wltID string, password []byte) (Wallet, error
 	}
 
 	// Updates wallets in memory
+	serv.endWalletSessions(wltID)
 	serv.wallets.set(w)
 	return w, nil
 }
@@ -326,6 +366,7 @@ func (serv *Service) DecryptWallet(wltID string, password []byte) (Wallet, error
 	}
 
 	// Sets the decrypted wallet in memory
+	serv.endWalletSessions(wltID)
 	serv.wallets.set(unlockWlt)
 	return unlockWlt, nil
 }
@@ -392,11 +433,19 @@ func (serv *Service) NewAddresses(wltID string, password []byte, options ...Opti
 		return nil, ErrWalletAPIDisabled
 	}
 
+	return serv.newAddresses(wltID, password, options...)
+}
+
+func (serv *Service) newAddresses(wltID string, password []byte, options ...Option) ([]cipher.Address, error) {
 	w, err := serv.getWallet(wltID)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := CheckAccountOption(w, options...); err != nil {
+		return nil, err
+	}
+
 	var addrs []cipher.Addresser
 	f := func(w Wallet) error {
 		var err error
@@ -539,6 +588,11 @@ func (serv *Service) GetAddresses(wltID string, options ...Option) ([]cipher.Add
 	if err != nil {
 		return nil, err
 	}
+
+	if err := CheckAccountOption(w, options...); err != nil {
+		return nil, err
+	}
+
 	addrs, err := w.GetAddresses(options...)
 	if err != nil {
 		return nil, err
@@ -620,7 +674,12 @@ func (serv *Service) UnloadWallet(wltID string) error {
 		}
 	}
 
+	serv.endWalletSessions(wltID)
 	serv.wallets.remove(wltID)
+
+	serv.discoveryLock.Lock()
+	delete(serv.discovery, wltID)
+	serv.discoveryLock.Unlock()
 	return nil
 }
 
@@ -668,6 +727,24 @@ func (serv *Service) GetWalletSeed(wltID string, password []byte) (string, strin
 	return seed, seedPassphrase, nil
 }
 
+// SplitWalletSeed splits the seed of an encrypted wallet into count mnemonic shares,
+// threshold of which are required to recover the seed.
+// The seed passphrase of bip44 wallets is returned alongside, it is not part of the shares.
+// Returns ErrWalletNotEncrypted if it's not encrypted
+func (serv *Service) SplitWalletSeed(wltID string, password []byte, threshold, count int) ([]string, string, error) {
+	seed, seedPassphrase, err := serv.GetWalletSeed(wltID, password)
+	if err != nil {
+		return nil, "", err
+	}
+
+	shares, err := SplitSeed(seed, threshold, count)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return shares, seedPassphrase, nil
+}
+
 // UpdateSecrets opens a wallet for modification of secret data and saves it safely
 func (serv *Service) UpdateSecrets(wltID string, password []byte, f func(Wallet) error) error {
 	serv.Lock()
@@ -743,6 +820,10 @@ func (serv *Service) ViewSecrets(wltID string, password []byte, f func(Wallet) e
 		return err
 	}
 
+	if !CanSign(w) {
+		return ErrWalletCantSign
+	}
+
 	if w.IsEncrypted() {
 		return GuardView(w, password, f)
 	} else if len(password) != 0 {
@@ -841,30 +922,65 @@ func (serv *Service) RecoverWallet(wltName, seed, seedPassphrase string,
 	}
 
 	if w.Type() == WalletTypeBip44 {
-		// regenerate external address for bip44 wallet when creating the wallet
-		cl, err := w.EntriesLen(OptionChange())
-		if err != nil {
+		if err := recoverBip44Accounts(w, w3, password); err != nil {
 			return nil, err
 		}
-
-		// regenerate the change addresses
-		if cl > 1 {
-			_, err := w3.GenerateAddresses(OptionGenerateN(uint64(cl-1)), OptionChange())
-			if err != nil {
-				return nil, err
-			}
-		}
 	}
 
-	// Preserve the timestamp of the old wallet
+	// Preserve the timestamp and gap limit of the old wallet
 	w3.SetTimestamp(w.Timestamp())
+	w3.SetGapLimit(w.GapLimit())
 
 	// Save to disk
 	if err := Save(w3, serv.config.WalletDir); err != nil {
 		return nil, err
 	}
 
+	serv.endWalletSessions(wltName)
 	serv.wallets.set(w3)
+	serv.TriggerAddressDiscovery()
 
 	return w3.Clone(), nil
 }
+
+// recoverBip44Accounts recreates the accounts of the bip44 wallet w in the recovered wallet w3,
+// and regenerates the same number of addresses on each chain
+func recoverBip44Accounts(w, w3 Wallet, password []byte) error {
+	accounts := w.Accounts()
+	for _, a := range accounts[1:] {
+		f := func(w Wallet) error {
+			_, err := w.(bip44AccountsWallet).NewAccount(a.Name)
+			return err
+		}
+
+		if w3.IsEncrypted() {
+			if err := GuardUpdate(w3, password, f); err != nil {
+				return err
+			}
+		} else if err := f(w3); err != nil {
+			return err
+		}
+	}
+
+	for _, a := range accounts {
+		for _, chain := range []Option{OptionExternal(), OptionChange()} {
+			n, err := w.EntriesLen(OptionAccount(a.Index), chain)
+			if err != nil {
+				return err
+			}
+
+			n3, err := w3.EntriesLen(OptionAccount(a.Index), chain)
+			if err != nil {
+				return err
+			}
+
+			if n > n3 {
+				if _, err := w3.GenerateAddresses(OptionAccount(a.Index), chain, OptionGenerateN(uint64(n-n3))); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/skycoin/skycoin/src/wallet/bip44wallet"
 	"github.com/skycoin/skycoin/src/wallet/collection"
 	_ "github.com/skycoin/skycoin/src/wallet/deterministic"
+	_ "github.com/skycoin/skycoin/src/wallet/watchwallet"
 	_ "github.com/skycoin/skycoin/src/wallet/xpubwallet"
 	"github.com/stretchr/testify/require"
 
@@ -3285,6 +3286,82 @@ func TestGetWalletSeed(t *testing.T) {
 	}
 }
 
+func TestServiceSplitWalletSeed(t *testing.T) {
+	dir := prepareWltDir()
+	s, err := wallet.NewService(wallet.Config{
+		WalletDir:       dir,
+		CryptoType:      crypto.CryptoTypeSha256Xor,
+		EnableWalletAPI: true,
+		EnableSeedAPI:   true,
+	})
+	require.NoError(t, err)
+
+	seed := bip39.MustNewDefaultMnemonic()
+	w, err := s.CreateWallet("wallet.wlt", wallet.Options{
+		Seed:           seed,
+		SeedPassphrase: "seed-passphrase",
+		Label:          "label",
+		Encrypt:        true,
+		Password:       []byte("pwd"),
+		Type:           wallet.WalletTypeBip44,
+		GenerateN:      2,
+	})
+	require.NoError(t, err)
+
+	_, _, err = s.SplitWalletSeed("wallet.wlt", []byte("wrong"), 2, 3)
+	require.Equal(t, wallet.ErrInvalidPassword, err)
+
+	shares, seedPassphrase, err := s.SplitWalletSeed("wallet.wlt", []byte("pwd"), 2, 3)
+	require.NoError(t, err)
+	require.Len(t, shares, 3)
+	require.Equal(t, "seed-passphrase", seedPassphrase)
+
+	combined, err := wallet.CombineSeedShares(shares[1:])
+	require.NoError(t, err)
+	require.Equal(t, seed, combined)
+
+	// Seed shares can not be combined with a seed when creating a wallet
+	_, err = s.CreateWallet("wallet2.wlt", wallet.Options{
+		Seed:       seed,
+		SeedShares: shares,
+		Label:      "label",
+		Type:       wallet.WalletTypeBip44,
+	})
+	require.Equal(t, wallet.ErrSeedAndSeedSharesSet, err)
+
+	// Not enough shares
+	_, err = s.CreateWallet("wallet2.wlt", wallet.Options{
+		SeedShares: shares[:1],
+		Label:      "label",
+		Type:       wallet.WalletTypeBip44,
+	})
+	require.Error(t, err)
+
+	// Creating a wallet from the shares in another service restores the same addresses
+	s2, err := wallet.NewService(wallet.Config{
+		WalletDir:       prepareWltDir(),
+		CryptoType:      crypto.CryptoTypeSha256Xor,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+
+	w2, err := s2.CreateWallet("wallet.wlt", wallet.Options{
+		SeedShares:     shares[:2],
+		SeedPassphrase: "seed-passphrase",
+		Label:          "label",
+		Type:           wallet.WalletTypeBip44,
+		GenerateN:      2,
+	})
+	require.NoError(t, err)
+
+	addrs, err := w.GetAddresses()
+	require.NoError(t, err)
+	addrs2, err := w2.GetAddresses()
+	require.NoError(t, err)
+	require.Equal(t, addrs, addrs2)
+	require.Equal(t, seed, w2.Seed())
+}
+
 func TestServiceView(t *testing.T) {
 	tt := []struct {
 		name             string
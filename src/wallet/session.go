@@ -0,0 +1,338 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/util/mlock"
+)
+
+const sessionTokenLength = 32
+
+var (
+	// ErrSessionNotFound is returned if a wallet session token is unknown or expired
+	ErrSessionNotFound = NewError(errors.New("wallet session not found or expired"))
+	// ErrInvalidSessionTimeout is returned if the wallet unlock timeout is invalid
+	ErrInvalidSessionTimeout = NewError(errors.New("invalid wallet unlock timeout"))
+	// ErrSessionsDisabled is returned when unlocking a wallet while sessions are disabled
+	ErrSessionsDisabled = NewError(errors.New("wallet sessions are disabled"))
+	// ErrSessionPasswordRequired is returned when generating addresses with a session in a wallet
+	// whose encrypted secrets change when addresses are generated
+	ErrSessionPasswordRequired = NewError(errors.New("the wallet password is required to generate addresses of this wallet type"))
+)
+
+// Session is an unlocked session of an encrypted wallet.
+// The session token can be used instead of the wallet password until the session expires.
+type Session struct {
+	WalletID  string
+	Token     string
+	ExpiresAt time.Time
+}
+
+// session holds the decrypted copy of a wallet, serialized in locked memory
+// that is zeroed when the session ends
+type session struct {
+	Session
+	// decrypted is the serialized decrypted copy of source
+	decrypted *mlock.Buffer
+	// source is the encrypted wallet held by the service when the session was unlocked,
+	// the session ends when the service replaces it
+	source Wallet
+	timer  *time.Timer
+}
+
+// newDecryptedBuffer serializes a decrypted wallet into locked memory
+func newDecryptedBuffer(w Wallet) (*mlock.Buffer, error) {
+	data, err := w.Serialize()
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		for i := range data {
+			data[i] = 0
+		}
+	}()
+
+	return mlock.NewBuffer(data)
+}
+
+// wallet decodes the decrypted copy of the wallet. The returned wallet must be erased after use
+func (s *session) wallet() (Wallet, error) {
+	l, ok := getLoader(s.source.Type())
+	if !ok {
+		return nil, ErrInvalidWalletType
+	}
+
+	var w Wallet
+	if err := s.decrypted.View(func(data []byte) error {
+		var err error
+		w, err = l.Load(data)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	w.SetFilename(s.source.Filename())
+	return w, nil
+}
+
+func (s *session) erase() {
+	s.timer.Stop()
+	if err := s.decrypted.Destroy(); err != nil {
+		logger.WithError(err).WithField("walletID", s.WalletID).Error("Destroy wallet session buffer failed")
+	}
+}
+
+// UnlockWallet decrypts an encrypted wallet and keeps the decrypted copy in memory until
+// the timeout expires or the wallet is locked. The returned session token can be used
+// instead of the password to sign transactions and generate addresses.
+func (serv *Service) UnlockWallet(wltID string, password []byte, timeout time.Duration) (*Session, error) {
+	serv.Lock()
+	defer serv.Unlock()
+	if !serv.config.EnableWalletAPI {
+		return nil, ErrWalletAPIDisabled
+	}
+
+	if serv.config.MaxSessionTimeout <= 0 {
+		return nil, ErrSessionsDisabled
+	}
+
+	if timeout <= 0 || timeout > serv.config.MaxSessionTimeout {
+		return nil, ErrInvalidSessionTimeout
+	}
+
+	w := serv.wallets.get(wltID)
+	if w == nil {
+		return nil, ErrWalletNotExist
+	}
+
+	if !w.IsEncrypted() {
+		return nil, ErrWalletNotEncrypted
+	}
+
+	if len(password) == 0 {
+		return nil, ErrMissingPassword
+	}
+
+	wlt, err := w.Unlock(password)
+	if err != nil {
+		return nil, err
+	}
+	defer wlt.Erase()
+
+	decrypted, err := newDecryptedBuffer(wlt)
+	if err != nil {
+		logger.WithError(err).WithField("walletID", wltID).Error("Failed to keep the decrypted wallet in locked memory")
+		return nil, err
+	}
+
+	s := &session{
+		Session: Session{
+			WalletID:  wltID,
+			Token:     hex.EncodeToString(cipher.RandByte(sessionTokenLength)),
+			ExpiresAt: time.Now().Add(timeout),
+		},
+		decrypted: decrypted,
+		source:    w,
+	}
+
+	s.timer = time.AfterFunc(timeout, func() {
+		serv.Lock()
+		defer serv.Unlock()
+		serv.endSession(s.Token)
+	})
+
+	serv.sessions[s.Token] = s
+
+	logger.WithField("walletID", wltID).Infof("Unlocked wallet until %s", s.ExpiresAt)
+
+	ss := s.Session
+	return &ss, nil
+}
+
+// LockWallet ends all sessions of a wallet and wipes its decrypted copies
+func (serv *Service) LockWallet(wltID string) error {
+	serv.Lock()
+	defer serv.Unlock()
+	if !serv.config.EnableWalletAPI {
+		return ErrWalletAPIDisabled
+	}
+
+	if serv.wallets.get(wltID) == nil {
+		return ErrWalletNotExist
+	}
+
+	serv.endWalletSessions(wltID)
+	return nil
+}
+
+// ViewSecretsWithSession opens the decrypted copy of a wallet of an unlocked session for reading secret data
+func (serv *Service) ViewSecretsWithSession(wltID, token string, f func(Wallet) error) error {
+	serv.Lock()
+	defer serv.Unlock()
+	if !serv.config.EnableWalletAPI {
+		return ErrWalletAPIDisabled
+	}
+
+	s, err := serv.getSession(wltID, token)
+	if err != nil {
+		return err
+	}
+
+	wlt, err := s.wallet()
+	if err != nil {
+		return err
+	}
+	defer wlt.Erase()
+
+	return f(wlt)
+}
+
+// UpdateWithSession opens a wallet of an unlocked session for modification of non-secret data and saves it safely.
+// f is applied to the wallet and to the decrypted copy of the session, so that the session is not ended
+// by the change. f must not change the encrypted secrets of the wallet.
+func (serv *Service) UpdateWithSession(wltID, token string, f func(Wallet) error) error {
+	serv.Lock()
+	defer serv.Unlock()
+	if !serv.config.EnableWalletAPI {
+		return ErrWalletAPIDisabled
+	}
+
+	s, err := serv.getSession(wltID, token)
+	if err != nil {
+		return err
+	}
+
+	return serv.updateSession(s, f)
+}
+
+// NewAddressesWithSession generates addresses in a wallet of an unlocked session.
+// Only bip44 wallets can generate addresses without changing their encrypted secrets,
+// other wallet types require the password.
+func (serv *Service) NewAddressesWithSession(wltID, token string, options ...Option) ([]cipher.Address, error) {
+	serv.Lock()
+	defer serv.Unlock()
+	if !serv.config.EnableWalletAPI {
+		return nil, ErrWalletAPIDisabled
+	}
+
+	s, err := serv.getSession(wltID, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.source.Type() != WalletTypeBip44 {
+		return nil, ErrSessionPasswordRequired
+	}
+
+	if err := CheckAccountOption(s.source, options...); err != nil {
+		return nil, err
+	}
+
+	var addrs []cipher.Addresser
+	if err := serv.updateSession(s, func(w Wallet) error {
+		var err error
+		addrs, err = w.GenerateAddresses(options...)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return SkycoinAddresses(addrs), nil
+}
+
+// updateSession applies f to the wallet of a session and to the decrypted copy of the session,
+// then binds the session to the updated wallet. The service must be write locked.
+func (serv *Service) updateSession(s *session, f func(Wallet) error) error {
+	w, err := serv.getWallet(s.WalletID)
+	if err != nil {
+		return err
+	}
+
+	if err := f(w); err != nil {
+		return err
+	}
+
+	wlt, err := s.wallet()
+	if err != nil {
+		return err
+	}
+	defer wlt.Erase()
+
+	if err := f(wlt); err != nil {
+		return err
+	}
+
+	decrypted, err := newDecryptedBuffer(wlt)
+	if err != nil {
+		return err
+	}
+
+	if err := Save(w, serv.config.WalletDir); err != nil {
+		if err := decrypted.Destroy(); err != nil {
+			logger.WithError(err).WithField("walletID", s.WalletID).Error("Destroy wallet session buffer failed")
+		}
+		return err
+	}
+
+	serv.wallets.set(w)
+
+	if err := s.decrypted.Destroy(); err != nil {
+		logger.WithError(err).WithField("walletID", s.WalletID).Error("Destroy wallet session buffer failed")
+	}
+	s.decrypted = decrypted
+	s.source = serv.wallets.get(s.WalletID)
+
+	return nil
+}
+
+// getSession returns the session of a wallet with the given token.
+// The session ends if the wallet was replaced since it was unlocked, its decrypted copy is not refreshed.
+// The service must be write locked.
+func (serv *Service) getSession(wltID, token string) (*session, error) {
+	s, ok := serv.sessions[token]
+	if !ok || s.WalletID != wltID {
+		return nil, ErrSessionNotFound
+	}
+
+	if time.Now().After(s.ExpiresAt) {
+		serv.endSession(token)
+		return nil, ErrSessionNotFound
+	}
+
+	w := serv.wallets.get(wltID)
+	if w == nil || !w.IsEncrypted() {
+		serv.endSession(token)
+		return nil, ErrSessionNotFound
+	}
+
+	if w != s.source {
+		serv.endSession(token)
+		return nil, ErrSessionNotFound
+	}
+
+	return s, nil
+}
+
+// endSession ends a session and wipes its decrypted wallet. The service must be write locked.
+func (serv *Service) endSession(token string) {
+	s, ok := serv.sessions[token]
+	if !ok {
+		return
+	}
+
+	s.erase()
+	delete(serv.sessions, token)
+}
+
+// endWalletSessions ends all sessions of a wallet. The service must be write locked.
+func (serv *Service) endWalletSessions(wltID string) {
+	for token, s := range serv.sessions {
+		if s.WalletID == wltID {
+			serv.endSession(token)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package wallet_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher/crypto"
+	"github.com/skycoin/skycoin/src/wallet"
+)
+
+func newSessionTestService(t *testing.T, maxTimeout time.Duration) *wallet.Service {
+	s, err := wallet.NewService(wallet.Config{
+		WalletDir:         prepareWltDir(),
+		CryptoType:        crypto.CryptoTypeSha256Xor,
+		EnableWalletAPI:   true,
+		MaxSessionTimeout: maxTimeout,
+	})
+	require.NoError(t, err)
+
+	_, err = s.CreateWallet("t.wlt", wallet.Options{
+		Seed:      "fooseed",
+		Label:     "label",
+		Encrypt:   true,
+		Password:  []byte("pwd"),
+		Type:      wallet.WalletTypeDeterministic,
+		GenerateN: 1,
+	})
+	require.NoError(t, err)
+
+	_, err = s.CreateWallet("b.wlt", wallet.Options{
+		Seed:      "voyage say extend find sheriff surge priority merit ignore maple cash argue",
+		Label:     "label",
+		Encrypt:   true,
+		Password:  []byte("pwd"),
+		Type:      wallet.WalletTypeBip44,
+		GenerateN: 1,
+	})
+	require.NoError(t, err)
+
+	_, err = s.CreateWallet("u.wlt", wallet.Options{
+		Seed:      "barseed",
+		Label:     "label",
+		Type:      wallet.WalletTypeDeterministic,
+		GenerateN: 1,
+	})
+	require.NoError(t, err)
+
+	return s
+}
+
+func TestServiceUnlockWallet(t *testing.T) {
+	s := newSessionTestService(t, time.Hour)
+
+	_, err := s.UnlockWallet("t.wlt", []byte("pwd"), 0)
+	require.Equal(t, wallet.ErrInvalidSessionTimeout, err)
+
+	_, err = s.UnlockWallet("t.wlt", []byte("pwd"), 2*time.Hour)
+	require.Equal(t, wallet.ErrInvalidSessionTimeout, err)
+
+	_, err = s.UnlockWallet("none.wlt", []byte("pwd"), time.Minute)
+	require.Equal(t, wallet.ErrWalletNotExist, err)
+
+	_, err = s.UnlockWallet("u.wlt", []byte("pwd"), time.Minute)
+	require.Equal(t, wallet.ErrWalletNotEncrypted, err)
+
+	_, err = s.UnlockWallet("t.wlt", nil, time.Minute)
+	require.Equal(t, wallet.ErrMissingPassword, err)
+
+	_, err = s.UnlockWallet("t.wlt", []byte("wrong"), time.Minute)
+	require.Equal(t, wallet.ErrInvalidPassword, err)
+
+	ss, err := s.UnlockWallet("t.wlt", []byte("pwd"), time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, "t.wlt", ss.WalletID)
+	require.Len(t, ss.Token, 64)
+	require.True(t, ss.ExpiresAt.After(time.Now()))
+
+	// The session gives access to the decrypted wallet
+	err = s.ViewSecretsWithSession("t.wlt", ss.Token, func(w wallet.Wallet) error {
+		require.False(t, w.IsEncrypted())
+		require.Equal(t, "fooseed", w.Seed())
+		return nil
+	})
+	require.NoError(t, err)
+
+	// The token is bound to its wallet
+	err = s.ViewSecretsWithSession("u.wlt", ss.Token, func(w wallet.Wallet) error {
+		return nil
+	})
+	require.Equal(t, wallet.ErrSessionNotFound, err)
+
+	err = s.ViewSecretsWithSession("t.wlt", "bad", func(w wallet.Wallet) error {
+		return nil
+	})
+	require.Equal(t, wallet.ErrSessionNotFound, err)
+
+	// The stored wallet stays encrypted
+	w, err := s.GetWallet("t.wlt")
+	require.NoError(t, err)
+	require.True(t, w.IsEncrypted())
+	require.Empty(t, w.Seed())
+
+	// Generating addresses changes the encrypted secrets of a deterministic wallet, which requires the password
+	_, err = s.NewAddressesWithSession("t.wlt", ss.Token, wallet.OptionGenerateN(2))
+	require.Equal(t, wallet.ErrSessionPasswordRequired, err)
+
+	// Locking the wallet ends the session
+	require.NoError(t, s.LockWallet("t.wlt"))
+	err = s.ViewSecretsWithSession("t.wlt", ss.Token, func(w wallet.Wallet) error {
+		return nil
+	})
+	require.Equal(t, wallet.ErrSessionNotFound, err)
+
+	require.Equal(t, wallet.ErrWalletNotExist, s.LockWallet("none.wlt"))
+}
+
+func TestServiceNewAddressesWithSession(t *testing.T) {
+	s := newSessionTestService(t, time.Hour)
+
+	ss, err := s.UnlockWallet("b.wlt", []byte("pwd"), time.Minute)
+	require.NoError(t, err)
+
+	w, err := s.GetWallet("b.wlt")
+	require.NoError(t, err)
+	n, err := w.EntriesLen()
+	require.NoError(t, err)
+
+	// A bip44 wallet generates addresses without changing its secrets,
+	// the addresses are generated in the decrypted copy too and the session is kept
+	addrs, err := s.NewAddressesWithSession("b.wlt", ss.Token, wallet.OptionGenerateN(2))
+	require.NoError(t, err)
+	require.Len(t, addrs, 2)
+
+	w, err = s.GetWallet("b.wlt")
+	require.NoError(t, err)
+	require.True(t, w.IsEncrypted())
+	l, err := w.EntriesLen()
+	require.NoError(t, err)
+	require.Equal(t, n+2, l)
+
+	err = s.ViewSecretsWithSession("b.wlt", ss.Token, func(w wallet.Wallet) error {
+		require.False(t, w.IsEncrypted())
+		l, err := w.EntriesLen()
+		require.NoError(t, err)
+		require.Equal(t, n+2, l)
+		for _, a := range addrs {
+			e, err := w.GetEntry(a)
+			require.NoError(t, err)
+			require.False(t, e.Secret.Null())
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Updates made with the session keep it too
+	err = s.UpdateWithSession("b.wlt", ss.Token, func(w wallet.Wallet) error {
+		w.SetLabel("new label")
+		return nil
+	})
+	require.NoError(t, err)
+
+	w, err = s.GetWallet("b.wlt")
+	require.NoError(t, err)
+	require.Equal(t, "new label", w.Label())
+
+	err = s.ViewSecretsWithSession("b.wlt", ss.Token, func(w wallet.Wallet) error {
+		require.Equal(t, "new label", w.Label())
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = s.UpdateWithSession("b.wlt", "bad", func(w wallet.Wallet) error {
+		return nil
+	})
+	require.Equal(t, wallet.ErrSessionNotFound, err)
+}
+
+func TestServiceUnlockWalletDisabled(t *testing.T) {
+	s := newSessionTestService(t, 0)
+	_, err := s.UnlockWallet("t.wlt", []byte("pwd"), time.Minute)
+	require.Equal(t, wallet.ErrSessionsDisabled, err)
+}
+
+func TestServiceUnlockWalletExpiry(t *testing.T) {
+	s := newSessionTestService(t, time.Hour)
+
+	ss, err := s.UnlockWallet("t.wlt", []byte("pwd"), 50*time.Millisecond)
+	require.NoError(t, err)
+
+	require.NoError(t, s.ViewSecretsWithSession("t.wlt", ss.Token, func(w wallet.Wallet) error {
+		return nil
+	}))
+
+	time.Sleep(100 * time.Millisecond)
+
+	err = s.ViewSecretsWithSession("t.wlt", ss.Token, func(w wallet.Wallet) error {
+		return nil
+	})
+	require.Equal(t, wallet.ErrSessionNotFound, err)
+}
+
+func TestServiceUnlockWalletInvalidation(t *testing.T) {
+	view := func(s *wallet.Service, token string) error {
+		return s.ViewSecretsWithSession("t.wlt", token, func(w wallet.Wallet) error {
+			return nil
+		})
+	}
+
+	// Unloading the wallet ends its sessions
+	s := newSessionTestService(t, time.Hour)
+	ss, err := s.UnlockWallet("t.wlt", []byte("pwd"), time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, s.UnloadWallet("t.wlt"))
+	require.Equal(t, wallet.ErrSessionNotFound, view(s, ss.Token))
+
+	// Decrypting the wallet ends its sessions
+	s = newSessionTestService(t, time.Hour)
+	ss, err = s.UnlockWallet("t.wlt", []byte("pwd"), time.Minute)
+	require.NoError(t, err)
+	_, err = s.DecryptWallet("t.wlt", []byte("pwd"))
+	require.NoError(t, err)
+	require.Equal(t, wallet.ErrSessionNotFound, view(s, ss.Token))
+
+	// Re-encrypting the wallet with another password does not revive the session
+	_, err = s.EncryptWallet("t.wlt", []byte("pwd2"))
+	require.NoError(t, err)
+	require.Equal(t, wallet.ErrSessionNotFound, view(s, ss.Token))
+
+	// Recovering the wallet ends its sessions
+	s = newSessionTestService(t, time.Hour)
+	ss, err = s.UnlockWallet("t.wlt", []byte("pwd"), time.Minute)
+	require.NoError(t, err)
+	_, err = s.RecoverWallet("t.wlt", "fooseed", "", []byte("pwd2"))
+	require.NoError(t, err)
+	require.Equal(t, wallet.ErrSessionNotFound, view(s, ss.Token))
+
+	// Any other change to the wallet ends its sessions, the decrypted copy is not refreshed
+	s = newSessionTestService(t, time.Hour)
+	ss, err = s.UnlockWallet("t.wlt", []byte("pwd"), time.Minute)
+	require.NoError(t, err)
+	ss2, err := s.UnlockWallet("t.wlt", []byte("pwd"), time.Minute)
+	require.NoError(t, err)
+	require.NotEqual(t, ss.Token, ss2.Token)
+	require.NoError(t, view(s, ss.Token))
+	require.NoError(t, s.UpdateWalletLabel("t.wlt", "new label"))
+	require.Equal(t, wallet.ErrSessionNotFound, view(s, ss.Token))
+	require.Equal(t, wallet.ErrSessionNotFound, view(s, ss2.Token))
+
+	s = newSessionTestService(t, time.Hour)
+	ss, err = s.UnlockWallet("t.wlt", []byte("pwd"), time.Minute)
+	require.NoError(t, err)
+	_, err = s.NewAddresses("t.wlt", []byte("pwd"), wallet.OptionGenerateN(1))
+	require.NoError(t, err)
+	require.Equal(t, wallet.ErrSessionNotFound, view(s, ss.Token))
+}
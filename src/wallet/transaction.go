@@ -69,8 +69,7 @@ func copyTransaction(txn *coin.Transaction) *coin.Transaction {
 // but a valid existing signature cannot be overwritten.
 // Clients should avoid signing the same transaction multiple times.
 func SignTransaction(w Wallet, txn *coin.Transaction, signIndexes []int, uxOuts []coin.UxOut) (*coin.Transaction, error) {
-	switch w.Type() {
-	case WalletTypeXPub:
+	if !CanSign(w) {
 		return nil, ErrWalletCantSign
 	}
 
@@ -129,7 +128,7 @@ func SignTransaction(w Wallet, txn *coin.Transaction, signIndexes []int, uxOuts
 
 	// Check that the wallet has all addresses needed for signing
 	toSign := make(map[cipher.SecKey][]int)
-	entries, err := w.GetEntries()
+	entries, err := signingEntries(w)
 	if err != nil {
 		return nil, err
 	}
@@ -184,6 +183,24 @@ func SignTransaction(w Wallet, txn *coin.Transaction, signIndexes []int, uxOuts
 	return signedTxn, nil
 }
 
+// signingEntries returns the entries that may sign transaction inputs,
+// the entries of all accounts are included for bip44 wallets
+func signingEntries(w Wallet) (Entries, error) {
+	if w.Type() != WalletTypeBip44 {
+		return w.GetEntries()
+	}
+
+	var entries Entries
+	for _, a := range w.Accounts() {
+		es, err := w.GetEntries(OptionAccount(a.Index))
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, es...)
+	}
+	return entries, nil
+}
+
 // CreateTransaction creates an unsigned transaction based upon transaction.Params.
 // Set the password as nil if the wallet is not encrypted, otherwise the password must be provided.
 // NOTE: Caller must ensure that auxs correspond to params.Wallet.Addresses and params.Wallet.UxOuts options
@@ -197,15 +214,16 @@ func SignTransaction(w Wallet, txn *coin.Transaction, signIndexes []int, uxOuts
 //     if the coinhour cost of adding that output is less than the coinhours that would be lost as change
 // If receiving hours are not explicitly specified, hours are allocated amongst the receiving outputs proportional to the number of coins being sent to them.
 // If the change address is not specified, the address whose bytes are lexically sorted first is chosen from the owners of the outputs being spent.
+// The bip44 account of the outputs can be selected with OptionAccount, account 0 is used by default.
 // WARNING: This method is not concurrent-safe if operating on the same wallet. Use Service.View or Service.ViewSecrets to lock the wallet, or use your own lock.
-func CreateTransaction(w Wallet, p transaction.Params, auxs coin.AddressUxOuts, headTime uint64) (*coin.Transaction, []transaction.UxBalance, error) {
+func CreateTransaction(w Wallet, p transaction.Params, auxs coin.AddressUxOuts, headTime uint64, options ...Option) (*coin.Transaction, []transaction.UxBalance, error) {
 	if err := p.Validate(); err != nil {
 		return nil, nil, err
 	}
 
 	// Check that auxs does not contain addresses that are not known to this wallet
 	for a := range auxs {
-		has, err := w.HasEntry(a)
+		has, err := w.HasEntry(a, options...)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -227,8 +245,8 @@ func CreateTransaction(w Wallet, p transaction.Params, auxs coin.AddressUxOuts,
 // CreateTransactionSigned creates and signs a transaction based upon transaction.Params.
 // Set the password as nil if the wallet is not encrypted, otherwise the password must be provided.
 // Refer to CreateTransaction for information about transaction creation.
-func CreateTransactionSigned(w Wallet, p transaction.Params, auxs coin.AddressUxOuts, headTime uint64) (*coin.Transaction, []transaction.UxBalance, error) {
-	txn, uxb, err := CreateTransaction(w, p, auxs, headTime)
+func CreateTransactionSigned(w Wallet, p transaction.Params, auxs coin.AddressUxOuts, headTime uint64, options ...Option) (*coin.Transaction, []transaction.UxBalance, error) {
+	txn, uxb, err := CreateTransaction(w, p, auxs, headTime, options...)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -241,7 +259,7 @@ func CreateTransactionSigned(w Wallet, p transaction.Params, auxs coin.AddressUx
 		entry, ok := entriesMap[s.Address]
 		if !ok {
 			var err error
-			entry, err = w.GetEntry(s.Address)
+			entry, err = w.GetEntry(s.Address, options...)
 			if err == ErrEntryNotFound {
 				// This should not occur because CreateTransaction should have checked it already
 				err := fmt.Errorf("Chosen spend address %s not found in wallet", s.Address)
@@ -3,7 +3,7 @@ Package wallet implements wallets and the wallet database service
 
 Values of the Wallet interface can be created by calling function NewWallet,
 or by loading from `[]byte` that containing wallet data of type such as
-"deterministic", "collection", "bip44" or "xpubwallet". Loading any particular
+"deterministic", "collection", "bip44", "xpubwallet" or "watch-only". Loading any particular
 type of wallet requires the prior registration of a loader. Registration is typically
 automatic as a side effect of initializing that wallet's package so that, to load a
 "deterministic" wallet, it suffices to have
@@ -101,6 +101,28 @@ var (
 	ErrWalletPermission = NewError(errors.New("saving wallet permission denied"))
 	// ErrInvalidPrivateKeys is returned when creating a collection wallet with invalid private keys
 	ErrInvalidPrivateKeys = NewError(errors.New("invalid private keys"))
+	// ErrWalletNotBip44 is returned when managing bip44 accounts of a wallet of another type
+	ErrWalletNotBip44 = NewError(errors.New("wallet is not a bip44 wallet"))
+	// ErrBip44AccountNotExist is returned if the selected bip44 account does not exist
+	ErrBip44AccountNotExist = NewError(errors.New("bip44 account doesn't exist"))
+	// ErrMissingBip44AccountName is returned when creating or renaming a bip44 account without name
+	ErrMissingBip44AccountName = NewError(errors.New("missing bip44 account name"))
+	// ErrBip44AccountNameConflict is returned if the bip44 account name is used by another account of the wallet
+	ErrBip44AccountNameConflict = NewError(errors.New("bip44 account name already exists"))
+	// ErrInvalidGapLimit is returned if the address discovery gap limit is 0
+	ErrInvalidGapLimit = NewError(errors.New("gap limit must be > 0"))
+	// ErrMissingWatchAddresses is returned when creating a watch-only wallet without addresses or xpub keys
+	ErrMissingWatchAddresses = NewError(errors.New("missing watch addresses or xpub keys"))
+	// ErrWalletNotWatchOnly is returned when adding watched addresses to a wallet of another type
+	ErrWalletNotWatchOnly = NewError(errors.New("wallet is not a watch-only wallet"))
+	// ErrWatchAddressExists is returned if an address is already watched by the wallet
+	ErrWatchAddressExists = NewError(errors.New("address is already watched by the wallet"))
+	// ErrWatchXPubExists is returned if an xpub key is already watched by the wallet
+	ErrWatchXPubExists = NewError(errors.New("xpub key is already watched by the wallet"))
+	// ErrWatchXPubNotExist is returned if the xpub key selected with OptionWatchXPub does not exist
+	ErrWatchXPubNotExist = NewError(errors.New("watch-only wallet xpub key doesn't exist"))
+	// ErrWatchNoXPub is returned when generating addresses in a watch-only wallet without xpub keys
+	ErrWatchNoXPub = NewError(errors.New("watch-only wallet has no xpub key to generate addresses from"))
 
 	// ErrEntryNotFound is returned by GetEntry is the wallet does not contains the entry
 	ErrEntryNotFound = errors.New("entry not found")
@@ -132,6 +154,9 @@ const (
 	// WalletTypeXPub xpub HD wallet type.
 	// Allows generating addresses without a secret key
 	WalletTypeXPub = "xpub"
+	// WalletTypeWatchOnly watch-only wallet type.
+	// Holds a list of addresses and xpub keys, for tracking balances without any secret key
+	WalletTypeWatchOnly = "watch-only"
 )
 
 // CoinType represents the wallet coin type, which refers to the pubkey2addr method used
@@ -153,17 +178,21 @@ type Options struct {
 	Bip44Coin             *bip44.CoinType   // bip44 path coin type
 	Label                 string            // wallet label
 	Seed                  string            // wallet seed
+	SeedShares            []string          // mnemonic shares of the wallet seed, an alternative to Seed
 	SeedPassphrase        string            // wallet seed passphrase (bip44 wallets only)
 	Encrypt               bool              // whether the wallet need to be encrypted.
 	Password              []byte            // password that would be used for encryption, and would only be used when 'Encrypt' is true.
 	CryptoType            crypto.CryptoType // wallet encryption type, scrypt-chacha20poly1305 or sha256-xor.
 	ScanN                 uint64            // number of addresses that're going to be scanned for a balance. The highest address with a balance will be used.
 	GenerateN             uint64            // number of addresses to generate, regardless of balance
+	GapLimit              uint64            // number of unused addresses scanned ahead by address discovery, defaults to DefaultGapLimit
 	XPub                  string            // xpub key (xpub wallets only)
 	Decoder               Decoder
 	TF                    TransactionsFinder
-	Temp                  bool            // whether the wallet is created temporary in memory.
-	CollectionPrivateKeys []cipher.SecKey // private keys for collection wallet
+	Temp                  bool               // whether the wallet is created temporary in memory.
+	CollectionPrivateKeys []cipher.SecKey    // private keys for collection wallet
+	WatchAddresses        []cipher.Addresser // addresses of watch-only wallet
+	WatchXPubs            []string           // xpub keys of watch-only wallet
 }
 
 func (opts Options) Validate() error {
@@ -258,6 +287,10 @@ type Wallet interface {
 	IsTemp() bool
 	// SetTemp sets wallet temporary flag
 	SetTemp(temp bool)
+	// GapLimit returns the number of unused addresses scanned ahead by address discovery
+	GapLimit() uint64
+	// SetGapLimit sets the number of unused addresses scanned ahead by address discovery
+	SetGapLimit(n uint64)
 }
 
 // Decoder is the interface that wraps the Encode and Decode methods.
@@ -473,7 +506,8 @@ func IsValidWalletType(t string) bool {
 	case WalletTypeDeterministic,
 		WalletTypeCollection,
 		WalletTypeBip44,
-		WalletTypeXPub:
+		WalletTypeXPub,
+		WalletTypeWatchOnly:
 		return true
 	default:
 		return false
@@ -618,3 +652,13 @@ func GetGenerateNFromOptions(options ...Option) uint64 {
 func GetPrivateKeysFromOptions(options ...Option) []cipher.SecKey {
 	return applyAdvancedOptions(options...).PrivateKeys
 }
+
+// CanSign returns whether the wallet holds the secret keys to sign transactions
+func CanSign(w Wallet) bool {
+	switch w.Type() {
+	case WalletTypeXPub, WalletTypeWatchOnly:
+		return false
+	default:
+		return true
+	}
+}
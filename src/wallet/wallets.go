@@ -111,11 +111,11 @@ func (wlts Wallets) containsDuplicate() (string, string, bool) {
 }
 
 // containsEmpty returns true there is an empty wallet and the ID of that wallet if true.
-// Does not apply to collection wallets
+// Does not apply to collection and watch-only wallets
 func (wlts Wallets) containsEmpty() (string, bool) {
 	for wltID, wlt := range wlts {
 		switch wlt.Type() {
-		case WalletTypeCollection:
+		case WalletTypeCollection, WalletTypeWatchOnly:
 			continue
 		case WalletTypeBip44:
 			var l int
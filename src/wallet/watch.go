@@ -0,0 +1,61 @@
+package wallet
+
+import (
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+// WatchWallet is implemented by watch-only wallets
+type WatchWallet interface {
+	Wallet
+	// XPubs returns the xpub keys watched by the wallet
+	XPubs() []string
+	// AddAddresses adds addresses to the watched addresses
+	AddAddresses(addrs []cipher.Addresser) error
+	// AddXPub adds an xpub key to the wallet and generates its first n addresses
+	AddXPub(xpub string, n uint64) ([]cipher.Addresser, error)
+}
+
+// AddWatchAddresses adds addresses and xpub keys to a watch-only wallet.
+// The first address of each xpub key is generated, further addresses are found by address discovery.
+// The new watched addresses are returned.
+func (serv *Service) AddWatchAddresses(wltID string, addrs []cipher.Address, xpubs []string) ([]cipher.Address, error) {
+	serv.Lock()
+	defer serv.Unlock()
+	if !serv.config.EnableWalletAPI {
+		return nil, ErrWalletAPIDisabled
+	}
+
+	w, err := serv.getWallet(wltID)
+	if err != nil {
+		return nil, err
+	}
+
+	ww, ok := w.(WatchWallet)
+	if !ok {
+		return nil, ErrWalletNotWatchOnly
+	}
+
+	added := make([]cipher.Addresser, len(addrs))
+	for i, a := range addrs {
+		added[i] = a
+	}
+
+	if err := ww.AddAddresses(added); err != nil {
+		return nil, err
+	}
+
+	for _, xpub := range xpubs {
+		xpubAddrs, err := ww.AddXPub(xpub, 1)
+		if err != nil {
+			return nil, err
+		}
+		added = append(added, xpubAddrs...)
+	}
+
+	if err := serv.saveWallet(ww); err != nil {
+		return nil, err
+	}
+
+	serv.TriggerAddressDiscovery()
+	return SkycoinAddresses(added), nil
+}
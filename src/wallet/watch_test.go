@@ -0,0 +1,102 @@
+package wallet_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/bip32"
+	"github.com/skycoin/skycoin/src/cipher/crypto"
+	"github.com/skycoin/skycoin/src/testutil"
+	"github.com/skycoin/skycoin/src/wallet"
+)
+
+func TestServiceAddWatchAddresses(t *testing.T) {
+	dir := prepareWltDir()
+	s, err := wallet.NewService(wallet.Config{
+		WalletDir:       dir,
+		CryptoType:      crypto.CryptoTypeSha256Xor,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+
+	k, err := bip32.NewMasterKey([]byte("watch-only service test seed 012"))
+	require.NoError(t, err)
+	xpub := k.PublicKey().String()
+
+	addrs := []cipher.Address{
+		testutil.MakeAddress(),
+		testutil.MakeAddress(),
+		testutil.MakeAddress(),
+	}
+
+	w, err := s.CreateWallet("t.wlt", wallet.Options{
+		Label:          "watch",
+		Type:           wallet.WalletTypeWatchOnly,
+		WatchAddresses: []cipher.Addresser{addrs[0]},
+	})
+	require.NoError(t, err)
+	require.Equal(t, wallet.WalletTypeWatchOnly, w.Type())
+
+	_, err = s.CreateWallet("t2.wlt", wallet.Options{
+		Label: "watch",
+		Type:  wallet.WalletTypeWatchOnly,
+	})
+	require.Equal(t, wallet.ErrMissingWatchAddresses, err)
+
+	added, err := s.AddWatchAddresses("t.wlt", addrs[1:], []string{xpub})
+	require.NoError(t, err)
+	require.Len(t, added, 3)
+	require.Equal(t, addrs[1:], added[:2])
+
+	_, err = s.AddWatchAddresses("t.wlt", addrs[:1], nil)
+	require.Equal(t, wallet.ErrWatchAddressExists, err)
+
+	_, err = s.AddWatchAddresses("t.wlt", nil, []string{xpub})
+	require.Equal(t, wallet.ErrWatchXPubExists, err)
+
+	_, err = s.AddWatchAddresses("none.wlt", addrs, nil)
+	require.Equal(t, wallet.ErrWalletNotExist, err)
+
+	_, err = s.CreateWallet("d.wlt", wallet.Options{
+		Seed:  "seed",
+		Label: "label",
+		Type:  wallet.WalletTypeDeterministic,
+	})
+	require.NoError(t, err)
+	_, err = s.AddWatchAddresses("d.wlt", addrs, nil)
+	require.Equal(t, wallet.ErrWalletNotWatchOnly, err)
+
+	// Watch-only wallets can't be opened for signing
+	err = s.ViewSecrets("t.wlt", nil, func(wallet.Wallet) error {
+		t.Fatal("ViewSecrets should not open a watch-only wallet")
+		return nil
+	})
+	require.Equal(t, wallet.ErrWalletCantSign, err)
+
+	// The watched addresses and xpub keys are persisted
+	s, err = wallet.NewService(wallet.Config{
+		WalletDir:       dir,
+		CryptoType:      crypto.CryptoTypeSha256Xor,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+
+	all, err := s.GetAddresses("t.wlt")
+	require.NoError(t, err)
+	require.Equal(t, append(addrs[:1:1], added...), all)
+
+	w, err = s.GetWallet("t.wlt")
+	require.NoError(t, err)
+	require.Equal(t, []string{xpub}, w.(wallet.WatchWallet).XPubs())
+
+	// Address discovery scans the xpub keys of watch-only wallets
+	xpubAddrs := chainAddresses(t, w, 5, wallet.OptionWatchXPub(0))
+	found, err := s.DiscoverAddresses("t.wlt", mockTxnsFinder{
+		addrs[0]:     true,
+		xpubAddrs[3]: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, wallet.SkycoinAddresses(xpubAddrs[1:4]), found)
+}
@@ -0,0 +1,143 @@
+package watchwallet
+
+import (
+	"encoding/json"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/wallet"
+)
+
+// JSONDecoder implements the the WalletDecoder interface,
+// which provides methods for encoding and decoding a watch-only wallet in JSON format.
+type JSONDecoder struct{}
+
+// Encode encodes the watch-only wallet to []byte, and error if any
+func (d JSONDecoder) Encode(w wallet.Wallet) ([]byte, error) {
+	return json.MarshalIndent(newReadableWallet(w.(*Wallet)), "", "    ")
+}
+
+// Decode decodes the watch-only wallet from byte slice
+func (d JSONDecoder) Decode(b []byte) (wallet.Wallet, error) {
+	rw := readableWallet{}
+	if err := json.Unmarshal(b, &rw); err != nil {
+		return nil, err
+	}
+
+	return rw.toWallet()
+}
+
+type readableWallet struct {
+	wallet.Meta `json:"meta"`
+	Entries     readableEntries `json:"entries"`
+	XPubs       []readableXPub  `json:"xpubs"`
+}
+
+type readableXPub struct {
+	XPub    string          `json:"xpub"`
+	Entries readableEntries `json:"entries"`
+}
+
+func (w readableWallet) toWallet() (*Wallet, error) {
+	ad := wallet.ResolveAddressDecoder(w.Coin())
+	addresses, err := w.Entries.toEntries(ad)
+	if err != nil {
+		return nil, err
+	}
+
+	var xpubs []xpubChain
+	for _, x := range w.XPubs {
+		key, err := parseXPub(x.XPub)
+		if err != nil {
+			return nil, err
+		}
+
+		entries, err := x.Entries.toEntries(ad)
+		if err != nil {
+			return nil, err
+		}
+
+		xpubs = append(xpubs, xpubChain{
+			encoded: x.XPub,
+			key:     key,
+			entries: entries,
+		})
+	}
+
+	return &Wallet{
+		Meta:      w.Meta.Clone(),
+		addresses: addresses,
+		xpubs:     xpubs,
+		decoder:   &JSONDecoder{},
+	}, nil
+}
+
+func newReadableWallet(w *Wallet) *readableWallet {
+	xpubs := make([]readableXPub, len(w.xpubs))
+	for i, c := range w.xpubs {
+		xpubs[i] = readableXPub{
+			XPub:    c.encoded,
+			Entries: newReadableEntries(c.entries),
+		}
+	}
+
+	return &readableWallet{
+		Meta:    w.Meta.Clone(),
+		Entries: newReadableEntries(w.addresses),
+		XPubs:   xpubs,
+	}
+}
+
+type readableEntries []readableEntry
+
+func (es readableEntries) toEntries(ad wallet.AddressDecoder) (wallet.Entries, error) {
+	if len(es) == 0 {
+		return nil, nil
+	}
+
+	entries := make(wallet.Entries, len(es))
+	for i, e := range es {
+		addr, err := ad.DecodeBase58Address(e.Address)
+		if err != nil {
+			return nil, err
+		}
+
+		// The watched addresses that are not derived from an xpub key have no public key
+		var p cipher.PubKey
+		if e.Public != "" {
+			p, err = cipher.PubKeyFromHex(e.Public)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		entries[i] = wallet.Entry{
+			Address:     addr,
+			Public:      p,
+			ChildNumber: e.ChildNumber,
+		}
+	}
+
+	return entries, nil
+}
+
+func newReadableEntries(entries wallet.Entries) readableEntries {
+	res := make(readableEntries, len(entries))
+	for i, e := range entries {
+		res[i] = readableEntry{
+			Address:     e.Address.String(),
+			ChildNumber: e.ChildNumber,
+		}
+
+		if !e.Public.Null() {
+			res[i].Public = e.Public.Hex()
+		}
+	}
+
+	return res
+}
+
+type readableEntry struct {
+	Address     string `json:"address"`
+	Public      string `json:"public,omitempty"`
+	ChildNumber uint32 `json:"child_number"` // For xpub entries
+}
@@ -0,0 +1,577 @@
+package watchwallet
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/bip32"
+	"github.com/skycoin/skycoin/src/util/mathutil"
+	"github.com/skycoin/skycoin/src/wallet"
+)
+
+// WalletType represents the watch-only wallet type
+const WalletType = wallet.WalletTypeWatchOnly
+
+var defaultWalletDecoder = &JSONDecoder{}
+
+func init() {
+	if err := wallet.RegisterCreator(WalletType, &Creator{}); err != nil {
+		panic(err)
+	}
+
+	if err := wallet.RegisterLoader(WalletType, &Loader{}); err != nil {
+		panic(err)
+	}
+}
+
+// Wallet holds a list of watched addresses and any number of xpub keys, for tracking
+// the balance and history of addresses whose secret keys are kept elsewhere.
+// Addresses can be generated from the xpub keys, but the wallet can't spend coins
+// because the private keys are not available.
+type Wallet struct {
+	wallet.Meta
+	// addresses are the watched addresses that are not derived from an xpub key
+	addresses wallet.Entries
+	xpubs     []xpubChain
+	decoder   wallet.Decoder
+}
+
+// xpubChain is a watched xpub key and the addresses generated from it
+type xpubChain struct {
+	encoded string
+	key     *bip32.PublicKey
+	entries wallet.Entries
+}
+
+func (c xpubChain) clone() xpubChain {
+	key := c.key.Clone()
+	return xpubChain{
+		encoded: c.encoded,
+		key:     &key,
+		entries: c.entries.Clone(),
+	}
+}
+
+// NewWallet creates a watch-only wallet with options
+func NewWallet(filename, label string, options ...wallet.Option) (*Wallet, error) {
+	wlt := &Wallet{
+		Meta: wallet.Meta{
+			wallet.MetaFilename:  filename,
+			wallet.MetaLabel:     label,
+			wallet.MetaType:      WalletType,
+			wallet.MetaVersion:   wallet.Version,
+			wallet.MetaCoin:      string(wallet.CoinTypeSkycoin),
+			wallet.MetaTimestamp: strconv.FormatInt(time.Now().Unix(), 10),
+		},
+		decoder: defaultWalletDecoder,
+	}
+
+	advOpts := &wallet.AdvancedOptions{}
+	for _, opt := range options {
+		opt(wlt)
+		opt(advOpts)
+	}
+
+	if err := validateMeta(wlt.Meta); err != nil {
+		return nil, err
+	}
+
+	if len(advOpts.WatchAddresses) == 0 && len(advOpts.WatchXPubs) == 0 {
+		return nil, wallet.ErrMissingWatchAddresses
+	}
+
+	if err := wlt.AddAddresses(advOpts.WatchAddresses); err != nil {
+		return nil, err
+	}
+
+	// Generates the first addresses of each xpub key, at least one so that
+	// the xpub key can be told apart from the other keys
+	generateN := advOpts.GenerateN
+	if generateN == 0 {
+		generateN = 1
+	}
+
+	for _, xpub := range advOpts.WatchXPubs {
+		if _, err := wlt.AddXPub(xpub, generateN); err != nil {
+			return nil, err
+		}
+	}
+
+	if advOpts.ScanN > 0 && len(wlt.xpubs) > 0 {
+		if advOpts.TF == nil {
+			return nil, wallet.ErrNilTransactionsFinder
+		}
+
+		scanN := advOpts.ScanN
+		if scanN > generateN {
+			scanN = scanN - generateN
+		}
+
+		if _, err := wlt.ScanAddresses(scanN, advOpts.TF); err != nil {
+			return nil, err
+		}
+	}
+
+	return wlt, nil
+}
+
+func validateMeta(m wallet.Meta) error {
+	if m[wallet.MetaType] != WalletType {
+		return wallet.ErrInvalidWalletType
+	}
+
+	if m[wallet.MetaSeed] != "" {
+		return wallet.NewError(fmt.Errorf("seed should not be provided for %q wallets", WalletType))
+	}
+
+	return wallet.ValidateMeta(m)
+}
+
+// SetDecoder sets the wallet decoder
+func (w *Wallet) SetDecoder(d wallet.Decoder) {
+	w.decoder = d
+}
+
+// Serialize encodes the watch-only wallet to []byte
+func (w Wallet) Serialize() ([]byte, error) {
+	if w.decoder == nil {
+		w.decoder = defaultWalletDecoder
+	}
+
+	return w.decoder.Encode(&w)
+}
+
+// Deserialize decodes the []byte to a watch-only wallet
+func (w *Wallet) Deserialize(b []byte) error {
+	if w.decoder == nil {
+		w.decoder = defaultWalletDecoder
+	}
+
+	toW, err := w.decoder.Decode(b)
+	if err != nil {
+		return err
+	}
+
+	toW2 := toW.(*Wallet)
+	toW2.decoder = w.decoder
+	*w = *toW2
+	return nil
+}
+
+// IsEncrypted returns whether the wallet is encrypted
+func (w Wallet) IsEncrypted() bool {
+	return w.Meta.IsEncrypted()
+}
+
+// Lock will do nothing to the watch-only wallet
+func (w Wallet) Lock(_ []byte) error {
+	return wallet.NewError(errors.New("watch-only wallet does not support encryption"))
+}
+
+// Unlock will do nothing to the watch-only wallet
+func (w *Wallet) Unlock(_ []byte) (wallet.Wallet, error) {
+	return nil, wallet.NewError(errors.New("watch-only wallet does not support encryption"))
+}
+
+// Fingerprint returns an empty string; the same addresses can be watched by several wallets
+func (w *Wallet) Fingerprint() string {
+	return ""
+}
+
+// Clone returns a copy of the wallet
+func (w Wallet) Clone() wallet.Wallet {
+	var xpubs []xpubChain
+	if len(w.xpubs) > 0 {
+		xpubs = make([]xpubChain, len(w.xpubs))
+		for i, c := range w.xpubs {
+			xpubs[i] = c.clone()
+		}
+	}
+
+	return &Wallet{
+		Meta:      w.Meta.Clone(),
+		addresses: w.addresses.Clone(),
+		xpubs:     xpubs,
+		decoder:   w.decoder,
+	}
+}
+
+// CopyFromRef copies the src wallet with a pointer dereference
+func (w *Wallet) CopyFromRef(src wallet.Wallet) {
+	*w = *(src.(*Wallet))
+}
+
+// Accounts is not implemented for watch-only wallet
+func (w *Wallet) Accounts() []wallet.Bip44Account {
+	return nil
+}
+
+// Erase removes sensitive data
+func (w *Wallet) Erase() {
+}
+
+// XPubs returns the xpub keys watched by the wallet
+func (w *Wallet) XPubs() []string {
+	xpubs := make([]string, len(w.xpubs))
+	for i, c := range w.xpubs {
+		xpubs[i] = c.encoded
+	}
+	return xpubs
+}
+
+// AddAddresses adds addresses to the watched addresses.
+// Returns wallet.ErrWatchAddressExists if any address is already in the wallet.
+func (w *Wallet) AddAddresses(addrs []cipher.Addresser) error {
+	entries := w.addresses.Clone()
+	for _, a := range addrs {
+		if w.hasEntry(a) || entries.Has(a) {
+			return wallet.ErrWatchAddressExists
+		}
+
+		entries = append(entries, wallet.Entry{
+			Address: a,
+		})
+	}
+
+	w.addresses = entries
+	return nil
+}
+
+// AddXPub adds an xpub key to the wallet and generates its first n addresses.
+// Returns wallet.ErrWatchXPubExists if the xpub key is already in the wallet.
+func (w *Wallet) AddXPub(xpub string, n uint64) ([]cipher.Addresser, error) {
+	for _, c := range w.xpubs {
+		if c.encoded == xpub {
+			return nil, wallet.ErrWatchXPubExists
+		}
+	}
+
+	key, err := parseXPub(xpub)
+	if err != nil {
+		return nil, wallet.NewError(err)
+	}
+
+	c := xpubChain{
+		encoded: xpub,
+		key:     key,
+	}
+
+	entries, err := w.generateEntries(c, n)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range entries {
+		if w.hasEntry(e.Address) {
+			return nil, wallet.ErrWatchAddressExists
+		}
+	}
+
+	c.entries = entries
+	w.xpubs = append(w.xpubs, c)
+	return entries.GetAddresses(), nil
+}
+
+// hasEntry returns true if the address is watched, directly or through an xpub key
+func (w *Wallet) hasEntry(a cipher.Addresser) bool {
+	if w.addresses.Has(a) {
+		return true
+	}
+
+	for _, c := range w.xpubs {
+		if c.entries.Has(a) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// generateEntries generates the next num entries of the xpub chain
+func (w *Wallet) generateEntries(c xpubChain, num uint64) (wallet.Entries, error) {
+	if num > math.MaxUint32 {
+		return nil, wallet.NewError(errors.New("WatchWallet.generateEntries num too large"))
+	}
+
+	initLen := uint32(len(c.entries))
+	if _, err := mathutil.AddUint32(initLen, uint32(num)); err != nil {
+		return nil, fmt.Errorf("generate %d more addresses failed: %v", num, err)
+	}
+
+	addressFromPubKey := wallet.ResolveAddressDecoder(w.Coin()).AddressFromPubKey
+
+	entries := make(wallet.Entries, num)
+	for i := uint32(0); i < uint32(num); i++ {
+		index := initLen + i
+		pk, err := c.key.NewPublicChildKey(index)
+		if err != nil {
+			return nil, err
+		}
+
+		cpk, err := cipher.NewPubKey(pk.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		entries[i] = wallet.Entry{
+			Address:     addressFromPubKey(cpk),
+			Public:      cpk,
+			ChildNumber: index,
+		}
+	}
+
+	return entries, nil
+}
+
+// selectedXPub returns the index of the xpub key selected with wallet.OptionWatchXPub,
+// and whether any xpub key was selected
+func (w *Wallet) selectedXPub(options ...wallet.Option) (int, bool, error) {
+	var opts wallet.WatchEntriesOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	if !opts.XPubSet {
+		return 0, false, nil
+	}
+
+	if int(opts.XPub) >= len(w.xpubs) {
+		return 0, false, wallet.ErrWatchXPubNotExist
+	}
+
+	return int(opts.XPub), true, nil
+}
+
+// entries returns the entries of the xpub key selected with wallet.OptionWatchXPub,
+// or all entries of the wallet if no xpub key is selected
+func (w *Wallet) entries(options ...wallet.Option) (wallet.Entries, error) {
+	i, ok, err := w.selectedXPub(options...)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		return w.xpubs[i].entries, nil
+	}
+
+	entries := w.addresses
+	for _, c := range w.xpubs {
+		entries = append(entries[:len(entries):len(entries)], c.entries...)
+	}
+	return entries, nil
+}
+
+// ScanAddresses scans ahead N addresses of each xpub key, truncating up to the highest address with any transaction history.
+func (w *Wallet) ScanAddresses(scanN uint64, tf wallet.TransactionsFinder) ([]cipher.Addresser, error) {
+	if scanN == 0 {
+		return nil, nil
+	}
+
+	w2 := w.Clone().(*Wallet)
+
+	var found []cipher.Addresser
+	for i := range w2.xpubs {
+		// Generate the addresses to scan
+		entries, err := w2.generateEntries(w2.xpubs[i], scanN)
+		if err != nil {
+			return nil, err
+		}
+		addrs := entries.GetAddresses()
+
+		// Find if these addresses had any activity
+		active, err := tf.AddressesActivity(addrs)
+		if err != nil {
+			return nil, err
+		}
+
+		// Check activity from the last one until we find the address that has activity
+		var keepNum uint64
+		for j := len(active) - 1; j >= 0; j-- {
+			if active[j] {
+				keepNum = uint64(j + 1)
+				break
+			}
+		}
+
+		w2.xpubs[i].entries = append(w2.xpubs[i].entries, entries[:keepNum]...)
+		found = append(found, addrs[:keepNum]...)
+	}
+
+	*w = *w2
+
+	return found, nil
+}
+
+// GetAddresses returns all addresses of the wallet, or the addresses of the xpub key selected with wallet.OptionWatchXPub
+func (w *Wallet) GetAddresses(options ...wallet.Option) ([]cipher.Addresser, error) {
+	entries, err := w.entries(options...)
+	if err != nil {
+		return nil, err
+	}
+	return entries.GetAddresses(), nil
+}
+
+// GetEntries returns a copy of all entries of the wallet, or the entries of the xpub key selected with wallet.OptionWatchXPub
+func (w *Wallet) GetEntries(options ...wallet.Option) (wallet.Entries, error) {
+	entries, err := w.entries(options...)
+	if err != nil {
+		return nil, err
+	}
+	return entries.Clone(), nil
+}
+
+// GenerateAddresses generates addresses from the xpub key selected with wallet.OptionWatchXPub,
+// or from the first xpub key if no xpub key is selected
+func (w *Wallet) GenerateAddresses(options ...wallet.Option) ([]cipher.Addresser, error) {
+	if len(w.xpubs) == 0 {
+		return nil, wallet.ErrWatchNoXPub
+	}
+
+	i, _, err := w.selectedXPub(options...)
+	if err != nil {
+		return nil, err
+	}
+
+	num := wallet.GetGenerateNFromOptions(options...)
+	entries, err := w.generateEntries(w.xpubs[i], num)
+	if err != nil {
+		return nil, err
+	}
+
+	w.xpubs[i].entries = append(w.xpubs[i].entries, entries...)
+	return entries.GetAddresses(), nil
+}
+
+func parseXPub(xp string) (*bip32.PublicKey, error) {
+	xPub, err := bip32.DeserializeEncodedPublicKey(xp)
+	if err != nil {
+		return nil, fmt.Errorf("invalid xpub key: %v", err)
+	}
+
+	return xPub, nil
+}
+
+// GetEntryAt returns the entry at a given index in the entries array
+func (w *Wallet) GetEntryAt(i int, options ...wallet.Option) (wallet.Entry, error) {
+	entries, err := w.entries(options...)
+	if err != nil {
+		return wallet.Entry{}, err
+	}
+
+	if i < 0 || i >= len(entries) {
+		return wallet.Entry{}, fmt.Errorf("entry index %d is out of range", i)
+	}
+	return entries[i], nil
+}
+
+// GetEntry returns a entry of given address
+func (w *Wallet) GetEntry(addr cipher.Addresser, options ...wallet.Option) (wallet.Entry, error) {
+	entries, err := w.entries(options...)
+	if err != nil {
+		return wallet.Entry{}, err
+	}
+
+	e, ok := entries.Get(addr)
+	if !ok {
+		return wallet.Entry{}, wallet.ErrEntryNotFound
+	}
+	return e, nil
+}
+
+// HasEntry returns true if the wallet has an Entry with a given address
+func (w *Wallet) HasEntry(addr cipher.Addresser, options ...wallet.Option) (bool, error) {
+	entries, err := w.entries(options...)
+	if err != nil {
+		return false, err
+	}
+	return entries.Has(addr), nil
+}
+
+// EntriesLen returns the number of entries in the wallet
+func (w *Wallet) EntriesLen(options ...wallet.Option) (int, error) {
+	entries, err := w.entries(options...)
+	if err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
+
+// Loader implements the wallet.Loader interface
+type Loader struct{}
+
+// Load loads the watch-only wallet from byte slice
+func (l Loader) Load(data []byte) (wallet.Wallet, error) {
+	w := &Wallet{}
+	if err := w.Deserialize(data); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Creator implements the wallet.Creator interface
+type Creator struct{}
+
+// Create creates a watch-only wallet
+func (c Creator) Create(filename, label, _ string, options wallet.Options) (wallet.Wallet, error) {
+	if err := validateOptions(options); err != nil {
+		return nil, err
+	}
+
+	return NewWallet(
+		filename,
+		label,
+		convertOptions(options)...)
+}
+
+func validateOptions(options wallet.Options) error {
+	if options.Encrypt {
+		return wallet.NewError(errors.New("watch-only wallet does not support encryption"))
+	}
+
+	return nil
+}
+
+func convertOptions(options wallet.Options) []wallet.Option {
+	var opts []wallet.Option
+
+	if options.Coin != "" {
+		opts = append(opts, wallet.OptionCoinType(options.Coin))
+	}
+
+	if options.Decoder != nil {
+		opts = append(opts, wallet.OptionDecoder(options.Decoder))
+	}
+
+	if options.GenerateN > 0 {
+		opts = append(opts, wallet.OptionGenerateN(options.GenerateN))
+	}
+
+	if options.ScanN > 0 {
+		opts = append(opts, wallet.OptionScanN(options.ScanN))
+		opts = append(opts, wallet.OptionTransactionsFinder(options.TF))
+	}
+
+	if options.Temp {
+		opts = append(opts, wallet.OptionTemp(true))
+	}
+
+	xpubs := options.WatchXPubs
+	if options.XPub != "" {
+		xpubs = append([]string{options.XPub}, xpubs...)
+	}
+
+	if len(xpubs) > 0 {
+		opts = append(opts, wallet.OptionWatchXPubs(xpubs))
+	}
+
+	if len(options.WatchAddresses) > 0 {
+		opts = append(opts, wallet.OptionWatchAddresses(options.WatchAddresses))
+	}
+
+	return opts
+}
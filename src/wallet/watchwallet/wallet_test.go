@@ -0,0 +1,330 @@
+package watchwallet
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/bip32"
+	"github.com/skycoin/skycoin/src/wallet"
+)
+
+var (
+	testXPub = "xpub6EMRsT95ntbCFRR2Z6WppnGss1SijAkarfKoRM8tft66tuJh2nt4aJi13S21hUCLZL4cbFBXgHuxipmsS7dj1DW1s4NRup3hzxWfqUdGYv7"
+
+	// The first addresses of testXPub
+	testXPubAddresses = stringsToAddresses([]string{
+		"2JBfeo6y6FQn2rCiuhdQ8F1E6bj6rpnHo5U",
+		"28Wn9scn3wb5nkScHiTHgNmLjSUS3F2SqAj",
+		"qHVbkuuzzxGE6p6CnLY1JxY9ifK1RxjoNS",
+		"2WNKEdCvoR8Mv5a7J5bLeE9syq7vHSzACmk",
+		"2Z1ZcRWwsyiRqTYLm6VJF914FAE8uhfgmkX",
+	})
+
+	testAddresses = []cipher.Addresser{
+		cipher.MustDecodeBase58Address("2GBifzJEehbDX7Mkk63Prfa4MQQQyRzBLfe"),
+		cipher.MustDecodeBase58Address("6TLy48a1LnvyNEG1u2LuDAffFsL9j21jZa"),
+	}
+)
+
+func stringsToAddresses(addrsStr []string) []cipher.Addresser {
+	var addrs []cipher.Addresser
+	for _, addr := range addrsStr {
+		addrs = append(addrs, cipher.MustDecodeBase58Address(addr))
+	}
+
+	return addrs
+}
+
+// newTestXPub returns an xpub key different from testXPub and its first n addresses
+func newTestXPub(t *testing.T, n int) (string, []cipher.Addresser) {
+	k, err := bip32.NewMasterKey([]byte("watch-only wallet test seed 0123"))
+	require.NoError(t, err)
+	pk := k.PublicKey()
+
+	addrs := make([]cipher.Addresser, n)
+	for i := range addrs {
+		ck, err := pk.NewPublicChildKey(uint32(i))
+		require.NoError(t, err)
+		addrs[i] = cipher.AddressFromPubKey(cipher.MustNewPubKey(ck.Key))
+	}
+
+	return pk.String(), addrs
+}
+
+type mockTxnsFinder map[cipher.Addresser]bool
+
+func (mb mockTxnsFinder) AddressesActivity(addrs []cipher.Addresser) ([]bool, error) {
+	if len(addrs) == 0 {
+		return nil, nil
+	}
+	active := make([]bool, len(addrs))
+	for i, addr := range addrs {
+		active[i] = mb[addr]
+	}
+	return active, nil
+}
+
+func TestNewWallet(t *testing.T) {
+	xpub2, xpub2Addrs := newTestXPub(t, 2)
+	_, invalidXPubErr := bip32.DeserializeEncodedPublicKey("xpub")
+
+	tt := []struct {
+		name        string
+		opts        []wallet.Option
+		expectAddrs []cipher.Addresser
+		expectXPubs []string
+		err         error
+	}{
+		{
+			name: "addresses",
+			opts: []wallet.Option{
+				wallet.OptionWatchAddresses(testAddresses),
+			},
+			expectAddrs: testAddresses,
+			expectXPubs: []string{},
+		},
+		{
+			name: "xpubs",
+			opts: []wallet.Option{
+				wallet.OptionWatchXPubs([]string{testXPub, xpub2}),
+				wallet.OptionGenerateN(2),
+			},
+			expectAddrs: append(testXPubAddresses[:2:2], xpub2Addrs...),
+			expectXPubs: []string{testXPub, xpub2},
+		},
+		{
+			name: "addresses and xpub",
+			opts: []wallet.Option{
+				wallet.OptionWatchAddresses(testAddresses),
+				wallet.OptionWatchXPubs([]string{testXPub}),
+			},
+			expectAddrs: append(testAddresses[:2:2], testXPubAddresses[0]),
+			expectXPubs: []string{testXPub},
+		},
+		{
+			name: "scan xpubs",
+			opts: []wallet.Option{
+				wallet.OptionWatchXPubs([]string{testXPub, xpub2}),
+				wallet.OptionScanN(5),
+				wallet.OptionTransactionsFinder(mockTxnsFinder{testXPubAddresses[2]: true}),
+			},
+			expectAddrs: append(testXPubAddresses[:3:3], xpub2Addrs[0]),
+			expectXPubs: []string{testXPub, xpub2},
+		},
+		{
+			name: "missing addresses",
+			err:  wallet.ErrMissingWatchAddresses,
+		},
+		{
+			name: "duplicate address",
+			opts: []wallet.Option{
+				wallet.OptionWatchAddresses([]cipher.Addresser{testAddresses[0], testAddresses[0]}),
+			},
+			err: wallet.ErrWatchAddressExists,
+		},
+		{
+			name: "address of xpub",
+			opts: []wallet.Option{
+				wallet.OptionWatchAddresses(testXPubAddresses[:1]),
+				wallet.OptionWatchXPubs([]string{testXPub}),
+			},
+			err: wallet.ErrWatchAddressExists,
+		},
+		{
+			name: "duplicate xpub",
+			opts: []wallet.Option{
+				wallet.OptionWatchXPubs([]string{testXPub, testXPub}),
+			},
+			err: wallet.ErrWatchXPubExists,
+		},
+		{
+			name: "invalid xpub",
+			opts: []wallet.Option{
+				wallet.OptionWatchXPubs([]string{"xpub"}),
+			},
+			err: wallet.NewError(fmt.Errorf("invalid xpub key: %v", invalidXPubErr)),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			w, err := NewWallet("test.wlt", "test", tc.opts...)
+			require.Equal(t, tc.err, err)
+			if err != nil {
+				return
+			}
+
+			require.Equal(t, wallet.WalletTypeWatchOnly, w.Type())
+			require.Equal(t, "", w.Fingerprint())
+			require.Equal(t, tc.expectXPubs, w.XPubs())
+
+			addrs, err := w.GetAddresses()
+			require.NoError(t, err)
+			require.Equal(t, tc.expectAddrs, addrs)
+		})
+	}
+}
+
+func TestWalletEntriesOptions(t *testing.T) {
+	xpub2, xpub2Addrs := newTestXPub(t, 3)
+
+	w, err := NewWallet("test.wlt", "test",
+		wallet.OptionWatchAddresses(testAddresses),
+		wallet.OptionWatchXPubs([]string{testXPub, xpub2}))
+	require.NoError(t, err)
+
+	// Addresses are generated on the first xpub key by default
+	addrs, err := w.GenerateAddresses(wallet.OptionGenerateN(2))
+	require.NoError(t, err)
+	require.Equal(t, testXPubAddresses[1:3], addrs)
+
+	addrs, err = w.GenerateAddresses(wallet.OptionGenerateN(2), wallet.OptionWatchXPub(1))
+	require.NoError(t, err)
+	require.Equal(t, xpub2Addrs[1:3], addrs)
+
+	_, err = w.GenerateAddresses(wallet.OptionGenerateN(2), wallet.OptionWatchXPub(2))
+	require.Equal(t, wallet.ErrWatchXPubNotExist, err)
+
+	n, err := w.EntriesLen()
+	require.NoError(t, err)
+	require.Equal(t, 8, n)
+
+	n, err = w.EntriesLen(wallet.OptionWatchXPub(0))
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	addrs, err = w.GetAddresses(wallet.OptionWatchXPub(1))
+	require.NoError(t, err)
+	require.Equal(t, xpub2Addrs, addrs)
+
+	e, err := w.GetEntryAt(1, wallet.OptionWatchXPub(1))
+	require.NoError(t, err)
+	require.Equal(t, xpub2Addrs[1], e.Address)
+	require.Equal(t, uint32(1), e.ChildNumber)
+	require.False(t, e.Public.Null())
+
+	// The watched addresses have no public key
+	e, err = w.GetEntry(testAddresses[1])
+	require.NoError(t, err)
+	require.True(t, e.Public.Null())
+
+	ok, err := w.HasEntry(testAddresses[1], wallet.OptionWatchXPub(0))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, err = w.GetEntries(wallet.OptionWatchXPub(3))
+	require.Equal(t, wallet.ErrWatchXPubNotExist, err)
+
+	// Wallets without xpub keys can't generate addresses
+	w, err = NewWallet("test.wlt", "test", wallet.OptionWatchAddresses(testAddresses))
+	require.NoError(t, err)
+	_, err = w.GenerateAddresses(wallet.OptionGenerateN(1))
+	require.Equal(t, wallet.ErrWatchNoXPub, err)
+}
+
+func TestWalletAddAddresses(t *testing.T) {
+	xpub2, xpub2Addrs := newTestXPub(t, 2)
+
+	w, err := NewWallet("test.wlt", "test", wallet.OptionWatchAddresses(testAddresses[:1]))
+	require.NoError(t, err)
+
+	w2 := w.Clone().(*Wallet)
+
+	require.Equal(t, wallet.ErrWatchAddressExists, w.AddAddresses(testAddresses))
+	require.NoError(t, w.AddAddresses(testAddresses[1:]))
+
+	addrs, err := w.AddXPub(xpub2, 2)
+	require.NoError(t, err)
+	require.Equal(t, xpub2Addrs, addrs)
+
+	_, err = w.AddXPub(xpub2, 1)
+	require.Equal(t, wallet.ErrWatchXPubExists, err)
+
+	require.Equal(t, wallet.ErrWatchAddressExists, w.AddAddresses(xpub2Addrs[1:]))
+
+	addrs, err = w.GetAddresses()
+	require.NoError(t, err)
+	require.Equal(t, append(testAddresses[:2:2], xpub2Addrs...), addrs)
+	require.Equal(t, []string{xpub2}, w.XPubs())
+
+	// The clone is not modified
+	addrs, err = w2.GetAddresses()
+	require.NoError(t, err)
+	require.Equal(t, testAddresses[:1], addrs)
+	require.Empty(t, w2.XPubs())
+}
+
+func TestWalletSerialize(t *testing.T) {
+	xpub2, _ := newTestXPub(t, 0)
+
+	w, err := NewWallet("test.wlt", "test",
+		wallet.OptionWatchAddresses(testAddresses),
+		wallet.OptionWatchXPubs([]string{testXPub, xpub2}),
+		wallet.OptionGenerateN(3))
+	require.NoError(t, err)
+
+	b, err := w.Serialize()
+	require.NoError(t, err)
+
+	w2 := &Wallet{}
+	require.NoError(t, w2.Deserialize(b))
+	require.Equal(t, w.Meta, w2.Meta)
+	require.Equal(t, w.XPubs(), w2.XPubs())
+
+	entries, err := w.GetEntries()
+	require.NoError(t, err)
+	entries2, err := w2.GetEntries()
+	require.NoError(t, err)
+	require.Equal(t, entries, entries2)
+	require.Len(t, entries2, 8)
+
+	// The loaded wallet keeps generating from the end of each xpub chain
+	addrs, err := w.GenerateAddresses(wallet.OptionGenerateN(1), wallet.OptionWatchXPub(1))
+	require.NoError(t, err)
+	addrs2, err := w2.GenerateAddresses(wallet.OptionGenerateN(1), wallet.OptionWatchXPub(1))
+	require.NoError(t, err)
+	require.Equal(t, addrs, addrs2)
+}
+
+func TestWalletCantSign(t *testing.T) {
+	w, err := NewWallet("test.wlt", "test", wallet.OptionWatchAddresses(testAddresses))
+	require.NoError(t, err)
+
+	require.False(t, wallet.CanSign(w))
+	require.Error(t, w.Lock([]byte("pwd")))
+
+	_, err = wallet.SignTransaction(w, nil, nil, nil)
+	require.Equal(t, wallet.ErrWalletCantSign, err)
+}
+
+func TestScanAddresses(t *testing.T) {
+	xpub2, xpub2Addrs := newTestXPub(t, 5)
+
+	w, err := NewWallet("test.wlt", "test",
+		wallet.OptionWatchAddresses(testAddresses),
+		wallet.OptionWatchXPubs([]string{testXPub, xpub2}))
+	require.NoError(t, err)
+
+	addrs, err := w.ScanAddresses(10, mockTxnsFinder{
+		testAddresses[0]:     true,
+		testXPubAddresses[2]: true,
+		xpub2Addrs[4]:        true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, append(testXPubAddresses[1:3:3], xpub2Addrs[1:]...), addrs)
+
+	n, err := w.EntriesLen(wallet.OptionWatchXPub(0))
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	n, err = w.EntriesLen(wallet.OptionWatchXPub(1))
+	require.NoError(t, err)
+	require.Equal(t, 5, n)
+
+	addrs, err = w.ScanAddresses(10, mockTxnsFinder{})
+	require.NoError(t, err)
+	require.Empty(t, addrs)
+}